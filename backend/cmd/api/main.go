@@ -11,12 +11,22 @@ import (
 
 	"qris-pos-backend/internal/infrastructure/config"
 	"qris-pos-backend/internal/infrastructure/database"
+	"qris-pos-backend/internal/infrastructure/scheduler"
+	"qris-pos-backend/internal/infrastructure/tracing"
 	"qris-pos-backend/internal/interfaces/http/server"
 	"qris-pos-backend/pkg/logger"
 
 	"github.com/joho/godotenv"
 )
 
+// @title QRIS POS API
+// @version 1.0
+// @description Point of sale backend with dynamic QRIS payments, inventory, promotions and reporting.
+// @BasePath /api/v1
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description Access token as "Bearer <token>"
 func main() {
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
@@ -32,6 +42,12 @@ func main() {
 	// Initialize logger
 	appLogger := logger.NewLogger(cfg.App.LogLevel)
 
+	// Initialize tracing, spans are only exported when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, cfg.App.Version)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize tracing", "error", err)
+	}
+
 	// Initialize database
 	db, err := database.NewConnection(cfg.Database)
 	if err != nil {
@@ -49,8 +65,14 @@ func main() {
 		appLogger.Fatal("Failed to seed data", "error", err)
 	}
 
+	// Initialize background job scheduler
+	jobScheduler := scheduler.NewScheduler(appLogger)
+
 	// Initialize HTTP server
-	httpServer := server.NewServer(cfg, db, appLogger)
+	httpServer := server.NewServer(cfg, db, appLogger, jobScheduler)
+
+	// Start background jobs (payment/transaction expiry, etc.)
+	jobScheduler.Start(context.Background())
 
 	// Start server in a goroutine
 	go func() {
@@ -67,13 +89,27 @@ func main() {
 
 	appLogger.Info("Shutting down server...")
 
-	// Give outstanding requests 30 seconds to complete
+	// Requests, background jobs and trace export share 30 seconds to drain
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Stop background jobs alongside the server, in-flight runs such as an expiry batch are let finish
+	jobsStopped := make(chan error, 1)
+	go func() {
+		jobsStopped <- jobScheduler.Stop(ctx)
+	}()
+
 	if err := httpServer.Shutdown(ctx); err != nil {
-		appLogger.Fatal("Server forced to shutdown", "error", err)
+		appLogger.Error("Server forced to shutdown", "error", err)
+	}
+	if err := <-jobsStopped; err != nil {
+		appLogger.Error("Background jobs forced to stop", "error", err)
+	}
+
+	// Flush the spans still buffered for export
+	if err := shutdownTracing(ctx); err != nil {
+		appLogger.Error("Failed to flush traces", "error", err)
 	}
 
 	appLogger.Info("Server exited")
-}
\ No newline at end of file
+}
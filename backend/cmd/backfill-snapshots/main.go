@@ -0,0 +1,51 @@
+// Command backfill-snapshots fills in the cost price and tax class of transaction items
+// sold before they were snapshotted at sale time, so margin reports cover old sales too.
+//
+// Usage:
+//
+//	go run ./cmd/backfill-snapshots [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/database"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count the items that would be backfilled")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found or failed to load")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	appLogger := logger.NewLogger(cfg.App.LogLevel)
+
+	db, err := database.NewConnection(cfg.Database)
+	if err != nil {
+		appLogger.Fatal("Failed to connect to database", "error", err)
+	}
+	defer database.Close(db)
+
+	result, err := database.BackfillItemSnapshots(context.Background(), db, *dryRun)
+	if err != nil {
+		appLogger.Fatal("Failed to backfill item snapshots", "error", err)
+	}
+
+	appLogger.Info("Backfilled transaction item snapshots",
+		"dry_run", *dryRun,
+		"unit_costs", result.UnitCosts,
+		"tax_classes", result.TaxClasses)
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccountingPosting records the daily sales journal of a store posted to an accounting system, so
+// the same day is never posted twice
+type AccountingPosting struct {
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	StoreID     string    `json:"store_id" gorm:"type:uuid;not null;uniqueIndex:idx_accounting_postings_day,priority:1"`
+	Connector   string    `json:"connector" gorm:"type:varchar(20);not null;uniqueIndex:idx_accounting_postings_day,priority:2"`
+	Date        time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_accounting_postings_day,priority:3"`
+	Reference   string    `json:"reference" gorm:"type:varchar(50);not null"`    // Journal reference sent, e.g. POS-JKT01-20260115
+	ExternalID  string    `json:"external_id" gorm:"type:varchar(100);not null"` // ID or number of the journal in the accounting system
+	TotalAmount int64     `json:"total_amount" gorm:"type:bigint;not null"`      // Sum of the debits
+	PostedBy    string    `json:"posted_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (AccountingPosting) TableName() string {
+	return "accounting_postings"
+}
+
+func (p *AccountingPosting) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
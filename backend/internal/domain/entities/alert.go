@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AlertType string
+
+const (
+	AlertVoidSpike        AlertType = "void_spike"
+	AlertAfterHoursRefund AlertType = "after_hours_refund"
+	AlertDiscountOutlier  AlertType = "discount_outlier"
+	// AlertStockUnavailable is a sale paid through the gateway after its stock ran out
+	AlertStockUnavailable AlertType = "stock_unavailable"
+)
+
+type AlertSeverity string
+
+const (
+	AlertWarning  AlertSeverity = "warning"
+	AlertCritical AlertSeverity = "critical"
+)
+
+// Alert is an unusual sales pattern flagged for the owner to review
+type Alert struct {
+	ID             string        `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Type           AlertType     `json:"type" gorm:"type:varchar(50);not null;index"`
+	Severity       AlertSeverity `json:"severity" gorm:"type:varchar(20);not null"`
+	Message        string        `json:"message" gorm:"not null"`
+	TransactionID  *string       `json:"transaction_id,omitempty" gorm:"type:uuid"`
+	UserID         *string       `json:"user_id,omitempty" gorm:"type:uuid"` // Staff member behind the flagged activity
+	Value          float64       `json:"value" gorm:"type:decimal(12,4);not null;default:0"`
+	Threshold      float64       `json:"threshold" gorm:"type:decimal(12,4);not null;default:0"`
+	Fingerprint    string        `json:"-" gorm:"type:varchar(255);not null;uniqueIndex"` // Keeps overlapping detection runs from raising the same alert twice
+	AcknowledgedAt *time.Time    `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *string       `json:"acknowledged_by,omitempty" gorm:"type:uuid"`
+	CreatedAt      time.Time     `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (Alert) TableName() string {
+	return "alerts"
+}
+
+func (a *Alert) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return
+}
+
+func (a *Alert) Acknowledge(userID string) error {
+	if a.AcknowledgedAt != nil {
+		return errors.New("alert is already acknowledged")
+	}
+
+	now := time.Now()
+	a.AcknowledgedAt = &now
+	a.AcknowledgedBy = &userID
+	return nil
+}
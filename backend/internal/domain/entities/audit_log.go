@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditAction is a sensitive operation recorded in the audit log
+type AuditAction string
+
+const (
+	AuditProductUpdate       AuditAction = "product.update" // Includes price changes
+	AuditProductBulkPrice    AuditAction = "product.bulk_price"
+	AuditProductStock        AuditAction = "product.stock"
+	AuditUserRegister        AuditAction = "user.register" // The only place a role is assigned
+	AuditUserRestore         AuditAction = "user.restore"
+	AuditTransactionVoid     AuditAction = "transaction.void"
+	AuditTransactionExchange AuditAction = "transaction.exchange"
+	AuditTransactionDiscount AuditAction = "transaction.discount" // Manual discounts given or taken off
+	AuditPaymentRefund       AuditAction = "payment.refund"
+	AuditMerchantUpdate      AuditAction = "merchant.update" // Includes changes of the Midtrans account
+	AuditStoreCredential     AuditAction = "store.gateway_credential"
+	AuditTenantUpdate        AuditAction = "tenant.update" // Includes onboarding and suspending tenants
+)
+
+// AuditLog records who performed a sensitive operation, on what, and with which request. Entries are
+// only ever inserted.
+type AuditLog struct {
+	ID         string      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID   *string     `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	UserID     string      `json:"user_id" gorm:"type:uuid;not null;index"`
+	UserEmail  string      `json:"user_email" gorm:"type:varchar(255)"` // As signed in, kept even if the user is renamed
+	UserRole   UserRole    `json:"user_role" gorm:"type:varchar(20)"`
+	StoreID    *string     `json:"store_id,omitempty" gorm:"type:uuid;index"` // Store the request worked in, empty for admins across stores
+	Action     AuditAction `json:"action" gorm:"type:varchar(50);not null;index"`
+	ResourceID string      `json:"resource_id,omitempty" gorm:"type:varchar(100);index"` // Product, transaction or payment the route acted on
+	Method     string      `json:"method" gorm:"type:varchar(10);not null"`
+	Path       string      `json:"path" gorm:"not null"`
+	StatusCode int         `json:"status_code"`
+	IPAddress  string      `json:"ip_address" gorm:"type:varchar(45)"`
+	Payload    string      `json:"payload,omitempty"` // JSON request body with secrets masked
+	CreatedAt  time.Time   `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CashMovementType string
+
+const (
+	CashDrawerOpen CashMovementType = "drawer_open" // Drawer opened without a sale, e.g. to give change; moves no cash
+	CashPayIn      CashMovementType = "pay_in"      // Cash added to the drawer, e.g. more change from the safe
+	CashPayOut     CashMovementType = "pay_out"     // Cash paid from the drawer, e.g. a delivery or petty expense
+	CashDrop       CashMovementType = "drop"        // Cash taken to the safe to keep the drawer light
+)
+
+// CashMovement is cash put into or taken out of the drawer outside a sale during a shift. Every
+// movement needs a reason, and pay-ins, pay-outs and drops change the cash the Z-report expects.
+type CashMovement struct {
+	ID        string           `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ShiftID   string           `json:"shift_id" gorm:"type:uuid;not null;index"`
+	UserID    string           `json:"user_id" gorm:"type:uuid;not null"`
+	Type      CashMovementType `json:"type" gorm:"type:varchar(20);not null;check:type IN ('drawer_open', 'pay_in', 'pay_out', 'drop')"`
+	Amount    int64            `json:"amount" gorm:"type:bigint;not null;default:0;check:amount >= 0"`
+	Reason    string           `json:"reason" gorm:"type:varchar(255);not null"`
+	CreatedAt time.Time        `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (CashMovement) TableName() string {
+	return "cash_movements"
+}
+
+func (m *CashMovement) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return
+}
+
+// NewCashMovement records a movement of the given shift. Opening the drawer moves no cash, every other
+// movement needs an amount.
+func NewCashMovement(shift *Shift, userID string, movementType CashMovementType, amount int64, reason string) (*CashMovement, error) {
+	if shift.Status != ShiftOpen {
+		return nil, errors.New("cash can only be moved during an open shift")
+	}
+	if reason == "" {
+		return nil, errors.New("a reason is required")
+	}
+
+	switch movementType {
+	case CashDrawerOpen:
+		if amount != 0 {
+			return nil, errors.New("opening the drawer moves no cash, record a pay-in or pay-out instead")
+		}
+	case CashPayIn:
+		if amount <= 0 {
+			return nil, errors.New("amount must be greater than zero")
+		}
+	case CashPayOut, CashDrop:
+		if amount <= 0 {
+			return nil, errors.New("amount must be greater than zero")
+		}
+		if amount > shift.ExpectedCash {
+			return nil, errors.New("amount is more than the cash the drawer should hold")
+		}
+	default:
+		return nil, errors.New("unknown cash movement type")
+	}
+
+	return &CashMovement{
+		ShiftID: shift.ID,
+		UserID:  userID,
+		Type:    movementType,
+		Amount:  amount,
+		Reason:  reason,
+	}, nil
+}
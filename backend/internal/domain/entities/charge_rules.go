@@ -0,0 +1,32 @@
+package entities
+
+import "errors"
+
+// ChargeRules are the service charge and cash rounding of a store. A sale copies the rules of its store
+// when it is created, so changing them doesn't reprice open carts.
+type ChargeRules struct {
+	ServiceChargeRate float64      `json:"service_charge_rate" gorm:"type:decimal(5,2);not null;default:0"` // Percentage of the discounted subtotal, 0 for none
+	RoundingIncrement int64        `json:"rounding_increment" gorm:"type:bigint;not null;default:0"`        // Totals are rounded to a multiple of it, e.g. 100; 0 for none
+	RoundingMode      RoundingMode `json:"rounding_mode,omitempty" gorm:"type:varchar(10)"`
+}
+
+func (r *ChargeRules) Validate() error {
+	if r.ServiceChargeRate < 0 || r.ServiceChargeRate > 100 {
+		return errors.New("service charge rate must be between 0 and 100")
+	}
+	if r.RoundingIncrement < 0 {
+		return errors.New("rounding increment cannot be negative")
+	}
+	if r.RoundingIncrement == 0 {
+		r.RoundingMode = ""
+		return nil
+	}
+	switch r.RoundingMode {
+	case "":
+		r.RoundingMode = RoundNearest
+	case RoundNearest, RoundUp, RoundDown:
+	default:
+		return errors.New("rounding mode must be nearest, up or down")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Customer is a member of the loyalty program. Members are looked up at checkout by phone or email,
+// earn points on what they pay and can spend the points as a discount.
+type Customer struct {
+	ID        string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID  *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	Name      string         `json:"name" gorm:"type:varchar(255);not null"`
+	Phone     *string        `json:"phone,omitempty" gorm:"type:varchar(20)"`  // E.164, unique among customers that aren't deleted
+	Email     *string        `json:"email,omitempty" gorm:"type:varchar(255)"` // Lowercase, unique among customers that aren't deleted
+	Points    int64          `json:"points" gorm:"type:bigint;not null;default:0;check:points >= 0"`
+	Notes     string         `json:"notes"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Customer) TableName() string {
+	return "customers"
+}
+
+func (c *Customer) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate normalizes the contact details and checks the customer can be looked up
+func (c *Customer) Validate() error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return errors.New("customer name is required")
+	}
+
+	c.Phone = normalizeContact(c.Phone, NormalizeCustomerPhone)
+	c.Email = normalizeContact(c.Email, NormalizeCustomerEmail)
+	if c.Phone == nil && c.Email == nil {
+		return errors.New("a phone number or email is required to look the customer up")
+	}
+	return nil
+}
+
+// NormalizeCustomerPhone drops the spaces and dashes people type in phone numbers and writes Indonesian
+// numbers in E.164, so 0812-3456-7890 and 62812 3456 7890 are both +6281234567890
+func NormalizeCustomerPhone(phone string) string {
+	phone = strings.NewReplacer(" ", "", "-", "", ".", "").Replace(strings.TrimSpace(phone))
+	switch {
+	case strings.HasPrefix(phone, "08"):
+		return "+62" + phone[1:]
+	case strings.HasPrefix(phone, "628"):
+		return "+" + phone
+	}
+	return phone
+}
+
+// NormalizeCustomerEmail makes email lookups case insensitive
+func NormalizeCustomerEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func normalizeContact(value *string, normalize func(string) string) *string {
+	if value == nil {
+		return nil
+	}
+	normalized := normalize(*value)
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
+
+type PointsEntryType string
+
+const (
+	PointsEarned   PointsEntryType = "earn"   // Points for a paid transaction
+	PointsRedeemed PointsEntryType = "redeem" // Points spent as a discount on a paid transaction
+	PointsAdjusted PointsEntryType = "adjust" // Manual correction by an admin
+)
+
+// PointsEntry is a change to a customer's points balance. The entries of a customer add up to their balance.
+type PointsEntry struct {
+	ID            string          `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CustomerID    string          `json:"customer_id" gorm:"type:uuid;not null;index"`
+	TransactionID *string         `json:"transaction_id,omitempty" gorm:"type:uuid;uniqueIndex:idx_points_entries_transaction_type"` // A transaction earns and redeems once
+	Type          PointsEntryType `json:"type" gorm:"type:varchar(20);not null;uniqueIndex:idx_points_entries_transaction_type;check:type IN ('earn', 'redeem', 'adjust')"`
+	Points        int64           `json:"points" gorm:"type:bigint;not null"` // Negative when points are spent or taken away
+	Balance       int64           `json:"balance" gorm:"type:bigint;not null"`
+	Note          string          `json:"note,omitempty"`
+	CreatedBy     *string         `json:"created_by,omitempty" gorm:"type:uuid"` // Admin who made an adjustment
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PointsEntry) TableName() string {
+	return "points_entries"
+}
+
+func (e *PointsEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+// PointsEarnedFor returns the points a paid amount earns, one point per earnAmount rupiah. Earning is off
+// when earnAmount is not positive.
+func PointsEarnedFor(paid, earnAmount int64) int64 {
+	if earnAmount <= 0 || paid <= 0 {
+		return 0
+	}
+	return paid / earnAmount
+}
@@ -0,0 +1,63 @@
+package entities
+
+import "errors"
+
+// DiscountType is how a manual discount is expressed
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountNominal    DiscountType = "nominal" // Fixed amount off, per unit on items
+)
+
+// ManualDiscount is a discount given by hand at the register, on an item or on the whole transaction.
+// Its amount is worked out again whenever the cart changes, so a percentage follows the quantities.
+type ManualDiscount struct {
+	Type       DiscountType `json:"type,omitempty" gorm:"type:varchar(20)"`
+	Value      float64      `json:"value,omitempty" gorm:"type:decimal(12,2);not null;default:0"` // Percentage, or amount off in the minor unit
+	Amount     int64        `json:"amount" gorm:"type:bigint;not null;default:0"`                 // Discount the value currently comes to
+	Reason     string       `json:"reason,omitempty" gorm:"type:varchar(255)"`
+	GivenBy    *string      `json:"given_by,omitempty" gorm:"type:uuid"`
+	ApprovedBy *string      `json:"approved_by,omitempty" gorm:"type:uuid"` // Manager who signed off a discount above the cashier's limit
+}
+
+func (d ManualDiscount) Validate() error {
+	switch d.Type {
+	case DiscountPercentage:
+		if d.Value <= 0 || d.Value > 100 {
+			return errors.New("discount percentage must be above 0 and at most 100")
+		}
+	case DiscountNominal:
+		if d.Value <= 0 {
+			return errors.New("discount amount must be above 0")
+		}
+	default:
+		return errors.New("discount type must be percentage or nominal")
+	}
+	return nil
+}
+
+// IsSet reports whether a discount was given
+func (d ManualDiscount) IsSet() bool {
+	return d.Type != ""
+}
+
+// AmountOff returns the discount on base, the price of quantity units, never more than base
+func (d ManualDiscount) AmountOff(base int64, quantity float64) int64 {
+	var amount int64
+	switch d.Type {
+	case DiscountPercentage:
+		amount = PercentOf(base, d.Value)
+	case DiscountNominal:
+		amount = RoundAmount(d.Value * quantity)
+	}
+	return min(max(amount, 0), max(base, 0))
+}
+
+// DiscountPercent returns the share of base a discount amount makes up, the measure role limits apply to
+func DiscountPercent(amount, base int64) float64 {
+	if base <= 0 {
+		return 0
+	}
+	return float64(amount) * 100 / float64(base)
+}
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExportStatus string
+
+const (
+	ExportPending    ExportStatus = "pending"
+	ExportProcessing ExportStatus = "processing"
+	ExportReady      ExportStatus = "ready"
+	ExportFailed     ExportStatus = "failed"
+)
+
+// Export is a merchant data export built in the background. The archive holds every product and
+// customer, and the transactions made in the date range.
+type Export struct {
+	ID          string       `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID    *string      `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	StoreID     *string      `json:"store_id,omitempty" gorm:"type:uuid;index"` // Every store when empty
+	RequestedBy string       `json:"requested_by" gorm:"type:uuid;not null"`
+	NotifyEmail string       `json:"notify_email" gorm:"type:varchar(255);not null"` // Sent the download link when the export is ready
+	DateFrom    time.Time    `json:"date_from" gorm:"not null"`                      // Inclusive
+	DateTo      time.Time    `json:"date_to" gorm:"not null"`                        // Exclusive
+	Status      ExportStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index;check:status IN ('pending', 'processing', 'ready', 'failed')"`
+	ObjectPath  string       `json:"-"` // Path of the archive in the export bucket
+	SizeBytes   int64        `json:"size_bytes" gorm:"type:bigint;default:0"`
+	Error       string       `json:"error,omitempty"`
+	StartedAt   *time.Time   `json:"started_at,omitempty"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Export) TableName() string {
+	return "exports"
+}
+
+func (e *Export) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewExport(storeID *string, requestedBy, notifyEmail string, dateFrom, dateTo time.Time) *Export {
+	return &Export{
+		StoreID:     storeID,
+		RequestedBy: requestedBy,
+		NotifyEmail: notifyEmail,
+		DateFrom:    dateFrom,
+		DateTo:      dateTo,
+		Status:      ExportPending,
+	}
+}
+
+func (e *Export) MarkAsReady(objectPath string, sizeBytes int64) {
+	now := time.Now()
+	e.Status = ExportReady
+	e.ObjectPath = objectPath
+	e.SizeBytes = sizeBytes
+	e.Error = ""
+	e.CompletedAt = &now
+}
+
+func (e *Export) MarkAsFailed(err error) {
+	now := time.Now()
+	e.Status = ExportFailed
+	e.Error = err.Error()
+	e.CompletedAt = &now
+}
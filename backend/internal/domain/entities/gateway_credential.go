@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GatewayCredential is the account a store takes payments with on a gateway, in place of the merchant's
+// or the configured one. It lets every store of a SaaS deployment settle into its own account. The
+// server key is encrypted at rest and never leaves the server.
+type GatewayCredential struct {
+	ID          string          `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	StoreID     string          `json:"store_id" gorm:"type:uuid;not null;uniqueIndex:idx_gateway_credentials_store_provider"`
+	Provider    PaymentProvider `json:"provider" gorm:"type:varchar(20);not null;uniqueIndex:idx_gateway_credentials_store_provider"`
+	ServerKey   string          `json:"-" gorm:"type:text;not null"` // Encrypted
+	ClientKey   string          `json:"client_key" gorm:"type:varchar(255)"`
+	Environment string          `json:"environment" gorm:"type:varchar(20);not null;default:'sandbox'"` // sandbox or production
+	UpdatedBy   *string         `json:"updated_by,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (GatewayCredential) TableName() string {
+	return "store_gateway_credentials"
+}
+
+func (g *GatewayCredential) BeforeCreate(tx *gorm.DB) (err error) {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate checks the credential before its server key is encrypted
+func (g *GatewayCredential) Validate() error {
+	if g.Provider != ProviderMidtrans {
+		return errors.New("only Midtrans accounts can be set per store")
+	}
+	g.ServerKey = strings.TrimSpace(g.ServerKey)
+	if g.ServerKey == "" {
+		return errors.New("server key is required")
+	}
+	g.ClientKey = strings.TrimSpace(g.ClientKey)
+	switch g.Environment {
+	case "":
+		g.Environment = "sandbox"
+	case "sandbox", "production":
+	default:
+		return errors.New("midtrans environment must be sandbox or production")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Merchant is the business profile of the merchant running the POS, set up once when onboarding. Its
+// name, contacts and NPWP head receipts and reports, and its Midtrans account takes payments in place of
+// the configured one. Outlets can override the name, contacts and NPWP on their own receipts.
+type Merchant struct {
+	ID         string `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Name       string `json:"name" gorm:"type:varchar(255);not null"` // Trading name
+	LegalName  string `json:"legal_name" gorm:"type:varchar(255)"`    // Registered name of the business, e.g. "PT Kopi Nusantara"
+	Address    string `json:"address"`
+	City       string `json:"city" gorm:"type:varchar(100)"`
+	PostalCode string `json:"postal_code" gorm:"type:varchar(10)"`
+	Phone      string `json:"phone" gorm:"type:varchar(20)"`
+	Email      string `json:"email" gorm:"type:varchar(255)"`
+	TaxID      string `json:"tax_id" gorm:"type:varchar(25)"` // NPWP
+	LogoURL    string `json:"logo_url"`
+
+	// Midtrans account of the merchant, the configured MIDTRANS_* one is used while the server key is empty
+	MidtransServerKey   string `json:"-" gorm:"type:text"` // Encrypted
+	MidtransClientKey   string `json:"midtrans_client_key" gorm:"type:varchar(255)"`
+	MidtransEnvironment string `json:"midtrans_environment" gorm:"type:varchar(20);not null;default:'sandbox'"` // sandbox or production
+
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Merchant) TableName() string {
+	return "merchants"
+}
+
+func (m *Merchant) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate trims the profile and checks it is complete
+func (m *Merchant) Validate() error {
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		return errors.New("merchant name is required")
+	}
+	m.LegalName = strings.TrimSpace(m.LegalName)
+	m.TaxID = strings.TrimSpace(m.TaxID)
+	m.MidtransClientKey = strings.TrimSpace(m.MidtransClientKey)
+	switch m.MidtransEnvironment {
+	case "":
+		m.MidtransEnvironment = "sandbox"
+	case "sandbox", "production":
+	default:
+		return errors.New("midtrans environment must be sandbox or production")
+	}
+	return nil
+}
+
+// HasMidtransAccount tells whether payments go through the merchant's own Midtrans account
+func (m *Merchant) HasMidtransAccount() bool {
+	return m.MidtransServerKey != ""
+}
+
+// FullAddress is the address followed by the city and postal code, as printed on receipts
+func (m *Merchant) FullAddress() string {
+	parts := make([]string, 0, 2)
+	if address := strings.TrimSpace(m.Address); address != "" {
+		parts = append(parts, address)
+	}
+	if city := strings.TrimSpace(m.City + " " + m.PostalCode); city != "" {
+		parts = append(parts, city)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,139 @@
+package entities
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amounts are int64 counts of the minor unit of their currency, so totals stay exact and match what the
+// gateway charges. Rupiah is counted in whole rupiah: it has no minor unit in practice, gateways reject
+// sen and QRIS carries whole amounts. Every rounding of an amount goes through the helpers below, half
+// away from zero, so transactions, payments and reports never disagree by one.
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+const (
+	CurrencyIDR Currency = "IDR"
+	CurrencySGD Currency = "SGD"
+	CurrencyMYR Currency = "MYR"
+	CurrencyUSD Currency = "USD"
+)
+
+// DefaultCurrency is the currency of stores that don't set one
+const DefaultCurrency = CurrencyIDR
+
+// currencyExponents is the number of decimals of the minor unit amounts of a currency are counted in
+var currencyExponents = map[Currency]int{
+	CurrencyIDR: 0,
+	CurrencySGD: 2,
+	CurrencyMYR: 2,
+	CurrencyUSD: 2,
+}
+
+// ParseCurrency normalizes a currency code and checks it is supported. An empty code is the default.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+	if !currency.IsSupported() {
+		return "", fmt.Errorf("unsupported currency %q", code)
+	}
+	return currency, nil
+}
+
+func (c Currency) IsSupported() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+// Exponent is the number of decimals between the major unit and the minor unit amounts are stored in
+func (c Currency) Exponent() int {
+	return currencyExponents[c]
+}
+
+// FromMajor converts an amount in major units, such as a gateway's "15000.00", to minor units
+func (c Currency) FromMajor(value float64) int64 {
+	return RoundAmount(value * math.Pow10(c.Exponent()))
+}
+
+// ParseMajor reads an amount in major units as written by gateways and settlement reports
+func (c Currency) ParseMajor(value string) (int64, error) {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, err
+	}
+	return c.FromMajor(parsed), nil
+}
+
+// ToMajor converts an amount in minor units to major units, for APIs that take decimal amounts
+func (c Currency) ToMajor(amount int64) float64 {
+	return float64(amount) / math.Pow10(c.Exponent())
+}
+
+// FormatMajor writes an amount in major units with the currency's decimals, e.g. "12.50"
+func (c Currency) FormatMajor(amount int64) string {
+	return strconv.FormatFloat(c.ToMajor(amount), 'f', c.Exponent(), 64)
+}
+
+// RoundAmount rounds a computed amount half away from zero to a whole minor unit
+func RoundAmount(value float64) int64 {
+	return int64(math.Round(value))
+}
+
+// PercentOf returns percent of an amount, rounded to a whole minor unit
+func PercentOf(amount int64, percent float64) int64 {
+	return RoundAmount(float64(amount) * percent / 100)
+}
+
+// PercentIncluded returns the part of an amount that a percentage added on top of a base makes up, such
+// as the tax included in a tax-inclusive price, rounded to a whole minor unit
+func PercentIncluded(amount int64, percent float64) int64 {
+	return RoundAmount(float64(amount) * percent / (100 + percent))
+}
+
+// ProRata returns the share of amount that part is of whole, rounded to a whole minor unit
+func ProRata(amount, part, whole int64) int64 {
+	if whole == 0 {
+		return 0
+	}
+	return RoundAmount(float64(amount) * float64(part) / float64(whole))
+}
+
+// RoundingMode is the direction a total is rounded to the cash increment of a store
+type RoundingMode string
+
+const (
+	RoundNearest RoundingMode = "nearest" // Halves round up
+	RoundUp      RoundingMode = "up"
+	RoundDown    RoundingMode = "down"
+)
+
+// RoundTo rounds an amount to a multiple of increment, such as the smallest coin in circulation. An
+// increment of 1 or less leaves the amount as it is.
+func RoundTo(amount, increment int64, mode RoundingMode) int64 {
+	if increment <= 1 {
+		return amount
+	}
+	remainder := amount % increment
+	if remainder < 0 {
+		remainder += increment
+	}
+	if remainder == 0 {
+		return amount
+	}
+	down := amount - remainder
+	switch mode {
+	case RoundUp:
+		return down + increment
+	case RoundDown:
+		return down
+	}
+	if remainder*2 >= increment {
+		return down + increment
+	}
+	return down
+}
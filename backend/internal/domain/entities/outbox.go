@@ -0,0 +1,150 @@
+package entities
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutboxEventType is a domain change published on the message bus
+type OutboxEventType string
+
+const (
+	OutboxTransactionCreated   OutboxEventType = "transaction.created"
+	OutboxPaymentStatusChanged OutboxEventType = "payment.status_changed"
+	OutboxStockChanged         OutboxEventType = "product.stock_changed"
+)
+
+// OutboxEvent is a domain change waiting to be published on the message bus. It is written in the same
+// database transaction as the change, so an event exists exactly when its change was committed, and a
+// relay publishes it afterwards, retrying until the bus takes it.
+type OutboxEvent struct {
+	ID            string          `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID      *string         `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	AggregateType string          `json:"aggregate_type" gorm:"type:varchar(50);not null"` // transaction, payment or product
+	AggregateID   string          `json:"aggregate_id" gorm:"type:uuid;not null"`          // Message key, events of an aggregate stay in order
+	EventType     OutboxEventType `json:"event_type" gorm:"type:varchar(100);not null"`
+	StoreID       *string         `json:"store_id,omitempty" gorm:"type:uuid"`
+	Payload       string          `json:"payload" gorm:"type:jsonb;not null"`
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"not null;index:idx_outbox_events_unpublished,priority:1,where:published_at IS NULL"`
+	PublishedAt   *time.Time      `json:"published_at"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime;index;index:idx_outbox_events_unpublished,priority:2"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+func (e *OutboxEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewOutboxEvent(aggregateType, aggregateID string, eventType OutboxEventType, storeID string, data any) (*OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &OutboxEvent{
+		ID:            uuid.New().String(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(payload),
+		NextAttemptAt: time.Now(),
+	}
+	if storeID != "" {
+		event.StoreID = &storeID
+	}
+	return event, nil
+}
+
+func (e *OutboxEvent) MarkAsPublished() {
+	now := time.Now()
+	e.Attempts++
+	e.LastError = ""
+	e.PublishedAt = &now
+}
+
+// MarkAttemptFailed schedules the next attempt after backoff, doubling it on every attempt up to an hour.
+// An event is never given up on, the bus being down must not lose events.
+func (e *OutboxEvent) MarkAttemptFailed(err error, backoff time.Duration) {
+	e.Attempts++
+	e.LastError = err.Error()
+
+	delay := backoff * time.Duration(1<<min(e.Attempts-1, 16))
+	e.NextAttemptAt = time.Now().Add(min(delay, time.Hour))
+}
+
+// OutboxSource is an aggregate that records events while it changes. The events are written to the
+// outbox when the aggregate is saved, in the same database transaction.
+type OutboxSource interface {
+	// TakeOutboxEvents returns the events recorded since the last save and forgets them. It is called
+	// after the row was written, so generated columns such as the ID are set.
+	TakeOutboxEvents() ([]*OutboxEvent, error)
+}
+
+// outboxRecorder collects the event types an aggregate recorded until its next save
+type outboxRecorder struct {
+	pending []OutboxEventType
+}
+
+func (r *outboxRecorder) recordOutboxEvent(eventType OutboxEventType) {
+	if !slices.Contains(r.pending, eventType) {
+		r.pending = append(r.pending, eventType)
+	}
+}
+
+func (r *outboxRecorder) takeOutboxEvents() []OutboxEventType {
+	pending := r.pending
+	r.pending = nil
+	return pending
+}
+
+// TransactionCreatedData is the payload of OutboxTransactionCreated
+type TransactionCreatedData struct {
+	TransactionID string                   `json:"transaction_id"`
+	StoreID       string                   `json:"store_id"`
+	UserID        string                   `json:"user_id"`
+	CustomerID    *string                  `json:"customer_id,omitempty"`
+	Status        TransactionStatus        `json:"status"`
+	Currency      Currency                 `json:"currency"`
+	TotalAmount   int64                    `json:"total_amount"`
+	Items         []TransactionCreatedItem `json:"items"`
+	CreatedAt     time.Time                `json:"created_at"`
+}
+
+type TransactionCreatedItem struct {
+	ProductID  string  `json:"product_id"`
+	Quantity   float64 `json:"quantity"`
+	UnitPrice  int64   `json:"unit_price"`
+	TotalPrice int64   `json:"total_price"`
+}
+
+// PaymentStatusChangedData is the payload of OutboxPaymentStatusChanged
+type PaymentStatusChangedData struct {
+	PaymentID     string          `json:"payment_id"`
+	TransactionID string          `json:"transaction_id"`
+	StoreID       string          `json:"store_id"`
+	Status        PaymentStatus   `json:"status"`
+	Method        PaymentMethod   `json:"method"`
+	Provider      PaymentProvider `json:"provider,omitempty"`
+	Amount        int64           `json:"amount"`
+	Currency      Currency        `json:"currency"`
+	PaidAt        *time.Time      `json:"paid_at,omitempty"`
+}
+
+// StockChangedData is the payload of OutboxStockChanged
+type StockChangedData struct {
+	ProductID string  `json:"product_id"`
+	StoreID   string  `json:"store_id"`
+	Stock     float64 `json:"stock"`
+}
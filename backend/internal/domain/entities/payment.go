@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"errors"
 	"time"
 	"gorm.io/gorm"
 	"github.com/google/uuid"
@@ -19,27 +20,66 @@ const (
 type PaymentMethod string
 
 const (
-	PaymentMethodQRIS PaymentMethod = "qris"
+	PaymentMethodQRIS  PaymentMethod = "qris"
+	PaymentMethodCash  PaymentMethod = "cash"
+	PaymentMethodCard  PaymentMethod = "card"
+	PaymentMethodOther PaymentMethod = "other"
+	// PaymentMethodEWallet is paid in an e-wallet app opened from a deeplink, for phone-to-phone payments
+	PaymentMethodEWallet PaymentMethod = "ewallet"
+)
+
+// EWallet is the e-wallet app an e-wallet payment is made in
+type EWallet string
+
+const (
+	EWalletGoPay     EWallet = "gopay"
+	EWalletShopeePay EWallet = "shopeepay"
+)
+
+// PaymentProvider is who generated a QRIS payment and reports its settlement
+type PaymentProvider string
+
+const (
+	ProviderMidtrans  PaymentProvider = "midtrans"
+	ProviderXendit    PaymentProvider = "xendit"
+	ProviderLocalQRIS PaymentProvider = "local_qris" // Built in-house from the merchant's own NMID, confirmed by the cashier
+	ProviderTraining  PaymentProvider = "training"   // Simulated for training transactions, confirmed by the cashier
 )
 
 type Payment struct {
-	ID               string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	TransactionID    string         `json:"transaction_id" gorm:"type:uuid;not null"`
-	Amount           float64        `json:"amount" gorm:"type:decimal(10,2);not null;check:amount >= 0"`
-	Method           PaymentMethod  `json:"method" gorm:"type:varchar(50);not null;check:method IN ('qris')"`
-	Status           PaymentStatus  `json:"status" gorm:"type:varchar(50);not null;check:status IN ('pending', 'success', 'failed', 'expired', 'cancelled')"`
-	OrderID          string         `json:"order_id"`              // Midtrans order ID for status checking
-	ExternalID       string         `json:"external_id"`           // Midtrans transaction ID
-	ExternalResponse string         `json:"external_response"`     // Midtrans response JSON
-	PaidAt           *time.Time     `json:"paid_at"`
-	ExpiresAt        time.Time      `json:"expires_at" gorm:"not null"`
-	CreatedAt        time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt        time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               string          `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID         *string         `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	TransactionID    string          `json:"transaction_id" gorm:"type:uuid;not null"`
+	StoreID          string          `json:"store_id" gorm:"type:uuid;not null;index"` // Store of the transaction, kept for store-scoped lookups
+	Amount           int64           `json:"amount" gorm:"type:bigint;not null;check:amount >= 0"`
+	AmountDue        int64           `json:"amount_due" gorm:"type:bigint;not null;default:0"`       // Balance of the transaction when the payment was taken, more than Amount for a deposit
+	Currency         Currency        `json:"currency" gorm:"type:varchar(3);not null;default:'IDR'"` // Currency of the transaction
+	Method           PaymentMethod   `json:"method" gorm:"type:varchar(50);not null;check:method IN ('qris', 'cash', 'card', 'other', 'ewallet')"`
+	Status           PaymentStatus   `json:"status" gorm:"type:varchar(50);not null;check:status IN ('pending', 'success', 'failed', 'expired', 'cancelled')"`
+	Provider         PaymentProvider `json:"provider,omitempty" gorm:"type:varchar(20)"`   // Empty for cash, card and other payments
+	// Current gateway order ID, for status checks and webhooks. Unique among live payments, the ones it
+	// superseded are kept in PaymentOrder.
+	OrderID          string          `json:"order_id" gorm:"type:varchar(255);uniqueIndex:idx_payments_order_id_unique,where:order_id <> '' AND deleted_at IS NULL"`
+	ExternalID       string          `json:"external_id" gorm:"type:varchar(255);index"`   // Gateway transaction ID
+	ExternalResponse string          `json:"external_response"`                            // Midtrans response JSON
+	AmountTendered   int64           `json:"amount_tendered" gorm:"type:bigint;default:0"` // Cash handed over by the customer
+	ChangeAmount     int64           `json:"change_amount" gorm:"type:bigint;default:0"`   // Cash returned to the customer
+	Reference        string          `json:"reference"`                                    // Card approval code or other method reference
+	Wallet           EWallet         `json:"wallet,omitempty" gorm:"type:varchar(20)"`     // E-wallet app of an e-wallet payment
+	DeeplinkURL      string          `json:"deeplink_url,omitempty"`                       // Opens the e-wallet app on the payment
+	IsTraining       bool            `json:"is_training" gorm:"default:false;index"`       // Payment of a training transaction, no money moved
+	PaidAt           *time.Time      `json:"paid_at"`
+	ExpiresAt        time.Time       `json:"expires_at" gorm:"not null"`
+	CreatedAt        time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt  `json:"-" gorm:"index"`
 
 	// Relations
 	Transaction Transaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
 	QRCode      *QRISCode   `json:"qr_code,omitempty" gorm:"foreignKey:PaymentID"`
+
+	outboxRecorder
+	paymentEventRecorder
 }
 
 func (Payment) TableName() string {
@@ -49,9 +89,42 @@ func (Payment) TableName() string {
 func (p *Payment) BeforeCreate(tx *gorm.DB) (err error) {
 	// Database handles UUID generation via DEFAULT gen_random_uuid()
 	// Do not set ID here to avoid conflicts
+
+	// Cash, card and other payments are settled when they are created
+	if p.Status != PaymentPending {
+		p.recordOutboxEvent(OutboxPaymentStatusChanged)
+	}
 	return
 }
 
+func (p *Payment) TakeOutboxEvents() ([]*OutboxEvent, error) {
+	pending := p.takeOutboxEvents()
+	// Practice sales stay inside the POS
+	if p.IsTraining {
+		return nil, nil
+	}
+
+	var outboxEvents []*OutboxEvent
+	for _, eventType := range pending {
+		event, err := NewOutboxEvent("payment", p.ID, eventType, p.StoreID, &PaymentStatusChangedData{
+			PaymentID:     p.ID,
+			TransactionID: p.TransactionID,
+			StoreID:       p.StoreID,
+			Status:        p.Status,
+			Method:        p.Method,
+			Provider:      p.Provider,
+			Amount:        p.Amount,
+			Currency:      p.Currency,
+			PaidAt:        p.PaidAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		outboxEvents = append(outboxEvents, event)
+	}
+	return outboxEvents, nil
+}
+
 type QRISCode struct {
 	ID            string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	TransactionID string         `json:"transaction_id" gorm:"type:uuid;not null"`
@@ -67,6 +140,21 @@ type QRISCode struct {
 	Payment     Payment     `json:"payment,omitempty" gorm:"foreignKey:PaymentID"`
 }
 
+// PaymentOrder maps a gateway order ID to the payment it was issued for. Refreshing a QRIS gives the
+// payment a new order ID, the mapping keeps the superseded ones so a late payment of an old QR can
+// still be traced to its transaction.
+type PaymentOrder struct {
+	OrderID       string          `json:"order_id" gorm:"type:varchar(255);primaryKey"`
+	PaymentID     string          `json:"payment_id" gorm:"type:uuid;not null;index"`
+	TransactionID string          `json:"transaction_id" gorm:"type:uuid;not null"`
+	Provider      PaymentProvider `json:"provider,omitempty" gorm:"type:varchar(20)"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PaymentOrder) TableName() string {
+	return "payment_orders"
+}
+
 func (QRISCode) TableName() string {
 	return "qris_codes"
 }
@@ -77,7 +165,7 @@ func (q *QRISCode) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
-func NewPayment(transactionID string, amount float64, expiryMinutes int) *Payment {
+func NewPayment(transactionID string, amount int64, expiryMinutes int) *Payment {
 	now := time.Now()
 	expiresAt := now.Add(time.Duration(expiryMinutes) * time.Minute)
 
@@ -90,6 +178,77 @@ func NewPayment(transactionID string, amount float64, expiryMinutes int) *Paymen
 	}
 }
 
+// NewEWalletPayment creates a pending payment to be made in an e-wallet app
+func NewEWalletPayment(transactionID string, amount int64, wallet EWallet, expiryMinutes int) *Payment {
+	payment := NewPayment(transactionID, amount, expiryMinutes)
+	payment.Method = PaymentMethodEWallet
+	payment.Wallet = wallet
+	return payment
+}
+
+// NewCashPayment creates a settled cash payment and calculates the change
+func NewCashPayment(transactionID string, amount, amountTendered int64) (*Payment, error) {
+	if amountTendered < amount {
+		return nil, errors.New("amount tendered is less than the amount due")
+	}
+
+	now := time.Now()
+	return &Payment{
+		TransactionID:  transactionID,
+		Amount:         amount,
+		Method:         PaymentMethodCash,
+		Status:         PaymentSuccess,
+		AmountTendered: amountTendered,
+		ChangeAmount:   amountTendered - amount,
+		PaidAt:         &now,
+		ExpiresAt:      now,
+	}, nil
+}
+
+// NewManualPayment creates a settled card or other non-QRIS payment confirmed at the terminal
+func NewManualPayment(transactionID string, amount int64, method PaymentMethod, reference string) (*Payment, error) {
+	if method != PaymentMethodCard && method != PaymentMethodOther {
+		return nil, errors.New("invalid manual payment method")
+	}
+	if method == PaymentMethodCard && reference == "" {
+		return nil, errors.New("card payment requires an approval reference")
+	}
+
+	now := time.Now()
+	return &Payment{
+		TransactionID: transactionID,
+		Amount:        amount,
+		Method:        method,
+		Status:        PaymentSuccess,
+		Reference:     reference,
+		PaidAt:        &now,
+		ExpiresAt:     now,
+	}, nil
+}
+
+// HasGatewayOrder reports whether the payment has an order on a payment gateway to query, cancel or refund
+func (p *Payment) HasGatewayOrder() bool {
+	return p.OrderID != "" && p.Provider != ProviderLocalQRIS
+}
+
+// SettledThroughGateway reports whether a gateway collected the money, so refunds have to go back through it
+func (p *Payment) SettledThroughGateway() bool {
+	return (p.Method == PaymentMethodQRIS || p.Method == PaymentMethodEWallet) && p.Provider != ProviderLocalQRIS
+}
+
+// IsDeposit reports whether the payment covers only part of the balance, the transaction stays open for the rest
+func (p *Payment) IsDeposit() bool {
+	return p.AmountDue > p.Amount
+}
+
+// RemainingAmount is the balance of the transaction left once the payment settles
+func (p *Payment) RemainingAmount() int64 {
+	if !p.IsDeposit() {
+		return 0
+	}
+	return p.AmountDue - p.Amount
+}
+
 func (p *Payment) IsExpired() bool {
 	return time.Now().After(p.ExpiresAt)
 }
@@ -98,21 +257,52 @@ func (p *Payment) CanBeProcessed() bool {
 	return p.Status == PaymentPending && !p.IsExpired()
 }
 
-func (p *Payment) MarkAsSuccess(externalID, externalResponse string) {
+// MarkAsSuccess settles the payment. The reason is the gateway status that reported it.
+func (p *Payment) MarkAsSuccess(actor PaymentActor, reason, externalID, externalResponse string) error {
+	if err := p.transition(PaymentSuccess, actor, reason, externalResponse); err != nil {
+		return err
+	}
 	now := time.Now()
-	p.Status = PaymentSuccess
 	p.ExternalID = externalID
 	p.ExternalResponse = externalResponse
 	p.PaidAt = &now
+	p.recordOutboxEvent(OutboxPaymentStatusChanged)
+	return nil
 }
 
-func (p *Payment) MarkAsFailed(externalResponse string) {
-	p.Status = PaymentFailed
+func (p *Payment) MarkAsFailed(actor PaymentActor, reason, externalResponse string) error {
+	if err := p.transition(PaymentFailed, actor, reason, externalResponse); err != nil {
+		return err
+	}
 	p.ExternalResponse = externalResponse
+	p.recordOutboxEvent(OutboxPaymentStatusChanged)
+	return nil
 }
 
-func (p *Payment) MarkAsExpired() {
-	p.Status = PaymentExpired
+func (p *Payment) MarkAsExpired(actor PaymentActor) error {
+	if err := p.transition(PaymentExpired, actor, "expired", ""); err != nil {
+		return err
+	}
+	p.recordOutboxEvent(OutboxPaymentStatusChanged)
+	return nil
+}
+
+func (p *Payment) MarkAsCancelled(actor PaymentActor, reason string) error {
+	if err := p.transition(PaymentCancelled, actor, reason, ""); err != nil {
+		return err
+	}
+	p.recordOutboxEvent(OutboxPaymentStatusChanged)
+	return nil
+}
+
+// Reissue makes the payment pending again for a new gateway order, the previous gateway response is dropped
+func (p *Payment) Reissue(actor PaymentActor, reason string) error {
+	if err := p.transition(PaymentPending, actor, reason, ""); err != nil {
+		return err
+	}
+	p.ExternalID = ""
+	p.ExternalResponse = ""
+	return nil
 }
 
 func NewQRISCode(transactionID, paymentID, qrCode, url string, expiryMinutes int) *QRISCode {
@@ -0,0 +1,146 @@
+package entities
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PaymentActorType is the kind of party that changed the status of a payment
+type PaymentActorType string
+
+const (
+	PaymentActorUser    PaymentActorType = "user"    // A cashier or admin, ID is the user
+	PaymentActorGateway PaymentActorType = "gateway" // A gateway notification or status check, ID is the provider
+	PaymentActorSystem  PaymentActorType = "system"  // A background job, ID names it
+)
+
+// PaymentActor is who changed the status of a payment
+type PaymentActor struct {
+	Type PaymentActorType
+	ID   string
+}
+
+func UserActor(userID string) PaymentActor {
+	return PaymentActor{Type: PaymentActorUser, ID: userID}
+}
+
+func GatewayActor(provider PaymentProvider) PaymentActor {
+	if provider == "" {
+		provider = ProviderMidtrans // Payments recorded before the provider was
+	}
+	return PaymentActor{Type: PaymentActorGateway, ID: string(provider)}
+}
+
+func SystemActor(job string) PaymentActor {
+	return PaymentActor{Type: PaymentActorSystem, ID: job}
+}
+
+// paymentTransitions lists the statuses a payment may move to. A payment is created pending, or settled
+// for cash, card and other methods. Settled, failed and cancelled payments are final: money that arrives
+// for them afterwards is a payment exception.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	"":             {PaymentPending, PaymentSuccess},
+	PaymentPending: {PaymentPending, PaymentSuccess, PaymentFailed, PaymentExpired, PaymentCancelled}, // Pending again when its QRIS is reissued
+	PaymentExpired: {PaymentPending, PaymentSuccess},                                                  // Refreshed, or a transfer confirmed late by the cashier
+}
+
+// CanTransitionTo reports whether a payment in the status may move to another one
+func (s PaymentStatus) CanTransitionTo(to PaymentStatus) bool {
+	return slices.Contains(paymentTransitions[s], to)
+}
+
+// PaymentEvent records a status change of a payment, from its creation on. Events are only ever
+// inserted, together with the change, and make up the audit trail of the payment.
+type PaymentEvent struct {
+	ID             string           `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID       *string          `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	PaymentID      string           `json:"payment_id" gorm:"type:uuid;not null;index"`
+	FromStatus     PaymentStatus    `json:"from_status,omitempty" gorm:"type:varchar(50)"` // Empty for the creation of the payment
+	ToStatus       PaymentStatus    `json:"to_status" gorm:"type:varchar(50);not null"`
+	ActorType      PaymentActorType `json:"actor_type" gorm:"type:varchar(20);not null"`
+	ActorID        string           `json:"actor_id,omitempty" gorm:"type:varchar(100)"`
+	Reason         string           `json:"reason,omitempty"`
+	GatewayPayload string           `json:"gateway_payload,omitempty"` // Raw gateway notification or response behind the change
+	CreatedAt      time.Time        `json:"created_at" gorm:"autoCreateTime;index"`
+}
+
+func (PaymentEvent) TableName() string {
+	return "payment_events"
+}
+
+func (e *PaymentEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+// paymentEventRecorder collects the status changes of a payment until its next save
+type paymentEventRecorder struct {
+	events []*PaymentEvent
+}
+
+// transition moves the payment to a status, refusing the changes the state machine doesn't allow
+func (p *Payment) transition(to PaymentStatus, actor PaymentActor, reason, payload string) error {
+	from := p.Status
+	if len(p.events) == 0 && p.ID == "" {
+		from = "" // Not stored yet, the payment is created in this status
+	}
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", appErrors.ErrIllegalPaymentTransition, describeStatus(from), to)
+	}
+
+	p.events = append(p.events, &PaymentEvent{
+		FromStatus:     from,
+		ToStatus:       to,
+		ActorType:      actor.Type,
+		ActorID:        actor.ID,
+		Reason:         reason,
+		GatewayPayload: payload,
+		CreatedAt:      time.Now(), // Orders the changes saved together
+	})
+	p.Status = to
+	return nil
+}
+
+// RecordCreation starts the audit trail of a payment about to be created, in the status it was built with
+func (p *Payment) RecordCreation(actor PaymentActor) error {
+	return p.transition(p.Status, actor, "created", "")
+}
+
+// StatusBeforeChanges returns the stored status the recorded changes started from, false when the
+// payment has no changes or is new
+func (p *Payment) StatusBeforeChanges() (PaymentStatus, bool) {
+	if len(p.events) == 0 || p.events[0].FromStatus == "" {
+		return "", false
+	}
+	return p.events[0].FromStatus, true
+}
+
+// TakePaymentEvents returns the status changes recorded since the last save, for the payment as stored.
+// Practice sales keep no history, they are purged with their transactions.
+func (p *Payment) TakePaymentEvents() []*PaymentEvent {
+	events := p.events
+	p.events = nil
+	if p.IsTraining {
+		return nil
+	}
+	for _, event := range events {
+		event.PaymentID = p.ID
+		event.TenantID = p.TenantID
+	}
+	return events
+}
+
+func describeStatus(status PaymentStatus) string {
+	if status == "" {
+		return "new"
+	}
+	return string(status)
+}
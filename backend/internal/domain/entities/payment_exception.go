@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentExceptionReason string
+
+const (
+	// ExceptionLatePayment is a successful payment for a payment or transaction that was already closed
+	ExceptionLatePayment PaymentExceptionReason = "late_payment"
+	// ExceptionDuplicatePayment is a second successful gateway transaction for an already paid payment
+	ExceptionDuplicatePayment PaymentExceptionReason = "duplicate_payment"
+	// ExceptionUnknownOrder is a successful payment for an order ID no payment refers to anymore
+	ExceptionUnknownOrder PaymentExceptionReason = "unknown_order"
+	// ExceptionVoidRefund is a voided sale the gateway couldn't refund, to be paid back by hand
+	ExceptionVoidRefund PaymentExceptionReason = "void_refund"
+	// ExceptionStockUnavailable is a settled payment for a cart whose stock ran out before it was paid,
+	// the sale is kept as paid until the goods are handed over some other way or the money is refunded
+	ExceptionStockUnavailable PaymentExceptionReason = "stock_unavailable"
+)
+
+type PaymentExceptionStatus string
+
+const (
+	ExceptionOpen         PaymentExceptionStatus = "open"
+	ExceptionAcknowledged PaymentExceptionStatus = "acknowledged"
+	ExceptionRefunded     PaymentExceptionStatus = "refunded"
+)
+
+// PaymentException is money received by the gateway that the POS did not expect, or owed back for
+// a voided sale, and that needs to be refunded or acknowledged by an admin
+type PaymentException struct {
+	ID            string                 `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PaymentID     *string                `json:"payment_id" gorm:"type:uuid;index"`
+	TransactionID *string                `json:"transaction_id" gorm:"type:uuid"`
+	OrderID       string                 `json:"order_id" gorm:"type:varchar(255);not null"`
+	ExternalID    string                 `json:"external_id" gorm:"type:varchar(255);uniqueIndex:idx_payment_exceptions_external_id,where:external_id <> ''"`
+	Amount        int64                  `json:"amount" gorm:"type:bigint;not null;default:0"`
+	Reason        PaymentExceptionReason `json:"reason" gorm:"type:varchar(50);not null;check:reason IN ('late_payment', 'duplicate_payment', 'unknown_order', 'void_refund', 'stock_unavailable')"`
+	Status        PaymentExceptionStatus `json:"status" gorm:"type:varchar(50);not null;default:open;check:status IN ('open', 'acknowledged', 'refunded')"`
+	Note          string                 `json:"note"`
+	RawResponse   string                 `json:"raw_response"`
+	ResolvedBy    *string                `json:"resolved_by" gorm:"type:uuid"`
+	ResolvedAt    *time.Time             `json:"resolved_at"`
+	CreatedAt     time.Time              `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (PaymentException) TableName() string {
+	return "payment_exceptions"
+}
+
+func (e *PaymentException) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewPaymentException(reason PaymentExceptionReason, orderID, externalID string, amount int64, rawResponse string) *PaymentException {
+	return &PaymentException{
+		OrderID:     orderID,
+		ExternalID:  externalID,
+		Amount:      amount,
+		Reason:      reason,
+		Status:      ExceptionOpen,
+		RawResponse: rawResponse,
+	}
+}
+
+// Resolve closes the exception as acknowledged or refunded
+func (e *PaymentException) Resolve(status PaymentExceptionStatus, userID, note string) error {
+	if e.Status != ExceptionOpen {
+		return errors.New("payment exception is already resolved")
+	}
+	if status != ExceptionAcknowledged && status != ExceptionRefunded {
+		return errors.New("invalid resolution status")
+	}
+
+	now := time.Now()
+	e.Status = status
+	e.Note = note
+	e.ResolvedBy = &userID
+	e.ResolvedAt = &now
+	return nil
+}
@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentNotificationStatus string
+
+const (
+	NotificationReceived  PaymentNotificationStatus = "received"  // Stored and being applied
+	NotificationProcessed PaymentNotificationStatus = "processed" // Applied to the payment
+	NotificationRetrying  PaymentNotificationStatus = "retrying"  // Failed to apply, retried with backoff
+	NotificationFailed    PaymentNotificationStatus = "failed"    // Out of attempts, waits for an admin to replay it
+	NotificationRejected  PaymentNotificationStatus = "rejected"  // Failed the signature or format check, never applied
+)
+
+// PaymentNotification is a gateway callback as it arrived. It is stored before it is applied, so a
+// callback that failed on a passing error is retried and one that keeps failing can be replayed by
+// hand once the cause is fixed, instead of the payment waiting for reconciliation.
+type PaymentNotification struct {
+	ID            string                    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Provider      PaymentProvider           `json:"provider" gorm:"type:varchar(20);not null"`
+	OrderID       string                    `json:"order_id" gorm:"type:varchar(100);index"`
+	ExternalID    string                    `json:"external_id" gorm:"type:varchar(100)"`
+	GatewayStatus string                    `json:"gateway_status" gorm:"type:varchar(50)"` // Status reported by the gateway, e.g. settlement
+	GrossAmount   int64                     `json:"gross_amount" gorm:"type:bigint;not null;default:0"`
+	Payload       string                    `json:"payload" gorm:"type:text;not null"` // Body exactly as received
+	Status        PaymentNotificationStatus `json:"status" gorm:"type:varchar(20);not null;default:'received';index:idx_payment_notifications_due,priority:1;check:status IN ('received', 'processed', 'retrying', 'failed', 'rejected')"`
+	Attempts      int                       `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time                 `json:"next_attempt_at" gorm:"not null;index:idx_payment_notifications_due,priority:2"`
+	LastError     string                    `json:"last_error"`
+	ProcessedAt   *time.Time                `json:"processed_at"`
+	ReplayedBy    *string                   `json:"replayed_by,omitempty" gorm:"type:uuid"` // Admin who last replayed it
+	CreatedAt     time.Time                 `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt     time.Time                 `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (PaymentNotification) TableName() string {
+	return "payment_notifications"
+}
+
+func (n *PaymentNotification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return
+}
+
+// NewPaymentNotification stores a callback body about to be applied. Should the server stop before the
+// outcome is recorded, the retry job picks it up once retryAfter has passed.
+func NewPaymentNotification(provider PaymentProvider, payload []byte, retryAfter time.Duration) *PaymentNotification {
+	return &PaymentNotification{
+		ID:            uuid.New().String(),
+		Provider:      provider,
+		Payload:       string(payload),
+		Status:        NotificationReceived,
+		NextAttemptAt: time.Now().Add(retryAfter),
+	}
+}
+
+func (n *PaymentNotification) MarkAsProcessed() {
+	now := time.Now()
+	n.Status = NotificationProcessed
+	n.Attempts++
+	n.LastError = ""
+	n.ProcessedAt = &now
+}
+
+// MarkAttemptFailed schedules a retry after backoff, doubling it on every attempt, and leaves the
+// notification for an admin once maxAttempts is reached
+func (n *PaymentNotification) MarkAttemptFailed(err error, maxAttempts int, backoff time.Duration) {
+	n.Attempts++
+	n.LastError = err.Error()
+
+	if n.Attempts >= maxAttempts {
+		n.Status = NotificationFailed
+		return
+	}
+	n.Status = NotificationRetrying
+	n.NextAttemptAt = time.Now().Add(backoff * time.Duration(1<<(n.Attempts-1)))
+}
+
+// MarkAsRejected records a callback that can never be applied
+func (n *PaymentNotification) MarkAsRejected(err error) {
+	n.Status = NotificationRejected
+	n.LastError = err.Error()
+}
+
+// CanBeReplayed reports whether the notification passed the gateway checks, so applying it again is safe
+func (n *PaymentNotification) CanBeReplayed() bool {
+	return n.Status != NotificationRejected
+}
+
+// Replay gives the notification a fresh set of attempts on behalf of an admin
+func (n *PaymentNotification) Replay(userID string, retryAfter time.Duration) {
+	n.Status = NotificationReceived
+	n.Attempts = 0
+	n.NextAttemptAt = time.Now().Add(retryAfter)
+	n.ReplayedBy = &userID
+}
@@ -0,0 +1,70 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PriceAdjustmentMode string
+
+const (
+	PriceAdjustPercentage PriceAdjustmentMode = "percentage"
+	PriceAdjustFixed      PriceAdjustmentMode = "fixed"
+)
+
+// PriceChangeSource tells what changed a product price
+type PriceChangeSource string
+
+const (
+	PriceChangeBulk      PriceChangeSource = "bulk"      // Bulk percentage or fixed adjustment
+	PriceChangeSuggested PriceChangeSource = "suggested" // Recalculated from cost price and category markup
+	PriceChangeSchedule  PriceChangeSource = "schedule"  // A price schedule starting or ending
+)
+
+// PriceChange is the audit entry of a single product price change. Together the entries of a product are
+// its price history.
+type PriceChange struct {
+	ID         string            `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ProductID  string            `json:"product_id" gorm:"type:uuid;not null;index"`
+	BatchID    string            `json:"batch_id" gorm:"type:uuid;not null;index"` // Groups changes made by one bulk update
+	OldPrice   int64             `json:"old_price" gorm:"type:bigint;not null"`
+	NewPrice   int64             `json:"new_price" gorm:"type:bigint;not null;check:new_price >= 0"`
+	Source     PriceChangeSource `json:"source" gorm:"type:varchar(20);not null;default:'bulk'"`
+	ScheduleID *string           `json:"schedule_id,omitempty" gorm:"type:uuid"` // Price schedule that started or ended
+	ChangedBy  string            `json:"changed_by" gorm:"type:uuid;not null"`
+	Reason     string            `json:"reason"`
+	CreatedAt  time.Time         `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PriceChange) TableName() string {
+	return "price_changes"
+}
+
+func (pc *PriceChange) BeforeCreate(tx *gorm.DB) (err error) {
+	if pc.ID == "" {
+		pc.ID = uuid.New().String()
+	}
+	return
+}
+
+// AdjustedPrice returns the product price raised or lowered by a percentage or a fixed rupiah amount.
+// Negative values lower the price.
+func (p *Product) AdjustedPrice(mode PriceAdjustmentMode, value float64) (int64, error) {
+	var newPrice int64
+	switch mode {
+	case PriceAdjustPercentage:
+		newPrice = p.Price + PercentOf(p.Price, value)
+	case PriceAdjustFixed:
+		newPrice = p.Price + RoundAmount(value)
+	default:
+		return 0, errors.New("invalid price adjustment mode")
+	}
+
+	if newPrice < 0 {
+		return 0, errors.New("adjusted price cannot be negative")
+	}
+	return newPrice, nil
+}
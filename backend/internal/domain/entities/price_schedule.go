@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PriceScheduleStatus string
+
+const (
+	PriceSchedulePending   PriceScheduleStatus = "pending"   // Waiting for its next window
+	PriceScheduleInEffect  PriceScheduleStatus = "in_effect" // The product is sold at the scheduled price
+	PriceScheduleCompleted PriceScheduleStatus = "completed" // Past its end, or a permanent change already applied
+)
+
+// PriceSchedule sets the price of a product from StartsAt. Without an end or days of the week it is a
+// permanent change that becomes the list price. Otherwise the product is sold at the scheduled price on
+// the given days until EndsAt, such as weekend pricing, and goes back to its list price in between.
+type PriceSchedule struct {
+	ID         string              `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID   *string             `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	StoreID    string              `json:"store_id" gorm:"type:uuid;not null;index"`
+	ProductID  string              `json:"product_id" gorm:"type:uuid;not null;index"`
+	Name       string              `json:"name" gorm:"type:varchar(100);not null"`
+	Price      int64               `json:"price" gorm:"type:bigint;not null;check:price >= 0"`
+	DaysOfWeek string              `json:"days_of_week" gorm:"type:varchar(20)"` // Comma separated, 0 = Sunday
+	StartsAt   time.Time           `json:"starts_at" gorm:"not null"`
+	EndsAt     *time.Time          `json:"ends_at"`
+	Status     PriceScheduleStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index;check:status IN ('pending', 'in_effect', 'completed')"`
+	ListPrice  int64               `json:"list_price" gorm:"type:bigint;not null;default:0"` // Price the product goes back to when the schedule ends, set while in effect
+	IsActive   bool                `json:"is_active" gorm:"default:true"`
+	CreatedBy  string              `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt  gorm.DeletedAt      `json:"-" gorm:"index"`
+}
+
+func (PriceSchedule) TableName() string {
+	return "price_schedules"
+}
+
+func (s *PriceSchedule) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
+func (s *PriceSchedule) Validate() error {
+	if s.Name == "" {
+		return errors.New("price schedule name cannot be empty")
+	}
+	if s.Price < 0 {
+		return errors.New("scheduled price cannot be negative")
+	}
+	if s.StartsAt.IsZero() {
+		return errors.New("starts_at is required")
+	}
+	if s.EndsAt != nil && !s.EndsAt.After(s.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if s.DaysOfWeek == "" {
+		return nil
+	}
+	for _, day := range strings.Split(s.DaysOfWeek, ",") {
+		if d, err := strconv.Atoi(strings.TrimSpace(day)); err != nil || d < 0 || d > 6 {
+			return errors.New("days_of_week must list days from 0 (Sunday) to 6")
+		}
+	}
+	return nil
+}
+
+// IsPermanent reports whether the schedule changes the list price for good once it starts
+func (s *PriceSchedule) IsPermanent() bool {
+	return s.EndsAt == nil && s.DaysOfWeek == ""
+}
+
+// IsActiveAt reports whether the product should be sold at the scheduled price at the given time
+func (s *PriceSchedule) IsActiveAt(t time.Time) bool {
+	if !s.IsActive || t.Before(s.StartsAt) {
+		return false
+	}
+	if s.EndsAt != nil && !t.Before(*s.EndsAt) {
+		return false
+	}
+	if s.DaysOfWeek == "" {
+		return true
+	}
+	for _, day := range strings.Split(s.DaysOfWeek, ",") {
+		if d, err := strconv.Atoi(strings.TrimSpace(day)); err == nil && time.Weekday(d) == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOver reports whether the schedule has no window left after the given time
+func (s *PriceSchedule) IsOver(t time.Time) bool {
+	return s.EndsAt != nil && !t.Before(*s.EndsAt)
+}
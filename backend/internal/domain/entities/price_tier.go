@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PriceTier is a quantity break of a product: from MinQuantity units in a transaction on, every unit
+// costs Price. Below the lowest break units cost the product's price.
+type PriceTier struct {
+	ID          string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ProductID   string    `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_product_price_tiers_quantity"`
+	MinQuantity int       `json:"min_quantity" gorm:"not null;uniqueIndex:idx_product_price_tiers_quantity;check:min_quantity >= 2"`
+	Price       int64     `json:"price" gorm:"type:bigint;not null;check:price >= 0"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PriceTier) TableName() string {
+	return "product_price_tiers"
+}
+
+func (t *PriceTier) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return
+}
+
+// ValidatePriceTiers checks the quantity breaks of a product and sorts them by quantity. A larger
+// quantity can't cost more per unit than a smaller one.
+func ValidatePriceTiers(tiers []PriceTier) error {
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinQuantity < tiers[j].MinQuantity })
+	for i, tier := range tiers {
+		if tier.MinQuantity < 2 {
+			return errors.New("price tier min_quantity must be at least 2")
+		}
+		if tier.Price < 0 {
+			return errors.New("price tier price cannot be negative")
+		}
+		if i > 0 && tier.MinQuantity == tiers[i-1].MinQuantity {
+			return errors.New("price tiers must have different min_quantity")
+		}
+		if i > 0 && tier.Price > tiers[i-1].Price {
+			return errors.New("price tiers for larger quantities cannot cost more")
+		}
+	}
+	return nil
+}
+
+// PriceTierFor returns the quantity break a quantity reaches, the one with the largest min quantity,
+// or nil when none is reached or the break would not lower the base price
+func PriceTierFor(tiers []PriceTier, quantity float64, basePrice int64) *PriceTier {
+	var reached *PriceTier
+	for i := range tiers {
+		if quantity >= float64(tiers[i].MinQuantity) && (reached == nil || tiers[i].MinQuantity > reached.MinQuantity) {
+			reached = &tiers[i]
+		}
+	}
+	if reached == nil || reached.Price >= basePrice {
+		return nil
+	}
+	return reached
+}
+
+// ApplyPriceTiers prices the items of a product at the quantity break their combined quantity reaches,
+// or at their base unit price below it. Prices an offline terminal charged are kept. It returns the
+// indexes of the items whose price changed.
+func ApplyPriceTiers(items []TransactionItem, productID string, tiers []PriceTier) []int {
+	var quantity float64
+	for _, item := range items {
+		if item.ProductID == productID && item.PriceSource != PriceSourceClient {
+			quantity += item.Quantity
+		}
+	}
+	quantity = RoundQuantity(quantity)
+
+	var changed []int
+	for i := range items {
+		item := &items[i]
+		if item.ProductID != productID || item.PriceSource == PriceSourceClient {
+			continue
+		}
+
+		unitPrice, tierQuantity := item.BaseUnitPrice, 0
+		if tier := PriceTierFor(tiers, quantity, item.BaseUnitPrice); tier != nil {
+			unitPrice, tierQuantity = tier.Price, tier.MinQuantity
+		}
+		totalPrice := LinePrice(unitPrice, item.Quantity)
+		if unitPrice == item.UnitPrice && tierQuantity == item.TierMinQuantity && totalPrice == item.TotalPrice {
+			continue
+		}
+		item.UnitPrice = unitPrice
+		item.TierMinQuantity = tierQuantity
+		item.TotalPrice = totalPrice
+		changed = append(changed, i)
+	}
+	return changed
+}
@@ -0,0 +1,156 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Printer is a networked thermal printer of a store. A print agent running next to it pulls the jobs
+// of the printer and authenticates with the printer's token.
+type Printer struct {
+	ID         string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID   *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	StoreID    string         `json:"store_id" gorm:"type:uuid;not null;index"`
+	Name       string         `json:"name" gorm:"type:varchar(100);not null"`
+	Width      int            `json:"width" gorm:"not null;default:32;check:width IN (32, 48)"` // Characters per line, 32 for 58mm and 48 for 80mm paper
+	TokenHash  string         `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`           // SHA-256 of the agent token, the token is shown once
+	IsActive   bool           `json:"is_active" gorm:"default:true"`
+	LastSeenAt *time.Time     `json:"last_seen_at"` // Last time the agent polled for jobs
+	CreatedAt  time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Printer) TableName() string {
+	return "printers"
+}
+
+func (p *Printer) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
+
+// IssueToken gives the printer a new agent token, replacing the previous one. The returned string is the
+// token to configure the agent with, it can't be recovered from what is stored.
+func (p *Printer) IssueToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := "prt_" + hex.EncodeToString(secret)
+	p.TokenHash = HashPrinterToken(token)
+	return token, nil
+}
+
+// HashPrinterToken returns the hash an agent token is looked up by
+func HashPrinterToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type PrintJobKind string
+
+const (
+	PrintJobReceipt PrintJobKind = "receipt"
+	PrintJobQRIS    PrintJobKind = "qris" // The QRIS of a pending payment, for customers to scan from paper
+)
+
+type PrintJobStatus string
+
+const (
+	PrintJobQueued   PrintJobStatus = "queued"
+	PrintJobPrinting PrintJobStatus = "printing"
+	PrintJobPrinted  PrintJobStatus = "printed"
+	PrintJobFailed   PrintJobStatus = "failed"
+)
+
+// PrintJob is a receipt or QRIS waiting for a printer. The content is rendered when the agent claims the
+// job, so it matches the printer's paper width. A claimed job the agent never acknowledges is handed out
+// again once its lease runs out, until MaxAttempts is reached.
+type PrintJob struct {
+	ID            string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID      *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	PrinterID     string         `json:"printer_id" gorm:"type:uuid;not null;index:idx_print_jobs_printer,priority:1"`
+	StoreID       string         `json:"store_id" gorm:"type:uuid;not null;index"`
+	Kind          PrintJobKind   `json:"kind" gorm:"type:varchar(20);not null;check:kind IN ('receipt', 'qris')"`
+	TransactionID string         `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	Copies        int            `json:"copies" gorm:"not null;default:1"`
+	Status        PrintJobStatus `json:"status" gorm:"type:varchar(20);not null;default:queued;check:status IN ('queued', 'printing', 'printed', 'failed')"`
+	Attempts      int            `json:"attempts" gorm:"not null;default:0"`
+	LastError     string         `json:"last_error"`
+	ReprintOf     *string        `json:"reprint_of,omitempty" gorm:"type:uuid"` // Job this one prints again
+	CreatedBy     string         `json:"created_by" gorm:"type:uuid;not null"`
+	ClaimedAt     *time.Time     `json:"claimed_at"`
+	PrintedAt     *time.Time     `json:"printed_at"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime;index:idx_print_jobs_printer,priority:2"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (PrintJob) TableName() string {
+	return "print_jobs"
+}
+
+func (j *PrintJob) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewPrintJob(printer *Printer, kind PrintJobKind, transactionID string, copies int, createdBy string) *PrintJob {
+	return &PrintJob{
+		ID:            uuid.New().String(),
+		PrinterID:     printer.ID,
+		StoreID:       printer.StoreID,
+		Kind:          kind,
+		TransactionID: transactionID,
+		Copies:        max(copies, 1),
+		Status:        PrintJobQueued,
+		CreatedBy:     createdBy,
+	}
+}
+
+// Reprint queues the same document on the same printer again
+func (j *PrintJob) Reprint(createdBy string) *PrintJob {
+	reprint := &PrintJob{
+		ID:            uuid.New().String(),
+		PrinterID:     j.PrinterID,
+		StoreID:       j.StoreID,
+		Kind:          j.Kind,
+		TransactionID: j.TransactionID,
+		Copies:        j.Copies,
+		Status:        PrintJobQueued,
+		CreatedBy:     createdBy,
+	}
+	reprint.ReprintOf = &j.ID
+	return reprint
+}
+
+func (j *PrintJob) IsFinished() bool {
+	return j.Status == PrintJobPrinted || j.Status == PrintJobFailed
+}
+
+func (j *PrintJob) MarkAsPrinted() {
+	now := time.Now()
+	j.Status = PrintJobPrinted
+	j.LastError = ""
+	j.PrintedAt = &now
+}
+
+// MarkAttemptFailed queues the job for another attempt, or gives up once maxAttempts is reached
+func (j *PrintJob) MarkAttemptFailed(message string, maxAttempts int) {
+	j.LastError = message
+	j.ClaimedAt = nil
+	if j.Attempts >= maxAttempts {
+		j.Status = PrintJobFailed
+		return
+	}
+	j.Status = PrintJobQueued
+}
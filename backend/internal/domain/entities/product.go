@@ -7,23 +7,52 @@ import (
 	"github.com/google/uuid"
 )
 
+// TaxClass decides how a product is taxed at sale time
+type TaxClass string
+
+const (
+	TaxClassStandard TaxClass = "standard" // Subject to PPN
+	TaxClassExempt   TaxClass = "exempt"   // PPN-exempt goods such as basic staples
+)
+
+// PriceSource tells where the unit price of a sold item came from
+type PriceSource string
+
+const (
+	PriceSourceList     PriceSource = "list"     // The product's list price
+	PriceSourceSchedule PriceSource = "schedule" // A price schedule in effect at the time of sale
+	PriceSourceClient   PriceSource = "client"   // The price an offline terminal charged, kept on sync
+)
+
 type Product struct {
-	ID          string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Price       float64        `json:"price" gorm:"type:decimal(10,2);not null;check:price >= 0"`
-	Stock       int            `json:"stock" gorm:"not null;check:stock >= 0"`
-	CategoryID  string         `json:"category_id" gorm:"type:uuid;not null"`
-	SKU         string         `json:"sku" gorm:"uniqueIndex"`
-	ImageURL    string         `json:"image_url" gorm:"type:text"`
-	IsActive    bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID        *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	Name            string         `json:"name" gorm:"not null"`
+	Description     string         `json:"description"`
+	Price           int64          `json:"price" gorm:"type:bigint;not null;check:price >= 0"`
+	CostPrice       int64          `json:"cost_price" gorm:"type:bigint;not null;default:0;check:cost_price >= 0"` // Purchase cost, used for margin reports
+	Stock           float64        `json:"stock" gorm:"type:decimal(12,3);not null;check:stock >= 0"` // In the product's unit
+	Unit            UnitOfMeasure  `json:"unit" gorm:"type:varchar(10);not null;default:'pcs';check:unit IN ('pcs', 'kg', 'liter')"`
+	CategoryID      string         `json:"category_id" gorm:"type:uuid;not null"`
+	StoreID         string         `json:"store_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_products_store_sku;uniqueIndex:idx_products_barcode,where:barcode <> '' AND deleted_at IS NULL"` // Store selling the product, stock is counted per store
+	SKU             string         `json:"sku" gorm:"uniqueIndex:idx_products_store_sku"` // Unique within a store
+	Barcode         string         `json:"barcode" gorm:"type:varchar(64);uniqueIndex:idx_products_barcode,where:barcode <> '' AND deleted_at IS NULL"` // EAN/UPC printed on the package, scanned at checkout, unique within a store
+	TaxClass        TaxClass       `json:"tax_class" gorm:"type:varchar(20);not null;default:'standard';check:tax_class IN ('standard', 'exempt')"`
+	ImageURL        string         `json:"image_url" gorm:"type:text"`
+	IsActive        bool           `json:"is_active" gorm:"default:true"`
+	PriceScheduleID *string        `json:"price_schedule_id,omitempty" gorm:"type:uuid"` // Price schedule the current price comes from, nil for the list price
+	CreatedAt       time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
 	Category         Category          `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Images           []ProductImage    `json:"images,omitempty" gorm:"foreignKey:ProductID"`
+	PriceTiers       []PriceTier       `json:"price_tiers,omitempty" gorm:"foreignKey:ProductID"` // Quantity breaks, lowest quantity first
+	Recipe           []RecipeItem      `json:"recipe,omitempty" gorm:"foreignKey:ProductID"` // Ingredients used per unit sold
 	TransactionItems []TransactionItem `json:"transaction_items,omitempty" gorm:"foreignKey:ProductID"`
+
+	outboxRecorder
 }
 
 func (Product) TableName() string {
@@ -37,7 +66,7 @@ func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
-func NewProduct(name, description, sku, categoryID string, price float64, stock int) (*Product, error) {
+func NewProduct(name, description, sku, categoryID string, price int64, stock float64) (*Product, error) {
 	if name == "" {
 		return nil, errors.New("product name cannot be empty")
 	}
@@ -54,37 +83,130 @@ func NewProduct(name, description, sku, categoryID string, price float64, stock
 		Description: description,
 		Price:       price,
 		Stock:       stock,
+		Unit:        UnitPiece,
 		CategoryID:  categoryID,
 		SKU:         sku,
+		TaxClass:    TaxClassStandard,
 		IsActive:    true,
 	}, nil
 }
 
-func (p *Product) UpdateStock(quantity int) error {
-	newStock := p.Stock + quantity
+func (p *Product) UpdateStock(quantity float64) error {
+	if err := p.unit().ValidateStock(quantity); err != nil {
+		return err
+	}
+	newStock := RoundQuantity(p.Stock + quantity)
 	if newStock < 0 {
 		return errors.New("insufficient stock")
 	}
 	
 	p.Stock = newStock
+	p.recordOutboxEvent(OutboxStockChanged)
 	return nil
 }
 
+// SetStock replaces the stock with a counted quantity
+func (p *Product) SetStock(stock float64) {
+	if stock == p.Stock {
+		return
+	}
+	p.Stock = stock
+	p.recordOutboxEvent(OutboxStockChanged)
+}
+
+func (p *Product) TakeOutboxEvents() ([]*OutboxEvent, error) {
+	var outboxEvents []*OutboxEvent
+	for _, eventType := range p.takeOutboxEvents() {
+		event, err := NewOutboxEvent("product", p.ID, eventType, p.StoreID, &StockChangedData{
+			ProductID: p.ID,
+			StoreID:   p.StoreID,
+			Stock:     p.Stock,
+		})
+		if err != nil {
+			return nil, err
+		}
+		outboxEvents = append(outboxEvents, event)
+	}
+	return outboxEvents, nil
+}
+
+// PriceSource tells whether the current price is the list price or comes from a price schedule
+func (p *Product) PriceSource() PriceSource {
+	if p.PriceScheduleID != nil {
+		return PriceSourceSchedule
+	}
+	return PriceSourceList
+}
+
 func (p *Product) IsAvailable() bool {
 	return p.IsActive && p.Stock > 0
 }
 
-func (p *Product) CanFulfillQuantity(quantity int) bool {
+func (p *Product) CanFulfillQuantity(quantity float64) bool {
 	return p.Stock >= quantity
 }
 
+// ValidateQuantity checks a quantity can be sold in the product's unit
+func (p *Product) ValidateQuantity(quantity float64) error {
+	return p.unit().ValidateQuantity(quantity)
+}
+
+func (p *Product) unit() UnitOfMeasure {
+	if p.Unit == "" {
+		return UnitPiece
+	}
+	return p.Unit
+}
+
+// ProductImage is one of the pictures of a product, stored resized in the product images bucket next to
+// its thumbnail. The first by sort order is the main picture and is mirrored to Product.ImageURL.
+type ProductImage struct {
+	ID            string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID     string    `json:"product_id" gorm:"type:uuid;not null;index"`
+	URL           string    `json:"url" gorm:"type:text;not null"`
+	ThumbnailURL  string    `json:"thumbnail_url" gorm:"type:text;not null"`
+	ObjectPath    string    `json:"-" gorm:"type:text;not null"`
+	ThumbnailPath string    `json:"-" gorm:"type:text;not null"`
+	Width         int       `json:"width"`
+	Height        int       `json:"height"`
+	SortOrder     int       `json:"sort_order" gorm:"not null;default:0"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ProductImage) TableName() string {
+	return "product_images"
+}
+
+func (i *ProductImage) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
+
+// ProductPopularity is the number of units of a product sold in the recent window, rolled up by a
+// background job so the catalog can be ordered by it without aggregating sales on every request
+type ProductPopularity struct {
+	ProductID   string    `json:"product_id" gorm:"type:uuid;primaryKey"`
+	UnitsSold   int64     `json:"units_sold" gorm:"type:bigint;not null;default:0"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+func (ProductPopularity) TableName() string {
+	return "product_popularity"
+}
+
 type Category struct {
-	ID        string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name      string         `json:"name" gorm:"uniqueIndex;not null"`
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID            string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID      *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	Name          string         `json:"name" gorm:"not null"`                                 // Unique within the tenant
+	ParentID      *string        `json:"parent_id,omitempty" gorm:"type:uuid;index"`           // Top-level category when empty
+	SKUPrefix     string         `json:"sku_prefix" gorm:"column:sku_prefix;type:varchar(10)"` // Starts the SKUs generated for its products
+	DefaultMarkup float64        `json:"default_markup" gorm:"type:decimal(7,2);not null;default:0;check:default_markup >= 0"` // Percentage added to cost price to suggest a sale price
+	IsActive      bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relations
 	Products []Product `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
@@ -99,4 +221,13 @@ func (c *Category) BeforeCreate(tx *gorm.DB) (err error) {
 		c.ID = uuid.New().String()
 	}
 	return
-}
\ No newline at end of file
+}
+
+// SuggestedPrice returns the cost price raised by the category's default markup,
+// or 0 when there is no cost or markup to base a suggestion on
+func (c *Category) SuggestedPrice(costPrice int64) int64 {
+	if costPrice <= 0 || c.DefaultMarkup <= 0 {
+		return 0
+	}
+	return costPrice + PercentOf(costPrice, c.DefaultMarkup)
+}
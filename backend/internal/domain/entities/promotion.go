@@ -0,0 +1,226 @@
+package entities
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PromotionType string
+
+const (
+	PromotionBuyXGetY        PromotionType = "buy_x_get_y"
+	PromotionHappyHour       PromotionType = "happy_hour"
+	PromotionCategoryPercent PromotionType = "category_percent"
+	PromotionPercentage      PromotionType = "percentage"   // Percentage off the basket, or off the scoped product or category
+	PromotionFixedAmount     PromotionType = "fixed_amount" // Fixed rupiah amount off the basket, or off the scoped items
+)
+
+// Promotion is a rule evaluated against the items of a pending transaction. Promotions without a code
+// apply automatically, those with a code only once the customer's promo code is entered.
+type Promotion struct {
+	ID          string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID    *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	Code        *string        `json:"code,omitempty" gorm:"type:varchar(50)"` // Promo code, unique among promotions that aren't deleted
+	Type        PromotionType  `json:"type" gorm:"type:varchar(50);not null;check:type IN ('buy_x_get_y', 'happy_hour', 'category_percent', 'percentage', 'fixed_amount')"`
+	ProductID   *string        `json:"product_id" gorm:"type:uuid"`  // Optional product scope
+	CategoryID  *string        `json:"category_id" gorm:"type:uuid"` // Optional category scope
+	Percentage  float64        `json:"percentage" gorm:"type:decimal(5,2);default:0;check:percentage >= 0 AND percentage <= 100"`
+	BuyQuantity int            `json:"buy_quantity" gorm:"default:0;check:buy_quantity >= 0"`
+	GetQuantity int            `json:"get_quantity" gorm:"default:0;check:get_quantity >= 0"`
+	StartTime   string         `json:"start_time" gorm:"type:varchar(5)"`    // Daily window start "HH:MM"
+	EndTime     string         `json:"end_time" gorm:"type:varchar(5)"`      // Daily window end "HH:MM"
+	DaysOfWeek  string         `json:"days_of_week" gorm:"type:varchar(20)"` // Comma separated, 0 = Sunday
+	StartsAt    *time.Time     `json:"starts_at"`
+	EndsAt      *time.Time     `json:"ends_at"`
+	Amount      int64          `json:"amount" gorm:"type:bigint;default:0;check:amount >= 0"`         // Rupiah off for fixed_amount promotions
+	MinBasket   int64          `json:"min_basket" gorm:"type:bigint;default:0;check:min_basket >= 0"` // Minimum subtotal before discounts for the promotion to apply
+	UsageLimit  int            `json:"usage_limit" gorm:"default:0;check:usage_limit >= 0"`           // Transactions a promo code can be used on, 0 for unlimited
+	Priority    int            `json:"priority" gorm:"default:0"`
+	Stackable   bool           `json:"stackable" gorm:"default:false"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+func (p *Promotion) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate checks that the fields required by the promotion type are present
+func (p *Promotion) Validate() error {
+	if p.Name == "" {
+		return errors.New("promotion name cannot be empty")
+	}
+
+	switch p.Type {
+	case PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return errors.New("buy_x_get_y promotion requires positive buy and get quantities")
+		}
+		if p.ProductID == nil && p.CategoryID == nil {
+			return errors.New("buy_x_get_y promotion requires a product or category")
+		}
+	case PromotionHappyHour:
+		if p.Percentage <= 0 {
+			return errors.New("happy_hour promotion requires a percentage")
+		}
+		if p.StartTime == "" || p.EndTime == "" {
+			return errors.New("happy_hour promotion requires start and end time")
+		}
+	case PromotionCategoryPercent:
+		if p.Percentage <= 0 {
+			return errors.New("category_percent promotion requires a percentage")
+		}
+		if p.CategoryID == nil {
+			return errors.New("category_percent promotion requires a category")
+		}
+	case PromotionPercentage:
+		if p.Percentage <= 0 {
+			return errors.New("percentage promotion requires a percentage")
+		}
+	case PromotionFixedAmount:
+		if p.Amount <= 0 {
+			return errors.New("fixed_amount promotion requires a positive amount")
+		}
+	default:
+		return errors.New("invalid promotion type")
+	}
+
+	if p.Code != nil {
+		code := NormalizePromoCode(*p.Code)
+		if code == "" {
+			p.Code = nil
+		} else {
+			p.Code = &code
+		}
+	}
+	if p.UsageLimit > 0 && p.Code == nil {
+		return errors.New("usage_limit requires a promo code")
+	}
+
+	if p.StartTime != "" {
+		if _, err := parseClock(p.StartTime); err != nil {
+			return errors.New("start_time must use HH:MM format")
+		}
+	}
+	if p.EndTime != "" {
+		if _, err := parseClock(p.EndTime); err != nil {
+			return errors.New("end_time must use HH:MM format")
+		}
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && p.EndsAt.Before(*p.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+// IsActiveAt reports whether the promotion can be applied at the given time
+func (p *Promotion) IsActiveAt(t time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && t.After(*p.EndsAt) {
+		return false
+	}
+
+	if p.DaysOfWeek != "" {
+		matched := false
+		for _, day := range strings.Split(p.DaysOfWeek, ",") {
+			if d, err := strconv.Atoi(strings.TrimSpace(day)); err == nil && time.Weekday(d) == t.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if p.StartTime != "" && p.EndTime != "" {
+		start, errStart := parseClock(p.StartTime)
+		end, errEnd := parseClock(p.EndTime)
+		if errStart != nil || errEnd != nil {
+			return false
+		}
+		now := t.Hour()*60 + t.Minute()
+		if start <= end {
+			return now >= start && now < end
+		}
+		// Window wraps around midnight, e.g. 22:00-02:00
+		return now >= start || now < end
+	}
+
+	return true
+}
+
+// RequiresCode reports whether the promotion only applies once its promo code is entered
+func (p *Promotion) RequiresCode() bool {
+	return p.Code != nil
+}
+
+// NormalizePromoCode makes promo codes case insensitive
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// AppliesTo reports whether a product falls within the promotion scope
+func (p *Promotion) AppliesTo(product *Product) bool {
+	if product == nil {
+		return false
+	}
+	if p.ProductID != nil && *p.ProductID != product.ID {
+		return false
+	}
+	if p.CategoryID != nil && *p.CategoryID != product.CategoryID {
+		return false
+	}
+	return true
+}
+
+// TransactionPromotion records a promotion applied to a transaction and its discount
+type TransactionPromotion struct {
+	ID             string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TransactionID  string    `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	PromotionID    string    `json:"promotion_id" gorm:"type:uuid;not null"`
+	Name           string    `json:"name" gorm:"not null"`
+	DiscountAmount int64     `json:"discount_amount" gorm:"type:bigint;not null;check:discount_amount >= 0"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (TransactionPromotion) TableName() string {
+	return "transaction_promotions"
+}
+
+func (tp *TransactionPromotion) BeforeCreate(tx *gorm.DB) (err error) {
+	if tp.ID == "" {
+		tp.ID = uuid.New().String()
+	}
+	return
+}
+
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
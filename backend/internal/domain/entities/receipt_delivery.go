@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReceiptChannel string
+
+const (
+	ReceiptChannelEmail    ReceiptChannel = "email"
+	ReceiptChannelWhatsApp ReceiptChannel = "whatsapp"
+)
+
+type ReceiptDeliveryStatus string
+
+const (
+	DeliveryPending ReceiptDeliveryStatus = "pending"
+	DeliverySent    ReceiptDeliveryStatus = "sent"
+	DeliveryFailed  ReceiptDeliveryStatus = "failed"
+)
+
+// ReceiptDelivery is a queued digital receipt for a settled transaction.
+// Failed sends stay pending and are retried with backoff until MaxAttempts is reached.
+type ReceiptDelivery struct {
+	ID            string                `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TransactionID string                `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	Channel       ReceiptChannel        `json:"channel" gorm:"type:varchar(20);not null;check:channel IN ('email', 'whatsapp')"`
+	Recipient     string                `json:"recipient" gorm:"type:varchar(255);not null"`
+	Status        ReceiptDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:pending;check:status IN ('pending', 'sent', 'failed')"`
+	Attempts      int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time             `json:"next_attempt_at" gorm:"not null"`
+	LastError     string                `json:"last_error"`
+	SentAt        *time.Time            `json:"sent_at"`
+	CreatedAt     time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ReceiptDelivery) TableName() string {
+	return "receipt_deliveries"
+}
+
+func (d *ReceiptDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewReceiptDelivery(transactionID string, channel ReceiptChannel, recipient string) *ReceiptDelivery {
+	return &ReceiptDelivery{
+		TransactionID: transactionID,
+		Channel:       channel,
+		Recipient:     recipient,
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+func (d *ReceiptDelivery) MarkAsSent() {
+	now := time.Now()
+	d.Status = DeliverySent
+	d.Attempts++
+	d.LastError = ""
+	d.SentAt = &now
+}
+
+// MarkAttemptFailed schedules a retry after backoff, doubling it on every attempt,
+// and gives up once maxAttempts is reached
+func (d *ReceiptDelivery) MarkAttemptFailed(err error, maxAttempts int, backoff time.Duration) {
+	d.Attempts++
+	d.LastError = err.Error()
+
+	if d.Attempts >= maxAttempts {
+		d.Status = DeliveryFailed
+		return
+	}
+	d.NextAttemptAt = time.Now().Add(backoff * time.Duration(1<<(d.Attempts-1)))
+}
+
+// MarkAsFailed gives up without further retries
+func (d *ReceiptDelivery) MarkAsFailed(err error) {
+	d.Attempts++
+	d.LastError = err.Error()
+	d.Status = DeliveryFailed
+}
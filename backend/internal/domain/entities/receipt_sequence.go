@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReceiptNumberPrefix starts every receipt number
+const ReceiptNumberPrefix = "INV"
+
+// ReceiptSequence is the last receipt number a store gave out on a business day. Numbers restart at 1
+// every day.
+type ReceiptSequence struct {
+	StoreID    string    `json:"store_id" gorm:"type:uuid;primaryKey"`
+	Day        time.Time `json:"day" gorm:"type:date;primaryKey"`
+	LastNumber int       `json:"last_number" gorm:"not null;default:0"`
+}
+
+func (ReceiptSequence) TableName() string {
+	return "receipt_sequences"
+}
+
+// FormatReceiptNumber writes the receipt number printed for the nth sale of a day, e.g. INV-20240521-0001
+func FormatReceiptNumber(day time.Time, number int) string {
+	return fmt.Sprintf("%s-%s-%04d", ReceiptNumberPrefix, day.Format("20060102"), number)
+}
+
+// NormalizeReceiptNumber cleans up a receipt number typed in from a paper receipt
+func NormalizeReceiptNumber(receiptNo string) string {
+	return strings.ToUpper(strings.TrimSpace(receiptNo))
+}
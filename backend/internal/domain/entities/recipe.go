@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecipeItem is an ingredient of a menu item: every unit of the product sold uses Quantity of the
+// ingredient, in the ingredient's unit. Ingredients are products too, so they are stocked and costed
+// like any other.
+type RecipeItem struct {
+	ID           string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ProductID    string    `json:"product_id" gorm:"type:uuid;not null;uniqueIndex:idx_recipe_items_ingredient"`
+	IngredientID string    `json:"ingredient_id" gorm:"type:uuid;not null;uniqueIndex:idx_recipe_items_ingredient;index"`
+	Quantity     float64   `json:"quantity" gorm:"type:decimal(12,3);not null;check:quantity > 0"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Relations
+	Ingredient Product `json:"ingredient,omitempty" gorm:"foreignKey:IngredientID"`
+}
+
+func (RecipeItem) TableName() string {
+	return "recipe_items"
+}
+
+func (r *RecipeItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+// ValidateRecipe checks the ingredients of a product, loaded with Ingredient. An ingredient can't be
+// used twice or be the product itself. Recipes don't nest, an ingredient is taken from its own stock
+// even when it has a recipe.
+func ValidateRecipe(productID string, items []RecipeItem) error {
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item.IngredientID == productID {
+			return errors.New("a product cannot be an ingredient of itself")
+		}
+		if seen[item.IngredientID] {
+			return errors.New("recipe ingredients must be different")
+		}
+		seen[item.IngredientID] = true
+
+		if err := item.Ingredient.ValidateQuantity(item.Quantity); err != nil {
+			return fmt.Errorf("ingredient %s: %s", item.Ingredient.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// RecipeCost is what the ingredients of one unit of a product cost at their current cost price,
+// rounded to a whole minor unit
+func RecipeCost(items []RecipeItem) int64 {
+	var cost float64
+	for _, item := range items {
+		cost += float64(item.Ingredient.CostPrice) * item.Quantity
+	}
+	return RoundAmount(cost)
+}
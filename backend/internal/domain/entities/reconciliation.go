@@ -0,0 +1,156 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReconciliationSource is where the settlements of a run come from
+type ReconciliationSource string
+
+const (
+	ReconciliationSourceMidtrans ReconciliationSource = "midtrans" // Pulled from the Midtrans status API
+	ReconciliationSourceUpload   ReconciliationSource = "upload"   // Settlement or disbursement CSV downloaded from the Midtrans dashboard
+)
+
+type ReconciliationStatus string
+
+const (
+	ReconciliationPending    ReconciliationStatus = "pending"
+	ReconciliationProcessing ReconciliationStatus = "processing"
+	ReconciliationCompleted  ReconciliationStatus = "completed"
+	ReconciliationFailed     ReconciliationStatus = "failed"
+)
+
+// ReconciliationResult is the outcome of matching a settlement to a local payment
+type ReconciliationResult string
+
+const (
+	ReconciliationMatched ReconciliationResult = "matched"
+	// ReconciliationAmountMismatch is settled for another amount than the payment recorded
+	ReconciliationAmountMismatch ReconciliationResult = "amount_mismatch"
+	// ReconciliationStatusMismatch is settled by Midtrans while the payment is not successful locally
+	ReconciliationStatusMismatch ReconciliationResult = "status_mismatch"
+	// ReconciliationMissingLocal is settled for an order ID no payment refers to
+	ReconciliationMissingLocal ReconciliationResult = "missing_local"
+	// ReconciliationMissingSettlement is a successful payment Midtrans has not settled
+	ReconciliationMissingSettlement ReconciliationResult = "missing_settlement"
+)
+
+// ReconciliationRun matches the Midtrans settlements of a period against the payments recorded by the
+// POS. Every payment or settlement of the period gets an item, the counters summarize them.
+type ReconciliationRun struct {
+	ID                string               `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Source            ReconciliationSource `json:"source" gorm:"type:varchar(20);not null;check:source IN ('midtrans', 'upload')"`
+	FileName          string               `json:"file_name,omitempty" gorm:"type:varchar(255)"` // Uploaded report
+	DateFrom          time.Time            `json:"date_from" gorm:"not null"`                    // Inclusive, payments are taken by the time they were created
+	DateTo            time.Time            `json:"date_to" gorm:"not null"`                      // Exclusive
+	Status            ReconciliationStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index;check:status IN ('pending', 'processing', 'completed', 'failed')"`
+	Matched           int                  `json:"matched" gorm:"not null;default:0"`
+	AmountMismatch    int                  `json:"amount_mismatch" gorm:"not null;default:0"`
+	StatusMismatch    int                  `json:"status_mismatch" gorm:"not null;default:0"`
+	MissingLocal      int                  `json:"missing_local" gorm:"not null;default:0"`
+	MissingSettlement int                  `json:"missing_settlement" gorm:"not null;default:0"`
+	SettledAmount     int64                `json:"settled_amount" gorm:"type:bigint;not null;default:0"`
+	FeeAmount         int64                `json:"fee_amount" gorm:"type:bigint;not null;default:0"` // Only known from disbursement reports
+	Error             string               `json:"error,omitempty"`
+	CreatedBy         string               `json:"created_by" gorm:"type:uuid;not null"`
+	StartedAt         *time.Time           `json:"started_at,omitempty"`
+	CompletedAt       *time.Time           `json:"completed_at,omitempty"`
+	CreatedAt         time.Time            `json:"created_at" gorm:"autoCreateTime;index"`
+	UpdatedAt         time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ReconciliationRun) TableName() string {
+	return "reconciliation_runs"
+}
+
+func (r *ReconciliationRun) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewReconciliationRun(source ReconciliationSource, fileName, createdBy string, dateFrom, dateTo time.Time) *ReconciliationRun {
+	return &ReconciliationRun{
+		ID:        uuid.New().String(),
+		Source:    source,
+		FileName:  fileName,
+		DateFrom:  dateFrom,
+		DateTo:    dateTo,
+		Status:    ReconciliationPending,
+		CreatedBy: createdBy,
+	}
+}
+
+// Discrepancies counts the items that need a look
+func (r *ReconciliationRun) Discrepancies() int {
+	return r.AmountMismatch + r.StatusMismatch + r.MissingLocal + r.MissingSettlement
+}
+
+// MarkAsCompleted counts the items of the run
+func (r *ReconciliationRun) MarkAsCompleted(items []ReconciliationItem) {
+	now := time.Now()
+	r.Matched, r.AmountMismatch, r.StatusMismatch, r.MissingLocal, r.MissingSettlement = 0, 0, 0, 0, 0
+	r.SettledAmount, r.FeeAmount = 0, 0
+	for _, item := range items {
+		switch item.Result {
+		case ReconciliationMatched:
+			r.Matched++
+		case ReconciliationAmountMismatch:
+			r.AmountMismatch++
+		case ReconciliationStatusMismatch:
+			r.StatusMismatch++
+		case ReconciliationMissingLocal:
+			r.MissingLocal++
+		case ReconciliationMissingSettlement:
+			r.MissingSettlement++
+		}
+		r.SettledAmount += item.SettledAmount
+		r.FeeAmount += item.FeeAmount
+	}
+	r.Status = ReconciliationCompleted
+	r.Error = ""
+	r.CompletedAt = &now
+}
+
+func (r *ReconciliationRun) MarkAsFailed(err error) {
+	now := time.Now()
+	r.Status = ReconciliationFailed
+	r.Error = err.Error()
+	r.CompletedAt = &now
+}
+
+// ReconciliationItem is a payment or settlement of a run and how they matched
+type ReconciliationItem struct {
+	ID               string               `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RunID            string               `json:"run_id" gorm:"type:uuid;not null;index:idx_reconciliation_items_run,priority:1"`
+	OrderID          string               `json:"order_id" gorm:"type:varchar(255);not null"`
+	PaymentID        *string              `json:"payment_id,omitempty" gorm:"type:uuid"` // Empty when no payment has the order ID
+	TransactionID    *string              `json:"transaction_id,omitempty" gorm:"type:uuid"`
+	StoreID          *string              `json:"store_id,omitempty" gorm:"type:uuid"`
+	Result           ReconciliationResult `json:"result" gorm:"type:varchar(30);not null;index:idx_reconciliation_items_run,priority:2;check:result IN ('matched', 'amount_mismatch', 'status_mismatch', 'missing_local', 'missing_settlement')"`
+	LocalStatus      PaymentStatus        `json:"local_status,omitempty" gorm:"type:varchar(50)"`
+	LocalAmount      int64                `json:"local_amount" gorm:"type:bigint;not null;default:0"`
+	SettlementStatus string               `json:"settlement_status,omitempty" gorm:"type:varchar(50)"` // Midtrans transaction_status
+	SettledAmount    int64                `json:"settled_amount" gorm:"type:bigint;not null;default:0"`
+	FeeAmount        int64                `json:"fee_amount" gorm:"type:bigint;not null;default:0"`
+	ExternalID       string               `json:"external_id,omitempty" gorm:"type:varchar(255)"` // Midtrans transaction ID
+	SettledAt        *time.Time           `json:"settled_at,omitempty"`
+	Note             string               `json:"note,omitempty"`
+	CreatedAt        time.Time            `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ReconciliationItem) TableName() string {
+	return "reconciliation_items"
+}
+
+func (i *ReconciliationItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
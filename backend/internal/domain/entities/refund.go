@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Refund records money returned to the customer for a settled payment
+type Refund struct {
+	ID                    string       `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PaymentID             string       `json:"payment_id" gorm:"type:uuid;not null;index"`
+	TransactionID         string       `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	Amount                int64        `json:"amount" gorm:"type:bigint;not null;check:amount > 0"`
+	Reason                string       `json:"reason"`
+	RefundKey             string       `json:"refund_key" gorm:"type:varchar(255);uniqueIndex"` // Idempotency key sent to Midtrans
+	ExternalResponse      string       `json:"external_response"`
+	RefundedBy            string       `json:"refunded_by" gorm:"type:uuid;not null"`
+	ExchangeTransactionID *string      `json:"exchange_transaction_id,omitempty" gorm:"type:uuid"`    // Replacement sale when the items were exchanged
+	CreditedAmount        int64        `json:"credited_amount" gorm:"type:bigint;not null;default:0"` // Part of Amount applied to the replacement sale instead of paid back
+	CreatedAt             time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	Items                 []RefundItem `json:"items,omitempty" gorm:"foreignKey:RefundID"`
+}
+
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+func (r *Refund) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+// RefundItem is a returned product whose quantity goes back to stock
+type RefundItem struct {
+	ID        string  `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	RefundID  string  `json:"refund_id" gorm:"type:uuid;not null;index"`
+	ProductID string  `json:"product_id" gorm:"type:uuid;not null"`
+	Quantity  float64 `json:"quantity" gorm:"type:decimal(12,3);not null;check:quantity > 0"`
+	Amount    int64   `json:"amount" gorm:"type:bigint;not null;check:amount >= 0"`
+	Restock   float64 `json:"-" gorm:"-"` // Part of the quantity going back to stock
+}
+
+func (RefundItem) TableName() string {
+	return "refund_items"
+}
+
+func (ri *RefundItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if ri.ID == "" {
+		ri.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,136 @@
+package entities
+
+import (
+	"errors"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReportFrequency string
+
+const (
+	ReportDaily  ReportFrequency = "daily"  // Covers the previous business day
+	ReportWeekly ReportFrequency = "weekly" // Covers the seven days before the day it is sent
+)
+
+type ReportFormat string
+
+const (
+	ReportFormatPDF ReportFormat = "pdf"
+	ReportFormatCSV ReportFormat = "csv"
+)
+
+// maxReportRecipients keeps a schedule from turning into a mailing list
+const maxReportRecipients = 20
+
+// ReportSchedule emails a sales summary to the owners on a fixed day and hour of the business timezone
+type ReportSchedule struct {
+	ID         string          `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID   *string         `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	StoreID    *string         `json:"store_id,omitempty" gorm:"type:uuid;index"` // Sales of every store when empty
+	Name       string          `json:"name" gorm:"type:varchar(100);not null"`
+	Frequency  ReportFrequency `json:"frequency" gorm:"type:varchar(10);not null;check:frequency IN ('daily', 'weekly')"`
+	Weekday    int             `json:"weekday" gorm:"not null;default:1;check:weekday BETWEEN 0 AND 6"` // Day a weekly report is sent, 0 = Sunday
+	Hour       int             `json:"hour" gorm:"not null;default:7;check:hour BETWEEN 0 AND 23"`      // Hour the report is sent
+	Format     ReportFormat    `json:"format" gorm:"type:varchar(10);not null;default:'pdf';check:format IN ('pdf', 'csv')"`
+	Recipients string          `json:"recipients" gorm:"type:text;not null"` // Comma separated email addresses
+	IsActive   bool            `json:"is_active" gorm:"default:true"`
+	NextRunAt  time.Time       `json:"next_run_at" gorm:"not null;index"`
+	LastRunAt  *time.Time      `json:"last_run_at"`
+	LastError  string          `json:"last_error,omitempty"` // Why the last report could not be sent to everyone
+	CreatedBy  string          `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt  gorm.DeletedAt  `json:"-" gorm:"index"`
+}
+
+func (ReportSchedule) TableName() string {
+	return "report_schedules"
+}
+
+func (s *ReportSchedule) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
+func (s *ReportSchedule) Validate() error {
+	if s.Name == "" {
+		return errors.New("report schedule name cannot be empty")
+	}
+	if s.Frequency != ReportDaily && s.Frequency != ReportWeekly {
+		return errors.New("frequency must be daily or weekly")
+	}
+	if s.Weekday < 0 || s.Weekday > 6 {
+		return errors.New("weekday must be from 0 (Sunday) to 6")
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return errors.New("hour must be from 0 to 23")
+	}
+	if s.Format != ReportFormatPDF && s.Format != ReportFormatCSV {
+		return errors.New("format must be pdf or csv")
+	}
+
+	recipients := s.RecipientList()
+	if len(recipients) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	if len(recipients) > maxReportRecipients {
+		return errors.New("a report can be sent to at most 20 recipients")
+	}
+	for _, recipient := range recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return errors.New("invalid recipient email: " + recipient)
+		}
+	}
+	return nil
+}
+
+// RecipientList returns the addresses the report is sent to
+func (s *ReportSchedule) RecipientList() []string {
+	var list []string
+	for _, recipient := range strings.Split(s.Recipients, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			list = append(list, recipient)
+		}
+	}
+	return list
+}
+
+func (s *ReportSchedule) SetRecipients(recipients []string) {
+	list := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		recipient = strings.ToLower(strings.TrimSpace(recipient))
+		if recipient != "" && !slices.Contains(list, recipient) {
+			list = append(list, recipient)
+		}
+	}
+	s.Recipients = strings.Join(list, ",")
+}
+
+// NextRun returns the first time after the given one the report is due, in the timezone of loc
+func (s *ReportSchedule) NextRun(after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, 0, 0, 0, loc)
+	for !next.After(local) || (s.Frequency == ReportWeekly && int(next.Weekday()) != s.Weekday) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Period returns the days a report sent at the given time covers, from the start of the first day to
+// the start of the day it is sent
+func (s *ReportSchedule) Period(sentAt time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := sentAt.In(loc)
+	to := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if s.Frequency == ReportWeekly {
+		return to.AddDate(0, 0, -7), to
+	}
+	return to.AddDate(0, 0, -1), to
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// Keys of the runtime settings merchants change without a redeploy
+const (
+	SettingStoreName         = "store_name"          // Merchant name on receipts until the merchant is onboarded
+	SettingStoreLogoURL      = "store_logo_url"      // Logo shown by the terminals and customer display
+	SettingReceiptFooter     = "receipt_footer"      // Closing text printed under every receipt
+	SettingQRISExpiryMinutes = "qris_expiry_minutes" // Lifetime of a QRIS when the request doesn't give one
+)
+
+// Setting is a business setting stored in the database, overriding the configured default of its key
+type Setting struct {
+	Key       string    `json:"key" gorm:"type:varchar(50);primaryKey"`
+	Value     string    `json:"value" gorm:"type:text;not null"`
+	UpdatedBy *string   `json:"updated_by,omitempty" gorm:"type:uuid"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Setting) TableName() string {
+	return "settings"
+}
@@ -0,0 +1,147 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ShiftStatus string
+
+const (
+	ShiftOpen            ShiftStatus = "open"
+	ShiftPendingApproval ShiftStatus = "pending_approval" // Closed with a cash variance a manager has yet to approve
+	ShiftClosed          ShiftStatus = "closed"
+)
+
+// Shift is a cashier's working session at the till. Every sale made while it is open attaches to it,
+// and closing it freezes the Z-report totals.
+type Shift struct {
+	ID               string      `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID         *string     `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	UserID           string      `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_shifts_user_open,where:status = 'open'"`
+	Status           ShiftStatus `json:"status" gorm:"type:varchar(20);not null;default:open;check:status IN ('open', 'pending_approval', 'closed')"`
+	OpeningFloat     int64       `json:"opening_float" gorm:"type:bigint;not null;default:0;check:opening_float >= 0"`
+	CashSales        int64       `json:"cash_sales" gorm:"type:bigint;not null;default:0"`
+	CashRefunds      int64       `json:"cash_refunds" gorm:"type:bigint;not null;default:0"`
+	CashPaidIn       int64       `json:"cash_paid_in" gorm:"type:bigint;not null;default:0"`
+	CashPaidOut      int64       `json:"cash_paid_out" gorm:"type:bigint;not null;default:0"`
+	CashDropped      int64       `json:"cash_dropped" gorm:"type:bigint;not null;default:0"` // Taken to the safe during the shift
+	DrawerOpens      int         `json:"drawer_opens" gorm:"not null;default:0"`             // Times the drawer was opened without a sale
+	QRISSales        int64       `json:"qris_sales" gorm:"column:qris_sales;type:bigint;not null;default:0"`
+	CardSales        int64       `json:"card_sales" gorm:"type:bigint;not null;default:0"`
+	OtherSales       int64       `json:"other_sales" gorm:"type:bigint;not null;default:0"`
+	TransactionCount int         `json:"transaction_count" gorm:"not null;default:0"`
+	ExpectedCash     int64       `json:"expected_cash" gorm:"type:bigint;not null;default:0"`
+	CountedCash      *int64      `json:"counted_cash" gorm:"type:bigint"`
+	CashDifference   int64       `json:"cash_difference" gorm:"type:bigint;not null;default:0"` // Counted minus expected, negative when cash is short
+	OpeningNote      string      `json:"opening_note"`
+	ClosingNote      string      `json:"closing_note"`
+	HandoverNote     string      `json:"handover_note"` // Left for whoever takes over the till next
+	OpenedAt         time.Time   `json:"opened_at" gorm:"not null"`
+	ClosedAt         *time.Time  `json:"closed_at"`
+	ApprovedBy       *string     `json:"approved_by" gorm:"type:uuid"` // Manager who accepted the cash variance
+	ApprovedAt       *time.Time  `json:"approved_at"`
+	ApprovalNote     string      `json:"approval_note"`
+	CreatedAt        time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// Relations
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (Shift) TableName() string {
+	return "shifts"
+}
+
+func (s *Shift) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewShift(userID string, openingFloat int64, note string) (*Shift, error) {
+	if openingFloat < 0 {
+		return nil, errors.New("opening float cannot be negative")
+	}
+
+	return &Shift{
+		UserID:       userID,
+		Status:       ShiftOpen,
+		OpeningFloat: openingFloat,
+		ExpectedCash: openingFloat,
+		OpeningNote:  note,
+		OpenedAt:     time.Now(),
+	}, nil
+}
+
+// ApplyTotals sets the sales totals and the cash the drawer should hold
+func (s *Shift) ApplyTotals(totals ShiftTotals) {
+	s.CashSales = totals.CashSales
+	s.CashRefunds = totals.CashRefunds
+	s.QRISSales = totals.QRISSales
+	s.CardSales = totals.CardSales
+	s.OtherSales = totals.OtherSales
+	s.TransactionCount = totals.TransactionCount
+	s.CashPaidIn = totals.CashPaidIn
+	s.CashPaidOut = totals.CashPaidOut
+	s.CashDropped = totals.CashDropped
+	s.DrawerOpens = totals.DrawerOpens
+	s.ExpectedCash = s.OpeningFloat + totals.CashSales - totals.CashRefunds + totals.CashPaidIn - totals.CashPaidOut - totals.CashDropped
+}
+
+// Close freezes the shift with the cash counted in the drawer. A cash difference larger than
+// varianceThreshold either way leaves the shift pending a manager's approval; 0 disables the check.
+func (s *Shift) Close(countedCash int64, closingNote, handoverNote string, varianceThreshold int64) error {
+	if s.Status != ShiftOpen {
+		return errors.New("shift is already closed")
+	}
+	if countedCash < 0 {
+		return errors.New("counted cash cannot be negative")
+	}
+
+	now := time.Now()
+	s.Status = ShiftClosed
+	s.CountedCash = &countedCash
+	s.CashDifference = countedCash - s.ExpectedCash
+	s.ClosingNote = closingNote
+	s.HandoverNote = handoverNote
+	s.ClosedAt = &now
+
+	if varianceThreshold > 0 && (s.CashDifference > varianceThreshold || s.CashDifference < -varianceThreshold) {
+		s.Status = ShiftPendingApproval
+	}
+	return nil
+}
+
+// ApproveVariance accepts the cash variance of a shift closed pending approval
+func (s *Shift) ApproveVariance(approverID, note string) error {
+	if s.Status != ShiftPendingApproval {
+		return errors.New("shift is not awaiting variance approval")
+	}
+
+	now := time.Now()
+	s.Status = ShiftClosed
+	s.ApprovedBy = &approverID
+	s.ApprovedAt = &now
+	s.ApprovalNote = note
+	return nil
+}
+
+// ShiftTotals are the payments settled during a shift, per method, and the cash moved in and out of
+// the drawer outside sales
+type ShiftTotals struct {
+	CashSales        int64
+	CashRefunds      int64
+	QRISSales        int64
+	CardSales        int64
+	OtherSales       int64
+	TransactionCount int
+	CashPaidIn       int64
+	CashPaidOut      int64
+	CashDropped      int64
+	DrawerOpens      int
+}
@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Store is an outlet of the merchant. Products, their stock, cashiers, transactions and payments
+// belong to one store.
+type Store struct {
+	ID           string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID     *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"` // Tenant owning the store in SaaS mode, empty otherwise
+	Code         string         `json:"code" gorm:"type:varchar(20);not null"`      // Short uppercase name unique within the tenant, e.g. "JKT01"
+	Name         string         `json:"name" gorm:"type:varchar(255);not null"`     // Printed on receipts instead of the configured store name
+	Address      string         `json:"address"`
+	Phone        string         `json:"phone" gorm:"type:varchar(20)"`
+	TaxID        string         `json:"tax_id" gorm:"type:varchar(25)"`                         // NPWP of the outlet, the configured one when empty
+	Currency     Currency       `json:"currency" gorm:"type:varchar(3);not null;default:'IDR'"` // Currency the store sells in, QRIS and e-wallets only take IDR
+	IsActive     bool           `json:"is_active" gorm:"default:true"`
+	TrainingMode bool           `json:"training_mode" gorm:"default:false"` // New sales are practice sales, see Transaction.IsTraining
+	ChargeRules                 // Service charge and cash rounding of new sales
+	CreatedAt    time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Store) TableName() string {
+	return "stores"
+}
+
+func (s *Store) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate normalizes the code and checks the store is complete
+func (s *Store) Validate() error {
+	s.Code = strings.ToUpper(strings.TrimSpace(s.Code))
+	if s.Code == "" {
+		return errors.New("store code is required")
+	}
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return errors.New("store name is required")
+	}
+	s.TaxID = strings.TrimSpace(s.TaxID)
+	currency, err := ParseCurrency(string(s.Currency))
+	if err != nil {
+		return err
+	}
+	s.Currency = currency
+	return s.ChargeRules.Validate()
+}
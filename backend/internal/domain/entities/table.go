@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Table is a dining table of a store. A dine-in order opened on a table stays open while the guests
+// keep ordering and is settled when they leave, which frees the table.
+type Table struct {
+	ID        string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID  *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	StoreID   string         `json:"store_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_tables_store_name,where:deleted_at IS NULL"`
+	Name      string         `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_tables_store_name,where:deleted_at IS NULL"` // Unique within a store, e.g. "T5"
+	Area      string         `json:"area" gorm:"type:varchar(50)"`                                                                     // Floor plan section, e.g. "Terrace"
+	Seats     int            `json:"seats" gorm:"not null;default:0;check:seats >= 0"`
+	SortOrder int            `json:"sort_order" gorm:"not null;default:0"` // Position on the floor plan within its area
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Table) TableName() string {
+	return "tables"
+}
+
+func (t *Table) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate checks the table can be shown on the floor plan
+func (t *Table) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Area = strings.TrimSpace(t.Area)
+	if t.Name == "" {
+		return errors.New("table name is required")
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TaxRule is a tax the merchant charges, such as PPN. Every active rule applies to each item that
+// isn't exempt, either by its product's tax class or by being in one of the rule's exempt categories.
+type TaxRule struct {
+	ID         string             `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID   *string            `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	Name       string             `json:"name" gorm:"type:varchar(100);not null"`                                // Printed on receipts, e.g. "PPN"
+	Rate       float64            `json:"rate" gorm:"type:decimal(5,2);not null;check:rate > 0 AND rate <= 100"` // Percentage
+	Inclusive  bool               `json:"inclusive" gorm:"default:false"`                                        // Prices already include the tax, so it is shown but not added to the total
+	IsActive   bool               `json:"is_active" gorm:"default:true"`
+	CreatedAt  time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt  gorm.DeletedAt     `json:"-" gorm:"index"`
+	Exemptions []TaxRuleExemption `json:"exemptions" gorm:"foreignKey:TaxRuleID"`
+}
+
+func (TaxRule) TableName() string {
+	return "tax_rules"
+}
+
+func (r *TaxRule) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+// TaxRuleExemption exempts the products of a category from a tax rule
+type TaxRuleExemption struct {
+	ID         string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TaxRuleID  string    `json:"tax_rule_id" gorm:"type:uuid;not null;index"`
+	CategoryID string    `json:"category_id" gorm:"type:uuid;not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (TaxRuleExemption) TableName() string {
+	return "tax_rule_exemptions"
+}
+
+func (e *TaxRuleExemption) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate checks the rule is complete
+func (r *TaxRule) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("tax rule name is required")
+	}
+	if r.Rate <= 0 || r.Rate > 100 {
+		return errors.New("tax rate must be above 0 and at most 100 percent")
+	}
+	return nil
+}
+
+// Exempts reports whether an item is left out of the rule. The item's product must be loaded for
+// category exemptions to be seen.
+func (r *TaxRule) Exempts(item TransactionItem) bool {
+	if item.TaxClass == TaxClassExempt {
+		return true
+	}
+	for _, exemption := range r.Exemptions {
+		if exemption.CategoryID == item.Product.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// TaxOn returns the tax on a taxable amount. For an inclusive rule the amount already contains the tax.
+func (r *TaxRule) TaxOn(amount int64) int64 {
+	if r.Inclusive {
+		return PercentIncluded(amount, r.Rate)
+	}
+	return PercentOf(amount, r.Rate)
+}
+
+// TransactionTax is a tax rule as applied to a transaction, kept so receipts show the rate that was charged
+type TransactionTax struct {
+	ID            string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TransactionID string    `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	TaxRuleID     string    `json:"tax_rule_id" gorm:"type:uuid;not null"`
+	Name          string    `json:"name" gorm:"type:varchar(100);not null"`
+	Rate          float64   `json:"rate" gorm:"type:decimal(5,2);not null"`
+	Inclusive     bool      `json:"inclusive" gorm:"default:false"`
+	TaxableAmount int64     `json:"taxable_amount" gorm:"type:bigint;not null;check:taxable_amount >= 0"`
+	Amount        int64     `json:"amount" gorm:"type:bigint;not null;check:amount >= 0"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (TransactionTax) TableName() string {
+	return "transaction_taxes"
+}
+
+func (tt *TransactionTax) BeforeCreate(tx *gorm.DB) (err error) {
+	if tt.ID == "" {
+		tt.ID = uuid.New().String()
+	}
+	return
+}
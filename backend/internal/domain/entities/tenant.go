@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{1,61}[a-z0-9])?$`)
+
+// Tenant is a merchant served by the deployment in SaaS mode. Its stores, users, catalog and sales carry
+// its ID in tenant_id, and requests made in the tenant only ever see those.
+type Tenant struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Slug      string    `json:"slug" gorm:"type:varchar(63);not null;uniqueIndex"` // Subdomain the tenant is reached on, e.g. "kopi"
+	Name      string    `json:"name" gorm:"type:varchar(255);not null"`
+	IsActive  bool      `json:"is_active" gorm:"default:true"` // Requests to a suspended tenant are refused
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (Tenant) TableName() string {
+	return "tenants"
+}
+
+func (t *Tenant) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return
+}
+
+// Validate normalizes the slug and checks the tenant is complete. Slugs are DNS labels, so every tenant
+// can have a subdomain.
+func (t *Tenant) Validate() error {
+	t.Slug = strings.ToLower(strings.TrimSpace(t.Slug))
+	if !tenantSlugPattern.MatchString(t.Slug) {
+		return errors.New("tenant slug must be 1 to 63 lowercase letters, digits or hyphens, not starting or ending with a hyphen")
+	}
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return errors.New("tenant name is required")
+	}
+	return nil
+}
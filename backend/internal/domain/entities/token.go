@@ -0,0 +1,211 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is an opaque, long lived token traded for a new access token. Only its hash is stored,
+// and it is rotated on every use: the token is revoked and points to the one issued in its place. The
+// tokens rotated from one login make up a session, whose usable token describes the device using it.
+type RefreshToken struct {
+	ID            string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID        string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	SessionID     string     `json:"session_id" gorm:"type:uuid;index"`              // ID of the login's first token, empty on tokens issued before sessions
+	TokenHash     string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"` // SHA-256 of the token handed to the client
+	AccessTokenID string     `json:"-" gorm:"type:varchar(64)"`                      // jti of the access token issued along with it
+	UserAgent     string     `json:"user_agent,omitempty" gorm:"type:varchar(255)"`
+	IPAddress     string     `json:"ip_address,omitempty" gorm:"type:varchar(45)"`
+	LoggedInAt    time.Time  `json:"logged_in_at"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"` // Last request with the session's access token, to the minute
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"not null;index"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID  *string    `json:"replaced_by_id,omitempty" gorm:"type:uuid"` // Token issued when this one was rotated
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return
+}
+
+// NewRefreshToken issues a refresh token for a user. The returned string is the token to hand to the
+// client, it can't be recovered from what is stored.
+func NewRefreshToken(userID string, ttl time.Duration) (*RefreshToken, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(secret)
+
+	id := uuid.New().String()
+	now := time.Now()
+	return &RefreshToken{
+		ID:         id,
+		UserID:     userID,
+		SessionID:  id,
+		TokenHash:  HashRefreshToken(token),
+		LoggedInAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}, token, nil
+}
+
+// ContinueSession makes the token the next one of the session of the token it replaces
+func (t *RefreshToken) ContinueSession(previous *RefreshToken) {
+	t.SessionID = previous.Session()
+	t.LoggedInAt = previous.SessionStartedAt()
+	t.LastUsedAt = previous.LastUsedAt
+}
+
+// UsedFrom records the device the session is used from
+func (t *RefreshToken) UsedFrom(userAgent, ipAddress string) {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	t.UserAgent = userAgent
+	t.IPAddress = ipAddress
+}
+
+// Session returns the session the token belongs to, tokens issued before sessions are one of their own
+func (t *RefreshToken) Session() string {
+	if t.SessionID == "" {
+		return t.ID
+	}
+	return t.SessionID
+}
+
+func (t *RefreshToken) SessionStartedAt() time.Time {
+	if t.LoggedInAt.IsZero() {
+		return t.CreatedAt
+	}
+	return t.LoggedInAt
+}
+
+// LastActiveAt is when the session was last used, refreshed or logged in
+func (t *RefreshToken) LastActiveAt() time.Time {
+	if t.LastUsedAt != nil && t.LastUsedAt.After(t.CreatedAt) {
+		return *t.LastUsedAt
+	}
+	return t.CreatedAt
+}
+
+// HashRefreshToken returns the hash a refresh token is looked up by
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// RevokedToken blacklists an access token by its jti until it expires, after which the row can be purged
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"type:varchar(64);primaryKey"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// PasswordReset is a forgot password request. A short code is emailed to the user, who trades it for a
+// reset token to set a new password with. Only hashes of both are stored, and a request is spent once
+// the password is set, too many wrong codes were tried or a newer request was made.
+type PasswordReset struct {
+	ID         string     `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	UserID     string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash   string     `json:"-" gorm:"type:varchar(64);not null"`
+	TokenHash  *string    `json:"-" gorm:"type:varchar(64);uniqueIndex"` // Set once the code is verified
+	Attempts   int        `json:"attempts" gorm:"not null;default:0"`    // Codes tried
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null;index"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PasswordReset) TableName() string {
+	return "password_resets"
+}
+
+func (r *PasswordReset) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+// NewPasswordReset starts a password reset for a user. The returned string is the six digit code to
+// email, it can't be recovered from what is stored.
+func NewPasswordReset(userID string, ttl time.Duration) (*PasswordReset, string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	reset := &PasswordReset{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	reset.CodeHash = reset.hashCode(code)
+	return reset, code, nil
+}
+
+// hashCode salts the code with the request, the few possible codes would be trivial to look up otherwise
+func (r *PasswordReset) hashCode(code string) string {
+	sum := sha256.Sum256([]byte(r.ID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsUsable reports whether the reset can still be verified or completed
+func (r *PasswordReset) IsUsable() bool {
+	return r.UsedAt == nil && time.Now().Before(r.ExpiresAt)
+}
+
+// CheckCode verifies an emailed code. Tries are counted by the repository before the code is checked, so
+// concurrent guesses can't get past the limit.
+func (r *PasswordReset) CheckCode(code string) bool {
+	if !r.IsUsable() || r.VerifiedAt != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.hashCode(code)), []byte(r.CodeHash)) == 1
+}
+
+// Verify issues the reset token for a verified code, valid for ttl. The returned string is the token to
+// hand to the client.
+func (r *PasswordReset) Verify(ttl time.Duration) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(secret)
+
+	now := time.Now()
+	hash := HashRefreshToken(token)
+	r.TokenHash = &hash
+	r.VerifiedAt = &now
+	r.ExpiresAt = now.Add(ttl)
+	return token, nil
+}
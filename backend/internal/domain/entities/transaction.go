@@ -2,6 +2,8 @@ package entities
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 	"gorm.io/gorm"
 	"github.com/google/uuid"
@@ -10,29 +12,110 @@ import (
 type TransactionStatus string
 
 const (
-	StatusPending   TransactionStatus = "pending"
-	StatusPaid      TransactionStatus = "paid" 
-	StatusCancelled TransactionStatus = "cancelled"
-	StatusExpired   TransactionStatus = "expired"
+	StatusPending           TransactionStatus = "pending"
+	StatusPaid              TransactionStatus = "paid"
+	StatusCancelled         TransactionStatus = "cancelled"
+	StatusExpired           TransactionStatus = "expired"
+	StatusRefunded          TransactionStatus = "refunded"
+	StatusPartiallyRefunded TransactionStatus = "partially_refunded"
 )
 
+// OrderType is how the customer receives the order
+type OrderType string
+
+const (
+	OrderDineIn   OrderType = "dine_in"
+	OrderPickup   OrderType = "pickup"
+	OrderDelivery OrderType = "delivery"
+)
+
+// FulfillmentStatus is the kitchen progress of a paid order
+type FulfillmentStatus string
+
+const (
+	FulfillmentPreparing FulfillmentStatus = "preparing"
+	FulfillmentReady     FulfillmentStatus = "ready"
+	FulfillmentCompleted FulfillmentStatus = "completed"
+)
+
+// ItemPrepStatus is the kitchen progress of a single item of an order
+type ItemPrepStatus string
+
+const (
+	ItemQueued    ItemPrepStatus = "queued"
+	ItemPreparing ItemPrepStatus = "preparing"
+	ItemReady     ItemPrepStatus = "ready"
+)
+
+// itemPrepSteps orders the item statuses, an item only moves forward through them
+var itemPrepSteps = map[ItemPrepStatus]int{
+	ItemQueued:    0,
+	ItemPreparing: 1,
+	ItemReady:     2,
+}
+
+// fulfillmentSteps orders the kitchen statuses, an order only moves forward through them
+var fulfillmentSteps = map[FulfillmentStatus]int{
+	FulfillmentPreparing: 1,
+	FulfillmentReady:     2,
+	FulfillmentCompleted: 3,
+}
+
 type Transaction struct {
 	ID          string            `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID    *string           `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	StoreID     string            `json:"store_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_transactions_receipt_no,priority:1,where:receipt_no <> ''"` // Store the sale was made in
 	UserID      string            `json:"user_id" gorm:"type:uuid;not null"`
-	TotalAmount float64           `json:"total_amount" gorm:"type:decimal(10,2);not null;check:total_amount >= 0"`
-	TaxAmount   float64           `json:"tax_amount" gorm:"type:decimal(10,2);default:0;check:tax_amount >= 0"`
-	Discount    float64           `json:"discount" gorm:"type:decimal(10,2);default:0;check:discount >= 0"`
-	Status      TransactionStatus `json:"status" gorm:"type:varchar(50);not null;check:status IN ('pending', 'paid', 'cancelled', 'expired')"`
+	ReceiptNo   string            `json:"receipt_no,omitempty" gorm:"type:varchar(30);uniqueIndex:idx_transactions_receipt_no,priority:2"` // Printed number of the sale, sequential per store and business day; empty for practice sales
+	ShiftID     *string           `json:"shift_id" gorm:"type:uuid;index"` // Cashier shift the sale was made in
+	Currency    Currency          `json:"currency" gorm:"type:varchar(3);not null;default:'IDR'"` // Currency of the store, every amount of the sale is in its minor unit
+	TotalAmount int64             `json:"total_amount" gorm:"type:bigint;not null;check:total_amount >= 0"`
+	TaxAmount   int64             `json:"tax_amount" gorm:"type:bigint;default:0;check:tax_amount >= 0"`
+	IncludedTax int64             `json:"included_tax" gorm:"type:bigint;default:0;check:included_tax >= 0"` // Tax of inclusive tax rules, already in the prices so not added to the total
+	Discount    int64             `json:"discount" gorm:"type:bigint;default:0;check:discount >= 0"`
+	ManualDiscount ManualDiscount `json:"manual_discount" gorm:"embedded;embeddedPrefix:manual_discount_"` // Discount on the whole cart given by hand, included in Discount
+	ChargeRules                   // Service charge and cash rounding of the store when the sale was made
+	ServiceCharge int64           `json:"service_charge" gorm:"type:bigint;not null;default:0;check:service_charge >= 0"`
+	Rounding      int64           `json:"rounding" gorm:"type:bigint;not null;default:0"` // Added to round the total to the cash increment, negative when rounded down
+	Status      TransactionStatus `json:"status" gorm:"type:varchar(50);not null;check:status IN ('pending', 'paid', 'cancelled', 'expired', 'refunded', 'partially_refunded')"`
 	Notes       string            `json:"notes"`
+	CustomerEmail string          `json:"customer_email,omitempty"` // Digital receipt recipient captured at checkout
+	CustomerPhone string          `json:"customer_phone,omitempty"` // WhatsApp number for the digital receipt
+	StockReserved bool            `json:"stock_reserved" gorm:"default:false"` // Product stock already deducted for items
+	IsTraining    bool            `json:"is_training" gorm:"default:false;index"` // Practice sale of a store in training mode, never charged, reported or stocked
+	RefundedAmount int64          `json:"refunded_amount" gorm:"type:bigint;default:0;check:refunded_amount >= 0"`
+	ExchangeCredit int64          `json:"exchange_credit" gorm:"type:bigint;default:0;check:exchange_credit >= 0"` // Value of items returned in an exchange, deducted from the total
+	CostOfGoods   int64           `json:"-" gorm:"type:bigint;not null;default:0"` // What the items cost, ingredients of recipes included, set once the sale is costed
+	CostedAt      *time.Time      `json:"-" gorm:"index"` // Set once the sale is costed and the ingredients of its items deducted
+	PromoCode     *string         `json:"promo_code,omitempty" gorm:"type:varchar(50);index"` // Promo code entered by the customer, its promotion is evaluated with the automatic ones
+	CustomerID    *string         `json:"customer_id,omitempty" gorm:"type:uuid;index"` // Loyalty member the sale earns points for
+	PointsRedeemed int64          `json:"points_redeemed" gorm:"type:bigint;default:0;check:points_redeemed >= 0"` // Held from the customer's balance until the transaction is paid
+	PointsDiscount int64          `json:"points_discount" gorm:"type:bigint;default:0;check:points_discount >= 0"` // Value of the redeemed points, deducted from the total
+	OrderType     OrderType       `json:"order_type" gorm:"type:varchar(20);not null;default:'dine_in'"`
+	TrackingToken *string         `json:"-" gorm:"type:varchar(64);uniqueIndex"` // Secret of the public order status page, pickup and delivery only
+	FulfillmentStatus FulfillmentStatus `json:"fulfillment_status,omitempty" gorm:"type:varchar(20)"` // Empty until the kitchen starts the order
+	PreparingAt   *time.Time      `json:"preparing_at,omitempty"`
+	ReadyAt       *time.Time      `json:"ready_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+	HoldLabel     string          `json:"hold_label,omitempty" gorm:"type:varchar(100)"` // Name the cart was parked under, e.g. "Table 5"
+	HeldAt        *time.Time      `json:"held_at,omitempty" gorm:"index"` // Set while the cart is parked, parked carts never expire
+	TableID       *string         `json:"table_id,omitempty" gorm:"type:uuid;index"` // Dine-in table the order is open on, kept after it is settled
 	CreatedAt   time.Time         `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt   gorm.DeletedAt    `json:"-" gorm:"index"`
 	
 	// Relations
+	Store    *Store            `json:"store,omitempty" gorm:"foreignKey:StoreID"`
 	User     User              `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	Items    []TransactionItem `json:"items,omitempty" gorm:"foreignKey:TransactionID"`
 	Payment  *Payment          `json:"payment,omitempty" gorm:"foreignKey:TransactionID"`
 	QRCode   *QRISCode         `json:"qr_code,omitempty" gorm:"foreignKey:TransactionID"`
+	Promotions []TransactionPromotion `json:"promotions,omitempty" gorm:"foreignKey:TransactionID"`
+	Customer *Customer         `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+	Table    *Table            `json:"table,omitempty" gorm:"foreignKey:TableID"`
+	Taxes    []TransactionTax  `json:"taxes,omitempty" gorm:"foreignKey:TransactionID"`
+
+	outboxRecorder
 }
 
 func (Transaction) TableName() string {
@@ -43,18 +126,66 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) (err error) {
 	if t.ID == "" {
 		t.ID = uuid.New().String()
 	}
+	// Practice sales stay inside the POS
+	if !t.IsTraining {
+		t.recordOutboxEvent(OutboxTransactionCreated)
+	}
 	return
 }
 
+func (t *Transaction) TakeOutboxEvents() ([]*OutboxEvent, error) {
+	var outboxEvents []*OutboxEvent
+	for _, eventType := range t.takeOutboxEvents() {
+		data := &TransactionCreatedData{
+			TransactionID: t.ID,
+			StoreID:       t.StoreID,
+			UserID:        t.UserID,
+			CustomerID:    t.CustomerID,
+			Status:        t.Status,
+			Currency:      t.Currency,
+			TotalAmount:   t.TotalAmount,
+			Items:         make([]TransactionCreatedItem, 0, len(t.Items)),
+			CreatedAt:     t.CreatedAt,
+		}
+		for _, item := range t.Items {
+			data.Items = append(data.Items, TransactionCreatedItem{
+				ProductID:  item.ProductID,
+				Quantity:   item.Quantity,
+				UnitPrice:  item.UnitPrice,
+				TotalPrice: item.TotalPrice,
+			})
+		}
+
+		event, err := NewOutboxEvent("transaction", t.ID, eventType, t.StoreID, data)
+		if err != nil {
+			return nil, err
+		}
+		outboxEvents = append(outboxEvents, event)
+	}
+	return outboxEvents, nil
+}
+
 type TransactionItem struct {
-	ID            string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	TransactionID string         `json:"transaction_id" gorm:"type:uuid;not null"`
-	ProductID     string         `json:"product_id" gorm:"type:uuid;not null"`
-	Quantity      int            `json:"quantity" gorm:"not null;check:quantity > 0"`
-	UnitPrice     float64        `json:"unit_price" gorm:"type:decimal(10,2);not null;check:unit_price >= 0"`
-	TotalPrice    float64        `json:"total_price" gorm:"type:decimal(10,2);not null;check:total_price >= 0"`
-	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TransactionID   string         `json:"transaction_id" gorm:"type:uuid;not null"`
+	ProductID       string         `json:"product_id" gorm:"type:uuid;not null"`
+	Quantity        float64        `json:"quantity" gorm:"type:decimal(12,3);not null;check:quantity > 0"`
+	StockShortfall  float64        `json:"stock_shortfall,omitempty" gorm:"type:decimal(12,3);not null;default:0"` // Sold offline while out of stock, never deducted from it
+	Unit            UnitOfMeasure  `json:"unit" gorm:"type:varchar(10);not null;default:'pcs'"` // Product unit at the time of sale
+	UnitPrice       int64          `json:"unit_price" gorm:"type:bigint;not null;check:unit_price >= 0"`
+	BaseUnitPrice   int64          `json:"base_unit_price" gorm:"type:bigint;not null;default:0"` // Unit price before quantity breaks
+	TierMinQuantity int            `json:"tier_min_quantity,omitempty" gorm:"not null;default:0"` // Quantity break the unit price comes from, 0 for none
+	TotalPrice      int64          `json:"total_price" gorm:"type:bigint;not null;check:total_price >= 0"`
+	ManualDiscount  ManualDiscount `json:"manual_discount" gorm:"embedded;embeddedPrefix:manual_discount_"` // Discount on the item given by hand, included in the transaction discount
+	UnitCost        int64          `json:"-" gorm:"type:bigint;not null;default:0"` // Product cost price at the time of sale
+	TaxClass        TaxClass       `json:"tax_class" gorm:"type:varchar(20)"` // Product tax class at the time of sale
+	PriceSource     PriceSource    `json:"price_source" gorm:"type:varchar(20);not null;default:'list'"` // Where the unit price came from
+	PriceScheduleID *string        `json:"price_schedule_id,omitempty" gorm:"type:uuid"` // Price schedule in effect at the time of sale
+	Sequence        int            `json:"sequence" gorm:"not null;default:0"` // Display order on receipts and kitchen tickets
+	PrepStatus      ItemPrepStatus `json:"prep_status" gorm:"type:varchar(20);not null;default:'queued'"` // Kitchen progress of the item
+	PrepUpdatedAt   *time.Time     `json:"prep_updated_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 	
 	// Relations
 	Transaction Transaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
@@ -71,45 +202,188 @@ func (ti *TransactionItem) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
-func NewTransaction(userID string) *Transaction {
+// Restock returns how much of a returned quantity goes back to stock, given the quantity refunded before.
+// The units sold out of stock never left it, the reserved ones are returned first.
+func (ti *TransactionItem) Restock(refunded, quantity float64) float64 {
+	reserved := ti.Quantity - ti.StockShortfall
+	return RoundQuantity(max(0, min(refunded+quantity, reserved)-min(refunded, reserved)))
+}
+
+// AdvancePrep moves the item forward to the given kitchen status
+func (ti *TransactionItem) AdvancePrep(status ItemPrepStatus) error {
+	step, ok := itemPrepSteps[status]
+	if !ok {
+		return errors.New("invalid preparation status")
+	}
+	if step <= itemPrepSteps[ti.PrepStatus] {
+		return fmt.Errorf("item is already %s", ti.PrepStatus)
+	}
+
+	now := time.Now()
+	ti.PrepStatus = status
+	ti.PrepUpdatedAt = &now
+	return nil
+}
+
+func NewTransaction(storeID, userID string) *Transaction {
 	return &Transaction{
 		ID:          uuid.New().String(),
+		StoreID:     storeID,
 		UserID:      userID,
+		Currency:    DefaultCurrency,
 		TotalAmount: 0,
 		TaxAmount:   0,
 		Discount:    0,
 		Status:      StatusPending,
+		OrderType:   OrderDineIn,
 		Items:       []TransactionItem{},
 	}
 }
 
-func (t *Transaction) AddItem(productID string, product *Product, quantity int) error {
+// EnableTracking gives a pickup or delivery order the token of its public status page
+func (t *Transaction) EnableTracking() {
+	if t.OrderType == OrderDineIn || t.TrackingToken != nil {
+		return
+	}
+	token := strings.ReplaceAll(uuid.New().String(), "-", "")
+	t.TrackingToken = &token
+}
+
+// AdvanceFulfillment moves a paid order forward to the given kitchen status
+func (t *Transaction) AdvanceFulfillment(status FulfillmentStatus) error {
+	step, ok := fulfillmentSteps[status]
+	if !ok {
+		return errors.New("invalid fulfillment status")
+	}
+	if t.Status != StatusPaid && t.Status != StatusPartiallyRefunded {
+		return errors.New("only paid orders can be fulfilled")
+	}
+	if step <= fulfillmentSteps[t.FulfillmentStatus] {
+		return fmt.Errorf("order is already %s", t.FulfillmentStatus)
+	}
+
+	now := time.Now()
+	switch status {
+	case FulfillmentPreparing:
+		t.PreparingAt = &now
+	case FulfillmentReady:
+		t.ReadyAt = &now
+	case FulfillmentCompleted:
+		t.CompletedAt = &now
+	}
+	t.FulfillmentStatus = status
+	t.UpdatedAt = now
+	return nil
+}
+
+// Hold parks a pending cart under a label so the register is free for the next customer
+func (t *Transaction) Hold(label string) error {
+	if t.Status != StatusPending {
+		return errors.New("only pending transactions can be held")
+	}
+	if t.HeldAt != nil {
+		return errors.New("transaction is already on hold")
+	}
+
+	now := time.Now()
+	t.HoldLabel = label
+	t.HeldAt = &now
+	t.UpdatedAt = now
+	return nil
+}
+
+// Resume takes a parked cart back to the register. The label is kept to show where the order came from.
+func (t *Transaction) Resume() error {
+	if t.HeldAt == nil {
+		return errors.New("transaction is not on hold")
+	}
+
+	t.HeldAt = nil
+	t.UpdatedAt = time.Now()
+	return nil
+}
+
+// IsHeld reports whether the cart is parked. Items can still be added to a parked cart but it
+// must be resumed before checkout.
+func (t *Transaction) IsHeld() bool {
+	return t.HeldAt != nil
+}
+
+// ItemsReady reports whether the kitchen has prepared every item of the order
+func (t *Transaction) ItemsReady() bool {
+	if len(t.Items) == 0 {
+		return false
+	}
+	for _, item := range t.Items {
+		if item.PrepStatus != ItemReady {
+			return false
+		}
+	}
+	return true
+}
+
+// IsOpenTableOrder reports whether the transaction is a dine-in order still open on a table. Guests
+// keep adding items to it and it is settled when they leave.
+func (t *Transaction) IsOpenTableOrder() bool {
+	return t.TableID != nil && t.Status == StatusPending
+}
+
+// ReceiptRecipients lists the channels the customer asked the digital receipt on
+func (t *Transaction) ReceiptRecipients() map[ReceiptChannel]string {
+	recipients := make(map[ReceiptChannel]string)
+	if t.CustomerEmail != "" {
+		recipients[ReceiptChannelEmail] = t.CustomerEmail
+	}
+	if t.CustomerPhone != "" {
+		recipients[ReceiptChannelWhatsApp] = t.CustomerPhone
+	}
+	return recipients
+}
+
+func (t *Transaction) AddItem(productID string, product *Product, quantity float64) error {
 	if product == nil {
 		return errors.New("product cannot be nil")
 	}
 	
+	if product.StoreID != t.StoreID {
+		return errors.New("product is sold by another store")
+	}
+	
 	if !product.IsAvailable() {
 		return errors.New("product is not available")
 	}
 	
+	if err := product.ValidateQuantity(quantity); err != nil {
+		return err
+	}
+	
 	if !product.CanFulfillQuantity(quantity) {
 		return errors.New("insufficient stock")
 	}
 	
 	unitPrice := product.Price
-	totalPrice := unitPrice * float64(quantity)
+	totalPrice := LinePrice(unitPrice, quantity)
 	
 	// Let the database handle ID generation
 	item := TransactionItem{
-		TransactionID: t.ID,
-		ProductID:     productID,
-		Quantity:      quantity,
-		UnitPrice:     unitPrice,
-		TotalPrice:    totalPrice,
-		Product:       *product,
+		TransactionID:   t.ID,
+		ProductID:       productID,
+		Quantity:        quantity,
+		Unit:            product.unit(),
+		UnitPrice:       unitPrice,
+		BaseUnitPrice:   unitPrice,
+		TotalPrice:      totalPrice,
+		UnitCost:        product.CostPrice,
+		TaxClass:        product.TaxClass,
+		PriceSource:     product.PriceSource(),
+		PriceScheduleID: product.PriceScheduleID,
+		Sequence:        len(t.Items) + 1,
+		PrepStatus:      ItemQueued,
+		Product:         *product,
 	}
 	
 	t.Items = append(t.Items, item)
+	ApplyPriceTiers(t.Items, productID, product.PriceTiers)
 	t.calculateTotal()
 	
 	return nil
@@ -126,16 +400,54 @@ func (t *Transaction) RemoveItem(productID string) {
 }
 
 func (t *Transaction) calculateTotal() {
-	var subtotal float64
-	for _, item := range t.Items {
-		subtotal += item.TotalPrice
-	}
+	subtotal := t.getSubtotal()
+	t.ServiceCharge = t.serviceChargeOn(subtotal)
 	
-	t.TotalAmount = subtotal - t.Discount + t.TaxAmount
+	due := max(subtotal-t.Discount+t.ServiceCharge-t.ExchangeCredit-t.PointsDiscount+t.TaxAmount, 0)
+	// The rounding is part of the total whatever the payment method, so the amount is the same in cash
+	// and by QRIS. A total is never rounded down to nothing.
+	t.Rounding = 0
+	if rounded := RoundTo(due, t.RoundingIncrement, t.RoundingMode); rounded > 0 {
+		t.Rounding = rounded - due
+	}
+	t.TotalAmount = due + t.Rounding
 	t.UpdatedAt = time.Now()
 }
 
-func (t *Transaction) ApplyDiscount(discount float64) error {
+// serviceChargeOn returns the service charge on the subtotal after discounts
+func (t *Transaction) serviceChargeOn(subtotal int64) int64 {
+	if t.ServiceChargeRate <= 0 {
+		return 0
+	}
+	return PercentOf(max(subtotal-t.Discount, 0), t.ServiceChargeRate)
+}
+
+// ApplyManualDiscounts works out the manual discounts on the current items and on the cart, and sets the
+// discount of the transaction to them plus the promotion discount. The cart discount applies to what is
+// left after the others and the total discount never exceeds the subtotal. It returns the indexes of the
+// items whose discount amount changed.
+func (t *Transaction) ApplyManualDiscounts(promotionDiscount int64) []int {
+	var changed []int
+	var itemDiscount int64
+	for i := range t.Items {
+		item := &t.Items[i]
+		amount := item.ManualDiscount.AmountOff(item.TotalPrice, item.Quantity)
+		if amount != item.ManualDiscount.Amount {
+			item.ManualDiscount.Amount = amount
+			changed = append(changed, i)
+		}
+		itemDiscount += amount
+	}
+
+	subtotal := t.getSubtotal()
+	discount := min(promotionDiscount+itemDiscount, subtotal)
+	t.ManualDiscount.Amount = t.ManualDiscount.AmountOff(subtotal-discount, 1)
+	t.Discount = discount + t.ManualDiscount.Amount
+	t.calculateTotal()
+	return changed
+}
+
+func (t *Transaction) ApplyDiscount(discount int64) error {
 	if discount < 0 {
 		return errors.New("discount cannot be negative")
 	}
@@ -150,19 +462,109 @@ func (t *Transaction) ApplyDiscount(discount float64) error {
 	return nil
 }
 
-func (t *Transaction) ApplyTax(taxRate float64) error {
-	if taxRate < 0 {
-		return errors.New("tax rate cannot be negative")
+// ApplyExchangeCredit deducts the value of items returned in an exchange from what the customer owes
+func (t *Transaction) ApplyExchangeCredit(credit int64) error {
+	if credit < 0 {
+		return errors.New("exchange credit cannot be negative")
 	}
-	
-	subtotal := t.getSubtotal()
-	t.TaxAmount = (subtotal - t.Discount) * taxRate / 100
+	if t.Status != StatusPending {
+		return errors.New("exchange credit can only be applied to pending transactions")
+	}
+
+	if credit > t.getSubtotal()-t.Discount+t.ServiceCharge-t.PointsDiscount+t.TaxAmount {
+		return errors.New("exchange credit cannot exceed the transaction total")
+	}
+
+	t.ExchangeCredit = credit
 	t.calculateTotal()
 	return nil
 }
 
-func (t *Transaction) getSubtotal() float64 {
-	var subtotal float64
+// RedeemPoints spends loyalty points of the attached customer as a discount worth pointValue rupiah each.
+// Like exchange credit it is taken off the taxed total, and zero points removes the redemption.
+func (t *Transaction) RedeemPoints(points, pointValue int64) error {
+	if points < 0 {
+		return errors.New("redeemed points cannot be negative")
+	}
+	if t.Status != StatusPending {
+		return errors.New("points can only be redeemed on pending transactions")
+	}
+	if points > 0 && t.CustomerID == nil {
+		return errors.New("attach a customer before redeeming points")
+	}
+	if points > 0 && pointValue <= 0 {
+		return errors.New("points redemption is disabled")
+	}
+
+	discount := points * pointValue
+	if discount > t.getSubtotal()-t.Discount+t.ServiceCharge-t.ExchangeCredit+t.TaxAmount {
+		return errors.New("redeemed points cannot exceed the transaction total")
+	}
+
+	t.PointsRedeemed = points
+	t.PointsDiscount = discount
+	t.calculateTotal()
+	return nil
+}
+
+// ApplyTax charges the active tax rules on the items and returns the tax of each rule. A rule taxes the
+// items it doesn't exempt after their share of the discount, spread in proportion to price. Exclusive
+// taxes are also charged on the service charge of those items and added to the total; inclusive ones
+// are already in the prices and only recorded.
+func (t *Transaction) ApplyTax(rules []TaxRule) ([]TransactionTax, error) {
+	subtotal := t.getSubtotal()
+	taxes := []TransactionTax{}
+	var added, included int64
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsActive {
+			continue
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+
+		var taxable int64
+		for _, item := range t.Items {
+			if !rule.Exempts(item) {
+				taxable += item.TotalPrice
+			}
+		}
+		if taxable == 0 {
+			continue
+		}
+		taxable -= ProRata(t.Discount, taxable, subtotal)
+		// Inclusive taxes are in the prices, the service charge added on top only carries exclusive ones
+		if !rule.Inclusive && t.ServiceChargeRate > 0 {
+			taxable += PercentOf(max(taxable, 0), t.ServiceChargeRate)
+		}
+
+		amount := rule.TaxOn(max(taxable, 0))
+		if rule.Inclusive {
+			included += amount
+		} else {
+			added += amount
+		}
+		taxes = append(taxes, TransactionTax{
+			TransactionID: t.ID,
+			TaxRuleID:     rule.ID,
+			Name:          rule.Name,
+			Rate:          rule.Rate,
+			Inclusive:     rule.Inclusive,
+			TaxableAmount: max(taxable, 0),
+			Amount:        amount,
+		})
+	}
+
+	t.TaxAmount = added
+	t.IncludedTax = included
+	t.calculateTotal()
+	return taxes, nil
+}
+
+func (t *Transaction) getSubtotal() int64 {
+	var subtotal int64
 	for _, item := range t.Items {
 		subtotal += item.TotalPrice
 	}
@@ -175,6 +577,7 @@ func (t *Transaction) Cancel() error {
 	}
 	
 	t.Status = StatusCancelled
+	t.HeldAt = nil
 	t.UpdatedAt = time.Now()
 	return nil
 }
@@ -185,6 +588,7 @@ func (t *Transaction) MarkAsPaid() error {
 	}
 	
 	t.Status = StatusPaid
+	t.HeldAt = nil
 	t.UpdatedAt = time.Now()
 	return nil
 }
@@ -197,4 +601,26 @@ func (t *Transaction) MarkAsExpired() error {
 	t.Status = StatusExpired
 	t.UpdatedAt = time.Now()
 	return nil
-}
\ No newline at end of file
+}
+
+// ApplyRefund adds a refunded amount and moves the transaction to refunded or partially refunded
+func (t *Transaction) ApplyRefund(amount int64) error {
+	if t.Status != StatusPaid && t.Status != StatusPartiallyRefunded {
+		return errors.New("only paid transactions can be refunded")
+	}
+	if amount <= 0 {
+		return errors.New("refund amount must be positive")
+	}
+	if t.RefundedAmount+amount > t.TotalAmount {
+		return errors.New("refund amount exceeds the remaining paid amount")
+	}
+
+	t.RefundedAmount += amount
+	if t.RefundedAmount >= t.TotalAmount {
+		t.Status = StatusRefunded
+	} else {
+		t.Status = StatusPartiallyRefunded
+	}
+	t.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// Quantities and stock are decimals so goods sold by weight or volume can be sold in parts, like
+// 0.5 kg of coffee beans. They are kept to three decimals, a gram or a millilitre, and rounded through
+// RoundQuantity so sums of floats don't drift. Products counted in pieces only take whole quantities.
+
+// UnitOfMeasure is the unit a product is stocked and sold in, prices are per unit
+type UnitOfMeasure string
+
+const (
+	UnitPiece    UnitOfMeasure = "pcs"
+	UnitKilogram UnitOfMeasure = "kg"
+	UnitLiter    UnitOfMeasure = "liter"
+)
+
+// QuantityDecimals is the number of decimals quantities are kept to
+const QuantityDecimals = 3
+
+func (u UnitOfMeasure) IsValid() bool {
+	switch u {
+	case UnitPiece, UnitKilogram, UnitLiter:
+		return true
+	}
+	return false
+}
+
+// AllowsFraction reports whether the unit can be sold in parts
+func (u UnitOfMeasure) AllowsFraction() bool {
+	return u == UnitKilogram || u == UnitLiter
+}
+
+// ValidateQuantity checks a quantity can be sold in the unit: above 0, whole for pieces and with no
+// more decimals than QuantityDecimals otherwise
+func (u UnitOfMeasure) ValidateQuantity(quantity float64) error {
+	if math.IsNaN(quantity) || math.IsInf(quantity, 0) || quantity <= 0 {
+		return errors.New("quantity must be above 0")
+	}
+	if RoundQuantity(quantity) != quantity {
+		return errors.New("quantity cannot have more than 3 decimals")
+	}
+	if !u.AllowsFraction() && quantity != math.Trunc(quantity) {
+		return errors.New("quantity must be whole for products sold in pieces")
+	}
+	return nil
+}
+
+// ValidateStock checks a stock level or a change to it can be counted in the unit, like a quantity
+// but also 0 and below
+func (u UnitOfMeasure) ValidateStock(stock float64) error {
+	if stock == 0 {
+		return nil
+	}
+	if err := u.ValidateQuantity(math.Abs(stock)); err != nil {
+		return errors.New("stock " + err.Error())
+	}
+	return nil
+}
+
+// RoundQuantity rounds a quantity half away from zero to QuantityDecimals decimals
+func RoundQuantity(quantity float64) float64 {
+	scale := math.Pow10(QuantityDecimals)
+	return math.Round(quantity*scale) / scale
+}
+
+// LinePrice returns the price of quantity units at unitPrice, rounded to a whole minor unit
+func LinePrice(unitPrice int64, quantity float64) int64 {
+	return RoundAmount(float64(unitPrice) * quantity)
+}
+
+// FormatQuantity writes a quantity with no trailing zeros, followed by its unit unless it is counted
+// in pieces, e.g. "2" or "0.5 kg"
+func FormatQuantity(quantity float64, unit UnitOfMeasure) string {
+	formatted := strconv.FormatFloat(RoundQuantity(quantity), 'f', -1, 64)
+	if unit == "" || unit == UnitPiece {
+		return formatted
+	}
+	return formatted + " " + string(unit)
+}
@@ -15,11 +15,14 @@ const (
 
 type User struct {
 	ID        string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID  *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
 	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
 	Password  string         `json:"-" gorm:"not null"`
 	Name      string         `json:"name" gorm:"not null"`
 	Role      UserRole       `json:"role" gorm:"type:varchar(50);not null;check:role IN ('admin', 'cashier')"`
+	StoreID   *string        `json:"store_id,omitempty" gorm:"type:uuid;index"` // Store a cashier works in, admins without one oversee every store
 	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	TokenInvalidBefore *time.Time `json:"-"` // Tokens issued earlier are rejected, set on password change
 	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -50,6 +53,18 @@ func NewUser(email, name, password string, role UserRole) *User {
 	}
 }
 
+// InvalidateTokens revokes every token issued until now.
+// JWT iat has second precision, so the cut-off is truncated to keep tokens issued right after valid.
+func (u *User) InvalidateTokens() {
+	now := time.Now().Truncate(time.Second)
+	u.TokenInvalidBefore = &now
+}
+
+// AcceptsTokenIssuedAt reports whether a token issued at issuedAt has not been revoked
+func (u *User) AcceptsTokenIssuedAt(issuedAt time.Time) bool {
+	return u.TokenInvalidBefore == nil || !issuedAt.Before(*u.TokenInvalidBefore)
+}
+
 func (u *User) IsValidRole() bool {
 	return u.Role == RoleAdmin || u.Role == RoleCashier
 }
@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VoidRequestStatus string
+
+const (
+	VoidPending  VoidRequestStatus = "pending"
+	VoidApproved VoidRequestStatus = "approved"
+	VoidRejected VoidRequestStatus = "rejected"
+)
+
+// VoidSettlement is how the money of a voided sale went back to the customer
+type VoidSettlement string
+
+const (
+	VoidSettledByGateway VoidSettlement = "gateway" // Refunded through the gateway that collected it
+	VoidSettledAtCounter VoidSettlement = "counter" // Cash, card and local QRIS, handed back at the register
+	VoidSettledManually  VoidSettlement = "manual"  // The gateway refund failed, queued as a payment exception
+)
+
+// VoidRequest is a cashier's request to void a paid sale. Nothing changes until a manager approves it,
+// the sale is then fully refunded and its items go back to stock.
+type VoidRequest struct {
+	ID            string            `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID      *string           `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	TransactionID string            `json:"transaction_id" gorm:"type:uuid;not null;index"`
+	StoreID       string            `json:"store_id" gorm:"type:uuid;not null;index"`
+	RequestedBy   string            `json:"requested_by" gorm:"type:uuid;not null"`
+	Reason        string            `json:"reason" gorm:"not null"`
+	Status        VoidRequestStatus `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	DecidedBy     *string           `json:"decided_by,omitempty" gorm:"type:uuid"` // Manager who approved or rejected it
+	DecidedAt     *time.Time        `json:"decided_at,omitempty"`
+	DecisionNote  string            `json:"decision_note,omitempty"`
+	RefundID      *string           `json:"refund_id,omitempty" gorm:"type:uuid"`
+	Settlement    VoidSettlement    `json:"settlement,omitempty" gorm:"type:varchar(20)"`
+	ExceptionID   *string           `json:"exception_id,omitempty" gorm:"type:uuid"` // Payment exception to settle by hand, for manual settlements
+	CreatedAt     time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (VoidRequest) TableName() string {
+	return "void_requests"
+}
+
+func (v *VoidRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewVoidRequest(transaction *Transaction, requestedBy, reason string) *VoidRequest {
+	return &VoidRequest{
+		TransactionID: transaction.ID,
+		StoreID:       transaction.StoreID,
+		RequestedBy:   requestedBy,
+		Reason:        reason,
+		Status:        VoidPending,
+	}
+}
+
+// Decide approves or rejects a pending request
+func (v *VoidRequest) Decide(status VoidRequestStatus, userID, note string) error {
+	if v.Status != VoidPending {
+		return errors.New("void request is already decided")
+	}
+	if status != VoidApproved && status != VoidRejected {
+		return errors.New("invalid void decision")
+	}
+
+	now := time.Now()
+	v.Status = status
+	v.DecidedBy = &userID
+	v.DecidedAt = &now
+	v.DecisionNote = note
+	return nil
+}
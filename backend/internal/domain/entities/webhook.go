@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is a POS event a merchant integration can subscribe to
+type WebhookEvent string
+
+const (
+	WebhookPaymentSettled     WebhookEvent = "payment.settled"
+	WebhookTransactionCreated WebhookEvent = "transaction.created"
+	WebhookStockLow           WebhookEvent = "stock.low"
+)
+
+// WebhookEndpoint is a URL of an external system, such as accounting or an ERP, the POS posts its
+// events to. Every request is signed with the secret of the endpoint.
+type WebhookEndpoint struct {
+	ID          string         `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	TenantID    *string        `json:"tenant_id,omitempty" gorm:"type:uuid;index"`
+	StoreID     *string        `json:"store_id,omitempty" gorm:"type:uuid;index"` // Events of every store when empty
+	URL         string         `json:"url" gorm:"type:varchar(500);not null"`
+	Description string         `json:"description" gorm:"type:varchar(255)"`
+	Events      string         `json:"events" gorm:"type:varchar(255);not null"` // Comma separated
+	Secret      string         `json:"-" gorm:"type:varchar(100);not null"`      // Key of the HMAC signature, shown once when created
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CreatedBy   string         `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+func (e *WebhookEndpoint) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
+
+// EventList returns the events the endpoint subscribes to
+func (e *WebhookEndpoint) EventList() []WebhookEvent {
+	var list []WebhookEvent
+	for _, event := range strings.Split(e.Events, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			list = append(list, WebhookEvent(event))
+		}
+	}
+	return list
+}
+
+func (e *WebhookEndpoint) SetEvents(events []WebhookEvent) {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(names, string(event)) {
+			names = append(names, string(event))
+		}
+	}
+	e.Events = strings.Join(names, ",")
+}
+
+// Receives reports whether an event of a store, empty when it belongs to none, is posted to the endpoint
+func (e *WebhookEndpoint) Receives(event WebhookEvent, storeID string) bool {
+	if !e.IsActive || !slices.Contains(e.EventList(), event) {
+		return false
+	}
+	return e.StoreID == nil || *e.StoreID == storeID
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	WebhookDeliverySent    WebhookDeliveryStatus = "sent"
+	WebhookDeliveryFailed  WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event queued for an endpoint. The payload is kept so every attempt posts the
+// same body, failed attempts are retried with backoff until MaxAttempts is reached.
+type WebhookDelivery struct {
+	ID             string                `json:"id" gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	EndpointID     string                `json:"endpoint_id" gorm:"type:uuid;not null;index:idx_webhook_deliveries_endpoint,priority:1;uniqueIndex:idx_webhook_deliveries_fingerprint,priority:1"`
+	Event          WebhookEvent          `json:"event" gorm:"type:varchar(50);not null"`
+	Fingerprint    string                `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_webhook_deliveries_fingerprint,priority:2"` // Keeps an event published twice from being sent twice
+	Payload        string                `json:"payload" gorm:"type:jsonb;not null"`
+	Status         WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;default:pending;check:status IN ('pending', 'sent', 'failed')"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" gorm:"not null"`
+	ResponseStatus int                   `json:"response_status"` // HTTP status of the last attempt, 0 when no answer came
+	LastError      string                `json:"last_error"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"autoCreateTime;index:idx_webhook_deliveries_endpoint,priority:2"`
+	UpdatedAt      time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return
+}
+
+func NewWebhookDelivery(endpointID string, event WebhookEvent, fingerprint string, payload []byte) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:            uuid.New().String(),
+		EndpointID:    endpointID,
+		Event:         event,
+		Fingerprint:   fingerprint,
+		Payload:       string(payload),
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+}
+
+func (d *WebhookDelivery) MarkAsSent(responseStatus int) {
+	now := time.Now()
+	d.Status = WebhookDeliverySent
+	d.Attempts++
+	d.ResponseStatus = responseStatus
+	d.LastError = ""
+	d.DeliveredAt = &now
+}
+
+// MarkAttemptFailed schedules a retry after backoff, doubling it on every attempt,
+// and gives up once maxAttempts is reached
+func (d *WebhookDelivery) MarkAttemptFailed(err error, responseStatus, maxAttempts int, backoff time.Duration) {
+	d.Attempts++
+	d.ResponseStatus = responseStatus
+	d.LastError = err.Error()
+
+	if d.Attempts >= maxAttempts {
+		d.Status = WebhookDeliveryFailed
+		return
+	}
+	d.NextAttemptAt = time.Now().Add(backoff * time.Duration(1<<(d.Attempts-1)))
+}
+
+// MarkAsFailed gives up without further retries
+func (d *WebhookDelivery) MarkAsFailed(err error) {
+	d.Attempts++
+	d.LastError = err.Error()
+	d.Status = WebhookDeliveryFailed
+}
+
+// Redeliver queues a failed delivery again with a fresh set of attempts
+func (d *WebhookDelivery) Redeliver() {
+	d.Status = WebhookDeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+}
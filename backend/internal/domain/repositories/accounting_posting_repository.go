@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type AccountingPostingRepository interface {
+	Create(ctx context.Context, posting *entities.AccountingPosting) error
+	// ListByConnector returns the postings of a connector for days in the range, of one store or of
+	// every store when storeID is empty
+	ListByConnector(ctx context.Context, connector, storeID string, dateFrom, dateTo time.Time) ([]entities.AccountingPosting, error)
+}
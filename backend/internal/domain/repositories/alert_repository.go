@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"time"
+)
+
+type AlertRepository interface {
+	// Create saves the alert unless one with the same fingerprint exists, reporting whether it was created
+	Create(ctx context.Context, alert *entities.Alert) (bool, error)
+	GetByID(ctx context.Context, id string) (*entities.Alert, error)
+	Update(ctx context.Context, alert *entities.Alert) error
+	List(ctx context.Context, filters AlertFilters) ([]entities.Alert, error)
+
+	// Sales pattern metrics the detectors compare against
+	CountVoids(ctx context.Context, from, to time.Time) (int64, error)
+	ListRefunds(ctx context.Context, from, to time.Time) ([]entities.Refund, error)
+	DiscountRateStats(ctx context.Context, from, to time.Time) (*DiscountRateStats, error)
+	ListDiscountedSales(ctx context.Context, from, to time.Time) ([]DiscountedSale, error)
+}
+
+type AlertFilters struct {
+	Type         entities.AlertType
+	Acknowledged *bool
+	Limit        int
+	Offset       int
+}
+
+// DiscountRateStats describes discount as a share of the pre-discount amount over settled sales
+type DiscountRateStats struct {
+	Count  int64
+	Mean   float64
+	StdDev float64
+}
+
+type DiscountedSale struct {
+	TransactionID string
+	UserID        string
+	Discount      int64
+	Rate          float64
+	CreatedAt     time.Time
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"time"
+)
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *entities.AuditLog) error
+	List(ctx context.Context, filters AuditLogFilters) ([]entities.AuditLog, error)
+}
+
+type AuditLogFilters struct {
+	UserID     string
+	Action     entities.AuditAction
+	ResourceID string
+	From       *time.Time
+	To         *time.Time // Exclusive
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *entities.Customer) error
+	GetByID(ctx context.Context, id string) (*entities.Customer, error)
+	GetByPhone(ctx context.Context, phone string) (*entities.Customer, error)
+	GetByEmail(ctx context.Context, email string) (*entities.Customer, error)
+	Update(ctx context.Context, customer *entities.Customer) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filters CustomerFilters) ([]entities.Customer, error)
+
+	// Points
+	// HeldPoints sums the points redeemed on pending transactions of the customer, optionally leaving one out.
+	// Those points are spoken for until the transactions are paid or closed.
+	HeldPoints(ctx context.Context, customerID, excludeTransactionID string) (int64, error)
+	// SettleTransactionPoints takes the redeemed points off the balance and adds the earned ones for a
+	// paid transaction. Settling a transaction again is a no-op.
+	SettleTransactionPoints(ctx context.Context, customerID, transactionID string, redeemed, earned int64) error
+	// AddPointsEntry changes the balance by the entry's points and records the entry with the new balance
+	AddPointsEntry(ctx context.Context, entry *entities.PointsEntry) error
+	ListPointsEntries(ctx context.Context, customerID string, limit, offset int) ([]entities.PointsEntry, error)
+}
+
+type CustomerFilters struct {
+	Search string // Matches name, phone or email
+	Limit  int
+	Offset int
+}
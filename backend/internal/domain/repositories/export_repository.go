@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type ExportRepository interface {
+	Create(ctx context.Context, export *entities.Export) error
+	GetByID(ctx context.Context, id string) (*entities.Export, error)
+	Update(ctx context.Context, export *entities.Export) error
+	List(ctx context.Context, limit, offset int) ([]entities.Export, error)
+	// ListPending returns the exports waiting to be built, oldest first
+	ListPending(ctx context.Context, limit int) ([]entities.Export, error)
+	// Claim moves a pending export to processing so it is only built once
+	Claim(ctx context.Context, export *entities.Export) error
+}
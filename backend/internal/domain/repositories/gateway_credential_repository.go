@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type GatewayCredentialRepository interface {
+	// Get returns gorm.ErrRecordNotFound when the store has no account of its own on the gateway
+	Get(ctx context.Context, storeID string, provider entities.PaymentProvider) (*entities.GatewayCredential, error)
+	// Save creates the credential of the store and gateway or overwrites it
+	Save(ctx context.Context, credential *entities.GatewayCredential) error
+	Delete(ctx context.Context, storeID string, provider entities.PaymentProvider) error
+}
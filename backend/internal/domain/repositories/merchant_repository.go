@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+// MerchantRepository stores the profile of the merchant, there is at most one
+type MerchantRepository interface {
+	// Get returns gorm.ErrRecordNotFound until the merchant is onboarded
+	Get(ctx context.Context) (*entities.Merchant, error)
+	Create(ctx context.Context, merchant *entities.Merchant) error
+	Update(ctx context.Context, merchant *entities.Merchant) error
+}
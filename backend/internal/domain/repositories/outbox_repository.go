@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+// OutboxRepository reads the outbox for the relay. Events are written by the repositories of the
+// aggregates, in the transaction of the change.
+type OutboxRepository interface {
+	// ListUnpublished returns the events not yet published, oldest first
+	ListUnpublished(ctx context.Context, limit int) ([]entities.OutboxEvent, error)
+	Update(ctx context.Context, event *entities.OutboxEvent) error
+	// PurgeBefore deletes the events created before the cutoff, published or not
+	PurgeBefore(ctx context.Context, before time.Time) (int64, error)
+}
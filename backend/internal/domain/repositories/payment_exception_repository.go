@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type PaymentExceptionRepository interface {
+	Create(ctx context.Context, exception *entities.PaymentException) error
+	GetByID(ctx context.Context, id string) (*entities.PaymentException, error)
+	GetByExternalID(ctx context.Context, externalID string) (*entities.PaymentException, error)
+	Update(ctx context.Context, exception *entities.PaymentException) error
+	List(ctx context.Context, filters PaymentExceptionFilters) ([]entities.PaymentException, error)
+}
+
+type PaymentExceptionFilters struct {
+	Status entities.PaymentExceptionStatus
+	Reason entities.PaymentExceptionReason
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type PaymentNotificationRepository interface {
+	Create(ctx context.Context, notification *entities.PaymentNotification) error
+	GetByID(ctx context.Context, id string) (*entities.PaymentNotification, error)
+	Update(ctx context.Context, notification *entities.PaymentNotification) error
+	List(ctx context.Context, filters PaymentNotificationFilters) ([]entities.PaymentNotification, error)
+	// ListDue returns the notifications waiting for a retry, and those whose processing was cut off
+	ListDue(ctx context.Context, now time.Time, limit int) ([]entities.PaymentNotification, error)
+}
+
+type PaymentNotificationFilters struct {
+	Status   entities.PaymentNotificationStatus
+	Provider entities.PaymentProvider
+	OrderID  string
+	Limit    int
+	Offset   int
+}
@@ -3,14 +3,47 @@ package repositories
 import (
 	"context"
 	"qris-pos-backend/internal/domain/entities"
+	"time"
 )
 
+type PaymentFilters struct {
+	StoreID   string
+	Status    entities.PaymentStatus
+	Method    entities.PaymentMethod
+	Training  bool // Only payments of practice sales, which are left out otherwise
+	From      *time.Time
+	To        *time.Time // Exclusive
+	MinAmount *int64
+	MaxAmount *int64
+	Limit     int
+	Offset    int
+}
+
 type PaymentRepository interface {
 	CreatePayment(ctx context.Context, payment *entities.Payment) error
 	GetPaymentByID(ctx context.Context, id string) (*entities.Payment, error)
 	GetPaymentByTransactionID(ctx context.Context, transactionID string) (*entities.Payment, error)
+	// GetPaidAmount sums the settled payments of a transaction, deposits included
+	GetPaidAmount(ctx context.Context, transactionID string) (int64, error)
+	// GetPaymentByOrderID returns the payment whose current order ID it is, of every store
+	GetPaymentByOrderID(ctx context.Context, orderID string) (*entities.Payment, error)
+	// GetPaymentByExternalID returns the latest payment with the transaction ID its gateway assigned, of every store
+	GetPaymentByExternalID(ctx context.Context, externalID string) (*entities.Payment, error)
+	// GetPaymentOrder returns the mapping of an order ID to its payment, including orders a refresh superseded
+	GetPaymentOrder(ctx context.Context, orderID string) (*entities.PaymentOrder, error)
+	// UpdatePayment saves a payment with its status changes. Returns ErrIllegalPaymentTransition when the
+	// stored status changed since the payment was read.
 	UpdatePayment(ctx context.Context, payment *entities.Payment) error
-	DeletePayment(ctx context.Context, id string) error
+	ListPaymentEvents(ctx context.Context, paymentID string) ([]entities.PaymentEvent, error)
+	ListExpiredPending(ctx context.Context, now time.Time, limit int) ([]entities.Payment, error)
+	// ListUnsettledGatewayPayments returns live gateway QRIS and e-wallet payments created before createdBefore that are still pending
+	ListUnsettledGatewayPayments(ctx context.Context, createdBefore, now time.Time, limit int) ([]entities.Payment, error)
+	// ListGatewayPayments returns the live payments a gateway issued in [createdFrom, createdTo), of every store
+	ListGatewayPayments(ctx context.Context, provider entities.PaymentProvider, createdFrom, createdTo time.Time, limit int) ([]entities.Payment, error)
+	// ListPaymentsByOrderIDs returns the payments of the gateway order IDs, of every store
+	ListPaymentsByOrderIDs(ctx context.Context, orderIDs []string) ([]entities.Payment, error)
+	// ListPayments returns the payments matching the filters, newest first
+	ListPayments(ctx context.Context, filters PaymentFilters) ([]entities.Payment, error)
 	
 	CreateQRISCode(ctx context.Context, qrisCode *entities.QRISCode) error
 	GetQRISCodeByID(ctx context.Context, id string) (*entities.QRISCode, error)
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type PriceScheduleFilters struct {
+	ProductID string
+	Status    entities.PriceScheduleStatus
+	Limit     int
+	Offset    int
+}
+
+type PriceScheduleRepository interface {
+	Create(ctx context.Context, schedule *entities.PriceSchedule) error
+	GetByID(ctx context.Context, id string) (*entities.PriceSchedule, error)
+	Update(ctx context.Context, schedule *entities.PriceSchedule) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filters PriceScheduleFilters) ([]entities.PriceSchedule, error)
+	// ListDue returns the schedules of every store that are in effect, or active and pending with a start
+	// no later than now, oldest start first
+	ListDue(ctx context.Context, now time.Time) ([]entities.PriceSchedule, error)
+	// Transition saves the schedule and, when change is not nil, sets the product price and records the
+	// change in one transaction. The product is marked as priced by the schedule while it is in effect.
+	Transition(ctx context.Context, schedule *entities.PriceSchedule, change *entities.PriceChange) error
+}
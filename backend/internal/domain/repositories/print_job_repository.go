@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type PrintJobFilters struct {
+	PrinterID     string
+	TransactionID string
+	Status        entities.PrintJobStatus
+	Limit         int
+	Offset        int
+}
+
+type PrintJobRepository interface {
+	CreatePrinter(ctx context.Context, printer *entities.Printer) error
+	GetPrinter(ctx context.Context, id string) (*entities.Printer, error)
+	// GetPrinterByTokenHash returns the active printer an agent token belongs to, of every store
+	GetPrinterByTokenHash(ctx context.Context, tokenHash string) (*entities.Printer, error)
+	UpdatePrinter(ctx context.Context, printer *entities.Printer) error
+	// TouchPrinter records that the agent of a printer polled for jobs
+	TouchPrinter(ctx context.Context, id string, seenAt time.Time) error
+	ListPrinters(ctx context.Context) ([]entities.Printer, error)
+	DeletePrinter(ctx context.Context, id string) error
+
+	CreateJob(ctx context.Context, job *entities.PrintJob) error
+	GetJob(ctx context.Context, id string) (*entities.PrintJob, error)
+	UpdateJob(ctx context.Context, job *entities.PrintJob) error
+	ListJobs(ctx context.Context, filters PrintJobFilters) ([]entities.PrintJob, error)
+	// ClaimNext moves the oldest queued job of a printer, or a printing one whose lease expired before
+	// leaseExpiredBefore, to printing and counts the attempt. Returns gorm.ErrRecordNotFound when none is waiting.
+	ClaimNext(ctx context.Context, printerID string, leaseExpiredBefore time.Time) (*entities.PrintJob, error)
+}
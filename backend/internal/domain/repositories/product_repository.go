@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"time"
+
 	"qris-pos-backend/internal/domain/entities"
 )
 
@@ -9,16 +11,69 @@ type ProductRepository interface {
 	Create(ctx context.Context, product *entities.Product) error
 	GetByID(ctx context.Context, id string) (*entities.Product, error)
 	GetBySKU(ctx context.Context, sku string) (*entities.Product, error)
+	// NextSKUNumber draws the next number of the SKU sequence, it is never handed out twice
+	NextSKUNumber(ctx context.Context) (int64, error)
+	// GetByBarcode finds a product by exact barcode, falling back to an exact SKU match
+	GetByBarcode(ctx context.Context, code string) (*entities.Product, error)
 	Update(ctx context.Context, product *entities.Product) error
+	// ReplacePriceTiers sets the quantity breaks of a product, removing the previous ones
+	ReplacePriceTiers(ctx context.Context, productID string, tiers []entities.PriceTier) error
+	// GetRecipe returns the ingredients of a product, loaded with the ingredient products
+	GetRecipe(ctx context.Context, productID string) ([]entities.RecipeItem, error)
+	// ReplaceRecipe sets the ingredients of a product, removing the previous ones
+	ReplaceRecipe(ctx context.Context, productID string, items []entities.RecipeItem) error
 	Delete(ctx context.Context, id string) error
+	// GetDeletedByID finds a deleted product of the current store
+	GetDeletedByID(ctx context.Context, id string) (*entities.Product, error)
+	// Restore brings a deleted product back
+	Restore(ctx context.Context, id string) error
 	List(ctx context.Context, filters ProductFilters) ([]entities.Product, error)
-	UpdateStock(ctx context.Context, id string, quantity int) error
-	Search(ctx context.Context, query string, limit int) ([]entities.Product, error)
+	UpdateStock(ctx context.Context, id string, quantity float64) error
+	ReserveStock(ctx context.Context, id string, quantity float64) error
+	// Search ranks active products by how well their name, SKU, barcode and description match the
+	// query, tolerating typos. Filters narrow the matches.
+	Search(ctx context.Context, filters ProductSearchFilters) ([]entities.Product, error)
+	GetByIDs(ctx context.Context, ids []string) ([]entities.Product, error)
+	ApplyPriceChanges(ctx context.Context, changes []entities.PriceChange) error
+	// ListPriceChanges returns the price history of a product, latest change first
+	ListPriceChanges(ctx context.Context, productID string, limit, offset int) ([]entities.PriceChange, error)
+	// RefreshPopularity recounts the units of each product sold since the given time and returns how many
+	// products sold any
+	RefreshPopularity(ctx context.Context, since time.Time) (int64, error)
+	// ListChanged returns the products of the current store, deleted ones included, last changed after
+	// the position (since, afterID) and no later than until, oldest change first
+	ListChanged(ctx context.Context, since time.Time, afterID string, until time.Time, limit int) ([]entities.Product, error)
+}
+
+type ProductImageRepository interface {
+	Create(ctx context.Context, image *entities.ProductImage) error
+	GetByID(ctx context.Context, productID, id string) (*entities.ProductImage, error)
+	// ListByProduct returns the images of a product, main picture first
+	ListByProduct(ctx context.Context, productID string) ([]entities.ProductImage, error)
+	Delete(ctx context.Context, id string) error
+	// UpdateSortOrders saves the sort order of each image in one transaction
+	UpdateSortOrders(ctx context.Context, images []entities.ProductImage) error
+	// FilterReferenced returns which of the URLs a product or product image still points to, deleted
+	// products included so restoring one brings its pictures back
+	FilterReferenced(ctx context.Context, urls []string) (map[string]bool, error)
 }
 
 type ProductFilters struct {
+	CategoryID     string
+	IsActive       *bool
+	MinPrice       *int64
+	MaxPrice       *int64
+	SortBy         string // "popular" for most units sold first, newest first otherwise
+	IncludeDeleted bool   // Deleted products are listed too
+	Limit          int
+	Offset         int
+}
+
+type ProductSearchFilters struct {
+	Query      string
 	CategoryID string
-	IsActive   *bool
+	MinPrice   *int64
+	MaxPrice   *int64
 	Limit      int
 	Offset     int
 }
@@ -28,5 +83,22 @@ type CategoryRepository interface {
 	GetByID(ctx context.Context, id string) (*entities.Category, error)
 	Update(ctx context.Context, category *entities.Category) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]entities.Category, error)
+	// GetDeletedByID finds a deleted category
+	GetDeletedByID(ctx context.Context, id string) (*entities.Category, error)
+	// Restore brings a deleted category back
+	Restore(ctx context.Context, id string) error
+	// List returns categories by name, deleted ones too when includeDeleted is set
+	List(ctx context.Context, limit, offset int, includeDeleted bool) ([]entities.Category, error)
+	// GetByName finds a category by name, ignoring case
+	GetByName(ctx context.Context, name string) (*entities.Category, error)
+	// Merge moves the products and promotions of the source category to the target and deactivates the source.
+	// It returns the number of products moved.
+	Merge(ctx context.Context, sourceID, targetID string) (int64, error)
+	// ListAll returns every category, for building the tree
+	ListAll(ctx context.Context) ([]entities.Category, error)
+	// CountProducts counts the products filed directly under a category, deleted ones aside
+	CountProducts(ctx context.Context, id string) (int64, error)
+	// DeleteAndReassign moves the products and promotions of a category to targetID, when given, hands its
+	// subcategories to its parent and deletes it. It returns the number of products moved.
+	DeleteAndReassign(ctx context.Context, category *entities.Category, targetID string) (int64, error)
 }
\ No newline at end of file
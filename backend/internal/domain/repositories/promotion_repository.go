@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *entities.Promotion) error
+	GetByID(ctx context.Context, id string) (*entities.Promotion, error)
+	Update(ctx context.Context, promotion *entities.Promotion) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, filters PromotionFilters) ([]entities.Promotion, error)
+	// ListActive returns the enabled promotions that apply without a promo code
+	ListActive(ctx context.Context) ([]entities.Promotion, error)
+	GetByCode(ctx context.Context, code string) (*entities.Promotion, error)
+	// CountCodeRedemptions counts the open and paid transactions a promo code is used on, excluding one transaction
+	CountCodeRedemptions(ctx context.Context, code, excludeTransactionID string) (int64, error)
+
+	// Applied promotions per transaction
+	ReplaceTransactionPromotions(ctx context.Context, transactionID string, applied []entities.TransactionPromotion) error
+	GetTransactionPromotions(ctx context.Context, transactionID string) ([]entities.TransactionPromotion, error)
+}
+
+type PromotionFilters struct {
+	Type     entities.PromotionType
+	IsActive *bool
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"time"
+)
+
+type ReceiptDeliveryRepository interface {
+	Create(ctx context.Context, delivery *entities.ReceiptDelivery) error
+	Update(ctx context.Context, delivery *entities.ReceiptDelivery) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]entities.ReceiptDelivery, error)
+	ListByTransactionID(ctx context.Context, transactionID string) ([]entities.ReceiptDelivery, error)
+}
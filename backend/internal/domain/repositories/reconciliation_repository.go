@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type ReconciliationRepository interface {
+	CreateRun(ctx context.Context, run *entities.ReconciliationRun) error
+	GetRun(ctx context.Context, id string) (*entities.ReconciliationRun, error)
+	UpdateRun(ctx context.Context, run *entities.ReconciliationRun) error
+	ListRuns(ctx context.Context, limit, offset int) ([]entities.ReconciliationRun, error)
+	// ListPendingRuns returns the runs waiting to be processed, oldest first
+	ListPendingRuns(ctx context.Context, limit int) ([]entities.ReconciliationRun, error)
+	// ClaimRun moves a pending run to processing so it is only processed once
+	ClaimRun(ctx context.Context, run *entities.ReconciliationRun) error
+	// CompleteRun saves a run together with its items, replacing earlier ones, and creates the run when it is new
+	CompleteRun(ctx context.Context, run *entities.ReconciliationRun, items []entities.ReconciliationItem) error
+	// ListItems returns the items of a run, discrepancies first, optionally of one result only
+	ListItems(ctx context.Context, runID string, result entities.ReconciliationResult, limit, offset int) ([]entities.ReconciliationItem, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type RefundRepository interface {
+	// RecordRefund saves the refund, updates the transaction and restores stock of the returned items atomically
+	RecordRefund(ctx context.Context, refund *entities.Refund, transaction *entities.Transaction, restock bool) error
+	ListByPaymentID(ctx context.Context, paymentID string) ([]entities.Refund, error)
+	GetRefundedQuantities(ctx context.Context, transactionID string) (map[string]float64, error)
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type ReportRepository interface {
+	SalesByProduct(ctx context.Context, filters SalesReportFilters) ([]ProductSales, error)
+	SalesByCategory(ctx context.Context, filters SalesReportFilters) ([]CategorySales, error)
+	// DailySalesByProduct rolls net sold quantities up per product and calendar day; days without sales are omitted
+	DailySalesByProduct(ctx context.Context, filters SalesReportFilters) ([]DailyProductSales, error)
+	// DailyProfit sets net sales against the cost of goods of settled transactions per calendar day; days
+	// without sales are omitted
+	DailyProfit(ctx context.Context, filters SalesReportFilters) ([]DailyProfit, error)
+	// DailySettlements totals the settled payments per store, day of payment and payment method
+	DailySettlements(ctx context.Context, filters SalesReportFilters) ([]DailySettlement, error)
+}
+
+type SalesReportFilters struct {
+	DateFrom   time.Time // Inclusive
+	DateTo     time.Time // Exclusive
+	StoreID    string    // Every store when empty
+	Currency   string    // Every currency when empty, amounts of different currencies can't be summed
+	CategoryID string
+	SortBy     string // quantity, revenue or margin
+	Limit      int
+}
+
+// ProductSales is the net sales of a product, refunded quantities excluded
+type ProductSales struct {
+	ProductID    string  `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	CategoryID   string  `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	QuantitySold float64 `json:"quantity_sold"` // In the product's unit
+	Revenue      int64   `json:"revenue"`
+	Cost         int64   `json:"cost" visible:"admin"`
+	Margin       int64   `json:"margin" visible:"admin"`
+}
+
+// CategorySales is the net sales of a product category, refunded quantities excluded
+type CategorySales struct {
+	CategoryID   string  `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	QuantitySold float64 `json:"quantity_sold"` // Mixes the units of the products in it
+	Revenue      int64   `json:"revenue"`
+	Cost         int64   `json:"cost" visible:"admin"`
+	Margin       int64   `json:"margin" visible:"admin"`
+}
+
+// DailySettlement is what a store collected with one payment method on one day, and the tax in it
+type DailySettlement struct {
+	StoreID      string                 `json:"store_id"`
+	StoreCode    string                 `json:"store_code"`
+	Currency     entities.Currency      `json:"currency"`
+	Date         time.Time              `json:"date"`
+	Method       entities.PaymentMethod `json:"method"`
+	Transactions int                    `json:"transactions"`
+	Amount       int64                  `json:"amount"`
+	Tax          int64                  `json:"tax"` // Exclusive and inclusive tax of the transactions
+}
+
+// DailyProfit is the gross profit of the sales made on one day. Cost of goods is what the items used
+// when the sale was paid, recipe ingredients at their cost price then, and is not lowered by refunds.
+type DailyProfit struct {
+	Date         time.Time `json:"date"`
+	Transactions int       `json:"transactions"`
+	Uncosted     int       `json:"uncosted"` // Transactions whose cost of goods is not recorded yet, counted as 0
+	Revenue      int64     `json:"revenue"`  // Item totals before transaction level discounts, refunds excluded
+	CostOfGoods  int64     `json:"cost_of_goods"`
+	GrossProfit  int64     `json:"gross_profit"`
+}
+
+// DailyProductSales is the net quantity of a product sold on one day
+type DailyProductSales struct {
+	ProductID    string    `json:"product_id"`
+	Date         time.Time `json:"date"`
+	QuantitySold float64   `json:"quantity_sold"`
+}
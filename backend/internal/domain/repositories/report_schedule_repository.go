@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type ReportScheduleRepository interface {
+	Create(ctx context.Context, schedule *entities.ReportSchedule) error
+	GetByID(ctx context.Context, id string) (*entities.ReportSchedule, error)
+	Update(ctx context.Context, schedule *entities.ReportSchedule) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]entities.ReportSchedule, error)
+	// ListDue returns the active schedules of every store whose report is due, oldest first
+	ListDue(ctx context.Context, now time.Time, limit int) ([]entities.ReportSchedule, error)
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type SettingRepository interface {
+	List(ctx context.Context) ([]entities.Setting, error)
+	// Save creates or overwrites the given settings, leaving the others alone
+	Save(ctx context.Context, settings []entities.Setting) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type ShiftRepository interface {
+	Create(ctx context.Context, shift *entities.Shift) error
+	GetByID(ctx context.Context, id string) (*entities.Shift, error)
+	GetOpenByUserID(ctx context.Context, userID string) (*entities.Shift, error)
+	Update(ctx context.Context, shift *entities.Shift) error
+	ListByStatus(ctx context.Context, status entities.ShiftStatus) ([]entities.Shift, error)
+	// GetLastHandover returns the most recent shift closed before the given time that left a handover note
+	GetLastHandover(ctx context.Context, before time.Time) (*entities.Shift, error)
+	// GetTotals sums the successful payments of the shift's transactions and the cash paid back by its cashier
+	GetTotals(ctx context.Context, shift *entities.Shift) (*entities.ShiftTotals, error)
+	CreateCashMovement(ctx context.Context, movement *entities.CashMovement) error
+	// ListCashMovements returns the cash moved in and out of the drawer during a shift, oldest first
+	ListCashMovements(ctx context.Context, shiftID string) ([]entities.CashMovement, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type StoreRepository interface {
+	Create(ctx context.Context, store *entities.Store) error
+	GetByID(ctx context.Context, id string) (*entities.Store, error)
+	GetByCode(ctx context.Context, code string) (*entities.Store, error)
+	Update(ctx context.Context, store *entities.Store) error
+	List(ctx context.Context, limit, offset int) ([]entities.Store, error)
+}
+
+type storeKey struct{}
+
+// WithStore scopes the reads of products, users, transactions and payments made with the context to a store
+func WithStore(ctx context.Context, storeID string) context.Context {
+	return context.WithValue(ctx, storeKey{}, storeID)
+}
+
+// StoreFromContext returns the store a context is scoped to. Contexts without one, such as those of
+// background jobs and webhooks, see every store.
+func StoreFromContext(ctx context.Context) (string, bool) {
+	storeID, ok := ctx.Value(storeKey{}).(string)
+	return storeID, ok && storeID != ""
+}
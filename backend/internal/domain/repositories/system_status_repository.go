@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// SystemStatusRepository reads the backlogs and resource usage shown on the admin system status page
+type SystemStatusRepository interface {
+	ReceiptDeliveryBacklog(ctx context.Context) (*QueueBacklog, error)
+	PaymentExceptionBacklog(ctx context.Context) (*QueueBacklog, error)
+	// StuckPayments counts payments that expired before expiredBefore but are still pending,
+	// which the expiry job should have closed by then
+	StuckPayments(ctx context.Context, expiredBefore time.Time) (*QueueBacklog, error)
+	DatabaseSize(ctx context.Context) (int64, error)
+}
+
+// QueueBacklog is the work waiting in a queue
+type QueueBacklog struct {
+	Pending  int64      `json:"pending"`
+	Failed   int64      `json:"failed"`
+	OldestAt *time.Time `json:"oldest_at,omitempty"` // Oldest item still pending
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type TableRepository interface {
+	Create(ctx context.Context, table *entities.Table) error
+	GetByID(ctx context.Context, id string) (*entities.Table, error)
+	GetByName(ctx context.Context, name string) (*entities.Table, error)
+	Update(ctx context.Context, table *entities.Table) error
+	Delete(ctx context.Context, id string) error
+	// List returns the tables in floor plan order, by area then sort order
+	List(ctx context.Context, activeOnly bool) ([]entities.Table, error)
+	// ListOpenOrders returns the pending orders open on tables
+	ListOpenOrders(ctx context.Context) ([]TableOrder, error)
+	// GetOpenOrder returns the pending order open on a table, gorm.ErrRecordNotFound when it is free
+	GetOpenOrder(ctx context.Context, tableID string) (*TableOrder, error)
+}
+
+// TableOrder is the order open on a table
+type TableOrder struct {
+	TableID       string
+	TransactionID string
+	UserID        string
+	TotalAmount   int64
+	ItemCount     int
+	OpenedAt      time.Time
+}
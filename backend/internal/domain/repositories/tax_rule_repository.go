@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type TaxRuleRepository interface {
+	// Create and Update store the rule with its exemptions, replacing the previous exemptions
+	Create(ctx context.Context, rule *entities.TaxRule) error
+	GetByID(ctx context.Context, id string) (*entities.TaxRule, error)
+	Update(ctx context.Context, rule *entities.TaxRule) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]entities.TaxRule, error)
+	ListActive(ctx context.Context) ([]entities.TaxRule, error)
+
+	// Taxes charged per transaction
+	ReplaceTransactionTaxes(ctx context.Context, transactionID string, taxes []entities.TransactionTax) error
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type TenantRepository interface {
+	Create(ctx context.Context, tenant *entities.Tenant) error
+	GetByID(ctx context.Context, id string) (*entities.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*entities.Tenant, error)
+	Update(ctx context.Context, tenant *entities.Tenant) error
+	List(ctx context.Context, limit, offset int) ([]entities.Tenant, error)
+}
+
+type tenantKey struct{}
+
+// WithTenant scopes every statement made with the context to a tenant: reads only see its rows and
+// created rows belong to it
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// WithTenantOf scopes the context to the tenant of a record, for background work done on its behalf.
+// Records outside any tenant leave the context as it is.
+func WithTenantOf(ctx context.Context, tenantID *string) context.Context {
+	if tenantID == nil || *tenantID == "" {
+		return ctx
+	}
+	return WithTenant(ctx, *tenantID)
+}
+
+// TenantFromContext returns the tenant a context is scoped to. Contexts without one, such as those of
+// the platform, background jobs and webhooks, see every tenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
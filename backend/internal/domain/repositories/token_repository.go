@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *entities.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error)
+	// RotateRefreshToken revokes a refresh token in favour of next in one transaction. Returns
+	// ErrTokenRevoked when the token was revoked concurrently, so it can only be rotated once.
+	RotateRefreshToken(ctx context.Context, current, next *entities.RefreshToken) error
+	// RevokeRefreshToken revokes a refresh token of a user, a no-op when it is already revoked or unknown
+	RevokeRefreshToken(ctx context.Context, userID, tokenHash string) error
+	// RevokeUserRefreshTokens revokes every refresh token of a user that is still usable
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+
+	// ListActiveSessions returns the usable refresh token of every session of a user, most recently
+	// active first
+	ListActiveSessions(ctx context.Context, userID string) ([]entities.RefreshToken, error)
+	// ListSessionTokens returns every refresh token of a session of a user, oldest first
+	ListSessionTokens(ctx context.Context, userID, sessionID string) ([]entities.RefreshToken, error)
+	// TouchSession records a request made in a session, at most once a minute
+	TouchSession(ctx context.Context, sessionID string, at time.Time) error
+
+	RevokeAccessToken(ctx context.Context, token *entities.RevokedToken) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+
+	// CreatePasswordReset stores a password reset and spends the earlier ones of the user, only the
+	// latest code works
+	CreatePasswordReset(ctx context.Context, reset *entities.PasswordReset) error
+	// GetLatestPasswordReset returns the newest password reset of a user, spent or not
+	GetLatestPasswordReset(ctx context.Context, userID string) (*entities.PasswordReset, error)
+	GetPasswordResetByTokenHash(ctx context.Context, tokenHash string) (*entities.PasswordReset, error)
+	// CountPasswordResetAttempt counts a try at the code of a reset. Returns ErrInvalidResetCode once
+	// maxAttempts tries were made, or when the reset was verified or spent meanwhile.
+	CountPasswordResetAttempt(ctx context.Context, id string, maxAttempts int) error
+	// VerifyPasswordReset stores the reset token of a verified reset. Returns ErrInvalidResetCode when the
+	// reset was verified or spent meanwhile.
+	VerifyPasswordReset(ctx context.Context, reset *entities.PasswordReset) error
+	// SpendPasswordReset marks a reset as used. Returns ErrInvalidResetCode when it was spent meanwhile.
+	SpendPasswordReset(ctx context.Context, id string) error
+
+	// DeleteExpired purges refresh tokens, revoked access tokens and password resets that expired before
+	// the given time
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
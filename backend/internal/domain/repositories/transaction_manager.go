@@ -0,0 +1,11 @@
+package repositories
+
+import "context"
+
+// TransactionManager runs the calls of several repositories as one unit of work
+type TransactionManager interface {
+	// WithinTransaction runs fn in a database transaction, committed when fn returns nil and rolled back
+	// otherwise. Repository calls made with the context fn receives take part in the transaction, and
+	// calling it again from fn joins the transaction already running.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
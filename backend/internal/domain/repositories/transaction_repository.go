@@ -3,28 +3,54 @@ package repositories
 import (
 	"context"
 	"qris-pos-backend/internal/domain/entities"
+	"time"
 )
 
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *entities.Transaction) error
 	GetByID(ctx context.Context, id string) (*entities.Transaction, error)
 	GetByIDWithDetails(ctx context.Context, id string) (*entities.Transaction, error)
+	GetByTrackingToken(ctx context.Context, token string) (*entities.Transaction, error)
 	Update(ctx context.Context, transaction *entities.Transaction) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, filters TransactionFilters) ([]entities.Transaction, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]entities.Transaction, error)
 	GetByStatus(ctx context.Context, status entities.TransactionStatus, limit, offset int) ([]entities.Transaction, error)
+	ListStalePending(ctx context.Context, createdBefore time.Time, limit int) ([]entities.Transaction, error)
+	// ListCreatedBetween pages through the transactions created in [from, to) with their items and payment, oldest first
+	ListCreatedBetween(ctx context.Context, from, to time.Time, limit, offset int) ([]entities.Transaction, error)
+	ReleaseReservedStock(ctx context.Context, transactionID string) error
+	// ReserveStock takes the item quantities out of stock when the transaction holds no reservation,
+	// failing with ErrInsufficientStock when an item is short
+	ReserveStock(ctx context.Context, transactionID string) error
+	// ListUncosted returns the IDs of settled transactions whose cost of goods is not recorded yet, oldest first
+	ListUncosted(ctx context.Context, limit int) ([]string, error)
+	// RecordCostOfGoods costs the items of a settled transaction and deducts the ingredients of their
+	// recipes from stock, once
+	RecordCostOfGoods(ctx context.Context, transactionID string) error
+	// PurgeTraining hard deletes the training transactions of a store with everything recorded against them
+	PurgeTraining(ctx context.Context, storeID string) (int64, error)
 
 	// Transaction Items operations
 	AddItem(ctx context.Context, item *entities.TransactionItem) error
 	RemoveItem(ctx context.Context, transactionID, productID string) error
-	UpdateItemQuantity(ctx context.Context, transactionID, productID string, quantity int) error
+	UpdateItemQuantity(ctx context.Context, transactionID, productID string, quantity float64) error
+	// UpdateItemPrices saves the unit price, total, quantity break and manual discount amount of each item
+	UpdateItemPrices(ctx context.Context, items []entities.TransactionItem) error
+	// SetItemDiscount gives every line of a product in the transaction the manual discount, the amounts
+	// are saved when the transaction is recalculated
+	SetItemDiscount(ctx context.Context, transactionID, productID string, discount entities.ManualDiscount) error
 	GetItems(ctx context.Context, transactionID string) ([]entities.TransactionItem, error)
+	GetItem(ctx context.Context, transactionID, itemID string) (*entities.TransactionItem, error)
+	UpdateItemPrep(ctx context.Context, item *entities.TransactionItem) error
+	ReorderItems(ctx context.Context, transactionID string, itemIDs []string) error
 }
 
 type TransactionFilters struct {
 	UserID    string
+	ReceiptNo string // Number printed on the receipt, e.g. INV-20240521-0001
 	Status    entities.TransactionStatus
+	Held      bool    // Only carts parked on hold
 	DateFrom  *string // Format: "2023-01-01"
 	DateTo    *string // Format: "2023-12-31"
 	Limit     int
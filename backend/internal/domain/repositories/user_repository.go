@@ -5,11 +5,29 @@ import (
 	"qris-pos-backend/internal/domain/entities"
 )
 
+type userKey struct{}
+
+// WithUser records the signed in user a context acts for, so changes made with it can be attributed
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// UserFromContext returns the user a context acts for. Contexts of background jobs and webhooks have none.
+func UserFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userKey{}).(string)
+	return userID, ok && userID != ""
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *entities.User) error
 	GetByID(ctx context.Context, id string) (*entities.User, error)
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]entities.User, error)
+	// GetDeletedByID finds a deleted user of the current store
+	GetDeletedByID(ctx context.Context, id string) (*entities.User, error)
+	// Restore brings a deleted user back
+	Restore(ctx context.Context, id string) error
+	// List returns users newest first, deleted ones too when includeDeleted is set
+	List(ctx context.Context, limit, offset int, includeDeleted bool) ([]entities.User, error)
 }
\ No newline at end of file
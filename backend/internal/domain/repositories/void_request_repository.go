@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type VoidRequestRepository interface {
+	Create(ctx context.Context, request *entities.VoidRequest) error
+	GetByID(ctx context.Context, id string) (*entities.VoidRequest, error)
+	GetPendingByTransactionID(ctx context.Context, transactionID string) (*entities.VoidRequest, error)
+	// SaveDecision stores the decision of a request that is still pending in the database. Returns
+	// ErrVoidRequestDecided when another manager decided it first.
+	SaveDecision(ctx context.Context, request *entities.VoidRequest) error
+	Update(ctx context.Context, request *entities.VoidRequest) error
+	List(ctx context.Context, filters VoidRequestFilters) ([]entities.VoidRequest, error)
+}
+
+type VoidRequestFilters struct {
+	Status entities.VoidRequestStatus
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+type WebhookDeliveryFilters struct {
+	Status entities.WebhookDeliveryStatus
+	Limit  int
+	Offset int
+}
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id string) (*entities.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error
+	DeleteEndpoint(ctx context.Context, id string) error
+	ListEndpoints(ctx context.Context) ([]entities.WebhookEndpoint, error)
+	// ListActiveEndpoints returns the active endpoints of every store, for routing events
+	ListActiveEndpoints(ctx context.Context) ([]entities.WebhookEndpoint, error)
+
+	// CreateDelivery queues the delivery unless the endpoint has one with the same fingerprint, reporting
+	// whether it was created
+	CreateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) (bool, error)
+	GetDelivery(ctx context.Context, endpointID, id string) (*entities.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error
+	// ListDueDeliveries returns pending deliveries whose next attempt is due, oldest first
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]entities.WebhookDelivery, error)
+	// ListDeliveries returns the deliveries of an endpoint, newest first
+	ListDeliveries(ctx context.Context, endpointID string, filters WebhookDeliveryFilters) ([]entities.WebhookDelivery, error)
+}
@@ -0,0 +1,63 @@
+package services
+
+import "math"
+
+// ForecastMethod selects how the daily demand rate is estimated from past sales
+type ForecastMethod string
+
+const (
+	ForecastMovingAverage        ForecastMethod = "sma"
+	ForecastExponentialSmoothing ForecastMethod = "ses"
+)
+
+// DemandForecaster estimates product demand from a daily sales history.
+//
+// The simple moving average is the mean of the last Window days. Simple exponential
+// smoothing runs over the whole history and weighs each day by Alpha against everything
+// before it, so recent days count the most. Both yield a flat daily rate: trends and
+// weekly seasonality are not modelled.
+type DemandForecaster struct {
+	Window int
+	Alpha  float64
+}
+
+func NewDemandForecaster(window int, alpha float64) *DemandForecaster {
+	return &DemandForecaster{
+		Window: window,
+		Alpha:  alpha,
+	}
+}
+
+// DailyRate estimates the units sold per day from a history ordered oldest day first.
+// Days without sales must be present as zeros.
+func (f *DemandForecaster) DailyRate(history []float64, method ForecastMethod) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+
+	if method == ForecastExponentialSmoothing {
+		alpha := math.Min(math.Max(f.Alpha, 0), 1)
+		level := history[0]
+		for _, quantity := range history[1:] {
+			level = alpha*quantity + (1-alpha)*level
+		}
+		return level
+	}
+
+	window := history
+	if f.Window > 0 && len(window) > f.Window {
+		window = window[len(window)-f.Window:]
+	}
+	var total float64
+	for _, quantity := range window {
+		total += quantity
+	}
+	return total / float64(len(window))
+}
+
+// ReorderQuantity is the number of whole units to order so that stock covers the demand
+// of the given number of days, or 0 when the stock on hand is enough
+func (f *DemandForecaster) ReorderQuantity(stock, dailyRate float64, days int) int {
+	needed := math.Ceil(dailyRate * float64(days))
+	return max(int(math.Ceil(needed-stock)), 0)
+}
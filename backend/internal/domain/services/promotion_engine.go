@@ -0,0 +1,166 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+// AppliedPromotion is a promotion that contributed a discount to a cart
+type AppliedPromotion struct {
+	PromotionID string                 `json:"promotion_id"`
+	Name        string                 `json:"name"`
+	Type        entities.PromotionType `json:"type"`
+	Discount    int64                  `json:"discount"`
+}
+
+// PromotionResult is the outcome of evaluating promotions against a cart
+type PromotionResult struct {
+	Subtotal      int64              `json:"subtotal"`
+	TotalDiscount int64              `json:"total_discount"`
+	Applied       []AppliedPromotion `json:"applied"`
+}
+
+// PromotionEngine evaluates promotions against transaction items.
+//
+// Stacking rules: stackable promotions are applied in priority order, each on the
+// amount left after the previous ones. Non-stackable (exclusive) promotions are never
+// combined; the engine picks whichever is larger for the customer — the best single
+// exclusive promotion or the combined stackable promotions.
+type PromotionEngine struct{}
+
+func NewPromotionEngine() *PromotionEngine {
+	return &PromotionEngine{}
+}
+
+// Evaluate computes the discount for the given items at the given time
+func (e *PromotionEngine) Evaluate(promotions []entities.Promotion, items []entities.TransactionItem, at time.Time) *PromotionResult {
+	result := &PromotionResult{Applied: []AppliedPromotion{}}
+
+	lineTotals := make([]int64, len(items))
+	for i, item := range items {
+		lineTotals[i] = item.TotalPrice
+		result.Subtotal += item.TotalPrice
+	}
+
+	var stackable, exclusive []entities.Promotion
+	for _, promo := range promotions {
+		if !promo.IsActiveAt(at) || result.Subtotal < promo.MinBasket {
+			continue
+		}
+		if promo.Stackable {
+			stackable = append(stackable, promo)
+		} else {
+			exclusive = append(exclusive, promo)
+		}
+	}
+
+	sort.SliceStable(stackable, func(i, j int) bool {
+		return stackable[i].Priority > stackable[j].Priority
+	})
+
+	// Combined stackable promotions, each applied on the remaining line amounts
+	remaining := append([]int64(nil), lineTotals...)
+	var stacked []AppliedPromotion
+	var stackedTotal int64
+	for i := range stackable {
+		discount := e.discountFor(&stackable[i], items, remaining, true)
+		if discount <= 0 {
+			continue
+		}
+		stacked = append(stacked, newApplied(&stackable[i], discount))
+		stackedTotal += discount
+	}
+
+	// Best single exclusive promotion on the full line amounts
+	var best *AppliedPromotion
+	bestPriority := 0
+	for i := range exclusive {
+		discount := e.discountFor(&exclusive[i], items, append([]int64(nil), lineTotals...), false)
+		if discount <= 0 {
+			continue
+		}
+		if best == nil || discount > best.Discount ||
+			(discount == best.Discount && exclusive[i].Priority > bestPriority) {
+			applied := newApplied(&exclusive[i], discount)
+			best = &applied
+			bestPriority = exclusive[i].Priority
+		}
+	}
+
+	if best != nil && best.Discount > stackedTotal {
+		result.Applied = append(result.Applied, *best)
+		result.TotalDiscount = best.Discount
+	} else if len(stacked) > 0 {
+		result.Applied = stacked
+		result.TotalDiscount = stackedTotal
+	}
+
+	return result
+}
+
+// discountFor computes a promotion discount against the remaining line amounts.
+// When consume is true the remaining amounts are reduced by the discount.
+func (e *PromotionEngine) discountFor(promo *entities.Promotion, items []entities.TransactionItem, remaining []int64, consume bool) int64 {
+	var total int64
+
+	// A fixed amount is spread over the scoped lines in order, never taking a line below zero
+	if promo.Type == entities.PromotionFixedAmount {
+		left := promo.Amount
+		for i := range items {
+			if left <= 0 {
+				break
+			}
+			if remaining[i] <= 0 || !promo.AppliesTo(&items[i].Product) {
+				continue
+			}
+			discount := min(left, remaining[i])
+			if consume {
+				remaining[i] -= discount
+			}
+			left -= discount
+			total += discount
+		}
+		return total
+	}
+
+	for i := range items {
+		item := &items[i]
+		if remaining[i] <= 0 || !promo.AppliesTo(&item.Product) {
+			continue
+		}
+
+		var discount int64
+		switch promo.Type {
+		case entities.PromotionCategoryPercent, entities.PromotionHappyHour, entities.PromotionPercentage:
+			discount = entities.PercentOf(remaining[i], promo.Percentage)
+		case entities.PromotionBuyXGetY:
+			bundle := promo.BuyQuantity + promo.GetQuantity
+			if bundle <= 0 {
+				continue
+			}
+			// Only whole bundles count, also for products sold by weight or volume
+			freeUnits := math.Floor(item.Quantity/float64(bundle)) * float64(promo.GetQuantity)
+			discount = entities.LinePrice(item.UnitPrice, freeUnits)
+		}
+
+		discount = min(discount, remaining[i])
+		if consume {
+			remaining[i] -= discount
+		}
+		total += discount
+	}
+
+	return total
+}
+
+func newApplied(promo *entities.Promotion, discount int64) AppliedPromotion {
+	return AppliedPromotion{
+		PromotionID: promo.ID,
+		Name:        promo.Name,
+		Type:        promo.Type,
+		Discount:    discount,
+	}
+}
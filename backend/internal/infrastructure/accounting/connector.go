@@ -0,0 +1,79 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/tracing"
+)
+
+// Accounting systems a journal can be posted to
+const (
+	ConnectorAccurate = "accurate"
+	ConnectorJurnal   = "jurnal"
+	ConnectorXero     = "xero"
+)
+
+// maxErrorBody caps how much of a failed response is kept in the error
+const maxErrorBody = 1024
+
+// JournalLine debits or credits one account, exactly one of Debit and Credit is set
+type JournalLine struct {
+	Account     string `json:"account"` // Account code, or name for Jurnal
+	Description string `json:"description"`
+	Debit       int64  `json:"debit"`
+	Credit      int64  `json:"credit"`
+}
+
+// JournalEntry is a balanced journal of one store and day
+type JournalEntry struct {
+	Date        time.Time     `json:"date"`
+	StoreID     string        `json:"store_id"`
+	StoreCode   string        `json:"store_code"`
+	Currency    string        `json:"currency"`  // Currency of the store, amounts are in its minor unit
+	Reference   string        `json:"reference"` // Unique per store and day, e.g. POS-JKT01-20260115
+	Description string        `json:"description"`
+	Lines       []JournalLine `json:"lines"`
+}
+
+// Connector posts journals to an accounting system
+type Connector interface {
+	Name() string
+	// PostJournal creates the journal and returns its ID or number in the accounting system
+	PostJournal(ctx context.Context, entry *JournalEntry) (string, error)
+}
+
+// NewConnectors returns a connector for every accounting system whose credentials are configured
+func NewConnectors(cfg config.AccountingConfig) []Connector {
+	var connectors []Connector
+	if cfg.AccurateHost != "" && cfg.AccurateAPIToken != "" && cfg.AccurateSignatureSecret != "" {
+		connectors = append(connectors, NewAccurateConnector(cfg))
+	}
+	if cfg.JurnalAPIKey != "" {
+		connectors = append(connectors, NewJurnalConnector(cfg))
+	}
+	if cfg.XeroClientID != "" && cfg.XeroClientSecret != "" {
+		connectors = append(connectors, NewXeroConnector(cfg))
+	}
+	return connectors
+}
+
+func newHTTPClient(cfg config.AccountingConfig) *http.Client {
+	return &http.Client{
+		Timeout:   time.Duration(cfg.TimeoutSeconds) * time.Second,
+		Transport: tracing.Transport(nil),
+	}
+}
+
+// checkResponse turns a non 2xx answer into an error carrying the start of its body
+func checkResponse(resp *http.Response, system string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return fmt.Errorf("%s answered with status %d: %s", system, resp.StatusCode, string(body))
+}
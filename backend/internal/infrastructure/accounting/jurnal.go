@@ -0,0 +1,103 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+// JurnalConnector posts journal entries to Jurnal by Mekari with a company API key
+type JurnalConnector struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type jurnalLine struct {
+	AccountName string `json:"account_name"`
+	Description string `json:"description"`
+	Debit       int64  `json:"debit"`
+	Credit      int64  `json:"credit"`
+}
+
+type jurnalJournalEntry struct {
+	JournalEntry struct {
+		TransactionDate string       `json:"transaction_date"`
+		TransactionNo   string       `json:"transaction_no"`
+		Memo            string       `json:"memo"`
+		AccountLines    []jurnalLine `json:"transaction_account_lines_attributes"`
+		Tags            []string     `json:"tags"`
+	} `json:"journal_entry"`
+}
+
+type jurnalResponse struct {
+	JournalEntry struct {
+		ID            int64  `json:"id"`
+		TransactionNo string `json:"transaction_no"`
+	} `json:"journal_entry"`
+}
+
+func NewJurnalConnector(cfg config.AccountingConfig) *JurnalConnector {
+	return &JurnalConnector{
+		baseURL:    strings.TrimRight(cfg.JurnalBaseURL, "/"),
+		apiKey:     cfg.JurnalAPIKey,
+		httpClient: newHTTPClient(cfg),
+	}
+}
+
+func (c *JurnalConnector) Name() string {
+	return ConnectorJurnal
+}
+
+func (c *JurnalConnector) PostJournal(ctx context.Context, entry *JournalEntry) (string, error) {
+	var payload jurnalJournalEntry
+	payload.JournalEntry.TransactionDate = entry.Date.Format("02/01/2006")
+	payload.JournalEntry.TransactionNo = entry.Reference
+	payload.JournalEntry.Memo = entry.Description
+	payload.JournalEntry.Tags = []string{"POS", entry.StoreCode}
+	for _, line := range entry.Lines {
+		payload.JournalEntry.AccountLines = append(payload.JournalEntry.AccountLines, jurnalLine{
+			AccountName: line.Account,
+			Description: line.Description,
+			Debit:       line.Debit,
+			Credit:      line.Credit,
+		})
+	}
+
+	body, err := json.Marshal(&payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode journal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/journal_entries", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, "jurnal"); err != nil {
+		return "", err
+	}
+
+	var result jurnalResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.JournalEntry.TransactionNo != "" {
+		return result.JournalEntry.TransactionNo, nil
+	}
+	return strconv.FormatInt(result.JournalEntry.ID, 10), nil
+}
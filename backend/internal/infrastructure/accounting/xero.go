@@ -0,0 +1,161 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+const (
+	xeroTokenURL    = "https://identity.xero.com/connect/token"
+	xeroJournalsURL = "https://api.xero.com/api.xro/2.0/ManualJournals"
+)
+
+// XeroConnector posts manual journals to the organisation of a Xero custom connection. Access tokens
+// come from the client credentials grant and are reused until shortly before they expire.
+type XeroConnector struct {
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type xeroJournalLine struct {
+	LineAmount  int64  `json:"LineAmount"` // Positive debits, negative credits
+	AccountCode string `json:"AccountCode"`
+	Description string `json:"Description"`
+}
+
+type xeroManualJournal struct {
+	Narration    string            `json:"Narration"`
+	Date         string            `json:"Date"`
+	Status       string            `json:"Status"`
+	JournalLines []xeroJournalLine `json:"JournalLines"`
+}
+
+type xeroJournalsResponse struct {
+	ManualJournals []struct {
+		ManualJournalID string `json:"ManualJournalID"`
+	} `json:"ManualJournals"`
+}
+
+func NewXeroConnector(cfg config.AccountingConfig) *XeroConnector {
+	return &XeroConnector{
+		clientID:     cfg.XeroClientID,
+		clientSecret: cfg.XeroClientSecret,
+		httpClient:   newHTTPClient(cfg),
+	}
+}
+
+func (c *XeroConnector) Name() string {
+	return ConnectorXero
+}
+
+func (c *XeroConnector) PostJournal(ctx context.Context, entry *JournalEntry) (string, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	journal := xeroManualJournal{
+		// Xero has no journal number field, the reference leads the narration so it can be searched
+		Narration: fmt.Sprintf("%s %s", entry.Reference, entry.Description),
+		Date:      entry.Date.Format("2006-01-02"),
+		Status:    "POSTED",
+	}
+	for _, line := range entry.Lines {
+		journal.JournalLines = append(journal.JournalLines, xeroJournalLine{
+			LineAmount:  line.Debit - line.Credit,
+			AccountCode: line.Account,
+			Description: line.Description,
+		})
+	}
+
+	body, err := json.Marshal(map[string][]xeroManualJournal{"ManualJournals": {journal}})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode journal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, xeroJournalsURL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, "xero"); err != nil {
+		return "", err
+	}
+
+	var result xeroJournalsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.ManualJournals) == 0 {
+		return "", errors.New("xero returned no journal")
+	}
+	return result.ManualJournals[0].ManualJournalID, nil
+}
+
+// token returns a cached access token, or requests a new one when it is about to expire
+func (c *XeroConnector) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Now().Before(c.expiresAt) {
+		return c.accessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", "accounting.transactions")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, xeroTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request Xero token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, "xero identity"); err != nil {
+		return "", err
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	c.accessToken = result.AccessToken
+	// A minute early, so a token never expires between this check and the request
+	c.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return c.accessToken, nil
+}
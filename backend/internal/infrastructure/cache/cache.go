@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss is returned by Get when the key holds nothing
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores serialized values for a limited time. Implementations are safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the value, a zero ttl keeps it until deleted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New returns the Redis cache when a Redis URL is configured, the in-memory cache otherwise
+func New(cfg config.CacheConfig) (Cache, error) {
+	if cfg.RedisURL == "" {
+		return NewMemoryCache(), nil
+	}
+
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache redis url: %w", err)
+	}
+	return NewRedisCache(redis.NewClient(opts)), nil
+}
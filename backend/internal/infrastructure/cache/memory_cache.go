@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired entries are dropped
+const sweepInterval = time.Minute
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time // Zero never expires
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// MemoryCache keeps the values in process memory, for a single instance and for tests
+type MemoryCache struct {
+	mu        sync.Mutex
+	entries   map[string]entry
+	lastSweep time.Time
+}
+
+// NewMemoryCache creates a new in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries:   make(map[string]entry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.expired(time.Now()) {
+		return nil, ErrMiss
+	}
+	return e.value, nil
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(now)
+
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+	c.entries[key] = e
+	return nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+func (c *MemoryCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < sweepInterval {
+		return
+	}
+	c.lastSweep = now
+
+	for key, e := range c.entries {
+		if e.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+}
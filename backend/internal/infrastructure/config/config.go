@@ -3,15 +3,42 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	App      AppConfig
-	Server   ServerConfig
-	Database DatabaseConfig
-	Midtrans MidtransConfig
-	JWT      JWTConfig
-	Storage  StorageConfig
+	App            AppConfig
+	Server         ServerConfig
+	CORS           CORSConfig
+	RateLimit      RateLimitConfig
+	Cache          CacheConfig
+	Database       DatabaseConfig
+	Midtrans       MidtransConfig
+	Xendit         XenditConfig
+	JWT            JWTConfig
+	PasswordReset  PasswordResetConfig
+	Storage        StorageConfig
+	Jobs           JobsConfig
+	Payment        PaymentConfig
+	Store          StoreConfig
+	Notification   NotificationConfig
+	Shift          ShiftConfig
+	Alert          AlertConfig
+	Inventory      InventoryConfig
+	Loyalty        LoyaltyConfig
+	Discount       DiscountConfig
+	SKU            SKUConfig
+	Export         ExportConfig
+	Tracing        TracingConfig
+	Sync           SyncConfig
+	Webhook        WebhookConfig
+	Outbox         OutboxConfig
+	Accounting     AccountingConfig
+	Reconciliation ReconciliationConfig
+	Print          PrintConfig
+	Share          ShareConfig
+	Secrets        SecretsConfig
+	Tenancy        TenancyConfig
 }
 
 type AppConfig struct {
@@ -21,8 +48,54 @@ type AppConfig struct {
 }
 
 type ServerConfig struct {
-	Host string
-	Port int
+	Host                  string
+	Port                  int
+	RequestTimeoutSeconds int // Context deadline of a regular API request
+	PaymentTimeoutSeconds int // Payment routes wait on Midtrans, so they get longer
+	ReportTimeoutSeconds  int
+	MaxConcurrentRequests int      // In-flight requests before new ones are queued, then rejected
+	MaxConcurrentReports  int      // Reports are heavy queries and get their own smaller pool
+	QueueTimeoutMillis    int      // How long a request waits for a free slot before a 503
+	TrustedProxies        []string // Proxies whose X-Forwarded-For is believed, every proxy when empty
+	DocsEnabled           bool     // Serve the Swagger UI at /api/v1/docs and the spec at /openapi.json
+}
+
+// CORSConfig lists the browser origins allowed to call the API. A "*" origin allows any site
+// but then never sends credentials.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAgeSeconds    int // How long browsers cache a preflight answer
+}
+
+// RateLimitConfig sets the token buckets requests are throttled with. A PerMinute of 0 disables a limit.
+type RateLimitConfig struct {
+	Enabled  bool
+	RedisURL string // Shares the buckets between replicas, in-memory per process when empty
+
+	GlobalPerMinute int // Every API request, per client IP
+	GlobalBurst     int
+
+	LoginPerMinute int // Per client IP, slows down password guessing
+	LoginBurst     int
+
+	PasswordResetPerMinute int // Forgot password requests and code checks, per client IP
+	PasswordResetBurst     int
+
+	CallbackPerMinute int // Payment gateway webhooks, per client IP
+	CallbackBurst     int
+
+	QRISPerMinute int // QRIS generation, per cashier
+	QRISBurst     int
+}
+
+// CacheConfig configures the cache in front of the product catalog reads
+type CacheConfig struct {
+	RedisURL          string // Shared between replicas, in-memory per process when empty
+	ProductTTLSeconds int    // 0 disables the catalog cache
 }
 
 type DatabaseConfig struct {
@@ -37,21 +110,290 @@ type DatabaseConfig struct {
 }
 
 type MidtransConfig struct {
-	ServerKey   string
-	ClientKey   string
-	Environment string
+	ServerKey      string
+	ClientKey      string
+	Environment    string
+	TimeoutSeconds int // HTTP timeout of gateway calls, the SDK default is 80 seconds
+
+	StatusTimeoutSeconds   int // Status checks are polled while the customer waits, so they get a shorter timeout
+	MaxRetries             int // Retries of a status check that failed to reach Midtrans, charges are never retried
+	RetryBackoffMillis     int // Base delay between retries, doubled on each one and jittered
+	BreakerThreshold       int // Failures in a row after which calls fail fast, 0 disables the circuit breaker
+	BreakerCooldownSeconds int // How long calls fail fast before a probe call is let through
+}
+
+type XenditConfig struct {
+	SecretKey      string
+	CallbackToken  string // Verification token Xendit sends with every webhook
+	TimeoutSeconds int
 }
 
 type JWTConfig struct {
-	Secret     string
-	ExpiryHour int
+	Secret             string
+	ExpiryHour         int
+	RefreshExpiryHours int // Lifetime of a refresh token, each refresh issues a new one
+	Issuer             string
+	Audience           string
+	LeewaySeconds      int // Tolerated clock skew between servers when checking exp/iat/nbf
+}
+
+// PasswordResetConfig sets the codes emailed to users who forgot their password
+type PasswordResetConfig struct {
+	CodeTTLMinutes        int // How long an emailed code, and the reset token it is traded for, stays valid
+	MaxAttempts           int // Wrong codes before the code is spent and a new one must be requested
+	ResendCooldownSeconds int // Minimum time between two codes to the same user
 }
 
 type StorageConfig struct {
-	SupabaseURL       string
-	SupabaseKey       string
-	BucketName        string
-	MaxFileSizeMB     int
+	Driver        string // supabase, s3 or local
+	SupabaseURL   string
+	SupabaseKey   string
+	BucketName    string
+	MaxFileSizeMB int
+
+	// S3 compatible storage such as MinIO
+	S3Endpoint  string // host[:port] of the S3 API
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+	S3PublicURL string // Base URL the image bucket is served from, defaults to the bucket on the endpoint
+
+	// Local disk, files are served by the API under /uploads
+	LocalDir        string
+	LocalPublicURL  string // Base URL the API is reached at for /uploads
+	LocalSigningKey string // Signs download links of private buckets, a random key is used when empty
+
+	ImageMaxDimension     int // Longest side product images are scaled down to on upload
+	ThumbnailDimension    int // Longest side of the thumbnail made of each product image
+	MaxImagesPerProduct   int
+	OrphanImageGraceHours int // Age before an image no product refers to is deleted from the bucket
+}
+
+type JobsConfig struct {
+	ExpiryIntervalSeconds         int
+	TransactionTTLMinutes         int
+	CancelExpiredOnGateway        bool
+	ReconcileIntervalSeconds      int // How often pending gateway payments are requeried in case a webhook was lost
+	PopularityIntervalSeconds     int // How often product sales counts behind the popular sort are rolled up
+	PopularityWindowDays          int // Days of sales the counts cover
+	TokenCleanupIntervalSeconds   int // How often expired refresh tokens and revoked access tokens are purged
+	ImageCleanupIntervalSeconds   int // How often product images no product refers to are removed from storage
+	PriceScheduleIntervalSeconds  int // How often price schedules are started and ended
+	ReportScheduleIntervalSeconds int // How often due scheduled reports are emailed
+	SettingsReloadIntervalSeconds int // How often runtime settings are reloaded, picking up changes made through another instance
+	CostingIntervalSeconds        int // How often paid sales are costed and the ingredients of their recipes deducted
+}
+
+type PaymentConfig struct {
+	QRISMinAmount                   int64
+	QRISMaxAmount                   int64
+	QRISExpiryMinutes               int      // Default lifetime of a QRIS, the qris_expiry_minutes setting overrides it
+	StatusCheckConcurrency          int      // Parallel gateway requeries of a batch status check
+	QRISWorkers                     int      // Parallel gateway charges of asynchronous QRIS generation
+	QRISQueueSize                   int      // Asynchronous QRIS generations waiting for a worker before new ones are refused
+	QRISJobRetentionSeconds         int      // How long a finished QRIS generation job can still be polled
+	NotificationIntervalSeconds     int      // How often stored gateway notifications that failed are retried
+	NotificationMaxAttempts         int      // Attempts before a notification is left for an admin to replay
+	NotificationRetryBackoffSeconds int      // Delay before the first retry, doubled on every further attempt
+	CallbackAllowedIPs              []string // Addresses or CIDR ranges gateway callbacks may come from, any address when empty
+	Gateway                         string   // midtrans or xendit, the acquirer new QRIS payments go through
+	QRISMode                        string   // gateway, or local to build QRIS payloads in-house from the merchant's own NMID
+	LocalQRIS                       LocalQRISConfig
+}
+
+// LocalQRISConfig identifies the merchant in locally built QRIS payloads. A static QRIS issued
+// to the merchant is used as the template when set, otherwise one is built from the NMID fields.
+type LocalQRISConfig struct {
+	StaticPayload string
+	NMID          string
+	Criteria      string // UMI, UKE, UME or UBE
+	CategoryCode  string // Merchant category code (MCC)
+	MerchantName  string
+	MerchantCity  string
+	PostalCode    string
+}
+
+// StoreConfig is the merchant information printed on receipts until the merchant is onboarded, its
+// profile replaces it then. Name and footer are defaults, the store_name and receipt_footer settings
+// override them at runtime.
+type StoreConfig struct {
+	Name    string
+	Address string
+	Phone   string
+	TaxID   string // NPWP
+	Footer  string
+
+	OrderTrackingURL string // Public order status page, the tracking token is appended
+}
+
+// NotificationConfig configures digital receipt delivery. A channel is enabled once its provider is configured.
+type NotificationConfig struct {
+	SMTPHost              string
+	SMTPPort              int
+	SMTPUsername          string
+	SMTPPassword          string
+	SMTPFrom              string
+	WhatsAppAPIURL        string
+	WhatsAppPhoneNumberID string
+	WhatsAppAccessToken   string
+	IntervalSeconds       int
+	MaxAttempts           int
+	RetryBackoffSeconds   int // Delay before the first retry, doubled on every further attempt
+}
+
+type ShiftConfig struct {
+	Required                  bool  // Reject sales from users without an open shift
+	VarianceApprovalThreshold int64 // Cash differences beyond this need a manager's approval; 0 disables approvals
+}
+
+// AlertConfig tunes the anomaly detection job. Business hours are read in Timezone.
+type AlertConfig struct {
+	IntervalSeconds      int
+	LookbackMinutes      int // How far back each run scans for refunds and discounts
+	VoidWindowMinutes    int // Window the void count is compared against its baseline in
+	VoidBaselineDays     int
+	VoidSpikeMultiplier  float64 // Voids above baseline times this raise an alert
+	VoidMinCount         int     // Ignore spikes below this many voids
+	BusinessOpenHour     int
+	BusinessCloseHour    int
+	Timezone             string
+	DiscountZScore       float64 // Discount rate standard deviations above the mean that raise an alert
+	DiscountBaselineDays int
+	DiscountMinSamples   int
+	NotifyEmails         []string // Owners emailed when an alert is raised
+}
+
+// InventoryConfig tunes demand forecasting for reorder suggestions
+type InventoryConfig struct {
+	ForecastHistoryDays int     // Days of sales history a forecast is based on
+	MovingAverageDays   int     // Window of the simple moving average
+	SmoothingAlpha      float64 // Weight of the most recent day in exponential smoothing, between 0 and 1
+	LeadTimeDays        int     // Days a reorder takes to arrive, covered on top of the forecast horizon
+}
+
+// LoyaltyConfig sets how customers earn and spend points
+type LoyaltyConfig struct {
+	EarnAmount int64 // Rupiah paid per point earned; 0 stops earning
+	PointValue int64 // Rupiah discount a redeemed point is worth; 0 stops redemption
+}
+
+// DiscountConfig caps the manual discounts each role can give, as a percentage of what is discounted.
+// A cashier going past their cap needs an admin to enter their credentials.
+type DiscountConfig struct {
+	CashierMaxPercent float64
+	AdminMaxPercent   float64
+}
+
+// SKUConfig shapes the SKUs generated for products created without one, e.g. BEV-000042
+type SKUConfig struct {
+	DefaultPrefix string // Used when the category has no SKU prefix
+	Digits        int    // The sequence number is zero padded to this width
+}
+
+// ExportConfig configures the merchant data exports built in the background
+type ExportConfig struct {
+	Bucket          string // Private bucket the export archives are stored in
+	IntervalSeconds int
+	LinkExpiryHours int // How long a download link stays valid
+	MaxRangeDays    int
+}
+
+// TracingConfig configures the OpenTelemetry trace export. Tracing is off while no OTLP endpoint is set.
+type TracingConfig struct {
+	Endpoint    string
+	ServiceName string
+	SampleRatio float64 // Share of new traces recorded, between 0 and 1
+}
+
+// SyncConfig bounds the offline sync of terminals that lost their connection
+type SyncConfig struct {
+	MaxAgeHours       int // Offline sales older than this are rejected instead of recorded
+	MaxClockSkewMins  int // How far ahead of the server a terminal's clock may run
+	PageSize          int // Product changes returned per pull
+	SettleDelayMillis int // Changes younger than this wait for the next pull, so writes still committing aren't skipped
+}
+
+// WebhookConfig controls the delivery of POS events to the URLs merchants register
+type WebhookConfig struct {
+	IntervalSeconds     int
+	MaxAttempts         int
+	RetryBackoffSeconds int // Delay before the first retry, doubled on every further attempt
+	TimeoutSeconds      int // How long a receiver may take to answer
+	LowStockThreshold   int // Stock at or below which a change sends stock.low
+}
+
+// OutboxConfig controls the relay publishing outbox events to the message bus
+type OutboxConfig struct {
+	Driver              string // none, nats or kafka
+	IntervalSeconds     int
+	BatchSize           int
+	RetryBackoffSeconds int    // Delay before retrying after the bus failed, doubled on every further attempt
+	RetentionHours      int    // How long events are kept, published or not, so a bus enabled later can catch up
+	TopicPrefix         string // An event goes to the subject or topic <prefix>.<event type>
+	TimeoutSeconds      int
+	NATSURL             string
+	NATSJetStream       bool // Wait for a JetStream stream to store each message instead of a plain publish
+	KafkaBrokers        []string
+}
+
+// AccountingConfig maps the daily sales journal to the chart of accounts and holds the credentials
+// of the accounting systems it can be posted to. Jurnal matches accounts by name, so use account names
+// there and account codes for Accurate and Xero.
+type AccountingConfig struct {
+	SalesAccount      string
+	TaxPayableAccount string
+	CashAccount       string // Clearing accounts debited per payment method
+	QRISAccount       string
+	CardAccount       string
+	EWalletAccount    string
+	OtherAccount      string
+	TimeoutSeconds    int
+
+	AccurateHost            string // Database host of the company, e.g. https://zeus.accurate.id
+	AccurateAPIToken        string
+	AccurateSignatureSecret string
+	JurnalBaseURL           string
+	JurnalAPIKey            string
+	XeroClientID            string // Custom connection app, tokens come from the client credentials grant
+	XeroClientSecret        string
+}
+
+// ReconciliationConfig bounds the settlement reconciliation runs. Runs pulled from Midtrans check every
+// gateway payment of the range one by one, so they are built in the background.
+type ReconciliationConfig struct {
+	IntervalSeconds int // How often queued runs are picked up
+	MaxPayments     int // Payments a pulled run checks at most, larger ranges are refused
+	MaxRangeDays    int
+}
+
+// PrintConfig controls the print spooler the agents of networked thermal printers pull jobs from
+type PrintConfig struct {
+	LeaseSeconds   int // How long an agent has to acknowledge a claimed job before it is handed out again
+	MaxAttempts    int // Claims of a job before it is given up on
+	MaxWaitSeconds int // Longest an agent's poll waits for a job to be queued
+}
+
+// ShareConfig configures the links customers open their invoice with, no login needed until they expire
+type ShareConfig struct {
+	Secret         string // Signs share tokens, the JWT secret when empty
+	URL            string // Public invoice page, the share token is appended
+	ExpiryHours    int    // Lifetime of a link when the request doesn't give one
+	MaxExpiryHours int
+}
+
+// SecretsConfig holds the keys secrets stored in the database are encrypted with, e.g. the Midtrans
+// server keys of stores. Each is the base64 of 32 random bytes.
+type SecretsConfig struct {
+	EncryptionKey string   // New secrets are encrypted with it, they can't be stored without one
+	PreviousKeys  []string // Rotated out keys, still decrypting the secrets encrypted with them
+}
+
+// TenancyConfig turns on SaaS mode, where one deployment serves several merchants whose data is kept
+// apart. Requests pick their tenant with a subdomain of the base domain or the X-Tenant-ID header.
+type TenancyConfig struct {
+	Enabled    bool
+	BaseDomain string // e.g. "pos.example.com", so "kopi.pos.example.com" is the tenant "kopi"
 }
 
 func Load() (*Config, error) {
@@ -62,8 +404,42 @@ func Load() (*Config, error) {
 			LogLevel: getEnv("LOG_LEVEL", "info"),
 		},
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: getEnvInt("SERVER_PORT", 8080),
+			Host:                  getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:                  getEnvInt("SERVER_PORT", 8080),
+			RequestTimeoutSeconds: getEnvInt("SERVER_REQUEST_TIMEOUT_SECONDS", 15),
+			PaymentTimeoutSeconds: getEnvInt("SERVER_PAYMENT_TIMEOUT_SECONDS", 30),
+			ReportTimeoutSeconds:  getEnvInt("SERVER_REPORT_TIMEOUT_SECONDS", 60),
+			MaxConcurrentRequests: getEnvInt("SERVER_MAX_CONCURRENT_REQUESTS", 200),
+			MaxConcurrentReports:  getEnvInt("SERVER_MAX_CONCURRENT_REPORTS", 4),
+			QueueTimeoutMillis:    getEnvInt("SERVER_QUEUE_TIMEOUT_MS", 2000),
+			TrustedProxies:        getEnvList("SERVER_TRUSTED_PROXIES"),
+			DocsEnabled:           getEnvBool("SERVER_DOCS_ENABLED", true),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   getEnvListDefault("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
+			AllowedMethods:   getEnvListDefault("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders:   getEnvListDefault("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "X-Store-ID", "X-Request-ID", "Cache-Control", "X-Requested-With"}),
+			ExposedHeaders:   getEnvListDefault("CORS_EXPOSED_HEADERS", []string{"X-Request-ID"}),
+			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
+			MaxAgeSeconds:    getEnvInt("CORS_MAX_AGE_SECONDS", 600),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:                getEnvBool("RATE_LIMIT_ENABLED", true),
+			RedisURL:               getEnv("RATE_LIMIT_REDIS_URL", ""),
+			GlobalPerMinute:        getEnvInt("RATE_LIMIT_GLOBAL_PER_MINUTE", 600),
+			GlobalBurst:            getEnvInt("RATE_LIMIT_GLOBAL_BURST", 100),
+			LoginPerMinute:         getEnvInt("RATE_LIMIT_LOGIN_PER_MINUTE", 10),
+			LoginBurst:             getEnvInt("RATE_LIMIT_LOGIN_BURST", 5),
+			PasswordResetPerMinute: getEnvInt("RATE_LIMIT_PASSWORD_RESET_PER_MINUTE", 5),
+			PasswordResetBurst:     getEnvInt("RATE_LIMIT_PASSWORD_RESET_BURST", 3),
+			CallbackPerMinute:      getEnvInt("RATE_LIMIT_CALLBACK_PER_MINUTE", 300),
+			CallbackBurst:          getEnvInt("RATE_LIMIT_CALLBACK_BURST", 60),
+			QRISPerMinute:          getEnvInt("RATE_LIMIT_QRIS_PER_MINUTE", 30),
+			QRISBurst:              getEnvInt("RATE_LIMIT_QRIS_BURST", 10),
+		},
+		Cache: CacheConfig{
+			RedisURL:          getEnv("CACHE_REDIS_URL", ""),
+			ProductTTLSeconds: getEnvInt("CACHE_PRODUCT_TTL_SECONDS", 30),
 		},
 		Database: DatabaseConfig{
 			Host:         getEnv("DB_HOST", "localhost"),
@@ -76,19 +452,232 @@ func Load() (*Config, error) {
 			MaxOpenConns: getEnvInt("DB_MAX_OPEN_CONNS", 100),
 		},
 		Midtrans: MidtransConfig{
-			ServerKey:   getEnv("MIDTRANS_SERVER_KEY", ""),
-			ClientKey:   getEnv("MIDTRANS_CLIENT_KEY", ""),
-			Environment: getEnv("MIDTRANS_ENVIRONMENT", "sandbox"),
+			ServerKey:      getEnv("MIDTRANS_SERVER_KEY", ""),
+			ClientKey:      getEnv("MIDTRANS_CLIENT_KEY", ""),
+			Environment:    getEnv("MIDTRANS_ENVIRONMENT", "sandbox"),
+			TimeoutSeconds: getEnvInt("MIDTRANS_TIMEOUT_SECONDS", 20),
+
+			StatusTimeoutSeconds:   getEnvInt("MIDTRANS_STATUS_TIMEOUT_SECONDS", 5),
+			MaxRetries:             getEnvInt("MIDTRANS_MAX_RETRIES", 2),
+			RetryBackoffMillis:     getEnvInt("MIDTRANS_RETRY_BACKOFF_MILLIS", 200),
+			BreakerThreshold:       getEnvInt("MIDTRANS_BREAKER_THRESHOLD", 5),
+			BreakerCooldownSeconds: getEnvInt("MIDTRANS_BREAKER_COOLDOWN_SECONDS", 30),
+		},
+		Xendit: XenditConfig{
+			SecretKey:      getEnv("XENDIT_SECRET_KEY", ""),
+			CallbackToken:  getEnv("XENDIT_CALLBACK_TOKEN", ""),
+			TimeoutSeconds: getEnvInt("XENDIT_TIMEOUT_SECONDS", 20),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key"),
-			ExpiryHour: getEnvInt("JWT_EXPIRY_HOUR", 24),
+			Secret:             getEnv("JWT_SECRET", "your-secret-key"),
+			ExpiryHour:         getEnvInt("JWT_EXPIRY_HOUR", 24),
+			RefreshExpiryHours: getEnvInt("JWT_REFRESH_EXPIRY_HOURS", 720),
+			Issuer:             getEnv("JWT_ISSUER", "qris-pos-backend"),
+			Audience:           getEnv("JWT_AUDIENCE", "qris-pos-api"),
+			LeewaySeconds:      getEnvInt("JWT_LEEWAY_SECONDS", 30),
+		},
+		PasswordReset: PasswordResetConfig{
+			CodeTTLMinutes:        getEnvInt("PASSWORD_RESET_CODE_TTL_MINUTES", 15),
+			MaxAttempts:           getEnvInt("PASSWORD_RESET_MAX_ATTEMPTS", 5),
+			ResendCooldownSeconds: getEnvInt("PASSWORD_RESET_RESEND_COOLDOWN_SECONDS", 60),
 		},
 		Storage: StorageConfig{
-			SupabaseURL:       getEnv("SUPABASE_URL", ""),
-			SupabaseKey:       getEnv("SUPABASE_ANON_KEY", ""),
-			BucketName:        getEnv("SUPABASE_BUCKET_NAME", "product-images"),
-			MaxFileSizeMB:     getEnvInt("MAX_FILE_SIZE_MB", 2),
+			Driver:        getEnv("STORAGE_DRIVER", "supabase"),
+			SupabaseURL:   getEnv("SUPABASE_URL", ""),
+			SupabaseKey:   getEnv("SUPABASE_ANON_KEY", ""),
+			BucketName:    getEnv("STORAGE_BUCKET_NAME", getEnv("SUPABASE_BUCKET_NAME", "product-images")),
+			MaxFileSizeMB: getEnvInt("MAX_FILE_SIZE_MB", 2),
+
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3UseSSL:    getEnvBool("S3_USE_SSL", true),
+			S3PublicURL: getEnv("S3_PUBLIC_URL", ""),
+
+			LocalDir:        getEnv("STORAGE_LOCAL_DIR", "./uploads"),
+			LocalPublicURL:  getEnv("STORAGE_LOCAL_PUBLIC_URL", "http://localhost:8080/uploads"),
+			LocalSigningKey: getEnv("STORAGE_LOCAL_SIGNING_KEY", ""),
+
+			ImageMaxDimension:     getEnvInt("IMAGE_MAX_DIMENSION", 1200),
+			ThumbnailDimension:    getEnvInt("IMAGE_THUMBNAIL_DIMENSION", 300),
+			MaxImagesPerProduct:   getEnvInt("IMAGE_MAX_PER_PRODUCT", 8),
+			OrphanImageGraceHours: getEnvInt("IMAGE_ORPHAN_GRACE_HOURS", 24),
+		},
+		Jobs: JobsConfig{
+			ExpiryIntervalSeconds:         getEnvInt("JOB_EXPIRY_INTERVAL_SECONDS", 60),
+			TransactionTTLMinutes:         getEnvInt("TRANSACTION_TTL_MINUTES", 60),
+			CancelExpiredOnGateway:        getEnvBool("JOB_CANCEL_EXPIRED_ON_GATEWAY", false),
+			ReconcileIntervalSeconds:      getEnvInt("JOB_RECONCILE_INTERVAL_SECONDS", 300),
+			PopularityIntervalSeconds:     getEnvInt("JOB_POPULARITY_INTERVAL_SECONDS", 900),
+			PopularityWindowDays:          getEnvInt("POPULARITY_WINDOW_DAYS", 30),
+			TokenCleanupIntervalSeconds:   getEnvInt("JOB_TOKEN_CLEANUP_INTERVAL_SECONDS", 3600),
+			ImageCleanupIntervalSeconds:   getEnvInt("JOB_IMAGE_CLEANUP_INTERVAL_SECONDS", 21600),
+			PriceScheduleIntervalSeconds:  getEnvInt("JOB_PRICE_SCHEDULE_INTERVAL_SECONDS", 60),
+			ReportScheduleIntervalSeconds: getEnvInt("JOB_REPORT_SCHEDULE_INTERVAL_SECONDS", 300),
+			SettingsReloadIntervalSeconds: getEnvInt("JOB_SETTINGS_RELOAD_INTERVAL_SECONDS", 30),
+			CostingIntervalSeconds:        getEnvInt("JOB_COSTING_INTERVAL_SECONDS", 60),
+		},
+		Payment: PaymentConfig{
+			// Bank Indonesia caps a single QRIS payment at Rp 10.000.000
+			QRISMinAmount:                   getEnvInt64("QRIS_MIN_AMOUNT", 1),
+			QRISMaxAmount:                   getEnvInt64("QRIS_MAX_AMOUNT", 10000000),
+			QRISExpiryMinutes:               getEnvInt("QRIS_EXPIRY_MINUTES", 10),
+			StatusCheckConcurrency:          getEnvInt("QRIS_STATUS_CHECK_CONCURRENCY", 5),
+			QRISWorkers:                     getEnvInt("QRIS_WORKERS", 4),
+			QRISQueueSize:                   getEnvInt("QRIS_QUEUE_SIZE", 100),
+			QRISJobRetentionSeconds:         getEnvInt("QRIS_JOB_RETENTION_SECONDS", 600),
+			NotificationIntervalSeconds:     getEnvInt("PAYMENT_NOTIFICATION_INTERVAL_SECONDS", 30),
+			NotificationMaxAttempts:         getEnvInt("PAYMENT_NOTIFICATION_MAX_ATTEMPTS", 6),
+			NotificationRetryBackoffSeconds: getEnvInt("PAYMENT_NOTIFICATION_RETRY_BACKOFF_SECONDS", 30),
+			CallbackAllowedIPs:              getEnvList("PAYMENT_CALLBACK_ALLOWED_IPS"),
+			Gateway:                         getEnv("PAYMENT_GATEWAY", "midtrans"),
+			QRISMode:                        getEnv("QRIS_MODE", "gateway"),
+			LocalQRIS: LocalQRISConfig{
+				StaticPayload: getEnv("QRIS_STATIC_PAYLOAD", ""),
+				NMID:          getEnv("QRIS_MERCHANT_NMID", ""),
+				Criteria:      getEnv("QRIS_MERCHANT_CRITERIA", ""),
+				CategoryCode:  getEnv("QRIS_MERCHANT_CATEGORY_CODE", ""),
+				MerchantName:  getEnv("QRIS_MERCHANT_NAME", ""),
+				MerchantCity:  getEnv("QRIS_MERCHANT_CITY", ""),
+				PostalCode:    getEnv("QRIS_MERCHANT_POSTAL_CODE", ""),
+			},
+		},
+		Store: StoreConfig{
+			Name:    getEnv("STORE_NAME", "QRIS POS"),
+			Address: getEnv("STORE_ADDRESS", ""),
+			Phone:   getEnv("STORE_PHONE", ""),
+			TaxID:   getEnv("STORE_TAX_ID", ""),
+			Footer:  getEnv("STORE_RECEIPT_FOOTER", "Terima kasih"),
+
+			OrderTrackingURL: getEnv("ORDER_TRACKING_URL", "http://localhost:3000/track"),
+		},
+		Notification: NotificationConfig{
+			SMTPHost:              getEnv("SMTP_HOST", ""),
+			SMTPPort:              getEnvInt("SMTP_PORT", 587),
+			SMTPUsername:          getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:          getEnv("SMTP_PASSWORD", ""),
+			SMTPFrom:              getEnv("SMTP_FROM", ""),
+			WhatsAppAPIURL:        getEnv("WHATSAPP_API_URL", "https://graph.facebook.com/v19.0"),
+			WhatsAppPhoneNumberID: getEnv("WHATSAPP_PHONE_NUMBER_ID", ""),
+			WhatsAppAccessToken:   getEnv("WHATSAPP_ACCESS_TOKEN", ""),
+			IntervalSeconds:       getEnvInt("NOTIFICATION_INTERVAL_SECONDS", 30),
+			MaxAttempts:           getEnvInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+			RetryBackoffSeconds:   getEnvInt("NOTIFICATION_RETRY_BACKOFF_SECONDS", 60),
+		},
+		Shift: ShiftConfig{
+			Required:                  getEnvBool("SHIFT_REQUIRED", true),
+			VarianceApprovalThreshold: getEnvInt64("SHIFT_VARIANCE_APPROVAL_THRESHOLD", 50000),
+		},
+		Alert: AlertConfig{
+			IntervalSeconds:      getEnvInt("ALERT_INTERVAL_SECONDS", 300),
+			LookbackMinutes:      getEnvInt("ALERT_LOOKBACK_MINUTES", 60),
+			VoidWindowMinutes:    getEnvInt("ALERT_VOID_WINDOW_MINUTES", 60),
+			VoidBaselineDays:     getEnvInt("ALERT_VOID_BASELINE_DAYS", 7),
+			VoidSpikeMultiplier:  getEnvFloat("ALERT_VOID_SPIKE_MULTIPLIER", 3),
+			VoidMinCount:         getEnvInt("ALERT_VOID_MIN_COUNT", 5),
+			BusinessOpenHour:     getEnvInt("BUSINESS_OPEN_HOUR", 8),
+			BusinessCloseHour:    getEnvInt("BUSINESS_CLOSE_HOUR", 22),
+			Timezone:             getEnv("BUSINESS_TIMEZONE", "Asia/Jakarta"),
+			DiscountZScore:       getEnvFloat("ALERT_DISCOUNT_Z_SCORE", 3),
+			DiscountBaselineDays: getEnvInt("ALERT_DISCOUNT_BASELINE_DAYS", 30),
+			DiscountMinSamples:   getEnvInt("ALERT_DISCOUNT_MIN_SAMPLES", 30),
+			NotifyEmails:         getEnvList("ALERT_NOTIFY_EMAILS"),
+		},
+		Inventory: InventoryConfig{
+			ForecastHistoryDays: getEnvInt("FORECAST_HISTORY_DAYS", 56),
+			MovingAverageDays:   getEnvInt("FORECAST_MOVING_AVERAGE_DAYS", 14),
+			SmoothingAlpha:      getEnvFloat("FORECAST_SMOOTHING_ALPHA", 0.3),
+			LeadTimeDays:        getEnvInt("REORDER_LEAD_TIME_DAYS", 3),
+		},
+		Loyalty: LoyaltyConfig{
+			EarnAmount: getEnvInt64("LOYALTY_EARN_AMOUNT", 10000),
+			PointValue: getEnvInt64("LOYALTY_POINT_VALUE", 100),
+		},
+		Discount: DiscountConfig{
+			CashierMaxPercent: getEnvFloat("DISCOUNT_CASHIER_MAX_PERCENT", 10),
+			AdminMaxPercent:   getEnvFloat("DISCOUNT_ADMIN_MAX_PERCENT", 100),
+		},
+		SKU: SKUConfig{
+			DefaultPrefix: getEnv("SKU_DEFAULT_PREFIX", "SKU"),
+			Digits:        getEnvInt("SKU_DIGITS", 6),
+		},
+		Export: ExportConfig{
+			Bucket:          getEnv("EXPORT_BUCKET_NAME", "merchant-exports"),
+			IntervalSeconds: getEnvInt("EXPORT_INTERVAL_SECONDS", 30),
+			LinkExpiryHours: getEnvInt("EXPORT_LINK_EXPIRY_HOURS", 24),
+			MaxRangeDays:    getEnvInt("EXPORT_MAX_RANGE_DAYS", 366),
+		},
+		Tracing: TracingConfig{
+			Endpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")),
+			ServiceName: getEnv("OTEL_SERVICE_NAME", "qris-pos-backend"),
+			SampleRatio: getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+		},
+		Sync: SyncConfig{
+			MaxAgeHours:       getEnvInt("SYNC_MAX_AGE_HOURS", 168),
+			MaxClockSkewMins:  getEnvInt("SYNC_MAX_CLOCK_SKEW_MINUTES", 5),
+			PageSize:          getEnvInt("SYNC_PAGE_SIZE", 500),
+			SettleDelayMillis: getEnvInt("SYNC_SETTLE_DELAY_MS", 2000),
+		},
+		Webhook: WebhookConfig{
+			IntervalSeconds:     getEnvInt("WEBHOOK_INTERVAL_SECONDS", 10),
+			MaxAttempts:         getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBackoffSeconds: getEnvInt("WEBHOOK_RETRY_BACKOFF_SECONDS", 30),
+			TimeoutSeconds:      getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+			LowStockThreshold:   getEnvInt("WEBHOOK_LOW_STOCK_THRESHOLD", 5),
+		},
+		Outbox: OutboxConfig{
+			Driver:              getEnv("OUTBOX_DRIVER", "none"),
+			IntervalSeconds:     getEnvInt("OUTBOX_INTERVAL_SECONDS", 5),
+			BatchSize:           getEnvInt("OUTBOX_BATCH_SIZE", 200),
+			RetryBackoffSeconds: getEnvInt("OUTBOX_RETRY_BACKOFF_SECONDS", 5),
+			RetentionHours:      getEnvInt("OUTBOX_RETENTION_HOURS", 72),
+			TopicPrefix:         getEnv("OUTBOX_TOPIC_PREFIX", "pos"),
+			TimeoutSeconds:      getEnvInt("OUTBOX_TIMEOUT_SECONDS", 10),
+			NATSURL:             getEnv("NATS_URL", "nats://localhost:4222"),
+			NATSJetStream:       getEnvBool("NATS_JETSTREAM", true),
+			KafkaBrokers:        getEnvListDefault("KAFKA_BROKERS", []string{"localhost:9092"}),
+		},
+		Accounting: AccountingConfig{
+			SalesAccount:            getEnv("ACCOUNTING_SALES_ACCOUNT", "4-40000"),
+			TaxPayableAccount:       getEnv("ACCOUNTING_TAX_PAYABLE_ACCOUNT", "2-20500"),
+			CashAccount:             getEnv("ACCOUNTING_CASH_ACCOUNT", "1-10001"),
+			QRISAccount:             getEnv("ACCOUNTING_QRIS_ACCOUNT", "1-10101"),
+			CardAccount:             getEnv("ACCOUNTING_CARD_ACCOUNT", "1-10102"),
+			EWalletAccount:          getEnv("ACCOUNTING_EWALLET_ACCOUNT", "1-10103"),
+			OtherAccount:            getEnv("ACCOUNTING_OTHER_ACCOUNT", "1-10104"),
+			TimeoutSeconds:          getEnvInt("ACCOUNTING_TIMEOUT_SECONDS", 30),
+			AccurateHost:            getEnv("ACCURATE_HOST", ""),
+			AccurateAPIToken:        getEnv("ACCURATE_API_TOKEN", ""),
+			AccurateSignatureSecret: getEnv("ACCURATE_SIGNATURE_SECRET", ""),
+			JurnalBaseURL:           getEnv("JURNAL_BASE_URL", "https://api.jurnal.id/core/api/v1"),
+			JurnalAPIKey:            getEnv("JURNAL_API_KEY", ""),
+			XeroClientID:            getEnv("XERO_CLIENT_ID", ""),
+			XeroClientSecret:        getEnv("XERO_CLIENT_SECRET", ""),
+		},
+		Reconciliation: ReconciliationConfig{
+			IntervalSeconds: getEnvInt("RECONCILIATION_INTERVAL_SECONDS", 60),
+			MaxPayments:     getEnvInt("RECONCILIATION_MAX_PAYMENTS", 5000),
+			MaxRangeDays:    getEnvInt("RECONCILIATION_MAX_RANGE_DAYS", 31),
+		},
+		Print: PrintConfig{
+			LeaseSeconds:   getEnvInt("PRINT_LEASE_SECONDS", 60),
+			MaxAttempts:    getEnvInt("PRINT_MAX_ATTEMPTS", 3),
+			MaxWaitSeconds: getEnvInt("PRINT_MAX_WAIT_SECONDS", 25),
+		},
+		Share: ShareConfig{
+			Secret:         getEnv("SHARE_TOKEN_SECRET", getEnv("JWT_SECRET", "your-secret-key")),
+			URL:            getEnv("INVOICE_SHARE_URL", "http://localhost:3000/invoice"),
+			ExpiryHours:    getEnvInt("SHARE_TOKEN_EXPIRY_HOURS", 72),
+			MaxExpiryHours: getEnvInt("SHARE_TOKEN_MAX_EXPIRY_HOURS", 720),
+		},
+		Secrets: SecretsConfig{
+			EncryptionKey: getEnv("SECRETS_ENCRYPTION_KEY", ""),
+			PreviousKeys:  getEnvList("SECRETS_PREVIOUS_KEYS"),
+		},
+		Tenancy: TenancyConfig{
+			Enabled:    getEnvBool("TENANCY_ENABLED", false),
+			BaseDomain: strings.ToLower(getEnv("TENANCY_BASE_DOMAIN", "")),
 		},
 	}
 
@@ -102,6 +691,25 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList splits a comma separated variable, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvListDefault is getEnvList falling back to the default when the variable is unset or empty
+func getEnvListDefault(key string, defaultValue []string) []string {
+	if values := getEnvList(key); len(values) > 0 {
+		return values
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -109,4 +717,31 @@ func getEnvInt(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// SnapshotBackfillResult counts the transaction items a snapshot backfill touched
+type SnapshotBackfillResult struct {
+	UnitCosts  int64
+	TaxClasses int64
+}
+
+const (
+	// Items sold before cost snapshotting existed were stored with a zero unit cost
+	missingUnitCostFilter = "transaction_items.product_id = products.id AND transaction_items.unit_cost = 0 AND products.cost_price > 0"
+	missingTaxClassFilter = "transaction_items.product_id = products.id AND transaction_items.tax_class IS NULL"
+)
+
+// BackfillItemSnapshots copies the current product cost price and tax class onto transaction items
+// sold before those were snapshotted. The current values are the best estimate left for old sales;
+// items that already carry a snapshot are never touched. With dryRun set nothing is written.
+func BackfillItemSnapshots(ctx context.Context, db *gorm.DB, dryRun bool) (*SnapshotBackfillResult, error) {
+	result := &SnapshotBackfillResult{}
+
+	if dryRun {
+		if err := db.WithContext(ctx).Table("transaction_items").
+			Joins("JOIN products ON " + missingUnitCostFilter).
+			Count(&result.UnitCosts).Error; err != nil {
+			return nil, err
+		}
+		if err := db.WithContext(ctx).Table("transaction_items").
+			Joins("JOIN products ON " + missingTaxClassFilter).
+			Count(&result.TaxClasses).Error; err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		costs := tx.Exec("UPDATE transaction_items SET unit_cost = products.cost_price FROM products WHERE " + missingUnitCostFilter)
+		if costs.Error != nil {
+			return costs.Error
+		}
+		result.UnitCosts = costs.RowsAffected
+
+		taxClasses := tx.Exec("UPDATE transaction_items SET tax_class = products.tax_class FROM products WHERE " + missingTaxClassFilter)
+		if taxClasses.Error != nil {
+			return taxClasses.Error
+		}
+		result.TaxClasses = taxClasses.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
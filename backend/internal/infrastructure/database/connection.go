@@ -28,6 +28,18 @@ func NewConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := registerTracing(db); err != nil {
+		return nil, fmt.Errorf("failed to register query tracing: %w", err)
+	}
+
+	if err := registerOutbox(db); err != nil {
+		return nil, fmt.Errorf("failed to register outbox: %w", err)
+	}
+
+	if err := registerTenancy(db); err != nil {
+		return nil, fmt.Errorf("failed to register tenancy: %w", err)
+	}
+
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -47,15 +59,147 @@ func getLogLevel(cfg config.DatabaseConfig) logger.LogLevel {
 }
 
 func RunMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
+		&entities.Tenant{},
+		&entities.Store{},
+		&entities.Table{},
 		&entities.User{},
 		&entities.Category{},
 		&entities.Product{},
+		&entities.ProductImage{},
+		&entities.ProductPopularity{},
 		&entities.Transaction{},
 		&entities.TransactionItem{},
 		&entities.Payment{},
+		&entities.PaymentEvent{},
+		&entities.PaymentOrder{},
 		&entities.QRISCode{},
-	)
+		&entities.Promotion{},
+		&entities.TransactionPromotion{},
+		&entities.TaxRule{},
+		&entities.TaxRuleExemption{},
+		&entities.TransactionTax{},
+		&entities.Customer{},
+		&entities.PointsEntry{},
+		&entities.PaymentException{},
+		&entities.PriceChange{},
+		&entities.PriceSchedule{},
+		&entities.PriceTier{}, &entities.RecipeItem{}, &entities.ReceiptSequence{}, &entities.PaymentNotification{}, &entities.ReportSchedule{},
+		&entities.Refund{},
+		&entities.RefundItem{},
+		&entities.ReceiptDelivery{},
+		&entities.Shift{},
+		&entities.CashMovement{},
+		&entities.Alert{},
+		&entities.Export{},
+		&entities.RefreshToken{},
+		&entities.RevokedToken{},
+		&entities.PasswordReset{},
+		&entities.AuditLog{},
+		&entities.VoidRequest{},
+		&entities.WebhookEndpoint{},
+		&entities.WebhookDelivery{},
+		&entities.OutboxEvent{},
+		&entities.AccountingPosting{},
+		&entities.ReconciliationRun{},
+		&entities.ReconciliationItem{},
+		&entities.Printer{},
+		&entities.PrintJob{},
+		&entities.Setting{},
+		&entities.Merchant{},
+		&entities.GatewayCredential{},
+	); err != nil {
+		return err
+	}
+
+	if err := migrateProductSearch(db); err != nil {
+		return err
+	}
+	if err := migrateTenantUniqueness(db); err != nil {
+		return err
+	}
+	if err := migratePaymentEventsAppendOnly(db); err != nil {
+		return err
+	}
+	return migrateSKUSequence(db)
+}
+
+// migrateProductSearch adds what AutoMigrate can't express: the generated search vector of products and
+// the full-text and trigram indexes product search relies on. Mirrors 047_add_product_search.up.sql.
+func migrateProductSearch(db *gorm.DB) error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(sku, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(barcode, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+			) STORED`,
+		"CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_products_sku_trgm ON products USING GIN (sku gin_trgm_ops)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to set up product search: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrateTenantUniqueness makes store codes, category names and customer contacts unique within a
+// tenant, which AutoMigrate can't express. Mirrors 074_create_tenants.up.sql.
+func migrateTenantUniqueness(db *gorm.DB) error {
+	statements := []string{
+		"DROP INDEX IF EXISTS idx_stores_code",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_stores_tenant_code ON stores(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), code)",
+		"ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key",
+		"DROP INDEX IF EXISTS idx_categories_name",
+		"CREATE INDEX IF NOT EXISTS idx_categories_name ON categories(name)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_tenant_name ON categories(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), name)",
+		"DROP INDEX IF EXISTS idx_customers_phone",
+		"DROP INDEX IF EXISTS idx_customers_email",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_phone ON customers(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), phone) WHERE phone IS NOT NULL AND deleted_at IS NULL",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_email ON customers(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), email) WHERE email IS NOT NULL AND deleted_at IS NULL",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to scope unique indexes to tenants: %w", err)
+		}
+	}
+	return nil
+}
+
+// migratePaymentEventsAppendOnly adds the trigger refusing to change or delete payment events, which
+// AutoMigrate can't express. Mirrors 077_create_payment_events.up.sql.
+func migratePaymentEventsAppendOnly(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION reject_payment_event_changes()
+			RETURNS TRIGGER AS $$
+			BEGIN
+				RAISE EXCEPTION 'payment events are append only';
+			END;
+			$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS payment_events_append_only ON payment_events",
+		`CREATE TRIGGER payment_events_append_only BEFORE UPDATE OR DELETE ON payment_events
+			FOR EACH ROW EXECUTE FUNCTION reject_payment_event_changes()`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to make payment events append only: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrateSKUSequence creates the sequence numbering generated SKUs, which AutoMigrate has no model for.
+// Mirrors 065_add_sku_generation.up.sql.
+func migrateSKUSequence(db *gorm.DB) error {
+	if err := db.Exec("CREATE SEQUENCE IF NOT EXISTS product_sku_seq").Error; err != nil {
+		return fmt.Errorf("failed to create the SKU sequence: %w", err)
+	}
+	return nil
 }
 
 func SeedData(db *gorm.DB) error {
@@ -82,6 +226,17 @@ func SeedData(db *gorm.DB) error {
 		}
 	}
 
+	// Create the first store, more are added by admins
+	var storeCount int64
+	if err := db.Model(&entities.Store{}).Count(&storeCount).Error; err != nil {
+		return fmt.Errorf("failed to count stores: %w", err)
+	}
+	if storeCount == 0 {
+		if err := db.Create(&entities.Store{Code: "MAIN", Name: "Main Store", IsActive: true}).Error; err != nil {
+			return fmt.Errorf("failed to create default store: %w", err)
+		}
+	}
+
 	// Create default admin user
 	var adminUser entities.User
 	if err := db.Where("email = ?", "admin@qrispos.com").First(&adminUser).Error; err != nil {
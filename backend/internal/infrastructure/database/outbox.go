@@ -0,0 +1,73 @@
+package database
+
+import (
+	"reflect"
+
+	"qris-pos-backend/internal/domain/entities"
+
+	"gorm.io/gorm"
+)
+
+// registerOutbox writes the events recorded by a saved aggregate to the outbox before the statement
+// commits. Creates and updates run in a transaction of their own, or in the one of the repository, so
+// the events are committed or rolled back together with the change.
+func registerOutbox(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:after_create").Before("gorm:commit_or_rollback_transaction").
+		Register("outbox:after_create", writeOutboxEvents); err != nil {
+		return err
+	}
+	return cb.Update().After("gorm:after_update").Before("gorm:commit_or_rollback_transaction").
+		Register("outbox:after_update", writeOutboxEvents)
+}
+
+func writeOutboxEvents(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	var outboxEvents []*entities.OutboxEvent
+	collect := func(value reflect.Value) {
+		if !value.CanAddr() {
+			return
+		}
+		source, ok := value.Addr().Interface().(entities.OutboxSource)
+		if !ok {
+			return
+		}
+		events, err := source.TakeOutboxEvents()
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		// Events belong to the tenant of their aggregate, also when a gateway callback changed it
+		if field := db.Statement.Schema.LookUpField(tenantColumn); field != nil {
+			if tenantID, ok := field.ReflectValueOf(db.Statement.Context, value).Interface().(*string); ok && tenantID != nil {
+				for _, event := range events {
+					if event.TenantID == nil {
+						event.TenantID = tenantID
+					}
+				}
+			}
+		}
+		outboxEvents = append(outboxEvents, events...)
+	}
+
+	switch value := reflect.Indirect(db.Statement.ReflectValue); value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			collect(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		collect(value)
+	}
+
+	if db.Error != nil || len(outboxEvents) == 0 {
+		return
+	}
+
+	// Same connection, so the rows land in the transaction of the statement
+	if err := db.Session(&gorm.Session{NewDB: true, SkipDefaultTransaction: true}).Create(&outboxEvents).Error; err != nil {
+		db.AddError(err)
+	}
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type accountingPostingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewAccountingPostingRepository(db *gorm.DB) repositories.AccountingPostingRepository {
+	return &accountingPostingRepositoryImpl{db: db}
+}
+
+func (r *accountingPostingRepositoryImpl) Create(ctx context.Context, posting *entities.AccountingPosting) error {
+	return dbFrom(ctx, r.db).Create(posting).Error
+}
+
+func (r *accountingPostingRepositoryImpl) ListByConnector(ctx context.Context, connector, storeID string, dateFrom, dateTo time.Time) ([]entities.AccountingPosting, error) {
+	// Compared as calendar days, a timestamp would shift with the time zone of the connection
+	query := dbFrom(ctx, r.db).
+		Where("connector = ? AND date >= ? AND date <= ?", connector, dateFrom.Format("2006-01-02"), dateTo.Format("2006-01-02"))
+	if storeID != "" {
+		query = query.Where("store_id = ?", storeID)
+	}
+
+	var postings []entities.AccountingPosting
+	err := query.Order("date ASC").Find(&postings).Error
+	return postings, err
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// discountRateExpr is the discount as a share of the amount before discount
+const discountRateExpr = "discount::numeric / NULLIF(total_amount + discount + exchange_credit + points_discount - tax_amount, 0)"
+
+var settledStatuses = []entities.TransactionStatus{entities.StatusPaid, entities.StatusPartiallyRefunded, entities.StatusRefunded}
+
+type alertRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAlertRepository creates a new alert repository instance
+func NewAlertRepository(db *gorm.DB) repositories.AlertRepository {
+	return &alertRepositoryImpl{db: db}
+}
+
+// Create inserts the alert, skipping it when its fingerprint was already raised
+func (r *alertRepositoryImpl) Create(ctx context.Context, alert *entities.Alert) (bool, error) {
+	result := dbFrom(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "fingerprint"}}, DoNothing: true}).
+		Create(alert)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetByID retrieves an alert by its ID
+func (r *alertRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Alert, error) {
+	var alert entities.Alert
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&alert).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// Update updates an alert record
+func (r *alertRepositoryImpl) Update(ctx context.Context, alert *entities.Alert) error {
+	return dbFrom(ctx, r.db).Save(alert).Error
+}
+
+// List retrieves alerts newest first
+func (r *alertRepositoryImpl) List(ctx context.Context, filters repositories.AlertFilters) ([]entities.Alert, error) {
+	query := dbFrom(ctx, r.db)
+
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+	if filters.Acknowledged != nil {
+		if *filters.Acknowledged {
+			query = query.Where("acknowledged_at IS NOT NULL")
+		} else {
+			query = query.Where("acknowledged_at IS NULL")
+		}
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	var alerts []entities.Alert
+	err := query.Order("created_at DESC").Find(&alerts).Error
+	return alerts, err
+}
+
+// CountVoids counts transactions cancelled in the time range
+func (r *alertRepositoryImpl) CountVoids(ctx context.Context, from, to time.Time) (int64, error) {
+	var count int64
+	err := dbFrom(ctx, r.db).
+		Model(&entities.Transaction{}).
+		Where("status = ? AND NOT is_training", entities.StatusCancelled).
+		Where("updated_at >= ? AND updated_at < ?", from, to).
+		Count(&count).Error
+	return count, err
+}
+
+// ListRefunds retrieves refunds recorded in the time range, those of training sales aside
+func (r *alertRepositoryImpl) ListRefunds(ctx context.Context, from, to time.Time) ([]entities.Refund, error) {
+	var refunds []entities.Refund
+	err := dbFrom(ctx, r.db).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Where("NOT EXISTS (SELECT 1 FROM transactions t WHERE t.id = refunds.transaction_id AND t.is_training)").
+		Order("created_at ASC").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+// DiscountRateStats computes the mean and standard deviation of the discount rate of settled sales
+func (r *alertRepositoryImpl) DiscountRateStats(ctx context.Context, from, to time.Time) (*repositories.DiscountRateStats, error) {
+	var stats repositories.DiscountRateStats
+	err := r.settledSales(ctx, from, to).
+		Select("COUNT(*) AS count, COALESCE(AVG(" + discountRateExpr + "), 0) AS mean, COALESCE(STDDEV_POP(" + discountRateExpr + "), 0) AS std_dev").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ListDiscountedSales retrieves settled sales with a discount in the time range
+func (r *alertRepositoryImpl) ListDiscountedSales(ctx context.Context, from, to time.Time) ([]repositories.DiscountedSale, error) {
+	var sales []repositories.DiscountedSale
+	err := r.settledSales(ctx, from, to).
+		Select("id AS transaction_id, user_id, discount, " + discountRateExpr + " AS rate, created_at").
+		Where("discount > 0").
+		Order("created_at ASC").
+		Scan(&sales).Error
+	return sales, err
+}
+
+func (r *alertRepositoryImpl) settledSales(ctx context.Context, from, to time.Time) *gorm.DB {
+	return dbFrom(ctx, r.db).
+		Model(&entities.Transaction{}).
+		Where("status IN ? AND NOT is_training", settledStatuses).
+		Where("total_amount + discount + exchange_credit + points_discount - tax_amount > 0").
+		Where("created_at >= ? AND created_at < ?", from, to)
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type auditLogRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository instance
+func NewAuditLogRepository(db *gorm.DB) repositories.AuditLogRepository {
+	return &auditLogRepositoryImpl{db: db}
+}
+
+// Create inserts an audit log entry
+func (r *auditLogRepositoryImpl) Create(ctx context.Context, log *entities.AuditLog) error {
+	return dbFrom(ctx, r.db).Create(log).Error
+}
+
+// List retrieves audit log entries newest first, limited to the store of the context when it has one
+func (r *auditLogRepositoryImpl) List(ctx context.Context, filters repositories.AuditLogFilters) ([]entities.AuditLog, error) {
+	query := dbFrom(ctx, r.db).Scopes(scopeStore(ctx, "audit_logs.store_id"))
+
+	if filters.UserID != "" {
+		query = query.Where("user_id = ?", filters.UserID)
+	}
+	if filters.Action != "" {
+		query = query.Where("action = ?", filters.Action)
+	}
+	if filters.ResourceID != "" {
+		query = query.Where("resource_id = ?", filters.ResourceID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at < ?", *filters.To)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	var logs []entities.AuditLog
+	err := query.Order("created_at DESC").Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type customerRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCustomerRepository(db *gorm.DB) repositories.CustomerRepository {
+	return &customerRepositoryImpl{db: db}
+}
+
+func (r *customerRepositoryImpl) Create(ctx context.Context, customer *entities.Customer) error {
+	return dbFrom(ctx, r.db).Create(customer).Error
+}
+
+func (r *customerRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Customer, error) {
+	var customer entities.Customer
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&customer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerRepositoryImpl) GetByPhone(ctx context.Context, phone string) (*entities.Customer, error) {
+	var customer entities.Customer
+	err := dbFrom(ctx, r.db).Where("phone = ?", phone).First(&customer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (r *customerRepositoryImpl) GetByEmail(ctx context.Context, email string) (*entities.Customer, error) {
+	var customer entities.Customer
+	err := dbFrom(ctx, r.db).Where("email = ?", email).First(&customer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// Update saves the customer's details. The points balance only changes through points entries.
+func (r *customerRepositoryImpl) Update(ctx context.Context, customer *entities.Customer) error {
+	return dbFrom(ctx, r.db).Omit("Points").Save(customer).Error
+}
+
+func (r *customerRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.Customer{}, "id = ?", id).Error
+}
+
+func (r *customerRepositoryImpl) List(ctx context.Context, filters repositories.CustomerFilters) ([]entities.Customer, error) {
+	var customers []entities.Customer
+	query := dbFrom(ctx, r.db)
+
+	if filters.Search != "" {
+		pattern := "%" + filters.Search + "%"
+		query = query.Where("name ILIKE ? OR phone ILIKE ? OR email ILIKE ?", pattern, pattern, pattern)
+	}
+
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	err := query.Order("name ASC").Find(&customers).Error
+	return customers, err
+}
+
+func (r *customerRepositoryImpl) HeldPoints(ctx context.Context, customerID, excludeTransactionID string) (int64, error) {
+	query := dbFrom(ctx, r.db).
+		Model(&entities.Transaction{}).
+		Select("COALESCE(SUM(points_redeemed), 0)").
+		Where("customer_id = ? AND status = ?", customerID, entities.StatusPending)
+
+	if excludeTransactionID != "" {
+		query = query.Where("id <> ?", excludeTransactionID)
+	}
+
+	var held int64
+	err := query.Scan(&held).Error
+	return held, err
+}
+
+func (r *customerRepositoryImpl) SettleTransactionPoints(ctx context.Context, customerID, transactionID string, redeemed, earned int64) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var settled int64
+		if err := tx.Model(&entities.PointsEntry{}).
+			Where("transaction_id = ? AND type IN ?", transactionID, []entities.PointsEntryType{entities.PointsEarned, entities.PointsRedeemed}).
+			Count(&settled).Error; err != nil {
+			return err
+		}
+		if settled > 0 {
+			return nil
+		}
+
+		if redeemed > 0 {
+			entry := &entities.PointsEntry{CustomerID: customerID, TransactionID: &transactionID, Type: entities.PointsRedeemed, Points: -redeemed}
+			if err := addPointsEntry(tx, entry); err != nil {
+				return err
+			}
+		}
+		if earned > 0 {
+			entry := &entities.PointsEntry{CustomerID: customerID, TransactionID: &transactionID, Type: entities.PointsEarned, Points: earned}
+			if err := addPointsEntry(tx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *customerRepositoryImpl) AddPointsEntry(ctx context.Context, entry *entities.PointsEntry) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return addPointsEntry(tx, entry)
+	})
+}
+
+func (r *customerRepositoryImpl) ListPointsEntries(ctx context.Context, customerID string, limit, offset int) ([]entities.PointsEntry, error) {
+	var entries []entities.PointsEntry
+	query := dbFrom(ctx, r.db).Where("customer_id = ?", customerID)
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&entries).Error
+	return entries, err
+}
+
+// addPointsEntry moves the balance only when it doesn't go negative, guarding against spending points twice
+func addPointsEntry(tx *gorm.DB, entry *entities.PointsEntry) error {
+	result := tx.Model(&entities.Customer{}).
+		Where("id = ? AND points + ? >= 0", entry.CustomerID, entry.Points).
+		Update("points", gorm.Expr("points + ?", entry.Points))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrInsufficientPoints
+	}
+
+	if err := tx.Model(&entities.Customer{}).
+		Where("id = ?", entry.CustomerID).
+		Select("points").
+		Scan(&entry.Balance).Error; err != nil {
+		return err
+	}
+	return tx.Create(entry).Error
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type exportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewExportRepository(db *gorm.DB) repositories.ExportRepository {
+	return &exportRepositoryImpl{db: db}
+}
+
+func (r *exportRepositoryImpl) Create(ctx context.Context, export *entities.Export) error {
+	return dbFrom(ctx, r.db).Create(export).Error
+}
+
+func (r *exportRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Export, error) {
+	var export entities.Export
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "exports.store_id")).
+		Where("id = ?", id).
+		First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *exportRepositoryImpl) Update(ctx context.Context, export *entities.Export) error {
+	return dbFrom(ctx, r.db).Save(export).Error
+}
+
+func (r *exportRepositoryImpl) List(ctx context.Context, limit, offset int) ([]entities.Export, error) {
+	var exports []entities.Export
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "exports.store_id")).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *exportRepositoryImpl) ListPending(ctx context.Context, limit int) ([]entities.Export, error) {
+	var exports []entities.Export
+	err := dbFrom(ctx, r.db).
+		Where("status = ?", entities.ExportPending).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *exportRepositoryImpl) Claim(ctx context.Context, export *entities.Export) error {
+	now := time.Now()
+	result := dbFrom(ctx, r.db).
+		Model(&entities.Export{}).
+		Where("id = ? AND status = ?", export.ID, entities.ExportPending).
+		Updates(map[string]interface{}{
+			"status":     entities.ExportProcessing,
+			"started_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrExportAlreadyClaimed
+	}
+	export.Status = entities.ExportProcessing
+	export.StartedAt = &now
+	return nil
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gatewayCredentialRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewGatewayCredentialRepository(db *gorm.DB) repositories.GatewayCredentialRepository {
+	return &gatewayCredentialRepositoryImpl{db: db}
+}
+
+func (r *gatewayCredentialRepositoryImpl) Get(ctx context.Context, storeID string, provider entities.PaymentProvider) (*entities.GatewayCredential, error) {
+	var credential entities.GatewayCredential
+	err := dbFrom(ctx, r.db).Where("store_id = ? AND provider = ?", storeID, provider).First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *gatewayCredentialRepositoryImpl) Save(ctx context.Context, credential *entities.GatewayCredential) error {
+	return dbFrom(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "store_id"}, {Name: "provider"}},
+			DoUpdates: clause.AssignmentColumns([]string{"server_key", "client_key", "environment", "updated_by", "updated_at"}),
+		}).
+		Create(credential).Error
+}
+
+func (r *gatewayCredentialRepositoryImpl) Delete(ctx context.Context, storeID string, provider entities.PaymentProvider) error {
+	return dbFrom(ctx, r.db).
+		Where("store_id = ? AND provider = ?", storeID, provider).
+		Delete(&entities.GatewayCredential{}).Error
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type merchantRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewMerchantRepository(db *gorm.DB) repositories.MerchantRepository {
+	return &merchantRepositoryImpl{db: db}
+}
+
+func (r *merchantRepositoryImpl) Get(ctx context.Context) (*entities.Merchant, error) {
+	var merchant entities.Merchant
+	if err := dbFrom(ctx, r.db).Order("created_at ASC").First(&merchant).Error; err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+func (r *merchantRepositoryImpl) Create(ctx context.Context, merchant *entities.Merchant) error {
+	return dbFrom(ctx, r.db).Create(merchant).Error
+}
+
+func (r *merchantRepositoryImpl) Update(ctx context.Context, merchant *entities.Merchant) error {
+	return dbFrom(ctx, r.db).Save(merchant).Error
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type outboxRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) repositories.OutboxRepository {
+	return &outboxRepositoryImpl{db: db}
+}
+
+func (r *outboxRepositoryImpl) ListUnpublished(ctx context.Context, limit int) ([]entities.OutboxEvent, error) {
+	var outboxEvents []entities.OutboxEvent
+	err := dbFrom(ctx, r.db).
+		Where("published_at IS NULL").
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&outboxEvents).Error
+	return outboxEvents, err
+}
+
+func (r *outboxRepositoryImpl) Update(ctx context.Context, event *entities.OutboxEvent) error {
+	return dbFrom(ctx, r.db).Save(event).Error
+}
+
+func (r *outboxRepositoryImpl) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := dbFrom(ctx, r.db).
+		Where("created_at < ?", before).
+		Delete(&entities.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// recordStockChanges writes a stock changed event with the stock the products have now. It is for
+// stock moved by a plain UPDATE, which no aggregate records, and must run in the transaction of the update.
+func recordStockChanges(tx *gorm.DB, productIDs ...string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	now := tx.NowFunc()
+	return tx.Exec(`INSERT INTO outbox_events
+			(id, aggregate_type, aggregate_id, event_type, store_id, payload, attempts, last_error, next_attempt_at, created_at)
+		SELECT gen_random_uuid(), 'product', id, ?, store_id,
+			jsonb_build_object('product_id', id, 'store_id', store_id, 'stock', stock), 0, '', ?, ?
+		FROM products
+		WHERE id IN ?`, entities.OutboxStockChanged, now, now, productIDs).Error
+}
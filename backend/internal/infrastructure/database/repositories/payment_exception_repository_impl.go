@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type paymentExceptionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPaymentExceptionRepository creates a new payment exception repository instance
+func NewPaymentExceptionRepository(db *gorm.DB) repositories.PaymentExceptionRepository {
+	return &paymentExceptionRepositoryImpl{db: db}
+}
+
+// Create creates a new payment exception record
+func (r *paymentExceptionRepositoryImpl) Create(ctx context.Context, exception *entities.PaymentException) error {
+	return dbFrom(ctx, r.db).Create(exception).Error
+}
+
+// GetByID retrieves a payment exception by its ID
+func (r *paymentExceptionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.PaymentException, error) {
+	var exception entities.PaymentException
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&exception).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exception, nil
+}
+
+// GetByExternalID retrieves a payment exception by the gateway transaction ID
+func (r *paymentExceptionRepositoryImpl) GetByExternalID(ctx context.Context, externalID string) (*entities.PaymentException, error) {
+	var exception entities.PaymentException
+	err := dbFrom(ctx, r.db).Where("external_id = ?", externalID).First(&exception).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exception, nil
+}
+
+// Update updates a payment exception record
+func (r *paymentExceptionRepositoryImpl) Update(ctx context.Context, exception *entities.PaymentException) error {
+	return dbFrom(ctx, r.db).Save(exception).Error
+}
+
+// List retrieves payment exceptions, oldest open first
+func (r *paymentExceptionRepositoryImpl) List(ctx context.Context, filters repositories.PaymentExceptionFilters) ([]entities.PaymentException, error) {
+	var exceptions []entities.PaymentException
+
+	query := dbFrom(ctx, r.db).Model(&entities.PaymentException{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Reason != "" {
+		query = query.Where("reason = ?", filters.Reason)
+	}
+
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	err := query.Order("created_at ASC").Find(&exceptions).Error
+	return exceptions, err
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type paymentNotificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPaymentNotificationRepository creates a new payment notification repository instance
+func NewPaymentNotificationRepository(db *gorm.DB) repositories.PaymentNotificationRepository {
+	return &paymentNotificationRepositoryImpl{db: db}
+}
+
+// Create stores a gateway notification
+func (r *paymentNotificationRepositoryImpl) Create(ctx context.Context, notification *entities.PaymentNotification) error {
+	return dbFrom(ctx, r.db).Create(notification).Error
+}
+
+// GetByID retrieves a payment notification by its ID
+func (r *paymentNotificationRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.PaymentNotification, error) {
+	var notification entities.PaymentNotification
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&notification).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// Update updates a payment notification record
+func (r *paymentNotificationRepositoryImpl) Update(ctx context.Context, notification *entities.PaymentNotification) error {
+	return dbFrom(ctx, r.db).Save(notification).Error
+}
+
+// List retrieves payment notifications, newest first
+func (r *paymentNotificationRepositoryImpl) List(ctx context.Context, filters repositories.PaymentNotificationFilters) ([]entities.PaymentNotification, error) {
+	var notifications []entities.PaymentNotification
+
+	query := dbFrom(ctx, r.db).Model(&entities.PaymentNotification{})
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Provider != "" {
+		query = query.Where("provider = ?", filters.Provider)
+	}
+	if filters.OrderID != "" {
+		query = query.Where("order_id = ?", filters.OrderID)
+	}
+
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&notifications).Error
+	return notifications, err
+}
+
+// ListDue retrieves the notifications due for another attempt, oldest first
+func (r *paymentNotificationRepositoryImpl) ListDue(ctx context.Context, now time.Time, limit int) ([]entities.PaymentNotification, error) {
+	var notifications []entities.PaymentNotification
+	err := dbFrom(ctx, r.db).
+		Where("status IN ? AND next_attempt_at <= ?", []entities.PaymentNotificationStatus{entities.NotificationReceived, entities.NotificationRetrying}, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, err
+}
@@ -2,10 +2,14 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type paymentRepositoryImpl struct {
@@ -17,50 +21,258 @@ func NewPaymentRepository(db *gorm.DB) repositories.PaymentRepository {
 	return &paymentRepositoryImpl{db: db}
 }
 
-// CreatePayment creates a new payment record
+// CreatePayment creates a new payment record along with the mapping of its gateway order and its
+// recorded events
 func (r *paymentRepositoryImpl) CreatePayment(ctx context.Context, payment *entities.Payment) error {
-	return r.db.WithContext(ctx).Create(payment).Error
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		if err := recordPaymentOrder(tx, payment); err != nil {
+			return err
+		}
+		return recordPaymentEvents(tx, payment)
+	})
 }
 
 // GetPaymentByID retrieves a payment by its ID
 func (r *paymentRepositoryImpl) GetPaymentByID(ctx context.Context, id string) (*entities.Payment, error) {
 	var payment entities.Payment
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&payment).Error
+	err := dbFrom(ctx, r.db).Scopes(scopeStore(ctx, "payments.store_id")).Where("id = ?", id).First(&payment).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
 
-// GetPaymentByTransactionID retrieves a payment by transaction ID
+// GetPaymentByTransactionID retrieves the latest payment of a transaction
 func (r *paymentRepositoryImpl) GetPaymentByTransactionID(ctx context.Context, transactionID string) (*entities.Payment, error) {
 	var payment entities.Payment
-	err := r.db.WithContext(ctx).Where("transaction_id = ?", transactionID).First(&payment).Error
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "payments.store_id")).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at DESC").
+		First(&payment).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
 
-// UpdatePayment updates a payment record
+// GetPaymentByOrderID retrieves a payment by its current gateway order ID
+func (r *paymentRepositoryImpl) GetPaymentByOrderID(ctx context.Context, orderID string) (*entities.Payment, error) {
+	var payment entities.Payment
+	err := dbFrom(ctx, r.db).Where("order_id = ?", orderID).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByExternalID retrieves a payment by the transaction ID its gateway assigned
+func (r *paymentRepositoryImpl) GetPaymentByExternalID(ctx context.Context, externalID string) (*entities.Payment, error) {
+	var payment entities.Payment
+	err := dbFrom(ctx, r.db).Where("external_id = ?", externalID).Order("created_at DESC").First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentOrder retrieves the mapping of a current or superseded gateway order ID
+func (r *paymentRepositoryImpl) GetPaymentOrder(ctx context.Context, orderID string) (*entities.PaymentOrder, error) {
+	var order entities.PaymentOrder
+	err := dbFrom(ctx, r.db).Where("order_id = ?", orderID).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// UpdatePayment updates a payment record, mapping its order ID when a refresh issued a new one and
+// recording its status changes
 func (r *paymentRepositoryImpl) UpdatePayment(ctx context.Context, payment *entities.Payment) error {
-	return r.db.WithContext(ctx).Save(payment).Error
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := lockPaymentStatus(tx, payment); err != nil {
+			return err
+		}
+		if err := tx.Save(payment).Error; err != nil {
+			return err
+		}
+		if err := recordPaymentOrder(tx, payment); err != nil {
+			return err
+		}
+		return recordPaymentEvents(tx, payment)
+	})
+}
+
+// lockPaymentStatus refuses status changes made to a stale copy of a payment, such as a gateway settling
+// a payment the expiry job expired meanwhile: the status they started from must still be the stored one.
+// The row stays locked until the transaction ends, so concurrent changes are applied one after the other.
+func lockPaymentStatus(tx *gorm.DB, payment *entities.Payment) error {
+	from, changed := payment.StatusBeforeChanges()
+	if !changed {
+		return nil
+	}
+
+	var stored entities.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("status").
+		Where("id = ?", payment.ID).
+		Take(&stored).Error; err != nil {
+		return err
+	}
+	if stored.Status != from {
+		return fmt.Errorf("%w: payment is %s now", appErrors.ErrIllegalPaymentTransition, stored.Status)
+	}
+	return nil
+}
+
+// recordPaymentEvents inserts the status changes recorded by the payment since it was last saved
+func recordPaymentEvents(tx *gorm.DB, payment *entities.Payment) error {
+	events := payment.TakePaymentEvents()
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.Create(&events).Error
+}
+
+// ListPaymentEvents returns the status changes of a payment, oldest first
+func (r *paymentRepositoryImpl) ListPaymentEvents(ctx context.Context, paymentID string) ([]entities.PaymentEvent, error) {
+	var events []entities.PaymentEvent
+	err := dbFrom(ctx, r.db).
+		Where("payment_id = ?", paymentID).
+		Order("created_at ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// recordPaymentOrder maps the order ID of a payment to it, once
+func recordPaymentOrder(tx *gorm.DB, payment *entities.Payment) error {
+	if payment.OrderID == "" {
+		return nil
+	}
+	order := &entities.PaymentOrder{
+		OrderID:       payment.OrderID,
+		PaymentID:     payment.ID,
+		TransactionID: payment.TransactionID,
+		Provider:      payment.Provider,
+	}
+	return tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "order_id"}}, DoNothing: true}).Create(order).Error
+}
+
+func (r *paymentRepositoryImpl) GetPaidAmount(ctx context.Context, transactionID string) (int64, error) {
+	var paid int64
+	err := dbFrom(ctx, r.db).
+		Model(&entities.Payment{}).
+		Where("transaction_id = ? AND status = ?", transactionID, entities.PaymentSuccess).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&paid).Error
+	return paid, err
+}
+
+// ListExpiredPending retrieves pending payments whose expiry time has passed
+func (r *paymentRepositoryImpl) ListExpiredPending(ctx context.Context, now time.Time, limit int) ([]entities.Payment, error) {
+	var payments []entities.Payment
+	err := dbFrom(ctx, r.db).
+		Where("status = ? AND expires_at < ?", entities.PaymentPending, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// ListUnsettledGatewayPayments retrieves pending, unexpired QRIS and e-wallet payments issued by a gateway, oldest first
+func (r *paymentRepositoryImpl) ListUnsettledGatewayPayments(ctx context.Context, createdBefore, now time.Time, limit int) ([]entities.Payment, error) {
+	var payments []entities.Payment
+	err := dbFrom(ctx, r.db).
+		Where("status = ? AND method IN ? AND order_id <> '' AND expires_at >= ?", entities.PaymentPending,
+			[]entities.PaymentMethod{entities.PaymentMethodQRIS, entities.PaymentMethodEWallet}, now).
+		Where("created_at < ? AND (provider IS NULL OR provider NOT IN ?)", createdBefore,
+			[]entities.PaymentProvider{entities.ProviderLocalQRIS, entities.ProviderTraining}).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
+
+// ListGatewayPayments retrieves the non-training payments issued by a gateway in a period, oldest first.
+// Midtrans also owns the payments recorded before the provider was.
+func (r *paymentRepositoryImpl) ListGatewayPayments(ctx context.Context, provider entities.PaymentProvider, createdFrom, createdTo time.Time, limit int) ([]entities.Payment, error) {
+	query := dbFrom(ctx, r.db).
+		Where("order_id <> '' AND is_training = ? AND method IN ?", false,
+			[]entities.PaymentMethod{entities.PaymentMethodQRIS, entities.PaymentMethodEWallet}).
+		Where("created_at >= ? AND created_at < ?", createdFrom, createdTo)
+	if provider == entities.ProviderMidtrans {
+		query = query.Where("(provider = ? OR provider IS NULL OR provider = '')", provider)
+	} else {
+		query = query.Where("provider = ?", provider)
+	}
+
+	var payments []entities.Payment
+	err := query.Order("created_at ASC").Limit(limit).Find(&payments).Error
+	return payments, err
 }
 
-// DeletePayment deletes a payment record
-func (r *paymentRepositoryImpl) DeletePayment(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.Payment{}).Error
+// ListPaymentsByOrderIDs retrieves the payments with the given gateway order IDs
+func (r *paymentRepositoryImpl) ListPaymentsByOrderIDs(ctx context.Context, orderIDs []string) ([]entities.Payment, error) {
+	var payments []entities.Payment
+	if len(orderIDs) == 0 {
+		return payments, nil
+	}
+	err := dbFrom(ctx, r.db).Where("order_id IN ?", orderIDs).Find(&payments).Error
+	return payments, err
+}
+
+// ListPayments retrieves the payments matching the filters, newest first
+func (r *paymentRepositoryImpl) ListPayments(ctx context.Context, filters repositories.PaymentFilters) ([]entities.Payment, error) {
+	query := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "payments.store_id")).
+		Where("is_training = ?", filters.Training)
+
+	if filters.StoreID != "" {
+		query = query.Where("store_id = ?", filters.StoreID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Method != "" {
+		query = query.Where("method = ?", filters.Method)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at < ?", *filters.To)
+	}
+	if filters.MinAmount != nil {
+		query = query.Where("amount >= ?", *filters.MinAmount)
+	}
+	if filters.MaxAmount != nil {
+		query = query.Where("amount <= ?", *filters.MaxAmount)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	var payments []entities.Payment
+	// The ID breaks ties so pages don't skip or repeat payments made in the same instant
+	err := query.Order("created_at DESC").Order("id DESC").Find(&payments).Error
+	return payments, err
 }
 
 // CreateQRISCode creates a new QRIS code record
 func (r *paymentRepositoryImpl) CreateQRISCode(ctx context.Context, qrisCode *entities.QRISCode) error {
-	return r.db.WithContext(ctx).Create(qrisCode).Error
+	return dbFrom(ctx, r.db).Create(qrisCode).Error
 }
 
 // GetQRISCodeByID retrieves a QRIS code by its ID
 func (r *paymentRepositoryImpl) GetQRISCodeByID(ctx context.Context, id string) (*entities.QRISCode, error) {
 	var qrisCode entities.QRISCode
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&qrisCode).Error
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&qrisCode).Error
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +282,7 @@ func (r *paymentRepositoryImpl) GetQRISCodeByID(ctx context.Context, id string)
 // GetQRISCodeByTransactionID retrieves a QRIS code by transaction ID
 func (r *paymentRepositoryImpl) GetQRISCodeByTransactionID(ctx context.Context, transactionID string) (*entities.QRISCode, error) {
 	var qrisCode entities.QRISCode
-	err := r.db.WithContext(ctx).Where("transaction_id = ?", transactionID).First(&qrisCode).Error
+	err := dbFrom(ctx, r.db).Where("transaction_id = ?", transactionID).First(&qrisCode).Error
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +292,7 @@ func (r *paymentRepositoryImpl) GetQRISCodeByTransactionID(ctx context.Context,
 // GetQRISCodeByPaymentID retrieves a QRIS code by payment ID
 func (r *paymentRepositoryImpl) GetQRISCodeByPaymentID(ctx context.Context, paymentID string) (*entities.QRISCode, error) {
 	var qrisCode entities.QRISCode
-	err := r.db.WithContext(ctx).Where("payment_id = ?", paymentID).First(&qrisCode).Error
+	err := dbFrom(ctx, r.db).Where("payment_id = ?", paymentID).First(&qrisCode).Error
 	if err != nil {
 		return nil, err
 	}
@@ -89,10 +301,10 @@ func (r *paymentRepositoryImpl) GetQRISCodeByPaymentID(ctx context.Context, paym
 
 // UpdateQRISCode updates a QRIS code record
 func (r *paymentRepositoryImpl) UpdateQRISCode(ctx context.Context, qrisCode *entities.QRISCode) error {
-	return r.db.WithContext(ctx).Save(qrisCode).Error
+	return dbFrom(ctx, r.db).Save(qrisCode).Error
 }
 
 // DeleteQRISCode deletes a QRIS code record
 func (r *paymentRepositoryImpl) DeleteQRISCode(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.QRISCode{}).Error
+	return dbFrom(ctx, r.db).Where("id = ?", id).Delete(&entities.QRISCode{}).Error
 }
\ No newline at end of file
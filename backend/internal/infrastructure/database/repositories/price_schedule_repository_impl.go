@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type priceScheduleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPriceScheduleRepository(db *gorm.DB) repositories.PriceScheduleRepository {
+	return &priceScheduleRepositoryImpl{db: db}
+}
+
+func (r *priceScheduleRepositoryImpl) Create(ctx context.Context, schedule *entities.PriceSchedule) error {
+	return dbFrom(ctx, r.db).Create(schedule).Error
+}
+
+func (r *priceScheduleRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.PriceSchedule, error) {
+	var schedule entities.PriceSchedule
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "price_schedules.store_id")).
+		Where("id = ?", id).
+		First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *priceScheduleRepositoryImpl) Update(ctx context.Context, schedule *entities.PriceSchedule) error {
+	return dbFrom(ctx, r.db).Save(schedule).Error
+}
+
+func (r *priceScheduleRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.PriceSchedule{}, "id = ?", id).Error
+}
+
+func (r *priceScheduleRepositoryImpl) List(ctx context.Context, filters repositories.PriceScheduleFilters) ([]entities.PriceSchedule, error) {
+	query := dbFrom(ctx, r.db).Scopes(scopeStore(ctx, "price_schedules.store_id"))
+	if filters.ProductID != "" {
+		query = query.Where("product_id = ?", filters.ProductID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var schedules []entities.PriceSchedule
+	err := query.Order("starts_at DESC").Limit(filters.Limit).Offset(filters.Offset).Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *priceScheduleRepositoryImpl) ListDue(ctx context.Context, now time.Time) ([]entities.PriceSchedule, error) {
+	var schedules []entities.PriceSchedule
+	err := dbFrom(ctx, r.db).
+		Where("status = ? OR (status = ? AND is_active AND starts_at <= ?)", entities.PriceScheduleInEffect, entities.PriceSchedulePending, now).
+		Order("starts_at ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *priceScheduleRepositoryImpl) Transition(ctx context.Context, schedule *entities.PriceSchedule, change *entities.PriceChange) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if change != nil {
+			var scheduleID *string
+			if schedule.Status == entities.PriceScheduleInEffect {
+				scheduleID = &schedule.ID
+			}
+			if err := tx.Model(&entities.Product{}).
+				Where("id = ?", change.ProductID).
+				Updates(map[string]interface{}{"price": change.NewPrice, "price_schedule_id": scheduleID}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(change).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(schedule).Error
+	})
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type printJobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPrintJobRepository(db *gorm.DB) repositories.PrintJobRepository {
+	return &printJobRepositoryImpl{db: db}
+}
+
+func (r *printJobRepositoryImpl) CreatePrinter(ctx context.Context, printer *entities.Printer) error {
+	return dbFrom(ctx, r.db).Create(printer).Error
+}
+
+func (r *printJobRepositoryImpl) GetPrinter(ctx context.Context, id string) (*entities.Printer, error) {
+	var printer entities.Printer
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "printers.store_id")).
+		Where("id = ?", id).
+		First(&printer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &printer, nil
+}
+
+func (r *printJobRepositoryImpl) GetPrinterByTokenHash(ctx context.Context, tokenHash string) (*entities.Printer, error) {
+	var printer entities.Printer
+	err := dbFrom(ctx, r.db).
+		Where("token_hash = ? AND is_active = ?", tokenHash, true).
+		First(&printer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &printer, nil
+}
+
+func (r *printJobRepositoryImpl) UpdatePrinter(ctx context.Context, printer *entities.Printer) error {
+	return dbFrom(ctx, r.db).Save(printer).Error
+}
+
+func (r *printJobRepositoryImpl) TouchPrinter(ctx context.Context, id string, seenAt time.Time) error {
+	return dbFrom(ctx, r.db).
+		Model(&entities.Printer{}).
+		Where("id = ?", id).
+		UpdateColumn("last_seen_at", seenAt).Error
+}
+
+func (r *printJobRepositoryImpl) ListPrinters(ctx context.Context) ([]entities.Printer, error) {
+	var printers []entities.Printer
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "printers.store_id")).
+		Order("name ASC").
+		Find(&printers).Error
+	return printers, err
+}
+
+func (r *printJobRepositoryImpl) DeletePrinter(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.Printer{}, "id = ?", id).Error
+}
+
+func (r *printJobRepositoryImpl) CreateJob(ctx context.Context, job *entities.PrintJob) error {
+	return dbFrom(ctx, r.db).Create(job).Error
+}
+
+func (r *printJobRepositoryImpl) GetJob(ctx context.Context, id string) (*entities.PrintJob, error) {
+	var job entities.PrintJob
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "print_jobs.store_id")).
+		Where("id = ?", id).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *printJobRepositoryImpl) UpdateJob(ctx context.Context, job *entities.PrintJob) error {
+	return dbFrom(ctx, r.db).Save(job).Error
+}
+
+func (r *printJobRepositoryImpl) ListJobs(ctx context.Context, filters repositories.PrintJobFilters) ([]entities.PrintJob, error) {
+	query := dbFrom(ctx, r.db).Scopes(scopeStore(ctx, "print_jobs.store_id"))
+	if filters.PrinterID != "" {
+		query = query.Where("printer_id = ?", filters.PrinterID)
+	}
+	if filters.TransactionID != "" {
+		query = query.Where("transaction_id = ?", filters.TransactionID)
+	}
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var jobs []entities.PrintJob
+	err := query.Order("created_at DESC").Limit(filters.Limit).Offset(filters.Offset).Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *printJobRepositoryImpl) ClaimNext(ctx context.Context, printerID string, leaseExpiredBefore time.Time) (*entities.PrintJob, error) {
+	var job entities.PrintJob
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Two agents polling for the same printer must not both get the job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("printer_id = ?", printerID).
+			Where("status = ? OR (status = ? AND claimed_at < ?)", entities.PrintJobQueued, entities.PrintJobPrinting, leaseExpiredBefore).
+			Order("created_at ASC").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = entities.PrintJobPrinting
+		job.Attempts++
+		job.ClaimedAt = &now
+		return tx.Save(&job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
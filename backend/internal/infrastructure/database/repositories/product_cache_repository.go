@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/cache"
+
+	"gorm.io/gorm"
+)
+
+// catalogVersionKey holds the current generation of the cached catalog. Cached reads are keyed by it,
+// so replacing it invalidates every entry at once, list and search results included.
+const catalogVersionKey = "products:version"
+
+// catalogTables are the tables whose writes change what a cached product read returns
+var catalogTables = map[string]bool{"products": true, "categories": true, "product_images": true, "product_price_tiers": true, "recipe_items": true}
+
+// cachedProductRepository puts a cache-aside layer in front of the catalog reads of the POS screen.
+// Writes are passed through, methods not overridden here always hit the database.
+type cachedProductRepository struct {
+	repositories.ProductRepository
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedProductRepository caches GetByID, List and Search of the wrapped repository. Every write to
+// the catalog tables made through db invalidates the cache, including stock returned by
+// cancellations and refunds in other repositories.
+func NewCachedProductRepository(db *gorm.DB, next repositories.ProductRepository, c cache.Cache, ttl time.Duration) repositories.ProductRepository {
+	r := &cachedProductRepository{
+		ProductRepository: next,
+		cache:             c,
+		ttl:               ttl,
+	}
+
+	invalidate := func(tx *gorm.DB) {
+		if tx.Error == nil && tx.RowsAffected > 0 && catalogTables[tx.Statement.Table] {
+			r.invalidate(tx.Statement.Context)
+		}
+	}
+	db.Callback().Create().After("gorm:create").Register("cache:invalidate_catalog", invalidate)
+	db.Callback().Update().After("gorm:update").Register("cache:invalidate_catalog", invalidate)
+	db.Callback().Delete().After("gorm:delete").Register("cache:invalidate_catalog", invalidate)
+
+	return r
+}
+
+func (r *cachedProductRepository) GetByID(ctx context.Context, id string) (*entities.Product, error) {
+	var product *entities.Product
+	err := r.cached(ctx, "id:"+id, &product, func() (err error) {
+		product, err = r.ProductRepository.GetByID(ctx, id)
+		return err
+	})
+	return product, err
+}
+
+func (r *cachedProductRepository) List(ctx context.Context, filters repositories.ProductFilters) ([]entities.Product, error) {
+	active := "any"
+	if filters.IsActive != nil {
+		active = strconv.FormatBool(*filters.IsActive)
+	}
+	key := fmt.Sprintf("list:%s:%s:%s:%s:%s:%d:%d", filters.CategoryID, active, priceBound(filters.MinPrice), priceBound(filters.MaxPrice),
+		filters.SortBy, filters.Limit, filters.Offset)
+
+	var products []entities.Product
+	err := r.cached(ctx, key, &products, func() (err error) {
+		products, err = r.ProductRepository.List(ctx, filters)
+		return err
+	})
+	return products, err
+}
+
+func (r *cachedProductRepository) Search(ctx context.Context, filters repositories.ProductSearchFilters) ([]entities.Product, error) {
+	key := fmt.Sprintf("search:%s:%s:%s:%d:%d:%s", filters.CategoryID, priceBound(filters.MinPrice), priceBound(filters.MaxPrice),
+		filters.Limit, filters.Offset, strings.ToLower(filters.Query))
+
+	var products []entities.Product
+	err := r.cached(ctx, key, &products, func() (err error) {
+		products, err = r.ProductRepository.Search(ctx, filters)
+		return err
+	})
+	return products, err
+}
+
+// RefreshPopularity reorders the popular product lists with raw SQL the write callbacks don't see
+func (r *cachedProductRepository) RefreshPopularity(ctx context.Context, since time.Time) (int64, error) {
+	refreshed, err := r.ProductRepository.RefreshPopularity(ctx, since)
+	if err == nil {
+		r.invalidate(ctx)
+	}
+	return refreshed, err
+}
+
+// cached reads the key into dest, or runs load and stores what dest holds afterwards. The cache is
+// best effort: when it fails the database answers. Errors of load, such as a missing record, are not cached.
+func (r *cachedProductRepository) cached(ctx context.Context, key string, dest any, load func() error) error {
+	version, ok := r.version(ctx)
+	if !ok {
+		return load()
+	}
+
+	// Products are scoped to the tenant and store of the request, so are their cache entries
+	scope := "all"
+	if storeID, ok := repositories.StoreFromContext(ctx); ok {
+		scope = storeID
+	}
+	if tenantID, ok := repositories.TenantFromContext(ctx); ok {
+		scope = tenantID + ":" + scope
+	}
+	key = fmt.Sprintf("products:%s:%s:%s", version, scope, key)
+
+	if data, err := r.cache.Get(ctx, key); err == nil && json.Unmarshal(data, dest) == nil {
+		return nil
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+	if data, err := json.Marshal(dest); err == nil {
+		r.cache.Set(ctx, key, data, r.ttl)
+	}
+	return nil
+}
+
+// version returns the current catalog generation, starting a new one when none is stored. A
+// generation evicted from the cache is never reused, so entries written under it can't come back.
+func (r *cachedProductRepository) version(ctx context.Context) (string, bool) {
+	data, err := r.cache.Get(ctx, catalogVersionKey)
+	if err == nil {
+		return string(data), true
+	}
+	if !errors.Is(err, cache.ErrMiss) {
+		return "", false
+	}
+
+	version := newCatalogVersion()
+	if err := r.cache.Set(ctx, catalogVersionKey, []byte(version), 0); err != nil {
+		return "", false
+	}
+	return version, true
+}
+
+func (r *cachedProductRepository) invalidate(ctx context.Context) {
+	// A write that went through must invalidate even when its request is being cancelled
+	r.cache.Set(context.WithoutCancel(ctx), catalogVersionKey, []byte(newCatalogVersion()), 0)
+}
+
+func priceBound(price *int64) string {
+	if price == nil {
+		return "-"
+	}
+	return strconv.FormatInt(*price, 10)
+}
+
+func newCatalogVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
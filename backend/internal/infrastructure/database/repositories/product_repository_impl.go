@@ -2,12 +2,23 @@ package repositories
 
 import (
 	"context"
+	"regexp"
+	"strings"
+	"time"
+
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// maxSearchTerms caps the words of a search query, a product name rarely needs more
+const maxSearchTerms = 8
+
+var searchSeparator = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
 type productRepositoryImpl struct {
 	db *gorm.DB
 }
@@ -17,13 +28,18 @@ func NewProductRepository(db *gorm.DB) repositories.ProductRepository {
 }
 
 func (r *productRepositoryImpl) Create(ctx context.Context, product *entities.Product) error {
-	return r.db.WithContext(ctx).Create(product).Error
+	return dbFrom(ctx, r.db).Create(product).Error
 }
 
 func (r *productRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Product, error) {
 	var product entities.Product
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
 		Preload("Category").
+		Preload("PriceTiers", orderPriceTiers).
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Order("product_images.sort_order ASC, product_images.created_at ASC")
+		}).
+		Scopes(scopeStore(ctx, "products.store_id")).
 		Where("id = ?", id).
 		First(&product).Error
 	if err != nil {
@@ -34,8 +50,9 @@ func (r *productRepositoryImpl) GetByID(ctx context.Context, id string) (*entiti
 
 func (r *productRepositoryImpl) GetBySKU(ctx context.Context, sku string) (*entities.Product, error) {
 	var product entities.Product
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
 		Preload("Category").
+		Scopes(scopeStore(ctx, "products.store_id")).
 		Where("sku = ?", sku).
 		First(&product).Error
 	if err != nil {
@@ -44,20 +61,114 @@ func (r *productRepositoryImpl) GetBySKU(ctx context.Context, sku string) (*enti
 	return &product, nil
 }
 
+func (r *productRepositoryImpl) NextSKUNumber(ctx context.Context) (int64, error) {
+	var number int64
+	err := dbFrom(ctx, r.db).Raw("SELECT nextval('product_sku_seq')").Scan(&number).Error
+	return number, err
+}
+
+func (r *productRepositoryImpl) GetByBarcode(ctx context.Context, code string) (*entities.Product, error) {
+	var product entities.Product
+	err := dbFrom(ctx, r.db).
+		Preload("Category").
+		Preload("PriceTiers", orderPriceTiers).
+		Scopes(scopeStore(ctx, "products.store_id")).
+		Where("barcode = ? OR sku = ?", code, code).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "barcode = ? DESC", Vars: []any{code}}}).
+		First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
 func (r *productRepositoryImpl) Update(ctx context.Context, product *entities.Product) error {
-	return r.db.WithContext(ctx).Save(product).Error
+	// Images, price tiers and recipes are managed on their own, saving a loaded product must not bring
+	// back one deleted in the meantime
+	return dbFrom(ctx, r.db).Omit("Images", "PriceTiers", "Recipe").Save(product).Error
+}
+
+// ReplacePriceTiers sets the quantity breaks of a product, removing the previous ones
+func (r *productRepositoryImpl) ReplacePriceTiers(ctx context.Context, productID string, tiers []entities.PriceTier) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&entities.PriceTier{}).Error; err != nil {
+			return err
+		}
+		if len(tiers) == 0 {
+			return nil
+		}
+		for i := range tiers {
+			tiers[i].ProductID = productID
+		}
+		return tx.Create(&tiers).Error
+	})
+}
+
+// GetRecipe returns the ingredients of a product with the ingredient products, deleted ones included
+func (r *productRepositoryImpl) GetRecipe(ctx context.Context, productID string) ([]entities.RecipeItem, error) {
+	var items []entities.RecipeItem
+	err := dbFrom(ctx, r.db).
+		Preload("Ingredient", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("product_id = ?", productID).
+		Order("created_at ASC, id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// ReplaceRecipe sets the ingredients of a product, removing the previous ones
+func (r *productRepositoryImpl) ReplaceRecipe(ctx context.Context, productID string, items []entities.RecipeItem) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&entities.RecipeItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		for i := range items {
+			items[i].ProductID = productID
+		}
+		return tx.Omit("Ingredient").Create(&items).Error
+	})
+}
+
+func orderPriceTiers(db *gorm.DB) *gorm.DB {
+	return db.Order("product_price_tiers.min_quantity ASC")
 }
 
 func (r *productRepositoryImpl) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&entities.Product{}, "id = ?", id).Error
+	return dbFrom(ctx, r.db).Delete(&entities.Product{}, "id = ?", id).Error
+}
+
+func (r *productRepositoryImpl) GetDeletedByID(ctx context.Context, id string) (*entities.Product, error) {
+	var product entities.Product
+	err := dbFrom(ctx, r.db).
+		Unscoped().
+		Scopes(scopeStore(ctx, "products.store_id")).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *productRepositoryImpl) Restore(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Unscoped().Model(&entities.Product{}).Where("id = ?", id).Update("deleted_at", nil).Error
 }
 
 func (r *productRepositoryImpl) List(ctx context.Context, filters repositories.ProductFilters) ([]entities.Product, error) {
 	var products []entities.Product
-	query := r.db.WithContext(ctx).Preload("Category")
+	query := dbFrom(ctx, r.db).
+		Preload("Category").
+		Preload("PriceTiers", orderPriceTiers).
+		Scopes(scopeStore(ctx, "products.store_id"), scopePriceRange(filters.MinPrice, filters.MaxPrice))
+
+	if filters.IncludeDeleted {
+		query = query.Unscoped()
+	}
 
 	if filters.CategoryID != "" {
-		query = query.Where("category_id = ?", filters.CategoryID)
+		query = query.Where("category_id IN (?)", categorySubtree(r.db, filters.CategoryID))
 	}
 
 	if filters.IsActive != nil {
@@ -72,29 +183,284 @@ func (r *productRepositoryImpl) List(ctx context.Context, filters repositories.P
 		query = query.Offset(filters.Offset)
 	}
 
-	err := query.Order("created_at DESC").Find(&products).Error
+	if filters.SortBy == "popular" {
+		// Products without sales in the window, including new ones, follow by name
+		query = query.
+			Joins("LEFT JOIN product_popularity pp ON pp.product_id = products.id").
+			Order("COALESCE(pp.units_sold, 0) DESC, products.name ASC")
+	} else {
+		query = query.Order("products.created_at DESC")
+	}
+
+	err := query.Find(&products).Error
 	return products, err
 }
 
-func (r *productRepositoryImpl) UpdateStock(ctx context.Context, id string, quantity int) error {
-	return r.db.WithContext(ctx).
-		Model(&entities.Product{}).
-		Where("id = ?", id).
-		Update("stock", gorm.Expr("stock + ?", quantity)).
-		Error
+func (r *productRepositoryImpl) UpdateStock(ctx context.Context, id string, quantity float64) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.Product{}).
+			Where("id = ?", id).
+			Update("stock", gorm.Expr("stock + ?", quantity)).Error; err != nil {
+			return err
+		}
+		return recordStockChanges(tx, id)
+	})
+}
+
+// ReserveStock deducts stock only when enough is available, guarding against overselling
+func (r *productRepositoryImpl) ReserveStock(ctx context.Context, id string, quantity float64) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.Product{}).
+			Where("id = ? AND stock >= ?", id, quantity).
+			Update("stock", gorm.Expr("stock - ?", quantity))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return appErrors.ErrInsufficientStock
+		}
+		return recordStockChanges(tx, id)
+	})
+}
+
+// productChangedAt is when a product last changed, soft deletes don't touch updated_at
+const productChangedAt = "GREATEST(products.updated_at, products.deleted_at)"
+
+func (r *productRepositoryImpl) ListChanged(ctx context.Context, since time.Time, afterID string, until time.Time, limit int) ([]entities.Product, error) {
+	var products []entities.Product
+	err := dbFrom(ctx, r.db).
+		Unscoped().
+		Scopes(scopeStore(ctx, "products.store_id")).
+		Where("("+productChangedAt+" > ? OR ("+productChangedAt+" = ? AND products.id::text > ?))", since, since, afterID).
+		Where(productChangedAt+" <= ?", until).
+		Order(productChangedAt + " ASC, products.id ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
 }
 
-func (r *productRepositoryImpl) Search(ctx context.Context, query string, limit int) ([]entities.Product, error) {
+func (r *productRepositoryImpl) GetByIDs(ctx context.Context, ids []string) ([]entities.Product, error) {
 	var products []entities.Product
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
 		Preload("Category").
-		Where("name ILIKE ? OR sku ILIKE ?", "%"+query+"%", "%"+query+"%").
-		Where("is_active = true").
+		Preload("PriceTiers", orderPriceTiers).
+		Scopes(scopeStore(ctx, "products.store_id")).
+		Where("id IN ?", ids).
+		Order("name ASC").
+		Find(&products).Error
+	return products, err
+}
+
+// ApplyPriceChanges updates product prices and records an audit entry per change atomically. The new
+// prices are list prices, replacing the price of any schedule in effect.
+func (r *productRepositoryImpl) ApplyPriceChanges(ctx context.Context, changes []entities.PriceChange) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i := range changes {
+			change := &changes[i]
+			if err := tx.Model(&entities.Product{}).
+				Where("id = ?", change.ProductID).
+				Updates(map[string]interface{}{"price": change.NewPrice, "price_schedule_id": nil}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(change).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *productRepositoryImpl) ListPriceChanges(ctx context.Context, productID string, limit, offset int) ([]entities.PriceChange, error) {
+	var changes []entities.PriceChange
+	err := dbFrom(ctx, r.db).
+		Where("product_id = ?", productID).
+		Order("created_at DESC").
 		Limit(limit).
+		Offset(offset).
+		Find(&changes).Error
+	return changes, err
+}
+
+// Search matches every term of the query as a word prefix through the search vector, so "kop sus" finds
+// "Kopi Susu". A misspelled name still matches by trigram word similarity, and SKUs match by prefix.
+// The best full-text and similarity scores come first.
+func (r *productRepositoryImpl) Search(ctx context.Context, filters repositories.ProductSearchFilters) ([]entities.Product, error) {
+	var products []entities.Product
+
+	terms := searchTerms(filters.Query)
+	if len(terms) == 0 {
+		return products, nil
+	}
+	prefixQuery := strings.Join(terms, ":* & ") + ":*"
+	text := strings.Join(terms, " ")
+
+	query := dbFrom(ctx, r.db).
+		Preload("Category").
+		Preload("PriceTiers", orderPriceTiers).
+		Scopes(scopeStore(ctx, "products.store_id"), scopePriceRange(filters.MinPrice, filters.MaxPrice)).
+		Where("products.is_active = true").
+		Where("products.search_vector @@ to_tsquery('simple', ?) OR ? <% products.name OR products.sku ILIKE ?", prefixQuery, text, text+"%")
+
+	if filters.CategoryID != "" {
+		query = query.Where("products.category_id IN (?)", categorySubtree(r.db, filters.CategoryID))
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	err := query.
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ts_rank(products.search_vector, to_tsquery('simple', ?)) + word_similarity(?, products.name) DESC, products.name ASC",
+			Vars: []any{prefixQuery, text},
+		}}).
 		Find(&products).Error
 	return products, err
 }
 
+// searchTerms splits a query into lowercase words. Everything but letters and digits is dropped, which
+// also keeps tsquery syntax out of user input.
+func searchTerms(query string) []string {
+	var terms []string
+	for _, term := range searchSeparator.Split(strings.ToLower(query), -1) {
+		if term != "" {
+			terms = append(terms, term)
+		}
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// categorySubtree selects the ID of a category and of every category nested under it, so filtering by a
+// category includes the products of its subcategories
+func categorySubtree(db *gorm.DB, categoryID string) *gorm.DB {
+	return db.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION
+		SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+	) SELECT id FROM subtree`, categoryID)
+}
+
+// scopePriceRange narrows products to a price range, either bound may be omitted
+func scopePriceRange(minPrice, maxPrice *int64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if minPrice != nil {
+			db = db.Where("products.price >= ?", *minPrice)
+		}
+		if maxPrice != nil {
+			db = db.Where("products.price <= ?", *maxPrice)
+		}
+		return db
+	}
+}
+
+// RefreshPopularity replaces the rolled up sales counts with the net units sold since the given time,
+// the same way the sales reports count them
+func (r *productRepositoryImpl) RefreshPopularity(ctx context.Context, since time.Time) (int64, error) {
+	refunded := r.db.
+		Table("refund_items").
+		Select("refunds.transaction_id, refund_items.product_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Group("refunds.transaction_id, refund_items.product_id")
+
+	sold := r.db.
+		Table("transaction_items ti").
+		Select("ti.product_id, SUM(ti.quantity - COALESCE(rf.quantity, 0)), ?", time.Now()).
+		Joins("JOIN transactions t ON t.id = ti.transaction_id AND t.deleted_at IS NULL AND NOT t.is_training").
+		Joins("LEFT JOIN (?) rf ON rf.transaction_id = ti.transaction_id AND rf.product_id = ti.product_id", refunded).
+		Where("ti.deleted_at IS NULL").
+		Where("t.status IN ?", []entities.TransactionStatus{entities.StatusPaid, entities.StatusPartiallyRefunded, entities.StatusRefunded}).
+		Where("t.created_at >= ?", since).
+		Group("ti.product_id").
+		Having("SUM(ti.quantity - COALESCE(rf.quantity, 0)) > 0")
+
+	var refreshed int64
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM product_popularity").Error; err != nil {
+			return err
+		}
+		result := tx.Exec("INSERT INTO product_popularity (product_id, units_sold, refreshed_at) ?", sold)
+		refreshed = result.RowsAffected
+		return result.Error
+	})
+	return refreshed, err
+}
+
+type productImageRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) repositories.ProductImageRepository {
+	return &productImageRepositoryImpl{db: db}
+}
+
+func (r *productImageRepositoryImpl) Create(ctx context.Context, image *entities.ProductImage) error {
+	return dbFrom(ctx, r.db).Create(image).Error
+}
+
+func (r *productImageRepositoryImpl) GetByID(ctx context.Context, productID, id string) (*entities.ProductImage, error) {
+	var image entities.ProductImage
+	err := dbFrom(ctx, r.db).
+		Where("id = ? AND product_id = ?", id, productID).
+		First(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *productImageRepositoryImpl) ListByProduct(ctx context.Context, productID string) ([]entities.ProductImage, error) {
+	var images []entities.ProductImage
+	err := dbFrom(ctx, r.db).
+		Where("product_id = ?", productID).
+		Order("sort_order ASC, created_at ASC").
+		Find(&images).Error
+	return images, err
+}
+
+func (r *productImageRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.ProductImage{}, "id = ?", id).Error
+}
+
+func (r *productImageRepositoryImpl) UpdateSortOrders(ctx context.Context, images []entities.ProductImage) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for _, image := range images {
+			if err := tx.Model(&entities.ProductImage{}).
+				Where("id = ?", image.ID).
+				Update("sort_order", image.SortOrder).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *productImageRepositoryImpl) FilterReferenced(ctx context.Context, urls []string) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	if len(urls) == 0 {
+		return referenced, nil
+	}
+
+	var found []string
+	err := dbFrom(ctx, r.db).
+		Raw(`SELECT url FROM product_images WHERE url IN ?
+			UNION SELECT thumbnail_url FROM product_images WHERE thumbnail_url IN ?
+			UNION SELECT image_url FROM products WHERE image_url IN ?`, urls, urls, urls).
+		Scan(&found).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, url := range found {
+		referenced[url] = true
+	}
+	return referenced, nil
+}
+
 type categoryRepositoryImpl struct {
 	db *gorm.DB
 }
@@ -104,12 +470,12 @@ func NewCategoryRepository(db *gorm.DB) repositories.CategoryRepository {
 }
 
 func (r *categoryRepositoryImpl) Create(ctx context.Context, category *entities.Category) error {
-	return r.db.WithContext(ctx).Create(category).Error
+	return dbFrom(ctx, r.db).Create(category).Error
 }
 
 func (r *categoryRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Category, error) {
 	var category entities.Category
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&category).Error
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&category).Error
 	if err != nil {
 		return nil, err
 	}
@@ -117,19 +483,112 @@ func (r *categoryRepositoryImpl) GetByID(ctx context.Context, id string) (*entit
 }
 
 func (r *categoryRepositoryImpl) Update(ctx context.Context, category *entities.Category) error {
-	return r.db.WithContext(ctx).Save(category).Error
+	return dbFrom(ctx, r.db).Save(category).Error
 }
 
 func (r *categoryRepositoryImpl) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&entities.Category{}, "id = ?", id).Error
+	return dbFrom(ctx, r.db).Delete(&entities.Category{}, "id = ?", id).Error
+}
+
+func (r *categoryRepositoryImpl) GetDeletedByID(ctx context.Context, id string) (*entities.Category, error) {
+	var category entities.Category
+	err := dbFrom(ctx, r.db).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepositoryImpl) Restore(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Unscoped().Model(&entities.Category{}).Where("id = ?", id).Update("deleted_at", nil).Error
 }
 
-func (r *categoryRepositoryImpl) List(ctx context.Context, limit, offset int) ([]entities.Category, error) {
+func (r *categoryRepositoryImpl) List(ctx context.Context, limit, offset int, includeDeleted bool) ([]entities.Category, error) {
 	var categories []entities.Category
-	err := r.db.WithContext(ctx).
+	query := dbFrom(ctx, r.db)
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	err := query.
 		Limit(limit).
 		Offset(offset).
 		Order("name ASC").
 		Find(&categories).Error
 	return categories, err
-}
\ No newline at end of file
+}
+
+func (r *categoryRepositoryImpl) GetByName(ctx context.Context, name string) (*entities.Category, error) {
+	var category entities.Category
+	err := dbFrom(ctx, r.db).Where("LOWER(name) = LOWER(?)", name).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepositoryImpl) Merge(ctx context.Context, sourceID, targetID string) (int64, error) {
+	var moved int64
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.Product{}).
+			Where("category_id = ?", sourceID).
+			Update("category_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		if err := tx.Model(&entities.Promotion{}).
+			Where("category_id = ?", sourceID).
+			Update("category_id", targetID).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&entities.Category{}).
+			Where("id = ?", sourceID).
+			Update("is_active", false).Error
+	})
+	return moved, err
+}
+
+func (r *categoryRepositoryImpl) ListAll(ctx context.Context) ([]entities.Category, error) {
+	var categories []entities.Category
+	err := dbFrom(ctx, r.db).Order("name ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *categoryRepositoryImpl) CountProducts(ctx context.Context, id string) (int64, error) {
+	var count int64
+	err := dbFrom(ctx, r.db).Model(&entities.Product{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *categoryRepositoryImpl) DeleteAndReassign(ctx context.Context, category *entities.Category, targetID string) (int64, error) {
+	var moved int64
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if targetID != "" {
+			// Deleted products move too, so restoring one doesn't bring back a deleted category
+			result := tx.Unscoped().Model(&entities.Product{}).
+				Where("category_id = ?", category.ID).
+				Update("category_id", targetID)
+			if result.Error != nil {
+				return result.Error
+			}
+			moved = result.RowsAffected
+
+			if err := tx.Model(&entities.Promotion{}).
+				Where("category_id = ?", category.ID).
+				Update("category_id", targetID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&entities.Category{}).
+			Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&entities.Category{}, "id = ?", category.ID).Error
+	})
+	return moved, err
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type promotionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) repositories.PromotionRepository {
+	return &promotionRepositoryImpl{db: db}
+}
+
+func (r *promotionRepositoryImpl) Create(ctx context.Context, promotion *entities.Promotion) error {
+	return dbFrom(ctx, r.db).Create(promotion).Error
+}
+
+func (r *promotionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Promotion, error) {
+	var promotion entities.Promotion
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&promotion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+func (r *promotionRepositoryImpl) Update(ctx context.Context, promotion *entities.Promotion) error {
+	return dbFrom(ctx, r.db).Save(promotion).Error
+}
+
+func (r *promotionRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.Promotion{}, "id = ?", id).Error
+}
+
+func (r *promotionRepositoryImpl) List(ctx context.Context, filters repositories.PromotionFilters) ([]entities.Promotion, error) {
+	var promotions []entities.Promotion
+	query := dbFrom(ctx, r.db)
+
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+
+	if filters.IsActive != nil {
+		query = query.Where("is_active = ?", *filters.IsActive)
+	}
+
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	err := query.Order("priority DESC, created_at DESC").Find(&promotions).Error
+	return promotions, err
+}
+
+// ListActive returns enabled promotions without a promo code; time windows are evaluated by the promotion engine
+func (r *promotionRepositoryImpl) ListActive(ctx context.Context) ([]entities.Promotion, error) {
+	var promotions []entities.Promotion
+	err := dbFrom(ctx, r.db).
+		Where("is_active = ? AND code IS NULL", true).
+		Order("priority DESC").
+		Find(&promotions).Error
+	return promotions, err
+}
+
+func (r *promotionRepositoryImpl) GetByCode(ctx context.Context, code string) (*entities.Promotion, error) {
+	var promotion entities.Promotion
+	err := dbFrom(ctx, r.db).Where("code = ?", code).First(&promotion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// CountCodeRedemptions counts transactions holding the code; cancelled and expired ones give their use back
+func (r *promotionRepositoryImpl) CountCodeRedemptions(ctx context.Context, code, excludeTransactionID string) (int64, error) {
+	var count int64
+	err := dbFrom(ctx, r.db).
+		Model(&entities.Transaction{}).
+		Where("promo_code = ? AND id <> ?", code, excludeTransactionID).
+		Where("status NOT IN ?", []entities.TransactionStatus{entities.StatusCancelled, entities.StatusExpired}).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *promotionRepositoryImpl) ReplaceTransactionPromotions(ctx context.Context, transactionID string, applied []entities.TransactionPromotion) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transaction_id = ?", transactionID).Delete(&entities.TransactionPromotion{}).Error; err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			return nil
+		}
+
+		for i := range applied {
+			applied[i].TransactionID = transactionID
+		}
+		return tx.Create(&applied).Error
+	})
+}
+
+func (r *promotionRepositoryImpl) GetTransactionPromotions(ctx context.Context, transactionID string) ([]entities.TransactionPromotion, error) {
+	var applied []entities.TransactionPromotion
+	err := dbFrom(ctx, r.db).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&applied).Error
+	return applied, err
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type receiptDeliveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewReceiptDeliveryRepository creates a new receipt delivery repository instance
+func NewReceiptDeliveryRepository(db *gorm.DB) repositories.ReceiptDeliveryRepository {
+	return &receiptDeliveryRepositoryImpl{db: db}
+}
+
+// Create queues a new receipt delivery
+func (r *receiptDeliveryRepositoryImpl) Create(ctx context.Context, delivery *entities.ReceiptDelivery) error {
+	return dbFrom(ctx, r.db).Create(delivery).Error
+}
+
+// Update updates a receipt delivery record
+func (r *receiptDeliveryRepositoryImpl) Update(ctx context.Context, delivery *entities.ReceiptDelivery) error {
+	return dbFrom(ctx, r.db).Save(delivery).Error
+}
+
+// ListDue retrieves pending deliveries whose next attempt is due, oldest first
+func (r *receiptDeliveryRepositoryImpl) ListDue(ctx context.Context, now time.Time, limit int) ([]entities.ReceiptDelivery, error) {
+	var deliveries []entities.ReceiptDelivery
+	err := dbFrom(ctx, r.db).
+		Where("status = ? AND next_attempt_at <= ?", entities.DeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ListByTransactionID retrieves the receipt deliveries of a transaction
+func (r *receiptDeliveryRepositoryImpl) ListByTransactionID(ctx context.Context, transactionID string) ([]entities.ReceiptDelivery, error) {
+	var deliveries []entities.ReceiptDelivery
+	err := dbFrom(ctx, r.db).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
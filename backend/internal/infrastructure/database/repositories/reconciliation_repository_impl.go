@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// itemBatchSize keeps the inserts of a large run under the parameter limit of Postgres
+const itemBatchSize = 500
+
+type reconciliationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) repositories.ReconciliationRepository {
+	return &reconciliationRepositoryImpl{db: db}
+}
+
+func (r *reconciliationRepositoryImpl) CreateRun(ctx context.Context, run *entities.ReconciliationRun) error {
+	return dbFrom(ctx, r.db).Create(run).Error
+}
+
+func (r *reconciliationRepositoryImpl) GetRun(ctx context.Context, id string) (*entities.ReconciliationRun, error) {
+	var run entities.ReconciliationRun
+	if err := dbFrom(ctx, r.db).Where("id = ?", id).First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *reconciliationRepositoryImpl) UpdateRun(ctx context.Context, run *entities.ReconciliationRun) error {
+	return dbFrom(ctx, r.db).Save(run).Error
+}
+
+func (r *reconciliationRepositoryImpl) ListRuns(ctx context.Context, limit, offset int) ([]entities.ReconciliationRun, error) {
+	var runs []entities.ReconciliationRun
+	err := dbFrom(ctx, r.db).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&runs).Error
+	return runs, err
+}
+
+func (r *reconciliationRepositoryImpl) ListPendingRuns(ctx context.Context, limit int) ([]entities.ReconciliationRun, error) {
+	var runs []entities.ReconciliationRun
+	err := dbFrom(ctx, r.db).
+		Where("status = ?", entities.ReconciliationPending).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
+
+func (r *reconciliationRepositoryImpl) ClaimRun(ctx context.Context, run *entities.ReconciliationRun) error {
+	now := time.Now()
+	result := dbFrom(ctx, r.db).
+		Model(&entities.ReconciliationRun{}).
+		Where("id = ? AND status = ?", run.ID, entities.ReconciliationPending).
+		Updates(map[string]interface{}{
+			"status":     entities.ReconciliationProcessing,
+			"started_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrReconciliationAlreadyClaimed
+	}
+	run.Status = entities.ReconciliationProcessing
+	run.StartedAt = &now
+	return nil
+}
+
+func (r *reconciliationRepositoryImpl) CompleteRun(ctx context.Context, run *entities.ReconciliationRun, items []entities.ReconciliationItem) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("run_id = ?", run.ID).Delete(&entities.ReconciliationItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			if err := tx.CreateInBatches(items, itemBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(run).Error
+	})
+}
+
+func (r *reconciliationRepositoryImpl) ListItems(ctx context.Context, runID string, result entities.ReconciliationResult, limit, offset int) ([]entities.ReconciliationItem, error) {
+	query := dbFrom(ctx, r.db).Where("run_id = ?", runID)
+	if result != "" {
+		query = query.Where("result = ?", result)
+	}
+
+	var items []entities.ReconciliationItem
+	err := query.
+		Order("result = 'matched' ASC, order_id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&items).Error
+	return items, err
+}
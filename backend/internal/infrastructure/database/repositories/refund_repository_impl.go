@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type refundRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewRefundRepository creates a new refund repository instance
+func NewRefundRepository(db *gorm.DB) repositories.RefundRepository {
+	return &refundRepositoryImpl{db: db}
+}
+
+// RecordRefund saves the refund with its items, updates the transaction and restores stock in one database transaction
+func (r *refundRepositoryImpl) RecordRefund(ctx context.Context, refund *entities.Refund, transaction *entities.Transaction, restock bool) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entities.Transaction{}).
+			Where("id = ?", transaction.ID).
+			Updates(map[string]interface{}{
+				"status":          transaction.Status,
+				"refunded_amount": transaction.RefundedAmount,
+			}).Error; err != nil {
+			return err
+		}
+
+		if !restock {
+			return nil
+		}
+
+		productIDs := make([]string, 0, len(refund.Items))
+		for _, item := range refund.Items {
+			if item.Restock == 0 {
+				continue
+			}
+			if err := tx.Model(&entities.Product{}).
+				Where("id = ?", item.ProductID).
+				Update("stock", gorm.Expr("stock + ?", item.Restock)).Error; err != nil {
+				return err
+			}
+			productIDs = append(productIDs, item.ProductID)
+		}
+		return recordStockChanges(tx, productIDs...)
+	})
+}
+
+// ListByPaymentID retrieves the refunds of a payment with their items
+func (r *refundRepositoryImpl) ListByPaymentID(ctx context.Context, paymentID string) ([]entities.Refund, error) {
+	var refunds []entities.Refund
+	err := dbFrom(ctx, r.db).
+		Preload("Items").
+		Where("payment_id = ?", paymentID).
+		Order("created_at ASC").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+// GetRefundedQuantities returns the quantity already refunded per product of a transaction
+func (r *refundRepositoryImpl) GetRefundedQuantities(ctx context.Context, transactionID string) (map[string]float64, error) {
+	var rows []struct {
+		ProductID string
+		Quantity  float64
+	}
+	err := dbFrom(ctx, r.db).
+		Table("refund_items").
+		Select("refund_items.product_id, SUM(refund_items.quantity) AS quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.transaction_id = ?", transactionID).
+		Group("refund_items.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		quantities[row.ProductID] = row.Quantity
+	}
+	return quantities, nil
+}
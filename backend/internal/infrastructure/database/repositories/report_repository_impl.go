@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+// Net figures per sold item line, returned quantities and amounts taken off. Cost is rounded to a whole
+// minor unit once per group since quantities can be fractional.
+const (
+	netQuantityExpr = "SUM(ti.quantity - COALESCE(rf.quantity, 0))"
+	netRevenueExpr  = "SUM(ti.total_price - COALESCE(rf.amount, 0))"
+	netCostExpr     = "ROUND(SUM(ti.unit_cost * (ti.quantity - COALESCE(rf.quantity, 0))))::bigint"
+)
+
+var salesSortColumns = map[string]string{
+	"quantity": "quantity_sold DESC",
+	"revenue":  "revenue DESC",
+	"margin":   "margin DESC",
+}
+
+type reportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewReportRepository creates a new report repository instance
+func NewReportRepository(db *gorm.DB) repositories.ReportRepository {
+	return &reportRepositoryImpl{db: db}
+}
+
+// SalesByProduct aggregates sold quantity, revenue and margin per product
+func (r *reportRepositoryImpl) SalesByProduct(ctx context.Context, filters repositories.SalesReportFilters) ([]repositories.ProductSales, error) {
+	var rows []repositories.ProductSales
+	err := r.salesQuery(ctx, filters).
+		Select("p.id AS product_id, p.name AS product_name, c.id AS category_id, c.name AS category_name, " +
+			netQuantityExpr + " AS quantity_sold, " +
+			netRevenueExpr + " AS revenue, " +
+			netCostExpr + " AS cost, " +
+			netRevenueExpr + " - " + netCostExpr + " AS margin").
+		Group("p.id, p.name, c.id, c.name").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// SalesByCategory aggregates sold quantity, revenue and margin per category
+func (r *reportRepositoryImpl) SalesByCategory(ctx context.Context, filters repositories.SalesReportFilters) ([]repositories.CategorySales, error) {
+	var rows []repositories.CategorySales
+	err := r.salesQuery(ctx, filters).
+		Select("c.id AS category_id, c.name AS category_name, " +
+			netQuantityExpr + " AS quantity_sold, " +
+			netRevenueExpr + " AS revenue, " +
+			netCostExpr + " AS cost, " +
+			netRevenueExpr + " - " + netCostExpr + " AS margin").
+		Group("c.id, c.name").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// DailySalesByProduct aggregates sold quantity per product and day
+func (r *reportRepositoryImpl) DailySalesByProduct(ctx context.Context, filters repositories.SalesReportFilters) ([]repositories.DailyProductSales, error) {
+	var rows []repositories.DailyProductSales
+	err := r.salesQuery(ctx, filters).
+		Select("p.id AS product_id, DATE(t.created_at) AS date, " + netQuantityExpr + " AS quantity_sold").
+		Group("p.id, DATE(t.created_at)").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// DailySettlements sums the successful payments of settled transactions per store, payment day and method.
+// Refunds are left out, the journal books what was collected on the day.
+func (r *reportRepositoryImpl) DailySettlements(ctx context.Context, filters repositories.SalesReportFilters) ([]repositories.DailySettlement, error) {
+	query := dbFrom(ctx, r.db).
+		Table("payments pm").
+		Select("t.store_id, s.code AS store_code, s.currency, DATE(pm.paid_at) AS date, pm.method, "+
+			"COUNT(*) AS transactions, SUM(pm.amount) AS amount, SUM(t.tax_amount + t.included_tax) AS tax").
+		Joins("JOIN transactions t ON t.id = pm.transaction_id AND t.deleted_at IS NULL AND NOT t.is_training").
+		Joins("JOIN stores s ON s.id = t.store_id").
+		Scopes(scopeTenant(ctx, "t.tenant_id")).
+		Where("pm.deleted_at IS NULL AND pm.status = ?", entities.PaymentSuccess).
+		Where("t.status IN ?", []entities.TransactionStatus{entities.StatusPaid, entities.StatusPartiallyRefunded, entities.StatusRefunded}).
+		Where("pm.paid_at >= ? AND pm.paid_at < ?", filters.DateFrom, filters.DateTo)
+
+	if filters.StoreID != "" {
+		query = query.Where("t.store_id = ?", filters.StoreID)
+	}
+
+	var rows []repositories.DailySettlement
+	err := query.
+		Group("t.store_id, s.code, s.currency, DATE(pm.paid_at), pm.method").
+		Order("date ASC, store_code ASC, pm.method ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// DailyProfit sets the net sales of settled transactions per day against the cost of goods recorded
+// for them
+func (r *reportRepositoryImpl) DailyProfit(ctx context.Context, filters repositories.SalesReportFilters) ([]repositories.DailyProfit, error) {
+	revenue := r.db.
+		Table("transaction_items ti").
+		Select("ti.transaction_id, SUM(ti.total_price - COALESCE(rf.amount, 0)) AS revenue").
+		Joins("LEFT JOIN (?) rf ON rf.transaction_id = ti.transaction_id AND rf.product_id = ti.product_id", r.refundedQuery()).
+		Where("ti.deleted_at IS NULL AND ti.transaction_id IN (?)", r.settledQuery(ctx, filters).Select("t.id")).
+		Group("ti.transaction_id")
+
+	var rows []repositories.DailyProfit
+	err := r.settledQuery(ctx, filters).
+		Select("DATE(t.created_at) AS date, COUNT(*) AS transactions, "+
+			"COUNT(*) FILTER (WHERE t.costed_at IS NULL) AS uncosted, "+
+			"COALESCE(SUM(s.revenue), 0) AS revenue, "+
+			"SUM(t.cost_of_goods) AS cost_of_goods, "+
+			"COALESCE(SUM(s.revenue), 0) - SUM(t.cost_of_goods) AS gross_profit").
+		Joins("LEFT JOIN (?) s ON s.transaction_id = t.id", revenue).
+		Group("DATE(t.created_at)").
+		Order("date ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// settledQuery selects the settled transactions created in the date range
+func (r *reportRepositoryImpl) settledQuery(ctx context.Context, filters repositories.SalesReportFilters) *gorm.DB {
+	query := dbFrom(ctx, r.db).
+		Table("transactions t").
+		Scopes(scopeTenant(ctx, "t.tenant_id")).
+		Where("t.deleted_at IS NULL AND NOT t.is_training").
+		Where("t.status IN ?", []entities.TransactionStatus{entities.StatusPaid, entities.StatusPartiallyRefunded, entities.StatusRefunded}).
+		Where("t.created_at >= ? AND t.created_at < ?", filters.DateFrom, filters.DateTo)
+
+	if filters.StoreID != "" {
+		query = query.Where("t.store_id = ?", filters.StoreID)
+	}
+
+	if filters.Currency != "" {
+		query = query.Where("t.currency = ?", filters.Currency)
+	}
+
+	return query
+}
+
+// refundedQuery sums the refunded quantity and amount per transaction and product
+func (r *reportRepositoryImpl) refundedQuery() *gorm.DB {
+	return r.db.
+		Table("refund_items").
+		Select("refunds.transaction_id, refund_items.product_id, SUM(refund_items.quantity) AS quantity, SUM(refund_items.amount) AS amount").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Group("refunds.transaction_id, refund_items.product_id")
+}
+
+// salesQuery selects the item lines of settled transactions in the date range
+func (r *reportRepositoryImpl) salesQuery(ctx context.Context, filters repositories.SalesReportFilters) *gorm.DB {
+	query := dbFrom(ctx, r.db).
+		Table("transaction_items ti").
+		Joins("JOIN transactions t ON t.id = ti.transaction_id AND t.deleted_at IS NULL AND NOT t.is_training").
+		Joins("JOIN products p ON p.id = ti.product_id").
+		Joins("JOIN categories c ON c.id = p.category_id").
+		Joins("LEFT JOIN (?) rf ON rf.transaction_id = ti.transaction_id AND rf.product_id = ti.product_id", r.refundedQuery()).
+		Scopes(scopeTenant(ctx, "t.tenant_id")).
+		Where("ti.deleted_at IS NULL").
+		Where("t.status IN ?", []entities.TransactionStatus{entities.StatusPaid, entities.StatusPartiallyRefunded, entities.StatusRefunded}).
+		Where("t.created_at >= ? AND t.created_at < ?", filters.DateFrom, filters.DateTo)
+
+	if filters.StoreID != "" {
+		query = query.Where("t.store_id = ?", filters.StoreID)
+	}
+
+	if filters.Currency != "" {
+		query = query.Where("t.currency = ?", filters.Currency)
+	}
+
+	if filters.CategoryID != "" {
+		query = query.Where("p.category_id = ?", filters.CategoryID)
+	}
+
+	order, ok := salesSortColumns[filters.SortBy]
+	if !ok {
+		order = salesSortColumns["quantity"]
+	}
+	query = query.Order(order)
+
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+
+	return query
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// capturedSQL records the statements a dry-run connection builds, with their variables inlined
+type capturedSQL struct {
+	gormlogger.Interface
+	statements []string
+}
+
+func (c *capturedSQL) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	c.statements = append(c.statements, sql)
+}
+
+func dryRunReports(t *testing.T) (repositories.ReportRepository, *capturedSQL) {
+	t.Helper()
+	captured := &capturedSQL{Interface: gormlogger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 captured,
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return NewReportRepository(db), captured
+}
+
+// reportQueries runs every report built from raw joins, which the tenancy callbacks can't scope
+func reportQueries(ctx context.Context, reports repositories.ReportRepository) map[string]func() {
+	filters := repositories.SalesReportFilters{
+		DateFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		DateTo:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	return map[string]func(){
+		"sales by product":       func() { reports.SalesByProduct(ctx, filters) },
+		"sales by category":      func() { reports.SalesByCategory(ctx, filters) },
+		"daily sales by product": func() { reports.DailySalesByProduct(ctx, filters) },
+		"daily settlements":      func() { reports.DailySettlements(ctx, filters) },
+		"daily profit":           func() { reports.DailyProfit(ctx, filters) },
+	}
+}
+
+func TestReportsScopeTransactionsToTenant(t *testing.T) {
+	ctx := repositories.WithTenant(context.Background(), "tenant-a")
+
+	reports, captured := dryRunReports(t)
+	for name, run := range reportQueries(ctx, reports) {
+		captured.statements = nil
+		run()
+		if len(captured.statements) == 0 {
+			t.Fatalf("%s built no statement", name)
+		}
+		for _, sql := range captured.statements {
+			if !strings.Contains(sql, "t.tenant_id = 'tenant-a'") {
+				t.Errorf("%s isn't limited to the tenant: %s", name, sql)
+			}
+		}
+	}
+}
+
+func TestReportsWithoutTenantCoverEveryTenant(t *testing.T) {
+	reports, captured := dryRunReports(t)
+	for name, run := range reportQueries(context.Background(), reports) {
+		captured.statements = nil
+		run()
+		for _, sql := range captured.statements {
+			if strings.Contains(sql, "tenant_id") {
+				t.Errorf("%s is scoped without a tenant: %s", name, sql)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type reportScheduleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReportScheduleRepository(db *gorm.DB) repositories.ReportScheduleRepository {
+	return &reportScheduleRepositoryImpl{db: db}
+}
+
+func (r *reportScheduleRepositoryImpl) Create(ctx context.Context, schedule *entities.ReportSchedule) error {
+	return dbFrom(ctx, r.db).Create(schedule).Error
+}
+
+func (r *reportScheduleRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.ReportSchedule, error) {
+	var schedule entities.ReportSchedule
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "report_schedules.store_id")).
+		Where("id = ?", id).
+		First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *reportScheduleRepositoryImpl) Update(ctx context.Context, schedule *entities.ReportSchedule) error {
+	return dbFrom(ctx, r.db).Save(schedule).Error
+}
+
+func (r *reportScheduleRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.ReportSchedule{}, "id = ?", id).Error
+}
+
+func (r *reportScheduleRepositoryImpl) List(ctx context.Context) ([]entities.ReportSchedule, error) {
+	var schedules []entities.ReportSchedule
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "report_schedules.store_id")).
+		Order("created_at ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *reportScheduleRepositoryImpl) ListDue(ctx context.Context, now time.Time, limit int) ([]entities.ReportSchedule, error) {
+	var schedules []entities.ReportSchedule
+	err := dbFrom(ctx, r.db).
+		Where("is_active AND next_run_at <= ?", now).
+		Order("next_run_at ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type settingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSettingRepository(db *gorm.DB) repositories.SettingRepository {
+	return &settingRepositoryImpl{db: db}
+}
+
+func (r *settingRepositoryImpl) List(ctx context.Context) ([]entities.Setting, error) {
+	var settings []entities.Setting
+	err := dbFrom(ctx, r.db).Order("key ASC").Find(&settings).Error
+	return settings, err
+}
+
+func (r *settingRepositoryImpl) Save(ctx context.Context, settings []entities.Setting) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	return dbFrom(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+		}).
+		Create(&settings).Error
+}
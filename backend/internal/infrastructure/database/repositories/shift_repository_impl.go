@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type shiftRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewShiftRepository creates a new shift repository instance
+func NewShiftRepository(db *gorm.DB) repositories.ShiftRepository {
+	return &shiftRepositoryImpl{db: db}
+}
+
+// Create opens a new shift
+func (r *shiftRepositoryImpl) Create(ctx context.Context, shift *entities.Shift) error {
+	return dbFrom(ctx, r.db).Create(shift).Error
+}
+
+// GetByID retrieves a shift by its ID
+func (r *shiftRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Shift, error) {
+	var shift entities.Shift
+	err := dbFrom(ctx, r.db).Preload("User").Where("id = ?", id).First(&shift).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// GetOpenByUserID retrieves the shift a user currently has open
+func (r *shiftRepositoryImpl) GetOpenByUserID(ctx context.Context, userID string) (*entities.Shift, error) {
+	var shift entities.Shift
+	err := dbFrom(ctx, r.db).
+		Where("user_id = ? AND status = ?", userID, entities.ShiftOpen).
+		First(&shift).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// Update updates a shift record
+func (r *shiftRepositoryImpl) Update(ctx context.Context, shift *entities.Shift) error {
+	return dbFrom(ctx, r.db).Omit("User").Save(shift).Error
+}
+
+// ListByStatus lists shifts in a status, oldest closing first
+func (r *shiftRepositoryImpl) ListByStatus(ctx context.Context, status entities.ShiftStatus) ([]entities.Shift, error) {
+	var shifts []entities.Shift
+	err := dbFrom(ctx, r.db).
+		Preload("User").
+		Where("status = ?", status).
+		Order("closed_at ASC, opened_at ASC").
+		Find(&shifts).Error
+	return shifts, err
+}
+
+// GetLastHandover retrieves the latest shift closed before the given time with a handover note
+func (r *shiftRepositoryImpl) GetLastHandover(ctx context.Context, before time.Time) (*entities.Shift, error) {
+	var shift entities.Shift
+	err := dbFrom(ctx, r.db).
+		Preload("User").
+		Where("closed_at < ? AND handover_note <> ''", before).
+		Order("closed_at DESC").
+		First(&shift).Error
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// GetTotals sums the shift's settled payments per method and the cash refunds its cashier handed out,
+// training sales aside
+func (r *shiftRepositoryImpl) GetTotals(ctx context.Context, shift *entities.Shift) (*entities.ShiftTotals, error) {
+	var rows []struct {
+		Method entities.PaymentMethod
+		Amount int64
+		Count  int
+	}
+	err := dbFrom(ctx, r.db).
+		Table("payments").
+		Select("payments.method, SUM(payments.amount) AS amount, COUNT(DISTINCT payments.transaction_id) AS count").
+		Joins("JOIN transactions ON transactions.id = payments.transaction_id").
+		Where("transactions.shift_id = ? AND payments.status = ? AND payments.deleted_at IS NULL", shift.ID, entities.PaymentSuccess).
+		Where("NOT payments.is_training").
+		Group("payments.method").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := &entities.ShiftTotals{}
+	for _, row := range rows {
+		totals.TransactionCount += row.Count
+		switch row.Method {
+		case entities.PaymentMethodCash:
+			totals.CashSales = row.Amount
+		case entities.PaymentMethodQRIS:
+			totals.QRISSales = row.Amount
+		case entities.PaymentMethodCard:
+			totals.CardSales = row.Amount
+		default:
+			totals.OtherSales += row.Amount
+		}
+	}
+
+	// Cash handed back over the counter while the shift was open; exchange credit never left the drawer
+	closedAt := time.Now()
+	if shift.ClosedAt != nil {
+		closedAt = *shift.ClosedAt
+	}
+	err = dbFrom(ctx, r.db).
+		Table("refunds").
+		Select("COALESCE(SUM(refunds.amount - refunds.credited_amount), 0)").
+		Joins("JOIN payments ON payments.id = refunds.payment_id").
+		Where("payments.method = ? AND refunds.refunded_by = ? AND NOT payments.is_training", entities.PaymentMethodCash, shift.UserID).
+		Where("refunds.created_at >= ? AND refunds.created_at <= ?", shift.OpenedAt, closedAt).
+		Scan(&totals.CashRefunds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var movements []struct {
+		Type   entities.CashMovementType
+		Amount int64
+		Count  int
+	}
+	err = dbFrom(ctx, r.db).
+		Model(&entities.CashMovement{}).
+		Select("type, SUM(amount) AS amount, COUNT(*) AS count").
+		Where("shift_id = ?", shift.ID).
+		Group("type").
+		Scan(&movements).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, movement := range movements {
+		switch movement.Type {
+		case entities.CashPayIn:
+			totals.CashPaidIn = movement.Amount
+		case entities.CashPayOut:
+			totals.CashPaidOut = movement.Amount
+		case entities.CashDrop:
+			totals.CashDropped = movement.Amount
+		case entities.CashDrawerOpen:
+			totals.DrawerOpens = movement.Count
+		}
+	}
+
+	return totals, nil
+}
+
+// CreateCashMovement records cash moved in or out of the drawer
+func (r *shiftRepositoryImpl) CreateCashMovement(ctx context.Context, movement *entities.CashMovement) error {
+	return dbFrom(ctx, r.db).Create(movement).Error
+}
+
+// ListCashMovements lists the cash movements of a shift, oldest first
+func (r *shiftRepositoryImpl) ListCashMovements(ctx context.Context, shiftID string) ([]entities.CashMovement, error) {
+	var movements []entities.CashMovement
+	err := dbFrom(ctx, r.db).
+		Where("shift_id = ?", shiftID).
+		Order("created_at ASC").
+		Find(&movements).Error
+	return movements, err
+}
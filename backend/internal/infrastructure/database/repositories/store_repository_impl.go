@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type storeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewStoreRepository(db *gorm.DB) repositories.StoreRepository {
+	return &storeRepositoryImpl{db: db}
+}
+
+func (r *storeRepositoryImpl) Create(ctx context.Context, store *entities.Store) error {
+	return dbFrom(ctx, r.db).Create(store).Error
+}
+
+func (r *storeRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Store, error) {
+	var store entities.Store
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&store).Error
+	if err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *storeRepositoryImpl) GetByCode(ctx context.Context, code string) (*entities.Store, error) {
+	var store entities.Store
+	err := dbFrom(ctx, r.db).Where("code = ?", code).First(&store).Error
+	if err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *storeRepositoryImpl) Update(ctx context.Context, store *entities.Store) error {
+	return dbFrom(ctx, r.db).Save(store).Error
+}
+
+func (r *storeRepositoryImpl) List(ctx context.Context, limit, offset int) ([]entities.Store, error) {
+	var stores []entities.Store
+	err := dbFrom(ctx, r.db).
+		Limit(limit).
+		Offset(offset).
+		Order("name ASC").
+		Find(&stores).Error
+	return stores, err
+}
+
+// scopeStore limits a query to the store of the context, the column is qualified so joins can't
+// make it ambiguous
+func scopeStore(ctx context.Context, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if storeID, ok := repositories.StoreFromContext(ctx); ok {
+			return db.Where(column+" = ?", storeID)
+		}
+		return db
+	}
+}
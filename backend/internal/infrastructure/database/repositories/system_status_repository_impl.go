@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type systemStatusRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewSystemStatusRepository creates a new system status repository instance
+func NewSystemStatusRepository(db *gorm.DB) repositories.SystemStatusRepository {
+	return &systemStatusRepositoryImpl{db: db}
+}
+
+// ReceiptDeliveryBacklog counts receipts waiting to be sent and those that gave up retrying
+func (r *systemStatusRepositoryImpl) ReceiptDeliveryBacklog(ctx context.Context) (*repositories.QueueBacklog, error) {
+	var backlog repositories.QueueBacklog
+	err := dbFrom(ctx, r.db).
+		Model(&entities.ReceiptDelivery{}).
+		Select("COUNT(*) FILTER (WHERE status = ?) AS pending, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS failed, "+
+			"MIN(created_at) FILTER (WHERE status = ?) AS oldest_at",
+			entities.DeliveryPending, entities.DeliveryFailed, entities.DeliveryPending).
+		Scan(&backlog).Error
+	return &backlog, err
+}
+
+// PaymentExceptionBacklog counts exceptions nobody has resolved yet
+func (r *systemStatusRepositoryImpl) PaymentExceptionBacklog(ctx context.Context) (*repositories.QueueBacklog, error) {
+	var backlog repositories.QueueBacklog
+	err := dbFrom(ctx, r.db).
+		Model(&entities.PaymentException{}).
+		Select("COUNT(*) AS pending, MIN(created_at) AS oldest_at").
+		Where("status = ?", entities.ExceptionOpen).
+		Scan(&backlog).Error
+	return &backlog, err
+}
+
+// StuckPayments counts pending payments that expired before expiredBefore
+func (r *systemStatusRepositoryImpl) StuckPayments(ctx context.Context, expiredBefore time.Time) (*repositories.QueueBacklog, error) {
+	var backlog repositories.QueueBacklog
+	err := dbFrom(ctx, r.db).
+		Model(&entities.Payment{}).
+		Select("COUNT(*) AS pending, MIN(expires_at) AS oldest_at").
+		Where("status = ? AND expires_at < ?", entities.PaymentPending, expiredBefore).
+		Scan(&backlog).Error
+	return &backlog, err
+}
+
+// DatabaseSize is the disk space used by the current database in bytes
+func (r *systemStatusRepositoryImpl) DatabaseSize(ctx context.Context) (int64, error) {
+	var size int64
+	err := dbFrom(ctx, r.db).Raw("SELECT pg_database_size(current_database())").Scan(&size).Error
+	return size, err
+}
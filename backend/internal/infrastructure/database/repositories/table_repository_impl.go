@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type tableRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTableRepository(db *gorm.DB) repositories.TableRepository {
+	return &tableRepositoryImpl{db: db}
+}
+
+func (r *tableRepositoryImpl) Create(ctx context.Context, table *entities.Table) error {
+	return dbFrom(ctx, r.db).Create(table).Error
+}
+
+func (r *tableRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Table, error) {
+	var table entities.Table
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "tables.store_id")).
+		Where("id = ?", id).
+		First(&table).Error
+	if err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+func (r *tableRepositoryImpl) GetByName(ctx context.Context, name string) (*entities.Table, error) {
+	var table entities.Table
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "tables.store_id")).
+		Where("LOWER(name) = LOWER(?)", name).
+		First(&table).Error
+	if err != nil {
+		return nil, err
+	}
+	return &table, nil
+}
+
+func (r *tableRepositoryImpl) Update(ctx context.Context, table *entities.Table) error {
+	return dbFrom(ctx, r.db).Save(table).Error
+}
+
+func (r *tableRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.Table{}, "id = ?", id).Error
+}
+
+func (r *tableRepositoryImpl) List(ctx context.Context, activeOnly bool) ([]entities.Table, error) {
+	var tables []entities.Table
+	query := dbFrom(ctx, r.db).Scopes(scopeStore(ctx, "tables.store_id"))
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	err := query.Order("area ASC, sort_order ASC, name ASC").Find(&tables).Error
+	return tables, err
+}
+
+func (r *tableRepositoryImpl) ListOpenOrders(ctx context.Context) ([]repositories.TableOrder, error) {
+	var orders []repositories.TableOrder
+	err := r.openOrders(ctx).Scan(&orders).Error
+	return orders, err
+}
+
+func (r *tableRepositoryImpl) GetOpenOrder(ctx context.Context, tableID string) (*repositories.TableOrder, error) {
+	var orders []repositories.TableOrder
+	if err := r.openOrders(ctx).Where("t.table_id = ?", tableID).Limit(1).Scan(&orders).Error; err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &orders[0], nil
+}
+
+// openOrders selects the pending transactions attached to a table with their item count
+func (r *tableRepositoryImpl) openOrders(ctx context.Context) *gorm.DB {
+	return dbFrom(ctx, r.db).
+		Table("transactions t").
+		Select(`t.table_id, t.id AS transaction_id, t.user_id, t.total_amount,
+			COALESCE(SUM(CASE WHEN ti.unit = 'pcs' THEN ti.quantity ELSE 1 END), 0)::int AS item_count, t.created_at AS opened_at`).
+		Joins("LEFT JOIN transaction_items ti ON ti.transaction_id = t.id AND ti.deleted_at IS NULL").
+		Scopes(scopeTenant(ctx, "t.tenant_id"), scopeStore(ctx, "t.store_id")).
+		Where("t.table_id IS NOT NULL AND t.status = ? AND t.deleted_at IS NULL", entities.StatusPending).
+		Group("t.id").
+		Order("t.created_at ASC")
+}
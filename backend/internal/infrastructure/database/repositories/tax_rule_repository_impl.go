@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type taxRuleRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTaxRuleRepository(db *gorm.DB) repositories.TaxRuleRepository {
+	return &taxRuleRepositoryImpl{db: db}
+}
+
+func (r *taxRuleRepositoryImpl) Create(ctx context.Context, rule *entities.TaxRule) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Exemptions").Create(rule).Error; err != nil {
+			return err
+		}
+		return replaceExemptions(tx, rule)
+	})
+}
+
+func (r *taxRuleRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.TaxRule, error) {
+	var rule entities.TaxRule
+	err := dbFrom(ctx, r.db).
+		Preload("Exemptions").
+		Where("id = ?", id).
+		First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *taxRuleRepositoryImpl) Update(ctx context.Context, rule *entities.TaxRule) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Exemptions").Save(rule).Error; err != nil {
+			return err
+		}
+		return replaceExemptions(tx, rule)
+	})
+}
+
+func (r *taxRuleRepositoryImpl) Delete(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.TaxRule{}, "id = ?", id).Error
+}
+
+func (r *taxRuleRepositoryImpl) List(ctx context.Context) ([]entities.TaxRule, error) {
+	var rules []entities.TaxRule
+	err := dbFrom(ctx, r.db).
+		Preload("Exemptions").
+		Order("created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *taxRuleRepositoryImpl) ListActive(ctx context.Context) ([]entities.TaxRule, error) {
+	var rules []entities.TaxRule
+	err := dbFrom(ctx, r.db).
+		Preload("Exemptions").
+		Where("is_active = ?", true).
+		Order("created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *taxRuleRepositoryImpl) ReplaceTransactionTaxes(ctx context.Context, transactionID string, taxes []entities.TransactionTax) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("transaction_id = ?", transactionID).Delete(&entities.TransactionTax{}).Error; err != nil {
+			return err
+		}
+
+		if len(taxes) == 0 {
+			return nil
+		}
+
+		for i := range taxes {
+			taxes[i].TransactionID = transactionID
+		}
+		return tx.Create(&taxes).Error
+	})
+}
+
+func replaceExemptions(tx *gorm.DB, rule *entities.TaxRule) error {
+	if err := tx.Where("tax_rule_id = ?", rule.ID).Delete(&entities.TaxRuleExemption{}).Error; err != nil {
+		return err
+	}
+
+	if len(rule.Exemptions) == 0 {
+		return nil
+	}
+
+	for i := range rule.Exemptions {
+		rule.Exemptions[i].ID = ""
+		rule.Exemptions[i].TaxRuleID = rule.ID
+	}
+	return tx.Create(&rule.Exemptions).Error
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type tenantRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTenantRepository(db *gorm.DB) repositories.TenantRepository {
+	return &tenantRepositoryImpl{db: db}
+}
+
+func (r *tenantRepositoryImpl) Create(ctx context.Context, tenant *entities.Tenant) error {
+	return dbFrom(ctx, r.db).Create(tenant).Error
+}
+
+func (r *tenantRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	var tenant entities.Tenant
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *tenantRepositoryImpl) GetBySlug(ctx context.Context, slug string) (*entities.Tenant, error) {
+	var tenant entities.Tenant
+	err := dbFrom(ctx, r.db).Where("slug = ?", slug).First(&tenant).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *tenantRepositoryImpl) Update(ctx context.Context, tenant *entities.Tenant) error {
+	return dbFrom(ctx, r.db).Save(tenant).Error
+}
+
+func (r *tenantRepositoryImpl) List(ctx context.Context, limit, offset int) ([]entities.Tenant, error) {
+	var tenants []entities.Tenant
+	err := dbFrom(ctx, r.db).
+		Limit(limit).
+		Offset(offset).
+		Order("slug ASC").
+		Find(&tenants).Error
+	return tenants, err
+}
+
+// scopeTenant limits a query the tenancy callbacks can't see through, such as a join scanned into a
+// report row, to the tenant of the context. The column is qualified so joins can't make it ambiguous.
+func scopeTenant(ctx context.Context, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenantID, ok := repositories.TenantFromContext(ctx); ok {
+			return db.Where(column+" = ?", tenantID)
+		}
+		return db
+	}
+}
@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) repositories.TokenRepository {
+	return &tokenRepositoryImpl{db: db}
+}
+
+func (r *tokenRepositoryImpl) CreateRefreshToken(ctx context.Context, token *entities.RefreshToken) error {
+	return dbFrom(ctx, r.db).Create(token).Error
+}
+
+func (r *tokenRepositoryImpl) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
+	var token entities.RefreshToken
+	err := dbFrom(ctx, r.db).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *tokenRepositoryImpl) RotateRefreshToken(ctx context.Context, current, next *entities.RefreshToken) error {
+	now := time.Now()
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		// Only one of two concurrent refreshes with the same token gets to revoke it
+		result := tx.Model(&entities.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":     now,
+				"replaced_by_id": next.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return appErrors.ErrTokenRevoked
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	current.RevokedAt = &now
+	current.ReplacedByID = &next.ID
+	return nil
+}
+
+func (r *tokenRepositoryImpl) RevokeRefreshToken(ctx context.Context, userID, tokenHash string) error {
+	return dbFrom(ctx, r.db).
+		Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND token_hash = ? AND revoked_at IS NULL", userID, tokenHash).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *tokenRepositoryImpl) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	return dbFrom(ctx, r.db).
+		Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *tokenRepositoryImpl) ListActiveSessions(ctx context.Context, userID string) ([]entities.RefreshToken, error) {
+	var tokens []entities.RefreshToken
+	err := dbFrom(ctx, r.db).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("GREATEST(last_used_at, created_at) DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *tokenRepositoryImpl) ListSessionTokens(ctx context.Context, userID, sessionID string) ([]entities.RefreshToken, error) {
+	var tokens []entities.RefreshToken
+	// Tokens issued before sessions have none and are a session of their own
+	err := dbFrom(ctx, r.db).
+		Where("user_id = ? AND COALESCE(session_id, id) = ?", userID, sessionID).
+		Order("created_at ASC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *tokenRepositoryImpl) TouchSession(ctx context.Context, sessionID string, at time.Time) error {
+	return dbFrom(ctx, r.db).
+		Model(&entities.RefreshToken{}).
+		Where("session_id = ? AND revoked_at IS NULL AND (last_used_at IS NULL OR last_used_at < ?)", sessionID, at.Add(-time.Minute)).
+		Update("last_used_at", at).Error
+}
+
+func (r *tokenRepositoryImpl) RevokeAccessToken(ctx context.Context, token *entities.RevokedToken) error {
+	// Logging out twice with the same token is harmless
+	return dbFrom(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "jti"}}, DoNothing: true}).
+		Create(token).Error
+}
+
+func (r *tokenRepositoryImpl) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := dbFrom(ctx, r.db).
+		Model(&entities.RevokedToken{}).
+		Where("jti = ?", jti).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *tokenRepositoryImpl) CreatePasswordReset(ctx context.Context, reset *entities.PasswordReset) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.PasswordReset{}).
+			Where("user_id = ? AND used_at IS NULL", reset.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(reset).Error
+	})
+}
+
+func (r *tokenRepositoryImpl) GetLatestPasswordReset(ctx context.Context, userID string) (*entities.PasswordReset, error) {
+	var reset entities.PasswordReset
+	err := dbFrom(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&reset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *tokenRepositoryImpl) GetPasswordResetByTokenHash(ctx context.Context, tokenHash string) (*entities.PasswordReset, error) {
+	var reset entities.PasswordReset
+	err := dbFrom(ctx, r.db).Where("token_hash = ?", tokenHash).First(&reset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *tokenRepositoryImpl) CountPasswordResetAttempt(ctx context.Context, id string, maxAttempts int) error {
+	// Only maxAttempts of any number of concurrent tries get counted
+	result := dbFrom(ctx, r.db).
+		Model(&entities.PasswordReset{}).
+		Where("id = ? AND attempts < ? AND verified_at IS NULL AND used_at IS NULL", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrInvalidResetCode
+	}
+	return nil
+}
+
+func (r *tokenRepositoryImpl) VerifyPasswordReset(ctx context.Context, reset *entities.PasswordReset) error {
+	// Only one of two concurrent verifications with the right code gets a reset token
+	result := dbFrom(ctx, r.db).
+		Model(&entities.PasswordReset{}).
+		Where("id = ? AND verified_at IS NULL AND used_at IS NULL", reset.ID).
+		Updates(map[string]interface{}{
+			"token_hash":  reset.TokenHash,
+			"verified_at": reset.VerifiedAt,
+			"expires_at":  reset.ExpiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrInvalidResetCode
+	}
+	return nil
+}
+
+func (r *tokenRepositoryImpl) SpendPasswordReset(ctx context.Context, id string) error {
+	// Only one of two concurrent resets with the same token gets to spend it
+	result := dbFrom(ctx, r.db).
+		Model(&entities.PasswordReset{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrInvalidResetCode
+	}
+	return nil
+}
+
+func (r *tokenRepositoryImpl) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at < ?", before).Delete(&entities.RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		result = tx.Where("expires_at < ?", before).Delete(&entities.RevokedToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		result = tx.Where("expires_at < ?", before).Delete(&entities.PasswordReset{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
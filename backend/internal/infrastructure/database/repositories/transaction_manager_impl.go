@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type transactionManagerImpl struct {
+	db *gorm.DB
+}
+
+// NewTransactionManager creates the unit of work use cases wrap multi-repository writes in
+func NewTransactionManager(db *gorm.DB) repositories.TransactionManager {
+	return &transactionManagerImpl{db: db}
+}
+
+func (m *transactionManagerImpl) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// dbFrom returns the database handle a repository call runs on: the transaction the context carries,
+// or db when there is none. Repositories that open a transaction of their own get a savepoint inside it.
+func dbFrom(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -3,24 +3,35 @@ package repositories
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
 
 	"gorm.io/gorm"
 )
 
 type transactionRepositoryImpl struct {
-	db *gorm.DB
+	db       *gorm.DB
+	location *time.Location // Business timezone receipt numbers restart their count in
 }
 
-func NewTransactionRepository(db *gorm.DB) repositories.TransactionRepository {
-	return &transactionRepositoryImpl{db: db}
+func NewTransactionRepository(db *gorm.DB, location *time.Location) repositories.TransactionRepository {
+	return &transactionRepositoryImpl{db: db, location: location}
 }
 
 func (r *transactionRepositoryImpl) Create(ctx context.Context, transaction *entities.Transaction) error {
 	// Use transaction to ensure data consistency
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Practice sales are not numbered, so the numbers of real sales have no gaps
+		if transaction.ReceiptNo == "" && !transaction.IsTraining {
+			if err := r.assignReceiptNumber(tx, transaction); err != nil {
+				return err
+			}
+		}
+
 		// Create the transaction first
 		if err := tx.Omit("Items").Create(transaction).Error; err != nil {
 			return err
@@ -41,9 +52,33 @@ func (r *transactionRepositoryImpl) Create(ctx context.Context, transaction *ent
 	})
 }
 
+// assignReceiptNumber gives the sale the next receipt number of its store on the business day it was
+// made. The upsert locks the counter row until the sale is saved, so concurrent sales never share a
+// number and a sale that fails to save gives its number back.
+func (r *transactionRepositoryImpl) assignReceiptNumber(tx *gorm.DB, transaction *entities.Transaction) error {
+	madeAt := transaction.CreatedAt
+	if madeAt.IsZero() {
+		madeAt = time.Now()
+	}
+	day := madeAt.In(r.location)
+
+	var number int
+	err := tx.Raw(`INSERT INTO receipt_sequences (store_id, day, last_number) VALUES (?, ?, 1)
+		ON CONFLICT (store_id, day) DO UPDATE SET last_number = receipt_sequences.last_number + 1
+		RETURNING last_number`, transaction.StoreID, day.Format("2006-01-02")).
+		Scan(&number).Error
+	if err != nil {
+		return err
+	}
+
+	transaction.ReceiptNo = entities.FormatReceiptNumber(day, number)
+	return nil
+}
+
 func (r *transactionRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.Transaction, error) {
 	var transaction entities.Transaction
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "transactions.store_id")).
 		Where("id = ?", id).
 		First(&transaction).Error
 
@@ -56,13 +91,19 @@ func (r *transactionRepositoryImpl) GetByID(ctx context.Context, id string) (*en
 
 func (r *transactionRepositoryImpl) GetByIDWithDetails(ctx context.Context, id string) (*entities.Transaction, error) {
 	var transaction entities.Transaction
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
 		Preload("User").
-		Preload("Items").
+		Preload("Items", orderItemsBySequence).
 		Preload("Items.Product").
 		Preload("Items.Product.Category").
 		Preload("Payment").
 		Preload("QRCode").
+		Preload("Promotions").
+		Preload("Taxes").
+		Preload("Customer").
+		Preload("Table").
+		Preload("Store").
+		Scopes(scopeStore(ctx, "transactions.store_id")).
 		Where("id = ?", id).
 		First(&transaction).Error
 
@@ -73,31 +114,58 @@ func (r *transactionRepositoryImpl) GetByIDWithDetails(ctx context.Context, id s
 	return &transaction, nil
 }
 
+func (r *transactionRepositoryImpl) GetByTrackingToken(ctx context.Context, token string) (*entities.Transaction, error) {
+	var transaction entities.Transaction
+	err := dbFrom(ctx, r.db).
+		Preload("Items", orderItemsBySequence).
+		Preload("Items.Product").
+		Preload("Payment").
+		Where("tracking_token = ?", token).
+		First(&transaction).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
 func (r *transactionRepositoryImpl) Update(ctx context.Context, transaction *entities.Transaction) error {
-	return r.db.WithContext(ctx).Save(transaction).Error
+	// The cost of goods is only written by RecordCostOfGoods, saving a transaction loaded before it was
+	// costed must not clear it
+	return dbFrom(ctx, r.db).Omit("CostOfGoods", "CostedAt").Save(transaction).Error
 }
 
 func (r *transactionRepositoryImpl) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&entities.Transaction{}, "id = ?", id).Error
+	return dbFrom(ctx, r.db).Delete(&entities.Transaction{}, "id = ?", id).Error
 }
 
 func (r *transactionRepositoryImpl) List(ctx context.Context, filters repositories.TransactionFilters) ([]entities.Transaction, error) {
 	var transactions []entities.Transaction
-	query := r.db.WithContext(ctx).
+	query := dbFrom(ctx, r.db).
 		Preload("User").
-		Preload("Items").
+		Preload("Items", orderItemsBySequence).
 		Preload("Items.Product").
-		Preload("Payment")
+		Preload("Payment").
+		Scopes(scopeStore(ctx, "transactions.store_id"))
 
 	// Apply filters
 	if filters.UserID != "" {
 		query = query.Where("user_id = ?", filters.UserID)
 	}
 
+	if filters.ReceiptNo != "" {
+		query = query.Where("receipt_no = ?", filters.ReceiptNo)
+	}
+
 	if filters.Status != "" {
 		query = query.Where("status = ?", filters.Status)
 	}
 
+	if filters.Held {
+		query = query.Where("held_at IS NOT NULL")
+	}
+
 	if filters.DateFrom != nil {
 		query = query.Where("created_at >= ?", *filters.DateFrom)
 	}
@@ -120,10 +188,11 @@ func (r *transactionRepositoryImpl) List(ctx context.Context, filters repositori
 
 func (r *transactionRepositoryImpl) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]entities.Transaction, error) {
 	var transactions []entities.Transaction
-	err := r.db.WithContext(ctx).
-		Preload("Items").
+	err := dbFrom(ctx, r.db).
+		Preload("Items", orderItemsBySequence).
 		Preload("Items.Product").
 		Preload("Payment").
+		Scopes(scopeStore(ctx, "transactions.store_id")).
 		Where("user_id = ?", userID).
 		Limit(limit).
 		Offset(offset).
@@ -135,10 +204,11 @@ func (r *transactionRepositoryImpl) GetByUserID(ctx context.Context, userID stri
 
 func (r *transactionRepositoryImpl) GetByStatus(ctx context.Context, status entities.TransactionStatus, limit, offset int) ([]entities.Transaction, error) {
 	var transactions []entities.Transaction
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
 		Preload("User").
-		Preload("Items").
+		Preload("Items", orderItemsBySequence).
 		Preload("Items.Product").
+		Scopes(scopeStore(ctx, "transactions.store_id")).
 		Where("status = ?", status).
 		Limit(limit).
 		Offset(offset).
@@ -148,11 +218,252 @@ func (r *transactionRepositoryImpl) GetByStatus(ctx context.Context, status enti
 	return transactions, err
 }
 
+// ListStalePending returns pending transactions created before the cutoff that have no active payment.
+// Carts parked on hold, orders open on a dine-in table and sales with a deposit paid are left alone.
+func (r *transactionRepositoryImpl) ListStalePending(ctx context.Context, createdBefore time.Time, limit int) ([]entities.Transaction, error) {
+	var transactions []entities.Transaction
+	activePayments := r.db.
+		Model(&entities.Payment{}).
+		Select("1").
+		Where("payments.transaction_id = transactions.id AND ((payments.status = ? AND payments.expires_at > ?) OR payments.status = ?)",
+			entities.PaymentPending, time.Now(), entities.PaymentSuccess)
+
+	err := dbFrom(ctx, r.db).
+		Where("status = ? AND created_at < ? AND held_at IS NULL AND table_id IS NULL", entities.StatusPending, createdBefore).
+		Where("NOT EXISTS (?)", activePayments).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&transactions).Error
+
+	return transactions, err
+}
+
+func (r *transactionRepositoryImpl) ListCreatedBetween(ctx context.Context, from, to time.Time, limit, offset int) ([]entities.Transaction, error) {
+	var transactions []entities.Transaction
+	err := dbFrom(ctx, r.db).
+		Preload("User").
+		Preload("Items", orderItemsBySequence).
+		Preload("Items.Product").
+		Preload("Payment").
+		Scopes(scopeStore(ctx, "transactions.store_id")).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&transactions).Error
+
+	return transactions, err
+}
+
+// ReleaseReservedStock returns reserved item quantities to product stock exactly once
+func (r *transactionRepositoryImpl) ReleaseReservedStock(ctx context.Context, transactionID string) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Flip the flag first so concurrent callers can't release twice
+		result := tx.Model(&entities.Transaction{}).
+			Where("id = ? AND stock_reserved = ?", transactionID, true).
+			Update("stock_reserved", false)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var items []entities.TransactionItem
+		if err := tx.Where("transaction_id = ?", transactionID).Find(&items).Error; err != nil {
+			return err
+		}
+
+		productIDs := make([]string, 0, len(items))
+		for _, item := range items {
+			// What a synced sale sold out of stock was never deducted
+			reserved := entities.RoundQuantity(item.Quantity - item.StockShortfall)
+			if reserved <= 0 {
+				continue
+			}
+			if err := tx.Model(&entities.Product{}).
+				Where("id = ?", item.ProductID).
+				Update("stock", gorm.Expr("stock + ?", reserved)).Error; err != nil {
+				return err
+			}
+			productIDs = append(productIDs, item.ProductID)
+		}
+
+		return recordStockChanges(tx, productIDs...)
+	})
+}
+
+// ReserveStock deducts the item quantities from product stock for a transaction holding no reservation.
+// Nothing is deducted when one item is short, deleted products count as out of stock.
+func (r *transactionRepositoryImpl) ReserveStock(ctx context.Context, transactionID string) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Flip the flag first so concurrent callers can't reserve twice
+		result := tx.Model(&entities.Transaction{}).
+			Where("id = ? AND stock_reserved = ?", transactionID, false).
+			Update("stock_reserved", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var items []entities.TransactionItem
+		if err := tx.Where("transaction_id = ?", transactionID).Find(&items).Error; err != nil {
+			return err
+		}
+
+		productIDs := make([]string, 0, len(items))
+		for _, item := range items {
+			result := tx.Model(&entities.Product{}).
+				Where("id = ? AND stock >= ?", item.ProductID, item.Quantity).
+				Update("stock", gorm.Expr("stock - ?", item.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: product %s", appErrors.ErrInsufficientStock, item.ProductID)
+			}
+			productIDs = append(productIDs, item.ProductID)
+		}
+
+		return recordStockChanges(tx, productIDs...)
+	})
+}
+
+// ListUncosted returns the settled transactions not costed yet, oldest first. Practice sales are never costed.
+func (r *transactionRepositoryImpl) ListUncosted(ctx context.Context, limit int) ([]string, error) {
+	var ids []string
+	err := dbFrom(ctx, r.db).
+		Model(&entities.Transaction{}).
+		Where("costed_at IS NULL AND NOT is_training").
+		Where("status IN ?", []entities.TransactionStatus{entities.StatusPaid, entities.StatusPartiallyRefunded, entities.StatusRefunded}).
+		Order("created_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// RecordCostOfGoods costs a settled transaction exactly once. Items with a recipe are costed at the
+// current cost price of their ingredients, which are deducted from stock, other items keep the cost
+// price they were sold at.
+func (r *transactionRepositoryImpl) RecordCostOfGoods(ctx context.Context, transactionID string) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Claim the transaction first so concurrent runs can't deduct the ingredients twice
+		result := tx.Model(&entities.Transaction{}).
+			Where("id = ? AND costed_at IS NULL", transactionID).
+			Update("costed_at", tx.NowFunc())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Exec(`UPDATE transaction_items ti
+			SET unit_cost = recipe.cost
+			FROM (
+				SELECT ri.product_id, ROUND(SUM(p.cost_price * ri.quantity))::bigint AS cost
+				FROM recipe_items ri
+				JOIN products p ON p.id = ri.ingredient_id
+				GROUP BY ri.product_id
+			) recipe
+			WHERE ti.transaction_id = ? AND ti.deleted_at IS NULL AND ti.product_id = recipe.product_id`, transactionID).Error; err != nil {
+			return err
+		}
+
+		var used []struct {
+			IngredientID string
+			Quantity     float64
+		}
+		if err := tx.Raw(`SELECT ri.ingredient_id, ROUND(SUM(ri.quantity * ti.quantity), 3) AS quantity
+			FROM transaction_items ti
+			JOIN recipe_items ri ON ri.product_id = ti.product_id
+			WHERE ti.transaction_id = ? AND ti.deleted_at IS NULL
+			GROUP BY ri.ingredient_id
+			ORDER BY ri.ingredient_id`, transactionID).Scan(&used).Error; err != nil {
+			return err
+		}
+
+		// Ingredients used up are taken down to 0, the sale already happened
+		ingredientIDs := make([]string, 0, len(used))
+		for _, ingredient := range used {
+			if err := tx.Model(&entities.Product{}).
+				Unscoped().
+				Where("id = ?", ingredient.IngredientID).
+				Update("stock", gorm.Expr("GREATEST(stock - ?, 0)", ingredient.Quantity)).Error; err != nil {
+				return err
+			}
+			ingredientIDs = append(ingredientIDs, ingredient.IngredientID)
+		}
+		if err := recordStockChanges(tx, ingredientIDs...); err != nil {
+			return err
+		}
+
+		return tx.Exec(`UPDATE transactions
+			SET cost_of_goods = COALESCE((
+				SELECT ROUND(SUM(unit_cost * quantity))::bigint
+				FROM transaction_items
+				WHERE transaction_id = ? AND deleted_at IS NULL
+			), 0)
+			WHERE id = ?`, transactionID, transactionID).Error
+	})
+}
+
+func (r *transactionRepositoryImpl) PurgeTraining(ctx context.Context, storeID string) (int64, error) {
+	var purged int64
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		training := tx.Unscoped().
+			Model(&entities.Transaction{}).
+			Select("id").
+			Where("store_id = ? AND is_training", storeID)
+		refunds := tx.Unscoped().
+			Model(&entities.Refund{}).
+			Select("id").
+			Where("transaction_id IN (?) OR exchange_transaction_id IN (?)", training, training)
+
+		// Children first, most of them reference transactions without cascading
+		deletes := []struct {
+			model any
+			where string
+			arg   any
+		}{
+			{&entities.VoidRequest{}, "transaction_id IN (?)", training}, // Also references refunds and exceptions
+			{&entities.RefundItem{}, "refund_id IN (?)", refunds},
+			{&entities.Refund{}, "id IN (?)", refunds},
+			{&entities.Alert{}, "transaction_id IN (?)", training},
+			{&entities.PointsEntry{}, "transaction_id IN (?)", training},
+			{&entities.PaymentException{}, "transaction_id IN (?)", training},
+			{&entities.ReceiptDelivery{}, "transaction_id IN (?)", training},
+			{&entities.PrintJob{}, "transaction_id IN (?)", training},
+			{&entities.QRISCode{}, "transaction_id IN (?)", training},
+			{&entities.Payment{}, "transaction_id IN (?)", training},
+			{&entities.TransactionPromotion{}, "transaction_id IN (?)", training},
+			{&entities.TransactionTax{}, "transaction_id IN (?)", training},
+			{&entities.TransactionItem{}, "transaction_id IN (?)", training},
+		}
+		for _, d := range deletes {
+			if err := tx.Unscoped().Where(d.where, d.arg).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("store_id = ? AND is_training", storeID).Delete(&entities.Transaction{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
 func (r *transactionRepositoryImpl) AddItem(ctx context.Context, item *entities.TransactionItem) error {
-	// Check if item already exists for this transaction and product
+	// Check if item already exists for this transaction and product. Items the kitchen already
+	// started on are left alone, more of them is a new line with its own preparation status.
 	var existingItem entities.TransactionItem
-	err := r.db.WithContext(ctx).
-		Where("transaction_id = ? AND product_id = ?", item.TransactionID, item.ProductID).
+	err := dbFrom(ctx, r.db).
+		Where("transaction_id = ? AND product_id = ? AND prep_status = ?", item.TransactionID, item.ProductID, entities.ItemQueued).
 		First(&existingItem).Error
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -161,28 +472,39 @@ func (r *transactionRepositoryImpl) AddItem(ctx context.Context, item *entities.
 
 	if err == nil {
 		// Item exists, update quantity
-		existingItem.Quantity += item.Quantity
-		existingItem.TotalPrice = existingItem.UnitPrice * float64(existingItem.Quantity)
-		return r.db.WithContext(ctx).Save(&existingItem).Error
+		existingItem.Quantity = entities.RoundQuantity(existingItem.Quantity + item.Quantity)
+		existingItem.TotalPrice = entities.LinePrice(existingItem.UnitPrice, existingItem.Quantity)
+		return dbFrom(ctx, r.db).Save(&existingItem).Error
 	}
 
-	// Item doesn't exist, create new
-	return r.db.WithContext(ctx).Create(item).Error
+	// Item doesn't exist, create new at the end of the display order
+	if item.Sequence == 0 {
+		var maxSequence int
+		if err := dbFrom(ctx, r.db).
+			Model(&entities.TransactionItem{}).
+			Where("transaction_id = ?", item.TransactionID).
+			Select("COALESCE(MAX(sequence), 0)").
+			Scan(&maxSequence).Error; err != nil {
+			return err
+		}
+		item.Sequence = maxSequence + 1
+	}
+	return dbFrom(ctx, r.db).Create(item).Error
 }
 
 func (r *transactionRepositoryImpl) RemoveItem(ctx context.Context, transactionID, productID string) error {
-	return r.db.WithContext(ctx).
+	return dbFrom(ctx, r.db).
 		Where("transaction_id = ? AND product_id = ?", transactionID, productID).
 		Delete(&entities.TransactionItem{}).Error
 }
 
-func (r *transactionRepositoryImpl) UpdateItemQuantity(ctx context.Context, transactionID, productID string, quantity int) error {
+func (r *transactionRepositoryImpl) UpdateItemQuantity(ctx context.Context, transactionID, productID string, quantity float64) error {
 	if quantity <= 0 {
 		return r.RemoveItem(ctx, transactionID, productID)
 	}
 
 	var item entities.TransactionItem
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
 		Where("transaction_id = ? AND product_id = ?", transactionID, productID).
 		First(&item).Error
 
@@ -191,18 +513,99 @@ func (r *transactionRepositoryImpl) UpdateItemQuantity(ctx context.Context, tran
 	}
 
 	item.Quantity = quantity
-	item.TotalPrice = item.UnitPrice * float64(quantity)
+	item.TotalPrice = entities.LinePrice(item.UnitPrice, quantity)
+
+	return dbFrom(ctx, r.db).Save(&item).Error
+}
+
+func (r *transactionRepositoryImpl) UpdateItemPrices(ctx context.Context, items []entities.TransactionItem) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			if err := tx.Model(&entities.TransactionItem{}).
+				Where("id = ?", item.ID).
+				Updates(map[string]interface{}{
+					"unit_price":             item.UnitPrice,
+					"total_price":            item.TotalPrice,
+					"tier_min_quantity":      item.TierMinQuantity,
+					"manual_discount_amount": item.ManualDiscount.Amount,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
 
-	return r.db.WithContext(ctx).Save(&item).Error
+func (r *transactionRepositoryImpl) SetItemDiscount(ctx context.Context, transactionID, productID string, discount entities.ManualDiscount) error {
+	result := dbFrom(ctx, r.db).
+		Model(&entities.TransactionItem{}).
+		Where("transaction_id = ? AND product_id = ?", transactionID, productID).
+		Updates(map[string]interface{}{
+			"manual_discount_type":        discount.Type,
+			"manual_discount_value":       discount.Value,
+			"manual_discount_amount":      discount.Amount,
+			"manual_discount_reason":      discount.Reason,
+			"manual_discount_given_by":    discount.GivenBy,
+			"manual_discount_approved_by": discount.ApprovedBy,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReorderItems sets the display order of the items to the order of the given item IDs
+func (r *transactionRepositoryImpl) ReorderItems(ctx context.Context, transactionID string, itemIDs []string) error {
+	return dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i, itemID := range itemIDs {
+			if err := tx.Model(&entities.TransactionItem{}).
+				Where("id = ? AND transaction_id = ?", itemID, transactionID).
+				Update("sequence", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *transactionRepositoryImpl) GetItem(ctx context.Context, transactionID, itemID string) (*entities.TransactionItem, error) {
+	var item entities.TransactionItem
+	err := dbFrom(ctx, r.db).
+		Where("id = ? AND transaction_id = ?", itemID, transactionID).
+		First(&item).Error
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// UpdateItemPrep saves the kitchen status of an item
+func (r *transactionRepositoryImpl) UpdateItemPrep(ctx context.Context, item *entities.TransactionItem) error {
+	return dbFrom(ctx, r.db).
+		Model(&entities.TransactionItem{}).
+		Where("id = ?", item.ID).
+		Updates(map[string]interface{}{
+			"prep_status":     item.PrepStatus,
+			"prep_updated_at": item.PrepUpdatedAt,
+		}).Error
 }
 
 func (r *transactionRepositoryImpl) GetItems(ctx context.Context, transactionID string) ([]entities.TransactionItem, error) {
 	var items []entities.TransactionItem
-	err := r.db.WithContext(ctx).
+	err := dbFrom(ctx, r.db).
 		Preload("Product").
 		Preload("Product.Category").
 		Where("transaction_id = ?", transactionID).
+		Order("sequence ASC, created_at ASC").
 		Find(&items).Error
 
 	return items, err
-}
\ No newline at end of file
+}
+
+// orderItemsBySequence preloads transaction items in their display order
+func orderItemsBySequence(db *gorm.DB) *gorm.DB {
+	return db.Order("sequence ASC, created_at ASC")
+}
@@ -17,12 +17,12 @@ func NewUserRepository(db *gorm.DB) repositories.UserRepository {
 }
 
 func (r *userRepositoryImpl) Create(ctx context.Context, user *entities.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return dbFrom(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.User, error) {
 	var user entities.User
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	err := dbFrom(ctx, r.db).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +31,7 @@ func (r *userRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.
 
 func (r *userRepositoryImpl) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
 	var user entities.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := dbFrom(ctx, r.db).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -39,16 +39,38 @@ func (r *userRepositoryImpl) GetByEmail(ctx context.Context, email string) (*ent
 }
 
 func (r *userRepositoryImpl) Update(ctx context.Context, user *entities.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	return dbFrom(ctx, r.db).Save(user).Error
 }
 
 func (r *userRepositoryImpl) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&entities.User{}, "id = ?", id).Error
+	return dbFrom(ctx, r.db).Delete(&entities.User{}, "id = ?", id).Error
 }
 
-func (r *userRepositoryImpl) List(ctx context.Context, limit, offset int) ([]entities.User, error) {
+func (r *userRepositoryImpl) GetDeletedByID(ctx context.Context, id string) (*entities.User, error) {
+	var user entities.User
+	err := dbFrom(ctx, r.db).
+		Unscoped().
+		Scopes(scopeStore(ctx, "users.store_id")).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepositoryImpl) Restore(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Unscoped().Model(&entities.User{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+func (r *userRepositoryImpl) List(ctx context.Context, limit, offset int, includeDeleted bool) ([]entities.User, error) {
 	var users []entities.User
-	err := r.db.WithContext(ctx).
+	query := dbFrom(ctx, r.db)
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	err := query.
+		Scopes(scopeStore(ctx, "users.store_id")).
 		Limit(limit).
 		Offset(offset).
 		Order("created_at DESC").
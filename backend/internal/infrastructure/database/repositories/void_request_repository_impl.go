@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type voidRequestRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewVoidRequestRepository creates a new void request repository instance
+func NewVoidRequestRepository(db *gorm.DB) repositories.VoidRequestRepository {
+	return &voidRequestRepositoryImpl{db: db}
+}
+
+func (r *voidRequestRepositoryImpl) Create(ctx context.Context, request *entities.VoidRequest) error {
+	return dbFrom(ctx, r.db).Create(request).Error
+}
+
+func (r *voidRequestRepositoryImpl) GetByID(ctx context.Context, id string) (*entities.VoidRequest, error) {
+	var request entities.VoidRequest
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "void_requests.store_id")).
+		Where("id = ?", id).
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *voidRequestRepositoryImpl) GetPendingByTransactionID(ctx context.Context, transactionID string) (*entities.VoidRequest, error) {
+	var request entities.VoidRequest
+	err := dbFrom(ctx, r.db).
+		Where("transaction_id = ? AND status = ?", transactionID, entities.VoidPending).
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *voidRequestRepositoryImpl) SaveDecision(ctx context.Context, request *entities.VoidRequest) error {
+	result := dbFrom(ctx, r.db).
+		Model(&entities.VoidRequest{}).
+		Where("id = ? AND status = ?", request.ID, entities.VoidPending).
+		Updates(map[string]interface{}{
+			"status":        request.Status,
+			"decided_by":    request.DecidedBy,
+			"decided_at":    request.DecidedAt,
+			"decision_note": request.DecisionNote,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrVoidRequestDecided
+	}
+	return nil
+}
+
+func (r *voidRequestRepositoryImpl) Update(ctx context.Context, request *entities.VoidRequest) error {
+	return dbFrom(ctx, r.db).Save(request).Error
+}
+
+// List retrieves void requests oldest first, so the queue is worked in order
+func (r *voidRequestRepositoryImpl) List(ctx context.Context, filters repositories.VoidRequestFilters) ([]entities.VoidRequest, error) {
+	query := dbFrom(ctx, r.db).Scopes(scopeStore(ctx, "void_requests.store_id"))
+
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.Limit > 0 {
+		query = query.Limit(filters.Limit)
+	}
+	if filters.Offset > 0 {
+		query = query.Offset(filters.Offset)
+	}
+
+	var requests []entities.VoidRequest
+	err := query.Order("created_at ASC").Find(&requests).Error
+	return requests, err
+}
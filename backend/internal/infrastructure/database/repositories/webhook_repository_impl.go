@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) repositories.WebhookRepository {
+	return &webhookRepositoryImpl{db: db}
+}
+
+func (r *webhookRepositoryImpl) CreateEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	return dbFrom(ctx, r.db).Create(endpoint).Error
+}
+
+func (r *webhookRepositoryImpl) GetEndpoint(ctx context.Context, id string) (*entities.WebhookEndpoint, error) {
+	var endpoint entities.WebhookEndpoint
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "webhook_endpoints.store_id")).
+		Where("id = ?", id).
+		First(&endpoint).Error
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *webhookRepositoryImpl) UpdateEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	return dbFrom(ctx, r.db).Save(endpoint).Error
+}
+
+func (r *webhookRepositoryImpl) DeleteEndpoint(ctx context.Context, id string) error {
+	return dbFrom(ctx, r.db).Delete(&entities.WebhookEndpoint{}, "id = ?", id).Error
+}
+
+func (r *webhookRepositoryImpl) ListEndpoints(ctx context.Context) ([]entities.WebhookEndpoint, error) {
+	var endpoints []entities.WebhookEndpoint
+	err := dbFrom(ctx, r.db).
+		Scopes(scopeStore(ctx, "webhook_endpoints.store_id")).
+		Order("created_at ASC").
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepositoryImpl) ListActiveEndpoints(ctx context.Context) ([]entities.WebhookEndpoint, error) {
+	var endpoints []entities.WebhookEndpoint
+	err := dbFrom(ctx, r.db).
+		Where("is_active = ?", true).
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *webhookRepositoryImpl) CreateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) (bool, error) {
+	result := dbFrom(ctx, r.db).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "endpoint_id"}, {Name: "fingerprint"}}, DoNothing: true}).
+		Create(delivery)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *webhookRepositoryImpl) GetDelivery(ctx context.Context, endpointID, id string) (*entities.WebhookDelivery, error) {
+	var delivery entities.WebhookDelivery
+	err := dbFrom(ctx, r.db).
+		Where("id = ? AND endpoint_id = ?", id, endpointID).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepositoryImpl) UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	return dbFrom(ctx, r.db).Save(delivery).Error
+}
+
+func (r *webhookRepositoryImpl) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	var deliveries []entities.WebhookDelivery
+	err := dbFrom(ctx, r.db).
+		Where("status = ? AND next_attempt_at <= ?", entities.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepositoryImpl) ListDeliveries(ctx context.Context, endpointID string, filters repositories.WebhookDeliveryFilters) ([]entities.WebhookDelivery, error) {
+	query := dbFrom(ctx, r.db).Where("endpoint_id = ?", endpointID)
+	if filters.Status != "" {
+		query = query.Where("status = ?", filters.Status)
+	}
+
+	var deliveries []entities.WebhookDelivery
+	err := query.
+		Order("created_at DESC").
+		Limit(filters.Limit).
+		Offset(filters.Offset).
+		Find(&deliveries).Error
+	return deliveries, err
+}
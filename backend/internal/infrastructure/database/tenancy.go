@@ -0,0 +1,94 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const tenantColumn = "tenant_id"
+
+// errOtherTenant is returned when a row of one tenant is created with the context of another
+var errOtherTenant = errors.New("record belongs to another tenant")
+
+// registerTenancy keeps the tenants of SaaS mode apart. Statements on models with a tenant_id column,
+// made with a context scoped to a tenant, only reach the rows of that tenant and stamp the rows they
+// create with it. Raw SQL and joins scanned into other types are scoped by their repositories.
+func registerTenancy(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenancy:create", stampTenant); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenancy:query", scopeToTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenancy:update", scopeToTenant); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenancy:delete", scopeToTenant); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("tenancy:row", scopeToTenant)
+}
+
+// tenantField returns the tenant column of the statement's model and the tenant of its context, nil
+// when the model has no tenant or the context isn't scoped to one
+func tenantField(db *gorm.DB) (*schema.Field, string) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return nil, ""
+	}
+	field := db.Statement.Schema.LookUpField(tenantColumn)
+	if field == nil {
+		return nil, ""
+	}
+	tenantID, ok := repositories.TenantFromContext(db.Statement.Context)
+	if !ok {
+		return nil, ""
+	}
+	return field, tenantID
+}
+
+func scopeToTenant(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+func stampTenant(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	stamp := func(value reflect.Value) {
+		current, isZero := field.ValueOf(db.Statement.Context, value)
+		if !isZero {
+			if id, ok := current.(*string); ok && id != nil && *id != tenantID {
+				db.AddError(errOtherTenant)
+			}
+			return
+		}
+		id := tenantID
+		if err := field.Set(db.Statement.Context, value, &id); err != nil {
+			db.AddError(err)
+		}
+	}
+
+	switch value := reflect.Indirect(db.Statement.ReflectValue); value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			stamp(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		stamp(value)
+	}
+}
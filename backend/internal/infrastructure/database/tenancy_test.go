@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRun opens a connection that builds statements without running them, with the tenancy callbacks
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := registerTenancy(db); err != nil {
+		t.Fatalf("register tenancy: %v", err)
+	}
+	return db
+}
+
+func tenantCondition(stmt *gorm.Statement) (string, bool) {
+	sql := stmt.SQL.String()
+	if !strings.Contains(sql, `"tenant_id" = `) {
+		return sql, false
+	}
+	for _, v := range stmt.Vars {
+		if v == "tenant-a" {
+			return sql, true
+		}
+	}
+	return sql, false
+}
+
+func TestTenancyScopesStatementsToTenant(t *testing.T) {
+	db := dryRun(t)
+	ctx := repositories.WithTenant(context.Background(), "tenant-a")
+
+	statements := map[string]*gorm.Statement{
+		"query":  db.WithContext(ctx).Find(&[]entities.Store{}).Statement,
+		"first":  db.WithContext(ctx).Where("code = ?", "MAIN").First(&entities.Store{}).Statement,
+		"count":  db.WithContext(ctx).Model(&entities.Product{}).Count(new(int64)).Statement,
+		"update": db.WithContext(ctx).Model(&entities.Store{}).Where("id = ?", "store-b").Update("name", "Taken").Statement,
+		"delete": db.WithContext(ctx).Where("id = ?", "product-b").Delete(&entities.Product{}).Statement,
+	}
+	for name, stmt := range statements {
+		if sql, ok := tenantCondition(stmt); !ok {
+			t.Errorf("%s isn't limited to the tenant: %s", name, sql)
+		}
+	}
+}
+
+func TestTenancyLeavesUnscopedStatementsAlone(t *testing.T) {
+	db := dryRun(t)
+
+	// The platform and background jobs see every tenant
+	stmt := db.WithContext(context.Background()).Find(&[]entities.Store{}).Statement
+	if sql := stmt.SQL.String(); strings.Contains(sql, "tenant_id") {
+		t.Errorf("query without a tenant is scoped: %s", sql)
+	}
+
+	// Models without a tenant column are shared
+	ctx := repositories.WithTenant(context.Background(), "tenant-a")
+	stmt = db.WithContext(ctx).Find(&[]entities.Setting{}).Statement
+	if sql := stmt.SQL.String(); strings.Contains(sql, "tenant_id") {
+		t.Errorf("query of a model without a tenant is scoped: %s", sql)
+	}
+}
+
+func TestTenancyStampsCreatedRows(t *testing.T) {
+	db := dryRun(t)
+	ctx := repositories.WithTenant(context.Background(), "tenant-a")
+
+	store := entities.Store{Code: "MAIN", Name: "Main"}
+	if err := db.WithContext(ctx).Create(&store).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if store.TenantID == nil || *store.TenantID != "tenant-a" {
+		t.Errorf("store tenant = %v, want tenant-a", store.TenantID)
+	}
+
+	products := []*entities.Product{{Name: "Tea"}, {Name: "Coffee"}}
+	if err := db.WithContext(ctx).Create(&products).Error; err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+	for _, product := range products {
+		if product.TenantID == nil || *product.TenantID != "tenant-a" {
+			t.Errorf("%s tenant = %v, want tenant-a", product.Name, product.TenantID)
+		}
+	}
+}
+
+func TestTenancyRefusesRowsOfAnotherTenant(t *testing.T) {
+	db := dryRun(t)
+	ctx := repositories.WithTenant(context.Background(), "tenant-a")
+
+	other := "tenant-b"
+	err := db.WithContext(ctx).Create(&entities.Store{Code: "B", Name: "Other", TenantID: &other}).Error
+	if !errors.Is(err, errOtherTenant) {
+		t.Errorf("err = %v, want the row refused", err)
+	}
+
+	products := []*entities.Product{{Name: "Tea"}, {Name: "Coffee", TenantID: &other}}
+	if err := db.WithContext(ctx).Create(&products).Error; !errors.Is(err, errOtherTenant) {
+		t.Errorf("batch err = %v, want the row refused", err)
+	}
+}
@@ -0,0 +1,87 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Columns fits a line of Courier at pdfFontSize between the margins of an A4 page
+	Columns = 90
+
+	pdfFontSize     = 9.0
+	pdfLeading      = 12.0
+	pdfMargin       = 40.0
+	pdfPageWidth    = 595.28 // A4 in points
+	pdfPageHeight   = 841.89
+	pdfLinesPerPage = 63 // (pdfPageHeight - pdfMargin*2) / pdfLeading
+)
+
+// RenderTextPDF lays out plain text lines on as many A4 pages as they need, in a fixed-width font so
+// columns padded with spaces stay aligned. Lines longer than Columns run off the page.
+func RenderTextPDF(lines []string) []byte {
+	var pages [][]string
+	for start := 0; start < len(lines) || start == 0; start += pdfLinesPerPage {
+		end := min(start+pdfLinesPerPage, len(lines))
+		pages = append(pages, lines[start:end])
+	}
+
+	// Objects 1 and 2 are the catalog and page tree, 3 the font, then a page and its content per page
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+	}
+	for i, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %.1f Tf\n%.2f TL\n%.2f %.2f Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) Tj\nT*\n", escapeText(asciiOnly(line)))
+		}
+		content.WriteString("ET\n")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R "+
+				"/Resources << /Font << /F1 3 0 R >> >> >>", pdfPageWidth, pdfPageHeight, 5+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+
+	return buf.Bytes()
+}
+
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	return replacer.Replace(text)
+}
+
+// asciiOnly replaces what the standard Type1 fonts can't show without an encoding
+func asciiOnly(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 32 || r > 126 {
+			return '?'
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of event flowing through the broker
+type EventType string
+
+const (
+	EventPaymentStatus       EventType = "payment.status"
+	EventPaymentException    EventType = "payment.exception"
+	EventQRISJob             EventType = "qris.job"
+	EventStockChanged        EventType = "product.stock_changed"
+	EventTransactionCreated  EventType = "transaction.created"
+	EventAlertRaised         EventType = "alert.raised"
+	EventOrderStatus         EventType = "order.status"
+	EventKitchenOrderNew     EventType = "kitchen.order_new"
+	EventKitchenOrderUpdated EventType = "kitchen.order_updated"
+	EventKitchenOrderReady   EventType = "kitchen.order_ready"
+	EventPrintJobQueued      EventType = "print.job_queued"
+	EventSettingsUpdated     EventType = "settings.updated"
+)
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before events are dropped
+const subscriberBuffer = 16
+
+// Event is a domain notification published to in-process subscribers
+type Event struct {
+	Type          EventType `json:"type"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	StoreID       string    `json:"store_id,omitempty"`  // Set on events routed to a single store, such as kitchen orders and webhooks
+	TenantID      string    `json:"tenant_id,omitempty"` // Set on the events of a tenant in SaaS mode, see ForTenant
+	Data          any       `json:"data"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// StockChange is the payload of EventStockChanged
+type StockChange struct {
+	ProductID string  `json:"product_id"`
+	Stock     float64 `json:"stock"`
+}
+
+// Filter decides whether a subscriber receives an event
+type Filter func(event Event) bool
+
+type subscriber struct {
+	ch     chan Event
+	filter Filter
+}
+
+// Broker is an in-memory publish/subscribe hub for pushing events to live clients
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[uint64]*subscriber
+	nextID      uint64
+	closed      bool
+}
+
+// NewBroker creates a new event broker instance
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[uint64]*subscriber),
+	}
+}
+
+// Subscribe registers a subscriber and returns its event channel and an unsubscribe function.
+// A nil filter receives every event.
+func (b *Broker) Subscribe(filter Filter) (<-chan Event, func()) {
+	return b.SubscribeBuffered(filter, subscriberBuffer)
+}
+
+// SubscribeBuffered is Subscribe with room for more events, for subscribers that must not miss events
+// during bursts, such as the webhook dispatcher
+func (b *Broker) SubscribeBuffered(filter Filter, buffer int) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscriber{
+		ch:     make(chan Event, buffer),
+		filter: filter,
+	}
+	// A closed broker hands out closed channels so late streams end right away
+	if b.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = sub
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		// Close may have released the subscriber already
+		if _, ok := b.subscribers[id]; ok {
+			delete(b.subscribers, id)
+			close(sub.ch)
+		}
+	}
+
+	return sub.ch, unsubscribe
+}
+
+// Publish delivers the event to every matching subscriber without blocking.
+// Subscribers whose buffer is full miss the event.
+func (b *Broker) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription by closing its channel, so SSE and WebSocket streams finish and
+// the server can drain on shutdown. Later subscriptions are closed immediately.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for id, sub := range b.subscribers {
+		delete(b.subscribers, id)
+		close(sub.ch)
+	}
+}
+
+// ForTransaction returns a filter matching events of a single transaction
+func ForTransaction(transactionID string) Filter {
+	return func(event Event) bool {
+		return event.TransactionID == transactionID
+	}
+}
+
+// ForKitchen returns a filter matching the kitchen order events of a store, or of every store when storeID is empty
+func ForKitchen(storeID string) Filter {
+	return func(event Event) bool {
+		if !strings.HasPrefix(string(event.Type), "kitchen.") {
+			return false
+		}
+		return storeID == "" || event.StoreID == storeID
+	}
+}
+
+// ForTenant narrows a filter, nil for every event, to the events of a tenant in SaaS mode. Settings
+// updates are the platform's and reach every tenant, other events outside the tenant never do. An empty
+// tenantID leaves the filter as it is.
+func ForTenant(tenantID string, filter Filter) Filter {
+	if tenantID == "" {
+		return filter
+	}
+	return func(event Event) bool {
+		if event.TenantID != tenantID && event.Type != EventSettingsUpdated {
+			return false
+		}
+		return filter == nil || filter(event)
+	}
+}
+
+// TenantOf returns the tenant of a record for Event.TenantID, empty outside SaaS mode
+func TenantOf(tenantID *string) string {
+	if tenantID == nil {
+		return ""
+	}
+	return *tenantID
+}
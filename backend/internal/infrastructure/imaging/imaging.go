@@ -0,0 +1,85 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	// Registered for image.Decode
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// jpegQuality keeps photos sharp at a fraction of the size a camera saves them at
+const jpegQuality = 85
+
+// maxSourcePixels refuses images whose decoded size would exhaust memory, whatever their file size
+const maxSourcePixels = 50_000_000
+
+var ErrUnsupportedImage = errors.New("unsupported or corrupt image")
+
+// Image is an encoded image ready to be stored
+type Image struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Resize decodes a JPEG, PNG, GIF or WebP image and scales it down so its longest side is at most
+// maxDimension, smaller images keep their size. PNG, GIF and transparent images are encoded as PNG,
+// photos as JPEG. Re-encoding also strips the metadata cameras embed.
+func Resize(data []byte, maxDimension int) (*Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxSourcePixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrUnsupportedImage, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+
+	width, height := fit(config.Width, config.Height, maxDimension)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if width == config.Width && height == config.Height {
+		draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+	}
+
+	var buf bytes.Buffer
+	if format == "png" || format == "gif" || !dst.Opaque() {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, err
+		}
+		return &Image{Data: buf.Bytes(), ContentType: "image/png", Extension: ".png", Width: width, Height: height}, nil
+	}
+
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return &Image{Data: buf.Bytes(), ContentType: "image/jpeg", Extension: ".jpg", Width: width, Height: height}, nil
+}
+
+// fit scales width and height down to maxDimension on the longest side, keeping the aspect ratio
+func fit(width, height, maxDimension int) (int, int) {
+	if maxDimension <= 0 || (width <= maxDimension && height <= maxDimension) {
+		return width, height
+	}
+	if width >= height {
+		return maxDimension, max(1, height*maxDimension/width)
+	}
+	return max(1, width*maxDimension/height), maxDimension
+}
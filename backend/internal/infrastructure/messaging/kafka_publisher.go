@@ -0,0 +1,63 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher publishes to Kafka topics, acknowledged by every in-sync replica. Messages are
+// partitioned by key, so the events of one aggregate are consumed in order.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaPublisher(cfg config.OutboxConfig, logger logger.Logger) (*KafkaPublisher, error) {
+	if len(cfg.KafkaBrokers) == 0 {
+		return nil, fmt.Errorf("KAFKA_BROKERS is required for the kafka driver")
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.KafkaBrokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// The relay sends in batches already, don't hold messages back waiting for more
+			BatchTimeout:           10 * time.Millisecond,
+			WriteTimeout:           timeout,
+			ReadTimeout:            timeout,
+			AllowAutoTopicCreation: true,
+			ErrorLogger: kafka.LoggerFunc(func(msg string, args ...interface{}) {
+				logger.Warn("Kafka writer error", "error", fmt.Sprintf(msg, args...))
+			}),
+		},
+	}, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, msg *Message) error {
+	headers := make([]kafka.Header, 0, len(msg.Headers))
+	for key, value := range msg.Headers {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	err := p.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     []byte(msg.Key),
+		Value:   msg.Payload,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to Kafka: %w", err)
+	}
+	return nil
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
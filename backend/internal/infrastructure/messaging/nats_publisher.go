@@ -0,0 +1,80 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes to NATS subjects. With JetStream the publish waits for the stream to store
+// the message, without it the message only reaches the subscribers connected at the time.
+type NATSPublisher struct {
+	conn      *nats.Conn
+	jetStream nats.JetStreamContext
+	logger    logger.Logger
+}
+
+func NewNATSPublisher(cfg config.OutboxConfig, logger logger.Logger) (*NATSPublisher, error) {
+	conn, err := nats.Connect(cfg.NATSURL,
+		nats.Name("qris-pos-backend"),
+		nats.Timeout(time.Duration(cfg.TimeoutSeconds)*time.Second),
+		// Keep reconnecting, the relay retries whatever fails in the meantime
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Warn("Disconnected from NATS", "error", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Info("Reconnected to NATS", "url", conn.ConnectedUrl())
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	publisher := &NATSPublisher{conn: conn, logger: logger}
+	if cfg.NATSJetStream {
+		jetStream, err := conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to open JetStream: %w", err)
+		}
+		publisher.jetStream = jetStream
+	}
+	return publisher, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, msg *Message) error {
+	natsMsg := nats.NewMsg(msg.Topic)
+	natsMsg.Data = msg.Payload
+	for key, value := range msg.Headers {
+		natsMsg.Header.Set(key, value)
+	}
+
+	if p.jetStream != nil {
+		// The message ID lets the stream drop a message relayed twice within its duplicate window
+		if _, err := p.jetStream.PublishMsg(natsMsg, nats.Context(ctx), nats.MsgId(msg.ID)); err != nil {
+			return fmt.Errorf("failed to publish to JetStream: %w", err)
+		}
+		return nil
+	}
+
+	if err := p.conn.PublishMsg(natsMsg); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	// A round trip to the server, so the message left the client buffer
+	if err := p.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush to NATS: %w", err)
+	}
+	return nil
+}
+
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
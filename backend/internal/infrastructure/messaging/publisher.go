@@ -0,0 +1,46 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/pkg/logger"
+)
+
+// Message bus drivers selectable with OUTBOX_DRIVER
+const (
+	DriverNone  = "none"
+	DriverNATS  = "nats"
+	DriverKafka = "kafka"
+)
+
+// Message is an event published on the bus
+type Message struct {
+	ID      string // Unique per event, lets consumers and the bus drop a message relayed twice
+	Topic   string // NATS subject or Kafka topic
+	Key     string // Messages with the same key keep their order, such as the events of one transaction
+	Payload []byte
+	Headers map[string]string
+}
+
+// Publisher hands events to the message bus. Publish returns once the bus has taken the message, so
+// an error means it has to be published again.
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// New returns the publisher of the configured driver, nil when no bus is configured
+func New(cfg config.OutboxConfig, logger logger.Logger) (Publisher, error) {
+	switch cfg.Driver {
+	case DriverNone, "":
+		return nil, nil
+	case DriverNATS:
+		return NewNATSPublisher(cfg, logger)
+	case DriverKafka:
+		return NewKafkaPublisher(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unknown message bus driver %q, use none, nats or kafka", cfg.Driver)
+	}
+}
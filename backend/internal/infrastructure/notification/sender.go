@@ -0,0 +1,41 @@
+package notification
+
+import (
+	"context"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+// Attachment is a file sent along with a message
+type Attachment struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// Message is a notification to a single recipient
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	Attachments []Attachment
+}
+
+// Sender delivers messages over one channel
+type Sender interface {
+	Channel() entities.ReceiptChannel
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSenders returns a sender for every channel whose provider is configured
+func NewSenders(cfg config.NotificationConfig) []Sender {
+	var senders []Sender
+	if cfg.SMTPHost != "" && cfg.SMTPFrom != "" {
+		senders = append(senders, NewSMTPSender(cfg))
+	}
+	if cfg.WhatsAppPhoneNumberID != "" && cfg.WhatsAppAccessToken != "" {
+		senders = append(senders, NewWhatsAppSender(cfg))
+	}
+	return senders
+}
@@ -0,0 +1,84 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+// WhatsAppSender sends text messages through the WhatsApp Business Cloud API
+type WhatsAppSender struct {
+	baseURL       string
+	phoneNumberID string
+	accessToken   string
+	httpClient    *http.Client
+}
+
+type whatsAppTextMessage struct {
+	MessagingProduct string `json:"messaging_product"`
+	To               string `json:"to"`
+	Type             string `json:"type"`
+	Text             struct {
+		Body string `json:"body"`
+	} `json:"text"`
+}
+
+func NewWhatsAppSender(cfg config.NotificationConfig) *WhatsAppSender {
+	return &WhatsAppSender{
+		baseURL:       strings.TrimRight(cfg.WhatsAppAPIURL, "/"),
+		phoneNumberID: cfg.WhatsAppPhoneNumberID,
+		accessToken:   cfg.WhatsAppAccessToken,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (s *WhatsAppSender) Channel() entities.ReceiptChannel {
+	return entities.ReceiptChannelWhatsApp
+}
+
+// Send sends the message text; attachments are not supported on this channel.
+// The receipt is wrapped in a code block so WhatsApp keeps the column layout.
+func (s *WhatsAppSender) Send(ctx context.Context, msg *Message) error {
+	payload := whatsAppTextMessage{
+		MessagingProduct: "whatsapp",
+		To:               strings.TrimPrefix(msg.To, "+"),
+		Type:             "text",
+	}
+	payload.Text.Body = fmt.Sprintf("*%s*\n```\n%s```", msg.Subject, msg.Text)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/messages", s.baseURL, s.phoneNumberID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.accessToken))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("whatsapp send failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
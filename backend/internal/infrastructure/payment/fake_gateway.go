@@ -0,0 +1,172 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+// FakeGateway is an in-memory acquirer standing in for a real gateway, so the payment use cases can be
+// exercised without reaching Midtrans or Xendit. It issues a QRIS for every request, keeps the orders
+// it issued as pending until SetStatus settles or fails them, and records the refunds it was asked for.
+type FakeGateway struct {
+	// Err, when set, is returned by every call that would reach the gateway, e.g. a
+	// *errors.GatewayUnavailableError to simulate an outage
+	Err error
+
+	mu       sync.Mutex
+	provider entities.PaymentProvider
+	requests []QRISRequest
+	orders   map[string]*TransactionStatus
+	refunds  []RefundRequest
+}
+
+// NewFakeGateway creates a fake answering for the given provider
+func NewFakeGateway(provider entities.PaymentProvider) *FakeGateway {
+	return &FakeGateway{
+		provider: provider,
+		orders:   make(map[string]*TransactionStatus),
+	}
+}
+
+func (f *FakeGateway) Provider() entities.PaymentProvider {
+	return f.provider
+}
+
+// GenerateQRIS issues a placeholder QR for the order and remembers it as pending
+func (f *FakeGateway) GenerateQRIS(ctx context.Context, req QRISRequest) (*QRISResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.requests = append(f.requests, req)
+	f.orders[req.OrderID] = &TransactionStatus{OrderID: req.OrderID, Status: StatusPending}
+
+	return &QRISResponse{
+		Token:    "fake-" + req.OrderID,
+		OrderID:  req.OrderID,
+		QRString: fakeQRString(req.OrderID, req.GrossAmount),
+	}, nil
+}
+
+// ChargeEWallet issues a placeholder deeplink and QR for the order and remembers it as pending
+func (f *FakeGateway) ChargeEWallet(ctx context.Context, req EWalletRequest) (*EWalletResponse, error) {
+	qris, err := f.GenerateQRIS(ctx, req.QRISRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &EWalletResponse{
+		OrderID:     qris.OrderID,
+		DeeplinkURL: fmt.Sprintf("fake://%s/pay/%s", req.Wallet, req.OrderID),
+		QRString:    qris.QRString,
+	}, nil
+}
+
+// GetTransactionStatus reports the order as last set, ErrOrderNotFound for orders it never issued
+func (f *FakeGateway) GetTransactionStatus(ctx context.Context, orderID string) (*TransactionStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	status, ok := f.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+	}
+	result := *status
+	return &result, nil
+}
+
+func (f *FakeGateway) CancelTransaction(ctx context.Context, orderID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	if status, ok := f.orders[orderID]; ok {
+		status.Status = StatusCancel
+	}
+	return nil
+}
+
+// RefundTransaction acknowledges the refund right away and records it
+func (f *FakeGateway) RefundTransaction(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	f.refunds = append(f.refunds, req)
+
+	return &RefundResponse{
+		RefundID: "fake-" + req.RefundKey,
+		Status:   "success",
+		Amount:   req.Amount,
+	}, nil
+}
+
+// ParseNotification decodes a Notification sent as JSON, without any authentication
+func (f *FakeGateway) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	var notification struct {
+		OrderID     string `json:"order_id"`
+		Status      string `json:"status"`
+		ExternalID  string `json:"external_id"`
+		GrossAmount int64  `json:"gross_amount"`
+	}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+	if notification.OrderID == "" {
+		return nil, fmt.Errorf("%w: missing order_id", ErrInvalidNotification)
+	}
+
+	return &Notification{
+		OrderID:     notification.OrderID,
+		Status:      notification.Status,
+		ExternalID:  notification.ExternalID,
+		GrossAmount: notification.GrossAmount,
+		RawResponse: string(body),
+	}, nil
+}
+
+// SetStatus moves an order to a gateway status, as if the customer paid or the gateway failed it.
+// externalID is the gateway ID of the payment, reported once settled.
+func (f *FakeGateway) SetStatus(orderID, status, externalID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.orders[orderID] = &TransactionStatus{
+		OrderID:       orderID,
+		TransactionID: externalID,
+		Status:        status,
+	}
+}
+
+// Requests returns the QRIS and e-wallet requests the gateway received, oldest first
+func (f *FakeGateway) Requests() []QRISRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]QRISRequest(nil), f.requests...)
+}
+
+// Refunds returns the refunds the gateway acknowledged, oldest first
+func (f *FakeGateway) Refunds() []RefundRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]RefundRequest(nil), f.refunds...)
+}
+
+func fakeQRString(orderID string, amount int64) string {
+	return fmt.Sprintf("FAKE:%s:%d", orderID, amount)
+}
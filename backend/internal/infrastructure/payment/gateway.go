@@ -0,0 +1,287 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+// Gateway statuses, the Midtrans transaction_status vocabulary that every gateway maps its own statuses onto
+const (
+	StatusPending    = "pending"
+	StatusSettlement = "settlement"
+	StatusCapture    = "capture"
+	StatusDeny       = "deny"
+	StatusCancel     = "cancel"
+	StatusExpire     = "expire"
+)
+
+var (
+	ErrInvalidNotification = errors.New("invalid payment notification")
+	ErrOrderNotFound       = errors.New("order not found on the payment gateway")
+
+	// ErrUnauthenticatedNotification is an invalid notification that failed its signature or token check,
+	// it may not have come from the gateway at all
+	ErrUnauthenticatedNotification = fmt.Errorf("%w: authentication failed", ErrInvalidNotification)
+)
+
+// Gateway is a payment acquirer that issues dynamic QRIS and settles them
+type Gateway interface {
+	Provider() entities.PaymentProvider
+	GenerateQRIS(ctx context.Context, req QRISRequest) (*QRISResponse, error)
+	GetTransactionStatus(ctx context.Context, orderID string) (*TransactionStatus, error)
+	CancelTransaction(ctx context.Context, orderID string) error
+	RefundTransaction(ctx context.Context, req RefundRequest) (*RefundResponse, error)
+	// ParseNotification authenticates and decodes a webhook sent by the gateway
+	ParseNotification(header http.Header, body []byte) (*Notification, error)
+}
+
+// EWalletGateway is a gateway that can also charge e-wallets, handing back a deeplink that opens
+// the wallet app on the payment
+type EWalletGateway interface {
+	Gateway
+	ChargeEWallet(ctx context.Context, req EWalletRequest) (*EWalletResponse, error)
+}
+
+// SettlementReporter is a gateway that tells how it settled an order, for reconciling its settlements
+// against the payments of the POS
+type SettlementReporter interface {
+	Gateway
+	// GetSettlement returns ErrOrderNotFound when the gateway doesn't know the order
+	GetSettlement(ctx context.Context, orderID string) (*Settlement, error)
+}
+
+// QRISRequest represents the data needed to generate a QRIS code
+type QRISRequest struct {
+	TransactionID  string
+	OrderID        string
+	GrossAmount    int64
+	CustomerName   string
+	CustomerEmail  string
+	CustomerPhone  string
+	Items          []QRISItem
+	ExpiryDuration int // in minutes
+}
+
+// QRISItem represents an item in the QRIS transaction
+type QRISItem struct {
+	ID       string
+	Name     string
+	Price    int64
+	Quantity int // Gateways only take whole quantities, a fractional line is sent as 1 at its total
+}
+
+// QRISResponse represents the QRIS issued by the gateway
+type QRISResponse struct {
+	Token    string
+	OrderID  string // Gateway reference of the order, used for status checks, cancellation and refunds
+	QRString string
+	URL      string // Simulator URL for testing
+}
+
+// EWalletRequest charges an order to an e-wallet. The order details are the same as for QRIS.
+type EWalletRequest struct {
+	QRISRequest
+	Wallet      entities.EWallet
+	CallbackURL string // Where the wallet app sends the customer back after paying
+}
+
+// EWalletResponse is the e-wallet charge as issued by the gateway
+type EWalletResponse struct {
+	OrderID     string
+	DeeplinkURL string
+	QRString    string // QRIS of the charge when the wallet offers one, for paying from another phone
+	QRImageURL  string
+}
+
+// TransactionStatus is the state of an order on the gateway
+type TransactionStatus struct {
+	OrderID       string
+	TransactionID string // Gateway ID of the payment, empty until paid
+	Status        string
+	StatusMessage string
+}
+
+// RefundRequest refunds a settled order fully or partially.
+// The refund key makes retries of the same refund idempotent on the gateway.
+type RefundRequest struct {
+	OrderID    string
+	ExternalID string // Gateway ID of the settled payment
+	RefundKey  string
+	Amount     int64
+	Reason     string
+}
+
+// RefundResponse is the refund as acknowledged by the gateway, kept for reconciliation
+type RefundResponse struct {
+	RefundID string `json:"refund_id"`
+	Status   string `json:"status"`
+	Amount   int64  `json:"amount"`
+	Raw      any    `json:"raw,omitempty"`
+}
+
+// Settlement is an order as the gateway recorded it
+type Settlement struct {
+	OrderID       string
+	TransactionID string
+	Status        string // Gateway status, StatusSettlement once the money is settled
+	GrossAmount   int64
+	SettledAt     *time.Time
+}
+
+// Notification is a payment status pushed by a gateway webhook
+type Notification struct {
+	OrderID     string
+	Status      string
+	ExternalID  string
+	GrossAmount int64
+	RawResponse string
+}
+
+// Gateways holds every configured acquirer. New QRIS go through the active one while existing
+// payments keep talking to the gateway that issued them, so the acquirer can be switched anytime.
+// Stores with a Midtrans account of their own get a client of it, built on first use.
+type Gateways struct {
+	active    Gateway
+	providers map[entities.PaymentProvider]Gateway
+
+	midtransConfig config.MidtransConfig // Timeouts, retries and breaker of the store clients
+	accounts       MidtransAccounts      // nil when every store uses the shared account
+
+	mu           sync.Mutex
+	storeClients map[string]*storeClient
+}
+
+// MidtransAccount is the Midtrans account of its own a store takes payments with
+type MidtransAccount struct {
+	ServerKey   string
+	Environment string
+	UpdatedAt   time.Time // The client of the store is built again when its account changes
+}
+
+// MidtransAccounts finds the Midtrans accounts of stores
+type MidtransAccounts interface {
+	// MidtransAccount returns nil when the store takes payments with the shared account
+	MidtransAccount(ctx context.Context, storeID string) (*MidtransAccount, error)
+}
+
+type storeClient struct {
+	client    *MidtransClient
+	updatedAt time.Time
+}
+
+// NewGateways sets up the gateways that have credentials and activates the configured one
+func NewGateways(cfg *config.Config) (*Gateways, error) {
+	g := &Gateways{
+		providers:      make(map[entities.PaymentProvider]Gateway),
+		midtransConfig: cfg.Midtrans,
+		storeClients:   make(map[string]*storeClient),
+	}
+
+	// Midtrans is always registered, payments made before gateways were configurable went through it
+	g.register(NewMidtransClient(cfg.Midtrans))
+	if cfg.Xendit.SecretKey != "" {
+		g.register(NewXenditClient(cfg.Xendit))
+	}
+
+	active, err := g.Get(entities.PaymentProvider(cfg.Payment.Gateway))
+	if err != nil {
+		return nil, err
+	}
+	g.active = active
+
+	// Training transactions are simulated in-process, registered last so it can't be the active gateway
+	g.register(NewTrainingGateway())
+	return g, nil
+}
+
+// NewGatewaysFrom builds the gateways from ready-made ones instead of the configuration, e.g. a FakeGateway
+// to run the payment use cases without an acquirer. New QRIS go through active, others serve the
+// payments they issued.
+func NewGatewaysFrom(active Gateway, others ...Gateway) *Gateways {
+	g := &Gateways{active: active, providers: make(map[entities.PaymentProvider]Gateway), storeClients: make(map[string]*storeClient)}
+	g.register(active)
+	for _, gateway := range others {
+		g.register(gateway)
+	}
+	return g
+}
+
+func (g *Gateways) register(gateway Gateway) {
+	g.providers[gateway.Provider()] = gateway
+}
+
+// Active is the gateway new QRIS are issued through
+func (g *Gateways) Active() Gateway {
+	return g.active
+}
+
+// Get returns the gateway of a provider. Payments recorded without a provider are Midtrans payments.
+func (g *Gateways) Get(provider entities.PaymentProvider) (Gateway, error) {
+	if provider == "" {
+		provider = entities.ProviderMidtrans
+	}
+	gateway, ok := g.providers[provider]
+	if !ok {
+		return nil, fmt.Errorf("payment gateway %q is not configured", provider)
+	}
+	return gateway, nil
+}
+
+// UseStoreAccounts makes stores with a Midtrans account of their own take payments with it. It is set
+// up once at startup.
+func (g *Gateways) UseStoreAccounts(accounts MidtransAccounts) {
+	g.accounts = accounts
+}
+
+// ForStore returns the gateway of a provider for a store: a client of the store's own Midtrans account
+// when it has one, the shared gateway otherwise
+func (g *Gateways) ForStore(ctx context.Context, storeID string, provider entities.PaymentProvider) (Gateway, error) {
+	gateway, err := g.Get(provider)
+	if err != nil {
+		return nil, err
+	}
+	if gateway.Provider() != entities.ProviderMidtrans || g.accounts == nil || storeID == "" {
+		return gateway, nil
+	}
+
+	account, err := g.accounts.MidtransAccount(ctx, storeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the Midtrans account of store %s: %w", storeID, err)
+	}
+	if account == nil {
+		return gateway, nil
+	}
+	return g.storeClient(storeID, account), nil
+}
+
+// ActiveFor is the gateway new QRIS of a store are issued through
+func (g *Gateways) ActiveFor(ctx context.Context, storeID string) (Gateway, error) {
+	return g.ForStore(ctx, storeID, g.active.Provider())
+}
+
+// For returns the gateway that issued a payment, with the account of its store
+func (g *Gateways) For(ctx context.Context, payment *entities.Payment) (Gateway, error) {
+	return g.ForStore(ctx, payment.StoreID, payment.Provider)
+}
+
+func (g *Gateways) storeClient(storeID string, account *MidtransAccount) *MidtransClient {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if cached, ok := g.storeClients[storeID]; ok && cached.updatedAt.Equal(account.UpdatedAt) {
+		return cached.client
+	}
+
+	cfg := g.midtransConfig
+	cfg.ServerKey = account.ServerKey
+	cfg.Environment = account.Environment
+	client := NewMidtransClient(cfg)
+	g.storeClients[storeID] = &storeClient{client: client, updatedAt: account.UpdatedAt}
+	return client
+}
@@ -2,63 +2,112 @@ package payment
 
 import (
 	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/tracing"
+	appErrors "qris-pos-backend/pkg/errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/coreapi"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// MidtransClient wraps the Midtrans SDK client
+// MidtransClient wraps the Midtrans SDK client. Every call goes through a circuit breaker, so while
+// Midtrans is down requests fail fast with a gateway unavailable error instead of hanging checkout.
 type MidtransClient struct {
+	mu      sync.RWMutex
+	current *midtransAccount
+	breaker *CircuitBreaker
+	config  config.MidtransConfig
+}
+
+// midtransAccount is the Midtrans account calls are made with
+type midtransAccount struct {
+	serverKey     string
 	coreAPIClient *coreapi.Client
-	config        config.MidtransConfig
+	statusClient  *coreapi.Client // Same account with the shorter timeout of status checks
+}
+
+// Provider identifies Midtrans on the payments it issued
+func (m *MidtransClient) Provider() entities.PaymentProvider {
+	return entities.ProviderMidtrans
 }
 
 // NewMidtransClient creates a new Midtrans client instance
 func NewMidtransClient(cfg config.MidtransConfig) *MidtransClient {
-	coreAPIClient := &coreapi.Client{}
-	coreAPIClient.New(cfg.ServerKey, getEnvironment(cfg.Environment))
-
 	return &MidtransClient{
-		coreAPIClient: coreAPIClient,
-		config:        cfg,
+		current: newMidtransAccount(cfg),
+		breaker: NewCircuitBreaker(cfg.BreakerThreshold, time.Duration(cfg.BreakerCooldownSeconds)*time.Second),
+		config:  cfg,
 	}
 }
 
-// Helper function to get environment
-func getEnvironment(env string) midtrans.EnvironmentType {
-	if env == "production" {
-		return midtrans.Production
+func newMidtransAccount(cfg config.MidtransConfig) *midtransAccount {
+	return &midtransAccount{
+		serverKey:     cfg.ServerKey,
+		coreAPIClient: newCoreAPIClient(cfg, cfg.TimeoutSeconds),
+		statusClient:  newCoreAPIClient(cfg, cfg.StatusTimeoutSeconds),
 	}
-	return midtrans.Sandbox
 }
 
-// QRISRequest represents the data needed to generate a QRIS code
-type QRISRequest struct {
-	TransactionID   string
-	OrderID         string
-	GrossAmount     float64
-	CustomerName    string
-	CustomerEmail   string
-	CustomerPhone   string
-	Items           []QRISItem
-	ExpiryDuration  int // in minutes
+// UseAccount makes the following calls with the merchant's own Midtrans account, an empty server key
+// goes back to the configured account. Orders issued through the previous account can't be checked or
+// refunded through the new one, so accounts are best switched while no payment is pending.
+func (m *MidtransClient) UseAccount(serverKey, environment string) {
+	cfg := m.config
+	if serverKey != "" {
+		cfg.ServerKey = serverKey
+		cfg.Environment = environment
+	}
+	account := newMidtransAccount(cfg)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = account
 }
 
-// QRISItem represents an item in the QRIS transaction
-type QRISItem struct {
-	ID       string
-	Name     string
-	Price    float64
-	Quantity int
+func (m *MidtransClient) account() *midtransAccount {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
 }
 
-// QRISResponse represents the response from Midtrans
-type QRISResponse struct {
-	Token    string
-	QRString string
-	URL      string // Simulator URL for testing
+func newCoreAPIClient(cfg config.MidtransConfig, timeoutSeconds int) *coreapi.Client {
+	client := &coreapi.Client{}
+	client.New(cfg.ServerKey, getEnvironment(cfg.Environment))
+
+	// The SDK ignores request contexts, so a bounded HTTP timeout is what keeps a slow gateway from holding workers
+	if timeoutSeconds > 0 {
+		httpClient := midtrans.GetHttpClient(getEnvironment(cfg.Environment))
+		httpClient.HttpClient = &http.Client{Timeout: time.Duration(timeoutSeconds) * time.Second}
+		client.HttpClient = httpClient
+	}
+	return client
+}
+
+// BreakerState tells whether Midtrans is currently considered reachable
+func (m *MidtransClient) BreakerState() BreakerState {
+	return m.breaker.State()
+}
+
+// Helper function to get environment
+func getEnvironment(env string) midtrans.EnvironmentType {
+	if env == "production" {
+		return midtrans.Production
+	}
+	return midtrans.Sandbox
 }
 
 // GenerateQRIS generates a QRIS code for payment
@@ -68,13 +117,16 @@ func (m *MidtransClient) GenerateQRIS(ctx context.Context, req QRISRequest) (*QR
 		return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
 	}
 
+	_, span := tracing.StartClient(ctx, "midtrans.charge_qris", attribute.String("payment.order_id", req.OrderID))
+	defer span.End()
+
 	// Prepare items
 	var items []midtrans.ItemDetails
 	for _, item := range req.Items {
 		items = append(items, midtrans.ItemDetails{
 			ID:    item.ID,
 			Name:  item.Name,
-			Price: int64(item.Price), // Price already in correct format (IDR)
+			Price: item.Price, // Whole rupiah, as Midtrans expects for IDR
 			Qty:   int32(item.Quantity),
 		})
 	}
@@ -84,7 +136,7 @@ func (m *MidtransClient) GenerateQRIS(ctx context.Context, req QRISRequest) (*QR
 		"payment_type": "qris",
 		"transaction_details": map[string]interface{}{
 			"order_id":     req.OrderID,
-			"gross_amount": int64(req.GrossAmount), // Amount already in correct format (IDR)
+			"gross_amount": req.GrossAmount, // Whole rupiah, as Midtrans expects for IDR
 		},
 		"item_details": items,
 		"customer_details": map[string]interface{}{
@@ -95,8 +147,14 @@ func (m *MidtransClient) GenerateQRIS(ctx context.Context, req QRISRequest) (*QR
 	}
 
 	// Charge the transaction
-	res, err := m.coreAPIClient.ChargeTransactionWithMap(chargeReq)
+	var res map[string]interface{}
+	err := m.call(ctx, 0, func() *midtrans.Error {
+		var err *midtrans.Error
+		res, err = m.account().coreAPIClient.ChargeTransactionWithMap(chargeReq)
+		return err
+	})
 	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to create Midtrans transaction: %w", err)
 	}
 
@@ -108,14 +166,7 @@ func (m *MidtransClient) GenerateQRIS(ctx context.Context, req QRISRequest) (*QR
 	}
 
 	// Extract simulator URL from actions
-	var simulatorURL string
-	if actions, ok := res["actions"].([]interface{}); ok && len(actions) > 0 {
-		if action, ok := actions[0].(map[string]interface{}); ok {
-			if url, ok := action["url"].(string); ok {
-				simulatorURL = url
-			}
-		}
-	}
+	simulatorURL := actionURL(res, "generate-qr-code")
 
 	// Extract transaction ID
 	token := ""
@@ -125,25 +176,332 @@ func (m *MidtransClient) GenerateQRIS(ctx context.Context, req QRISRequest) (*QR
 
 	return &QRISResponse{
 		Token:    token,
+		OrderID:  req.OrderID,
 		QRString: qrString,
 		URL:      simulatorURL,
 	}, nil
 }
 
+// ChargeEWallet charges GoPay or ShopeePay. Both go through their own payment type rather than QRIS
+// so Midtrans returns a deeplink into the wallet app.
+func (m *MidtransClient) ChargeEWallet(ctx context.Context, req EWalletRequest) (*EWalletResponse, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+	}
+
+	_, span := tracing.StartClient(ctx, "midtrans.charge_ewallet",
+		attribute.String("payment.order_id", req.OrderID),
+		attribute.String("payment.wallet", string(req.Wallet)),
+	)
+	defer span.End()
+
+	var items []midtrans.ItemDetails
+	for _, item := range req.Items {
+		items = append(items, midtrans.ItemDetails{
+			ID:    item.ID,
+			Name:  item.Name,
+			Price: item.Price,
+			Qty:   int32(item.Quantity),
+		})
+	}
+
+	wallet := map[string]interface{}{}
+	if req.CallbackURL != "" {
+		wallet["callback_url"] = req.CallbackURL
+		if req.Wallet == entities.EWalletGoPay {
+			wallet["enable_callback"] = true
+		}
+	}
+
+	chargeReq := coreapi.ChargeReqWithMap{
+		"payment_type": string(req.Wallet),
+		"transaction_details": map[string]interface{}{
+			"order_id":     req.OrderID,
+			"gross_amount": req.GrossAmount,
+		},
+		"item_details": items,
+		"customer_details": map[string]interface{}{
+			"first_name": req.CustomerName,
+			"email":      req.CustomerEmail,
+			"phone":      req.CustomerPhone,
+		},
+		string(req.Wallet): wallet,
+	}
+	if req.ExpiryDuration > 0 {
+		chargeReq["custom_expiry"] = map[string]interface{}{
+			"expiry_duration": req.ExpiryDuration,
+			"unit":            "minute",
+		}
+	}
+
+	var res map[string]interface{}
+	err := m.call(ctx, 0, func() *midtrans.Error {
+		var err *midtrans.Error
+		res, err = m.account().coreAPIClient.ChargeTransactionWithMap(&chargeReq)
+		return err
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to create Midtrans %s transaction: %w", req.Wallet, err)
+	}
+
+	deeplinkURL := actionURL(res, "deeplink-redirect")
+	if deeplinkURL == "" {
+		err := fmt.Errorf("midtrans returned no deeplink for %s", req.Wallet)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	qrString, _ := res["qr_string"].(string)
+
+	return &EWalletResponse{
+		OrderID:     req.OrderID,
+		DeeplinkURL: deeplinkURL,
+		QRString:    qrString,
+		QRImageURL:  actionURL(res, "generate-qr-code"),
+	}, nil
+}
+
 // GetTransactionStatus gets the status of a transaction
-func (m *MidtransClient) GetTransactionStatus(ctx context.Context, orderID string) (*coreapi.TransactionStatusResponse, error) {
-	res, err := m.coreAPIClient.CheckTransaction(orderID)
+func (m *MidtransClient) GetTransactionStatus(ctx context.Context, orderID string) (*TransactionStatus, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+	}
+
+	_, span := tracing.StartClient(ctx, "midtrans.check_status", attribute.String("payment.order_id", orderID))
+	defer span.End()
+
+	res, err := m.checkTransaction(ctx, orderID)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to check transaction status: %w", err)
+	}
+	return &TransactionStatus{
+		OrderID:       res.OrderID,
+		TransactionID: res.TransactionID,
+		Status:        res.TransactionStatus,
+		StatusMessage: res.StatusMessage,
+	}, nil
+}
+
+// midtransTimeZone is the zone Midtrans writes its timestamps in, Western Indonesia Time
+var midtransTimeZone = time.FixedZone("WIB", 7*60*60)
+
+// GetSettlement reads the settlement of an order from the status API
+func (m *MidtransClient) GetSettlement(ctx context.Context, orderID string) (*Settlement, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+	}
+
+	_, span := tracing.StartClient(ctx, "midtrans.get_settlement", attribute.String("payment.order_id", orderID))
+	defer span.End()
+
+	res, err := m.checkTransaction(ctx, orderID)
 	if err != nil {
+		var midtransErr *midtrans.Error
+		if errors.As(err, &midtransErr) && midtransErr.StatusCode == http.StatusNotFound {
+			return nil, ErrOrderNotFound
+		}
+		tracing.RecordError(span, err)
 		return nil, fmt.Errorf("failed to check transaction status: %w", err)
 	}
-	return res, nil
+	if res.StatusCode == strconv.Itoa(http.StatusNotFound) {
+		return nil, ErrOrderNotFound
+	}
+
+	// Midtrans writes IDR amounts with decimals, e.g. "15000.00"
+	grossAmount, _ := entities.CurrencyIDR.ParseMajor(res.GrossAmount)
+	settlement := &Settlement{
+		OrderID:       res.OrderID,
+		TransactionID: res.TransactionID,
+		Status:        res.TransactionStatus,
+		GrossAmount:   grossAmount,
+	}
+	if settledAt, err := time.ParseInLocation("2006-01-02 15:04:05", res.SettlementTime, midtransTimeZone); err == nil {
+		settlement.SettledAt = &settledAt
+	}
+	return settlement, nil
 }
 
 // CancelTransaction cancels a transaction
 func (m *MidtransClient) CancelTransaction(ctx context.Context, orderID string) error {
-	_, err := m.coreAPIClient.CancelTransaction(orderID)
+	if ctx.Err() != nil {
+		return fmt.Errorf("context cancelled: %w", ctx.Err())
+	}
+
+	_, span := tracing.StartClient(ctx, "midtrans.cancel", attribute.String("payment.order_id", orderID))
+	defer span.End()
+
+	err := m.call(ctx, 0, func() *midtrans.Error {
+		_, err := m.account().coreAPIClient.CancelTransaction(orderID)
+		return err
+	})
 	if err != nil {
+		tracing.RecordError(span, err)
 		return fmt.Errorf("failed to cancel transaction: %w", err)
 	}
 	return nil
 }
+
+// RefundTransaction refunds a settled transaction fully or partially
+func (m *MidtransClient) RefundTransaction(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("context cancelled: %w", ctx.Err())
+	}
+
+	_, span := tracing.StartClient(ctx, "midtrans.refund",
+		attribute.String("payment.order_id", req.OrderID),
+		attribute.Int64("payment.refund_amount", req.Amount),
+	)
+	defer span.End()
+
+	var res *coreapi.RefundResponse
+	err := m.call(ctx, 0, func() *midtrans.Error {
+		var err *midtrans.Error
+		res, err = m.account().coreAPIClient.RefundTransaction(req.OrderID, &coreapi.RefundReq{
+			RefundKey: req.RefundKey,
+			Amount:    req.Amount,
+			Reason:    req.Reason,
+		})
+		return err
+	})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("failed to refund transaction: %w", err)
+	}
+	return &RefundResponse{
+		RefundID: strconv.Itoa(res.RefundChargebackID),
+		Status:   res.TransactionStatus,
+		Amount:   req.Amount,
+		Raw:      res,
+	}, nil
+}
+
+// ParseNotification decodes an HTTP notification after checking its signature. Midtrans sends IDR
+// amounts with a ".00" suffix.
+func (m *MidtransClient) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	var notification map[string]interface{}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	orderID, ok := notification["order_id"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing order_id", ErrInvalidNotification)
+	}
+	status, ok := notification["transaction_status"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing transaction_status", ErrInvalidNotification)
+	}
+
+	externalID, _ := notification["transaction_id"].(string)
+	statusCode, _ := notification["status_code"].(string)
+	grossAmountStr, _ := notification["gross_amount"].(string)
+	grossAmount, _ := entities.CurrencyIDR.ParseMajor(grossAmountStr)
+
+	signature, _ := notification["signature_key"].(string)
+	if !m.validSignature(orderID, statusCode, grossAmountStr, signature) {
+		return nil, fmt.Errorf("%w: signature_key mismatch for order %s", ErrUnauthenticatedNotification, orderID)
+	}
+
+	return &Notification{
+		OrderID:     orderID,
+		Status:      status,
+		ExternalID:  externalID,
+		GrossAmount: grossAmount,
+		RawResponse: string(body),
+	}, nil
+}
+
+// validSignature checks the signature_key of a notification, the SHA512 of the order ID, status code and
+// gross amount as sent, followed by the server key. Only Midtrans knows the server key. Payments issued
+// before switching accounts are still notified, so the configured account's key is accepted too.
+func (m *MidtransClient) validSignature(orderID, statusCode, grossAmount, signature string) bool {
+	if signature == "" {
+		return false
+	}
+	for _, serverKey := range []string{m.account().serverKey, m.config.ServerKey} {
+		if serverKey == "" {
+			continue
+		}
+		sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+		expected := hex.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(strings.ToLower(signature)), []byte(expected)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransaction reads the status of an order. Reading is idempotent, so it is retried when Midtrans
+// could not be reached.
+func (m *MidtransClient) checkTransaction(ctx context.Context, orderID string) (*coreapi.TransactionStatusResponse, error) {
+	var res *coreapi.TransactionStatusResponse
+	err := m.call(ctx, m.config.MaxRetries, func() *midtrans.Error {
+		var err *midtrans.Error
+		res, err = m.account().statusClient.CheckTransaction(orderID)
+		return err
+	})
+	return res, err
+}
+
+// call runs a request through the circuit breaker, retrying it up to retries times with jittered
+// backoff while Midtrans can't be reached. Answers, errors included, count as Midtrans being up; only
+// failures to get one open the breaker and are reported as the gateway being unavailable.
+func (m *MidtransClient) call(ctx context.Context, retries int, request func() *midtrans.Error) error {
+	if err := m.breaker.Allow(); err != nil {
+		return &appErrors.GatewayUnavailableError{Provider: string(entities.ProviderMidtrans), Err: err}
+	}
+
+	var err *midtrans.Error
+retry:
+	for attempt := 0; ; attempt++ {
+		if err = request(); err == nil || !unreachable(err) {
+			m.breaker.Success()
+			if err != nil {
+				return err
+			}
+			return nil
+		}
+		if attempt >= retries {
+			break
+		}
+
+		// Equal jitter: half the backoff is fixed, the other half random, so clients don't retry in lockstep
+		backoff := time.Duration(m.config.RetryBackoffMillis) * time.Millisecond << attempt
+		delay := backoff/2 + rand.N(backoff/2+1)
+		select {
+		case <-ctx.Done():
+			break retry
+		case <-time.After(delay):
+		}
+	}
+
+	m.breaker.Failure()
+	return &appErrors.GatewayUnavailableError{Provider: string(entities.ProviderMidtrans), Err: err}
+}
+
+// unreachable reports a request that got no answer from Midtrans: a network error, a timeout, throttling
+// or a server error
+func unreachable(err *midtrans.Error) bool {
+	switch {
+	case err.StatusCode == 0:
+		return err.RawError != nil // Without a cause the SDK refused the request itself, e.g. a missing server key
+	case err.StatusCode == http.StatusRequestTimeout, err.StatusCode == http.StatusTooManyRequests:
+		return true
+	}
+	return err.StatusCode >= http.StatusInternalServerError
+}
+
+// actionURL is the URL of a named action in a charge response
+func actionURL(res map[string]interface{}, name string) string {
+	actions, _ := res["actions"].([]interface{})
+	for _, a := range actions {
+		action, ok := a.(map[string]interface{})
+		if !ok || action["name"] != name {
+			continue
+		}
+		url, _ := action["url"].(string)
+		return url
+	}
+	return ""
+}
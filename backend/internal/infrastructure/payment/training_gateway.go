@@ -0,0 +1,71 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+// TrainingGateway simulates an acquirer for the training transactions of stores in training mode.
+// Nothing leaves the server: the QRIS it issues can't be paid and stays pending until the cashier
+// confirms it, like a local QRIS.
+type TrainingGateway struct{}
+
+// NewTrainingGateway creates the simulated gateway
+func NewTrainingGateway() *TrainingGateway {
+	return &TrainingGateway{}
+}
+
+// Provider identifies the simulator on training payments
+func (t *TrainingGateway) Provider() entities.PaymentProvider {
+	return entities.ProviderTraining
+}
+
+// GenerateQRIS returns a placeholder QR, scanning it with a banking app fails rather than paying anyone
+func (t *TrainingGateway) GenerateQRIS(ctx context.Context, req QRISRequest) (*QRISResponse, error) {
+	return &QRISResponse{
+		OrderID:  req.OrderID,
+		QRString: trainingQRString(req.OrderID, req.GrossAmount),
+	}, nil
+}
+
+// ChargeEWallet returns a placeholder QR without a deeplink, there is no wallet app to open
+func (t *TrainingGateway) ChargeEWallet(ctx context.Context, req EWalletRequest) (*EWalletResponse, error) {
+	return &EWalletResponse{
+		OrderID:  req.OrderID,
+		QRString: trainingQRString(req.OrderID, req.GrossAmount),
+	}, nil
+}
+
+// GetTransactionStatus always reports pending, training payments are only settled by the cashier
+func (t *TrainingGateway) GetTransactionStatus(ctx context.Context, orderID string) (*TransactionStatus, error) {
+	return &TransactionStatus{
+		OrderID:       orderID,
+		Status:        StatusPending,
+		StatusMessage: "Training payment, confirm it at the register",
+	}, nil
+}
+
+func (t *TrainingGateway) CancelTransaction(ctx context.Context, orderID string) error {
+	return nil
+}
+
+// RefundTransaction acknowledges the refund right away, no money was collected
+func (t *TrainingGateway) RefundTransaction(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	return &RefundResponse{
+		RefundID: "training-" + req.RefundKey,
+		Status:   "success",
+		Amount:   req.Amount,
+	}, nil
+}
+
+// ParseNotification rejects every webhook, nobody outside the server can settle a training payment
+func (t *TrainingGateway) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	return nil, fmt.Errorf("%w: training payments have no webhook", ErrInvalidNotification)
+}
+
+func trainingQRString(orderID string, amount int64) string {
+	return fmt.Sprintf("TRAINING:%s:%d", orderID, amount)
+}
@@ -0,0 +1,230 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/tracing"
+)
+
+const (
+	xenditBaseURL    = "https://api.xendit.co"
+	xenditAPIVersion = "2022-07-31"
+)
+
+// XenditClient issues QRIS through the Xendit QR Codes API. Xendit identifies a QR by its own ID,
+// which is returned as the order ID to store on the payment.
+type XenditClient struct {
+	httpClient *http.Client
+	config     config.XenditConfig
+}
+
+// NewXenditClient creates a new Xendit client instance
+func NewXenditClient(cfg config.XenditConfig) *XenditClient {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &XenditClient{
+		httpClient: &http.Client{Timeout: timeout, Transport: tracing.Transport(nil)},
+		config:     cfg,
+	}
+}
+
+// Provider identifies Xendit on the payments it issued
+func (x *XenditClient) Provider() entities.PaymentProvider {
+	return entities.ProviderXendit
+}
+
+type xenditQRCode struct {
+	ID          string `json:"id"`
+	ReferenceID string `json:"reference_id"`
+	QRString    string `json:"qr_string"`
+	Status      string `json:"status"`
+}
+
+type xenditQRPayment struct {
+	ID          string  `json:"id"`
+	QRID        string  `json:"qr_id"`
+	ReferenceID string  `json:"reference_id"`
+	Amount      float64 `json:"amount"`
+	Status      string  `json:"status"`
+}
+
+// GenerateQRIS creates a single use dynamic QR code for the amount
+func (x *XenditClient) GenerateQRIS(ctx context.Context, req QRISRequest) (*QRISResponse, error) {
+	body := map[string]interface{}{
+		"reference_id": req.OrderID,
+		"type":         "DYNAMIC",
+		"currency":     "IDR",
+		"amount":       req.GrossAmount,
+	}
+	if req.ExpiryDuration > 0 {
+		body["expires_at"] = time.Now().Add(time.Duration(req.ExpiryDuration) * time.Minute).UTC().Format(time.RFC3339)
+	}
+
+	var qrCode xenditQRCode
+	if err := x.do(ctx, http.MethodPost, "/qr_codes", req.OrderID, body, &qrCode); err != nil {
+		return nil, fmt.Errorf("failed to create Xendit QR code: %w", err)
+	}
+
+	return &QRISResponse{
+		Token:    qrCode.ID,
+		OrderID:  qrCode.ID,
+		QRString: qrCode.QRString,
+	}, nil
+}
+
+// GetTransactionStatus reports a QR code as settled once a payment to it succeeded
+func (x *XenditClient) GetTransactionStatus(ctx context.Context, orderID string) (*TransactionStatus, error) {
+	var payments struct {
+		Data []xenditQRPayment `json:"data"`
+	}
+	if err := x.do(ctx, http.MethodGet, "/qr_codes/"+url.PathEscape(orderID)+"/payments", "", nil, &payments); err != nil {
+		return nil, fmt.Errorf("failed to check transaction status: %w", err)
+	}
+
+	for _, p := range payments.Data {
+		if p.Status == "SUCCEEDED" {
+			return &TransactionStatus{
+				OrderID:       orderID,
+				TransactionID: p.ID,
+				Status:        StatusSettlement,
+				StatusMessage: "QR code payment succeeded",
+			}, nil
+		}
+	}
+
+	return &TransactionStatus{
+		OrderID:       orderID,
+		Status:        StatusPending,
+		StatusMessage: "Waiting for the QR code to be paid",
+	}, nil
+}
+
+// CancelTransaction is a no-op: Xendit QR codes can't be voided and stop accepting payments when they expire.
+// A payment made before then is caught by the late payment exceptions queue.
+func (x *XenditClient) CancelTransaction(ctx context.Context, orderID string) error {
+	return nil
+}
+
+// RefundTransaction refunds a QR code payment fully or partially
+func (x *XenditClient) RefundTransaction(ctx context.Context, req RefundRequest) (*RefundResponse, error) {
+	if req.ExternalID == "" {
+		return nil, fmt.Errorf("payment has no Xendit payment ID to refund")
+	}
+
+	var refund struct {
+		ID     string  `json:"id"`
+		Status string  `json:"status"`
+		Amount float64 `json:"amount"`
+	}
+	body := map[string]interface{}{
+		"amount": req.Amount,
+		"reason": req.Reason,
+	}
+	path := "/qr_codes/payments/" + url.PathEscape(req.ExternalID) + "/refunds"
+	if err := x.do(ctx, http.MethodPost, path, req.RefundKey, body, &refund); err != nil {
+		return nil, fmt.Errorf("failed to refund transaction: %w", err)
+	}
+
+	return &RefundResponse{
+		RefundID: refund.ID,
+		Status:   refund.Status,
+		Amount:   req.Amount,
+		Raw:      refund,
+	}, nil
+}
+
+// ParseNotification decodes a qr.payment webhook after checking its callback token
+func (x *XenditClient) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	token := header.Get("X-Callback-Token")
+	if x.config.CallbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(x.config.CallbackToken)) != 1 {
+		return nil, fmt.Errorf("%w: callback token mismatch", ErrUnauthenticatedNotification)
+	}
+
+	var notification struct {
+		Event string          `json:"event"`
+		Data  xenditQRPayment `json:"data"`
+	}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+	if notification.Data.QRID == "" {
+		return nil, fmt.Errorf("%w: missing qr_id", ErrInvalidNotification)
+	}
+
+	status := StatusPending
+	switch notification.Data.Status {
+	case "SUCCEEDED":
+		status = StatusSettlement
+	case "FAILED":
+		status = StatusDeny
+	}
+
+	return &Notification{
+		OrderID:     notification.Data.QRID,
+		Status:      status,
+		ExternalID:  notification.Data.ID,
+		GrossAmount: entities.CurrencyIDR.FromMajor(notification.Data.Amount),
+		RawResponse: string(body),
+	}, nil
+}
+
+// do sends an authenticated request. The idempotency key lets Xendit dedupe retried writes.
+func (x *XenditClient) do(ctx context.Context, method, path, idempotencyKey string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, xenditBaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(x.config.SecretKey, "")
+	req.Header.Set("api-version", xenditAPIVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	res, err := x.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		var apiErr struct {
+			ErrorCode string `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &apiErr)
+		return fmt.Errorf("xendit returned %d %s: %s", res.StatusCode, apiErr.ErrorCode, apiErr.Message)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
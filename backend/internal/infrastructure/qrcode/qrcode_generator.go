@@ -1,8 +1,10 @@
 package qrcode
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	qrcode "github.com/skip2/go-qrcode"
 )
@@ -63,3 +65,32 @@ func (q *QRCodeGenerator) GenerateQRCodeDataURI(content string, size int) (strin
 
 	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrData), nil
 }
+
+// GenerateQRCodeSVG generates a QR code as an SVG image of the given size, one path of dark modules
+func (q *QRCodeGenerator) GenerateQRCodeSVG(content string, size int) ([]byte, error) {
+	// Validate size
+	if size < MinQRCodeSize || size > MaxQRCodeSize {
+		return nil, fmt.Errorf("invalid QR code size: must be between %d and %d", MinQRCodeSize, MaxQRCodeSize)
+	}
+
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create QR code: %w", err)
+	}
+
+	// The bitmap includes the quiet zone, the viewBox scales the modules to the requested size
+	bitmap := qr.Bitmap()
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	var svg bytes.Buffer
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, len(bitmap), len(bitmap))
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="#ffffff"/><path fill="#000000" d="%s"/></svg>`, path.String())
+	return svg.Bytes(), nil
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit is a token bucket: PerMinute tokens are refilled each minute, up to Burst saved for a spike
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.PerMinute > 0
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst < 1 {
+		return 1
+	}
+	return float64(l.Burst)
+}
+
+// ratePerSecond is the refill speed of the bucket
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // Until the next token, set when the request was refused
+}
+
+// Store keeps the buckets. The memory store is per process, Redis shares the buckets between replicas.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// NewStore returns the Redis store when a Redis URL is configured, the in-memory store otherwise
+func NewStore(cfg config.RateLimitConfig) (Store, error) {
+	if cfg.RedisURL == "" {
+		return NewMemoryStore(), nil
+	}
+
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limit redis url: %w", err)
+	}
+	return NewRedisStore(redis.NewClient(opts)), nil
+}
+
+// retryAfter is the wait until the bucket holds a whole token again
+func retryAfter(tokens float64, limit Limit) time.Duration {
+	missing := 1 - tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / limit.ratePerSecond() * float64(time.Second))
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix namespaces the buckets in a Redis shared with other services
+const keyPrefix = "ratelimit:"
+
+// takeToken refills and takes from the bucket atomically. It returns whether a token was taken
+// and the tokens left, as a string so Redis doesn't truncate the fraction.
+var takeToken = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "updated")
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore keeps the buckets in Redis so every replica enforces the same limits
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a new Redis bucket store
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	reply, err := takeToken.Run(ctx, s.client, []string{keyPrefix + key},
+		limit.ratePerSecond(), limit.burst(), time.Now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply: %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokensText, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected rate limit tokens %q: %w", tokensText, err)
+	}
+
+	if allowed != 1 {
+		return Result{RetryAfter: retryAfter(tokens, limit)}, nil
+	}
+	return Result{Allowed: true, Remaining: int(math.Floor(tokens))}, nil
+}
@@ -0,0 +1,113 @@
+package receipt
+
+import (
+	"bytes"
+	"time"
+)
+
+// ESC/POS commands understood by common thermal receipt printers
+var (
+	escInit          = []byte{0x1B, 0x40}       // ESC @
+	escBoldOn        = []byte{0x1B, 0x45, 0x01} // ESC E 1
+	escBoldOff       = []byte{0x1B, 0x45, 0x00} // ESC E 0
+	escAlignLeft     = []byte{0x1B, 0x61, 0x00} // ESC a 0
+	escAlignCenter   = []byte{0x1B, 0x61, 0x01} // ESC a 1
+	escFeedLines     = []byte{0x1B, 0x64, 0x04} // ESC d 4
+	gsPartialCut     = []byte{0x1D, 0x56, 0x42, 0x00}
+	escCodePagePC437 = []byte{0x1B, 0x74, 0x00} // ESC t 0
+)
+
+// RenderESCPOS renders the receipt as an ESC/POS byte stream for a printer of the given character width
+func RenderESCPOS(r *Receipt, width int) []byte {
+	var buf bytes.Buffer
+
+	buf.Write(escInit)
+	buf.Write(escCodePagePC437)
+	buf.Write(escAlignLeft)
+
+	for _, line := range r.Lines(width) {
+		if line.Emphasis {
+			buf.Write(escBoldOn)
+		}
+		buf.WriteString(asciiOnly(line.Text))
+		buf.WriteByte('\n')
+		if line.Emphasis {
+			buf.Write(escBoldOff)
+		}
+	}
+
+	buf.Write(escFeedLines)
+	buf.Write(gsPartialCut)
+	return buf.Bytes()
+}
+
+// QRISSlip is a QRIS printed for the customer to scan from paper
+type QRISSlip struct {
+	Store         Store
+	TransactionID string
+	Amount        int64
+	ExpiresAt     time.Time
+	QRString      string // EMVCo payload
+}
+
+// RenderQRISESCPOS renders a QRIS slip as an ESC/POS byte stream, the QR is drawn by the printer itself
+func RenderQRISESCPOS(slip QRISSlip, width int) []byte {
+	var buf bytes.Buffer
+
+	buf.Write(escInit)
+	buf.Write(escCodePagePC437)
+	buf.Write(escAlignCenter)
+
+	buf.Write(escBoldOn)
+	buf.WriteString(asciiOnly(truncate(slip.Store.Name, width)) + "\n")
+	buf.Write(escBoldOff)
+	buf.WriteString("Scan to pay with QRIS\n\n")
+
+	writeQRCode(&buf, slip.QRString, width)
+
+	buf.WriteString("\n")
+	buf.Write(escBoldOn)
+	buf.WriteString(asciiOnly(FormatRupiah(slip.Amount)) + "\n")
+	buf.Write(escBoldOff)
+	buf.Write(escAlignLeft)
+	for _, line := range keyValue("No", slip.TransactionID, width) {
+		buf.WriteString(asciiOnly(line.Text) + "\n")
+	}
+	for _, line := range keyValue("Valid until", slip.ExpiresAt.Format("02/01/2006 15:04"), width) {
+		buf.WriteString(asciiOnly(line.Text) + "\n")
+	}
+
+	buf.Write(escFeedLines)
+	buf.Write(gsPartialCut)
+	return buf.Bytes()
+}
+
+// writeQRCode prints data as a QR code with the GS ( k commands, sized to fill most of the paper:
+// 6 dot modules on 58mm paper and 8 on 80mm
+func writeQRCode(buf *bytes.Buffer, data string, width int) {
+	moduleSize := byte(6)
+	if width >= WidthThermal80 {
+		moduleSize = 8
+	}
+	store := len(data) + 3
+
+	buf.Write([]byte{0x1D, 0x28, 0x6B, 0x04, 0x00, 0x31, 0x41, 0x32, 0x00})              // Model 2
+	buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x43, moduleSize})              // Module size
+	buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x45, 0x31})                    // Error correction M
+	buf.Write([]byte{0x1D, 0x28, 0x6B, byte(store), byte(store >> 8), 0x31, 0x50, 0x30}) // Store the data
+	buf.WriteString(data)
+	buf.Write([]byte{0x1D, 0x28, 0x6B, 0x03, 0x00, 0x31, 0x51, 0x30}) // Print the stored code
+}
+
+// asciiOnly replaces characters the printer code page can't print
+func asciiOnly(text string) string {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			out = append(out, '?')
+			continue
+		}
+		out = append(out, byte(r))
+	}
+	return string(out)
+}
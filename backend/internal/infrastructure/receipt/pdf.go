@@ -0,0 +1,72 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// pdfColumns fits 80mm paper with Courier at pdfFontSize
+	pdfColumns   = 42
+	pdfFontSize  = 8.0
+	pdfLeading   = 10.0
+	pdfMargin    = 12.0
+	pdfPageWidth = 226.77 // 80mm in points
+	pdfCharWidth = 0.6    // Courier glyph width relative to the font size
+	pdfMinHeight = 200.0
+)
+
+// RenderPDF renders the receipt as a single page PDF sized like a thermal paper roll
+func RenderPDF(r *Receipt) []byte {
+	lines := r.Lines(pdfColumns)
+	height := pdfMargin*2 + float64(len(lines))*pdfLeading
+	if height < pdfMinHeight {
+		height = pdfMinHeight
+	}
+
+	// Page content: one text object, Courier for the fixed-width layout
+	var content strings.Builder
+	fmt.Fprintf(&content, "BT\n%.2f TL\n%.2f %.2f Td\n", pdfLeading, pdfMargin, height-pdfMargin-pdfFontSize)
+	for _, line := range lines {
+		font := "F1"
+		if line.Emphasis {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "/%s %.1f Tf\n(%s) Tj\nT*\n", font, pdfFontSize, escapePDFText(asciiOnly(line.Text)))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents 4 0 R "+
+			"/Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>", pdfPageWidth, height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+
+	return buf.Bytes()
+}
+
+func escapePDFText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+	return replacer.Replace(text)
+}
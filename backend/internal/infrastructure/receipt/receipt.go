@@ -0,0 +1,395 @@
+package receipt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+const (
+	// WidthThermal58 is the character width of a 58mm thermal printer
+	WidthThermal58 = 32
+	// WidthThermal80 is the character width of an 80mm thermal printer
+	WidthThermal80 = 48
+)
+
+// Store is the merchant information printed in the receipt header
+type Store struct {
+	Name    string
+	Address string
+	Phone   string
+	TaxID   string
+	Footer  string
+}
+
+func NewStore(cfg config.StoreConfig) Store {
+	return Store{
+		Name:    cfg.Name,
+		Address: cfg.Address,
+		Phone:   cfg.Phone,
+		TaxID:   cfg.TaxID,
+		Footer:  cfg.Footer,
+	}
+}
+
+// ForOutlet prints the name, contacts and tax ID of the store a transaction was made in, the configured
+// footer is shared by every store
+func (s Store) ForOutlet(outlet *entities.Store) Store {
+	if outlet == nil {
+		return s
+	}
+	s.Name = outlet.Name
+	if outlet.Address != "" {
+		s.Address = outlet.Address
+	}
+	if outlet.Phone != "" {
+		s.Phone = outlet.Phone
+	}
+	if outlet.TaxID != "" {
+		s.TaxID = outlet.TaxID
+	}
+	return s
+}
+
+// Item is a purchased product line
+type Item struct {
+	Name         string
+	Quantity     float64
+	Unit         entities.UnitOfMeasure
+	UnitPrice    int64
+	Total        int64
+	TierQuantity int   // Quantity break the unit price comes from, 0 for none
+	Discount     int64 // Manual discount on the line, also counted in the receipt discount
+}
+
+// Tax is a tax charged on the transaction, inclusive taxes are already in the item prices
+type Tax struct {
+	Name      string
+	Rate      float64
+	Inclusive bool
+	Amount    int64
+}
+
+// Label names the tax with its rate, e.g. "PPN 11%"
+func (t Tax) Label() string {
+	return fmt.Sprintf("%s %s%%", t.Name, strconv.FormatFloat(t.Rate, 'f', -1, 64))
+}
+
+// Receipt holds everything printed on a customer receipt
+type Receipt struct {
+	Store            Store
+	TransactionID    string
+	ReceiptNo        string // Sequential receipt number, printed instead of the ID when the sale has one
+	Currency         entities.Currency
+	Date             time.Time
+	Cashier          string
+	Status           string
+	Items            []Item
+	Subtotal         int64
+	Discount         int64
+	ServiceRate      float64
+	ServiceCharge    int64
+	Tax              int64
+	Taxes            []Tax
+	ExchangeCredit   int64
+	PointsRedeemed   int64
+	PointsDiscount   int64
+	Rounding         int64 // Negative when the total was rounded down
+	Total            int64
+	RefundedAmount   int64
+	PaymentMethod    string
+	PaymentReference string // QRIS reference number or card approval code
+	AmountTendered   int64
+	Change           int64
+}
+
+// New builds the receipt of a transaction. Payment details are only printed once the payment succeeded.
+func New(store Store, transaction *entities.Transaction, payment *entities.Payment) *Receipt {
+	r := &Receipt{
+		Store:          store,
+		TransactionID:  transaction.ID,
+		ReceiptNo:      transaction.ReceiptNo,
+		Currency:       transaction.Currency,
+		Date:           transaction.CreatedAt,
+		Cashier:        transaction.User.Name,
+		Status:         string(transaction.Status),
+		Discount:       transaction.Discount,
+		ServiceRate:    transaction.ServiceChargeRate,
+		ServiceCharge:  transaction.ServiceCharge,
+		Tax:            transaction.TaxAmount,
+		ExchangeCredit: transaction.ExchangeCredit,
+		PointsRedeemed: transaction.PointsRedeemed,
+		PointsDiscount: transaction.PointsDiscount,
+		Rounding:       transaction.Rounding,
+		Total:          transaction.TotalAmount,
+		RefundedAmount: transaction.RefundedAmount,
+	}
+
+	for _, tax := range transaction.Taxes {
+		r.Taxes = append(r.Taxes, Tax{
+			Name:      tax.Name,
+			Rate:      tax.Rate,
+			Inclusive: tax.Inclusive,
+			Amount:    tax.Amount,
+		})
+	}
+
+	for _, item := range transaction.Items {
+		r.Items = append(r.Items, Item{
+			Name:         item.Product.Name,
+			Quantity:     item.Quantity,
+			Unit:         item.Unit,
+			UnitPrice:    item.UnitPrice,
+			Total:        item.TotalPrice,
+			TierQuantity: item.TierMinQuantity,
+			Discount:     item.ManualDiscount.Amount,
+		})
+		r.Subtotal += item.TotalPrice
+	}
+
+	if payment != nil && payment.Status == entities.PaymentSuccess {
+		r.PaymentMethod = string(payment.Method)
+		if payment.PaidAt != nil {
+			r.Date = *payment.PaidAt
+		}
+		switch payment.Method {
+		case entities.PaymentMethodQRIS:
+			r.PaymentReference = payment.ExternalID
+		case entities.PaymentMethodEWallet:
+			r.PaymentMethod = string(payment.Wallet)
+			r.PaymentReference = payment.ExternalID
+		case entities.PaymentMethodCash:
+			r.AmountTendered = payment.AmountTendered
+			r.Change = payment.ChangeAmount
+		default:
+			r.PaymentReference = payment.Reference
+		}
+	}
+
+	return r
+}
+
+// Text renders the receipt as plain text, for messaging channels
+func (r *Receipt) Text(width int) string {
+	var b strings.Builder
+	for _, line := range r.Lines(width) {
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Line is a single formatted receipt line
+type Line struct {
+	Text     string
+	Emphasis bool
+}
+
+// Lines lays the receipt out as fixed-width text lines
+func (r *Receipt) Lines(width int) []Line {
+	var lines []Line
+	add := func(text string) { lines = append(lines, Line{Text: text}) }
+	separator := strings.Repeat("-", width)
+
+	lines = append(lines, Line{Text: center(r.Store.Name, width), Emphasis: true})
+	for _, text := range []string{r.Store.Address, r.Store.Phone} {
+		for _, wrapped := range wrap(text, width) {
+			add(center(wrapped, width))
+		}
+	}
+	if r.Store.TaxID != "" {
+		add(center("NPWP "+r.Store.TaxID, width))
+	}
+	add(separator)
+
+	number := r.ReceiptNo
+	if number == "" {
+		number = r.TransactionID
+	}
+	lines = append(lines, keyValue("No", number, width)...)
+	lines = append(lines, keyValue("Date", r.Date.Format("02/01/2006 15:04"), width)...)
+	if r.Cashier != "" {
+		lines = append(lines, keyValue("Cashier", r.Cashier, width)...)
+	}
+	add(separator)
+
+	for _, item := range r.Items {
+		for _, wrapped := range wrap(item.Name, width) {
+			add(wrapped)
+		}
+		qty := fmt.Sprintf("  %s x %s", entities.FormatQuantity(item.Quantity, item.Unit), r.money(item.UnitPrice))
+		if item.TierQuantity > 0 {
+			qty += fmt.Sprintf(" (%d+)", item.TierQuantity)
+		}
+		add(justify(qty, r.money(item.Total), width))
+		if item.Discount > 0 {
+			add(justify("  Discount", "-"+r.money(item.Discount), width))
+		}
+	}
+	add(separator)
+
+	add(justify("Subtotal", r.money(r.Subtotal), width))
+	if r.Discount > 0 {
+		add(justify("Discount", "-"+r.money(r.Discount), width))
+	}
+	if r.ServiceCharge > 0 {
+		add(justify(fmt.Sprintf("Service %s%%", strconv.FormatFloat(r.ServiceRate, 'f', -1, 64)), r.money(r.ServiceCharge), width))
+	}
+	if len(r.Taxes) == 0 && r.Tax > 0 {
+		// Tax entered as an amount before tax rules recorded their breakdown
+		add(justify("Tax", r.money(r.Tax), width))
+	}
+	for _, tax := range r.Taxes {
+		if !tax.Inclusive {
+			add(justify(tax.Label(), r.money(tax.Amount), width))
+		}
+	}
+	if r.ExchangeCredit > 0 {
+		add(justify("Exchange credit", "-"+r.money(r.ExchangeCredit), width))
+	}
+	if r.PointsDiscount > 0 {
+		add(justify(fmt.Sprintf("Points (%d)", r.PointsRedeemed), "-"+r.money(r.PointsDiscount), width))
+	}
+	if r.Rounding > 0 {
+		add(justify("Rounding", r.money(r.Rounding), width))
+	} else if r.Rounding < 0 {
+		add(justify("Rounding", "-"+r.money(-r.Rounding), width))
+	}
+	lines = append(lines, Line{Text: justify("TOTAL", r.money(r.Total), width), Emphasis: true})
+	for _, tax := range r.Taxes {
+		if tax.Inclusive {
+			add(justify("Incl. "+tax.Label(), r.money(tax.Amount), width))
+		}
+	}
+
+	if r.PaymentMethod != "" {
+		add(separator)
+		add(justify("Payment", strings.ToUpper(r.PaymentMethod), width))
+		if r.AmountTendered > 0 {
+			add(justify("Cash", r.money(r.AmountTendered), width))
+			add(justify("Change", r.money(r.Change), width))
+		}
+		if r.PaymentReference != "" {
+			lines = append(lines, keyValue("Ref", r.PaymentReference, width)...)
+		}
+	}
+	if r.RefundedAmount > 0 {
+		add(justify("Refunded", "-"+r.money(r.RefundedAmount), width))
+	}
+
+	if r.Store.Footer != "" {
+		add(separator)
+		for _, wrapped := range wrap(r.Store.Footer, width) {
+			add(center(wrapped, width))
+		}
+	}
+
+	return lines
+}
+
+func (r *Receipt) money(amount int64) string {
+	return FormatAmount(amount, r.Currency)
+}
+
+// FormatAmount formats an amount in its currency, rupiah the Indonesian way and the others with their
+// code and decimals, e.g. "SGD 12.50"
+func FormatAmount(amount int64, currency entities.Currency) string {
+	if currency == "" || currency == entities.CurrencyIDR {
+		return FormatRupiah(amount)
+	}
+	if amount < 0 {
+		return "-" + string(currency) + " " + currency.FormatMajor(-amount)
+	}
+	return string(currency) + " " + currency.FormatMajor(amount)
+}
+
+// FormatRupiah formats an amount the Indonesian way, e.g. "Rp15.000"
+func FormatRupiah(amount int64) string {
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%d", amount)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(d)
+	}
+
+	result := "Rp" + grouped.String()
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
+
+func center(text string, width int) string {
+	if len(text) >= width {
+		return truncate(text, width)
+	}
+	return strings.Repeat(" ", (width-len(text))/2) + text
+}
+
+// justify puts left at the start and right at the end of the line
+func justify(left, right string, width int) string {
+	gap := width - len(left) - len(right)
+	if gap < 1 {
+		left = truncate(left, width-len(right)-1)
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// keyValue prints "Label: value", moving long values to their own line
+func keyValue(label, value string, width int) []Line {
+	text := label + ": " + value
+	if len(text) <= width {
+		return []Line{{Text: text}}
+	}
+	return []Line{{Text: label + ":"}, {Text: truncate(value, width)}}
+}
+
+func wrap(text string, width int) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		for len(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func truncate(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if len(text) <= width {
+		return text
+	}
+	return text[:width]
+}
@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"qris-pos-backend/pkg/logger"
+)
+
+// JobFunc is a unit of background work executed on every tick
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+// JobStatus is the run history of a job since the process started
+type JobStatus struct {
+	Name                string        `json:"name"`
+	Interval            time.Duration `json:"-"`
+	Runs                int           `json:"runs"`
+	Failures            int           `json:"failures"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	LastRunAt           *time.Time    `json:"last_run_at,omitempty"`
+	LastSuccessAt       *time.Time    `json:"last_success_at,omitempty"`
+	LastError           string        `json:"last_error,omitempty"`
+}
+
+// Scheduler runs registered jobs periodically until stopped
+type Scheduler struct {
+	jobs     []job
+	statuses map[string]*JobStatus
+	logger   logger.Logger
+	stop     chan struct{}
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// NewScheduler creates a new job scheduler instance
+func NewScheduler(logger logger.Logger) *Scheduler {
+	return &Scheduler{
+		statuses: make(map[string]*JobStatus),
+		logger:   logger,
+	}
+}
+
+// Register adds a job that runs every interval once the scheduler is started
+func (s *Scheduler) Register(name string, interval time.Duration, run JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if interval <= 0 {
+		s.logger.Warn("Skipping job with non-positive interval", "job", name)
+		return
+	}
+
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: run})
+	s.statuses[name] = &JobStatus{Name: name, Interval: interval}
+}
+
+// Status returns the run history of every registered job, in registration order
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, *s.statuses[j.name])
+	}
+	return statuses
+}
+
+// JobStatus returns the run history of a job, false when no job is registered under the name
+func (s *Scheduler) JobStatus(name string) (JobStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, ok := s.statuses[name]
+	if !ok {
+		return JobStatus{}, false
+	}
+	return *status, true
+}
+
+// Start launches every registered job in its own goroutine
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.stop = make(chan struct{})
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, s.stop, j)
+	}
+
+	s.logger.Info("Background scheduler started", "jobs", len(s.jobs))
+}
+
+// Stop stops scheduling new runs and waits for the in-flight ones to finish, so an expiry batch
+// isn't cut off halfway. Runs still going when ctx is done are cancelled and ctx's error is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	stop, cancel := s.stop, s.cancel
+	s.stop = nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+
+	drained := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		s.logger.Warn("Background jobs did not finish in time, cancelling them")
+		err = ctx.Err()
+	}
+	cancel()
+	<-drained
+
+	s.logger.Info("Background scheduler stopped")
+	return err
+}
+
+func (s *Scheduler) loop(ctx context.Context, stop <-chan struct{}, j job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, j)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j job) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("Background job panicked", "job", j.name, "panic", fmt.Sprint(r))
+			s.record(j.name, start, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	err := j.run(ctx)
+	if err != nil && ctx.Err() != nil {
+		// Interrupted by shutdown, not a failure of the job
+		return
+	}
+	s.record(j.name, start, err)
+	if err != nil {
+		s.logger.Error("Background job failed", "job", j.name, "error", err)
+		return
+	}
+	s.logger.Debug("Background job completed", "job", j.name, "duration", time.Since(start).String())
+}
+
+func (s *Scheduler) record(name string, start time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.statuses[name]
+	status.Runs++
+	status.LastRunAt = &start
+	if err != nil {
+		status.Failures++
+		status.ConsecutiveFailures++
+		status.LastError = err.Error()
+		return
+	}
+	status.ConsecutiveFailures = 0
+	status.LastSuccessAt = &start
+}
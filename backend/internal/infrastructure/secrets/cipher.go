@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+// prefix marks an encrypted value, followed by the ID of its key and the sealed secret:
+// "enc:v1:<key id>:<base64 of nonce and ciphertext>"
+const prefix = "enc:v1:"
+
+var (
+	// ErrNoKey is returned when a secret is encrypted without SECRETS_ENCRYPTION_KEY configured
+	ErrNoKey = errors.New("no encryption key is configured, set SECRETS_ENCRYPTION_KEY to store secrets")
+	// ErrUnknownKey is returned for a secret encrypted with a key that is no longer configured
+	ErrUnknownKey = errors.New("secret was encrypted with a key that is not configured")
+)
+
+// Cipher encrypts the secrets kept in the database, like the gateway credentials of stores, with
+// AES-256-GCM. New secrets are sealed with the current key, previous keys still open the secrets sealed
+// with them so keys can be rotated without re-entering every secret.
+type Cipher struct {
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+// NewCipher sets up the keys of the configuration, each the base64 of 32 random bytes. Without a current
+// key secrets can't be stored, but values saved before encryption was set up are still read.
+func NewCipher(cfg config.SecretsConfig) (*Cipher, error) {
+	c := &Cipher{keys: make(map[string]cipher.AEAD)}
+	for i, encoded := range append([]string{cfg.EncryptionKey}, cfg.PreviousKeys...) {
+		encoded = strings.TrimSpace(encoded)
+		if encoded == "" {
+			continue
+		}
+		id, aead, err := newKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			c.currentID = id
+		}
+		c.keys[id] = aead
+	}
+	return c, nil
+}
+
+func newKey(encoded string) (string, cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("encryption key is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return "", nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Identifies the key a secret was sealed with without revealing anything about it
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4]), aead, nil
+}
+
+// Configured tells whether secrets can be encrypted
+func (c *Cipher) Configured() bool {
+	return c.currentID != ""
+}
+
+// Encrypt seals a secret with the current key, an empty secret stays empty
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	if !c.Configured() {
+		return "", ErrNoKey
+	}
+	aead := c.keys[c.currentID]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(c.currentID))
+	return prefix + c.currentID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a secret sealed by Encrypt. A value without the encrypted prefix was saved before
+// encryption was set up and is returned as it is.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted secret")
+	}
+	aead, ok := c.keys[id]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("malformed encrypted secret")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/pkg/logger"
+)
+
+// LocalRoutePrefix is where the API serves the files of the local storage
+const LocalRoutePrefix = "/uploads"
+
+// uploadTempPrefix marks files still being written, they are never listed or served
+const uploadTempPrefix = ".upload-"
+
+var errInvalidPath = errors.New("invalid storage path")
+
+// LocalStorage keeps files on the disk of the API server, one directory per bucket, for deployments
+// without an object store. The image bucket is served publicly, other buckets only through signed URLs.
+type LocalStorage struct {
+	dir        string
+	bucketName string
+	publicURL  string
+	signingKey []byte
+	logger     logger.Logger
+}
+
+func NewLocalStorage(cfg config.StorageConfig, logger logger.Logger) (*LocalStorage, error) {
+	if err := os.MkdirAll(filepath.Join(cfg.LocalDir, cfg.BucketName), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	signingKey := []byte(cfg.LocalSigningKey)
+	if len(signingKey) == 0 {
+		// Links handed out before a restart stop working, which only matters for long lived ones
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, err
+		}
+		logger.Warn("STORAGE_LOCAL_SIGNING_KEY is not set, signed download links end with the process")
+	}
+
+	return &LocalStorage{
+		dir:        cfg.LocalDir,
+		bucketName: cfg.BucketName,
+		publicURL:  strings.TrimRight(cfg.LocalPublicURL, "/"),
+		signingKey: signingKey,
+		logger:     logger,
+	}, nil
+}
+
+func (s *LocalStorage) UploadImage(file io.Reader, fileName string, contentType string) (string, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return s.UploadProductImage(context.Background(), newImagePath(fileName), contentType, content)
+}
+
+func (s *LocalStorage) UploadProductImage(ctx context.Context, objectPath, contentType string, content []byte) (string, error) {
+	if err := s.UploadFile(ctx, s.bucketName, objectPath, contentType, content); err != nil {
+		return "", err
+	}
+	return s.GetPublicURL(objectPath), nil
+}
+
+func (s *LocalStorage) DeleteImage(objectPath string) error {
+	objectPath = s.ObjectPath(objectPath)
+	filePath, err := s.filePath(s.bucketName, objectPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	s.logger.Info("Image deleted successfully", "path", objectPath)
+	return nil
+}
+
+func (s *LocalStorage) GetPublicURL(objectPath string) string {
+	return fmt.Sprintf("%s/%s/%s", s.publicURL, s.bucketName, objectPath)
+}
+
+func (s *LocalStorage) ObjectPath(publicURL string) string {
+	prefix := fmt.Sprintf("%s/%s/", s.publicURL, s.bucketName)
+	if len(publicURL) > len(prefix) && publicURL[:len(prefix)] == prefix {
+		return publicURL[len(prefix):]
+	}
+	return publicURL
+}
+
+func (s *LocalStorage) ListProductImages(ctx context.Context) ([]StoredObject, error) {
+	root := filepath.Join(s.dir, s.bucketName)
+	var stored []StoredObject
+	err := filepath.WalkDir(filepath.Join(root, productImagesFolder), func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), uploadTempPrefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, StoredObject{
+			Path:      filepath.ToSlash(rel),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list failed: %w", err)
+	}
+	return stored, nil
+}
+
+func (s *LocalStorage) Usage(ctx context.Context) (*BucketUsage, error) {
+	objects, err := s.ListProductImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return usage(s.bucketName, objects), nil
+}
+
+// UploadFile writes the file next to its destination first so a reader never sees half of it
+func (s *LocalStorage) UploadFile(ctx context.Context, bucket, objectPath, contentType string, content []byte) error {
+	filePath, err := s.filePath(bucket, objectPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), uploadTempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+
+	s.logger.Info("File uploaded successfully", "bucket", bucket, "path", objectPath, "bytes", len(content))
+	return nil
+}
+
+func (s *LocalStorage) SignedURL(ctx context.Context, bucket, objectPath string, expiresIn time.Duration) (string, error) {
+	if _, err := s.filePath(bucket, objectPath); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiresIn).Unix(), 10)
+	return fmt.Sprintf("%s/%s/%s?expires=%s&signature=%s", s.publicURL, bucket, objectPath, expires, s.sign(bucket, objectPath, expires)), nil
+}
+
+// Handler serves the files under LocalRoutePrefix, with the prefix stripped: the image bucket to
+// anyone, other buckets to holders of an unexpired signed URL
+func (s *LocalStorage) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket, objectPath, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		filePath, err := s.filePath(bucket, objectPath)
+		if err != nil || strings.HasPrefix(path.Base(objectPath), uploadTempPrefix) {
+			http.NotFound(w, r)
+			return
+		}
+
+		if bucket == s.bucketName {
+			// Images are stored under new names, a changed picture is a new URL
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			expires := r.URL.Query().Get("expires")
+			expiresAt, err := strconv.ParseInt(expires, 10, 64)
+			signature, _ := hex.DecodeString(r.URL.Query().Get("signature"))
+			expected, _ := hex.DecodeString(s.sign(bucket, objectPath, expires))
+			if err != nil || time.Now().Unix() > expiresAt || !hmac.Equal(signature, expected) {
+				http.Error(w, "link is invalid or expired", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Cache-Control", "private, no-store")
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+	})
+}
+
+func (s *LocalStorage) sign(bucket, objectPath, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(bucket + "/" + objectPath + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// filePath maps a bucket and object path to a file under the storage directory, refusing paths that
+// would leave it
+func (s *LocalStorage) filePath(bucket, objectPath string) (string, error) {
+	if bucket == "" || bucket == "." || bucket == ".." || strings.ContainsAny(bucket, `/\`) {
+		return "", errInvalidPath
+	}
+	cleaned := path.Clean("/" + objectPath)
+	if cleaned == "/" || cleaned[1:] != objectPath || strings.Contains(objectPath, `\`) {
+		return "", errInvalidPath
+	}
+	return filepath.Join(s.dir, bucket, filepath.FromSlash(objectPath)), nil
+}
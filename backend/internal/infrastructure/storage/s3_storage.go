@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/tracing"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage keeps files in an S3 compatible object store such as MinIO. The image bucket needs a
+// public read policy, other buckets stay private.
+type S3Storage struct {
+	client     *minio.Client
+	bucketName string
+	publicURL  string
+	logger     logger.Logger
+}
+
+func NewS3Storage(cfg config.StorageConfig, logger logger.Logger) (*S3Storage, error) {
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure:    cfg.S3UseSSL,
+		Region:    cfg.S3Region,
+		Transport: tracing.Transport(nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	publicURL := strings.TrimRight(cfg.S3PublicURL, "/")
+	if publicURL == "" {
+		publicURL = fmt.Sprintf("%s/%s", strings.TrimRight(client.EndpointURL().String(), "/"), cfg.BucketName)
+	}
+
+	return &S3Storage{
+		client:     client,
+		bucketName: cfg.BucketName,
+		publicURL:  publicURL,
+		logger:     logger,
+	}, nil
+}
+
+func (s *S3Storage) UploadImage(file io.Reader, fileName string, contentType string) (string, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return s.UploadProductImage(context.Background(), newImagePath(fileName), contentType, content)
+}
+
+func (s *S3Storage) UploadProductImage(ctx context.Context, objectPath, contentType string, content []byte) (string, error) {
+	if err := s.UploadFile(ctx, s.bucketName, objectPath, contentType, content); err != nil {
+		return "", err
+	}
+	return s.GetPublicURL(objectPath), nil
+}
+
+func (s *S3Storage) DeleteImage(objectPath string) error {
+	objectPath = s.ObjectPath(objectPath)
+	if err := s.client.RemoveObject(context.Background(), s.bucketName, objectPath, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	s.logger.Info("Image deleted successfully", "path", objectPath)
+	return nil
+}
+
+func (s *S3Storage) GetPublicURL(objectPath string) string {
+	return fmt.Sprintf("%s/%s", s.publicURL, objectPath)
+}
+
+func (s *S3Storage) ObjectPath(publicURL string) string {
+	prefix := s.publicURL + "/"
+	if len(publicURL) > len(prefix) && publicURL[:len(prefix)] == prefix {
+		return publicURL[len(prefix):]
+	}
+	return publicURL
+}
+
+func (s *S3Storage) ListProductImages(ctx context.Context) ([]StoredObject, error) {
+	var stored []StoredObject
+	for object := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{
+		Prefix:    productImagesFolder + "/",
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("list failed: %w", object.Err)
+		}
+		stored = append(stored, StoredObject{
+			Path:      object.Key,
+			Size:      object.Size,
+			CreatedAt: object.LastModified,
+		})
+	}
+	return stored, nil
+}
+
+func (s *S3Storage) Usage(ctx context.Context) (*BucketUsage, error) {
+	objects, err := s.ListProductImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return usage(s.bucketName, objects), nil
+}
+
+func (s *S3Storage) UploadFile(ctx context.Context, bucket, objectPath, contentType string, content []byte) error {
+	_, err := s.client.PutObject(ctx, bucket, objectPath, bytes.NewReader(content), int64(len(content)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+
+	s.logger.Info("File uploaded successfully", "bucket", bucket, "path", objectPath, "bytes", len(content))
+	return nil
+}
+
+func (s *S3Storage) SignedURL(ctx context.Context, bucket, objectPath string, expiresIn time.Duration) (string, error) {
+	signed, err := s.client.PresignedGetObject(ctx, bucket, objectPath, expiresIn, nil)
+	if err != nil {
+		return "", fmt.Errorf("sign failed: %w", err)
+	}
+	return signed.String(), nil
+}
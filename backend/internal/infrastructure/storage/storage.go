@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// Storage drivers selectable with STORAGE_DRIVER
+const (
+	DriverSupabase = "supabase"
+	DriverS3       = "s3"
+	DriverLocal    = "local"
+)
+
+// Storage keeps the product images, served publicly, and files of private buckets such as exports,
+// handed out through signed URLs
+type Storage interface {
+	// UploadImage stores a product image under a new name and returns its public URL
+	UploadImage(file io.Reader, fileName string, contentType string) (string, error)
+	// UploadProductImage stores an already processed image at a path of the product images bucket
+	// and returns its public URL
+	UploadProductImage(ctx context.Context, objectPath, contentType string, content []byte) (string, error)
+	// DeleteImage removes a product image, by path or public URL
+	DeleteImage(objectPath string) error
+	GetPublicURL(objectPath string) string
+	// ObjectPath turns a public URL of the product images back into the path of its object, anything
+	// else is returned as is
+	ObjectPath(publicURL string) string
+	ListProductImages(ctx context.Context) ([]StoredObject, error)
+	Usage(ctx context.Context) (*BucketUsage, error)
+
+	// UploadFile stores a file at a path of a bucket, replacing what was there
+	UploadFile(ctx context.Context, bucket, objectPath, contentType string, content []byte) error
+	// SignedURL returns a link that downloads a file of a bucket until it expires
+	SignedURL(ctx context.Context, bucket, objectPath string, expiresIn time.Duration) (string, error)
+}
+
+// New returns the storage of the configured driver
+func New(cfg config.StorageConfig, logger logger.Logger) (Storage, error) {
+	switch cfg.Driver {
+	case DriverSupabase, "":
+		return NewSupabaseClient(cfg, logger), nil
+	case DriverS3:
+		return NewS3Storage(cfg, logger)
+	case DriverLocal:
+		return NewLocalStorage(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q, use supabase, s3 or local", cfg.Driver)
+	}
+}
+
+// Configured reports whether the configured driver has what it needs to reach its storage, the local
+// disk always does
+func Configured(cfg config.StorageConfig) bool {
+	switch cfg.Driver {
+	case DriverS3:
+		return cfg.S3Endpoint != ""
+	case DriverLocal:
+		return true
+	default:
+		return cfg.SupabaseURL != ""
+	}
+}
+
+// BucketUsage is the space taken by the product images in the bucket
+type BucketUsage struct {
+	Bucket  string `json:"bucket"`
+	Objects int    `json:"objects"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// StoredObject is a file of the product images bucket
+type StoredObject struct {
+	Path      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// productImagesFolder holds every product image of the bucket
+const productImagesFolder = "products"
+
+// newImagePath names an uploaded product image, keeping the extension of the original file
+func newImagePath(fileName string) string {
+	return fmt.Sprintf("%s/%s%s", productImagesFolder, uuid.New().String(), getFileExtension(fileName))
+}
+
+// usage adds up the size of stored objects
+func usage(bucket string, objects []StoredObject) *BucketUsage {
+	usage := &BucketUsage{Bucket: bucket}
+	for _, object := range objects {
+		usage.Objects++
+		usage.Bytes += object.Size
+	}
+	return usage
+}
+
+func getFileExtension(fileName string) string {
+	for i := len(fileName) - 1; i >= 0; i-- {
+		if fileName[i] == '.' {
+			return fileName[i:]
+		}
+	}
+	return ""
+}
+
+// ValidateImageFile validates if the uploaded file is a valid image
+func ValidateImageFile(contentType string, size int64, maxSizeMB int) error {
+	// Check content type
+	allowedTypes := map[string]bool{
+		"image/jpeg": true,
+		"image/jpg":  true,
+		"image/png":  true,
+		"image/webp": true,
+		"image/gif":  true,
+	}
+
+	if !allowedTypes[contentType] {
+		return fmt.Errorf("unsupported file type: %s. Allowed types: JPEG, PNG, WebP, GIF", contentType)
+	}
+
+	// Check file size
+	maxSize := int64(maxSizeMB) * 1024 * 1024 // Convert MB to bytes
+	if size > maxSize {
+		return fmt.Errorf("file size %d bytes exceeds maximum allowed size %d MB", size, maxSizeMB)
+	}
+
+	return nil
+}
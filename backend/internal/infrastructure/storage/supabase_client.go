@@ -2,15 +2,16 @@ package storage
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/tracing"
 	"qris-pos-backend/pkg/logger"
-
-	"github.com/google/uuid"
 )
 
 type SupabaseClient struct {
@@ -39,16 +40,15 @@ func NewSupabaseClient(cfg config.StorageConfig, logger logger.Logger) *Supabase
 		bucketName: cfg.BucketName,
 		logger:     logger,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
 
 func (s *SupabaseClient) UploadImage(file io.Reader, fileName string, contentType string) (string, error) {
 	// Generate UUID filename
-	fileExtension := getFileExtension(fileName)
-	uniqueFileName := fmt.Sprintf("%s%s", uuid.New().String(), fileExtension)
-	objectPath := fmt.Sprintf("products/%s", uniqueFileName)
+	objectPath := newImagePath(fileName)
 
 	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.baseURL, s.bucketName, objectPath)
 
@@ -89,15 +89,19 @@ func (s *SupabaseClient) GetPublicURL(objectPath string) string {
 	return fmt.Sprintf("%s/storage/v1/object/public/%s/%s", s.baseURL, s.bucketName, objectPath)
 }
 
+// ObjectPath turns a public URL of the bucket back into the path of its object, anything else is
+// returned as is
+func (s *SupabaseClient) ObjectPath(publicURL string) string {
+	prefix := fmt.Sprintf("%s/storage/v1/object/public/%s/", s.baseURL, s.bucketName)
+	if len(publicURL) > len(prefix) && publicURL[:len(prefix)] == prefix {
+		return publicURL[len(prefix):]
+	}
+	return publicURL
+}
+
 func (s *SupabaseClient) DeleteImage(objectPath string) error {
 	// Extract path from full URL if needed
-	if len(objectPath) > len(s.baseURL) && objectPath[:len(s.baseURL)] == s.baseURL {
-		// This is a full URL, extract the object path
-		prefix := fmt.Sprintf("%s/storage/v1/object/public/%s/", s.baseURL, s.bucketName)
-		if len(objectPath) > len(prefix) && objectPath[:len(prefix)] == prefix {
-			objectPath = objectPath[len(prefix):]
-		}
-	}
+	objectPath = s.ObjectPath(objectPath)
 
 	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.baseURL, s.bucketName, objectPath)
 	req, err := http.NewRequest("DELETE", url, nil)
@@ -122,35 +126,154 @@ func (s *SupabaseClient) DeleteImage(objectPath string) error {
 	return nil
 }
 
-func getFileExtension(fileName string) string {
-	for i := len(fileName) - 1; i >= 0; i-- {
-		if fileName[i] == '.' {
-			return fileName[i:]
-		}
+// UploadFile stores a file at a path of a bucket, replacing what was there. Unlike product images the
+// bucket may be private, files are then handed out through signed URLs.
+func (s *SupabaseClient) UploadFile(ctx context.Context, bucket, objectPath, contentType string, content []byte) error {
+	url := fmt.Sprintf("%s/storage/v1/object/%s/%s", s.baseURL, bucket, objectPath)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(body))
 	}
-	return ""
+
+	s.logger.Info("File uploaded successfully", "bucket", bucket, "path", objectPath, "bytes", len(content))
+	return nil
 }
 
-// ValidateImageFile validates if the uploaded file is a valid image
-func ValidateImageFile(contentType string, size int64, maxSizeMB int) error {
-	// Check content type
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/jpg":  true,
-		"image/png":  true,
-		"image/webp": true,
-		"image/gif":  true,
+// UploadProductImage stores an already processed image at a path of the product images bucket and
+// returns its public URL
+func (s *SupabaseClient) UploadProductImage(ctx context.Context, objectPath, contentType string, content []byte) (string, error) {
+	if err := s.UploadFile(ctx, s.bucketName, objectPath, contentType, content); err != nil {
+		return "", err
 	}
+	return s.GetPublicURL(objectPath), nil
+}
+
+// SignedURL returns a link that downloads a file of a private bucket until it expires
+func (s *SupabaseClient) SignedURL(ctx context.Context, bucket, objectPath string, expiresIn time.Duration) (string, error) {
+	url := fmt.Sprintf("%s/storage/v1/object/sign/%s/%s", s.baseURL, bucket, objectPath)
 
-	if !allowedTypes[contentType] {
-		return fmt.Errorf("unsupported file type: %s. Allowed types: JPEG, PNG, WebP, GIF", contentType)
+	payload, err := json.Marshal(map[string]interface{}{
+		"expiresIn": int(expiresIn.Seconds()),
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// Check file size
-	maxSize := int64(maxSizeMB) * 1024 * 1024 // Convert MB to bytes
-	if size > maxSize {
-		return fmt.Errorf("file size %d bytes exceeds maximum allowed size %d MB", size, maxSizeMB)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
+	req.Header.Set("Content-Type", "application/json")
 
-	return nil
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("sign failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var signed struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&signed); err != nil {
+		return "", fmt.Errorf("failed to decode signed URL: %w", err)
+	}
+
+	// The returned path is relative to the storage API
+	return fmt.Sprintf("%s/storage/v1%s", s.baseURL, signed.SignedURL), nil
+}
+
+// listPageSize is the largest page the Supabase list endpoint returns
+const listPageSize = 1000
+
+// Usage adds up the product images stored in the bucket
+func (s *SupabaseClient) Usage(ctx context.Context) (*BucketUsage, error) {
+	objects, err := s.ListProductImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return usage(s.bucketName, objects), nil
+}
+
+// ListProductImages lists the product images stored in the bucket, page by page
+func (s *SupabaseClient) ListProductImages(ctx context.Context) ([]StoredObject, error) {
+	var stored []StoredObject
+	url := fmt.Sprintf("%s/storage/v1/object/list/%s", s.baseURL, s.bucketName)
+
+	for offset := 0; ; offset += listPageSize {
+		payload, err := json.Marshal(map[string]interface{}{
+			"prefix": productImagesFolder,
+			"limit":  listPageSize,
+			"offset": offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.apiKey))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+
+		var objects []struct {
+			Name      string    `json:"name"`
+			CreatedAt time.Time `json:"created_at"`
+			Metadata  *struct {
+				Size int64 `json:"size"`
+			} `json:"metadata"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("list failed with status %d: %s", resp.StatusCode, string(body))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&objects)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object list: %w", err)
+		}
+
+		for _, object := range objects {
+			// Folders come back without metadata
+			if object.Metadata == nil {
+				continue
+			}
+			stored = append(stored, StoredObject{
+				Path:      productImagesFolder + "/" + object.Name,
+				Size:      object.Metadata.Size,
+				CreatedAt: object.CreatedAt,
+			})
+		}
+		if len(objects) < listPageSize {
+			return stored, nil
+		}
+	}
 }
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/tracing"
+)
+
+// Headers of a webhook request. The receiver recomputes the signature over "<timestamp>.<body>" with
+// the secret of its endpoint, and can drop requests whose timestamp is too old to stop replays.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery" // Same on every attempt of a delivery, for idempotency
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature" // "sha256=<hex HMAC>"
+)
+
+// maxErrorBody caps how much of a failed response is kept in the delivery log
+const maxErrorBody = 512
+
+// ErrPrivateAddress refuses a target inside the network of the deployment
+var ErrPrivateAddress = errors.New("private, loopback or link-local address")
+
+// Request is a signed event posted to an endpoint
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	Event      string
+	Payload    []byte
+}
+
+// Client posts events to the URLs of merchant integrations
+type Client struct {
+	httpClient *http.Client
+	publicOnly bool
+}
+
+// NewClient posts to any address, or in SaaS mode only to public ones so a tenant can't reach the services
+// next to the deployment. The address is checked when dialing, after the name was resolved.
+func NewClient(cfg config.WebhookConfig, tenancy config.TenancyConfig) *Client {
+	var transport http.RoundTripper
+	if tenancy.Enabled {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublic}
+		public := http.DefaultTransport.(*http.Transport).Clone()
+		public.DialContext = dialer.DialContext
+		public.Proxy = nil // A proxy would dial the target for us, past the check
+		transport = public
+	}
+
+	return &Client{
+		publicOnly: tenancy.Enabled,
+		httpClient: &http.Client{
+			Timeout:   time.Duration(cfg.TimeoutSeconds) * time.Second,
+			Transport: tracing.Transport(transport),
+			// A redirect could point the signed payload anywhere, the endpoint URL has to be the final one
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send posts the event and returns the HTTP status of the answer, 0 when none came. Only a 2xx
+// status counts as delivered.
+func (c *Client) Send(ctx context.Context, r *Request) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "qris-pos-webhook/1.0")
+	req.Header.Set(HeaderEvent, r.Event)
+	req.Header.Set(HeaderDelivery, r.DeliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(r.Secret, timestamp, r.Payload))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("endpoint answered with status %d: %s", resp.StatusCode, string(body))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, nil
+}
+
+// CheckHost refuses a host that names a private address outright, before an endpoint is saved. Names
+// resolving to one are refused when dialing.
+func (c *Client) CheckHost(host string) error {
+	if !c.publicOnly {
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && !isPublic(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<payload>" keyed with the secret
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"qris-pos-backend/internal/infrastructure/config"
+)
+
+func TestClientRefusesPrivateAddressesInSaaSMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	request := &Request{URL: server.URL, Secret: "whsec_test", DeliveryID: "delivery", Event: "payment.settled", Payload: []byte("{}")}
+	cfg := config.WebhookConfig{TimeoutSeconds: 5}
+
+	if _, err := NewClient(cfg, config.TenancyConfig{}).Send(context.Background(), request); err != nil {
+		t.Fatalf("single merchant deployment: %v", err)
+	}
+
+	status, err := NewClient(cfg, config.TenancyConfig{Enabled: true}).Send(context.Background(), request)
+	if !errors.Is(err, ErrPrivateAddress) || status != 0 {
+		t.Errorf("SaaS mode got %d, %v, want the loopback address refused", status, err)
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	client := NewClient(config.WebhookConfig{}, config.TenancyConfig{Enabled: true})
+
+	for _, host := range []string{"localhost", "api.localhost", "127.0.0.1", "10.1.2.3", "192.168.1.10", "169.254.169.254", "::1", "fe80::1", "0.0.0.0"} {
+		if err := client.CheckHost(host); !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("%s: err = %v, want it refused", host, err)
+		}
+	}
+	for _, host := range []string{"hooks.example.com", "203.0.113.7", "2001:db8::1"} {
+		if err := client.CheckHost(host); err != nil {
+			t.Errorf("%s: %v", host, err)
+		}
+	}
+
+	if err := NewClient(config.WebhookConfig{}, config.TenancyConfig{}).CheckHost("127.0.0.1"); err != nil {
+		t.Errorf("single merchant deployment refused loopback: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait is the time allowed to write a message to the peer
+	writeWait = 10 * time.Second
+	// pongWait is the time allowed to read the next pong message from the peer
+	pongWait = 60 * time.Second
+	// pingPeriod sends pings to the peer with this period, must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+	// maxMessageSize caps messages from the peer; terminals only send control frames
+	maxMessageSize = 512
+)
+
+type client struct {
+	conn   *websocket.Conn
+	userID string
+}
+
+// Hub keeps the connected POS terminals and pushes broker events to them
+type Hub struct {
+	broker   *events.Broker
+	logger   logger.Logger
+	upgrader websocket.Upgrader
+	mu       sync.Mutex
+	clients  map[*client]struct{}
+}
+
+// NewHub creates a new websocket hub instance
+func NewHub(broker *events.Broker, logger logger.Logger) *Hub {
+	return &Hub{
+		broker: broker,
+		logger: logger,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// CORS is already open for the API; terminals authenticate with a JWT
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		clients: make(map[*client]struct{}),
+	}
+}
+
+// Serve upgrades the request and streams the broker events matching the filter to the connection
+// until it closes. A nil filter streams every event.
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, userID string, filter events.Filter) error {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	c := &client{conn: conn, userID: userID}
+	h.register(c)
+
+	updates, unsubscribe := h.broker.Subscribe(filter)
+	done := make(chan struct{})
+
+	go h.readPump(c, done)
+	h.writePump(c, updates, done)
+
+	unsubscribe()
+	h.unregister(c)
+	return nil
+}
+
+func (h *Hub) register(c *client) {
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	count := len(h.clients)
+	h.mu.Unlock()
+
+	h.logger.Info("WebSocket client connected", "user_id", c.userID, "clients", count)
+}
+
+func (h *Hub) unregister(c *client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	count := len(h.clients)
+	h.mu.Unlock()
+
+	c.conn.Close()
+	h.logger.Info("WebSocket client disconnected", "user_id", c.userID, "clients", count)
+}
+
+// readPump drains incoming frames so pongs and close messages are processed
+func (h *Hub) readPump(c *client, done chan<- struct{}) {
+	defer close(done)
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.logger.Warn("WebSocket read error", "error", err, "user_id", c.userID)
+			}
+			return
+		}
+	}
+}
+
+// writePump forwards events and keeps the connection alive with pings
+func (h *Hub) writePump(c *client, updates <-chan events.Event, done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case event, ok := <-updates:
+			if !ok {
+				// The broker closed, the server is shutting down; terminals reconnect on going away
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+				return
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteJSON(event); err != nil {
+				h.logger.Warn("WebSocket write error", "error", err, "user_id", c.userID)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/report"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountingHandler struct {
+	accountingUseCase *report.AccountingUseCase
+	logger            logger.Logger
+}
+
+func NewAccountingHandler(accountingUseCase *report.AccountingUseCase, logger logger.Logger) *AccountingHandler {
+	return &AccountingHandler{
+		accountingUseCase: accountingUseCase,
+		logger:            logger,
+	}
+}
+
+// ExportJournal godoc
+// @Summary Accounting journal export
+// @Description Journal entries of the settled sales, one per store and day: the money collected is debited to the clearing account of each payment method, tax is credited to tax payable and the rest to sales (Admin only)
+// @Tags reports
+// @Produce json
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param date_from query string true "Start date (YYYY-MM-DD)"
+// @Param date_to query string true "End date, inclusive (YYYY-MM-DD)"
+// @Param store_id query string false "Only sales of this store, every store when neither it nor X-Store-ID is given"
+// @Param format query string false "Format" Enums(json, csv) default(json)
+// @Success 200 {object} response.Response{data=report.AccountingExportResponse}
+// @Failure 400 {object} response.Response
+// @Router /reports/accounting-export [get]
+func (h *AccountingHandler) ExportJournal(c *gin.Context) {
+	var req report.AccountingExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.accountingUseCase.ExportJournal(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to export accounting journal", "error", err)
+		h.handleError(c, err, "Failed to export accounting journal")
+		return
+	}
+
+	if req.Format != report.FormatCSV {
+		response.Success(c, "Accounting journal retrieved successfully", result)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="journal-%s-%s.csv"`, req.DateFrom, req.DateTo))
+	c.Status(http.StatusOK)
+
+	if err := h.accountingUseCase.WriteJournalCSV(c.Writer, result.Entries); err != nil {
+		h.logger.Error("Failed to write accounting journal", "error", err)
+		c.Error(err)
+	}
+}
+
+// PushJournal godoc
+// @Summary Post the journal to an accounting system
+// @Description Post the daily journal entries of the range to Accurate, Jurnal or Xero. Days already posted to the connector are skipped, a failed day is reported and can be pushed again. The connectors are set up for the whole deployment (platform admins only)
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body report.AccountingPushRequest true "Range and connector"
+// @Success 200 {object} response.Response{data=report.AccountingPushResponse}
+// @Failure 400 {object} response.Response
+// @Router /reports/accounting-export/push [post]
+func (h *AccountingHandler) PushJournal(c *gin.Context) {
+	var req report.AccountingPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.accountingUseCase.PushJournal(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to push accounting journal", "error", err, "connector", req.Connector)
+		h.handleError(c, err, "Failed to push accounting journal")
+		return
+	}
+
+	response.Success(c, "Accounting journal pushed", result)
+}
+
+func (h *AccountingHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, appErrors.ErrInvalidInput), errors.Is(err, appErrors.ErrAccountingConnectorNotConfigured):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/alert"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AlertHandler struct {
+	alertUseCase *alert.AlertUseCase
+	logger       logger.Logger
+}
+
+func NewAlertHandler(alertUseCase *alert.AlertUseCase, logger logger.Logger) *AlertHandler {
+	return &AlertHandler{
+		alertUseCase: alertUseCase,
+		logger:       logger,
+	}
+}
+
+// ListAlerts godoc
+// @Summary List anomaly alerts
+// @Description List sales anomalies flagged by the detection job: void spikes, after-hours refunds and outlier discounts (Admin only)
+// @Tags alerts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "Alert type" Enums(void_spike, after_hours_refund, discount_outlier, stock_unavailable)
+// @Param acknowledged query bool false "Filter by acknowledgement"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.Alert}
+// @Failure 400 {object} response.Response
+// @Router /alerts [get]
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	var req alert.ListAlertsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.alertUseCase.ListAlerts(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to list alerts", "error", err)
+		response.InternalError(c, "Failed to list alerts", err.Error())
+		return
+	}
+
+	response.Success(c, "Alerts retrieved successfully", result)
+}
+
+// AcknowledgeAlert godoc
+// @Summary Acknowledge an alert
+// @Description Mark an anomaly alert as reviewed (Admin only)
+// @Tags alerts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Alert ID"
+// @Success 200 {object} response.Response{data=entities.Alert}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /alerts/{id}/acknowledge [post]
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	alertID := c.Param("id")
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.alertUseCase.AcknowledgeAlert(c.Request.Context(), alertID, currentUser.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrAlertNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to acknowledge alert", "error", err, "alert_id", alertID)
+			response.InternalError(c, "Failed to acknowledge alert", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Alert acknowledged successfully", result)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/audit"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditUseCase *audit.AuditUseCase
+	logger       logger.Logger
+}
+
+func NewAuditHandler(auditUseCase *audit.AuditUseCase, logger logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+		logger:       logger,
+	}
+}
+
+// ListAuditLogs godoc
+// @Summary List audit logs
+// @Description List who changed prices, stock and user roles, voided transactions and issued refunds, newest first (Admin only)
+// @Tags audit
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user_id query string false "User who performed the action"
+// @Param action query string false "Action" Enums(product.update, product.bulk_price, product.stock, user.register, transaction.void, transaction.exchange, transaction.discount, payment.refund)
+// @Param resource_id query string false "Product, transaction or payment acted on"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date, inclusive (YYYY-MM-DD)"
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.AuditLog}
+// @Failure 400 {object} response.Response
+// @Router /audit-logs [get]
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	var req audit.ListAuditLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.auditUseCase.ListAuditLogs(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		h.logger.Error("Failed to list audit logs", "error", err)
+		response.InternalError(c, "Failed to list audit logs", err.Error())
+		return
+	}
+
+	response.Success(c, "Audit logs retrieved successfully", result)
+}
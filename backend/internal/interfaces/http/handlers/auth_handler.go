@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+
 	"qris-pos-backend/internal/interfaces/middleware"
 	"qris-pos-backend/internal/usecases/auth"
+	pkgAuth "qris-pos-backend/pkg/auth"
+	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
 	"qris-pos-backend/pkg/response"
 	"qris-pos-backend/pkg/validator"
@@ -24,7 +29,7 @@ func NewAuthHandler(authUseCase *auth.AuthUseCase, logger logger.Logger) *AuthHa
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return a JWT access token with a refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -47,7 +52,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	result, err := h.authUseCase.Login(c.Request.Context(), &req)
+	result, err := h.authUseCase.Login(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
 		h.logger.Error("Login failed", "error", err, "email", req.Email)
 		response.Unauthorized(c, err.Error())
@@ -126,33 +131,153 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 // RefreshToken godoc
 // @Summary Refresh JWT token
-// @Description Refresh the JWT token if it's close to expiry
+// @Description Trade a refresh token for a new access token and refresh token. Each refresh token can be used once.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Security ApiKeyAuth
-// @Success 200 {object} response.Response{data=map[string]string}
+// @Param request body auth.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} response.Response{data=auth.TokenResponse}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	// Get token from header
-	authHeader := c.GetHeader("Authorization")
-	if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-		response.Unauthorized(c, "Invalid authorization header")
+	var req auth.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
 		return
 	}
 
-	token := authHeader[7:]
-	newToken, err := h.authUseCase.RefreshToken(c.Request.Context(), token)
+	result, err := h.authUseCase.RefreshToken(c.Request.Context(), req.RefreshToken, clientInfo(c))
 	if err != nil {
 		h.logger.Error("Failed to refresh token", "error", err)
-		response.Unauthorized(c, "Invalid token")
+		switch {
+		case errors.Is(err, appErrors.ErrTokenRevoked):
+			response.Unauthorized(c, "Token has been revoked, please login again")
+		case errors.Is(err, appErrors.ErrTokenExpired):
+			response.Unauthorized(c, "Refresh token has expired, please login again")
+		default:
+			response.Unauthorized(c, "Invalid token")
+		}
+		return
+	}
+
+	response.Success(c, "Token refreshed successfully", result)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset code
+// @Description Email a six digit reset code to the user. The answer is the same whether or not the email is registered, and a user is sent at most one code per cooldown.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ForgotPasswordRequest true "Email of the account"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req auth.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	if err := h.authUseCase.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		if errors.Is(err, appErrors.ErrPasswordResetUnavailable) {
+			response.ServiceUnavailable(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to send reset code", "error", err)
+		response.InternalError(c, "Failed to send reset code", err.Error())
 		return
 	}
 
-	response.Success(c, "Token refreshed successfully", map[string]string{
-		"token": newToken,
-	})
+	response.Success(c, "If the email is registered, a reset code has been sent to it", nil)
+}
+
+// VerifyResetCode godoc
+// @Summary Verify a password reset code
+// @Description Trade the emailed reset code for a reset token. The code is spent after too many wrong tries.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.VerifyResetCodeRequest true "Email and reset code"
+// @Success 200 {object} response.Response{data=auth.ResetTokenResponse}
+// @Failure 400 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /auth/verify-reset-code [post]
+func (h *AuthHandler) VerifyResetCode(c *gin.Context) {
+	var req auth.VerifyResetCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.authUseCase.VerifyResetCode(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidResetCode) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		h.logger.Error("Failed to verify reset code", "error", err)
+		response.InternalError(c, "Failed to verify reset code", err.Error())
+		return
+	}
+
+	response.Success(c, "Reset code verified successfully", result)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the reset token of a verified code. Every session of the user is signed out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req auth.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	if err := h.authUseCase.ResetPassword(c.Request.Context(), &req); err != nil {
+		var passwordErr *pkgAuth.PasswordError
+		switch {
+		case errors.Is(err, appErrors.ErrInvalidResetCode), errors.As(err, &passwordErr):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to reset password", "error", err)
+			response.InternalError(c, "Failed to reset password", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Password reset successfully", nil)
 }
 
 type ChangePasswordRequest struct {
@@ -251,16 +376,164 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 // Logout godoc
 // @Summary User logout
-// @Description Logout user (client-side token removal)
+// @Description Revoke the access token of the request, and the refresh token of the session when given
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param request body auth.LogoutRequest false "Refresh token to revoke"
 // @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In JWT, logout is typically handled on the client side
-	// by removing the token from storage
-	// For server-side logout, you would need to implement token blacklisting
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	// The body is optional, clients that only hold an access token send none
+	var req auth.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if err := h.authUseCase.Logout(c.Request.Context(), currentUser, req.RefreshToken); err != nil {
+		h.logger.Error("Failed to logout", "error", err, "user_id", currentUser.UserID)
+		response.InternalError(c, "Failed to logout", err.Error())
+		return
+	}
+
 	response.Success(c, "Logged out successfully", nil)
-}
\ No newline at end of file
+}
+
+// ListSessions godoc
+// @Summary List sessions
+// @Description List the devices the current user is signed in on, with their address and last activity, most recently active first
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=[]auth.SessionResponse}
+// @Failure 401 {object} response.Response
+// @Router /auth/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.authUseCase.ListSessions(c.Request.Context(), currentUser)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "error", err, "user_id", currentUser.UserID)
+		response.InternalError(c, "Failed to retrieve sessions", err.Error())
+		return
+	}
+
+	response.Success(c, "Sessions retrieved successfully", result)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Sign the current user out of one of their sessions, for example on a lost device. Its refresh token and access tokens stop working.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.authUseCase.RevokeSession(c.Request.Context(), currentUser, id); err != nil {
+		if errors.Is(err, appErrors.ErrSessionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to revoke session", "error", err, "user_id", currentUser.UserID, "session_id", id)
+		response.InternalError(c, "Failed to revoke session", err.Error())
+		return
+	}
+
+	response.Success(c, "Session revoked successfully", nil)
+}
+
+// clientInfo describes the device a request was sent from, to tell sessions apart
+func clientInfo(c *gin.Context) auth.ClientInfo {
+	return auth.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List the users of the store the admin works in, newest first. Deleted users are included with include_deleted=true (Admin only).
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Param include_deleted query boolean false "Also list deleted users"
+// @Param limit query int false "Number of users to return" default(50)
+// @Param offset query int false "Number of users to skip" default(0)
+// @Success 200 {object} response.Response{data=[]auth.UserResponse}
+// @Failure 400 {object} response.Response
+// @Router /users [get]
+func (h *AuthHandler) ListUsers(c *gin.Context) {
+	var req auth.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.authUseCase.ListUsers(c.Request.Context(), &req)
+	if err != nil {
+		response.InternalError(c, "Failed to retrieve users", err.Error())
+		return
+	}
+
+	response.Success(c, "Users retrieved successfully", result)
+}
+
+// RestoreUser godoc
+// @Summary Restore a deleted user
+// @Description Bring a deleted user back so they can sign in again. Refused while another user has the same email (Admin only).
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response{data=auth.UserResponse}
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /users/{id}/restore [post]
+func (h *AuthHandler) RestoreUser(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.authUseCase.RestoreUser(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrUserNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrEmailExists):
+			response.Conflict(c, err.Error())
+		default:
+			h.logger.Error("Failed to restore user", "error", err, "user_id", id)
+			response.InternalError(c, "Failed to restore user", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "User restored successfully", result)
+}
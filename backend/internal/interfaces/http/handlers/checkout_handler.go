@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/checkout"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CheckoutHandler struct {
+	checkoutUseCase *checkout.CheckoutUseCase
+	logger          logger.Logger
+}
+
+func NewCheckoutHandler(checkoutUseCase *checkout.CheckoutUseCase, logger logger.Logger) *CheckoutHandler {
+	return &CheckoutHandler{
+		checkoutUseCase: checkoutUseCase,
+		logger:          logger,
+	}
+}
+
+// Checkout godoc
+// @Summary Check out a cart
+// @Description Create the transaction of a cart with its promotions, promo code, tax and stock reservation, and issue its QRIS, in one request.
+// @Description The register generates the transaction ID, so a checkout that timed out can be sent again: it returns the first checkout with 200
+// @Description and finishes the steps it left undone instead of ringing the cart up twice.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body checkout.CheckoutRequest true "Cart to check out"
+// @Success 201 {object} response.Response{data=checkout.CheckoutResponse}
+// @Success 200 {object} response.Response{data=checkout.CheckoutResponse} "Checkout already made with this ID"
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 410 {object} response.Response
+// @Failure 422 {object} response.Response
+// @Failure 503 {object} response.Response "Gateway unavailable, the transaction is kept and the checkout can be sent again"
+// @Router /checkout [post]
+func (h *CheckoutHandler) Checkout(c *gin.Context) {
+	var req checkout.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+	req.UserID = currentUser.UserID
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.checkoutUseCase.Checkout(c.Request.Context(), &req)
+	if err != nil {
+		var limitErr *appErrors.AmountLimitError
+		switch {
+		case errors.Is(err, appErrors.ErrPromoCodeUsedUp), errors.Is(err, appErrors.ErrTransactionHeld):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrTransactionExpired):
+			response.Gone(c, err.Error())
+		case errors.Is(err, appErrors.ErrUnsupportedCurrency), errors.As(err, &limitErr):
+			response.UnprocessableEntity(c, err.Error(), nil)
+		case errors.Is(err, appErrors.ErrGatewayUnavailable):
+			h.logger.Error("Payment gateway unavailable", "error", err, "transaction_id", req.ID)
+			response.ServiceUnavailable(c, "Payment gateway is unavailable, try again shortly or use another payment method")
+		default:
+			h.logger.Error("Failed to check out", "error", err, "transaction_id", req.ID, "user_id", req.UserID)
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	if result.Replayed {
+		response.Success(c, "Checkout already completed", result)
+		return
+	}
+	response.Created(c, "Checkout completed successfully", result)
+}
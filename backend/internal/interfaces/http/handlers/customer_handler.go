@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/customer"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomerHandler struct {
+	customerUseCase *customer.CustomerUseCase
+	logger          logger.Logger
+}
+
+func NewCustomerHandler(customerUseCase *customer.CustomerUseCase, logger logger.Logger) *CustomerHandler {
+	return &CustomerHandler{
+		customerUseCase: customerUseCase,
+		logger:          logger,
+	}
+}
+
+// CreateCustomer godoc
+// @Summary Register a customer
+// @Description Register a loyalty member with a phone number, an email or both
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body customer.CustomerRequest true "Customer data"
+// @Success 201 {object} response.Response{data=entities.Customer}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /customers [post]
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
+	var req customer.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.customerUseCase.CreateCustomer(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to create customer", "error", err)
+		if errors.Is(err, appErrors.ErrCustomerExists) {
+			response.Conflict(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Customer created successfully", result)
+}
+
+// GetCustomer godoc
+// @Summary Get customer by ID
+// @Description Get a loyalty member with their points balance
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response{data=entities.Customer}
+// @Failure 404 {object} response.Response
+// @Router /customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.customerUseCase.GetCustomer(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get customer", "error", err, "customer_id", id)
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Customer retrieved successfully", result)
+}
+
+// LookupCustomer godoc
+// @Summary Look up a customer
+// @Description Find a loyalty member at checkout by phone number or email
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param phone query string false "Phone number in E.164 format"
+// @Param email query string false "Email address"
+// @Success 200 {object} response.Response{data=entities.Customer}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /customers/lookup [get]
+func (h *CustomerHandler) LookupCustomer(c *gin.Context) {
+	var req customer.LookupRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.customerUseCase.LookupCustomer(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrCustomerNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to look up customer", "error", err)
+		response.InternalError(c, "Failed to look up customer", err.Error())
+		return
+	}
+
+	response.Success(c, "Customer retrieved successfully", result)
+}
+
+// UpdateCustomer godoc
+// @Summary Update a customer
+// @Description Update a loyalty member's details. The points balance is changed through the points endpoint.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID"
+// @Param request body customer.CustomerRequest true "Customer data"
+// @Success 200 {object} response.Response{data=entities.Customer}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /customers/{id} [put]
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	var req customer.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.customerUseCase.UpdateCustomer(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update customer", "error", err, "customer_id", id)
+		switch {
+		case errors.Is(err, appErrors.ErrCustomerNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrCustomerExists):
+			response.Conflict(c, err.Error())
+		default:
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(c, "Customer updated successfully", result)
+}
+
+// DeleteCustomer godoc
+// @Summary Delete a customer
+// @Description Remove a loyalty member (Admin only)
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /customers/{id} [delete]
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.customerUseCase.DeleteCustomer(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete customer", "error", err, "customer_id", id)
+		if errors.Is(err, appErrors.ErrCustomerNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to delete customer", err.Error())
+		return
+	}
+
+	response.Success(c, "Customer deleted successfully", nil)
+}
+
+// ListCustomers godoc
+// @Summary List customers
+// @Description Get loyalty members by name, optionally searching name, phone and email
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param search query string false "Search name, phone or email"
+// @Param limit query int false "Number of customers to return" default(20)
+// @Param offset query int false "Number of customers to skip" default(0)
+// @Success 200 {object} response.Response{data=[]entities.Customer}
+// @Router /customers [get]
+func (h *CustomerHandler) ListCustomers(c *gin.Context) {
+	var filters customer.CustomerFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if filters.Limit == 0 {
+		filters.Limit = 20
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.customerUseCase.ListCustomers(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list customers", "error", err)
+		response.InternalError(c, "Failed to retrieve customers", err.Error())
+		return
+	}
+
+	response.Success(c, "Customers retrieved successfully", result)
+}
+
+// GetPoints godoc
+// @Summary Get a customer's points
+// @Description Get the points balance of a loyalty member, what is held by unpaid transactions and the history of earned, redeemed and adjusted points
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID"
+// @Param limit query int false "Number of entries to return" default(20)
+// @Param offset query int false "Number of entries to skip" default(0)
+// @Success 200 {object} response.Response{data=customer.PointsResponse}
+// @Failure 404 {object} response.Response
+// @Router /customers/{id}/points [get]
+func (h *CustomerHandler) GetPoints(c *gin.Context) {
+	id := c.Param("id")
+
+	var filters customer.PointsFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if filters.Limit == 0 {
+		filters.Limit = 20
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.customerUseCase.GetPoints(c.Request.Context(), id, &filters)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrCustomerNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get points", "error", err, "customer_id", id)
+		response.InternalError(c, "Failed to retrieve points", err.Error())
+		return
+	}
+
+	response.Success(c, "Points retrieved successfully", result)
+}
+
+// AdjustPoints godoc
+// @Summary Adjust a customer's points
+// @Description Give or take away points by hand with a note explaining why. A balance can't go below zero. (Admin only)
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID"
+// @Param request body customer.AdjustPointsRequest true "Points adjustment"
+// @Success 200 {object} response.Response{data=customer.PointsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 422 {object} response.Response
+// @Router /customers/{id}/points [post]
+func (h *CustomerHandler) AdjustPoints(c *gin.Context) {
+	id := c.Param("id")
+
+	var req customer.AdjustPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.customerUseCase.AdjustPoints(c.Request.Context(), id, currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to adjust points", "error", err, "customer_id", id)
+		switch {
+		case errors.Is(err, appErrors.ErrCustomerNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInsufficientPoints):
+			response.UnprocessableEntity(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to adjust points", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Points adjusted successfully", result)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/refund"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeHandler struct {
+	exchangeUseCase *refund.ExchangeUseCase
+	logger          logger.Logger
+}
+
+func NewExchangeHandler(exchangeUseCase *refund.ExchangeUseCase, logger logger.Logger) *ExchangeHandler {
+	return &ExchangeHandler{
+		exchangeUseCase: exchangeUseCase,
+		logger:          logger,
+	}
+}
+
+// ExchangeItems godoc
+// @Summary Exchange items of a transaction
+// @Description Return items of a paid transaction and sell replacements in one operation (Admin only).
+// @Description Only the difference is charged through the replacement transaction or refunded on the original payment.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body refund.ExchangeRequest true "Exchange data"
+// @Success 201 {object} response.Response{data=refund.ExchangeResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/exchange [post]
+func (h *ExchangeHandler) ExchangeItems(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var req refund.ExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.exchangeUseCase.ExchangeItems(c.Request.Context(), transactionID, currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to exchange items", "error", err, "transaction_id", transactionID)
+		if errors.Is(err, appErrors.ErrTransactionNotFound) || errors.Is(err, appErrors.ErrPaymentNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Items exchanged successfully", result)
+}
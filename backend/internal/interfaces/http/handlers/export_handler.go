@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/export"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	exportUseCase *export.ExportUseCase
+	logger        logger.Logger
+}
+
+func NewExportHandler(exportUseCase *export.ExportUseCase, logger logger.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportUseCase: exportUseCase,
+		logger:        logger,
+	}
+}
+
+// CreateExport godoc
+// @Summary Request a data export
+// @Description Queue an export of the products, customers and the transactions of a date range as a zip of CSV files. The store picked with X-Store-ID is exported, or every store without it. A download link is emailed once the export is ready. (Admin only)
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body export.CreateExportRequest true "Export range"
+// @Success 202 {object} response.Response{data=export.ExportResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /exports [post]
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req export.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.exportUseCase.CreateExport(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create export", "error", err, "user_id", currentUser.UserID)
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to create export", err.Error())
+		return
+	}
+
+	response.Accepted(c, "Export queued, a download link will be emailed when it is ready", result)
+}
+
+// GetExport godoc
+// @Summary Get export by ID
+// @Description Get the status of an export, with a signed download link once it is ready (Admin only)
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Export ID"
+// @Success 200 {object} response.Response{data=export.ExportResponse}
+// @Failure 404 {object} response.Response
+// @Router /exports/{id} [get]
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.exportUseCase.GetExport(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get export", "error", err, "export_id", id)
+		if errors.Is(err, appErrors.ErrExportNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to retrieve export", err.Error())
+		return
+	}
+
+	response.Success(c, "Export retrieved successfully", result)
+}
+
+// ListExports godoc
+// @Summary List exports
+// @Description Get the requested exports, newest first (Admin only)
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Number of exports to return" default(20)
+// @Param offset query int false "Number of exports to skip" default(0)
+// @Success 200 {object} response.Response{data=[]entities.Export}
+// @Router /exports [get]
+func (h *ExportHandler) ListExports(c *gin.Context) {
+	var filters export.ExportFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if filters.Limit == 0 {
+		filters.Limit = 20
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.exportUseCase.ListExports(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list exports", "error", err)
+		response.InternalError(c, "Failed to retrieve exports", err.Error())
+		return
+	}
+
+	response.Success(c, "Exports retrieved successfully", result)
+}
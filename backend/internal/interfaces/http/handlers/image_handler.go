@@ -12,12 +12,12 @@ import (
 )
 
 type ImageHandler struct {
-	storageClient *storage.SupabaseClient
+	storageClient storage.Storage
 	config        config.StorageConfig
 	logger        logger.Logger
 }
 
-func NewImageHandler(storageClient *storage.SupabaseClient, config config.StorageConfig, logger logger.Logger) *ImageHandler {
+func NewImageHandler(storageClient storage.Storage, config config.StorageConfig, logger logger.Logger) *ImageHandler {
 	return &ImageHandler{
 		storageClient: storageClient,
 		config:        config,
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"qris-pos-backend/internal/usecases/inventory"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryHandler struct {
+	inventoryUseCase *inventory.InventoryUseCase
+	logger           logger.Logger
+}
+
+func NewInventoryHandler(inventoryUseCase *inventory.InventoryUseCase, logger logger.Logger) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryUseCase: inventoryUseCase,
+		logger:           logger,
+	}
+}
+
+// GetForecast godoc
+// @Summary Demand forecast and reorder suggestions
+// @Description Forecast product demand from daily sales with a simple moving average or exponential smoothing, and suggest reorder quantities (Admin only)
+// @Tags inventory
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "Forecast horizon in days" default(14)
+// @Param method query string false "Forecast method" Enums(sma, ses) default(ses)
+// @Param category_id query string false "Only products of this category"
+// @Param reorder_only query bool false "Only products that need reordering"
+// @Success 200 {object} response.Response{data=inventory.ForecastResponse}
+// @Failure 400 {object} response.Response
+// @Router /inventory/forecast [get]
+func (h *InventoryHandler) GetForecast(c *gin.Context) {
+	var req inventory.ForecastRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.inventoryUseCase.GetForecast(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to forecast demand", "error", err)
+		response.InternalError(c, "Failed to forecast demand", err.Error())
+		return
+	}
+
+	response.Success(c, "Demand forecast retrieved successfully", result)
+}
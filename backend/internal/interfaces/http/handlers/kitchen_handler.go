@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/transaction"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KitchenHandler struct {
+	kitchenUseCase *transaction.KitchenUseCase
+	logger         logger.Logger
+}
+
+func NewKitchenHandler(kitchenUseCase *transaction.KitchenUseCase, logger logger.Logger) *KitchenHandler {
+	return &KitchenHandler{
+		kitchenUseCase: kitchenUseCase,
+		logger:         logger,
+	}
+}
+
+// UpdateItemStatus godoc
+// @Summary Update item preparation status
+// @Description Mark an item of an order as preparing or ready. The order is pushed to the kitchen channel, as ready once every item is.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param item_id path string true "Transaction item ID"
+// @Param request body transaction.UpdateItemStatusRequest true "Preparation status"
+// @Success 200 {object} response.Response{data=transaction.KitchenOrder}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /orders/{id}/items/{item_id}/status [put]
+func (h *KitchenHandler) UpdateItemStatus(c *gin.Context) {
+	id := c.Param("id")
+	itemID := c.Param("item_id")
+
+	var req transaction.UpdateItemStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.kitchenUseCase.UpdateItemStatus(c.Request.Context(), id, itemID, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrOrderNotFound) || errors.Is(err, appErrors.ErrOrderItemNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to update item preparation status", "error", err, "transaction_id", id, "item_id", itemID)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Item status updated successfully", result)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/merchant"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MerchantHandler struct {
+	merchantUseCase *merchant.MerchantUseCase
+	logger          logger.Logger
+}
+
+func NewMerchantHandler(merchantUseCase *merchant.MerchantUseCase, logger logger.Logger) *MerchantHandler {
+	return &MerchantHandler{
+		merchantUseCase: merchantUseCase,
+		logger:          logger,
+	}
+}
+
+// GetMerchant godoc
+// @Summary Get the merchant profile
+// @Description Get the business profile printed on receipts and reports. The Midtrans server key is never returned, midtrans_configured tells whether one is set.
+// @Tags merchant
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=merchant.MerchantResponse}
+// @Failure 404 {object} response.Response
+// @Router /merchant [get]
+func (h *MerchantHandler) GetMerchant(c *gin.Context) {
+	result, err := h.merchantUseCase.GetProfile(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, appErrors.ErrMerchantNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get merchant", "error", err)
+		response.InternalError(c, "Failed to get merchant", err.Error())
+		return
+	}
+
+	response.Success(c, "Merchant retrieved successfully", result)
+}
+
+// OnboardMerchant godoc
+// @Summary Onboard the merchant
+// @Description Set up the business profile once (Admin only). Receipts, reports and the customer_details of QRIS charges then use it instead of the STORE_* configuration, and payments go through its Midtrans account when a server key is given.
+// @Tags merchant
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body merchant.MerchantRequest true "Merchant profile"
+// @Success 201 {object} response.Response{data=merchant.MerchantResponse}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /merchant [post]
+func (h *MerchantHandler) OnboardMerchant(c *gin.Context) {
+	var req merchant.MerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.merchantUseCase.Onboard(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrMerchantOnboarded):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to onboard merchant", "error", err)
+			response.InternalError(c, "Failed to onboard merchant", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Merchant onboarded successfully", result)
+}
+
+// UpdateMerchant godoc
+// @Summary Update the merchant profile
+// @Description Change the business profile (Admin only). The Midtrans server key is kept when omitted and an empty one goes back to the configured account. Changes apply right away, other instances pick them up within JOB_SETTINGS_RELOAD_INTERVAL_SECONDS.
+// @Tags merchant
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body merchant.MerchantRequest true "Merchant profile"
+// @Success 200 {object} response.Response{data=merchant.MerchantResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /merchant [put]
+func (h *MerchantHandler) UpdateMerchant(c *gin.Context) {
+	var req merchant.MerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.merchantUseCase.UpdateProfile(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrMerchantNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to update merchant", "error", err)
+			response.InternalError(c, "Failed to update merchant", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Merchant updated successfully", result)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/notification"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationUseCase *notification.NotificationUseCase
+	logger              logger.Logger
+}
+
+func NewNotificationHandler(notificationUseCase *notification.NotificationUseCase, logger logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationUseCase: notificationUseCase,
+		logger:              logger,
+	}
+}
+
+// SendReceipt godoc
+// @Summary Send digital receipt
+// @Description Queue the receipt of a paid transaction for delivery by email or WhatsApp
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body notification.SendReceiptRequest true "Receipt recipient"
+// @Success 202 {object} response.Response{data=entities.ReceiptDelivery}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/receipt/send [post]
+func (h *NotificationHandler) SendReceipt(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var req notification.SendReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.notificationUseCase.SendReceipt(c.Request.Context(), transactionID, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Accepted(c, "Receipt queued for delivery", result)
+}
+
+// ListReceiptDeliveries godoc
+// @Summary List receipt deliveries
+// @Description List the digital receipt deliveries of a transaction and their status
+// @Tags transactions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} response.Response{data=[]entities.ReceiptDelivery}
+// @Router /transactions/{id}/receipt/deliveries [get]
+func (h *NotificationHandler) ListReceiptDeliveries(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	result, err := h.notificationUseCase.ListReceiptDeliveries(c.Request.Context(), transactionID)
+	if err != nil {
+		h.logger.Error("Failed to list receipt deliveries", "error", err, "transaction_id", transactionID)
+		response.InternalError(c, "Failed to retrieve receipt deliveries", err.Error())
+		return
+	}
+
+	response.Success(c, "Receipt deliveries retrieved successfully", result)
+}
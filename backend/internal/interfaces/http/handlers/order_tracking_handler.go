@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"qris-pos-backend/internal/usecases/transaction"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderTrackingHandler struct {
+	orderTrackingUseCase *transaction.OrderTrackingUseCase
+	logger               logger.Logger
+}
+
+func NewOrderTrackingHandler(orderTrackingUseCase *transaction.OrderTrackingUseCase, logger logger.Logger) *OrderTrackingHandler {
+	return &OrderTrackingHandler{
+		orderTrackingUseCase: orderTrackingUseCase,
+		logger:               logger,
+	}
+}
+
+// UpdateFulfillment godoc
+// @Summary Update kitchen status
+// @Description Move a paid order forward to preparing, ready or completed. The change is pushed to the order status page.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body transaction.UpdateFulfillmentRequest true "Kitchen status"
+// @Success 200 {object} response.Response{data=transaction.OrderTrackingResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/fulfillment [put]
+func (h *OrderTrackingHandler) UpdateFulfillment(c *gin.Context) {
+	id := c.Param("id")
+
+	var req transaction.UpdateFulfillmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.orderTrackingUseCase.UpdateFulfillment(c.Request.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to update fulfillment status", "error", err, "transaction_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Order status updated successfully", result)
+}
+
+// GetOrderStatus godoc
+// @Summary Get order status
+// @Description Public status of a pickup or delivery order, looked up by the token of its tracking link
+// @Tags orders
+// @Produce json
+// @Param token path string true "Tracking token"
+// @Success 200 {object} response.Response{data=transaction.OrderTrackingResponse}
+// @Failure 404 {object} response.Response
+// @Router /orders/track/{token} [get]
+func (h *OrderTrackingHandler) GetOrderStatus(c *gin.Context) {
+	token := c.Param("token")
+
+	result, err := h.orderTrackingUseCase.GetOrderStatus(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrOrderNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get order status", "error", err)
+		response.InternalError(c, "Failed to get order status", err.Error())
+		return
+	}
+
+	response.Success(c, "Order status retrieved successfully", result)
+}
+
+// StreamOrderStatus godoc
+// @Summary Stream order status
+// @Description Stream the public status of an order as it is paid and prepared, using Server-Sent Events
+// @Tags orders
+// @Produce text/event-stream
+// @Param token path string true "Tracking token"
+// @Success 200 {object} transaction.OrderTrackingResponse
+// @Failure 404 {object} response.Response
+// @Router /orders/track/{token}/events [get]
+func (h *OrderTrackingHandler) StreamOrderStatus(c *gin.Context) {
+	token := c.Param("token")
+	ctx := c.Request.Context()
+
+	// Subscribe before reading the current status so no update is missed in between
+	updates, unsubscribe, err := h.orderTrackingUseCase.SubscribeOrderStatus(ctx, token)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrOrderNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to subscribe to order status", "error", err)
+		response.InternalError(c, "Failed to get order status", err.Error())
+		return
+	}
+	defer unsubscribe()
+
+	current, err := h.orderTrackingUseCase.GetOrderStatus(ctx, token)
+	if err != nil {
+		h.logger.Error("Failed to get order status for stream", "error", err)
+		response.InternalError(c, "Failed to get order status", err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("status", current)
+	c.Writer.Flush()
+	if current.IsFinal() {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Format(time.RFC3339))
+			return true
+		case _, ok := <-updates:
+			if !ok {
+				return false
+			}
+			status, err := h.orderTrackingUseCase.GetOrderStatus(ctx, token)
+			if err != nil {
+				h.logger.Error("Failed to reload order status for stream", "error", err)
+				return true
+			}
+			c.SSEvent("status", status)
+			return !status.IsFinal()
+		}
+	})
+}
@@ -1,8 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	infraPayment "qris-pos-backend/internal/infrastructure/payment"
+	"qris-pos-backend/internal/interfaces/middleware"
 	"qris-pos-backend/internal/usecases/payment"
+	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
 	"qris-pos-backend/pkg/response"
 	"qris-pos-backend/pkg/validator"
@@ -12,29 +21,38 @@ import (
 
 type PaymentHandler struct {
 	paymentUseCase *payment.PaymentUseCase
+	qrisQueue      *payment.QRISQueue
 	logger         logger.Logger
 }
 
-func NewPaymentHandler(paymentUseCase *payment.PaymentUseCase, logger logger.Logger) *PaymentHandler {
+func NewPaymentHandler(paymentUseCase *payment.PaymentUseCase, qrisQueue *payment.QRISQueue, logger logger.Logger) *PaymentHandler {
 	return &PaymentHandler{
 		paymentUseCase: paymentUseCase,
+		qrisQueue:      qrisQueue,
 		logger:         logger,
 	}
 }
 
 // GenerateQRIS godoc
 // @Summary Generate QRIS for transaction
-// @Description Generate a QRIS code for a pending transaction
+// @Description Generate a QRIS code for a pending transaction.
+// @Description The QRIS charges the balance due unless "amount" asks for less, which takes a deposit: the transaction stays pending with the rest open, shown as remaining_amount, until another payment covers it. More than the balance is refused with 422.
+// @Description With "async": true the gateway charge is queued instead and 202 returns a job, poll it at /qris/jobs/{id} or stream it from /qris/jobs/{id}/events.
 // @Tags payments
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param request body payment.GenerateQRISRequest true "QRIS generation data"
 // @Success 201 {object} response.Response{data=payment.PaymentResponse}
+// @Success 202 {object} response.Response{data=payment.QRISJob} "Generation queued"
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 404 {object} response.Response
-// @Router /payments/qris/generate [post]
+// @Failure 409 {object} response.Response{data=payment.PaymentResponse} "Transaction already has a pending QRIS, returned in data, or an item ran out of stock"
+// @Failure 410 {object} response.Response
+// @Failure 422 {object} response.Response
+// @Failure 503 {object} response.Response "Gateway unavailable, or the generation queue is full"
+// @Router /qris/generate [post]
 func (h *PaymentHandler) GenerateQRIS(c *gin.Context) {
 	var req payment.GenerateQRISRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -48,16 +66,105 @@ func (h *PaymentHandler) GenerateQRIS(c *gin.Context) {
 		return
 	}
 
+	if req.Async {
+		job, err := h.qrisQueue.Enqueue(c.Request.Context(), &req)
+		if err != nil {
+			h.handleGenerateQRISError(c, err, req.TransactionID)
+			return
+		}
+
+		c.Header("Location", "/api/v1/qris/jobs/"+job.ID)
+		response.Accepted(c, "QRIS generation queued", job)
+		return
+	}
+
 	result, err := h.paymentUseCase.GenerateQRIS(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("Failed to generate QRIS", "error", err, "transaction_id", req.TransactionID)
-		response.BadRequest(c, err.Error(), nil)
+		h.handleGenerateQRISError(c, err, req.TransactionID)
 		return
 	}
 
 	response.Created(c, "QRIS generated successfully", result)
 }
 
+// GetQRISJob godoc
+// @Summary Get a QRIS generation job
+// @Description Poll a queued QRIS generation, the QRIS is in payment once the job completed. Finished jobs are kept for a while only.
+// @Tags payments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Job ID"
+// @Success 200 {object} response.Response{data=payment.QRISJob}
+// @Failure 404 {object} response.Response
+// @Router /qris/jobs/{id} [get]
+func (h *PaymentHandler) GetQRISJob(c *gin.Context) {
+	job, err := h.qrisQueue.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "QRIS generation job retrieved successfully", job)
+}
+
+// StreamQRISJobEvents godoc
+// @Summary Stream a QRIS generation job
+// @Description Stream the updates of a queued QRIS generation using Server-Sent Events, the stream ends once the job completed or failed
+// @Tags payments
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Param id path string true "Job ID"
+// @Success 200 {object} payment.QRISJob
+// @Failure 404 {object} response.Response
+// @Router /qris/jobs/{id}/events [get]
+func (h *PaymentHandler) StreamQRISJobEvents(c *gin.Context) {
+	id := c.Param("id")
+
+	// Subscribe before reading the job so no update is missed in between
+	updates, unsubscribe := h.qrisQueue.SubscribeJob(id)
+	defer unsubscribe()
+
+	current, err := h.qrisQueue.GetJob(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("job", current)
+	c.Writer.Flush()
+	if current.IsFinished() {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Format(time.RFC3339))
+			return true
+		case event, ok := <-updates:
+			if !ok {
+				return false
+			}
+			job, ok := event.Data.(*payment.QRISJob)
+			if !ok {
+				return true
+			}
+			c.SSEvent("job", job)
+			return !job.IsFinished()
+		}
+	})
+}
+
 // GetPaymentStatus godoc
 // @Summary Get payment status
 // @Description Get the status of a payment for a transaction
@@ -71,6 +178,7 @@ func (h *PaymentHandler) GenerateQRIS(c *gin.Context) {
 // @Failure 401 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /payments/{transaction_id}/status [get]
+// @Router /qris/{transaction_id}/status [get]
 func (h *PaymentHandler) GetPaymentStatus(c *gin.Context) {
 	transactionID := c.Param("transaction_id")
 
@@ -84,6 +192,129 @@ func (h *PaymentHandler) GetPaymentStatus(c *gin.Context) {
 	response.Success(c, "Payment status retrieved successfully", result)
 }
 
+// GetQRIS godoc
+// @Summary Get the QRIS of a transaction
+// @Description Get the current QRIS payment of a transaction, with the seconds remaining before it expires computed by the server
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param transaction_id path string true "Transaction ID"
+// @Success 200 {object} response.Response{data=payment.PaymentResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /qris/{transaction_id} [get]
+func (h *PaymentHandler) GetQRIS(c *gin.Context) {
+	transactionID := c.Param("transaction_id")
+
+	result, err := h.paymentUseCase.GetQRIS(c.Request.Context(), transactionID)
+	if err != nil {
+		h.logger.Error("Failed to get QRIS", "error", err, "transaction_id", transactionID)
+		if errors.Is(err, appErrors.ErrPaymentNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to retrieve QRIS", err.Error())
+		return
+	}
+
+	response.Success(c, "QRIS retrieved successfully", result)
+}
+
+// GetQRISImage godoc
+// @Summary Render the QR of a transaction's QRIS
+// @Description Render the current QRIS of a transaction on the server, for clients that can't draw a QR themselves.
+// @Description PNG and SVG are returned as images, data-uri as JSON. The ETag changes when the QRIS is refreshed and the response may be cached until it expires.
+// @Tags payments
+// @Produce png
+// @Produce image/svg+xml
+// @Produce json
+// @Security ApiKeyAuth
+// @Param transaction_id path string true "Transaction ID"
+// @Param size query int false "Width and height in pixels" default(256) minimum(128) maximum(1024)
+// @Param format query string false "Image format" Enums(png, svg, data-uri) default(png)
+// @Success 200 {object} response.Response{data=payment.QRISImage} "data-uri format"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response "Already paid"
+// @Failure 410 {object} response.Response "QRIS expired, refresh it"
+// @Router /qris/{transaction_id}/image [get]
+func (h *PaymentHandler) GetQRISImage(c *gin.Context) {
+	transactionID := c.Param("transaction_id")
+
+	var req payment.QRISImageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	image, err := h.paymentUseCase.RenderQRIS(c.Request.Context(), transactionID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrPaymentNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrAlreadyPaid):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrQRISExpired):
+			response.Gone(c, err.Error())
+		default:
+			h.logger.Error("Failed to render QRIS", "error", err, "transaction_id", transactionID)
+			response.InternalError(c, "Failed to render QRIS", err.Error())
+		}
+		return
+	}
+
+	// The QR of a QRIS never changes, a refresh issues a new one with another ETag
+	c.Header("ETag", image.ETag)
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", max(int(time.Until(image.ExpiresAt).Seconds()), 0)))
+	c.Header("Expires", image.ExpiresAt.UTC().Format(http.TimeFormat))
+	if c.GetHeader("If-None-Match") == image.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if image.Format == "data-uri" {
+		response.Success(c, "QRIS rendered successfully", image)
+		return
+	}
+	c.Data(http.StatusOK, image.ContentType, image.Data)
+}
+
+// GetPaymentStatuses godoc
+// @Summary Get payment statuses in batch
+// @Description Get the payment status of up to 50 transactions in one call, for dashboards monitoring open payments.
+// @Description Pending QRIS payments are requeried on Midtrans in parallel. Transactions that can't be checked carry an error instead of a status.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body payment.BatchPaymentStatusRequest true "Transaction IDs"
+// @Success 200 {object} response.Response{data=payment.BatchPaymentStatusResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /qris/status/batch [post]
+func (h *PaymentHandler) GetPaymentStatuses(c *gin.Context) {
+	var req payment.BatchPaymentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result := h.paymentUseCase.GetPaymentStatuses(c.Request.Context(), &req)
+	response.Success(c, "Payment statuses retrieved successfully", result)
+}
+
 // RefreshQRIS godoc
 // @Summary Refresh QRIS code
 // @Description Refresh an expired QRIS code for a transaction
@@ -96,6 +327,7 @@ func (h *PaymentHandler) GetPaymentStatus(c *gin.Context) {
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response "Transaction is on hold, an item ran out of stock, or the payment changed meanwhile"
 // @Router /qris/{transaction_id}/refresh [post]
 func (h *PaymentHandler) RefreshQRIS(c *gin.Context) {
 	transactionID := c.Param("transaction_id")
@@ -107,6 +339,13 @@ func (h *PaymentHandler) RefreshQRIS(c *gin.Context) {
 	result, err := h.paymentUseCase.RefreshQRIS(c.Request.Context(), transactionID)
 	if err != nil {
 		h.logger.Error("Failed to refresh QRIS", "error", err, "transaction_id", transactionID)
+		if errors.Is(err, appErrors.ErrTransactionHeld) || errors.Is(err, appErrors.ErrInsufficientStock) || errors.Is(err, appErrors.ErrIllegalPaymentTransition) {
+			response.Conflict(c, err.Error())
+			return
+		}
+		if h.handleAmountLimitError(c, err) {
+			return
+		}
 		response.BadRequest(c, err.Error(), nil)
 		return
 	}
@@ -114,49 +353,597 @@ func (h *PaymentHandler) RefreshQRIS(c *gin.Context) {
 	response.Success(c, "QRIS refreshed successfully", result)
 }
 
+// ConfirmLocalQRIS godoc
+// @Summary Confirm local QRIS payment
+// @Description Confirm a locally generated QRIS payment once the transfer shows up in the merchant's account
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param transaction_id path string true "Transaction ID"
+// @Param request body payment.ConfirmQRISRequest true "Payment reference"
+// @Success 200 {object} response.Response{data=payment.PaymentResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /qris/{transaction_id}/confirm [post]
+func (h *PaymentHandler) ConfirmLocalQRIS(c *gin.Context) {
+	transactionID := c.Param("transaction_id")
+
+	var req payment.ConfirmQRISRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.paymentUseCase.ConfirmLocalQRIS(c.Request.Context(), transactionID, currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to confirm QRIS payment", "error", err, "transaction_id", transactionID)
+		switch {
+		case errors.Is(err, appErrors.ErrPaymentNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrAlreadyPaid), errors.Is(err, appErrors.ErrIllegalPaymentTransition):
+			response.Conflict(c, err.Error())
+		default:
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(c, "QRIS payment confirmed successfully", result)
+}
+
+// PayWithCash godoc
+// @Summary Pay with cash
+// @Description Settle a pending transaction with cash and calculate the change
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body payment.CashPaymentRequest true "Cash payment"
+// @Success 201 {object} response.Response{data=payment.PaymentResponse}
+// @Failure 400 {object} response.Response
+// @Router /payments/cash [post]
+func (h *PaymentHandler) PayWithCash(c *gin.Context) {
+	var req payment.CashPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.paymentUseCase.PayWithCash(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to process cash payment", "error", err, "transaction_id", req.TransactionID)
+		h.handleOfflinePaymentError(c, err)
+		return
+	}
+
+	response.Created(c, "Cash payment recorded successfully", result)
+}
+
+// PayWithManualMethod godoc
+// @Summary Pay with card or other method
+// @Description Settle a pending transaction with a card (EDC) or other payment confirmed at the terminal
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body payment.ManualPaymentRequest true "Manual payment"
+// @Success 201 {object} response.Response{data=payment.PaymentResponse}
+// @Failure 400 {object} response.Response
+// @Router /payments/manual [post]
+func (h *PaymentHandler) PayWithManualMethod(c *gin.Context) {
+	var req payment.ManualPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.paymentUseCase.PayWithManualMethod(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to process manual payment", "error", err, "transaction_id", req.TransactionID, "method", req.Method)
+		h.handleOfflinePaymentError(c, err)
+		return
+	}
+
+	response.Created(c, "Payment recorded successfully", result)
+}
+
+// PayWithEWallet godoc
+// @Summary Pay with GoPay or ShopeePay
+// @Description Charge a pending transaction to an e-wallet and return the deeplink that opens the wallet app on the customer's phone. GoPay also returns a QRIS for paying from another phone. Poll the payment status or stream payment events until it settles.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body payment.EWalletPaymentRequest true "E-wallet payment"
+// @Success 201 {object} response.Response{data=payment.PaymentResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /payments/ewallet [post]
+func (h *PaymentHandler) PayWithEWallet(c *gin.Context) {
+	var req payment.EWalletPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.paymentUseCase.PayWithEWallet(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to charge e-wallet", "error", err, "transaction_id", req.TransactionID, "wallet", req.Wallet)
+		h.handleOfflinePaymentError(c, err)
+		return
+	}
+
+	response.Created(c, "E-wallet payment created successfully", result)
+}
+
+func (h *PaymentHandler) handleOfflinePaymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, appErrors.ErrTransactionNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrAlreadyPaid), errors.Is(err, appErrors.ErrTransactionHeld), errors.Is(err, appErrors.ErrInsufficientStock),
+		errors.Is(err, appErrors.ErrIllegalPaymentTransition):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, appErrors.ErrUnsupportedCurrency):
+		response.UnprocessableEntity(c, err.Error(), nil)
+	case errors.Is(err, appErrors.ErrGatewayUnavailable):
+		response.ServiceUnavailable(c, "Payment gateway is unavailable, try again shortly or use another payment method")
+	default:
+		response.BadRequest(c, err.Error(), nil)
+	}
+}
+
+// sseHeartbeatInterval keeps idle event streams alive through proxies
+const sseHeartbeatInterval = 15 * time.Second
+
+// StreamPaymentEvents godoc
+// @Summary Stream payment status
+// @Description Stream real-time payment status updates for a transaction using Server-Sent Events
+// @Tags payments
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Param transaction_id path string true "Transaction ID"
+// @Success 200 {object} payment.PaymentStatusResponse
+// @Failure 404 {object} response.Response
+// @Router /qris/{transaction_id}/events [get]
+func (h *PaymentHandler) StreamPaymentEvents(c *gin.Context) {
+	transactionID := c.Param("transaction_id")
+	if transactionID == "" {
+		response.BadRequest(c, "Transaction ID is required", nil)
+		return
+	}
+
+	// Subscribe before reading the current status so no update is missed in between
+	updates, unsubscribe := h.paymentUseCase.SubscribePaymentStatus(transactionID)
+	defer unsubscribe()
+
+	current, err := h.paymentUseCase.GetPaymentStatus(c.Request.Context(), transactionID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrPaymentNotFound) {
+			response.NotFound(c, "Payment not found")
+			return
+		}
+		h.logger.Error("Failed to get payment status for stream", "error", err, "transaction_id", transactionID)
+		response.InternalError(c, "Failed to get payment status", err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("status", current)
+	c.Writer.Flush()
+	if isFinalPaymentStatus(current.Status) {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Format(time.RFC3339))
+			return true
+		case event, ok := <-updates:
+			if !ok {
+				return false
+			}
+			status, ok := event.Data.(*payment.PaymentStatusResponse)
+			if !ok {
+				return true
+			}
+			c.SSEvent("status", status)
+			return !isFinalPaymentStatus(status.Status)
+		}
+	})
+}
+
+// isFinalPaymentStatus reports whether no further updates are expected.
+// Expired payments can still be refreshed, so the stream stays open for them.
+func isFinalPaymentStatus(status entities.PaymentStatus) bool {
+	return status == entities.PaymentSuccess ||
+		status == entities.PaymentFailed ||
+		status == entities.PaymentCancelled
+}
+
 // PaymentCallback godoc
-// @Summary Payment callback from Midtrans
-// @Description Handle payment notification from Midtrans
+// @Summary Payment callback from a gateway
+// @Description Handle a payment notification from Midtrans, or from the gateway named in the path.
+// @Description Midtrans notifications must carry a valid signature_key and Xendit ones the callback token. Callers can be limited to PAYMENT_CALLBACK_ALLOWED_IPS.
+// @Description Every callback is stored in the payment notifications log. One that fails to apply is still acknowledged and retried in the background.
 // @Tags payments
 // @Accept json
 // @Produce json
-// @Param request body map[string]interface{} true "Midtrans notification data"
+// @Param provider path string false "Gateway (midtrans, xendit), Midtrans when omitted"
+// @Param request body map[string]interface{} true "Gateway notification data"
 // @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response "Signature or callback token check failed"
+// @Failure 403 {object} response.Response "Caller is not on the allow-list"
 // @Router /payments/callback [post]
+// @Router /payments/callback/{provider} [post]
 func (h *PaymentHandler) PaymentCallback(c *gin.Context) {
-	// Parse the notification data from Midtrans
-	var notification map[string]interface{}
-	if err := c.ShouldBindJSON(&notification); err != nil {
-		h.logger.Error("Failed to parse payment callback", "error", err)
+	provider := entities.PaymentProvider(c.Param("provider"))
+	if provider == "" {
+		provider = entities.ProviderMidtrans
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		h.logger.Error("Failed to read payment callback", "error", err, "provider", provider)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
 
-	// Extract required fields
-	orderID, ok := notification["order_id"].(string)
-	if !ok {
-		h.logger.Error("Missing order_id in payment callback")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing order_id"})
+	// Handle the payment notification
+	err = h.paymentUseCase.HandleGatewayCallback(c.Request.Context(), provider, c.Request.Header, body)
+	if err != nil {
+		// Forged or misconfigured callbacks are worth an alert, they never reach the payments
+		if errors.Is(err, infraPayment.ErrUnauthenticatedNotification) {
+			h.logger.Warn("Rejected unauthenticated payment callback", "error", err, "provider", provider, "client_ip", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid notification signature"})
+			return
+		}
+		h.logger.Error("Failed to handle payment notification", "error", err, "provider", provider)
+		if errors.Is(err, infraPayment.ErrInvalidNotification) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment notification"})
 		return
 	}
 
-	status, ok := notification["transaction_status"].(string)
-	if !ok {
-		h.logger.Error("Missing transaction_status in payment callback")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing transaction_status"})
+	c.JSON(http.StatusOK, gin.H{"message": "Payment notification processed successfully"})
+}
+
+// ListPaymentHistory godoc
+// @Summary List the status history of a payment
+// @Description List every status change of a payment with who made it and why, oldest first (Admin only)
+// @Tags payments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param transaction_id path string true "Payment ID"
+// @Success 200 {object} response.Response{data=[]entities.PaymentEvent}
+// @Failure 404 {object} response.Response
+// @Router /payments/{transaction_id}/history [get]
+func (h *PaymentHandler) ListPaymentHistory(c *gin.Context) {
+	paymentID := c.Param(paymentIDParam)
+
+	result, err := h.paymentUseCase.ListPaymentHistory(c.Request.Context(), paymentID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrPaymentNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to list payment history", "error", err, "payment_id", paymentID)
+		response.InternalError(c, "Failed to retrieve payment history", err.Error())
 		return
 	}
 
-	externalID, _ := notification["transaction_id"].(string)
-	responseData, _ := notification["response"].(string)
+	response.Success(c, "Payment history retrieved successfully", result)
+}
 
-	// Handle the payment notification
-	err := h.paymentUseCase.HandlePaymentNotification(c.Request.Context(), orderID, status, externalID, responseData)
+// ListPaymentExceptions godoc
+// @Summary List payment exceptions
+// @Description List late, duplicate or unmatched payments that need a refund or acknowledgement (Admin only)
+// @Tags payments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Exception status (open, acknowledged, refunded)"
+// @Param reason query string false "Exception reason (late_payment, duplicate_payment, unknown_order, void_refund, stock_unavailable)"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.PaymentException}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /payment-exceptions [get]
+func (h *PaymentHandler) ListPaymentExceptions(c *gin.Context) {
+	var filters payment.PaymentExceptionFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.paymentUseCase.ListPaymentExceptions(c.Request.Context(), &filters)
 	if err != nil {
-		h.logger.Error("Failed to handle payment notification", "error", err, "order_id", orderID)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment notification"})
+		h.logger.Error("Failed to list payment exceptions", "error", err)
+		response.InternalError(c, "Failed to retrieve payment exceptions", err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Payment notification processed successfully"})
+	response.Success(c, "Payment exceptions retrieved successfully", result)
+}
+
+// ResolvePaymentException godoc
+// @Summary Resolve a payment exception
+// @Description Mark a payment exception as refunded or acknowledged (Admin only)
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Payment exception ID"
+// @Param request body payment.ResolvePaymentExceptionRequest true "Resolution"
+// @Success 200 {object} response.Response{data=entities.PaymentException}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /payment-exceptions/{id}/resolve [post]
+func (h *PaymentHandler) ResolvePaymentException(c *gin.Context) {
+	id := c.Param("id")
+
+	var req payment.ResolvePaymentExceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.paymentUseCase.ResolvePaymentException(c.Request.Context(), id, currentUser.UserID, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrPaymentExceptionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to resolve payment exception", "error", err, "exception_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Payment exception resolved successfully", result)
+}
+
+// ListPaymentNotifications godoc
+// @Summary List payment notifications
+// @Description List the gateway callbacks as received, newest first, with the outcome of applying them. Failed ones ran out of retries and can be replayed (Admin only)
+// @Tags payments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Filter by status" Enums(received, processed, retrying, failed, rejected)
+// @Param provider query string false "Filter by gateway" Enums(midtrans, xendit)
+// @Param order_id query string false "Filter by gateway order ID"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.PaymentNotification}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /payment-notifications [get]
+func (h *PaymentHandler) ListPaymentNotifications(c *gin.Context) {
+	var filters payment.PaymentNotificationFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.paymentUseCase.ListPaymentNotifications(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list payment notifications", "error", err)
+		response.InternalError(c, "Failed to retrieve payment notifications", err.Error())
+		return
+	}
+
+	response.Success(c, "Payment notifications retrieved successfully", result)
+}
+
+// ReplayPaymentNotification godoc
+// @Summary Replay a payment notification
+// @Description Apply a stored gateway callback again, e.g. one out of retries once the cause is fixed. Replaying a processed one changes nothing; rejected ones cannot be replayed (Admin only)
+// @Tags payments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Payment notification ID"
+// @Success 200 {object} response.Response{data=entities.PaymentNotification} "Replayed, status tells whether it was applied or is retried again"
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response "Notification was rejected"
+// @Router /payment-notifications/{id}/replay [post]
+func (h *PaymentHandler) ReplayPaymentNotification(c *gin.Context) {
+	id := c.Param("id")
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.paymentUseCase.ReplayPaymentNotification(c.Request.Context(), id, currentUser.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrNotificationNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrNotificationRejected):
+			response.Conflict(c, err.Error())
+		default:
+			h.logger.Error("Failed to replay payment notification", "error", err, "notification_id", id)
+			response.InternalError(c, "Failed to replay payment notification", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Payment notification replayed", result)
+}
+
+// handleGenerateQRISError tells clients whether retrying makes sense: a gateway outage can be retried,
+// a duplicate already has a QRIS to show and an expired transaction needs a new order
+func (h *PaymentHandler) handleGenerateQRISError(c *gin.Context, err error, transactionID string) {
+	var duplicateErr *appErrors.DuplicatePaymentError
+	switch {
+	case errors.As(err, &duplicateErr):
+		h.logger.Info("Transaction already has a pending payment", "transaction_id", transactionID)
+		response.ConflictWithData(c, err.Error(), duplicateErr.Payment)
+	case errors.Is(err, appErrors.ErrTransactionNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrTransactionExpired):
+		response.Gone(c, err.Error())
+	case errors.Is(err, appErrors.ErrTransactionHeld), errors.Is(err, appErrors.ErrInsufficientStock), errors.Is(err, appErrors.ErrAlreadyPaid),
+		errors.Is(err, appErrors.ErrIllegalPaymentTransition):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, appErrors.ErrUnsupportedCurrency), errors.Is(err, appErrors.ErrAmountExceedsDue):
+		response.UnprocessableEntity(c, err.Error(), nil)
+	case errors.Is(err, appErrors.ErrGatewayUnavailable):
+		h.logger.Error("Payment gateway unavailable", "error", err, "transaction_id", transactionID)
+		response.ServiceUnavailable(c, "Payment gateway is unavailable, try again shortly or use another payment method")
+	case errors.Is(err, appErrors.ErrQRISQueueFull):
+		c.Header("Retry-After", "5")
+		response.ServiceUnavailable(c, err.Error())
+	default:
+		h.logger.Error("Failed to generate QRIS", "error", err, "transaction_id", transactionID)
+		if h.handleAmountLimitError(c, err) {
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+	}
+}
+
+// handleAmountLimitError writes a 422 response when the amount is outside QRIS limits
+func (h *PaymentHandler) handleAmountLimitError(c *gin.Context, err error) bool {
+	var limitErr *appErrors.AmountLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+
+	response.UnprocessableEntity(c, limitErr.Error(), gin.H{
+		"code":                         "AMOUNT_OUT_OF_RANGE",
+		"method":                       limitErr.Method,
+		"amount":                       limitErr.Amount,
+		"min_amount":                   limitErr.Min,
+		"max_amount":                   limitErr.Max,
+		"alternative_payment_required": limitErr.AboveMaximum(),
+		"alternative_methods":          []entities.PaymentMethod{entities.PaymentMethodCash, entities.PaymentMethodCard, entities.PaymentMethodOther},
+	})
+	return true
+}
+
+// ListPayments godoc
+// @Summary List payments
+// @Description Browse the payments of the current store, or of every store for admins working without one, newest first. With format=csv every matching payment is downloaded and limit and offset are ignored. Payments of practice sales are only listed with training=true.
+// @Tags payments
+// @Produce json
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param status query string false "Status" Enums(pending, success, failed, expired, cancelled)
+// @Param method query string false "Method" Enums(qris, cash, card, other, ewallet)
+// @Param store_id query string false "Store ID"
+// @Param date_from query string false "Created from (YYYY-MM-DD)"
+// @Param date_to query string false "Created until, inclusive (YYYY-MM-DD)"
+// @Param min_amount query int false "Minimum amount"
+// @Param max_amount query int false "Maximum amount"
+// @Param training query bool false "List the payments of practice sales instead"
+// @Param format query string false "Format" Enums(json, csv) default(json)
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]payment.PaymentResponse}
+// @Failure 400 {object} response.Response
+// @Router /payments [get]
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	var req payment.ListPaymentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	if req.Format == payment.FormatCSV {
+		if err := h.paymentUseCase.ValidatePaymentFilters(&req); err != nil {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="payments-%s.csv"`, time.Now().Format("20060102")))
+		c.Status(http.StatusOK)
+
+		// The file is streamed, once it started a failure can only cut it short
+		if err := h.paymentUseCase.ExportPayments(c.Request.Context(), c.Writer, &req); err != nil {
+			h.logger.Error("Failed to export payments", "error", err)
+			c.Error(err)
+		}
+		return
+	}
+
+	result, err := h.paymentUseCase.ListPayments(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		response.InternalError(c, "Failed to list payments", err.Error())
+		return
+	}
+
+	response.Success(c, "Payments retrieved successfully", result)
 }
@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/interfaces/http/handlers"
+	"qris-pos-backend/internal/usecases/payment/paymenttest"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGenerateQRISStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		arrange func(f *paymenttest.Fixture, transaction *entities.Transaction)
+		amount  int64
+		want    int
+	}{
+		{
+			name:    "issued",
+			arrange: func(f *paymenttest.Fixture, transaction *entities.Transaction) {},
+			want:    http.StatusCreated,
+		},
+		{
+			name: "pending QRIS already issued",
+			arrange: func(f *paymenttest.Fixture, transaction *entities.Transaction) {
+				f.AddPayment(entities.NewPayment(transaction.ID, transaction.TotalAmount, 15))
+			},
+			want: http.StatusConflict,
+		},
+		{
+			name: "gateway outage",
+			arrange: func(f *paymenttest.Fixture, transaction *entities.Transaction) {
+				f.Gateway.Err = &appErrors.GatewayUnavailableError{Provider: "midtrans", Err: errors.New("circuit open")}
+			},
+			want: http.StatusServiceUnavailable,
+		},
+		{
+			name: "transaction expired",
+			arrange: func(f *paymenttest.Fixture, transaction *entities.Transaction) {
+				transaction.Status = entities.StatusExpired
+				f.Transactions.Add(transaction)
+			},
+			want: http.StatusGone,
+		},
+		{
+			name:    "more than the balance",
+			arrange: func(f *paymenttest.Fixture, transaction *entities.Transaction) {},
+			amount:  60000,
+			want:    http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := paymenttest.New()
+			transaction := f.AddTransaction(50000)
+			tt.arrange(f, transaction)
+
+			router := gin.New()
+			router.POST("/qris/generate", handlers.NewPaymentHandler(f.UseCase, nil, logger.NewLogger("error")).GenerateQRIS)
+
+			body, _ := json.Marshal(map[string]any{"transaction_id": transaction.ID, "amount": tt.amount})
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/qris/generate", bytes.NewReader(body)))
+
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+		})
+	}
+}
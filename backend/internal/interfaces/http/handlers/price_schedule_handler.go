@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/product"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceScheduleHandler struct {
+	priceScheduleUseCase *product.PriceScheduleUseCase
+	logger               logger.Logger
+}
+
+func NewPriceScheduleHandler(priceScheduleUseCase *product.PriceScheduleUseCase, logger logger.Logger) *PriceScheduleHandler {
+	return &PriceScheduleHandler{
+		priceScheduleUseCase: priceScheduleUseCase,
+		logger:               logger,
+	}
+}
+
+// CreatePriceSchedule godoc
+// @Summary Create a price schedule
+// @Description Schedule a product price from a start time (Admin only). With days of the week or an end the
+// @Description product is sold at the price on those days until the end, such as weekend pricing, and at its
+// @Description list price in between. Without either the price becomes the list price once it starts.
+// @Tags price-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body product.PriceScheduleRequest true "Price schedule data"
+// @Success 201 {object} response.Response{data=entities.PriceSchedule}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /price-schedules [post]
+func (h *PriceScheduleHandler) CreatePriceSchedule(c *gin.Context) {
+	var req product.PriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.priceScheduleUseCase.CreatePriceSchedule(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create price schedule", "error", err)
+		h.handleError(c, err)
+		return
+	}
+
+	response.Created(c, "Price schedule created successfully", result)
+}
+
+// GetPriceSchedule godoc
+// @Summary Get price schedule by ID
+// @Description Get a single price schedule (Admin only)
+// @Tags price-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Price schedule ID"
+// @Success 200 {object} response.Response{data=entities.PriceSchedule}
+// @Failure 404 {object} response.Response
+// @Router /price-schedules/{id} [get]
+func (h *PriceScheduleHandler) GetPriceSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.priceScheduleUseCase.GetPriceSchedule(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get price schedule", "error", err, "price_schedule_id", id)
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, "Price schedule retrieved successfully", result)
+}
+
+// UpdatePriceSchedule godoc
+// @Summary Update a price schedule
+// @Description Change a pending or in effect price schedule (Admin only). A schedule in effect puts the
+// @Description product back to its list price until the changed schedule is applied.
+// @Tags price-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Price schedule ID"
+// @Param request body product.PriceScheduleRequest true "Price schedule data"
+// @Success 200 {object} response.Response{data=entities.PriceSchedule}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /price-schedules/{id} [put]
+func (h *PriceScheduleHandler) UpdatePriceSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	var req product.PriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.priceScheduleUseCase.UpdatePriceSchedule(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update price schedule", "error", err, "price_schedule_id", id)
+		h.handleError(c, err)
+		return
+	}
+
+	response.Success(c, "Price schedule updated successfully", result)
+}
+
+// DeletePriceSchedule godoc
+// @Summary Delete a price schedule
+// @Description Delete a price schedule. A schedule in effect puts the product back to its list price. (Admin only)
+// @Tags price-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Price schedule ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /price-schedules/{id} [delete]
+func (h *PriceScheduleHandler) DeletePriceSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.priceScheduleUseCase.DeletePriceSchedule(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete price schedule", "error", err, "price_schedule_id", id)
+		if errors.Is(err, appErrors.ErrPriceScheduleNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to delete price schedule", err.Error())
+		return
+	}
+
+	response.Success(c, "Price schedule deleted successfully", nil)
+}
+
+// ListPriceSchedules godoc
+// @Summary List price schedules
+// @Description Get the price schedules of the store, latest start first (Admin only)
+// @Tags price-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param product_id query string false "Filter by product ID"
+// @Param status query string false "Filter by status" Enums(pending, in_effect, completed)
+// @Param limit query int false "Maximum number of schedules" default(50)
+// @Param offset query int false "Number of schedules to skip" default(0)
+// @Success 200 {object} response.Response{data=[]entities.PriceSchedule}
+// @Failure 400 {object} response.Response
+// @Router /price-schedules [get]
+func (h *PriceScheduleHandler) ListPriceSchedules(c *gin.Context) {
+	var filters product.PriceScheduleFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.priceScheduleUseCase.ListPriceSchedules(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list price schedules", "error", err)
+		response.InternalError(c, "Failed to retrieve price schedules", err.Error())
+		return
+	}
+
+	response.Success(c, "Price schedules retrieved successfully", result)
+}
+
+func (h *PriceScheduleHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, appErrors.ErrPriceScheduleNotFound), errors.Is(err, appErrors.ErrProductNotFound):
+		response.NotFound(c, err.Error())
+	default:
+		response.BadRequest(c, err.Error(), nil)
+	}
+}
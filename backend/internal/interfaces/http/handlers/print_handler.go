@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/printing"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// printerTokenHeader carries the agent token of a printer on the print agent endpoints
+const printerTokenHeader = "X-Printer-Token"
+
+type PrintHandler struct {
+	printUseCase *printing.PrintUseCase
+	logger       logger.Logger
+}
+
+func NewPrintHandler(printUseCase *printing.PrintUseCase, logger logger.Logger) *PrintHandler {
+	return &PrintHandler{
+		printUseCase: printUseCase,
+		logger:       logger,
+	}
+}
+
+// CreatePrinter godoc
+// @Summary Register a printer
+// @Description Register a networked thermal printer of the store. The response holds the agent token, it is shown only once. (Admin only)
+// @Tags printing
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body printing.PrinterRequest true "Printer data"
+// @Success 201 {object} response.Response{data=printing.PrinterTokenResponse}
+// @Failure 400 {object} response.Response
+// @Router /printers [post]
+func (h *PrintHandler) CreatePrinter(c *gin.Context) {
+	var req printing.PrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.printUseCase.CreatePrinter(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to register printer")
+		return
+	}
+
+	response.Created(c, "Printer registered successfully", result)
+}
+
+// ListPrinters godoc
+// @Summary List printers
+// @Description List the printers of the store with the last time their agent polled for jobs (Admin only)
+// @Tags printing
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=[]entities.Printer}
+// @Router /printers [get]
+func (h *PrintHandler) ListPrinters(c *gin.Context) {
+	result, err := h.printUseCase.ListPrinters(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err, "Failed to list printers")
+		return
+	}
+
+	response.Success(c, "Printers retrieved successfully", result)
+}
+
+// RotatePrinterToken godoc
+// @Summary Rotate a printer token
+// @Description Issue a new agent token for a printer. The agent holding the old token is locked out. (Admin only)
+// @Tags printing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Printer ID"
+// @Success 200 {object} response.Response{data=printing.PrinterTokenResponse}
+// @Failure 404 {object} response.Response
+// @Router /printers/{id}/rotate-token [post]
+func (h *PrintHandler) RotatePrinterToken(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.printUseCase.RotateToken(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to rotate printer token")
+		return
+	}
+
+	response.Success(c, "Printer token rotated successfully", result)
+}
+
+// DeletePrinter godoc
+// @Summary Delete a printer
+// @Description Remove a printer, its agent token stops working (Admin only)
+// @Tags printing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Printer ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /printers/{id} [delete]
+func (h *PrintHandler) DeletePrinter(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.printUseCase.DeletePrinter(c.Request.Context(), id); err != nil {
+		h.handleError(c, err, "Failed to delete printer")
+		return
+	}
+
+	response.Success(c, "Printer deleted successfully", nil)
+}
+
+// CreatePrintJob godoc
+// @Summary Queue a print job
+// @Description Queue the receipt of a transaction, or the QRIS of its pending payment, on a printer of the store
+// @Tags printing
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body printing.PrintJobRequest true "Print job"
+// @Success 201 {object} response.Response{data=entities.PrintJob}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 410 {object} response.Response
+// @Router /print-jobs [post]
+func (h *PrintHandler) CreatePrintJob(c *gin.Context) {
+	var req printing.PrintJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.printUseCase.CreateJob(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to queue print job")
+		return
+	}
+
+	response.Created(c, "Print job queued successfully", result)
+}
+
+// ListPrintJobs godoc
+// @Summary List print jobs
+// @Description List the print jobs of the store, newest first
+// @Tags printing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param printer_id query string false "Printer ID"
+// @Param transaction_id query string false "Transaction ID"
+// @Param status query string false "Status" Enums(queued, printing, printed, failed)
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Offset"
+// @Success 200 {object} response.Response{data=[]entities.PrintJob}
+// @Failure 400 {object} response.Response
+// @Router /print-jobs [get]
+func (h *PrintHandler) ListPrintJobs(c *gin.Context) {
+	var filters printing.PrintJobFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.printUseCase.ListJobs(c.Request.Context(), &filters)
+	if err != nil {
+		h.handleError(c, err, "Failed to list print jobs")
+		return
+	}
+
+	response.Success(c, "Print jobs retrieved successfully", result)
+}
+
+// GetPrintJob godoc
+// @Summary Get a print job
+// @Description Get a print job with its status, attempts and last printer error
+// @Tags printing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Print job ID"
+// @Success 200 {object} response.Response{data=entities.PrintJob}
+// @Failure 404 {object} response.Response
+// @Router /print-jobs/{id} [get]
+func (h *PrintHandler) GetPrintJob(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.printUseCase.GetJob(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get print job")
+		return
+	}
+
+	response.Success(c, "Print job retrieved successfully", result)
+}
+
+// ReprintJob godoc
+// @Summary Reprint a job
+// @Description Queue a printed or failed job again on the same printer, as a new job
+// @Tags printing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Print job ID"
+// @Success 201 {object} response.Response{data=entities.PrintJob}
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /print-jobs/{id}/reprint [post]
+func (h *PrintHandler) ReprintJob(c *gin.Context) {
+	id := c.Param("id")
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.printUseCase.Reprint(c.Request.Context(), id, currentUser.UserID)
+	if err != nil {
+		h.handleError(c, err, "Failed to reprint job")
+		return
+	}
+
+	response.Created(c, "Print job queued successfully", result)
+}
+
+// NextPrintJob godoc
+// @Summary Claim the next print job
+// @Description Long poll used by the print agent of a printer. Returns the next job with its ESC/POS content as soon as one is queued,
+// @Description or 204 when none arrived within the wait. Acknowledge the job once printed, otherwise it is handed out again after the lease.
+// @Tags print-agent
+// @Produce json
+// @Param X-Printer-Token header string true "Agent token of the printer"
+// @Param wait query int false "Seconds to wait for a job, capped by PRINT_MAX_WAIT_SECONDS" default(0)
+// @Success 200 {object} response.Response{data=printing.PrintJobContent}
+// @Success 204
+// @Failure 401 {object} response.Response
+// @Router /print-agent/jobs/next [get]
+func (h *PrintHandler) NextPrintJob(c *gin.Context) {
+	var req printing.NextJobRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	printer, err := h.printUseCase.AuthenticatePrinter(c.Request.Context(), c.GetHeader(printerTokenHeader))
+	if err != nil {
+		h.handleError(c, err, "Failed to authenticate printer")
+		return
+	}
+
+	result, err := h.printUseCase.NextJob(c.Request.Context(), printer, time.Duration(req.Wait)*time.Second)
+	if err != nil {
+		h.handleError(c, err, "Failed to claim print job")
+		return
+	}
+	if result == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	response.Success(c, "Print job claimed successfully", result)
+}
+
+// AckPrintJob godoc
+// @Summary Acknowledge a print job
+// @Description Report whether the printer printed a claimed job. A failed job is queued again until it runs out of attempts.
+// @Tags print-agent
+// @Accept json
+// @Produce json
+// @Param X-Printer-Token header string true "Agent token of the printer"
+// @Param id path string true "Print job ID"
+// @Param request body printing.PrintAckRequest true "Print outcome"
+// @Success 200 {object} response.Response{data=entities.PrintJob}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /print-agent/jobs/{id}/ack [post]
+func (h *PrintHandler) AckPrintJob(c *gin.Context) {
+	id := c.Param("id")
+
+	var req printing.PrintAckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	printer, err := h.printUseCase.AuthenticatePrinter(c.Request.Context(), c.GetHeader(printerTokenHeader))
+	if err != nil {
+		h.handleError(c, err, "Failed to authenticate printer")
+		return
+	}
+
+	result, err := h.printUseCase.Ack(c.Request.Context(), printer, id, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to acknowledge print job")
+		return
+	}
+
+	response.Success(c, "Print job acknowledged successfully", result)
+}
+
+func (h *PrintHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, appErrors.ErrInvalidPrinterToken):
+		response.Unauthorized(c, err.Error())
+	case errors.Is(err, appErrors.ErrPrinterNotFound), errors.Is(err, appErrors.ErrPrintJobNotFound),
+		errors.Is(err, appErrors.ErrTransactionNotFound), errors.Is(err, appErrors.ErrPaymentNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrPrinterInactive), errors.Is(err, appErrors.ErrPrintJobNotClaimed),
+		errors.Is(err, appErrors.ErrPrintJobNotFinished), errors.Is(err, appErrors.ErrAlreadyPaid):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, appErrors.ErrQRISExpired):
+		response.Gone(c, err.Error())
+	case errors.Is(err, appErrors.ErrStoreRequired), errors.Is(err, appErrors.ErrInvalidInput):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		h.logger.Error(message, "error", err)
+		response.InternalError(c, message, err.Error())
+	}
+}
@@ -1,9 +1,17 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
+	"time"
 
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/interfaces/middleware"
 	"qris-pos-backend/internal/usecases/product"
+	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
 	"qris-pos-backend/pkg/response"
 	"qris-pos-backend/pkg/validator"
@@ -25,11 +33,12 @@ func NewProductHandler(productUseCase *product.ProductUseCase, logger logger.Log
 
 // CreateProduct godoc
 // @Summary Create a new product
-// @Description Create a new product (Admin only)
+// @Description Create a new product in the store picked with the X-Store-ID header (Admin only)
 // @Tags products
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param X-Store-ID header string true "Store to add the product to"
 // @Param request body product.CreateProductRequest true "Product data"
 // @Success 201 {object} response.Response{data=product.ProductResponse}
 // @Failure 400 {object} response.Response
@@ -55,7 +64,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	response.Created(c, "Product created successfully", result)
+	response.Created(c, "Product created successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
 }
 
 // GetProduct godoc
@@ -78,7 +87,34 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, "Product retrieved successfully", result)
+	response.Success(c, "Product retrieved successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
+// GetProductByBarcode godoc
+// @Summary Get product by barcode
+// @Description Look up a product by an exact barcode scan, falling back to an exact SKU match
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param code path string true "Barcode or SKU"
+// @Success 200 {object} response.Response{data=product.ProductResponse}
+// @Failure 404 {object} response.Response
+// @Router /products/barcode/{code} [get]
+func (h *ProductHandler) GetProductByBarcode(c *gin.Context) {
+	code := c.Param("code")
+
+	result, err := h.productUseCase.GetProductByBarcode(c.Request.Context(), code)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrProductNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get product by barcode", "error", err, "code", code)
+		response.InternalError(c, "Failed to get product", err.Error())
+		return
+	}
+
+	response.Success(c, "Product retrieved successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
 }
 
 // UpdateProduct godoc
@@ -117,7 +153,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, "Product updated successfully", result)
+	response.Success(c, "Product updated successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
 }
 
 // DeleteProduct godoc
@@ -146,6 +182,40 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	response.Success(c, "Product deleted successfully", nil)
 }
 
+// RestoreProduct godoc
+// @Summary Restore a deleted product
+// @Description Bring a deleted product back. Refused while another product uses its SKU or barcode, or while its category is deleted (Admin only).
+// @Tags products
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Success 200 {object} response.Response{data=product.ProductResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.productUseCase.RestoreProduct(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrProductNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrSKUExists), errors.Is(err, appErrors.ErrBarcodeExists):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to restore product", "error", err, "product_id", id)
+			response.InternalError(c, "Failed to restore product", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Product restored successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
 // ListProducts godoc
 // @Summary List products
 // @Description Get a list of products with optional filters
@@ -154,10 +224,15 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 // @Produce json
 // @Param category_id query string false "Filter by category ID"
 // @Param is_active query boolean false "Filter by active status"
-// @Param search query string false "Search in product name and SKU"
+// @Param search query string false "Search product name, SKU, barcode and description, best matches first and typos tolerated"
+// @Param min_price query int false "Lowest price in rupiah"
+// @Param max_price query int false "Highest price in rupiah"
+// @Param sort query string false "newest (default) or popular, which lists the best sellers of the recent window first"
+// @Param include_deleted query boolean false "Also list deleted products (Admin only, ignored when searching)"
 // @Param limit query int false "Number of products to return" default(20)
 // @Param offset query int false "Number of products to skip" default(0)
 // @Success 200 {object} response.Response{data=[]product.ProductResponse}
+// @Failure 403 {object} response.Response
 // @Router /products [get]
 func (h *ProductHandler) ListProducts(c *gin.Context) {
 	var filters product.ProductFilters
@@ -176,6 +251,11 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 		return
 	}
 
+	if filters.IncludeDeleted && middleware.GetCurrentRole(c) != entities.RoleAdmin {
+		response.Forbidden(c, "Only admins can list deleted products")
+		return
+	}
+
 	result, err := h.productUseCase.ListProducts(c.Request.Context(), &filters)
 	if err != nil {
 		h.logger.Error("Failed to list products", "error", err)
@@ -183,7 +263,7 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, "Products retrieved successfully", result)
+	response.Success(c, "Products retrieved successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
 }
 
 // UpdateStock godoc
@@ -194,7 +274,7 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "Product ID"
-// @Param request body map[string]int true "Stock change" example({"quantity": 10})
+// @Param request body map[string]number true "Stock change in the product's unit, fractions for kg and liter" example({"quantity": 10})
 // @Success 200 {object} response.Response{data=product.ProductResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
@@ -204,7 +284,7 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 	id := c.Param("id")
 
 	var req struct {
-		Quantity int `json:"quantity" validate:"required"`
+		Quantity float64 `json:"quantity" validate:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -224,7 +304,259 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, "Product stock updated successfully", result)
+	response.Success(c, "Product stock updated successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
+// BulkUpdatePrices godoc
+// @Summary Bulk update product prices
+// @Description Raise or lower prices of a category or selected products by a percentage or fixed amount (Admin only).
+// @Description Set preview to true to see the new prices without applying them.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body product.BulkPriceUpdateRequest true "Price adjustment"
+// @Success 200 {object} response.Response{data=product.BulkPriceUpdateResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /products/bulk-price [post]
+func (h *ProductHandler) BulkUpdatePrices(c *gin.Context) {
+	var req product.BulkPriceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.productUseCase.BulkUpdatePrices(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to bulk update prices", "error", err)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	message := "Prices updated successfully"
+	if req.Preview {
+		message = "Price update preview generated"
+	}
+	response.Success(c, message, response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
+// SetPriceTiers godoc
+// @Summary Set product price tiers
+// @Description Replace the quantity breaks of a product, e.g. 10 units or more at a lower unit price (Admin only).
+// @Description The break the quantity of the product in a transaction reaches sets its unit price. An empty list removes them.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param request body product.SetPriceTiersRequest true "Quantity breaks"
+// @Success 200 {object} response.Response{data=product.ProductResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/price-tiers [put]
+func (h *ProductHandler) SetPriceTiers(c *gin.Context) {
+	id := c.Param("id")
+
+	var req product.SetPriceTiersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.productUseCase.SetPriceTiers(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to set price tiers", "error", err, "product_id", id)
+		switch {
+		case errors.Is(err, appErrors.ErrProductNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to set price tiers", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Price tiers set successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
+// GetRecipe godoc
+// @Summary Get product recipe
+// @Description Get the ingredients used per unit of a product and what they cost (Admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Success 200 {object} response.Response{data=product.RecipeResponse}
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/recipe [get]
+func (h *ProductHandler) GetRecipe(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.productUseCase.GetRecipe(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get recipe", "error", err, "product_id", id)
+		if errors.Is(err, appErrors.ErrProductNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to retrieve recipe", err.Error())
+		return
+	}
+
+	response.Success(c, "Recipe retrieved successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
+// SetRecipe godoc
+// @Summary Set product recipe
+// @Description Replace the ingredients of a menu item, each a product used in a quantity of its own unit per unit sold (Admin only).
+// @Description Paid sales deduct the ingredients from stock and are costed at the ingredients' cost price. An empty list removes the recipe.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param request body product.SetRecipeRequest true "Ingredients"
+// @Success 200 {object} response.Response{data=product.RecipeResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/recipe [put]
+func (h *ProductHandler) SetRecipe(c *gin.Context) {
+	id := c.Param("id")
+
+	var req product.SetRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.productUseCase.SetRecipe(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to set recipe", "error", err, "product_id", id)
+		switch {
+		case errors.Is(err, appErrors.ErrProductNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			response.InternalError(c, "Failed to set recipe", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Recipe set successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
+// GetPriceHistory godoc
+// @Summary Get product price history
+// @Description Get the price changes of a product, latest first, from bulk updates, suggested prices and price schedules (Admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param limit query int false "Maximum number of changes" default(50)
+// @Param offset query int false "Number of changes to skip" default(0)
+// @Success 200 {object} response.Response{data=[]entities.PriceChange}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/price-history [get]
+func (h *ProductHandler) GetPriceHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	var filters product.PriceHistoryFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.productUseCase.GetPriceHistory(c.Request.Context(), id, &filters)
+	if err != nil {
+		h.logger.Error("Failed to get price history", "error", err, "product_id", id)
+		if errors.Is(err, appErrors.ErrProductNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to retrieve price history", err.Error())
+		return
+	}
+
+	response.Success(c, "Price history retrieved successfully", result)
+}
+
+// RecalculateSuggestedPrices godoc
+// @Summary Recalculate suggested product prices
+// @Description Set prices of a category or selected products to cost price plus the category's default markup (Admin only).
+// @Description Products without a cost price or markup are skipped. Set preview to true to see the new prices without applying them.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body product.SuggestedPriceRequest true "Products to reprice"
+// @Success 200 {object} response.Response{data=product.BulkPriceUpdateResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /products/suggested-prices [post]
+func (h *ProductHandler) RecalculateSuggestedPrices(c *gin.Context) {
+	var req product.SuggestedPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.productUseCase.RecalculateSuggestedPrices(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to recalculate suggested prices", "error", err)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	message := "Suggested prices applied successfully"
+	if req.Preview {
+		message = "Suggested price preview generated"
+	}
+	response.Success(c, message, response.ForRole(result, string(middleware.GetCurrentRole(c))))
 }
 
 // CreateCategory godoc
@@ -262,6 +594,347 @@ func (h *ProductHandler) CreateCategory(c *gin.Context) {
 	response.Created(c, "Category created successfully", result)
 }
 
+// UpdateCategory godoc
+// @Summary Update a category
+// @Description Update a product category and its default markup (Admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Category ID"
+// @Param request body product.UpdateCategoryRequest true "Category data"
+// @Success 200 {object} response.Response{data=product.CategoryResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /categories/{id} [put]
+func (h *ProductHandler) UpdateCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	var req product.UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.productUseCase.UpdateCategory(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update category", "error", err, "category_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Category updated successfully", result)
+}
+
+// ImportCategories godoc
+// @Summary Import categories from CSV
+// @Description Create or update categories from a CSV file with a header row. The name column is required, default_markup and is_active are optional. Existing categories are matched by name ignoring case. Rows with errors are skipped and reported. (Admin only)
+// @Tags categories
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "CSV file"
+// @Success 200 {object} response.Response{data=product.CategoryImportResult}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /categories/import [post]
+func (h *ProductHandler) ImportCategories(c *gin.Context) {
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file provided or invalid file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	result, err := h.productUseCase.ImportCategories(c.Request.Context(), file)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidImportFile) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		h.logger.Error("Failed to import categories", "error", err)
+		response.InternalError(c, "Failed to import categories", err.Error())
+		return
+	}
+
+	response.Success(c, "Categories imported successfully", result)
+}
+
+// ImportProducts godoc
+// @Summary Import products from CSV or XLSX
+// @Description Create or update the products of the current store from a .csv or .xlsx catalog with a header row, in the layout of the export. The name column is required and category takes a category name or ID. Products are matched by SKU, empty cells keep existing values. Rows with errors are skipped and reported; with dry_run nothing is saved. (Admin only)
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run formData boolean false "Only validate and report what would change"
+// @Success 200 {object} response.Response{data=product.ProductImportResult}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /products/import [post]
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file provided or invalid file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	format := product.CatalogFormat(header.Filename)
+	if format == "" {
+		response.BadRequest(c, "Upload a .csv or .xlsx file", nil)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+	result, err := h.productUseCase.ImportProducts(c.Request.Context(), file, format, dryRun)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidImportFile) || errors.Is(err, appErrors.ErrStoreRequired) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		h.logger.Error("Failed to import products", "error", err)
+		response.InternalError(c, "Failed to import products", err.Error())
+		return
+	}
+
+	if dryRun {
+		response.Success(c, "Product import validated, nothing was saved", result)
+		return
+	}
+	response.Success(c, "Products imported successfully", result)
+}
+
+// ExportProducts godoc
+// @Summary Export products to CSV or XLSX
+// @Description Download every product of the current store, active or not, in the layout the import accepts (Admin only)
+// @Tags products
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security ApiKeyAuth
+// @Param format query string false "csv (default) or xlsx"
+// @Success 200 {file} file
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /products/export [get]
+func (h *ProductHandler) ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", product.FormatCSV)
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case product.FormatCSV:
+	case product.FormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		response.BadRequest(c, "Format must be csv or xlsx", nil)
+		return
+	}
+	if _, ok := repositories.StoreFromContext(c.Request.Context()); !ok {
+		response.BadRequest(c, appErrors.ErrStoreRequired.Error(), nil)
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products-%s.%s"`, time.Now().Format("20060102"), format))
+	c.Status(http.StatusOK)
+
+	// The file is streamed, once it started a failure can only cut it short
+	if err := h.productUseCase.ExportProducts(c.Request.Context(), c.Writer, format); err != nil {
+		h.logger.Error("Failed to export products", "error", err)
+		c.Error(err)
+	}
+}
+
+// MergeCategory godoc
+// @Summary Merge a category into another
+// @Description Move every product and category promotion of a category to another category and deactivate it (Admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Category ID to merge away"
+// @Param request body product.MergeCategoryRequest true "Category to merge into"
+// @Success 200 {object} response.Response{data=product.MergeCategoryResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /categories/{id}/merge [post]
+func (h *ProductHandler) MergeCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	var req product.MergeCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.productUseCase.MergeCategory(c.Request.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrCategoryNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrCategoryMergeSelf):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to merge categories", "error", err, "category_id", id)
+			response.InternalError(c, "Failed to merge categories", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Categories merged successfully", result)
+}
+
+// ListCategoryTree godoc
+// @Summary List categories as a tree
+// @Description Get every product category nested under its parent, top-level categories first and each level sorted by name
+// @Tags categories
+// @Produce json
+// @Success 200 {object} response.Response{data=[]product.CategoryNode}
+// @Router /categories/tree [get]
+func (h *ProductHandler) ListCategoryTree(c *gin.Context) {
+	result, err := h.productUseCase.ListCategoryTree(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to retrieve categories", err.Error())
+		return
+	}
+
+	response.Success(c, "Categories retrieved successfully", result)
+}
+
+// MoveCategory godoc
+// @Summary Move a category
+// @Description File a category, with its subcategories, under another category, or at the top level when parent_id is empty (Admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Category ID"
+// @Param request body product.MoveCategoryRequest true "New parent"
+// @Success 200 {object} response.Response{data=product.CategoryResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /categories/{id}/move [post]
+func (h *ProductHandler) MoveCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	var req product.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.productUseCase.MoveCategory(c.Request.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrCategoryNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrCategoryCycle):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to move category", "error", err, "category_id", id)
+			response.InternalError(c, "Failed to move category", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Category moved successfully", result)
+}
+
+// DeleteCategory godoc
+// @Summary Delete a category
+// @Description Delete a category, its subcategories move up to its parent. A category with products can only be deleted with reassign_to, the category its products and promotions move to (Admin only)
+// @Tags categories
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Category ID"
+// @Param reassign_to query string false "Category ID to move the products to"
+// @Success 200 {object} response.Response{data=product.DeleteCategoryResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /categories/{id} [delete]
+func (h *ProductHandler) DeleteCategory(c *gin.Context) {
+	id := c.Param("id")
+	reassignTo := c.Query("reassign_to")
+
+	result, err := h.productUseCase.DeleteCategory(c.Request.Context(), id, reassignTo)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrCategoryNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrCategoryHasProducts):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrCategoryMergeSelf):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to delete category", "error", err, "category_id", id)
+			response.InternalError(c, "Failed to delete category", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Category deleted successfully", result)
+}
+
+// RestoreCategory godoc
+// @Summary Restore a deleted category
+// @Description Bring a deleted category back. Products and subcategories moved away when it was deleted stay where they are. Refused while another category has its name or while its parent is deleted (Admin only).
+// @Tags categories
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Category ID"
+// @Success 200 {object} response.Response{data=product.CategoryResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /categories/{id}/restore [post]
+func (h *ProductHandler) RestoreCategory(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.productUseCase.RestoreCategory(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrCategoryNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrCategoryExists):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to restore category", "error", err, "category_id", id)
+			response.InternalError(c, "Failed to restore category", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Category restored successfully", result)
+}
+
 // ListCategories godoc
 // @Summary List categories
 // @Description Get a list of product categories
@@ -270,7 +943,9 @@ func (h *ProductHandler) CreateCategory(c *gin.Context) {
 // @Produce json
 // @Param limit query int false "Number of categories to return" default(50)
 // @Param offset query int false "Number of categories to skip" default(0)
+// @Param include_deleted query boolean false "Also list deleted categories (Admin only)"
 // @Success 200 {object} response.Response{data=[]product.CategoryResponse}
+// @Failure 403 {object} response.Response
 // @Router /categories [get]
 func (h *ProductHandler) ListCategories(c *gin.Context) {
 	limit := 50
@@ -288,7 +963,13 @@ func (h *ProductHandler) ListCategories(c *gin.Context) {
 		}
 	}
 
-	result, err := h.productUseCase.ListCategories(c.Request.Context(), limit, offset)
+	includeDeleted := c.Query("include_deleted") == "true"
+	if includeDeleted && middleware.GetCurrentRole(c) != entities.RoleAdmin {
+		response.Forbidden(c, "Only admins can list deleted categories")
+		return
+	}
+
+	result, err := h.productUseCase.ListCategories(c.Request.Context(), limit, offset, includeDeleted)
 	if err != nil {
 		h.logger.Error("Failed to list categories", "error", err)
 		response.InternalError(c, "Failed to retrieve categories", err.Error())
@@ -296,4 +977,122 @@ func (h *ProductHandler) ListCategories(c *gin.Context) {
 	}
 
 	response.Success(c, "Categories retrieved successfully", result)
-}
\ No newline at end of file
+}
+
+// AddProductImage godoc
+// @Summary Add a product image
+// @Description Upload a picture of a product. It is scaled down, stored with a thumbnail and appended to the images of the product; the first image is the main picture (Admin only)
+// @Tags products
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param file formData file true "Image file (JPEG, PNG, WebP, GIF)"
+// @Success 201 {object} response.Response{data=product.ProductImageResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/images [post]
+func (h *ProductHandler) AddProductImage(c *gin.Context) {
+	id := c.Param("id")
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file provided or invalid file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	result, err := h.productUseCase.AddProductImage(c.Request.Context(), id, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrProductNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrTooManyImages), errors.Is(err, appErrors.ErrInvalidImage):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to add product image", "error", err, "product_id", id)
+			response.InternalError(c, "Failed to add product image", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Product image added successfully", result)
+}
+
+// ReorderProductImages godoc
+// @Summary Reorder product images
+// @Description Save the order of the images of a product, the first becomes the main picture (Admin only)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param request body product.ReorderProductImagesRequest true "Every image ID of the product in the new order"
+// @Success 200 {object} response.Response{data=[]product.ProductImageResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/images/order [put]
+func (h *ProductHandler) ReorderProductImages(c *gin.Context) {
+	id := c.Param("id")
+
+	var req product.ReorderProductImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.productUseCase.ReorderProductImages(c.Request.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrProductNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to reorder product images", "error", err, "product_id", id)
+			response.InternalError(c, "Failed to reorder product images", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Product images reordered successfully", result)
+}
+
+// DeleteProductImage godoc
+// @Summary Delete a product image
+// @Description Remove an image from a product and from storage (Admin only)
+// @Tags products
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Product ID"
+// @Param image_id path string true "Image ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /products/{id}/images/{image_id} [delete]
+func (h *ProductHandler) DeleteProductImage(c *gin.Context) {
+	id := c.Param("id")
+	imageID := c.Param("image_id")
+
+	if err := h.productUseCase.DeleteProductImage(c.Request.Context(), id, imageID); err != nil {
+		if errors.Is(err, appErrors.ErrProductNotFound) || errors.Is(err, appErrors.ErrProductImageNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to delete product image", "error", err, "product_id", id, "image_id", imageID)
+		response.InternalError(c, "Failed to delete product image", err.Error())
+		return
+	}
+
+	response.Success(c, "Product image deleted successfully", nil)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/promotion"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	promotionUseCase *promotion.PromotionUseCase
+	logger           logger.Logger
+}
+
+func NewPromotionHandler(promotionUseCase *promotion.PromotionUseCase, logger logger.Logger) *PromotionHandler {
+	return &PromotionHandler{
+		promotionUseCase: promotionUseCase,
+		logger:           logger,
+	}
+}
+
+// CreatePromotion godoc
+// @Summary Create a promotion
+// @Description Create a promotion rule, applied automatically or, when it has a code, once the promo code is entered (Admin only)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body promotion.PromotionRequest true "Promotion data"
+// @Success 201 {object} response.Response{data=entities.Promotion}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /promotions [post]
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req promotion.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.promotionUseCase.CreatePromotion(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to create promotion", "error", err)
+		if errors.Is(err, appErrors.ErrPromoCodeAlreadyExists) {
+			response.Conflict(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Promotion created successfully", result)
+}
+
+// GetPromotion godoc
+// @Summary Get promotion by ID
+// @Description Get a single promotion by its ID (Admin only)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} response.Response{data=entities.Promotion}
+// @Failure 404 {object} response.Response
+// @Router /promotions/{id} [get]
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.promotionUseCase.GetPromotion(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get promotion", "error", err, "promotion_id", id)
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Promotion retrieved successfully", result)
+}
+
+// UpdatePromotion godoc
+// @Summary Update a promotion
+// @Description Update an existing promotion rule (Admin only)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Promotion ID"
+// @Param request body promotion.PromotionRequest true "Promotion data"
+// @Success 200 {object} response.Response{data=entities.Promotion}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /promotions/{id} [put]
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	var req promotion.PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.promotionUseCase.UpdatePromotion(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update promotion", "error", err, "promotion_id", id)
+		if errors.Is(err, appErrors.ErrPromotionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		if errors.Is(err, appErrors.ErrPromoCodeAlreadyExists) {
+			response.Conflict(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Promotion updated successfully", result)
+}
+
+// DeletePromotion godoc
+// @Summary Delete a promotion
+// @Description Delete a promotion rule (Admin only)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.promotionUseCase.DeletePromotion(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete promotion", "error", err, "promotion_id", id)
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Promotion deleted successfully", nil)
+}
+
+// ListPromotions godoc
+// @Summary List promotions
+// @Description Get a list of promotion rules (Admin only)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "Filter by promotion type"
+// @Param is_active query boolean false "Filter by active status"
+// @Param limit query int false "Number of promotions to return" default(20)
+// @Param offset query int false "Number of promotions to skip" default(0)
+// @Success 200 {object} response.Response{data=[]entities.Promotion}
+// @Router /promotions [get]
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	var filters promotion.PromotionFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if filters.Limit == 0 {
+		filters.Limit = 20
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.promotionUseCase.ListPromotions(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list promotions", "error", err)
+		response.InternalError(c, "Failed to retrieve promotions", err.Error())
+		return
+	}
+
+	response.Success(c, "Promotions retrieved successfully", result)
+}
+
+// SimulatePromotions godoc
+// @Summary Simulate promotions
+// @Description Evaluate active promotions against a hypothetical cart without creating a transaction
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body promotion.SimulateRequest true "Cart to simulate"
+// @Success 200 {object} response.Response{data=promotion.SimulateResponse}
+// @Failure 400 {object} response.Response
+// @Router /promotions/simulate [post]
+func (h *PromotionHandler) SimulatePromotions(c *gin.Context) {
+	var req promotion.SimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.promotionUseCase.Simulate(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to simulate promotions", "error", err)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Promotions simulated successfully", result)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"qris-pos-backend/internal/usecases/receipt"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReceiptHandler struct {
+	receiptUseCase *receipt.ReceiptUseCase
+	logger         logger.Logger
+}
+
+func NewReceiptHandler(receiptUseCase *receipt.ReceiptUseCase, logger logger.Logger) *ReceiptHandler {
+	return &ReceiptHandler{
+		receiptUseCase: receiptUseCase,
+		logger:         logger,
+	}
+}
+
+// GetReceipt godoc
+// @Summary Get transaction receipt
+// @Description Render the receipt of a transaction as PDF or as an ESC/POS byte stream for thermal printers
+// @Tags transactions
+// @Produce application/pdf
+// @Produce application/octet-stream
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param format query string false "Receipt format" Enums(pdf, escpos) default(pdf)
+// @Param width query int false "Printer characters per line for ESC/POS" Enums(32, 48) default(32)
+// @Success 200 {file} binary
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/receipt [get]
+func (h *ReceiptHandler) GetReceipt(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var req receipt.ReceiptRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.receiptUseCase.GetReceipt(c.Request.Context(), transactionID, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to render receipt", "error", err, "transaction_id", transactionID)
+		response.InternalError(c, "Failed to render receipt", err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", result.FileName))
+	c.Data(http.StatusOK, result.ContentType, result.Content)
+}
+
+// ShareReceipt godoc
+// @Summary Share transaction receipt
+// @Description Create a signed link to the receipt of a transaction that opens without logging in until it expires, for sending to the customer over chat apps. Links can't be revoked before they expire.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body receipt.ShareRequest false "Link lifetime"
+// @Success 201 {object} response.Response{data=receipt.ShareLink}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/share [post]
+func (h *ReceiptHandler) ShareReceipt(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var req receipt.ShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	link, err := h.receiptUseCase.ShareReceipt(c.Request.Context(), transactionID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrTransactionNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to share receipt", "error", err, "transaction_id", transactionID)
+			response.InternalError(c, "Failed to share receipt", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Receipt link created successfully", link)
+}
+
+// GetSharedReceipt godoc
+// @Summary Get shared receipt
+// @Description Render the receipt a share link was created for, without logging in
+// @Tags transactions
+// @Produce application/pdf
+// @Produce application/octet-stream
+// @Param token path string true "Share token"
+// @Param format query string false "Receipt format" Enums(pdf, escpos) default(pdf)
+// @Param width query int false "Printer characters per line for ESC/POS" Enums(32, 48) default(32)
+// @Success 200 {file} binary
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response "Unknown or tampered link"
+// @Failure 410 {object} response.Response "Link expired"
+// @Router /invoices/{token} [get]
+func (h *ReceiptHandler) GetSharedReceipt(c *gin.Context) {
+	var req receipt.ReceiptRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.receiptUseCase.GetSharedReceipt(c.Request.Context(), c.Param("token"), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrTokenExpired):
+			response.Gone(c, "Receipt link has expired")
+		case errors.Is(err, appErrors.ErrInvalidToken), errors.Is(err, appErrors.ErrTransactionNotFound):
+			response.NotFound(c, "Receipt not found")
+		default:
+			h.logger.Error("Failed to render shared receipt", "error", err)
+			response.InternalError(c, "Failed to render receipt", err.Error())
+		}
+		return
+	}
+
+	// Links travel through chat apps, keep the receipt out of shared caches
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", result.FileName))
+	c.Data(http.StatusOK, result.ContentType, result.Content)
+}
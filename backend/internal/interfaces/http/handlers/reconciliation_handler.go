@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/reconciliation"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	reconciliationUseCase *reconciliation.ReconciliationUseCase
+	logger                logger.Logger
+}
+
+func NewReconciliationHandler(reconciliationUseCase *reconciliation.ReconciliationUseCase, logger logger.Logger) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationUseCase: reconciliationUseCase,
+		logger:                logger,
+	}
+}
+
+// CreateRun godoc
+// @Summary Reconcile settlements from Midtrans
+// @Description Queue a run that asks Midtrans how it settled every QRIS and e-wallet payment created in the range and flags amount mismatches, settlements of unpaid payments and successful payments Midtrans has not settled (Admin only)
+// @Tags reconciliation
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body reconciliation.CreateRunRequest true "Payment range"
+// @Success 202 {object} response.Response{data=entities.ReconciliationRun}
+// @Failure 400 {object} response.Response
+// @Router /reconciliation/runs [post]
+func (h *ReconciliationHandler) CreateRun(c *gin.Context) {
+	var req reconciliation.CreateRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.reconciliationUseCase.CreateRun(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to queue reconciliation run")
+		return
+	}
+
+	response.Accepted(c, "Reconciliation run queued", result)
+}
+
+// UploadRun godoc
+// @Summary Reconcile an uploaded settlement report
+// @Description Match a settlement or disbursement CSV from the Midtrans dashboard against the payments created in the range it covers. The order_id and gross_amount (or amount) columns are required, transaction_status, fee and settlement_time are used when present; rows of the same order are added up (Admin only)
+// @Tags reconciliation
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "CSV settlement report"
+// @Param date_from formData string true "First day the report covers (YYYY-MM-DD)"
+// @Param date_to formData string true "Last day the report covers, inclusive (YYYY-MM-DD)"
+// @Success 201 {object} response.Response{data=entities.ReconciliationRun}
+// @Failure 400 {object} response.Response
+// @Router /reconciliation/runs/upload [post]
+func (h *ReconciliationHandler) UploadRun(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file provided or invalid file", err.Error())
+		return
+	}
+	defer file.Close()
+
+	var req reconciliation.UploadRunRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.reconciliationUseCase.UploadRun(c.Request.Context(), currentUser.UserID, header.Filename, file, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to reconcile settlement report")
+		return
+	}
+
+	response.Created(c, "Settlement report reconciled", result)
+}
+
+// ListRuns godoc
+// @Summary List reconciliation runs
+// @Description List the reconciliation runs with their counters, newest first (Admin only)
+// @Tags reconciliation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Page size" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.ReconciliationRun}
+// @Router /reconciliation/runs [get]
+func (h *ReconciliationHandler) ListRuns(c *gin.Context) {
+	var filters reconciliation.RunFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.reconciliationUseCase.ListRuns(c.Request.Context(), &filters)
+	if err != nil {
+		response.InternalError(c, "Failed to list reconciliation runs", err.Error())
+		return
+	}
+
+	response.Success(c, "Reconciliation runs retrieved successfully", result)
+}
+
+// GetRun godoc
+// @Summary Get a reconciliation run
+// @Description Get the status and counters of a reconciliation run (Admin only)
+// @Tags reconciliation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Run ID"
+// @Success 200 {object} response.Response{data=entities.ReconciliationRun}
+// @Failure 404 {object} response.Response
+// @Router /reconciliation/runs/{id} [get]
+func (h *ReconciliationHandler) GetRun(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.reconciliationUseCase.GetRun(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get reconciliation run")
+		return
+	}
+
+	response.Success(c, "Reconciliation run retrieved successfully", result)
+}
+
+// ListItems godoc
+// @Summary List the items of a reconciliation run
+// @Description List the payments and settlements of a run and how they matched, discrepancies first (Admin only)
+// @Tags reconciliation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Run ID"
+// @Param result query string false "Filter by result" Enums(matched, amount_mismatch, status_mismatch, missing_local, missing_settlement)
+// @Param limit query int false "Page size" default(100)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.ReconciliationItem}
+// @Failure 404 {object} response.Response
+// @Router /reconciliation/runs/{id}/items [get]
+func (h *ReconciliationHandler) ListItems(c *gin.Context) {
+	id := c.Param("id")
+
+	var filters reconciliation.ItemFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.reconciliationUseCase.ListItems(c.Request.Context(), id, &filters)
+	if err != nil {
+		h.handleError(c, err, "Failed to list reconciliation items")
+		return
+	}
+
+	response.Success(c, "Reconciliation items retrieved successfully", result)
+}
+
+func (h *ReconciliationHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, appErrors.ErrReconciliationNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrInvalidInput), errors.Is(err, appErrors.ErrInvalidSettlementReport):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		h.logger.Error(message, "error", err)
+		response.InternalError(c, message, err.Error())
+	}
+}
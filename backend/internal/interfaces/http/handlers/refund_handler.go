@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/refund"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paymentIDParam is the payment ID path parameter. It shares its wildcard name with
+// /payments/:transaction_id/status because gin requires one name per path segment.
+const paymentIDParam = "transaction_id"
+
+type RefundHandler struct {
+	refundUseCase *refund.RefundUseCase
+	logger        logger.Logger
+}
+
+func NewRefundHandler(refundUseCase *refund.RefundUseCase, logger logger.Logger) *RefundHandler {
+	return &RefundHandler{
+		refundUseCase: refundUseCase,
+		logger:        logger,
+	}
+}
+
+// RefundPayment godoc
+// @Summary Refund a payment
+// @Description Fully or partially refund a settled payment (Admin only). Returned items are restocked.
+// @Description Without items and amount the remaining sale is refunded.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param transaction_id path string true "Payment ID"
+// @Param request body refund.RefundRequest true "Refund data"
+// @Success 201 {object} response.Response{data=refund.RefundResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /payments/{transaction_id}/refund [post]
+func (h *RefundHandler) RefundPayment(c *gin.Context) {
+	paymentID := c.Param(paymentIDParam)
+
+	var req refund.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.refundUseCase.RefundPayment(c.Request.Context(), paymentID, currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to refund payment", "error", err, "payment_id", paymentID)
+		if errors.Is(err, appErrors.ErrPaymentNotFound) || errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Payment refunded successfully", result)
+}
+
+// ListRefunds godoc
+// @Summary List refunds of a payment
+// @Description List the refunds made for a payment (Admin only)
+// @Tags payments
+// @Produce json
+// @Security ApiKeyAuth
+// @Param transaction_id path string true "Payment ID"
+// @Success 200 {object} response.Response{data=[]entities.Refund}
+// @Failure 404 {object} response.Response
+// @Router /payments/{transaction_id}/refunds [get]
+func (h *RefundHandler) ListRefunds(c *gin.Context) {
+	paymentID := c.Param(paymentIDParam)
+
+	result, err := h.refundUseCase.ListRefunds(c.Request.Context(), paymentID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrPaymentNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to list refunds", "error", err, "payment_id", paymentID)
+		response.InternalError(c, "Failed to retrieve refunds", err.Error())
+		return
+	}
+
+	response.Success(c, "Refunds retrieved successfully", result)
+}
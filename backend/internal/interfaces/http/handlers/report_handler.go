@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/report"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	reportUseCase *report.ReportUseCase
+	logger        logger.Logger
+}
+
+func NewReportHandler(reportUseCase *report.ReportUseCase, logger logger.Logger) *ReportHandler {
+	return &ReportHandler{
+		reportUseCase: reportUseCase,
+		logger:        logger,
+	}
+}
+
+// GetTopProducts godoc
+// @Summary Best-selling products
+// @Description Rank products or categories by quantity sold, revenue or margin over a date range, for one store or across every store (Admin only)
+// @Tags reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param date_from query string true "Start date (YYYY-MM-DD)"
+// @Param date_to query string true "End date, inclusive (YYYY-MM-DD)"
+// @Param category_id query string false "Only products of this category"
+// @Param store_id query string false "Only sales of this store, every store when neither it nor X-Store-ID is given"
+// @Param group_by query string false "Group by" Enums(product, category) default(product)
+// @Param sort_by query string false "Sort by" Enums(quantity, revenue, margin) default(quantity)
+// @Param limit query int false "Limit" default(10)
+// @Success 200 {object} response.Response{data=report.TopProductsResponse}
+// @Failure 400 {object} response.Response
+// @Router /reports/products/top [get]
+func (h *ReportHandler) GetTopProducts(c *gin.Context) {
+	var req report.TopProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.reportUseCase.GetTopProducts(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		h.logger.Error("Failed to get top products", "error", err)
+		response.InternalError(c, "Failed to retrieve top products", err.Error())
+		return
+	}
+
+	response.Success(c, "Top products retrieved successfully", response.ForRole(result, string(middleware.GetCurrentRole(c))))
+}
+
+// GetProfit godoc
+// @Summary Profit report
+// @Description Set the net sales of each day against the cost of goods of the sales, recipe ingredients at their cost price included, for one store or across every store (Admin only).
+// @Description Sales are costed shortly after they are paid, uncosted counts those still waiting.
+// @Tags reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param date_from query string true "Start date (YYYY-MM-DD)"
+// @Param date_to query string true "End date, inclusive (YYYY-MM-DD)"
+// @Param store_id query string false "Only sales of this store, every store when neither it nor X-Store-ID is given"
+// @Param currency query string false "Only sales in this currency"
+// @Success 200 {object} response.Response{data=report.ProfitReportResponse}
+// @Failure 400 {object} response.Response
+// @Router /reports/profit [get]
+func (h *ReportHandler) GetProfit(c *gin.Context) {
+	var req report.ProfitReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.reportUseCase.GetProfit(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		h.logger.Error("Failed to get profit report", "error", err)
+		response.InternalError(c, "Failed to retrieve profit report", err.Error())
+		return
+	}
+
+	response.Success(c, "Profit report retrieved successfully", result)
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/report"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportScheduleHandler struct {
+	scheduleUseCase *report.ReportScheduleUseCase
+	logger          logger.Logger
+}
+
+func NewReportScheduleHandler(scheduleUseCase *report.ReportScheduleUseCase, logger logger.Logger) *ReportScheduleHandler {
+	return &ReportScheduleHandler{
+		scheduleUseCase: scheduleUseCase,
+		logger:          logger,
+	}
+}
+
+// CreateReportSchedule godoc
+// @Summary Schedule a sales report
+// @Description Email a daily or weekly sales summary of the selected store, or of every store when none is selected, to a list of recipients as a PDF or CSV attachment (Admin only).
+// @Description Reports go out at the given hour of the business timezone. A daily report covers the previous day, a weekly one the seven days before the weekday it is sent.
+// @Tags report-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body report.ReportScheduleRequest true "Schedule"
+// @Success 201 {object} response.Response{data=entities.ReportSchedule}
+// @Failure 400 {object} response.Response
+// @Router /report-schedules [post]
+func (h *ReportScheduleHandler) CreateReportSchedule(c *gin.Context) {
+	var req report.ReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.scheduleUseCase.CreateReportSchedule(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to create report schedule")
+		return
+	}
+
+	response.Created(c, "Report schedule created successfully", result)
+}
+
+// ListReportSchedules godoc
+// @Summary List report schedules
+// @Description List the report schedules of the selected store, or of every store when none is selected, with the outcome of their last report (Admin only)
+// @Tags report-schedules
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=[]entities.ReportSchedule}
+// @Router /report-schedules [get]
+func (h *ReportScheduleHandler) ListReportSchedules(c *gin.Context) {
+	result, err := h.scheduleUseCase.ListReportSchedules(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list report schedules", "error", err)
+		response.InternalError(c, "Failed to list report schedules", err.Error())
+		return
+	}
+
+	response.Success(c, "Report schedules retrieved successfully", result)
+}
+
+// GetReportSchedule godoc
+// @Summary Get a report schedule
+// @Description Get a report schedule with when its next report goes out (Admin only)
+// @Tags report-schedules
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Report schedule ID"
+// @Success 200 {object} response.Response{data=entities.ReportSchedule}
+// @Failure 404 {object} response.Response
+// @Router /report-schedules/{id} [get]
+func (h *ReportScheduleHandler) GetReportSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.scheduleUseCase.GetReportSchedule(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get report schedule")
+		return
+	}
+
+	response.Success(c, "Report schedule retrieved successfully", result)
+}
+
+// UpdateReportSchedule godoc
+// @Summary Update a report schedule
+// @Description Change the frequency, time, format or recipients of a report schedule, or pause it with is_active false (Admin only)
+// @Tags report-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Report schedule ID"
+// @Param request body report.ReportScheduleRequest true "Schedule"
+// @Success 200 {object} response.Response{data=entities.ReportSchedule}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /report-schedules/{id} [put]
+func (h *ReportScheduleHandler) UpdateReportSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	var req report.ReportScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.scheduleUseCase.UpdateReportSchedule(c.Request.Context(), id, &req)
+	if err != nil {
+		h.handleError(c, err, "Failed to update report schedule")
+		return
+	}
+
+	response.Success(c, "Report schedule updated successfully", result)
+}
+
+// DeleteReportSchedule godoc
+// @Summary Delete a report schedule
+// @Description Stop sending a scheduled report (Admin only)
+// @Tags report-schedules
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Report schedule ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /report-schedules/{id} [delete]
+func (h *ReportScheduleHandler) DeleteReportSchedule(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.scheduleUseCase.DeleteReportSchedule(c.Request.Context(), id); err != nil {
+		h.handleError(c, err, "Failed to delete report schedule")
+		return
+	}
+
+	response.Success(c, "Report schedule deleted successfully", nil)
+}
+
+// SendReportNow godoc
+// @Summary Send a scheduled report now
+// @Description Send the latest report of a schedule right away, to check the recipients or resend one that failed. The next scheduled report is not moved (Admin only).
+// @Tags report-schedules
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Report schedule ID"
+// @Success 200 {object} response.Response{data=entities.ReportSchedule}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /report-schedules/{id}/send [post]
+func (h *ReportScheduleHandler) SendReportNow(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.scheduleUseCase.SendReportNow(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to send report")
+		return
+	}
+
+	response.Success(c, "Report sent successfully", result)
+}
+
+func (h *ReportScheduleHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, appErrors.ErrReportScheduleNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrInvalidInput), errors.Is(err, appErrors.ErrEmailNotConfigured):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		h.logger.Error(message, "error", err)
+		response.InternalError(c, message, err.Error())
+	}
+}
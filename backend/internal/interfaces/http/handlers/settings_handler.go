@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/settings"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettingsHandler struct {
+	settingsUseCase *settings.SettingsUseCase
+	logger          logger.Logger
+}
+
+func NewSettingsHandler(settingsUseCase *settings.SettingsUseCase, logger logger.Logger) *SettingsHandler {
+	return &SettingsHandler{
+		settingsUseCase: settingsUseCase,
+		logger:          logger,
+	}
+}
+
+// GetSettings godoc
+// @Summary Get runtime settings
+// @Description Get the store name and logo, receipt footer and default QRIS lifetime in effect. Settings never changed keep their configured default.
+// @Tags settings
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=settings.Settings}
+// @Router /settings [get]
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	response.Success(c, "Settings retrieved successfully", h.settingsUseCase.Current())
+}
+
+// UpdateSettings godoc
+// @Summary Update runtime settings
+// @Description Change any of the settings, omitted ones are left as they are (Admin only).
+// @Description Changes apply right away without a redeploy, live terminals get a settings.updated event and other instances pick them up within JOB_SETTINGS_RELOAD_INTERVAL_SECONDS.
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body settings.UpdateSettingsRequest true "Settings to change"
+// @Success 200 {object} response.Response{data=settings.Settings}
+// @Failure 400 {object} response.Response
+// @Router /settings [put]
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	var req settings.UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.settingsUseCase.UpdateSettings(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			response.BadRequest(c, err.Error(), nil)
+			return
+		}
+		h.logger.Error("Failed to update settings", "error", err)
+		response.InternalError(c, "Failed to update settings", err.Error())
+		return
+	}
+
+	response.Success(c, "Settings updated successfully", result)
+}
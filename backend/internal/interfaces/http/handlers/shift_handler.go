@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/shift"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShiftHandler struct {
+	shiftUseCase *shift.ShiftUseCase
+	logger       logger.Logger
+}
+
+func NewShiftHandler(shiftUseCase *shift.ShiftUseCase, logger logger.Logger) *ShiftHandler {
+	return &ShiftHandler{
+		shiftUseCase: shiftUseCase,
+		logger:       logger,
+	}
+}
+
+// OpenShift godoc
+// @Summary Open a shift
+// @Description Open a cashier shift with the starting cash float. Sales made while it is open attach to it.
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body shift.OpenShiftRequest true "Opening float"
+// @Success 201 {object} response.Response{data=entities.Shift}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /shifts/open [post]
+func (h *ShiftHandler) OpenShift(c *gin.Context) {
+	var req shift.OpenShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.shiftUseCase.OpenShift(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to open shift", "error", err, "user_id", currentUser.UserID)
+		h.handleError(c, err, "Failed to open shift")
+		return
+	}
+
+	response.Created(c, "Shift opened successfully", result)
+}
+
+// CloseShift godoc
+// @Summary Close the current shift
+// @Description Close the current user's shift with the counted drawer cash and an optional handover note, and return its Z-report.
+// @Description A cash variance beyond the configured threshold leaves the shift pending a manager's approval.
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body shift.CloseShiftRequest true "Counted cash"
+// @Success 200 {object} response.Response{data=shift.ShiftReport}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /shifts/close [post]
+func (h *ShiftHandler) CloseShift(c *gin.Context) {
+	var req shift.CloseShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.shiftUseCase.CloseShift(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to close shift", "error", err, "user_id", currentUser.UserID)
+		h.handleError(c, err, "Failed to close shift")
+		return
+	}
+
+	response.Success(c, "Shift closed successfully", result)
+}
+
+// GetCurrentShift godoc
+// @Summary Get the current shift
+// @Description Get the current user's open shift with its running totals
+// @Tags shifts
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=entities.Shift}
+// @Failure 404 {object} response.Response
+// @Router /shifts/current [get]
+func (h *ShiftHandler) GetCurrentShift(c *gin.Context) {
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.shiftUseCase.GetCurrentShift(c.Request.Context(), currentUser.UserID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get current shift")
+		return
+	}
+
+	response.Success(c, "Current shift retrieved successfully", result)
+}
+
+// GetShiftReport godoc
+// @Summary Get shift Z-report
+// @Description Get the Z-report of a shift: expected vs counted cash, totals per payment method and the cash moved in and out of the drawer.
+// @Description Cashiers can only view their own shifts.
+// @Tags shifts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Shift ID"
+// @Success 200 {object} response.Response{data=shift.ShiftReport}
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /shifts/{id}/report [get]
+func (h *ShiftHandler) GetShiftReport(c *gin.Context) {
+	shiftID := c.Param("id")
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.shiftUseCase.GetShiftReport(c.Request.Context(), shiftID)
+	if err != nil {
+		h.handleError(c, err, "Failed to get shift report")
+		return
+	}
+
+	if currentUser.Role != entities.RoleAdmin && result.Shift.UserID != currentUser.UserID {
+		response.Forbidden(c, "You can only view your own shifts")
+		return
+	}
+
+	response.Success(c, "Shift report retrieved successfully", result)
+}
+
+// RecordCashMovement godoc
+// @Summary Record a cash drawer movement
+// @Description Record cash put into (pay_in) or taken out of (pay_out, drop) the drawer of the current user's shift, or the drawer opened without a sale (drawer_open, amount 0), with a reason.
+// @Description Pay-ins, pay-outs and drops change the cash the shift expects at closing.
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body shift.CashMovementRequest true "Cash movement"
+// @Success 201 {object} response.Response{data=entities.CashMovement}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /shifts/cash-movements [post]
+func (h *ShiftHandler) RecordCashMovement(c *gin.Context) {
+	var req shift.CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.shiftUseCase.RecordCashMovement(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to record cash movement", "error", err, "user_id", currentUser.UserID)
+		h.handleError(c, err, "Failed to record cash movement")
+		return
+	}
+
+	response.Created(c, "Cash movement recorded successfully", result)
+}
+
+// ListPendingApprovals godoc
+// @Summary List shifts awaiting variance approval
+// @Description List shifts closed with a cash variance beyond the threshold that a manager has yet to approve (Admin only)
+// @Tags shifts
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=[]entities.Shift}
+// @Router /shifts/pending-approval [get]
+func (h *ShiftHandler) ListPendingApprovals(c *gin.Context) {
+	result, err := h.shiftUseCase.ListPendingApprovals(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err, "Failed to list shifts awaiting approval")
+		return
+	}
+
+	response.Success(c, "Shifts awaiting approval retrieved successfully", result)
+}
+
+// ApproveVariance godoc
+// @Summary Approve a shift cash variance
+// @Description Accept the cash variance of a shift closed pending approval and finalize it (Admin only).
+// @Description Managers cannot approve their own shifts.
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Shift ID"
+// @Param request body shift.ApproveVarianceRequest true "Approval note"
+// @Success 200 {object} response.Response{data=shift.ShiftReport}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /shifts/{id}/approve [post]
+func (h *ShiftHandler) ApproveVariance(c *gin.Context) {
+	shiftID := c.Param("id")
+
+	var req shift.ApproveVarianceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.shiftUseCase.ApproveVariance(c.Request.Context(), shiftID, currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to approve shift variance", "error", err, "shift_id", shiftID)
+		h.handleError(c, err, "Failed to approve shift variance")
+		return
+	}
+
+	response.Success(c, "Shift variance approved successfully", result)
+}
+
+func (h *ShiftHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, appErrors.ErrShiftNotFound), errors.Is(err, appErrors.ErrNoOpenShift):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrShiftAlreadyOpen), errors.Is(err, appErrors.ErrShiftNotPending):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, appErrors.ErrForbidden):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, appErrors.ErrInvalidInput):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message, err.Error())
+	}
+}
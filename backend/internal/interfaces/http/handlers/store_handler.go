@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/store"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StoreHandler struct {
+	storeUseCase *store.StoreUseCase
+	logger       logger.Logger
+}
+
+func NewStoreHandler(storeUseCase *store.StoreUseCase, logger logger.Logger) *StoreHandler {
+	return &StoreHandler{
+		storeUseCase: storeUseCase,
+		logger:       logger,
+	}
+}
+
+// CreateStore godoc
+// @Summary Create a store
+// @Description Open a new outlet. Products, cashiers and sales are then added to it. (Admin only)
+// @Tags stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body store.StoreRequest true "Store data"
+// @Success 201 {object} response.Response{data=entities.Store}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /stores [post]
+func (h *StoreHandler) CreateStore(c *gin.Context) {
+	var req store.StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.storeUseCase.CreateStore(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to create store", "error", err)
+		if errors.Is(err, appErrors.ErrStoreExists) {
+			response.Conflict(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Store created successfully", result)
+}
+
+// GetStore godoc
+// @Summary Get store by ID
+// @Description Get a store (Admin only)
+// @Tags stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Store ID"
+// @Success 200 {object} response.Response{data=entities.Store}
+// @Failure 404 {object} response.Response
+// @Router /stores/{id} [get]
+func (h *StoreHandler) GetStore(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.storeUseCase.GetStore(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get store", "error", err, "store_id", id)
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Store retrieved successfully", result)
+}
+
+// UpdateStore godoc
+// @Summary Update a store
+// @Description Update the details of a store, or deactivate it (Admin only)
+// @Tags stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Store ID"
+// @Param request body store.StoreRequest true "Store data"
+// @Success 200 {object} response.Response{data=entities.Store}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /stores/{id} [put]
+func (h *StoreHandler) UpdateStore(c *gin.Context) {
+	id := c.Param("id")
+
+	var req store.StoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.storeUseCase.UpdateStore(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update store", "error", err, "store_id", id)
+		switch {
+		case errors.Is(err, appErrors.ErrStoreNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrStoreExists):
+			response.Conflict(c, err.Error())
+		default:
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(c, "Store updated successfully", result)
+}
+
+// ListStores godoc
+// @Summary List stores
+// @Description Get every store by name (Admin only)
+// @Tags stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Number of stores to return" default(20)
+// @Param offset query int false "Number of stores to skip" default(0)
+// @Success 200 {object} response.Response{data=[]entities.Store}
+// @Router /stores [get]
+func (h *StoreHandler) ListStores(c *gin.Context) {
+	var filters store.StoreFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if filters.Limit == 0 {
+		filters.Limit = 20
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.storeUseCase.ListStores(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list stores", "error", err)
+		response.InternalError(c, "Failed to retrieve stores", err.Error())
+		return
+	}
+
+	response.Success(c, "Stores retrieved successfully", result)
+}
+
+// PurgeTrainingData godoc
+// @Summary Purge training data
+// @Description Delete the training transactions of a store with their payments, refunds and receipts. Real sales are kept. (Admin only)
+// @Tags stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Store ID"
+// @Success 200 {object} response.Response{data=store.PurgeTrainingResponse}
+// @Failure 404 {object} response.Response
+// @Router /stores/{id}/training-data [delete]
+func (h *StoreHandler) PurgeTrainingData(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.storeUseCase.PurgeTrainingData(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrStoreNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to purge training data", "error", err, "store_id", id)
+		response.InternalError(c, "Failed to purge training data", err.Error())
+		return
+	}
+
+	response.Success(c, "Training data purged successfully", result)
+}
+
+// GetGatewayCredential godoc
+// @Summary Get the Midtrans account of a store
+// @Description Tell whether a store takes payments with a Midtrans account of its own. The server key is never returned, only its last characters. (Admin only)
+// @Tags stores
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Store ID"
+// @Success 200 {object} response.Response{data=store.GatewayCredentialResponse}
+// @Failure 404 {object} response.Response
+// @Router /stores/{id}/gateway-credentials [get]
+func (h *StoreHandler) GetGatewayCredential(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.storeUseCase.GetGatewayCredential(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrStoreNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get gateway credential", "error", err, "store_id", id)
+		response.InternalError(c, "Failed to get gateway credential", err.Error())
+		return
+	}
+
+	response.Success(c, "Gateway credential retrieved successfully", result)
+}
+
+// SetGatewayCredential godoc
+// @Summary Set the Midtrans account of a store
+// @Description Make a store take its Midtrans payments with an account of its own instead of the merchant's or the configured one (Admin only).
+// @Description The server key is encrypted with SECRETS_ENCRYPTION_KEY, it can't be stored without one.
+// @Tags stores
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Store ID"
+// @Param request body store.GatewayCredentialRequest true "Midtrans account"
+// @Success 200 {object} response.Response{data=store.GatewayCredentialResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /stores/{id}/gateway-credentials [put]
+func (h *StoreHandler) SetGatewayCredential(c *gin.Context) {
+	id := c.Param("id")
+
+	var req store.GatewayCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.storeUseCase.SetGatewayCredential(c.Request.Context(), id, currentUser.UserID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrStoreNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to set gateway credential", "error", err, "store_id", id)
+			response.InternalError(c, "Failed to set gateway credential", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Gateway credential saved successfully", result)
+}
+
+// DeleteGatewayCredential godoc
+// @Summary Remove the Midtrans account of a store
+// @Description Make a store take its payments with the shared Midtrans account again (Admin only)
+// @Tags stores
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Store ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /stores/{id}/gateway-credentials [delete]
+func (h *StoreHandler) DeleteGatewayCredential(c *gin.Context) {
+	id := c.Param("id")
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.storeUseCase.DeleteGatewayCredential(c.Request.Context(), id, currentUser.UserID); err != nil {
+		if errors.Is(err, appErrors.ErrStoreNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to delete gateway credential", "error", err, "store_id", id)
+		response.InternalError(c, "Failed to delete gateway credential", err.Error())
+		return
+	}
+
+	response.Success(c, "Gateway credential deleted successfully", nil)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/offline"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncHandler struct {
+	syncUseCase *offline.SyncUseCase
+	logger      logger.Logger
+}
+
+func NewSyncHandler(syncUseCase *offline.SyncUseCase, logger logger.Logger) *SyncHandler {
+	return &SyncHandler{
+		syncUseCase: syncUseCase,
+		logger:      logger,
+	}
+}
+
+// PushTransactions godoc
+// @Summary Upload offline transactions
+// @Description Record paid sales a terminal made while offline, identified by UUIDs the terminal generated so a batch can be pushed again safely.
+// @Description Each sale gets its own result: accepted, duplicate, rejected or failed (push it again later). Accepted sales keep the prices the customer paid
+// @Description and list the conflicts the server resolved, such as changed prices, a recomputed total or stock that ran out.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body offline.PushTransactionsRequest true "Offline transactions"
+// @Success 200 {object} response.Response{data=offline.PushTransactionsResponse}
+// @Failure 400 {object} response.Response
+// @Router /sync/transactions [post]
+func (h *SyncHandler) PushTransactions(c *gin.Context) {
+	var req offline.PushTransactionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.syncUseCase.PushTransactions(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to sync offline transactions", "error", err, "user_id", currentUser.UserID)
+		h.handleError(c, err, "Failed to sync offline transactions")
+		return
+	}
+
+	response.Success(c, "Offline transactions synced", result)
+}
+
+// GetChanges godoc
+// @Summary Pull product changes
+// @Description Get the products of the store changed since the cursor, deleted ones included, oldest change first.
+// @Description Start without a cursor for a full download and pull again with next_cursor while has_more is true.
+// @Tags sync
+// @Produce json
+// @Security ApiKeyAuth
+// @Param cursor query string false "next_cursor of the previous pull"
+// @Param limit query int false "Changes per page, up to 1000"
+// @Success 200 {object} response.Response{data=offline.ChangesResponse}
+// @Failure 400 {object} response.Response
+// @Router /sync/changes [get]
+func (h *SyncHandler) GetChanges(c *gin.Context) {
+	var req offline.ChangesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.syncUseCase.GetChanges(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to get sync changes", "error", err)
+		h.handleError(c, err, "Failed to get changes")
+		return
+	}
+
+	response.Success(c, "Changes retrieved successfully", result)
+}
+
+func (h *SyncHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, appErrors.ErrUserNotFound), errors.Is(err, appErrors.ErrStoreNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrInvalidInput), errors.Is(err, appErrors.ErrStoreRequired):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"qris-pos-backend/internal/usecases/system"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SystemHandler struct {
+	systemStatusUseCase *system.SystemStatusUseCase
+	logger              logger.Logger
+}
+
+func NewSystemHandler(systemStatusUseCase *system.SystemStatusUseCase, logger logger.Logger) *SystemHandler {
+	return &SystemHandler{
+		systemStatusUseCase: systemStatusUseCase,
+		logger:              logger,
+	}
+}
+
+// GetSystemStatus godoc
+// @Summary Get system status
+// @Description Health of the background work in one place: pending and failed receipt deliveries, payment exceptions awaiting review, payments stuck pending past their expiry, background job failures, storage usage and the last successful payment reconciliation (Admin only)
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=system.SystemStatusResponse}
+// @Router /admin/system-status [get]
+func (h *SystemHandler) GetSystemStatus(c *gin.Context) {
+	result := h.systemStatusUseCase.GetSystemStatus(c.Request.Context())
+	response.Success(c, "System status retrieved successfully", result)
+}
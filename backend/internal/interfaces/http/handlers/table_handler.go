@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/table"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TableHandler struct {
+	tableUseCase *table.TableUseCase
+	logger       logger.Logger
+}
+
+func NewTableHandler(tableUseCase *table.TableUseCase, logger logger.Logger) *TableHandler {
+	return &TableHandler{
+		tableUseCase: tableUseCase,
+		logger:       logger,
+	}
+}
+
+// CreateTable godoc
+// @Summary Create a table
+// @Description Add a dining table to the floor plan of the store (Admin only)
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body table.TableRequest true "Table data"
+// @Success 201 {object} response.Response{data=entities.Table}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /tables [post]
+func (h *TableHandler) CreateTable(c *gin.Context) {
+	var req table.TableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.tableUseCase.CreateTable(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to create table", "error", err)
+		if errors.Is(err, appErrors.ErrTableExists) {
+			response.Conflict(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Table created successfully", result)
+}
+
+// GetTable godoc
+// @Summary Get table by ID
+// @Description Get a dining table
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Table ID"
+// @Success 200 {object} response.Response{data=entities.Table}
+// @Failure 404 {object} response.Response
+// @Router /tables/{id} [get]
+func (h *TableHandler) GetTable(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.tableUseCase.GetTable(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get table", "error", err, "table_id", id)
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Table retrieved successfully", result)
+}
+
+// UpdateTable godoc
+// @Summary Update a table
+// @Description Rename, move or deactivate a dining table. An order already open on it can still be settled. (Admin only)
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Table ID"
+// @Param request body table.TableRequest true "Table data"
+// @Success 200 {object} response.Response{data=entities.Table}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /tables/{id} [put]
+func (h *TableHandler) UpdateTable(c *gin.Context) {
+	id := c.Param("id")
+
+	var req table.TableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.tableUseCase.UpdateTable(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update table", "error", err, "table_id", id)
+		switch {
+		case errors.Is(err, appErrors.ErrTableNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrTableExists):
+			response.Conflict(c, err.Error())
+		default:
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(c, "Table updated successfully", result)
+}
+
+// DeleteTable godoc
+// @Summary Delete a table
+// @Description Remove a dining table from the floor plan. Tables with an open order can't be deleted. (Admin only)
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Table ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /tables/{id} [delete]
+func (h *TableHandler) DeleteTable(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.tableUseCase.DeleteTable(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete table", "error", err, "table_id", id)
+		switch {
+		case errors.Is(err, appErrors.ErrTableNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrTableOccupied):
+			response.Conflict(c, err.Error())
+		default:
+			response.InternalError(c, "Failed to delete table", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Table deleted successfully", nil)
+}
+
+// ListTables godoc
+// @Summary List tables
+// @Description Get the dining tables of the store by area and position
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param active_only query bool false "Only tables in service"
+// @Success 200 {object} response.Response{data=[]entities.Table}
+// @Router /tables [get]
+func (h *TableHandler) ListTables(c *gin.Context) {
+	var filters table.TableFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	result, err := h.tableUseCase.ListTables(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list tables", "error", err)
+		response.InternalError(c, "Failed to retrieve tables", err.Error())
+		return
+	}
+
+	response.Success(c, "Tables retrieved successfully", result)
+}
+
+// GetFloorPlan godoc
+// @Summary Get the floor plan
+// @Description Get the tables in service with the running total of the dine-in order open on each
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=table.FloorPlanResponse}
+// @Router /tables/floor-plan [get]
+func (h *TableHandler) GetFloorPlan(c *gin.Context) {
+	result, err := h.tableUseCase.GetFloorPlan(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get floor plan", "error", err)
+		response.InternalError(c, "Failed to retrieve floor plan", err.Error())
+		return
+	}
+
+	response.Success(c, "Floor plan retrieved successfully", result)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/tax"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxHandler struct {
+	taxUseCase *tax.TaxUseCase
+	logger     logger.Logger
+}
+
+func NewTaxHandler(taxUseCase *tax.TaxUseCase, logger logger.Logger) *TaxHandler {
+	return &TaxHandler{
+		taxUseCase: taxUseCase,
+		logger:     logger,
+	}
+}
+
+// CreateTaxRule godoc
+// @Summary Create a tax rule
+// @Description Create a tax rule such as PPN, charged on every taxable item of new and pending transactions (Admin only)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body tax.TaxRuleRequest true "Tax rule data"
+// @Success 201 {object} response.Response{data=entities.TaxRule}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /tax-rules [post]
+func (h *TaxHandler) CreateTaxRule(c *gin.Context) {
+	var req tax.TaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.taxUseCase.CreateTaxRule(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to create tax rule", "error", err)
+		if errors.Is(err, appErrors.ErrCategoryNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Created(c, "Tax rule created successfully", result)
+}
+
+// GetTaxRule godoc
+// @Summary Get tax rule by ID
+// @Description Get a single tax rule with its exempt categories (Admin only)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tax rule ID"
+// @Success 200 {object} response.Response{data=entities.TaxRule}
+// @Failure 404 {object} response.Response
+// @Router /tax-rules/{id} [get]
+func (h *TaxHandler) GetTaxRule(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.taxUseCase.GetTaxRule(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to get tax rule", "error", err, "tax_rule_id", id)
+		response.NotFound(c, err.Error())
+		return
+	}
+
+	response.Success(c, "Tax rule retrieved successfully", result)
+}
+
+// UpdateTaxRule godoc
+// @Summary Update a tax rule
+// @Description Update a tax rule and replace its exempt categories. Paid transactions keep the tax they were charged. (Admin only)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tax rule ID"
+// @Param request body tax.TaxRuleRequest true "Tax rule data"
+// @Success 200 {object} response.Response{data=entities.TaxRule}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /tax-rules/{id} [put]
+func (h *TaxHandler) UpdateTaxRule(c *gin.Context) {
+	id := c.Param("id")
+
+	var req tax.TaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.taxUseCase.UpdateTaxRule(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update tax rule", "error", err, "tax_rule_id", id)
+		if errors.Is(err, appErrors.ErrTaxRuleNotFound) || errors.Is(err, appErrors.ErrCategoryNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Tax rule updated successfully", result)
+}
+
+// DeleteTaxRule godoc
+// @Summary Delete a tax rule
+// @Description Delete a tax rule. Transactions keep the taxes already charged under it. (Admin only)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tax rule ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /tax-rules/{id} [delete]
+func (h *TaxHandler) DeleteTaxRule(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.taxUseCase.DeleteTaxRule(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete tax rule", "error", err, "tax_rule_id", id)
+		if errors.Is(err, appErrors.ErrTaxRuleNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		response.InternalError(c, "Failed to delete tax rule", err.Error())
+		return
+	}
+
+	response.Success(c, "Tax rule deleted successfully", nil)
+}
+
+// ListTaxRules godoc
+// @Summary List tax rules
+// @Description Get every tax rule with its exempt categories (Admin only)
+// @Tags taxes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=[]entities.TaxRule}
+// @Router /tax-rules [get]
+func (h *TaxHandler) ListTaxRules(c *gin.Context) {
+	result, err := h.taxUseCase.ListTaxRules(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list tax rules", "error", err)
+		response.InternalError(c, "Failed to retrieve tax rules", err.Error())
+		return
+	}
+
+	response.Success(c, "Tax rules retrieved successfully", result)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/usecases/tenant"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TenantHandler struct {
+	tenantUseCase *tenant.TenantUseCase
+	logger        logger.Logger
+}
+
+func NewTenantHandler(tenantUseCase *tenant.TenantUseCase, logger logger.Logger) *TenantHandler {
+	return &TenantHandler{
+		tenantUseCase: tenantUseCase,
+		logger:        logger,
+	}
+}
+
+// CreateTenant godoc
+// @Summary Onboard a tenant
+// @Description Create a tenant with its first store and admin in SaaS mode (platform admins only). The admin logs in on the tenant's subdomain, or with the X-Tenant-ID header, and sets up the rest.
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body tenant.CreateTenantRequest true "Tenant, store and admin"
+// @Success 201 {object} response.Response{data=tenant.OnboardTenantResponse}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /tenants [post]
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req tenant.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.tenantUseCase.CreateTenant(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrTenantExists), errors.Is(err, appErrors.ErrEmailExists):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to create tenant", "error", err)
+			response.InternalError(c, "Failed to create tenant", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Tenant created successfully", result)
+}
+
+// GetTenant godoc
+// @Summary Get tenant by ID
+// @Description Get a tenant (platform admins only)
+// @Tags tenants
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} response.Response{data=entities.Tenant}
+// @Failure 404 {object} response.Response
+// @Router /tenants/{id} [get]
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.tenantUseCase.GetTenant(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTenantNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to get tenant", "error", err, "tenant_id", id)
+		response.InternalError(c, "Failed to get tenant", err.Error())
+		return
+	}
+
+	response.Success(c, "Tenant retrieved successfully", result)
+}
+
+// UpdateTenant godoc
+// @Summary Update a tenant
+// @Description Rename a tenant, or suspend it to refuse every request to it while keeping its data (platform admins only)
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Param request body tenant.UpdateTenantRequest true "Tenant data"
+// @Success 200 {object} response.Response{data=entities.Tenant}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /tenants/{id} [put]
+func (h *TenantHandler) UpdateTenant(c *gin.Context) {
+	id := c.Param("id")
+
+	var req tenant.UpdateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.tenantUseCase.UpdateTenant(c.Request.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrTenantNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to update tenant", "error", err, "tenant_id", id)
+			response.InternalError(c, "Failed to update tenant", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Tenant updated successfully", result)
+}
+
+// ListTenants godoc
+// @Summary List tenants
+// @Description Get every tenant by slug (platform admins only)
+// @Tags tenants
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Number of tenants to return" default(20)
+// @Param offset query int false "Number of tenants to skip" default(0)
+// @Success 200 {object} response.Response{data=[]entities.Tenant}
+// @Router /tenants [get]
+func (h *TenantHandler) ListTenants(c *gin.Context) {
+	var filters tenant.TenantFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if filters.Limit == 0 {
+		filters.Limit = 20
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.tenantUseCase.ListTenants(c.Request.Context(), &filters)
+	if err != nil {
+		h.logger.Error("Failed to list tenants", "error", err)
+		response.InternalError(c, "Failed to retrieve tenants", err.Error())
+		return
+	}
+
+	response.Success(c, "Tenants retrieved successfully", result)
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
 	"qris-pos-backend/internal/interfaces/middleware"
 	"qris-pos-backend/internal/usecases/transaction"
+	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
 	"qris-pos-backend/pkg/response"
 	"qris-pos-backend/pkg/validator"
@@ -28,7 +30,7 @@ func NewTransactionHandler(transactionUseCase *transaction.TransactionUseCase, l
 
 // CreateTransaction godoc
 // @Summary Create a new transaction
-// @Description Create a new transaction with items (shopping cart checkout)
+// @Description Create a new transaction with items (shopping cart checkout). With a table_id it opens a dine-in order on the table, settled when the guests leave.
 // @Tags transactions
 // @Accept json
 // @Produce json
@@ -37,6 +39,8 @@ func NewTransactionHandler(transactionUseCase *transaction.TransactionUseCase, l
 // @Success 201 {object} response.Response{data=transaction.TransactionResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
 // @Router /transactions [post]
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	var req transaction.CreateTransactionRequest
@@ -64,7 +68,14 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	result, err := h.transactionUseCase.CreateTransaction(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.Error("Failed to create transaction", "error", err, "user_id", req.UserID)
-		response.BadRequest(c, err.Error(), nil)
+		switch {
+		case errors.Is(err, appErrors.ErrTableNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrTableOccupied):
+			response.Conflict(c, err.Error())
+		default:
+			response.BadRequest(c, err.Error(), nil)
+		}
 		return
 	}
 
@@ -97,12 +108,13 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 
 // ListTransactions godoc
 // @Summary List transactions
-// @Description Get a list of transactions with optional filters
+// @Description Get a list of transactions with optional filters. A customer's paper receipt is found by its receipt number.
 // @Tags transactions
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param user_id query string false "Filter by user ID"
+// @Param receipt_no query string false "Filter by the number printed on the receipt, e.g. INV-20240521-0001"
 // @Param status query string false "Filter by status"
 // @Param date_from query string false "Filter by date from (YYYY-MM-DD)"
 // @Param date_to query string false "Filter by date to (YYYY-MM-DD)"
@@ -112,9 +124,10 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 // @Router /transactions [get]
 func (h *TransactionHandler) ListTransactions(c *gin.Context) {
 	filters := repositories.TransactionFilters{
-		UserID: c.Query("user_id"),
-		Limit:  20, // default
-		Offset: 0,  // default
+		UserID:    c.Query("user_id"),
+		ReceiptNo: entities.NormalizeReceiptNumber(c.Query("receipt_no")),
+		Limit:     20, // default
+		Offset:    0,  // default
 	}
 
 	// Convert status string to enum if provided
@@ -154,7 +167,7 @@ func (h *TransactionHandler) ListTransactions(c *gin.Context) {
 
 // AddItemToTransaction godoc
 // @Summary Add item to transaction
-// @Description Add a product item to an existing pending transaction
+// @Description Add a product item to an existing pending transaction, by product ID or by scanned barcode
 // @Tags transactions
 // @Accept json
 // @Produce json
@@ -229,7 +242,7 @@ func (h *TransactionHandler) RemoveItemFromTransaction(c *gin.Context) {
 // @Success 200 {object} response.Response{data=transaction.TransactionResponse}
 // @Failure 400 {object} response.Response
 // @Failure 404 {object} response.Response
-// @Router /transactions/{id}/items/{product_id} [patch]
+// @Router /transactions/{id}/items/{product_id} [put]
 func (h *TransactionHandler) UpdateItemQuantity(c *gin.Context) {
 	id := c.Param("id")
 	productID := c.Param("product_id")
@@ -255,6 +268,387 @@ func (h *TransactionHandler) UpdateItemQuantity(c *gin.Context) {
 	response.Success(c, "Item quantity updated successfully", result)
 }
 
+// ReorderItems godoc
+// @Summary Reorder transaction items
+// @Description Set the display order of the items on receipts and kitchen tickets
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body transaction.ReorderItemsRequest true "Item IDs in display order"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /transactions/{id}/items/order [put]
+func (h *TransactionHandler) ReorderItems(c *gin.Context) {
+	id := c.Param("id")
+
+	var req transaction.ReorderItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.transactionUseCase.ReorderItems(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to reorder transaction items", "error", err, "transaction_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Transaction items reordered successfully", result)
+}
+
+// ApplyPromoCode godoc
+// @Summary Apply a promo code
+// @Description Enter a customer's promo code on a pending transaction. The total is recalculated with the code's promotion alongside the automatic ones.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body transaction.ApplyPromoCodeRequest true "Promo code"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /transactions/{id}/promo-code [post]
+func (h *TransactionHandler) ApplyPromoCode(c *gin.Context) {
+	id := c.Param("id")
+
+	var req transaction.ApplyPromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.transactionUseCase.ApplyPromoCode(c.Request.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrTransactionNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrPromoCodeUsedUp):
+			response.Conflict(c, err.Error())
+		default:
+			h.logger.Error("Failed to apply promo code", "error", err, "transaction_id", id)
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(c, "Promo code applied successfully", result)
+}
+
+// RemovePromoCode godoc
+// @Summary Remove the promo code
+// @Description Take the promo code off a pending transaction and recalculate its total
+// @Tags transactions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/promo-code [delete]
+func (h *TransactionHandler) RemovePromoCode(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.transactionUseCase.RemovePromoCode(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to remove promo code", "error", err, "transaction_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Promo code removed successfully", result)
+}
+
+// ApplyTransactionDiscount godoc
+// @Summary Discount the whole cart
+// @Description Give a percentage or nominal discount on a pending transaction, applied after promotions and item discounts. Discounts above the cashier's limit need an admin's credentials.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body transaction.ApplyDiscountRequest true "Discount"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 422 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /transactions/{id}/discount [post]
+func (h *TransactionHandler) ApplyTransactionDiscount(c *gin.Context) {
+	id := c.Param("id")
+
+	var req transaction.ApplyDiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.transactionUseCase.ApplyTransactionDiscount(c.Request.Context(), id, currentUser.UserID, currentUser.Role, &req)
+	if err != nil {
+		h.respondDiscountError(c, err, "Failed to apply transaction discount", "transaction_id", id)
+		return
+	}
+
+	response.Success(c, "Discount applied successfully", result)
+}
+
+// RemoveTransactionDiscount godoc
+// @Summary Remove the cart discount
+// @Description Take the manual discount off the whole cart of a pending transaction
+// @Tags transactions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/discount [delete]
+func (h *TransactionHandler) RemoveTransactionDiscount(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.transactionUseCase.RemoveTransactionDiscount(c.Request.Context(), id)
+	if err != nil {
+		h.respondDiscountError(c, err, "Failed to remove transaction discount", "transaction_id", id)
+		return
+	}
+
+	response.Success(c, "Discount removed successfully", result)
+}
+
+// ApplyItemDiscount godoc
+// @Summary Discount an item
+// @Description Give a percentage or nominal discount on a product of a pending transaction. A nominal discount is taken off each unit. Discounts above the cashier's limit need an admin's credentials.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param product_id path string true "Product ID"
+// @Param request body transaction.ApplyDiscountRequest true "Discount"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 422 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /transactions/{id}/items/{product_id}/discount [post]
+func (h *TransactionHandler) ApplyItemDiscount(c *gin.Context) {
+	id := c.Param("id")
+	productID := c.Param("product_id")
+
+	var req transaction.ApplyDiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.transactionUseCase.ApplyItemDiscount(c.Request.Context(), id, productID, currentUser.UserID, currentUser.Role, &req)
+	if err != nil {
+		h.respondDiscountError(c, err, "Failed to apply item discount", "transaction_id", id, "product_id", productID)
+		return
+	}
+
+	response.Success(c, "Discount applied successfully", result)
+}
+
+// RemoveItemDiscount godoc
+// @Summary Remove an item discount
+// @Description Take the manual discount off a product of a pending transaction
+// @Tags transactions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param product_id path string true "Product ID"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/items/{product_id}/discount [delete]
+func (h *TransactionHandler) RemoveItemDiscount(c *gin.Context) {
+	id := c.Param("id")
+	productID := c.Param("product_id")
+
+	result, err := h.transactionUseCase.RemoveItemDiscount(c.Request.Context(), id, productID)
+	if err != nil {
+		h.respondDiscountError(c, err, "Failed to remove item discount", "transaction_id", id, "product_id", productID)
+		return
+	}
+
+	response.Success(c, "Discount removed successfully", result)
+}
+
+func (h *TransactionHandler) respondDiscountError(c *gin.Context, err error, message string, keysAndValues ...interface{}) {
+	switch {
+	case errors.Is(err, appErrors.ErrTransactionNotFound),
+		errors.Is(err, appErrors.ErrOrderItemNotFound):
+		response.NotFound(c, err.Error())
+	// Wrong manager credentials are a 403, a 401 would sign the cashier out of the register
+	case errors.Is(err, appErrors.ErrInvalidCredentials),
+		errors.Is(err, appErrors.ErrApprovalRequired):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, appErrors.ErrDiscountTooLarge):
+		response.UnprocessableEntity(c, err.Error(), nil)
+	default:
+		h.logger.Error(message, append([]interface{}{"error", err}, keysAndValues...)...)
+		response.BadRequest(c, err.Error(), nil)
+	}
+}
+
+// AttachCustomer godoc
+// @Summary Attach a customer
+// @Description Link a loyalty member to a pending transaction so it earns them points once paid. Points redeemed for another customer are given back.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body transaction.AttachCustomerRequest true "Customer"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/customer [put]
+func (h *TransactionHandler) AttachCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	var req transaction.AttachCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.transactionUseCase.AttachCustomer(c.Request.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) || errors.Is(err, appErrors.ErrCustomerNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to attach customer", "error", err, "transaction_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Customer attached successfully", result)
+}
+
+// DetachCustomer godoc
+// @Summary Detach the customer
+// @Description Unlink the loyalty member of a pending transaction and give back the points redeemed on it
+// @Tags transactions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/customer [delete]
+func (h *TransactionHandler) DetachCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.transactionUseCase.DetachCustomer(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to detach customer", "error", err, "transaction_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Customer detached successfully", result)
+}
+
+// RedeemPoints godoc
+// @Summary Redeem loyalty points
+// @Description Spend points of the attached customer as a discount on a pending transaction. The points are held until it is paid; 0 removes the redemption.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body transaction.RedeemPointsRequest true "Points to redeem"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 422 {object} response.Response
+// @Router /transactions/{id}/points [post]
+func (h *TransactionHandler) RedeemPoints(c *gin.Context) {
+	id := c.Param("id")
+
+	var req transaction.RedeemPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.transactionUseCase.RedeemPoints(c.Request.Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrTransactionNotFound), errors.Is(err, appErrors.ErrCustomerNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrInsufficientPoints), errors.Is(err, appErrors.ErrTrainingPoints):
+			response.UnprocessableEntity(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to redeem points", "error", err, "transaction_id", id)
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(c, "Points redeemed successfully", result)
+}
+
 // CancelTransaction godoc
 // @Summary Cancel a transaction
 // @Description Cancel a pending transaction
@@ -279,3 +673,105 @@ func (h *TransactionHandler) CancelTransaction(c *gin.Context) {
 
 	response.Success(c, "Transaction cancelled successfully", nil)
 }
+
+// HoldTransaction godoc
+// @Summary Hold a transaction
+// @Description Park a pending cart under a label, e.g. "Table 5", to serve the next customer. Held carts keep their stock and don't expire.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body transaction.HoldTransactionRequest true "Hold label"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/hold [post]
+func (h *TransactionHandler) HoldTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	var req transaction.HoldTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.transactionUseCase.HoldTransaction(c.Request.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to hold transaction", "error", err, "transaction_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Transaction held successfully", result)
+}
+
+// ResumeTransaction godoc
+// @Summary Resume a held transaction
+// @Description Take a held cart back to the register so it can be paid
+// @Tags transactions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Success 200 {object} response.Response{data=transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /transactions/{id}/hold [delete]
+func (h *TransactionHandler) ResumeTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.transactionUseCase.ResumeTransaction(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTransactionNotFound) {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.logger.Error("Failed to resume transaction", "error", err, "transaction_id", id)
+		response.BadRequest(c, err.Error(), nil)
+		return
+	}
+
+	response.Success(c, "Transaction resumed successfully", result)
+}
+
+// ListHeldTransactions godoc
+// @Summary List held transactions
+// @Description List the carts parked on hold in the store, from every register
+// @Tags transactions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]transaction.TransactionResponse}
+// @Failure 400 {object} response.Response
+// @Router /transactions/held [get]
+func (h *TransactionHandler) ListHeldTransactions(c *gin.Context) {
+	var req transaction.ListHeldTransactionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.transactionUseCase.ListHeldTransactions(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to list held transactions", "error", err)
+		response.InternalError(c, "Failed to retrieve held transactions", err.Error())
+		return
+	}
+
+	response.Success(c, "Held transactions retrieved successfully", result)
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/refund"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VoidHandler struct {
+	voidUseCase *refund.VoidUseCase
+	logger      logger.Logger
+}
+
+func NewVoidHandler(voidUseCase *refund.VoidUseCase, logger logger.Logger) *VoidHandler {
+	return &VoidHandler{
+		voidUseCase: voidUseCase,
+		logger:      logger,
+	}
+}
+
+// RequestVoid godoc
+// @Summary Request a void
+// @Description Ask a manager to void a paid transaction. Nothing changes until the request is approved.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Transaction ID"
+// @Param request body refund.CreateVoidRequest true "Void reason"
+// @Success 201 {object} response.Response{data=entities.VoidRequest}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /transactions/{id}/void-requests [post]
+func (h *VoidHandler) RequestVoid(c *gin.Context) {
+	transactionID := c.Param("id")
+
+	var req refund.CreateVoidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.voidUseCase.RequestVoid(c.Request.Context(), transactionID, currentUser.UserID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrTransactionNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrVoidPending):
+			response.Conflict(c, err.Error())
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			response.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("Failed to request void", "error", err, "transaction_id", transactionID)
+			response.InternalError(c, "Failed to request void", err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "Void requested successfully", result)
+}
+
+// ListVoidRequests godoc
+// @Summary List void requests
+// @Description List the void requests of the store, oldest first (Admin only)
+// @Tags voids
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Request status" Enums(pending, approved, rejected)
+// @Param limit query int false "Limit" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.VoidRequest}
+// @Failure 400 {object} response.Response
+// @Router /void-requests [get]
+func (h *VoidHandler) ListVoidRequests(c *gin.Context) {
+	var req refund.ListVoidRequestsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.voidUseCase.ListVoidRequests(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("Failed to list void requests", "error", err)
+		response.InternalError(c, "Failed to list void requests", err.Error())
+		return
+	}
+
+	response.Success(c, "Void requests retrieved successfully", result)
+}
+
+// ApproveVoid godoc
+// @Summary Approve a void
+// @Description Void the transaction of a pending request: it is refunded in full and its items are restocked.
+// @Description A manager approves on the cashier's register with their own credentials, a signed in admin can approve without them.
+// @Description When the gateway refund fails the void is settled manually through the payment exceptions queue.
+// @Tags voids
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Void request ID"
+// @Param request body refund.ApproveVoidRequest false "Manager credentials"
+// @Success 200 {object} response.Response{data=entities.VoidRequest}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /void-requests/{id}/approve [post]
+func (h *VoidHandler) ApproveVoid(c *gin.Context) {
+	requestID := c.Param("id")
+
+	var req refund.ApproveVoidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.voidUseCase.ApproveVoid(c.Request.Context(), requestID, currentUser.UserID, currentUser.Role, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrVoidRequestNotFound),
+			errors.Is(err, appErrors.ErrPaymentNotFound),
+			errors.Is(err, appErrors.ErrTransactionNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrVoidRequestDecided):
+			response.Conflict(c, err.Error())
+		// Wrong manager credentials are a 403, a 401 would sign the cashier out of the register
+		case errors.Is(err, appErrors.ErrInvalidCredentials),
+			errors.Is(err, appErrors.ErrApprovalRequired),
+			errors.Is(err, appErrors.ErrForbidden):
+			response.Forbidden(c, err.Error())
+		default:
+			h.logger.Error("Failed to approve void", "error", err, "void_request_id", requestID)
+			response.BadRequest(c, err.Error(), nil)
+		}
+		return
+	}
+
+	response.Success(c, "Transaction voided successfully", result)
+}
+
+// RejectVoid godoc
+// @Summary Reject a void
+// @Description Close a pending void request without changing the transaction (Admin only)
+// @Tags voids
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Void request ID"
+// @Param request body refund.RejectVoidRequest true "Rejection note"
+// @Success 200 {object} response.Response{data=entities.VoidRequest}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /void-requests/{id}/reject [post]
+func (h *VoidHandler) RejectVoid(c *gin.Context) {
+	requestID := c.Param("id")
+
+	var req refund.RejectVoidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.voidUseCase.RejectVoid(c.Request.Context(), requestID, currentUser.UserID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, appErrors.ErrVoidRequestNotFound):
+			response.NotFound(c, err.Error())
+		case errors.Is(err, appErrors.ErrVoidRequestDecided):
+			response.Conflict(c, err.Error())
+		default:
+			h.logger.Error("Failed to reject void", "error", err, "void_request_id", requestID)
+			response.InternalError(c, "Failed to reject void", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, "Void request rejected successfully", result)
+}
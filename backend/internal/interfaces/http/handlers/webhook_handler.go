@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/webhook"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+	"qris-pos-backend/pkg/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookUseCase *webhook.WebhookUseCase
+	logger         logger.Logger
+}
+
+func NewWebhookHandler(webhookUseCase *webhook.WebhookUseCase, logger logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUseCase: webhookUseCase,
+		logger:         logger,
+	}
+}
+
+// CreateEndpoint godoc
+// @Summary Register a webhook endpoint
+// @Description Register a URL that receives POS events (payment.settled, transaction.created, stock.low) of the selected store, or of every store when none is selected (Admin only).
+// @Description Every request carries X-Webhook-Signature: sha256=HMAC-SHA256(secret, "<X-Webhook-Timestamp>.<body>"). The secret is only returned here and when rotated.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body webhook.EndpointRequest true "Endpoint"
+// @Success 201 {object} response.Response{data=webhook.EndpointResponse}
+// @Failure 400 {object} response.Response
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req webhook.EndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	result, err := h.webhookUseCase.CreateEndpoint(c.Request.Context(), currentUser.UserID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create webhook endpoint", "error", err)
+		h.handleError(c, err, "Failed to create webhook endpoint")
+		return
+	}
+
+	response.Created(c, "Webhook endpoint created successfully", result)
+}
+
+// ListEndpoints godoc
+// @Summary List webhook endpoints
+// @Description List the webhook endpoints of the selected store, or of every store when none is selected (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Response{data=[]webhook.EndpointResponse}
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	result, err := h.webhookUseCase.ListEndpoints(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list webhook endpoints", "error", err)
+		response.InternalError(c, "Failed to list webhook endpoints", err.Error())
+		return
+	}
+
+	response.Success(c, "Webhook endpoints retrieved successfully", result)
+}
+
+// GetEndpoint godoc
+// @Summary Get a webhook endpoint
+// @Description Get a webhook endpoint, without its secret (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Endpoint ID"
+// @Success 200 {object} response.Response{data=webhook.EndpointResponse}
+// @Failure 404 {object} response.Response
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.webhookUseCase.GetEndpoint(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to get webhook endpoint")
+		return
+	}
+
+	response.Success(c, "Webhook endpoint retrieved successfully", result)
+}
+
+// UpdateEndpoint godoc
+// @Summary Update a webhook endpoint
+// @Description Change the URL, events or state of a webhook endpoint, deliveries already queued still go out (Admin only)
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Endpoint ID"
+// @Param request body webhook.EndpointRequest true "Endpoint"
+// @Success 200 {object} response.Response{data=webhook.EndpointResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	id := c.Param("id")
+
+	var req webhook.EndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(req); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.webhookUseCase.UpdateEndpoint(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("Failed to update webhook endpoint", "error", err, "endpoint_id", id)
+		h.handleError(c, err, "Failed to update webhook endpoint")
+		return
+	}
+
+	response.Success(c, "Webhook endpoint updated successfully", result)
+}
+
+// RotateSecret godoc
+// @Summary Rotate a webhook secret
+// @Description Give a webhook endpoint a new signing secret and return it, requests are signed with it from now on (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Endpoint ID"
+// @Success 200 {object} response.Response{data=webhook.EndpointResponse}
+// @Failure 404 {object} response.Response
+// @Router /webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.webhookUseCase.RotateSecret(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to rotate webhook secret", "error", err, "endpoint_id", id)
+		h.handleError(c, err, "Failed to rotate webhook secret")
+		return
+	}
+
+	response.Success(c, "Webhook secret rotated successfully", result)
+}
+
+// DeleteEndpoint godoc
+// @Summary Delete a webhook endpoint
+// @Description Stop sending events to a webhook endpoint, its queued deliveries are dropped (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Endpoint ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.webhookUseCase.DeleteEndpoint(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete webhook endpoint", "error", err, "endpoint_id", id)
+		h.handleError(c, err, "Failed to delete webhook endpoint")
+		return
+	}
+
+	response.Success(c, "Webhook endpoint deleted successfully", nil)
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description Get the delivery log of a webhook endpoint, newest first, with the payload, attempts and last response of each delivery (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Endpoint ID"
+// @Param status query string false "Filter by status" Enums(pending, sent, failed)
+// @Param limit query int false "Page size" default(50)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} response.Response{data=[]entities.WebhookDelivery}
+// @Failure 404 {object} response.Response
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id := c.Param("id")
+
+	var filters webhook.DeliveryFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		response.BadRequest(c, "Invalid query parameters", err.Error())
+		return
+	}
+
+	if errors := validator.ValidateStruct(filters); len(errors) > 0 {
+		response.ValidationError(c, errors)
+		return
+	}
+
+	result, err := h.webhookUseCase.ListDeliveries(c.Request.Context(), id, &filters)
+	if err != nil {
+		h.handleError(c, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	response.Success(c, "Webhook deliveries retrieved successfully", result)
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook
+// @Description Queue a sent or failed delivery again with a fresh set of attempts, with the same payload and delivery ID (Admin only)
+// @Tags webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Endpoint ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} response.Response{data=entities.WebhookDelivery}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id := c.Param("id")
+	deliveryID := c.Param("delivery_id")
+
+	result, err := h.webhookUseCase.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		h.logger.Error("Failed to redeliver webhook", "error", err, "delivery_id", deliveryID)
+		h.handleError(c, err, "Failed to redeliver webhook")
+		return
+	}
+
+	response.Success(c, "Webhook delivery queued", result)
+}
+
+func (h *WebhookHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, appErrors.ErrWebhookEndpointNotFound), errors.Is(err, appErrors.ErrWebhookDeliveryNotFound):
+		response.NotFound(c, err.Error())
+	case errors.Is(err, appErrors.ErrInvalidInput):
+		response.BadRequest(c, err.Error(), nil)
+	default:
+		response.InternalError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/internal/infrastructure/websocket"
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebSocketHandler struct {
+	hub    *websocket.Hub
+	logger logger.Logger
+}
+
+func NewWebSocketHandler(hub *websocket.Hub, logger logger.Logger) *WebSocketHandler {
+	return &WebSocketHandler{
+		hub:    hub,
+		logger: logger,
+	}
+}
+
+// Connect godoc
+// @Summary Live POS updates
+// @Description Open a WebSocket that pushes payment settlements, stock changes and new transactions.
+// @Description Browsers can pass the JWT as the access_token query parameter.
+// @Tags realtime
+// @Security ApiKeyAuth
+// @Param access_token query string false "JWT when the Authorization header cannot be set"
+// @Success 101 "Switching Protocols"
+// @Failure 401 {object} response.Response
+// @Router /ws [get]
+func (h *WebSocketHandler) Connect(c *gin.Context) {
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	tenantID, _ := repositories.TenantFromContext(c.Request.Context())
+	if err := h.hub.Serve(c.Writer, c.Request, currentUser.UserID, events.ForTenant(tenantID, nil)); err != nil {
+		// The upgrader already wrote an HTTP error response
+		h.logger.Warn("WebSocket upgrade failed", "error", err, "user_id", currentUser.UserID)
+	}
+}
+
+// ConnectKitchen godoc
+// @Summary Kitchen display channel
+// @Description Open a WebSocket that pushes new, updated and ready orders of the store to the kitchen display.
+// @Description Admins without a store picked receive the orders of every store.
+// @Tags realtime
+// @Security ApiKeyAuth
+// @Param access_token query string false "JWT when the Authorization header cannot be set"
+// @Success 101 "Switching Protocols"
+// @Failure 401 {object} response.Response
+// @Router /ws/kitchen [get]
+func (h *WebSocketHandler) ConnectKitchen(c *gin.Context) {
+	currentUser, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	storeID, _ := repositories.StoreFromContext(c.Request.Context())
+	tenantID, _ := repositories.TenantFromContext(c.Request.Context())
+	if err := h.hub.Serve(c.Writer, c.Request, currentUser.UserID, events.ForTenant(tenantID, events.ForKitchen(storeID))); err != nil {
+		h.logger.Warn("WebSocket upgrade failed", "error", err, "user_id", currentUser.UserID)
+	}
+}
@@ -1,42 +1,109 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
+	"qris-pos-backend/docs"
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/services"
+	"qris-pos-backend/internal/infrastructure/accounting"
+	"qris-pos-backend/internal/infrastructure/cache"
 	"qris-pos-backend/internal/infrastructure/config"
 	"qris-pos-backend/internal/infrastructure/database/repositories"
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/internal/infrastructure/messaging"
+	"qris-pos-backend/internal/infrastructure/notification"
 	infraPayment "qris-pos-backend/internal/infrastructure/payment"
 	"qris-pos-backend/internal/infrastructure/qrcode"
+	"qris-pos-backend/internal/infrastructure/ratelimit"
+	"qris-pos-backend/internal/infrastructure/scheduler"
+	"qris-pos-backend/internal/infrastructure/secrets"
 	"qris-pos-backend/internal/infrastructure/storage"
+	"qris-pos-backend/internal/infrastructure/webhook"
+	"qris-pos-backend/internal/infrastructure/websocket"
 	"qris-pos-backend/internal/interfaces/http/handlers"
 	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/internal/usecases/alert"
+	"qris-pos-backend/internal/usecases/audit"
 	"qris-pos-backend/internal/usecases/auth"
+	"qris-pos-backend/internal/usecases/checkout"
+	"qris-pos-backend/internal/usecases/customer"
+	"qris-pos-backend/internal/usecases/expiry"
+	"qris-pos-backend/internal/usecases/export"
+	"qris-pos-backend/internal/usecases/inventory"
+	"qris-pos-backend/internal/usecases/merchant"
+	usecaseNotification "qris-pos-backend/internal/usecases/notification"
+	"qris-pos-backend/internal/usecases/offline"
+	"qris-pos-backend/internal/usecases/outbox"
 	usecasePayment "qris-pos-backend/internal/usecases/payment"
+	"qris-pos-backend/internal/usecases/printing"
 	"qris-pos-backend/internal/usecases/product"
+	"qris-pos-backend/internal/usecases/promotion"
+	"qris-pos-backend/internal/usecases/receipt"
+	"qris-pos-backend/internal/usecases/reconciliation"
+	"qris-pos-backend/internal/usecases/refund"
+	"qris-pos-backend/internal/usecases/report"
+	"qris-pos-backend/internal/usecases/settings"
+	"qris-pos-backend/internal/usecases/shift"
+	"qris-pos-backend/internal/usecases/store"
+	"qris-pos-backend/internal/usecases/system"
+	"qris-pos-backend/internal/usecases/table"
+	"qris-pos-backend/internal/usecases/tax"
+	"qris-pos-backend/internal/usecases/tenant"
 	"qris-pos-backend/internal/usecases/transaction"
+	usecaseWebhook "qris-pos-backend/internal/usecases/webhook"
 	pkgAuth "qris-pos-backend/pkg/auth"
 	"qris-pos-backend/pkg/logger"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 	"gorm.io/gorm"
 )
 
 type Server struct {
-	config *config.Config
-	db     *gorm.DB
-	logger logger.Logger
-	router *gin.Engine
+	config     *config.Config
+	db         *gorm.DB
+	logger     logger.Logger
+	router     *gin.Engine
+	httpServer *http.Server
+	scheduler  *scheduler.Scheduler
+	limiter    *middleware.ConcurrencyLimiter
+	throttle   *middleware.RateLimiter
+	broker     *events.Broker
+	publisher  messaging.Publisher
+	qrisQueue  *usecasePayment.QRISQueue
 }
 
-func NewServer(cfg *config.Config, db *gorm.DB, logger logger.Logger) *Server {
+func NewServer(cfg *config.Config, db *gorm.DB, logger logger.Logger, jobScheduler *scheduler.Scheduler) *Server {
 	server := &Server{
-		config: cfg,
-		db:     db,
-		logger: logger,
+		config:    cfg,
+		db:        db,
+		logger:    logger,
+		scheduler: jobScheduler,
 	}
 
 	server.setupRouter()
+	server.httpServer = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler: server.router,
+		// No write timeout: SSE and WebSocket streams stay open, API routes are bounded by the timeout middleware
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+	// Shutdown waits for every active connection, so the live streams have to be ended for it to drain
+	server.httpServer.RegisterOnShutdown(server.broker.Close)
+	server.httpServer.RegisterOnShutdown(server.qrisQueue.Stop)
+	if server.publisher != nil {
+		server.httpServer.RegisterOnShutdown(func() {
+			if err := server.publisher.Close(); err != nil {
+				logger.Error("Failed to close message bus publisher", "error", err)
+			}
+		})
+	}
 	return server
 }
 
@@ -50,55 +117,302 @@ func (s *Server) setupRouter() {
 
 	router := gin.New()
 
-	// Add middleware
-	router.Use(gin.Logger())
+	// Add middleware, tracing first so the span covers the time spent queued and timed out
+	router.Use(middleware.Tracing())
+	router.Use(middleware.RequestLogger(s.logger))
 	router.Use(gin.Recovery())
-	router.Use(s.corsMiddleware())
+	router.Use(middleware.CORS(s.config.CORS))
+	if len(s.config.Server.TrustedProxies) > 0 {
+		// Client IPs key the rate limits, so X-Forwarded-For is only believed from the known proxies
+		if err := router.SetTrustedProxies(s.config.Server.TrustedProxies); err != nil {
+			s.logger.Fatal("Invalid trusted proxies", "error", err)
+		}
+	}
+
+	// Shed load and bound request time so a slow Midtrans or database can't exhaust all workers
+	queueTimeout := time.Duration(s.config.Server.QueueTimeoutMillis) * time.Millisecond
+	s.limiter = middleware.NewConcurrencyLimiter(s.config.Server.MaxConcurrentRequests, queueTimeout)
+	reportLimiter := middleware.NewConcurrencyLimiter(s.config.Server.MaxConcurrentReports, queueTimeout)
+	paymentTimeout := time.Duration(s.config.Server.PaymentTimeoutSeconds) * time.Second
+	router.Use(s.limiter.Limit())
+	router.Use(middleware.Timeout(time.Duration(s.config.Server.RequestTimeoutSeconds)*time.Second, map[string]time.Duration{
+		"/api/v1/payments":                  paymentTimeout,
+		"/api/v1/qris":                      paymentTimeout,
+		"/api/v1/transactions/:id/exchange": paymentTimeout,
+		"/api/v1/reports":                   time.Duration(s.config.Server.ReportTimeoutSeconds) * time.Second,
+		"/api/v1/inventory":                 time.Duration(s.config.Server.ReportTimeoutSeconds) * time.Second,
+		"/api/v1/print-agent":               time.Duration(s.config.Print.MaxWaitSeconds+s.config.Server.RequestTimeoutSeconds) * time.Second, // Long poll for print jobs
+	}))
 
 	// Initialize services
 	passwordService := pkgAuth.NewPasswordService()
-	jwtService := pkgAuth.NewJWTService(s.config.JWT.Secret, s.config.JWT.ExpiryHour)
-	authMiddleware := middleware.NewAuthMiddleware(jwtService)
+	jwtService := pkgAuth.NewJWTService(s.config.JWT.Secret, s.config.JWT.ExpiryHour, s.config.JWT.Issuer, s.config.JWT.Audience, s.config.JWT.LeewaySeconds)
+	shareTokenService := pkgAuth.NewShareTokenService(s.config.Share.Secret)
 
 	// Initialize storage client
-	storageClient := storage.NewSupabaseClient(s.config.Storage, s.logger)
+	storageClient, err := storage.New(s.config.Storage, s.logger)
+	if err != nil {
+		s.logger.Fatal("Failed to set up storage", "error", err)
+	}
+	if local, ok := storageClient.(*storage.LocalStorage); ok {
+		router.GET(storage.LocalRoutePrefix+"/*path", gin.WrapH(http.StripPrefix(storage.LocalRoutePrefix, local.Handler())))
+	}
+
+	// Initialize message bus publisher, nil when no bus is configured
+	publisher, err := messaging.New(s.config.Outbox, s.logger)
+	if err != nil {
+		s.logger.Fatal("Failed to set up message bus", "error", err)
+	}
+	s.publisher = publisher
+
+	// Receipt numbers restart every business day, not every day of the server
+	businessLocation, err := time.LoadLocation(s.config.Alert.Timezone)
+	if err != nil {
+		s.logger.Warn("Unknown business timezone, using server local time", "timezone", s.config.Alert.Timezone, "error", err)
+		businessLocation = time.Local
+	}
 
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(s.db)
+	storeRepo := repositories.NewStoreRepository(s.db)
+	tableRepo := repositories.NewTableRepository(s.db)
+	printJobRepo := repositories.NewPrintJobRepository(s.db)
+	tokenRepo := repositories.NewTokenRepository(s.db)
 	productRepo := repositories.NewProductRepository(s.db)
+	if s.config.Cache.ProductTTLSeconds > 0 {
+		catalogCache, err := cache.New(s.config.Cache)
+		if err != nil {
+			s.logger.Fatal("Failed to set up the catalog cache", "error", err)
+		}
+		productRepo = repositories.NewCachedProductRepository(s.db, productRepo, catalogCache, time.Duration(s.config.Cache.ProductTTLSeconds)*time.Second)
+	}
 	categoryRepo := repositories.NewCategoryRepository(s.db)
-	transactionRepo := repositories.NewTransactionRepository(s.db)
+	productImageRepo := repositories.NewProductImageRepository(s.db)
+	priceScheduleRepo := repositories.NewPriceScheduleRepository(s.db)
+	transactionRepo := repositories.NewTransactionRepository(s.db, businessLocation)
 	paymentRepo := repositories.NewPaymentRepository(s.db)
+	promotionRepo := repositories.NewPromotionRepository(s.db)
+	taxRuleRepo := repositories.NewTaxRuleRepository(s.db)
+	customerRepo := repositories.NewCustomerRepository(s.db)
+	paymentExceptionRepo := repositories.NewPaymentExceptionRepository(s.db)
+	paymentNotificationRepo := repositories.NewPaymentNotificationRepository(s.db)
+	refundRepo := repositories.NewRefundRepository(s.db)
+	voidRequestRepo := repositories.NewVoidRequestRepository(s.db)
+	receiptDeliveryRepo := repositories.NewReceiptDeliveryRepository(s.db)
+	reportRepo := repositories.NewReportRepository(s.db)
+	shiftRepo := repositories.NewShiftRepository(s.db)
+	alertRepo := repositories.NewAlertRepository(s.db)
+	exportRepo := repositories.NewExportRepository(s.db)
+	auditLogRepo := repositories.NewAuditLogRepository(s.db)
+	systemStatusRepo := repositories.NewSystemStatusRepository(s.db)
+	webhookRepo := repositories.NewWebhookRepository(s.db)
+	outboxRepo := repositories.NewOutboxRepository(s.db)
+	accountingPostingRepo := repositories.NewAccountingPostingRepository(s.db)
+	reconciliationRepo := repositories.NewReconciliationRepository(s.db)
+	reportScheduleRepo := repositories.NewReportScheduleRepository(s.db)
+	settingRepo := repositories.NewSettingRepository(s.db)
+	merchantRepo := repositories.NewMerchantRepository(s.db)
+	gatewayCredentialRepo := repositories.NewGatewayCredentialRepository(s.db)
+	tenantRepo := repositories.NewTenantRepository(s.db)
+	txManager := repositories.NewTransactionManager(s.db)
+
+	// Every route below is scoped to the tenant of the request in SaaS mode
+	tenantMiddleware := middleware.NewTenantMiddleware(s.config.Tenancy, tenantRepo)
+	router.Use(tenantMiddleware.Resolve())
+	authMiddleware := middleware.NewAuthMiddleware(jwtService, userRepo, storeRepo, tenantRepo, tokenRepo)
+	auditMiddleware := middleware.NewAuditMiddleware(auditLogRepo, s.logger)
 
 	// Initialize infrastructure services
-	midtransClient := infraPayment.NewMidtransClient(s.config.Midtrans)
+	paymentGateways, err := infraPayment.NewGateways(s.config)
+	if err != nil {
+		s.logger.Fatal("Failed to set up payment gateways", "error", err)
+	}
+	secretsCipher, err := secrets.NewCipher(s.config.Secrets)
+	if err != nil {
+		s.logger.Fatal("Failed to set up secrets encryption", "error", err)
+	}
+	if !secretsCipher.Configured() {
+		s.logger.Warn("SECRETS_ENCRYPTION_KEY is not set, Midtrans accounts of stores and the merchant can't be saved")
+	}
 	qrCodeGenerator := qrcode.NewQRCodeGenerator()
+	promotionEngine := services.NewPromotionEngine()
+	eventBroker := events.NewBroker()
+	s.broker = eventBroker
+	wsHub := websocket.NewHub(eventBroker, s.logger)
+	notificationSenders := notification.NewSenders(s.config.Notification)
+	accountingConnectors := accounting.NewConnectors(s.config.Accounting)
 
 	// Initialize use cases
-	authUseCase := auth.NewAuthUseCase(userRepo, passwordService, jwtService, s.logger)
-	productUseCase := product.NewProductUseCase(productRepo, categoryRepo, s.logger)
-	transactionUseCase := transaction.NewTransactionUseCase(transactionRepo, productRepo, userRepo, s.logger)
-	paymentUseCase := usecasePayment.NewPaymentUseCase(paymentRepo, transactionRepo, midtransClient, qrCodeGenerator, s.logger)
+	settingsUseCase := settings.NewSettingsUseCase(settingRepo, eventBroker, s.config.Store, s.config.Payment, s.logger)
+	if err := settingsUseCase.Reload(context.Background()); err != nil {
+		s.logger.Error("Failed to load settings, using the configured defaults", "error", err)
+	}
+	merchantUseCase := merchant.NewMerchantUseCase(merchantRepo, settingsUseCase, secretsCipher, s.logger)
+	// Payments go through the merchant's own Midtrans account once it has one
+	if gateway, err := paymentGateways.Get(entities.ProviderMidtrans); err == nil {
+		if midtrans, ok := gateway.(*infraPayment.MidtransClient); ok {
+			merchantUseCase.OnChange(func(profile *entities.Merchant) {
+				serverKey, err := merchantUseCase.MidtransServerKey(profile)
+				if err != nil {
+					s.logger.Error("Failed to decrypt the merchant's Midtrans server key, using the configured account", "error", err)
+				}
+				midtrans.UseAccount(serverKey, profile.MidtransEnvironment)
+			})
+		}
+	}
+	if err := merchantUseCase.Reload(context.Background()); err != nil {
+		s.logger.Error("Failed to load the merchant profile, using the configured store", "error", err)
+	}
+	authUseCase := auth.NewAuthUseCase(userRepo, storeRepo, tokenRepo, passwordService, jwtService, s.config.JWT, notificationSenders, s.config.PasswordReset, s.logger)
+	productUseCase := product.NewProductUseCase(productRepo, categoryRepo, productImageRepo, storageClient, eventBroker, s.config.Jobs, s.config.Storage, s.config.SKU, s.logger)
+	priceScheduleUseCase := product.NewPriceScheduleUseCase(priceScheduleRepo, productRepo, s.config.Alert.Timezone, s.logger)
+	transactionUseCase := transaction.NewTransactionUseCase(transactionRepo, productRepo, userRepo, promotionRepo, taxRuleRepo, customerRepo, shiftRepo, storeRepo, tableRepo, txManager, promotionEngine, eventBroker, s.config.Shift, s.config.Store, s.config.Loyalty, s.config.Discount, passwordService, s.logger)
+	orderTrackingUseCase := transaction.NewOrderTrackingUseCase(transactionRepo, eventBroker, s.logger)
+	kitchenUseCase := transaction.NewKitchenUseCase(transactionRepo, eventBroker, s.logger)
+	promotionUseCase := promotion.NewPromotionUseCase(promotionRepo, productRepo, promotionEngine, s.logger)
+	taxUseCase := tax.NewTaxUseCase(taxRuleRepo, categoryRepo, s.logger)
+	customerUseCase := customer.NewCustomerUseCase(customerRepo, s.config.Loyalty, s.logger)
+	storeUseCase := store.NewStoreUseCase(storeRepo, transactionRepo, gatewayCredentialRepo, secretsCipher, s.logger)
+	tenantUseCase := tenant.NewTenantUseCase(tenantRepo, storeRepo, userRepo, txManager, passwordService, s.logger)
+	// Stores with a Midtrans account of their own take payments with it, the others with the shared one
+	paymentGateways.UseStoreAccounts(storeUseCase)
+	tableUseCase := table.NewTableUseCase(tableRepo, s.logger)
+	paymentUseCase := usecasePayment.NewPaymentUseCase(paymentRepo, transactionRepo, paymentExceptionRepo, paymentNotificationRepo, receiptDeliveryRepo, customerRepo, txManager, paymentGateways, qrCodeGenerator, eventBroker, s.config.Payment, s.config.Loyalty, settingsUseCase, merchantUseCase, s.logger)
+	refundUseCase := refund.NewRefundUseCase(refundRepo, paymentRepo, transactionRepo, paymentGateways, s.logger)
+	checkoutUseCase := checkout.NewCheckoutUseCase(transactionUseCase, paymentUseCase, s.logger)
+	syncUseCase := offline.NewSyncUseCase(transactionRepo, productRepo, userRepo, storeRepo, shiftRepo, taxRuleRepo, paymentUseCase, eventBroker, s.config.Sync, s.logger)
+	exchangeUseCase := refund.NewExchangeUseCase(refundRepo, paymentRepo, transactionRepo, productRepo, taxRuleRepo, shiftRepo, paymentGateways, s.config.Shift, s.logger)
+	voidUseCase := refund.NewVoidUseCase(voidRequestRepo, refundRepo, paymentRepo, transactionRepo, paymentExceptionRepo, userRepo, passwordService, paymentGateways, s.logger)
+	receiptUseCase := receipt.NewReceiptUseCase(transactionRepo, paymentRepo, merchantUseCase, shareTokenService, s.config.Share, s.logger)
+	printUseCase := printing.NewPrintUseCase(printJobRepo, transactionRepo, paymentRepo, eventBroker, s.config.Print, merchantUseCase, s.logger)
+	notificationUseCase := usecaseNotification.NewNotificationUseCase(receiptDeliveryRepo, transactionRepo, paymentRepo, notificationSenders, merchantUseCase, s.config.Notification, s.logger)
+	reportUseCase := report.NewReportUseCase(reportRepo, s.logger)
+	reportScheduleUseCase := report.NewReportScheduleUseCase(reportScheduleRepo, reportRepo, storeRepo, merchantUseCase, notificationSenders, s.config.Alert.Timezone, s.logger)
+	accountingUseCase := report.NewAccountingUseCase(reportRepo, accountingPostingRepo, accountingConnectors, s.config.Accounting, s.logger)
+	inventoryUseCase := inventory.NewInventoryUseCase(productRepo, reportRepo, transactionRepo, s.config.Inventory, s.logger)
+	shiftUseCase := shift.NewShiftUseCase(shiftRepo, s.config.Shift, s.logger)
+	alertUseCase := alert.NewAlertUseCase(alertRepo, notificationSenders, eventBroker, s.config.Alert, s.logger)
+	exportUseCase := export.NewExportUseCase(exportRepo, userRepo, productRepo, transactionRepo, customerRepo, storageClient, notificationSenders, s.config.Export, s.logger)
+	reconciliationUseCase := reconciliation.NewReconciliationUseCase(reconciliationRepo, paymentRepo, paymentGateways, s.config.Reconciliation, s.logger)
+	expiryUseCase := expiry.NewExpiryUseCase(paymentRepo, transactionRepo, paymentGateways, s.config.Jobs, s.logger)
+	auditUseCase := audit.NewAuditUseCase(auditLogRepo, s.logger)
+	webhookUseCase := usecaseWebhook.NewWebhookUseCase(webhookRepo, transactionRepo, productRepo, webhook.NewClient(s.config.Webhook, s.config.Tenancy), eventBroker, s.config.Webhook, s.logger)
+	relayUseCase := outbox.NewRelayUseCase(outboxRepo, publisher, s.config.Outbox, s.logger)
+	systemStatusUseCase := system.NewSystemStatusUseCase(systemStatusRepo, s.scheduler, storageClient, s.config.Jobs, s.config.Storage, s.logger)
+
+	// Queue webhook deliveries for broker events until the broker closes on shutdown
+	go webhookUseCase.Listen()
+	// Alert the owners of paid sales whose stock ran out
+	go alertUseCase.Listen()
+
+	// Charge queued QRIS generations until the server shuts down
+	qrisQueue := usecasePayment.NewQRISQueue(paymentUseCase, eventBroker, s.config.Payment, s.logger)
+	qrisQueue.Start()
+	s.qrisQueue = qrisQueue
+
+	// Register background jobs
+	s.scheduler.Register("expire-stale-records", time.Duration(s.config.Jobs.ExpiryIntervalSeconds)*time.Second, expiryUseCase.Run)
+	s.scheduler.Register("deliver-receipts", time.Duration(s.config.Notification.IntervalSeconds)*time.Second, notificationUseCase.Run)
+	s.scheduler.Register("detect-anomalies", time.Duration(s.config.Alert.IntervalSeconds)*time.Second, alertUseCase.Run)
+	s.scheduler.Register("rollup-product-popularity", time.Duration(s.config.Jobs.PopularityIntervalSeconds)*time.Second, productUseCase.RollupPopularity)
+	s.scheduler.Register("cost-sales", time.Duration(s.config.Jobs.CostingIntervalSeconds)*time.Second, inventoryUseCase.CostSales)
+	s.scheduler.Register("apply-price-schedules", time.Duration(s.config.Jobs.PriceScheduleIntervalSeconds)*time.Second, priceScheduleUseCase.Run)
+	s.scheduler.Register("build-exports", time.Duration(s.config.Export.IntervalSeconds)*time.Second, exportUseCase.Run)
+	s.scheduler.Register("reload-settings", time.Duration(s.config.Jobs.SettingsReloadIntervalSeconds)*time.Second, settingsUseCase.Reload)
+	s.scheduler.Register("reload-merchant", time.Duration(s.config.Jobs.SettingsReloadIntervalSeconds)*time.Second, merchantUseCase.Reload)
+	s.scheduler.Register("send-report-schedules", time.Duration(s.config.Jobs.ReportScheduleIntervalSeconds)*time.Second, reportScheduleUseCase.Run)
+	s.scheduler.Register("reconcile-settlements", time.Duration(s.config.Reconciliation.IntervalSeconds)*time.Second, reconciliationUseCase.Run)
+	s.scheduler.Register("deliver-webhooks", time.Duration(s.config.Webhook.IntervalSeconds)*time.Second, webhookUseCase.Run)
+	s.scheduler.Register("purge-expired-tokens", time.Duration(s.config.Jobs.TokenCleanupIntervalSeconds)*time.Second, authUseCase.PurgeExpiredTokens)
+	if storage.Configured(s.config.Storage) {
+		s.scheduler.Register("cleanup-orphan-images", time.Duration(s.config.Jobs.ImageCleanupIntervalSeconds)*time.Second, productUseCase.CleanupOrphanImages)
+	}
+	if publisher != nil {
+		s.scheduler.Register("relay-outbox", time.Duration(s.config.Outbox.IntervalSeconds)*time.Second, relayUseCase.Run)
+	}
+	s.scheduler.Register("purge-outbox", time.Hour, relayUseCase.Purge)
+	s.scheduler.Register("retry-payment-notifications", time.Duration(s.config.Payment.NotificationIntervalSeconds)*time.Second, paymentUseCase.RetryNotifications)
+	s.scheduler.Register(system.ReconciliationJob, time.Duration(s.config.Jobs.ReconcileIntervalSeconds)*time.Second, paymentUseCase.ReconcilePendingPayments)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authUseCase, s.logger)
 	productHandler := handlers.NewProductHandler(productUseCase, s.logger)
+	priceScheduleHandler := handlers.NewPriceScheduleHandler(priceScheduleUseCase, s.logger)
 	transactionHandler := handlers.NewTransactionHandler(transactionUseCase, s.logger)
-	paymentHandler := handlers.NewPaymentHandler(paymentUseCase, s.logger)
+	orderTrackingHandler := handlers.NewOrderTrackingHandler(orderTrackingUseCase, s.logger)
+	kitchenHandler := handlers.NewKitchenHandler(kitchenUseCase, s.logger)
+	paymentHandler := handlers.NewPaymentHandler(paymentUseCase, qrisQueue, s.logger)
 	imageHandler := handlers.NewImageHandler(storageClient, s.config.Storage, s.logger)
+	promotionHandler := handlers.NewPromotionHandler(promotionUseCase, s.logger)
+	taxHandler := handlers.NewTaxHandler(taxUseCase, s.logger)
+	customerHandler := handlers.NewCustomerHandler(customerUseCase, s.logger)
+	storeHandler := handlers.NewStoreHandler(storeUseCase, s.logger)
+	tenantHandler := handlers.NewTenantHandler(tenantUseCase, s.logger)
+	tableHandler := handlers.NewTableHandler(tableUseCase, s.logger)
+	wsHandler := handlers.NewWebSocketHandler(wsHub, s.logger)
+	refundHandler := handlers.NewRefundHandler(refundUseCase, s.logger)
+	exchangeHandler := handlers.NewExchangeHandler(exchangeUseCase, s.logger)
+	voidHandler := handlers.NewVoidHandler(voidUseCase, s.logger)
+	receiptHandler := handlers.NewReceiptHandler(receiptUseCase, s.logger)
+	printHandler := handlers.NewPrintHandler(printUseCase, s.logger)
+	notificationHandler := handlers.NewNotificationHandler(notificationUseCase, s.logger)
+	reportHandler := handlers.NewReportHandler(reportUseCase, s.logger)
+	reportScheduleHandler := handlers.NewReportScheduleHandler(reportScheduleUseCase, s.logger)
+	settingsHandler := handlers.NewSettingsHandler(settingsUseCase, s.logger)
+	merchantHandler := handlers.NewMerchantHandler(merchantUseCase, s.logger)
+	accountingHandler := handlers.NewAccountingHandler(accountingUseCase, s.logger)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryUseCase, s.logger)
+	shiftHandler := handlers.NewShiftHandler(shiftUseCase, s.logger)
+	syncHandler := handlers.NewSyncHandler(syncUseCase, s.logger)
+	checkoutHandler := handlers.NewCheckoutHandler(checkoutUseCase, s.logger)
+	alertHandler := handlers.NewAlertHandler(alertUseCase, s.logger)
+	exportHandler := handlers.NewExportHandler(exportUseCase, s.logger)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationUseCase, s.logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookUseCase, s.logger)
+	auditHandler := handlers.NewAuditHandler(auditUseCase, s.logger)
+	systemHandler := handlers.NewSystemHandler(systemStatusUseCase, s.logger)
 
 	// Health check endpoint
 
 	// API routes
 	api := router.Group("/api/v1")
+	rateLimits := s.config.RateLimit
+	if rateLimits.Enabled {
+		rateLimitStore, err := ratelimit.NewStore(rateLimits)
+		if err != nil {
+			s.logger.Fatal("Failed to set up rate limiting", "error", err)
+		}
+		s.throttle = middleware.NewRateLimiter(rateLimitStore, s.logger)
+	}
+	api.Use(s.throttle.Limit("global", ratelimit.Limit{PerMinute: rateLimits.GlobalPerMinute, Burst: rateLimits.GlobalBurst}, middleware.ByClientIP))
+	loginLimit := s.throttle.Limit("login", ratelimit.Limit{PerMinute: rateLimits.LoginPerMinute, Burst: rateLimits.LoginBurst}, middleware.ByClientIP)
+	// Manager credentials typed at a register, counted per signed in user
+	approvalLimit := s.throttle.Limit("approval", ratelimit.Limit{PerMinute: rateLimits.LoginPerMinute, Burst: rateLimits.LoginBurst}, middleware.ByUser)
+	passwordResetLimit := s.throttle.Limit("password_reset", ratelimit.Limit{PerMinute: rateLimits.PasswordResetPerMinute, Burst: rateLimits.PasswordResetBurst}, middleware.ByClientIP)
+	callbackLimit := s.throttle.Limit("callback", ratelimit.Limit{PerMinute: rateLimits.CallbackPerMinute, Burst: rateLimits.CallbackBurst}, middleware.ByClientIP)
+	qrisLimit := s.throttle.Limit("qris_generate", ratelimit.Limit{PerMinute: rateLimits.QRISPerMinute, Burst: rateLimits.QRISBurst}, middleware.ByUser)
+	callbackAllowList, err := middleware.AllowIPs("payment callback", s.config.Payment.CallbackAllowedIPs, s.logger)
+	if err != nil {
+		s.logger.Fatal("Invalid payment callback allow-list", "error", err)
+	}
+
 	api.GET("/health", s.healthCheck)
+	if s.config.Server.DocsEnabled {
+		s.setupDocs(router, api)
+	}
+	api.GET("/ws", authMiddleware.RequireAdminOrCashier(), wsHandler.Connect)                // Live POS updates
+	api.GET("/ws/kitchen", authMiddleware.RequireAdminOrCashier(), wsHandler.ConnectKitchen) // Kitchen display orders
 
 	{
 		// Auth routes (public)
 		authGroup := api.Group("/auth")
 		{
-			authGroup.POST("/login", authHandler.Login)
-			authGroup.POST("/register", authMiddleware.RequireAdmin(), authHandler.Register)
+			authGroup.POST("/login", loginLimit, authHandler.Login)
+			authGroup.POST("/refresh", authHandler.RefreshToken) // The access token may already have expired
+			authGroup.POST("/forgot-password", passwordResetLimit, authHandler.ForgotPassword)
+			authGroup.POST("/verify-reset-code", passwordResetLimit, authHandler.VerifyResetCode)
+			authGroup.POST("/reset-password", passwordResetLimit, authHandler.ResetPassword)
+			authGroup.POST("/register", authMiddleware.RequireAdmin(), auditMiddleware.Record(entities.AuditUserRegister), authHandler.Register)
 		}
 
 		// Auth routes (protected)
@@ -106,17 +420,28 @@ func (s *Server) setupRouter() {
 		authProtected.Use(authMiddleware.RequireAuth())
 		{
 			authProtected.GET("/me", authHandler.GetProfile)
-			authProtected.POST("/refresh", authHandler.RefreshToken)
 			authProtected.POST("/logout", authHandler.Logout)
+			authProtected.GET("/sessions", authHandler.ListSessions)
+			authProtected.DELETE("/sessions/:id", authHandler.RevokeSession)
 			authProtected.POST("/change-password", authHandler.ChangePassword)
 			authProtected.PUT("/profile", authHandler.UpdateProfile)
 		}
 
-		// Product routes
+		// User routes (Admin only)
+		users := api.Group("/users")
+		users.Use(authMiddleware.RequireAdmin())
+		{
+			users.GET("", authHandler.ListUsers)
+			users.POST("/:id/restore", auditMiddleware.Record(entities.AuditUserRestore), authHandler.RestoreUser)
+		}
+
+		// Product routes, signed in users only see the products of their store
 		products := api.Group("/products")
+		products.Use(authMiddleware.OptionalAuth())
 		{
 			products.GET("", productHandler.ListProducts)   // Public - can view products
 			products.GET("/:id", productHandler.GetProduct) // Public - can view single product
+			products.GET("/barcode/:code", productHandler.GetProductByBarcode)
 		}
 
 		// Product routes (Admin only)
@@ -124,15 +449,29 @@ func (s *Server) setupRouter() {
 		productsAdmin.Use(authMiddleware.RequireAdmin())
 		{
 			productsAdmin.POST("", productHandler.CreateProduct)
-			productsAdmin.PUT("/:id", productHandler.UpdateProduct)
+			productsAdmin.POST("/import", productHandler.ImportProducts)
+			productsAdmin.GET("/export", productHandler.ExportProducts)
+			productsAdmin.POST("/bulk-price", auditMiddleware.Record(entities.AuditProductBulkPrice), productHandler.BulkUpdatePrices)
+			productsAdmin.POST("/suggested-prices", productHandler.RecalculateSuggestedPrices)
+			productsAdmin.GET("/:id/price-history", productHandler.GetPriceHistory)
+			productsAdmin.PUT("/:id/price-tiers", productHandler.SetPriceTiers)
+			productsAdmin.GET("/:id/recipe", productHandler.GetRecipe)
+			productsAdmin.PUT("/:id/recipe", productHandler.SetRecipe)
+			productsAdmin.PUT("/:id", auditMiddleware.Record(entities.AuditProductUpdate), productHandler.UpdateProduct)
 			productsAdmin.DELETE("/:id", productHandler.DeleteProduct)
-			productsAdmin.PATCH("/:id/stock", productHandler.UpdateStock)
+			productsAdmin.POST("/:id/restore", productHandler.RestoreProduct)
+			productsAdmin.PATCH("/:id/stock", auditMiddleware.Record(entities.AuditProductStock), productHandler.UpdateStock)
+			productsAdmin.POST("/:id/images", productHandler.AddProductImage)
+			productsAdmin.PUT("/:id/images/order", productHandler.ReorderProductImages)
+			productsAdmin.DELETE("/:id/images/:image_id", productHandler.DeleteProductImage)
 		}
 
 		// Category routes
 		categories := api.Group("/categories")
+		categories.Use(authMiddleware.OptionalAuth())
 		{
-			categories.GET("", productHandler.ListCategories) // Public
+			categories.GET("", productHandler.ListCategories)        // Public
+			categories.GET("/tree", productHandler.ListCategoryTree) // Public
 		}
 
 		// Category routes (Admin only)
@@ -140,35 +479,355 @@ func (s *Server) setupRouter() {
 		categoriesAdmin.Use(authMiddleware.RequireAdmin())
 		{
 			categoriesAdmin.POST("", productHandler.CreateCategory)
+			categoriesAdmin.PUT("/:id", productHandler.UpdateCategory)
+			categoriesAdmin.POST("/import", productHandler.ImportCategories)
+			categoriesAdmin.POST("/:id/merge", productHandler.MergeCategory)
+			categoriesAdmin.POST("/:id/move", productHandler.MoveCategory)
+			categoriesAdmin.DELETE("/:id", productHandler.DeleteCategory)
+			categoriesAdmin.POST("/:id/restore", productHandler.RestoreCategory)
 		}
 
+		// Checkout route (Admin/Cashier), creates the transaction and its QRIS in one request
+		api.POST("/checkout", authMiddleware.RequireAdminOrCashier(), checkoutHandler.Checkout)
+
 		// Transaction routes
 		transactions := api.Group("/transactions")
 		transactions.Use(authMiddleware.RequireAdminOrCashier())
 		{
 			transactions.GET("", transactionHandler.ListTransactions)
 			transactions.POST("", transactionHandler.CreateTransaction)
+			transactions.GET("/held", transactionHandler.ListHeldTransactions)
 			transactions.GET("/:id", transactionHandler.GetTransaction)
-			transactions.PUT("/:id/cancel", transactionHandler.CancelTransaction)
+			transactions.POST("/:id/hold", transactionHandler.HoldTransaction)
+			transactions.DELETE("/:id/hold", transactionHandler.ResumeTransaction)
+			transactions.PUT("/:id/cancel", auditMiddleware.Record(entities.AuditTransactionVoid), transactionHandler.CancelTransaction)
+			transactions.POST("/:id/void-requests", voidHandler.RequestVoid)
+			transactions.POST("/:id/exchange", authMiddleware.RequireAdmin(), auditMiddleware.Record(entities.AuditTransactionExchange), exchangeHandler.ExchangeItems)
+			transactions.GET("/:id/receipt", receiptHandler.GetReceipt)
+			transactions.POST("/:id/receipt/send", notificationHandler.SendReceipt)
+			transactions.GET("/:id/receipt/deliveries", notificationHandler.ListReceiptDeliveries)
+			transactions.POST("/:id/share", receiptHandler.ShareReceipt)
 			transactions.POST("/:id/items", transactionHandler.AddItemToTransaction)
-			transactions.DELETE("/:id/items/:item_id", transactionHandler.RemoveItemFromTransaction)
-			transactions.PUT("/:id/items/:item_id", transactionHandler.UpdateItemQuantity)
+			transactions.DELETE("/:id/items/:product_id", transactionHandler.RemoveItemFromTransaction)
+			transactions.PUT("/:id/items/:product_id", transactionHandler.UpdateItemQuantity)
+			transactions.POST("/:id/items/:product_id/discount", approvalLimit, auditMiddleware.Record(entities.AuditTransactionDiscount), transactionHandler.ApplyItemDiscount)
+			transactions.DELETE("/:id/items/:product_id/discount", auditMiddleware.Record(entities.AuditTransactionDiscount), transactionHandler.RemoveItemDiscount)
+			transactions.PUT("/:id/items/order", transactionHandler.ReorderItems)
+			transactions.POST("/:id/discount", approvalLimit, auditMiddleware.Record(entities.AuditTransactionDiscount), transactionHandler.ApplyTransactionDiscount)
+			transactions.DELETE("/:id/discount", auditMiddleware.Record(entities.AuditTransactionDiscount), transactionHandler.RemoveTransactionDiscount)
+			transactions.POST("/:id/promo-code", transactionHandler.ApplyPromoCode)
+			transactions.DELETE("/:id/promo-code", transactionHandler.RemovePromoCode)
+			transactions.PUT("/:id/customer", transactionHandler.AttachCustomer)
+			transactions.DELETE("/:id/customer", transactionHandler.DetachCustomer)
+			transactions.POST("/:id/points", transactionHandler.RedeemPoints)
+			transactions.PUT("/:id/fulfillment", orderTrackingHandler.UpdateFulfillment)
+		}
+
+		// Shared invoices (public, the share token is the credential)
+		api.GET("/invoices/:token", receiptHandler.GetSharedReceipt)
+
+		// Order status page (public, the tracking token is the credential) and kitchen item statuses
+		orders := api.Group("/orders")
+		{
+			orders.GET("/track/:token", orderTrackingHandler.GetOrderStatus)
+			orders.GET("/track/:token/events", orderTrackingHandler.StreamOrderStatus)
+			orders.PUT("/:id/items/:item_id/status", authMiddleware.RequireAdminOrCashier(), kitchenHandler.UpdateItemStatus)
+		}
+
+		// Promotion routes
+		promotions := api.Group("/promotions")
+		{
+			promotions.POST("/simulate", authMiddleware.RequireAdminOrCashier(), promotionHandler.SimulatePromotions)
+		}
+
+		// Promotion routes (Admin only)
+		promotionsAdmin := api.Group("/promotions")
+		promotionsAdmin.Use(authMiddleware.RequireAdmin())
+		{
+			promotionsAdmin.GET("", promotionHandler.ListPromotions)
+			promotionsAdmin.POST("", promotionHandler.CreatePromotion)
+			promotionsAdmin.GET("/:id", promotionHandler.GetPromotion)
+			promotionsAdmin.PUT("/:id", promotionHandler.UpdatePromotion)
+			promotionsAdmin.DELETE("/:id", promotionHandler.DeletePromotion)
+		}
+
+		// Price schedule routes (Admin only)
+		priceSchedules := api.Group("/price-schedules")
+		priceSchedules.Use(authMiddleware.RequireAdmin())
+		{
+			priceSchedules.GET("", priceScheduleHandler.ListPriceSchedules)
+			priceSchedules.POST("", priceScheduleHandler.CreatePriceSchedule)
+			priceSchedules.GET("/:id", priceScheduleHandler.GetPriceSchedule)
+			priceSchedules.PUT("/:id", priceScheduleHandler.UpdatePriceSchedule)
+			priceSchedules.DELETE("/:id", priceScheduleHandler.DeletePriceSchedule)
+		}
+
+		// Settings routes, terminals read them and admins change them
+		settingsRoutes := api.Group("/settings")
+		{
+			settingsRoutes.GET("", authMiddleware.RequireAdminOrCashier(), settingsHandler.GetSettings)
+			settingsRoutes.PUT("", authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform(), settingsHandler.UpdateSettings)
+		}
+
+		// Merchant profile routes, admins onboard the merchant once and keep its profile up to date. In SaaS
+		// mode the profile is the platform's, tenants take payments with the Midtrans accounts of their stores.
+		merchantRoutes := api.Group("/merchant")
+		{
+			merchantRoutes.GET("", authMiddleware.RequireAdminOrCashier(), merchantHandler.GetMerchant)
+			merchantRoutes.POST("", authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform(), auditMiddleware.Record(entities.AuditMerchantUpdate), merchantHandler.OnboardMerchant)
+			merchantRoutes.PUT("", authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform(), auditMiddleware.Record(entities.AuditMerchantUpdate), merchantHandler.UpdateMerchant)
+		}
+
+		// Tax rule routes (Admin only)
+		taxRules := api.Group("/tax-rules")
+		taxRules.Use(authMiddleware.RequireAdmin())
+		{
+			taxRules.GET("", taxHandler.ListTaxRules)
+			taxRules.POST("", taxHandler.CreateTaxRule)
+			taxRules.GET("/:id", taxHandler.GetTaxRule)
+			taxRules.PUT("/:id", taxHandler.UpdateTaxRule)
+			taxRules.DELETE("/:id", taxHandler.DeleteTaxRule)
+		}
+
+		// Tenant routes (platform admins only), in SaaS mode
+		if s.config.Tenancy.Enabled {
+			tenants := api.Group("/tenants")
+			tenants.Use(authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform())
+			{
+				tenants.GET("", tenantHandler.ListTenants)
+				tenants.POST("", auditMiddleware.Record(entities.AuditTenantUpdate), tenantHandler.CreateTenant)
+				tenants.GET("/:id", tenantHandler.GetTenant)
+				tenants.PUT("/:id", auditMiddleware.Record(entities.AuditTenantUpdate), tenantHandler.UpdateTenant)
+			}
+		}
+
+		// Store routes (Admin only)
+		stores := api.Group("/stores")
+		stores.Use(authMiddleware.RequireAdmin())
+		{
+			stores.GET("", storeHandler.ListStores)
+			stores.POST("", storeHandler.CreateStore)
+			stores.GET("/:id", storeHandler.GetStore)
+			stores.PUT("/:id", storeHandler.UpdateStore)
+			stores.DELETE("/:id/training-data", storeHandler.PurgeTrainingData)
+			stores.GET("/:id/gateway-credentials", storeHandler.GetGatewayCredential)
+			stores.PUT("/:id/gateway-credentials", auditMiddleware.Record(entities.AuditStoreCredential), storeHandler.SetGatewayCredential)
+			stores.DELETE("/:id/gateway-credentials", auditMiddleware.Record(entities.AuditStoreCredential), storeHandler.DeleteGatewayCredential)
+		}
+
+		// Dining table routes, cashiers open dine-in orders from the floor plan
+		tables := api.Group("/tables")
+		tables.Use(authMiddleware.RequireAdminOrCashier())
+		{
+			tables.GET("", tableHandler.ListTables)
+			tables.GET("/floor-plan", tableHandler.GetFloorPlan)
+			tables.GET("/:id", tableHandler.GetTable)
+			tables.POST("", authMiddleware.RequireAdmin(), tableHandler.CreateTable)
+			tables.PUT("/:id", authMiddleware.RequireAdmin(), tableHandler.UpdateTable)
+			tables.DELETE("/:id", authMiddleware.RequireAdmin(), tableHandler.DeleteTable)
+		}
+
+		// Printer routes, admins register the printers of the store and hand their tokens to the agents
+		printers := api.Group("/printers")
+		printers.Use(authMiddleware.RequireAdmin())
+		{
+			printers.GET("", printHandler.ListPrinters)
+			printers.POST("", printHandler.CreatePrinter)
+			printers.DELETE("/:id", printHandler.DeletePrinter)
+			printers.POST("/:id/rotate-token", printHandler.RotatePrinterToken)
+		}
+
+		// Print job routes, cashiers send receipts and QRIS to a printer and reprint them
+		printJobs := api.Group("/print-jobs")
+		printJobs.Use(authMiddleware.RequireAdminOrCashier())
+		{
+			printJobs.GET("", printHandler.ListPrintJobs)
+			printJobs.POST("", printHandler.CreatePrintJob)
+			printJobs.GET("/:id", printHandler.GetPrintJob)
+			printJobs.POST("/:id/reprint", printHandler.ReprintJob)
+		}
+
+		// Print agent routes, authenticated with the printer token instead of a user
+		printAgent := api.Group("/print-agent")
+		{
+			printAgent.GET("/jobs/next", printHandler.NextPrintJob)
+			printAgent.POST("/jobs/:id/ack", printHandler.AckPrintJob)
+		}
+
+		// Customer routes, cashiers register and look up members at checkout
+		customers := api.Group("/customers")
+		customers.Use(authMiddleware.RequireAdminOrCashier())
+		{
+			customers.GET("", customerHandler.ListCustomers)
+			customers.POST("", customerHandler.CreateCustomer)
+			customers.GET("/lookup", customerHandler.LookupCustomer)
+			customers.GET("/:id", customerHandler.GetCustomer)
+			customers.PUT("/:id", customerHandler.UpdateCustomer)
+			customers.DELETE("/:id", authMiddleware.RequireAdmin(), customerHandler.DeleteCustomer)
+			customers.GET("/:id/points", customerHandler.GetPoints)
+			customers.POST("/:id/points", authMiddleware.RequireAdmin(), customerHandler.AdjustPoints)
 		}
 
 		// QRIS routes (Phase 2 implementation)
 		qris := api.Group("/qris")
 		qris.Use(authMiddleware.RequireAdminOrCashier())
 		{
-			qris.POST("/generate", paymentHandler.GenerateQRIS)
+			qris.POST("/generate", qrisLimit, paymentHandler.GenerateQRIS)
+			qris.GET("/jobs/:id", paymentHandler.GetQRISJob)
+			qris.GET("/jobs/:id/events", paymentHandler.StreamQRISJobEvents)
+			qris.GET("/:transaction_id", paymentHandler.GetQRIS)
+			qris.GET("/:transaction_id/image", paymentHandler.GetQRISImage)
 			qris.GET("/:transaction_id/status", paymentHandler.GetPaymentStatus)
+			qris.POST("/status/batch", paymentHandler.GetPaymentStatuses)
 			qris.POST("/:transaction_id/refresh", paymentHandler.RefreshQRIS)
+			qris.POST("/:transaction_id/confirm", paymentHandler.ConfirmLocalQRIS)
+			qris.GET("/:transaction_id/events", paymentHandler.StreamPaymentEvents)
 		}
 
 		// Payment routes (Phase 2 implementation)
 		payments := api.Group("/payments")
 		{
-			payments.POST("/callback", paymentHandler.PaymentCallback) // Public - webhook from Midtrans
+			payments.POST("/callback", callbackLimit, callbackAllowList, paymentHandler.PaymentCallback)           // Public - webhook from Midtrans
+			payments.POST("/callback/:provider", callbackLimit, callbackAllowList, paymentHandler.PaymentCallback) // Public - webhooks of the other gateways
+			payments.GET("", authMiddleware.RequireAdminOrCashier(), paymentHandler.ListPayments)
 			payments.GET("/:transaction_id/status", authMiddleware.RequireAdminOrCashier(), paymentHandler.GetPaymentStatus)
+			payments.POST("/cash", authMiddleware.RequireAdminOrCashier(), paymentHandler.PayWithCash)
+			payments.POST("/manual", authMiddleware.RequireAdminOrCashier(), paymentHandler.PayWithManualMethod)
+			payments.POST("/ewallet", authMiddleware.RequireAdminOrCashier(), paymentHandler.PayWithEWallet)
+			payments.POST("/:transaction_id/refund", authMiddleware.RequireAdmin(), auditMiddleware.Record(entities.AuditPaymentRefund), refundHandler.RefundPayment) // :transaction_id holds the payment ID
+			payments.GET("/:transaction_id/refunds", authMiddleware.RequireAdmin(), refundHandler.ListRefunds)
+			payments.GET("/:transaction_id/history", authMiddleware.RequireAdmin(), paymentHandler.ListPaymentHistory) // :transaction_id holds the payment ID
+		}
+
+		// Payment exceptions queue (Admin only), gateway callbacks are the platform's in SaaS mode
+		paymentExceptions := api.Group("/payment-exceptions")
+		paymentExceptions.Use(authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform())
+		{
+			paymentExceptions.GET("", paymentHandler.ListPaymentExceptions)
+			paymentExceptions.POST("/:id/resolve", paymentHandler.ResolvePaymentException)
+		}
+
+		// Payment notification routes (Admin only), the dead letters of gateway callbacks
+		paymentNotifications := api.Group("/payment-notifications")
+		paymentNotifications.Use(authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform())
+		{
+			paymentNotifications.GET("", paymentHandler.ListPaymentNotifications)
+			paymentNotifications.POST("/:id/replay", paymentHandler.ReplayPaymentNotification)
+		}
+
+		// Void approval routes. Approving is open to cashiers so a manager can sign off on their register.
+		voidRequests := api.Group("/void-requests")
+		voidRequests.Use(authMiddleware.RequireAdminOrCashier())
+		{
+			voidRequests.GET("", authMiddleware.RequireAdmin(), voidHandler.ListVoidRequests)
+			voidRequests.POST("/:id/approve", approvalLimit, auditMiddleware.Record(entities.AuditTransactionVoid), voidHandler.ApproveVoid)
+			voidRequests.POST("/:id/reject", authMiddleware.RequireAdmin(), voidHandler.RejectVoid)
+		}
+
+		// Shift routes (Admin/Cashier)
+		shifts := api.Group("/shifts")
+		shifts.Use(authMiddleware.RequireAdminOrCashier())
+		{
+			shifts.POST("/open", shiftHandler.OpenShift)
+			shifts.POST("/close", shiftHandler.CloseShift)
+			shifts.GET("/current", shiftHandler.GetCurrentShift)
+			shifts.POST("/cash-movements", shiftHandler.RecordCashMovement)
+			shifts.GET("/:id/report", shiftHandler.GetShiftReport)
+			shifts.GET("/pending-approval", authMiddleware.RequireAdmin(), shiftHandler.ListPendingApprovals)
+			shifts.POST("/:id/approve", authMiddleware.RequireAdmin(), shiftHandler.ApproveVariance)
+		}
+
+		// Offline sync routes (Admin/Cashier)
+		sync := api.Group("/sync")
+		sync.Use(authMiddleware.RequireAdminOrCashier())
+		{
+			sync.POST("/transactions", syncHandler.PushTransactions)
+			sync.GET("/changes", syncHandler.GetChanges)
+		}
+
+		// Report routes (Admin only)
+		reports := api.Group("/reports")
+		reports.Use(authMiddleware.RequireAdmin(), reportLimiter.Limit())
+		{
+			reports.GET("/products/top", reportHandler.GetTopProducts)
+			reports.GET("/profit", reportHandler.GetProfit)
+			reports.GET("/accounting-export", accountingHandler.ExportJournal)
+			reports.POST("/accounting-export/push", tenantMiddleware.RequirePlatform(), accountingHandler.PushJournal)
+		}
+
+		// Report schedule routes (Admin only)
+		reportSchedules := api.Group("/report-schedules")
+		reportSchedules.Use(authMiddleware.RequireAdmin())
+		{
+			reportSchedules.GET("", reportScheduleHandler.ListReportSchedules)
+			reportSchedules.POST("", reportScheduleHandler.CreateReportSchedule)
+			reportSchedules.GET("/:id", reportScheduleHandler.GetReportSchedule)
+			reportSchedules.PUT("/:id", reportScheduleHandler.UpdateReportSchedule)
+			reportSchedules.DELETE("/:id", reportScheduleHandler.DeleteReportSchedule)
+			reportSchedules.POST("/:id/send", reportScheduleHandler.SendReportNow)
+		}
+
+		// Inventory routes (Admin only)
+		inventoryRoutes := api.Group("/inventory")
+		inventoryRoutes.Use(authMiddleware.RequireAdmin(), reportLimiter.Limit())
+		{
+			inventoryRoutes.GET("/forecast", inventoryHandler.GetForecast)
+		}
+
+		// Alert routes (Admin only), emailed to the owners of the platform in SaaS mode
+		alerts := api.Group("/alerts")
+		alerts.Use(authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform())
+		{
+			alerts.GET("", alertHandler.ListAlerts)
+			alerts.POST("/:id/acknowledge", alertHandler.AcknowledgeAlert)
+		}
+
+		// Export routes (Admin only)
+		exports := api.Group("/exports")
+		exports.Use(authMiddleware.RequireAdmin())
+		{
+			exports.GET("", exportHandler.ListExports)
+			exports.POST("", exportHandler.CreateExport)
+			exports.GET("/:id", exportHandler.GetExport)
+		}
+
+		// Settlement reconciliation routes (Admin only)
+		reconciliationRoutes := api.Group("/reconciliation")
+		reconciliationRoutes.Use(authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform())
+		{
+			reconciliationRoutes.GET("/runs", reconciliationHandler.ListRuns)
+			reconciliationRoutes.POST("/runs", reconciliationHandler.CreateRun)
+			reconciliationRoutes.POST("/runs/upload", reconciliationHandler.UploadRun)
+			reconciliationRoutes.GET("/runs/:id", reconciliationHandler.GetRun)
+			reconciliationRoutes.GET("/runs/:id/items", reconciliationHandler.ListItems)
+		}
+
+		// Webhook routes (Admin only)
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(authMiddleware.RequireAdmin())
+		{
+			webhooks.GET("", webhookHandler.ListEndpoints)
+			webhooks.POST("", webhookHandler.CreateEndpoint)
+			webhooks.GET("/:id", webhookHandler.GetEndpoint)
+			webhooks.PUT("/:id", webhookHandler.UpdateEndpoint)
+			webhooks.DELETE("/:id", webhookHandler.DeleteEndpoint)
+			webhooks.POST("/:id/rotate-secret", webhookHandler.RotateSecret)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		}
+
+		// Audit log routes (Admin only)
+		auditLogs := api.Group("/audit-logs")
+		auditLogs.Use(authMiddleware.RequireAdmin())
+		{
+			auditLogs.GET("", auditHandler.ListAuditLogs)
+		}
+
+		// Admin routes
+		admin := api.Group("/admin")
+		admin.Use(authMiddleware.RequireAdmin(), tenantMiddleware.RequirePlatform())
+		{
+			admin.GET("/system-status", systemHandler.GetSystemStatus)
 		}
 
 		// Image routes (Admin only)
@@ -183,38 +842,46 @@ func (s *Server) setupRouter() {
 	s.router = router
 }
 
-func (s *Server) corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+// setupDocs serves the Swagger UI and the generated spec, regenerated with make gen-docs
+func (s *Server) setupDocs(router *gin.Engine, api *gin.RouterGroup) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(docs.SwaggerInfo.ReadDoc()))
+	})
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+	swaggerUI := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/openapi.json"))
+	api.GET("/docs/*any", func(c *gin.Context) {
+		if c.Param("any") == "/" {
+			c.Redirect(http.StatusFound, "index.html")
 			return
 		}
-
-		c.Next()
-	}
+		swaggerUI(c)
+	})
 }
 
+// healthCheck godoc
+// @Summary Health check
+// @Description Report that the API is up, with its version and the number of requests in flight
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health [get]
 func (s *Server) healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"service": s.config.App.Name,
-		"version": s.config.App.Version,
+		"status":    "ok",
+		"service":   s.config.App.Name,
+		"version":   s.config.App.Version,
+		"in_flight": s.limiter.InFlight(),
 	})
 }
 
 func (s *Server) ListenAndServe() error {
-	address := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
-	return s.router.Run(address)
+	return s.httpServer.ListenAndServe()
 }
 
-func (s *Server) Shutdown(ctx interface{}) error {
-	// Gin doesn't have built-in graceful shutdown, but we can implement it if needed
-	return nil
+// Shutdown stops accepting connections and waits for in-flight requests, webhooks included, until ctx
+// is done. SSE streams and WebSocket connections are closed so they don't hold the drain open.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
 }
 
 // Placeholder handlers - will be implemented later
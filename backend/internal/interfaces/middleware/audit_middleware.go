@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAuditPayload caps the request body kept with an entry, larger bodies are logged without it
+const maxAuditPayload = 64 << 10
+
+// AuditMiddleware records sensitive operations in the audit log. It goes on the routes to audit, after
+// authentication, and only records requests the handler completed successfully.
+type AuditMiddleware struct {
+	auditRepo repositories.AuditLogRepository
+	logger    logger.Logger
+}
+
+func NewAuditMiddleware(auditRepo repositories.AuditLogRepository, logger logger.Logger) *AuditMiddleware {
+	return &AuditMiddleware{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+// Record audits the route as the given action
+func (m *AuditMiddleware) Record(action entities.AuditAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= 400 {
+			return
+		}
+		claims, ok := GetCurrentUser(c)
+		if !ok {
+			return
+		}
+
+		log := &entities.AuditLog{
+			UserID:     claims.UserID,
+			UserEmail:  claims.Email,
+			UserRole:   claims.Role,
+			Action:     action,
+			ResourceID: auditResourceID(c),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			StatusCode: c.Writer.Status(),
+			IPAddress:  c.ClientIP(),
+			Payload:    maskPayload(body),
+		}
+		if storeID, ok := repositories.StoreFromContext(c.Request.Context()); ok {
+			log.StoreID = &storeID
+		}
+
+		// The operation already happened, so a failed entry is logged rather than failing the request
+		if err := m.auditRepo.Create(context.WithoutCancel(c.Request.Context()), log); err != nil {
+			m.logger.Error("Failed to record audit log", "error", err, "action", action, "user_id", claims.UserID, "path", log.Path)
+		}
+	}
+}
+
+// auditResourceID is the entity the route acted on, taken from its path
+func auditResourceID(c *gin.Context) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	return c.Param("transaction_id")
+}
+
+// maskPayload returns the JSON body with passwords, tokens and secrets replaced, empty when it isn't JSON
+func maskPayload(body []byte) string {
+	if len(body) == 0 || len(body) > maxAuditPayload {
+		return ""
+	}
+
+	var payload any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	maskSecrets(payload)
+
+	masked, err := json.Marshal(payload)
+	if err != nil {
+		return ""
+	}
+	return string(masked)
+}
+
+func maskSecrets(node any) {
+	switch value := node.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if isSecretKey(key) {
+				value[key] = "***"
+				continue
+			}
+			maskSecrets(child)
+		}
+	case []any:
+		for _, child := range value {
+			maskSecrets(child)
+		}
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "token") || strings.Contains(key, "secret") ||
+		strings.Contains(key, "server_key")
+}
@@ -1,65 +1,131 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"sync"
+	"time"
 
 	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
 	"qris-pos-backend/pkg/auth"
+	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// StoreHeader lets an admin pick the store a request works in
+const StoreHeader = "X-Store-ID"
+
+// sessionTouchInterval is how often the last activity of a session is written, a session is listed by the
+// minute
+const sessionTouchInterval = time.Minute
+
 type AuthMiddleware struct {
 	jwtService *auth.JWTService
+	userRepo   repositories.UserRepository
+	storeRepo  repositories.StoreRepository
+	tenantRepo repositories.TenantRepository
+	tokenRepo  repositories.TokenRepository
+
+	touchMu  sync.Mutex
+	touches  map[string]time.Time // Last activity written per session
+	prunedAt time.Time
 }
 
-func NewAuthMiddleware(jwtService *auth.JWTService) *AuthMiddleware {
+func NewAuthMiddleware(jwtService *auth.JWTService, userRepo repositories.UserRepository, storeRepo repositories.StoreRepository, tenantRepo repositories.TenantRepository, tokenRepo repositories.TokenRepository) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtService: jwtService,
+		userRepo:   userRepo,
+		storeRepo:  storeRepo,
+		tenantRepo: tenantRepo,
+		tokenRepo:  tokenRepo,
+		touches:    make(map[string]time.Time),
 	}
 }
 
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			response.Unauthorized(c, "Authorization header is required")
-			c.Abort()
+		if !m.authenticateRequest(c) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		// Check if header starts with Bearer
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			response.Unauthorized(c, "Invalid authorization header format")
-			c.Abort()
-			return
+// authenticateRequest checks the token, tenant and store of the request and sets the user info in the
+// context. It answers and aborts the request when they don't hold, and leaves running the rest of the
+// chain to the caller so a role check can come first. A request authenticated earlier in the chain is
+// not checked again.
+func (m *AuthMiddleware) authenticateRequest(c *gin.Context) bool {
+	if _, ok := GetCurrentUser(c); ok {
+		return true
+	}
+
+	authHeader := c.GetHeader("Authorization")
+	// Browser EventSource and WebSocket clients cannot set headers, so streams may pass the token as a query parameter
+	if authHeader == "" && isStreamingRequest(c) {
+		if token := c.Query("access_token"); token != "" {
+			authHeader = "Bearer " + token
 		}
+	}
+	if authHeader == "" {
+		response.Unauthorized(c, "Authorization header is required")
+		c.Abort()
+		return false
+	}
 
-		token := tokenParts[1]
-		claims, err := m.jwtService.ValidateToken(token)
-		if err != nil {
+	// Check if header starts with Bearer
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		response.Unauthorized(c, "Invalid authorization header format")
+		c.Abort()
+		return false
+	}
+
+	token := tokenParts[1]
+	claims, err := m.authenticate(c.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrTokenRevoked) {
+			response.Unauthorized(c, "Token has been revoked, please login again")
+		} else {
 			response.Unauthorized(c, "Invalid or expired token")
-			c.Abort()
-			return
 		}
+		c.Abort()
+		return false
+	}
 
-		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
-		c.Set("claims", claims)
-
-		c.Next()
+	if err := m.scopeToTenant(c, claims); err != nil {
+		response.Forbidden(c, err.Error())
+		c.Abort()
+		return false
 	}
+	if err := m.scopeToStore(c, claims); err != nil {
+		if errors.Is(err, appErrors.ErrStoreNotFound) {
+			response.NotFound(c, err.Error())
+		} else {
+			response.Forbidden(c, err.Error())
+		}
+		c.Abort()
+		return false
+	}
+
+	// Set user info in context
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Set("user_role", claims.Role)
+	c.Set("claims", claims)
+	c.Request = c.Request.WithContext(repositories.WithUser(c.Request.Context(), claims.UserID))
+	return true
 }
 
 func (m *AuthMiddleware) RequireRole(allowedRoles ...entities.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// First check authentication
-		m.RequireAuth()(c)
-		if c.IsAborted() {
+		// First check authentication, the handler only runs once the role is checked too
+		if !m.authenticateRequest(c) {
 			return
 		}
 
@@ -114,22 +180,159 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		}
 
 		token := tokenParts[1]
-		claims, err := m.jwtService.ValidateToken(token)
+		claims, err := m.authenticate(c.Request.Context(), token)
 		if err != nil {
 			c.Next()
 			return
 		}
 
+		// Without a usable tenant or store the request is treated as anonymous
+		if err := m.scopeToTenant(c, claims); err != nil {
+			c.Next()
+			return
+		}
+		if err := m.scopeToStore(c, claims); err != nil {
+			c.Next()
+			return
+		}
+
 		// Set user info in context if valid
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
 		c.Set("claims", claims)
+		c.Request = c.Request.WithContext(repositories.WithUser(c.Request.Context(), claims.UserID))
 
 		c.Next()
 	}
 }
 
+// authenticate validates the token and rejects it when it was logged out or issued before the user's
+// last password change
+func (m *AuthMiddleware) authenticate(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := m.jwtService.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.ID != "" {
+		revoked, err := m.tokenRepo.IsAccessTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, appErrors.ErrTokenRevoked
+		}
+	}
+
+	user, err := m.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, appErrors.ErrInvalidToken
+	}
+
+	if claims.IssuedAt == nil || !user.AcceptsTokenIssuedAt(claims.IssuedAt.Time) {
+		return nil, appErrors.ErrTokenRevoked
+	}
+
+	// A cashier moved to another store works there without logging in again
+	claims.StoreID = ""
+	if user.StoreID != nil {
+		claims.StoreID = *user.StoreID
+	}
+	claims.TenantID = ""
+	if user.TenantID != nil {
+		claims.TenantID = *user.TenantID
+	}
+
+	// Last activity of the session, best effort: the request goes ahead if it can't be recorded
+	if claims.SessionID != "" {
+		m.touchSession(ctx, claims.SessionID)
+	}
+
+	return claims, nil
+}
+
+// touchSession records the activity of a session at most once a minute per instance, so busy terminals
+// don't write on every request
+func (m *AuthMiddleware) touchSession(ctx context.Context, sessionID string) {
+	now := time.Now()
+
+	m.touchMu.Lock()
+	if now.Sub(m.touches[sessionID]) < sessionTouchInterval {
+		m.touchMu.Unlock()
+		return
+	}
+	m.touches[sessionID] = now
+	// Forget the sessions gone quiet, they are written on their next request anyway
+	if now.Sub(m.prunedAt) >= sessionTouchInterval {
+		for id, at := range m.touches {
+			if now.Sub(at) >= sessionTouchInterval {
+				delete(m.touches, id)
+			}
+		}
+		m.prunedAt = now
+	}
+	m.touchMu.Unlock()
+
+	_ = m.tokenRepo.TouchSession(ctx, sessionID, now)
+}
+
+// scopeToTenant keeps the users of a tenant in it, wherever they send their requests. The user was
+// looked up within the tenant the request named, if any, so users of other tenants never get this far.
+// Requests sent to the platform are checked against a suspended tenant here.
+func (m *AuthMiddleware) scopeToTenant(c *gin.Context, claims *auth.Claims) error {
+	if claims.TenantID == "" {
+		return nil
+	}
+	if _, ok := repositories.TenantFromContext(c.Request.Context()); !ok {
+		tenant, err := m.tenantRepo.GetByID(c.Request.Context(), claims.TenantID)
+		if err != nil {
+			return appErrors.ErrTenantNotFound
+		}
+		if !tenant.IsActive {
+			return appErrors.ErrTenantSuspended
+		}
+	}
+	c.Request = c.Request.WithContext(repositories.WithTenant(c.Request.Context(), claims.TenantID))
+	c.Set("tenant_id", claims.TenantID)
+	return nil
+}
+
+// scopeToStore limits the request to one store. Cashiers always work in their own store. Admins work in
+// the store picked with the X-Store-ID header, and across every store without it.
+func (m *AuthMiddleware) scopeToStore(c *gin.Context, claims *auth.Claims) error {
+	requested := c.GetHeader(StoreHeader)
+
+	storeID := requested
+	if claims.Role != entities.RoleAdmin {
+		if claims.StoreID == "" {
+			return appErrors.ErrStoreRequired
+		}
+		if requested != "" && requested != claims.StoreID {
+			return appErrors.ErrStoreForbidden
+		}
+		storeID = claims.StoreID
+	} else if requested != "" {
+		if _, err := uuid.Parse(requested); err != nil {
+			return appErrors.ErrStoreNotFound
+		}
+		if _, err := m.storeRepo.GetByID(c.Request.Context(), requested); err != nil {
+			return appErrors.ErrStoreNotFound
+		}
+	}
+
+	if storeID != "" {
+		c.Request = c.Request.WithContext(repositories.WithStore(c.Request.Context(), storeID))
+		c.Set("store_id", storeID)
+	}
+	return nil
+}
+
+func isStreamingRequest(c *gin.Context) bool {
+	return c.GetHeader("Accept") == "text/event-stream" ||
+		strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
+
 // Helper function to get current user from context
 func GetCurrentUser(c *gin.Context) (*auth.Claims, bool) {
 	claims, exists := c.Get("claims")
@@ -139,4 +342,12 @@ func GetCurrentUser(c *gin.Context) (*auth.Claims, bool) {
 
 	userClaims, ok := claims.(*auth.Claims)
 	return userClaims, ok
-}
\ No newline at end of file
+}
+
+// GetCurrentRole returns the role of the signed in user, empty for anonymous requests
+func GetCurrentRole(c *gin.Context) entities.UserRole {
+	if claims, ok := GetCurrentUser(c); ok {
+		return claims.Role
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/interfaces/middleware"
+	"qris-pos-backend/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	repositories.UserRepository
+	users map[string]*entities.User
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (*entities.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type tokenRepository struct {
+	repositories.TokenRepository
+}
+
+func (tokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+func TestRequireRoleStopsOtherRolesBeforeTheHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	storeID := "6f1c2a4e-7d7e-4c55-9a3e-2b8f0f5d8a11"
+	admin := &entities.User{ID: "admin", Email: "admin@example.com", Role: entities.RoleAdmin}
+	cashier := &entities.User{ID: "cashier", Email: "cashier@example.com", Role: entities.RoleCashier, StoreID: &storeID}
+	jwtService := auth.NewJWTService("secret", 1, "qris-pos", "qris-pos", 0)
+	authMiddleware := middleware.NewAuthMiddleware(jwtService,
+		&userRepository{users: map[string]*entities.User{admin.ID: admin, cashier.ID: cashier}},
+		nil, nil, tokenRepository{})
+
+	var handled bool
+	handler := func(c *gin.Context) {
+		handled = true
+		c.Status(http.StatusOK)
+	}
+	router := gin.New()
+	router.POST("/payments/:id/refund", authMiddleware.RequireAdmin(), handler)
+	// An admin route nested in a group open to cashiers, as the void requests are
+	group := router.Group("/void-requests", authMiddleware.RequireAdminOrCashier())
+	group.GET("", authMiddleware.RequireAdmin(), handler)
+
+	tests := []struct {
+		method, path string
+		user         *entities.User
+		want         int
+	}{
+		{http.MethodPost, "/payments/p1/refund", cashier, http.StatusForbidden},
+		{http.MethodPost, "/payments/p1/refund", admin, http.StatusOK},
+		{http.MethodGet, "/void-requests", cashier, http.StatusForbidden},
+		{http.MethodGet, "/void-requests", admin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" as "+string(tt.user.Role), func(t *testing.T) {
+			handled = false
+			token, _, err := jwtService.GenerateToken(tt.user, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+			if handled != (tt.want == http.StatusOK) {
+				t.Errorf("handler ran = %v with status %d", handled, recorder.Code)
+			}
+		})
+	}
+}
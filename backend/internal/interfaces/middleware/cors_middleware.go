@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"qris-pos-backend/internal/infrastructure/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS answers cross-origin requests from the configured origins only. The request origin is echoed
+// back rather than "*", which browsers reject together with credentials. Preflights from other
+// origins are refused, their simple requests go through without CORS headers so the browser blocks them.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	allowAny := false
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAny = true
+			continue
+		}
+		origins[strings.TrimSuffix(strings.ToLower(origin), "/")] = true
+	}
+
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(cfg.MaxAgeSeconds)
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			// Same-origin or non-browser client
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		explicit := origins[strings.ToLower(origin)]
+		if !explicit && !allowAny {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if explicit {
+			c.Header("Access-Control-Allow-Origin", origin)
+			if cfg.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+		} else {
+			// Credentials are never shared with an arbitrary site
+			c.Header("Access-Control-Allow-Origin", "*")
+		}
+		if exposed != "" {
+			c.Header("Access-Control-Expose-Headers", exposed)
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			if cfg.MaxAgeSeconds > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"qris-pos-backend/pkg/logger"
+	"qris-pos-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllowIPs admits only requests from the listed addresses or CIDR ranges, for public endpoints such as
+// gateway callbacks that a known set of servers calls. An empty list admits everyone. Client addresses
+// behind a proxy are only right when the proxy is trusted, see SERVER_TRUSTED_PROXIES.
+func AllowIPs(name string, entries []string, logger logger.Logger) (gin.HandlerFunc, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s allow-list entry %q: %w", name, entry, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s allow-list entry %q: %w", name, entry, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return func(c *gin.Context) {
+		if len(prefixes) == 0 {
+			c.Next()
+			return
+		}
+
+		clientIP := c.ClientIP()
+		if addr, err := netip.ParseAddr(clientIP); err == nil {
+			addr = addr.Unmap()
+			for _, prefix := range prefixes {
+				if prefix.Contains(addr) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		logger.Warn("Rejected request from address outside the allow-list", "allow_list", name, "client_ip", clientIP, "path", c.Request.URL.Path)
+		response.Forbidden(c, "Address is not allowed")
+		c.Abort()
+	}, nil
+}
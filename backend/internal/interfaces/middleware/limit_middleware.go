@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"qris-pos-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout bounds every request with a context deadline so database and gateway calls give up
+// instead of holding a worker. Overrides are keyed by route prefix, the longest matching prefix wins.
+// Streaming requests (SSE, WebSocket) are long-lived by design and are left alone.
+func Timeout(defaultTimeout time.Duration, overrides map[string]time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isStreamingRequest(c) {
+			c.Next()
+			return
+		}
+
+		timeout := routeTimeout(c.FullPath(), defaultTimeout, overrides)
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			response.GatewayTimeout(c, "Request timed out, please try again")
+			c.Abort()
+		}
+	}
+}
+
+func routeTimeout(path string, defaultTimeout time.Duration, overrides map[string]time.Duration) time.Duration {
+	timeout, matched := defaultTimeout, 0
+	for prefix, override := range overrides {
+		if strings.HasPrefix(path, prefix) && len(prefix) > matched {
+			timeout, matched = override, len(prefix)
+		}
+	}
+	return timeout
+}
+
+// ConcurrencyLimiter sheds load once too many requests are in flight. A request waits up to the
+// queue timeout for a free slot and is rejected with 503 after that, so a slow dependency
+// can't pile up an unbounded number of blocked workers.
+type ConcurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+func NewConcurrencyLimiter(maxInFlight int, queueTimeout time.Duration) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		slots:        make(chan struct{}, maxInFlight),
+		queueTimeout: queueTimeout,
+	}
+}
+
+func (l *ConcurrencyLimiter) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cap(l.slots) == 0 || isStreamingRequest(c) {
+			c.Next()
+			return
+		}
+
+		if !l.acquire(c.Request.Context()) {
+			c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(l.queueTimeout)))
+			response.ServiceUnavailable(c, "Server is busy, please try again shortly")
+			c.Abort()
+			return
+		}
+		defer l.release()
+
+		c.Next()
+	}
+}
+
+// acquire takes a free slot, waiting up to the queue timeout when all are in use
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	<-l.slots
+}
+
+// InFlight reports how many requests currently hold a slot
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+func retryAfterSeconds(queueTimeout time.Duration) int {
+	if seconds := int(queueTimeout.Seconds()); seconds > 1 {
+		return seconds
+	}
+	return 1
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"regexp"
+	"time"
+
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the correlation ID of a request, it is echoed on every response
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern accepts IDs forwarded by a proxy or the client, anything else is replaced
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._\-]{8,64}$`)
+
+// RequestLogger replaces the gin access log with one JSON line per request. Each request gets an ID,
+// taken from the X-Request-ID header when a proxy already assigned one, which is returned in the
+// response so support can find the matching log lines and trace.
+func RequestLogger(log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request.id", requestID))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		args := []interface{}{
+			"request_id", requestID,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+			"bytes", c.Writer.Size(),
+		}
+		if userID := c.GetString("user_id"); userID != "" {
+			args = append(args, "user_id", userID)
+		}
+		if storeID := c.GetString("store_id"); storeID != "" {
+			args = append(args, "store_id", storeID)
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, "error", c.Errors.String())
+		}
+
+		switch {
+		case status >= 500:
+			log.Error("Request completed", args...)
+		case status >= 400:
+			log.Warn("Request completed", args...)
+		default:
+			log.Info("Request completed", args...)
+		}
+	}
+}
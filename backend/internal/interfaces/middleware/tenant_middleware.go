@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TenantHeader picks the tenant of a request by slug or ID, for clients that can't use a subdomain
+const TenantHeader = "X-Tenant-ID"
+
+type TenantMiddleware struct {
+	config     config.TenancyConfig
+	tenantRepo repositories.TenantRepository
+}
+
+func NewTenantMiddleware(config config.TenancyConfig, tenantRepo repositories.TenantRepository) *TenantMiddleware {
+	return &TenantMiddleware{
+		config:     config,
+		tenantRepo: tenantRepo,
+	}
+}
+
+// Resolve scopes the request to the tenant named by the X-Tenant-ID header or, without it, by the
+// subdomain of TENANCY_BASE_DOMAIN the request was sent to. Requests naming no tenant reach the platform.
+// Does nothing outside SaaS mode.
+func (m *TenantMiddleware) Resolve() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.config.Enabled {
+			c.Next()
+			return
+		}
+
+		ref := strings.ToLower(strings.TrimSpace(c.GetHeader(TenantHeader)))
+		if ref == "" {
+			ref = m.subdomain(c.Request.Host)
+		}
+		if ref == "" {
+			c.Next()
+			return
+		}
+
+		tenant, err := m.lookup(c, ref)
+		if err != nil {
+			response.NotFound(c, appErrors.ErrTenantNotFound.Error())
+			c.Abort()
+			return
+		}
+		if !tenant.IsActive {
+			response.Forbidden(c, appErrors.ErrTenantSuspended.Error())
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(repositories.WithTenant(c.Request.Context(), tenant.ID))
+		c.Set("tenant_id", tenant.ID)
+		c.Next()
+	}
+}
+
+// RequirePlatform admits only requests made outside every tenant, for managing the tenants themselves.
+// It goes after authentication, which scopes the users of a tenant to it.
+func (m *TenantMiddleware) RequirePlatform() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := repositories.TenantFromContext(c.Request.Context()); ok {
+			response.Forbidden(c, appErrors.ErrTenantForbidden.Error())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func (m *TenantMiddleware) lookup(c *gin.Context, ref string) (*entities.Tenant, error) {
+	if _, err := uuid.Parse(ref); err == nil {
+		return m.tenantRepo.GetByID(c.Request.Context(), ref)
+	}
+	return m.tenantRepo.GetBySlug(c.Request.Context(), ref)
+}
+
+// subdomain returns the label in front of the base domain, empty for the base domain itself, hosts
+// deeper than one label under it and other hosts
+func (m *TenantMiddleware) subdomain(host string) string {
+	if m.config.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+m.config.BaseDomain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
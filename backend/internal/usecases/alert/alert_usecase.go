@@ -0,0 +1,308 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/internal/infrastructure/notification"
+	"qris-pos-backend/internal/infrastructure/receipt"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type ListAlertsRequest struct {
+	Type         entities.AlertType `form:"type" validate:"omitempty,oneof=void_spike after_hours_refund discount_outlier stock_unavailable"`
+	Acknowledged *bool              `form:"acknowledged"`
+	Limit        int                `form:"limit,default=50" validate:"gte=1,lte=200"`
+	Offset       int                `form:"offset,default=0" validate:"gte=0"`
+}
+
+type AlertUseCase struct {
+	alertRepo   repositories.AlertRepository
+	emailSender notification.Sender
+	eventBroker *events.Broker
+	location    *time.Location
+	config      config.AlertConfig
+	logger      logger.Logger
+}
+
+func NewAlertUseCase(
+	alertRepo repositories.AlertRepository,
+	senders []notification.Sender,
+	eventBroker *events.Broker,
+	cfg config.AlertConfig,
+	logger logger.Logger,
+) *AlertUseCase {
+	var emailSender notification.Sender
+	for _, sender := range senders {
+		if sender.Channel() == entities.ReceiptChannelEmail {
+			emailSender = sender
+		}
+	}
+
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		logger.Warn("Unknown business timezone, using server local time", "timezone", cfg.Timezone, "error", err)
+		location = time.Local
+	}
+
+	return &AlertUseCase{
+		alertRepo:   alertRepo,
+		emailSender: emailSender,
+		eventBroker: eventBroker,
+		location:    location,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// Run is the scheduled anomaly detection job. Every detector runs even when another one fails.
+func (uc *AlertUseCase) Run(ctx context.Context) error {
+	now := time.Now()
+	return errors.Join(
+		uc.detectVoidSpike(ctx, now),
+		uc.detectAfterHoursRefunds(ctx, now),
+		uc.detectDiscountOutliers(ctx, now),
+	)
+}
+
+// Listen raises an alert for every payment settled on a cart whose stock ran out, until the broker
+// closes on shutdown. The owners have to refund the customer or hand the goods over another way.
+func (uc *AlertUseCase) Listen() {
+	exceptions, unsubscribe := uc.eventBroker.Subscribe(func(event events.Event) bool {
+		return event.Type == events.EventPaymentException
+	})
+	defer unsubscribe()
+
+	for event := range exceptions {
+		exception, ok := event.Data.(*entities.PaymentException)
+		if !ok || exception.Reason != entities.ExceptionStockUnavailable {
+			continue
+		}
+		if err := uc.raiseStockUnavailable(context.Background(), exception); err != nil {
+			uc.logger.Error("Failed to raise stock unavailable alert", "error", err, "exception_id", exception.ID)
+		}
+	}
+}
+
+func (uc *AlertUseCase) ListAlerts(ctx context.Context, req *ListAlertsRequest) ([]entities.Alert, error) {
+	return uc.alertRepo.List(ctx, repositories.AlertFilters{
+		Type:         req.Type,
+		Acknowledged: req.Acknowledged,
+		Limit:        req.Limit,
+		Offset:       req.Offset,
+	})
+}
+
+func (uc *AlertUseCase) AcknowledgeAlert(ctx context.Context, id, userID string) (*entities.Alert, error) {
+	alert, err := uc.alertRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrAlertNotFound
+		}
+		return nil, err
+	}
+
+	if err := alert.Acknowledge(userID); err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+
+	if err := uc.alertRepo.Update(ctx, alert); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// detectVoidSpike compares the voids of the current window with the average window of the baseline period
+func (uc *AlertUseCase) detectVoidSpike(ctx context.Context, now time.Time) error {
+	window := time.Duration(uc.config.VoidWindowMinutes) * time.Minute
+	if window <= 0 {
+		return nil
+	}
+	from := now.Add(-window)
+
+	count, err := uc.alertRepo.CountVoids(ctx, from, now)
+	if err != nil {
+		return fmt.Errorf("count voids: %w", err)
+	}
+	if count < int64(uc.config.VoidMinCount) {
+		return nil
+	}
+
+	baselinePeriod := time.Duration(uc.config.VoidBaselineDays) * 24 * time.Hour
+	baselineCount, err := uc.alertRepo.CountVoids(ctx, from.Add(-baselinePeriod), from)
+	if err != nil {
+		return fmt.Errorf("count baseline voids: %w", err)
+	}
+
+	baseline := float64(baselineCount) / (float64(baselinePeriod) / float64(window))
+	threshold := math.Max(baseline*uc.config.VoidSpikeMultiplier, float64(uc.config.VoidMinCount))
+	if float64(count) < threshold {
+		return nil
+	}
+
+	severity := entities.AlertWarning
+	if float64(count) >= threshold*2 {
+		severity = entities.AlertCritical
+	}
+
+	return uc.raise(ctx, &entities.Alert{
+		Type:     entities.AlertVoidSpike,
+		Severity: severity,
+		Message: fmt.Sprintf("%d transactions voided in the last %d minutes, usually %.1f",
+			count, uc.config.VoidWindowMinutes, baseline),
+		Value:       float64(count),
+		Threshold:   threshold,
+		Fingerprint: fmt.Sprintf("%s:%d", entities.AlertVoidSpike, now.Truncate(window).Unix()),
+	})
+}
+
+// detectAfterHoursRefunds flags refunds recorded outside business hours
+func (uc *AlertUseCase) detectAfterHoursRefunds(ctx context.Context, now time.Time) error {
+	refunds, err := uc.alertRepo.ListRefunds(ctx, uc.lookbackStart(now), now)
+	if err != nil {
+		return fmt.Errorf("list refunds: %w", err)
+	}
+
+	for _, refund := range refunds {
+		local := refund.CreatedAt.In(uc.location)
+		if uc.withinBusinessHours(local.Hour()) {
+			continue
+		}
+
+		transactionID := refund.TransactionID
+		userID := refund.RefundedBy
+		err := uc.raise(ctx, &entities.Alert{
+			Type:     entities.AlertAfterHoursRefund,
+			Severity: entities.AlertWarning,
+			Message: fmt.Sprintf("Refund of %s recorded at %s, outside business hours (%02d:00-%02d:00)",
+				receipt.FormatRupiah(refund.Amount), local.Format("2006-01-02 15:04"),
+				uc.config.BusinessOpenHour, uc.config.BusinessCloseHour),
+			TransactionID: &transactionID,
+			UserID:        &userID,
+			Value:         float64(refund.Amount),
+			Fingerprint:   fmt.Sprintf("%s:%s", entities.AlertAfterHoursRefund, refund.ID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// detectDiscountOutliers flags sales whose discount rate is more than the configured z-score above the baseline mean
+func (uc *AlertUseCase) detectDiscountOutliers(ctx context.Context, now time.Time) error {
+	baselineStart := now.AddDate(0, 0, -uc.config.DiscountBaselineDays)
+	stats, err := uc.alertRepo.DiscountRateStats(ctx, baselineStart, now)
+	if err != nil {
+		return fmt.Errorf("discount rate stats: %w", err)
+	}
+	if stats.Count < int64(uc.config.DiscountMinSamples) || stats.StdDev == 0 {
+		return nil
+	}
+
+	sales, err := uc.alertRepo.ListDiscountedSales(ctx, uc.lookbackStart(now), now)
+	if err != nil {
+		return fmt.Errorf("list discounted sales: %w", err)
+	}
+
+	for _, sale := range sales {
+		zScore := (sale.Rate - stats.Mean) / stats.StdDev
+		if zScore < uc.config.DiscountZScore {
+			continue
+		}
+
+		transactionID := sale.TransactionID
+		userID := sale.UserID
+		err := uc.raise(ctx, &entities.Alert{
+			Type:     entities.AlertDiscountOutlier,
+			Severity: entities.AlertWarning,
+			Message: fmt.Sprintf("Discount of %s (%.1f%%) is %.1f standard deviations above the average %.1f%%",
+				receipt.FormatRupiah(sale.Discount), sale.Rate*100, zScore, stats.Mean*100),
+			TransactionID: &transactionID,
+			UserID:        &userID,
+			Value:         math.Round(zScore*10000) / 10000,
+			Threshold:     uc.config.DiscountZScore,
+			Fingerprint:   fmt.Sprintf("%s:%s", entities.AlertDiscountOutlier, sale.TransactionID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// raiseStockUnavailable flags a payment exception for a paid cart that can't be fulfilled
+func (uc *AlertUseCase) raiseStockUnavailable(ctx context.Context, exception *entities.PaymentException) error {
+	return uc.raise(ctx, &entities.Alert{
+		Type:     entities.AlertStockUnavailable,
+		Severity: entities.AlertCritical,
+		Message: fmt.Sprintf("Payment of %s for order %s was received but its items are out of stock, refund it or hand the goods over and resolve payment exception %s",
+			receipt.FormatRupiah(exception.Amount), exception.OrderID, exception.ID),
+		TransactionID: exception.TransactionID,
+		Value:         float64(exception.Amount),
+		Fingerprint:   fmt.Sprintf("%s:%s", entities.AlertStockUnavailable, exception.ID),
+	})
+}
+
+// raise stores a new alert and notifies the owners; alerts raised by an earlier run are skipped
+func (uc *AlertUseCase) raise(ctx context.Context, alert *entities.Alert) error {
+	created, err := uc.alertRepo.Create(ctx, alert)
+	if err != nil {
+		return fmt.Errorf("create alert: %w", err)
+	}
+	if !created {
+		return nil
+	}
+
+	uc.logger.Warn("Anomaly detected", "alert_id", alert.ID, "type", alert.Type, "severity", alert.Severity, "value", alert.Value)
+
+	event := events.Event{Type: events.EventAlertRaised, Data: alert}
+	if alert.TransactionID != nil {
+		event.TransactionID = *alert.TransactionID
+	}
+	uc.eventBroker.Publish(event)
+
+	uc.notifyOwners(ctx, alert)
+	return nil
+}
+
+// notifyOwners emails the alert to the configured owners. Failures are only logged, the alert stays listed.
+func (uc *AlertUseCase) notifyOwners(ctx context.Context, alert *entities.Alert) {
+	if uc.emailSender == nil {
+		return
+	}
+
+	for _, to := range uc.config.NotifyEmails {
+		err := uc.emailSender.Send(ctx, &notification.Message{
+			To:      to,
+			Subject: fmt.Sprintf("[%s] POS alert: %s", alert.Severity, alert.Type),
+			Text:    alert.Message + "\n\nReview it in the back office and acknowledge it once handled.",
+		})
+		if err != nil {
+			uc.logger.Error("Failed to email alert", "error", err, "alert_id", alert.ID)
+		}
+	}
+}
+
+func (uc *AlertUseCase) lookbackStart(now time.Time) time.Time {
+	return now.Add(-time.Duration(uc.config.LookbackMinutes) * time.Minute)
+}
+
+// withinBusinessHours also handles hours that wrap past midnight, e.g. open 18 close 2
+func (uc *AlertUseCase) withinBusinessHours(hour int) bool {
+	open, closing := uc.config.BusinessOpenHour, uc.config.BusinessCloseHour
+	if open <= closing {
+		return hour >= open && hour < closing
+	}
+	return hour >= open || hour < closing
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+)
+
+const dateLayout = "2006-01-02"
+
+type ListAuditLogsRequest struct {
+	UserID     string               `form:"user_id" validate:"omitempty,uuid"`
+	Action     entities.AuditAction `form:"action" validate:"omitempty,oneof=product.update product.bulk_price product.stock user.register transaction.void transaction.exchange transaction.discount payment.refund"`
+	ResourceID string               `form:"resource_id" validate:"omitempty,max=100"`
+	DateFrom   string               `form:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo     string               `form:"date_to" validate:"omitempty,datetime=2006-01-02"` // Inclusive
+	Limit      int                  `form:"limit,default=50" validate:"gte=1,lte=200"`
+	Offset     int                  `form:"offset,default=0" validate:"gte=0"`
+}
+
+type AuditUseCase struct {
+	auditRepo repositories.AuditLogRepository
+	logger    logger.Logger
+}
+
+func NewAuditUseCase(auditRepo repositories.AuditLogRepository, logger logger.Logger) *AuditUseCase {
+	return &AuditUseCase{
+		auditRepo: auditRepo,
+		logger:    logger,
+	}
+}
+
+func (uc *AuditUseCase) ListAuditLogs(ctx context.Context, req *ListAuditLogsRequest) ([]entities.AuditLog, error) {
+	filters := repositories.AuditLogFilters{
+		UserID:     req.UserID,
+		Action:     req.Action,
+		ResourceID: req.ResourceID,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	}
+
+	if req.DateFrom != "" {
+		from, err := time.ParseInLocation(dateLayout, req.DateFrom, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid date_from", appErrors.ErrInvalidInput)
+		}
+		filters.From = &from
+	}
+	if req.DateTo != "" {
+		to, err := time.ParseInLocation(dateLayout, req.DateTo, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid date_to", appErrors.ErrInvalidInput)
+		}
+		to = to.AddDate(0, 0, 1)
+		filters.To = &to
+	}
+	if filters.From != nil && filters.To != nil && !filters.From.Before(*filters.To) {
+		return nil, fmt.Errorf("%w: date_from must not be after date_to", appErrors.ErrInvalidInput)
+	}
+
+	return uc.auditRepo.List(ctx, filters)
+}
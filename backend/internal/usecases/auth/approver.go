@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/pkg/auth"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// VerifyApprover returns the admin whose credentials a manager entered on a cashier's register to sign off
+// on a void or a discount. An unknown email, an inactive or non-admin account and a wrong password all get
+// ErrInvalidCredentials, the role checked before the password, so the register can't tell whose password
+// was typed.
+func VerifyApprover(ctx context.Context, userRepo repositories.UserRepository, passwordService *auth.PasswordService, email, password string) (*entities.User, error) {
+	approver, err := userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if approver.Role != entities.RoleAdmin || !approver.IsActive || !passwordService.CheckPasswordHash(password, approver.Password) {
+		return nil, appErrors.ErrInvalidCredentials
+	}
+	return approver, nil
+}
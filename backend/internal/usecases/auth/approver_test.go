@@ -0,0 +1,61 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/usecases/auth"
+	pkgAuth "qris-pos-backend/pkg/auth"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	repositories.UserRepository
+	users []*entities.User
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestVerifyApprover(t *testing.T) {
+	passwords := pkgAuth.NewPasswordService()
+	hash, err := passwords.HashPassword("manager-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &userRepository{users: []*entities.User{
+		{ID: "manager", Email: "manager@example.com", Password: hash, Role: entities.RoleAdmin, IsActive: true},
+		{ID: "cashier", Email: "cashier@example.com", Password: hash, Role: entities.RoleCashier, IsActive: true},
+		{ID: "former", Email: "former@example.com", Password: hash, Role: entities.RoleAdmin, IsActive: false},
+	}}
+
+	approver, err := auth.VerifyApprover(context.Background(), users, passwords, "manager@example.com", "manager-secret")
+	if err != nil || approver.ID != "manager" {
+		t.Fatalf("approver = %+v, %v, want the manager", approver, err)
+	}
+
+	// The right password of a cashier is refused like any wrong entry
+	tests := []struct{ email, password string }{
+		{"manager@example.com", "wrong"},
+		{"cashier@example.com", "manager-secret"},
+		{"cashier@example.com", "wrong"},
+		{"former@example.com", "manager-secret"},
+		{"nobody@example.com", "manager-secret"},
+	}
+	for _, tt := range tests {
+		if _, err := auth.VerifyApprover(context.Background(), users, passwords, tt.email, tt.password); !errors.Is(err, appErrors.ErrInvalidCredentials) {
+			t.Errorf("%s with %q: err = %v, want invalid credentials", tt.email, tt.password, err)
+		}
+	}
+}
@@ -3,13 +3,18 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/notification"
 	"qris-pos-backend/pkg/auth"
 	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -23,43 +28,125 @@ type RegisterRequest struct {
 	Email    string            `json:"email" validate:"required,email"`
 	Password string            `json:"password" validate:"required,min=6"`
 	Role     entities.UserRole `json:"role" validate:"required,oneof=admin cashier"`
+	StoreID  *string           `json:"store_id" validate:"required_if=Role cashier,omitempty,uuid"` // Store the user works in, admins may be left without one to oversee every store
 }
 
 type LoginResponse struct {
-	User  *UserResponse `json:"user"`
-	Token string        `json:"token"`
+	User *UserResponse `json:"user"`
+	TokenResponse
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"` // Opaque, single use, traded at /auth/refresh for a new pair
+	ExpiresIn    int64  `json:"expires_in"`    // Seconds until the access token expires
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// ClientInfo describes the device a session is used from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// SessionResponse is a device the user is signed in on
+type SessionResponse struct {
+	ID           string    `json:"id"`
+	UserAgent    string    `json:"user_agent"`
+	IPAddress    string    `json:"ip_address"`
+	LoggedInAt   time.Time `json:"logged_in_at"`
+	LastActiveAt time.Time `json:"last_active_at"` // Last request, refresh or login, to the minute
+	ExpiresAt    time.Time `json:"expires_at"`     // When the session ends unless it is refreshed
+	Current      bool      `json:"current"`        // Session the request was made in
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Revoked along with the access token when given
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type VerifyResetCodeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// ResetTokenResponse is handed out for a verified reset code, to set the new password with
+type ResetTokenResponse struct {
+	ResetToken string `json:"reset_token"`
+	ExpiresIn  int64  `json:"expires_in"` // Seconds until the reset token expires
+}
+
+type ResetPasswordRequest struct {
+	ResetToken  string `json:"reset_token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
 }
 
 type UserResponse struct {
-	ID       string            `json:"id"`
-	Name     string            `json:"name"`
-	Email    string            `json:"email"`
-	Role     entities.UserRole `json:"role"`
-	IsActive bool              `json:"is_active"`
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email"`
+	Role      entities.UserRole `json:"role"`
+	StoreID   *string           `json:"store_id,omitempty"`
+	IsActive  bool              `json:"is_active"`
+	DeletedAt string            `json:"deleted_at,omitempty"` // Only set on deleted users, listed with include_deleted
+}
+
+type ListUsersRequest struct {
+	IncludeDeleted bool `form:"include_deleted"`
+	Limit          int  `form:"limit,default=50" validate:"gte=1,lte=100"`
+	Offset         int  `form:"offset,default=0" validate:"gte=0"`
 }
 
 type AuthUseCase struct {
 	userRepo        repositories.UserRepository
+	storeRepo       repositories.StoreRepository
+	tokenRepo       repositories.TokenRepository
 	passwordService *auth.PasswordService
 	jwtService      *auth.JWTService
+	refreshExpiry   time.Duration
+	mailer          notification.Sender // Nil when no email provider is configured
+	resetConfig     config.PasswordResetConfig
 	logger          logger.Logger
 }
 
 func NewAuthUseCase(
 	userRepo repositories.UserRepository,
+	storeRepo repositories.StoreRepository,
+	tokenRepo repositories.TokenRepository,
 	passwordService *auth.PasswordService,
 	jwtService *auth.JWTService,
+	cfg config.JWTConfig,
+	senders []notification.Sender,
+	resetConfig config.PasswordResetConfig,
 	logger logger.Logger,
 ) *AuthUseCase {
+	var mailer notification.Sender
+	for _, sender := range senders {
+		if sender.Channel() == entities.ReceiptChannelEmail {
+			mailer = sender
+		}
+	}
+
 	return &AuthUseCase{
 		userRepo:        userRepo,
+		storeRepo:       storeRepo,
+		tokenRepo:       tokenRepo,
 		passwordService: passwordService,
 		jwtService:      jwtService,
+		refreshExpiry:   time.Duration(cfg.RefreshExpiryHours) * time.Hour,
+		mailer:          mailer,
+		resetConfig:     resetConfig,
 		logger:          logger,
 	}
 }
 
-func (uc *AuthUseCase) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+func (uc *AuthUseCase) Login(ctx context.Context, req *LoginRequest, client ClientInfo) (*LoginResponse, error) {
 	// Find user by email
 	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
@@ -83,18 +170,21 @@ func (uc *AuthUseCase) Login(ctx context.Context, req *LoginRequest) (*LoginResp
 		return nil, appErrors.ErrInvalidCredentials
 	}
 
-	// Generate JWT token
-	token, err := uc.jwtService.GenerateToken(user)
+	// Generate the access and refresh tokens of a new session
+	tokens, refreshToken, err := uc.issueTokens(user, nil, client)
 	if err != nil {
-		uc.logger.Error("Failed to generate JWT token", "error", err, "user_id", user.ID)
-		return nil, errors.New("failed to generate token")
+		return nil, err
+	}
+	if err := uc.tokenRepo.CreateRefreshToken(ctx, refreshToken); err != nil {
+		uc.logger.Error("Failed to store refresh token", "error", err, "user_id", user.ID)
+		return nil, err
 	}
 
-	uc.logger.Info("User logged in successfully", "user_id", user.ID, "email", user.Email)
+	uc.logger.Info("User logged in successfully", "user_id", user.ID)
 
 	return &LoginResponse{
-		User:  uc.mapUserToResponse(user),
-		Token: token,
+		User:          uc.mapUserToResponse(user),
+		TokenResponse: *tokens,
 	}, nil
 }
 
@@ -116,6 +206,15 @@ func (uc *AuthUseCase) Register(ctx context.Context, req *RegisterRequest) (*Use
 		return nil, err
 	}
 
+	if req.StoreID != nil {
+		if _, err := uc.storeRepo.GetByID(ctx, *req.StoreID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, appErrors.ErrStoreNotFound
+			}
+			return nil, err
+		}
+	}
+
 	// Hash password
 	hashedPassword, err := uc.passwordService.HashPassword(req.Password)
 	if err != nil {
@@ -125,13 +224,14 @@ func (uc *AuthUseCase) Register(ctx context.Context, req *RegisterRequest) (*Use
 
 	// Create user
 	user := entities.NewUser(req.Email, req.Name, hashedPassword, req.Role)
+	user.StoreID = req.StoreID
 
 	if err := uc.userRepo.Create(ctx, user); err != nil {
 		uc.logger.Error("Failed to create user", "error", err)
 		return nil, err
 	}
 
-	uc.logger.Info("User registered successfully", "user_id", user.ID, "email", user.Email)
+	uc.logger.Info("User registered successfully", "user_id", user.ID)
 
 	return uc.mapUserToResponse(user), nil
 }
@@ -149,14 +249,205 @@ func (uc *AuthUseCase) GetCurrentUser(ctx context.Context, userID string) (*User
 	return uc.mapUserToResponse(user), nil
 }
 
-func (uc *AuthUseCase) RefreshToken(ctx context.Context, token string) (string, error) {
-	newToken, err := uc.jwtService.RefreshToken(token)
+// ListUsers lists the users of the current store, newest first
+func (uc *AuthUseCase) ListUsers(ctx context.Context, req *ListUsersRequest) ([]UserResponse, error) {
+	users, err := uc.userRepo.List(ctx, req.Limit, req.Offset, req.IncludeDeleted)
+	if err != nil {
+		uc.logger.Error("Failed to list users", "error", err)
+		return nil, err
+	}
+
+	responses := make([]UserResponse, len(users))
+	for i := range users {
+		responses[i] = *uc.mapUserToResponse(&users[i])
+	}
+	return responses, nil
+}
+
+// RestoreUser brings a deleted user back, refused while another user has its email. Sessions revoked
+// before the deletion stay revoked, the user signs in again.
+func (uc *AuthUseCase) RestoreUser(ctx context.Context, id string) (*UserResponse, error) {
+	user, err := uc.userRepo.GetDeletedByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := uc.userRepo.GetByEmail(ctx, user.Email); err == nil {
+		return nil, appErrors.ErrEmailExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := uc.userRepo.Restore(ctx, id); err != nil {
+		uc.logger.Error("Failed to restore user", "error", err, "user_id", id)
+		return nil, err
+	}
+
+	user.DeletedAt = gorm.DeletedAt{}
+	uc.logger.Info("User restored successfully", "user_id", id, "email", user.Email)
+	return uc.mapUserToResponse(user), nil
+}
+
+// RefreshToken trades a refresh token for a new access and refresh token. The refresh token is revoked,
+// so presenting it again means it leaked: every session of the user is then revoked.
+func (uc *AuthUseCase) RefreshToken(ctx context.Context, token string, client ClientInfo) (*TokenResponse, error) {
+	current, err := uc.tokenRepo.GetRefreshTokenByHash(ctx, entities.HashRefreshToken(token))
 	if err != nil {
-		uc.logger.Error("Failed to refresh token", "error", err)
-		return "", appErrors.ErrInvalidToken
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if current.IsRevoked() {
+		uc.logger.Warn("Revoked refresh token reused, revoking every session", "user_id", current.UserID, "token_id", current.ID)
+		if err := uc.tokenRepo.RevokeUserRefreshTokens(ctx, current.UserID); err != nil {
+			uc.logger.Error("Failed to revoke refresh tokens", "error", err, "user_id", current.UserID)
+		}
+		return nil, appErrors.ErrTokenRevoked
+	}
+	if current.IsExpired() {
+		return nil, appErrors.ErrTokenExpired
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !user.IsActive || !user.AcceptsTokenIssuedAt(current.CreatedAt) {
+		return nil, appErrors.ErrTokenRevoked
 	}
 
-	return newToken, nil
+	tokens, next, err := uc.issueTokens(user, current, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.tokenRepo.RotateRefreshToken(ctx, current, next); err != nil {
+		if !errors.Is(err, appErrors.ErrTokenRevoked) {
+			uc.logger.Error("Failed to rotate refresh token", "error", err, "user_id", user.ID)
+		}
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Logout revokes the access token of the request and, when given, the refresh token of the session
+func (uc *AuthUseCase) Logout(ctx context.Context, claims *auth.Claims, refreshToken string) error {
+	// Tokens issued before revocation was added carry no jti and simply run out
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := uc.tokenRepo.RevokeAccessToken(ctx, &entities.RevokedToken{
+			JTI:       claims.ID,
+			UserID:    claims.UserID,
+			ExpiresAt: claims.ExpiresAt.Time,
+		}); err != nil {
+			uc.logger.Error("Failed to revoke access token", "error", err, "user_id", claims.UserID)
+			return err
+		}
+	}
+
+	if refreshToken != "" {
+		if err := uc.tokenRepo.RevokeRefreshToken(ctx, claims.UserID, entities.HashRefreshToken(refreshToken)); err != nil {
+			uc.logger.Error("Failed to revoke refresh token", "error", err, "user_id", claims.UserID)
+			return err
+		}
+	}
+
+	uc.logger.Info("User logged out", "user_id", claims.UserID)
+	return nil
+}
+
+// ListSessions lists the devices the user is signed in on, most recently active first
+func (uc *AuthUseCase) ListSessions(ctx context.Context, claims *auth.Claims) ([]SessionResponse, error) {
+	tokens, err := uc.tokenRepo.ListActiveSessions(ctx, claims.UserID)
+	if err != nil {
+		uc.logger.Error("Failed to list sessions", "error", err, "user_id", claims.UserID)
+		return nil, err
+	}
+
+	sessions := make([]SessionResponse, len(tokens))
+	for i := range tokens {
+		token := &tokens[i]
+		sessions[i] = SessionResponse{
+			ID:           token.Session(),
+			UserAgent:    token.UserAgent,
+			IPAddress:    token.IPAddress,
+			LoggedInAt:   token.SessionStartedAt(),
+			LastActiveAt: token.LastActiveAt(),
+			ExpiresAt:    token.ExpiresAt,
+			Current:      token.Session() == claims.SessionID,
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of one of their sessions: its refresh token is revoked, and so are the
+// access tokens issued in it that haven't expired yet
+func (uc *AuthUseCase) RevokeSession(ctx context.Context, claims *auth.Claims, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return appErrors.ErrSessionNotFound
+	}
+
+	tokens, err := uc.tokenRepo.ListSessionTokens(ctx, claims.UserID, sessionID)
+	if err != nil {
+		return err
+	}
+	active := false
+	for i := range tokens {
+		if !tokens[i].IsRevoked() && !tokens[i].IsExpired() {
+			active = true
+		}
+	}
+	if !active {
+		return appErrors.ErrSessionNotFound
+	}
+
+	accessExpiry := uc.jwtService.Expiry()
+	for i := range tokens {
+		token := &tokens[i]
+		if !token.IsRevoked() {
+			if err := uc.tokenRepo.RevokeRefreshToken(ctx, claims.UserID, token.TokenHash); err != nil {
+				uc.logger.Error("Failed to revoke refresh token", "error", err, "user_id", claims.UserID)
+				return err
+			}
+		}
+
+		// The access token was issued along with the refresh token and lives as long as the JWT expiry
+		expiresAt := token.CreatedAt.Add(accessExpiry)
+		if token.AccessTokenID == "" || time.Now().After(expiresAt) {
+			continue
+		}
+		if err := uc.tokenRepo.RevokeAccessToken(ctx, &entities.RevokedToken{
+			JTI:       token.AccessTokenID,
+			UserID:    claims.UserID,
+			ExpiresAt: expiresAt,
+		}); err != nil {
+			uc.logger.Error("Failed to revoke access token", "error", err, "user_id", claims.UserID)
+			return err
+		}
+	}
+
+	uc.logger.Info("Session revoked", "user_id", claims.UserID, "session_id", sessionID)
+	return nil
+}
+
+// PurgeExpiredTokens deletes refresh tokens and revoked access tokens past their expiry. It is meant
+// to be called periodically by the scheduler.
+func (uc *AuthUseCase) PurgeExpiredTokens(ctx context.Context) error {
+	deleted, err := uc.tokenRepo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		uc.logger.Info("Expired tokens purged", "count", deleted)
+	}
+	return nil
 }
 
 func (uc *AuthUseCase) ChangePassword(ctx context.Context, userID string, oldPassword, newPassword string) error {
@@ -186,17 +477,185 @@ func (uc *AuthUseCase) ChangePassword(ctx context.Context, userID string, oldPas
 		return errors.New("failed to process password")
 	}
 
-	// Update password
+	// Update password and sign out every existing session
 	user.Password = hashedPassword
+	user.InvalidateTokens()
 	if err := uc.userRepo.Update(ctx, user); err != nil {
 		uc.logger.Error("Failed to update user password", "error", err)
 		return err
 	}
+	if err := uc.tokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		uc.logger.Error("Failed to revoke refresh tokens", "error", err, "user_id", userID)
+		return err
+	}
 
 	uc.logger.Info("Password changed successfully", "user_id", userID)
 	return nil
 }
 
+// ForgotPassword emails a reset code to a user. Unknown and inactive emails are answered the same way,
+// and a user is sent at most one code per cooldown, so the flow can't be used to probe for accounts or
+// flood an inbox.
+func (uc *AuthUseCase) ForgotPassword(ctx context.Context, email string) error {
+	if uc.mailer == nil {
+		return appErrors.ErrPasswordResetUnavailable
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			uc.logger.Warn("Password reset requested for non-existent email", "email", email)
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		uc.logger.Warn("Password reset requested for inactive user", "user_id", user.ID)
+		return nil
+	}
+
+	latest, err := uc.tokenRepo.GetLatestPasswordReset(ctx, user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	cooldown := time.Duration(uc.resetConfig.ResendCooldownSeconds) * time.Second
+	if latest != nil && time.Since(latest.CreatedAt) < cooldown {
+		uc.logger.Warn("Password reset requested again within the cooldown", "user_id", user.ID)
+		return nil
+	}
+
+	ttl := time.Duration(uc.resetConfig.CodeTTLMinutes) * time.Minute
+	reset, code, err := entities.NewPasswordReset(user.ID, ttl)
+	if err != nil {
+		uc.logger.Error("Failed to generate reset code", "error", err, "user_id", user.ID)
+		return errors.New("failed to generate reset code")
+	}
+	if err := uc.tokenRepo.CreatePasswordReset(ctx, reset); err != nil {
+		uc.logger.Error("Failed to store password reset", "error", err, "user_id", user.ID)
+		return err
+	}
+
+	if err := uc.mailer.Send(ctx, &notification.Message{
+		To:      user.Email,
+		Subject: "Password reset code",
+		Text: fmt.Sprintf("Hi %s,\n\nYour password reset code is %s. It expires in %d minutes.\n\n"+
+			"If you didn't ask to reset your password, ignore this email, your password stays the same.\n",
+			user.Name, code, uc.resetConfig.CodeTTLMinutes),
+	}); err != nil {
+		// Answered like any other request, an error here would tell the email is registered
+		uc.logger.Error("Failed to email reset code", "error", err, "user_id", user.ID)
+		return nil
+	}
+
+	uc.logger.Info("Password reset code sent", "user_id", user.ID, "reset_id", reset.ID)
+	return nil
+}
+
+// VerifyResetCode trades the emailed code for a reset token. Every failure looks the same to the client,
+// and the code can't be tried more than the configured number of times.
+func (uc *AuthUseCase) VerifyResetCode(ctx context.Context, req *VerifyResetCodeRequest) (*ResetTokenResponse, error) {
+	user, err := uc.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrInvalidResetCode
+		}
+		return nil, err
+	}
+
+	reset, err := uc.tokenRepo.GetLatestPasswordReset(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrInvalidResetCode
+		}
+		return nil, err
+	}
+	if !reset.IsUsable() || reset.VerifiedAt != nil {
+		return nil, appErrors.ErrInvalidResetCode
+	}
+
+	// The try is counted before the code is checked, parallel guesses share the same limit
+	if err := uc.tokenRepo.CountPasswordResetAttempt(ctx, reset.ID, uc.resetConfig.MaxAttempts); err != nil {
+		if !errors.Is(err, appErrors.ErrInvalidResetCode) {
+			uc.logger.Error("Failed to record reset code attempt", "error", err, "reset_id", reset.ID)
+		}
+		return nil, err
+	}
+	if !reset.CheckCode(req.Code) {
+		uc.logger.Warn("Invalid reset code attempt", "user_id", user.ID, "reset_id", reset.ID)
+		return nil, appErrors.ErrInvalidResetCode
+	}
+
+	ttl := time.Duration(uc.resetConfig.CodeTTLMinutes) * time.Minute
+	token, err := reset.Verify(ttl)
+	if err != nil {
+		uc.logger.Error("Failed to generate reset token", "error", err, "user_id", user.ID)
+		return nil, errors.New("failed to generate token")
+	}
+	if err := uc.tokenRepo.VerifyPasswordReset(ctx, reset); err != nil {
+		if !errors.Is(err, appErrors.ErrInvalidResetCode) {
+			uc.logger.Error("Failed to store reset token", "error", err, "reset_id", reset.ID)
+		}
+		return nil, err
+	}
+
+	return &ResetTokenResponse{ResetToken: token, ExpiresIn: int64(ttl.Seconds())}, nil
+}
+
+// ResetPassword sets a new password with a reset token and signs out every session of the user, as
+// changing the password does
+func (uc *AuthUseCase) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	reset, err := uc.tokenRepo.GetPasswordResetByTokenHash(ctx, entities.HashRefreshToken(req.ResetToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrInvalidResetCode
+		}
+		return err
+	}
+	if !reset.IsUsable() || reset.VerifiedAt == nil {
+		return appErrors.ErrInvalidResetCode
+	}
+
+	if err := uc.passwordService.ValidatePasswordStrength(req.NewPassword); err != nil {
+		return err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrInvalidResetCode
+		}
+		return err
+	}
+
+	hashedPassword, err := uc.passwordService.HashPassword(req.NewPassword)
+	if err != nil {
+		uc.logger.Error("Failed to hash new password", "error", err)
+		return errors.New("failed to process password")
+	}
+
+	// Spend the reset before the password changes, so the token can't be replayed or used twice at once
+	if err := uc.tokenRepo.SpendPasswordReset(ctx, reset.ID); err != nil {
+		if !errors.Is(err, appErrors.ErrInvalidResetCode) {
+			uc.logger.Error("Failed to spend password reset", "error", err, "reset_id", reset.ID)
+		}
+		return err
+	}
+
+	user.Password = hashedPassword
+	user.InvalidateTokens()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		uc.logger.Error("Failed to update user password", "error", err, "user_id", user.ID)
+		return err
+	}
+	if err := uc.tokenRepo.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+		uc.logger.Error("Failed to revoke refresh tokens", "error", err, "user_id", user.ID)
+		return err
+	}
+
+	uc.logger.Info("Password reset successfully", "user_id", user.ID)
+	return nil
+}
+
 func (uc *AuthUseCase) UpdateProfile(ctx context.Context, userID string, name string) (*UserResponse, error) {
 	user, err := uc.userRepo.GetByID(ctx, userID)
 	if err != nil {
@@ -216,12 +675,44 @@ func (uc *AuthUseCase) UpdateProfile(ctx context.Context, userID string, name st
 	return uc.mapUserToResponse(user), nil
 }
 
+// issueTokens creates the refresh token to store and signs an access token along with it. The refresh
+// token continues the session of the previous one, or starts a new session without it.
+func (uc *AuthUseCase) issueTokens(user *entities.User, previous *entities.RefreshToken, client ClientInfo) (*TokenResponse, *entities.RefreshToken, error) {
+	refreshToken, plain, err := entities.NewRefreshToken(user.ID, uc.refreshExpiry)
+	if err != nil {
+		uc.logger.Error("Failed to generate refresh token", "error", err, "user_id", user.ID)
+		return nil, nil, errors.New("failed to generate token")
+	}
+	if previous != nil {
+		refreshToken.ContinueSession(previous)
+	}
+	refreshToken.UsedFrom(client.UserAgent, client.IPAddress)
+
+	token, jti, err := uc.jwtService.GenerateToken(user, refreshToken.SessionID)
+	if err != nil {
+		uc.logger.Error("Failed to generate JWT token", "error", err, "user_id", user.ID)
+		return nil, nil, errors.New("failed to generate token")
+	}
+	refreshToken.AccessTokenID = jti
+
+	return &TokenResponse{
+		Token:        token,
+		RefreshToken: plain,
+		ExpiresIn:    int64(uc.jwtService.Expiry().Seconds()),
+	}, refreshToken, nil
+}
+
 func (uc *AuthUseCase) mapUserToResponse(user *entities.User) *UserResponse {
-	return &UserResponse{
+	response := &UserResponse{
 		ID:       user.ID,
 		Name:     user.Name,
 		Email:    user.Email,
 		Role:     user.Role,
+		StoreID:  user.StoreID,
 		IsActive: user.IsActive,
 	}
+	if user.DeletedAt.Valid {
+		response.DeletedAt = user.DeletedAt.Time.Format(time.RFC3339)
+	}
+	return response
 }
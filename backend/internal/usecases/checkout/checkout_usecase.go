@@ -0,0 +1,141 @@
+package checkout
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/usecases/payment"
+	"qris-pos-backend/internal/usecases/transaction"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+)
+
+type CheckoutRequest struct {
+	ID            string                           `json:"id" validate:"required,uuid"` // Generated by the register, sending the checkout again with it is safe
+	UserID        string                           `json:"-"`
+	Items         []transaction.TransactionItemReq `json:"items" validate:"required,min=1,dive"`
+	Notes         string                           `json:"notes"`
+	CustomerEmail string                           `json:"customer_email" validate:"omitempty,email"`
+	CustomerPhone string                           `json:"customer_phone" validate:"omitempty,phone_id"`
+	OrderType     entities.OrderType               `json:"order_type" validate:"omitempty,oneof=dine_in pickup delivery"`
+	CustomerID    *string                          `json:"customer_id" validate:"omitempty,uuid"`
+	PromoCode     string                           `json:"promo_code" validate:"omitempty,max=50"`
+	ExpiryMinutes int                              `json:"expiry_minutes" validate:"omitempty,gte=1,lte=1440"` // Lifetime of the QRIS, the payment default otherwise
+}
+
+type CheckoutResponse struct {
+	Transaction *transaction.TransactionResponse `json:"transaction"`
+	Payment     *payment.PaymentResponse         `json:"payment,omitempty"` // QRIS to show the customer, missing once the sale was paid another way
+	Replayed    bool                             `json:"replayed"`          // The checkout was already made by an earlier request with the same ID
+}
+
+// CheckoutUseCase rings up a cart in one request: it creates the transaction with its promotions, tax and
+// stock reservation, and issues the QRIS for it
+type CheckoutUseCase struct {
+	transactionUseCase *transaction.TransactionUseCase
+	paymentUseCase     *payment.PaymentUseCase
+	logger             logger.Logger
+}
+
+func NewCheckoutUseCase(
+	transactionUseCase *transaction.TransactionUseCase,
+	paymentUseCase *payment.PaymentUseCase,
+	logger logger.Logger,
+) *CheckoutUseCase {
+	return &CheckoutUseCase{
+		transactionUseCase: transactionUseCase,
+		paymentUseCase:     paymentUseCase,
+		logger:             logger,
+	}
+}
+
+// Checkout creates the transaction of the cart under the ID the register generated and issues its QRIS.
+// A checkout sent again with the same ID returns the transaction of the first one, whatever its cart, and
+// picks up the steps left undone when the first request failed part way, so a register can retry freely.
+func (uc *CheckoutUseCase) Checkout(ctx context.Context, req *CheckoutRequest) (*CheckoutResponse, error) {
+	result := &CheckoutResponse{}
+
+	existing, err := uc.transactionUseCase.GetTransaction(ctx, req.ID)
+	switch {
+	case err == nil:
+		result.Transaction = existing
+		result.Replayed = true
+	case errors.Is(err, appErrors.ErrTransactionNotFound):
+		result.Transaction, result.Replayed, err = uc.create(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if result.Transaction.Status != entities.StatusPending {
+		// Paid or closed since, hand back the QRIS it was checked out with if any
+		qris, err := uc.paymentUseCase.GetQRIS(ctx, req.ID)
+		if err != nil && !errors.Is(err, appErrors.ErrPaymentNotFound) {
+			return nil, err
+		}
+		result.Payment = qris
+		return result, nil
+	}
+
+	if req.PromoCode != "" && !hasPromoCode(result.Transaction, req.PromoCode) {
+		withPromo, err := uc.transactionUseCase.ApplyPromoCode(ctx, req.ID, &transaction.ApplyPromoCodeRequest{Code: req.PromoCode})
+		if err != nil {
+			return nil, err
+		}
+		result.Transaction = withPromo
+	}
+
+	qris, err := uc.paymentUseCase.GenerateQRIS(ctx, &payment.GenerateQRISRequest{
+		TransactionID: req.ID,
+		Amount:        result.Transaction.TotalAmount,
+		ExpiryMinutes: req.ExpiryMinutes,
+	})
+	if err != nil {
+		// The QRIS of an earlier attempt can still be paid, show that one
+		var duplicateErr *appErrors.DuplicatePaymentError
+		if !errors.As(err, &duplicateErr) {
+			return nil, err
+		}
+		existingQRIS, ok := duplicateErr.Payment.(*payment.PaymentResponse)
+		if !ok {
+			return nil, err
+		}
+		qris = existingQRIS
+	}
+	result.Payment = qris
+
+	uc.logger.Info("Checkout completed", "transaction_id", req.ID, "user_id", req.UserID, "total_amount", result.Transaction.TotalAmount, "replayed", result.Replayed)
+	return result, nil
+}
+
+// create makes the transaction of a checkout. When a concurrent request with the same ID won the race,
+// its transaction is returned instead and reported as replayed.
+func (uc *CheckoutUseCase) create(ctx context.Context, req *CheckoutRequest) (*transaction.TransactionResponse, bool, error) {
+	created, err := uc.transactionUseCase.CreateTransaction(ctx, &transaction.CreateTransactionRequest{
+		ID:            req.ID,
+		UserID:        req.UserID,
+		Items:         req.Items,
+		Notes:         req.Notes,
+		CustomerEmail: req.CustomerEmail,
+		CustomerPhone: req.CustomerPhone,
+		OrderType:     req.OrderType,
+		CustomerID:    req.CustomerID,
+	})
+	if err == nil {
+		return created, false, nil
+	}
+
+	existing, getErr := uc.transactionUseCase.GetTransaction(ctx, req.ID)
+	if getErr != nil {
+		return nil, false, err
+	}
+	return existing, true, nil
+}
+
+func hasPromoCode(t *transaction.TransactionResponse, code string) bool {
+	return t.PromoCode != nil && strings.EqualFold(*t.PromoCode, strings.TrimSpace(code))
+}
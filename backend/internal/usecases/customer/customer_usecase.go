@@ -0,0 +1,255 @@
+package customer
+
+import (
+	"context"
+	"errors"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type CustomerRequest struct {
+	Name  string  `json:"name" validate:"required,min=1,max=255"`
+	Phone *string `json:"phone" validate:"omitempty,phone_id"` // e.g. +6281234567890 or 0812-3456-7890, stored as +62
+	Email *string `json:"email" validate:"omitempty,email,max=255"`
+	Notes string  `json:"notes"`
+}
+
+type CustomerFilters struct {
+	Search string `form:"search" validate:"omitempty,max=255"` // Matches name, phone or email
+	Limit  int    `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset int    `form:"offset,default=0" validate:"gte=0"`
+}
+
+// LookupRequest finds a member at checkout by exactly one of their contacts
+type LookupRequest struct {
+	Phone string `form:"phone" validate:"required_without=Email,omitempty,phone_id"`
+	Email string `form:"email" validate:"required_without=Phone,omitempty,email"`
+}
+
+type PointsFilters struct {
+	Limit  int `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset int `form:"offset,default=0" validate:"gte=0"`
+}
+
+// AdjustPointsRequest corrects a balance by hand, positive to give points and negative to take them away
+type AdjustPointsRequest struct {
+	Points int64  `json:"points" validate:"required"`
+	Note   string `json:"note" validate:"required,max=255"` // Why the balance was changed
+}
+
+type PointsResponse struct {
+	CustomerID string                 `json:"customer_id"`
+	Balance    int64                  `json:"balance"`
+	Held       int64                  `json:"held"`      // Redeemed on transactions that aren't paid yet
+	Available  int64                  `json:"available"` // What can still be redeemed
+	PointValue int64                  `json:"point_value"`
+	Entries    []entities.PointsEntry `json:"entries"`
+}
+
+type CustomerUseCase struct {
+	customerRepo  repositories.CustomerRepository
+	loyaltyConfig config.LoyaltyConfig
+	logger        logger.Logger
+}
+
+func NewCustomerUseCase(
+	customerRepo repositories.CustomerRepository,
+	loyaltyConfig config.LoyaltyConfig,
+	logger logger.Logger,
+) *CustomerUseCase {
+	return &CustomerUseCase{
+		customerRepo:  customerRepo,
+		loyaltyConfig: loyaltyConfig,
+		logger:        logger,
+	}
+}
+
+func (uc *CustomerUseCase) CreateCustomer(ctx context.Context, req *CustomerRequest) (*entities.Customer, error) {
+	customer := &entities.Customer{}
+	applyCustomerRequest(customer, req)
+
+	if err := customer.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureContactsAvailable(ctx, customer); err != nil {
+		return nil, err
+	}
+
+	if err := uc.customerRepo.Create(ctx, customer); err != nil {
+		uc.logger.Error("Failed to create customer", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Customer created successfully", "customer_id", customer.ID)
+	return customer, nil
+}
+
+func (uc *CustomerUseCase) GetCustomer(ctx context.Context, id string) (*entities.Customer, error) {
+	customer, err := uc.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+	return customer, nil
+}
+
+// LookupCustomer finds a member by phone number or email, the phone number wins when both are given
+func (uc *CustomerUseCase) LookupCustomer(ctx context.Context, req *LookupRequest) (*entities.Customer, error) {
+	var (
+		customer *entities.Customer
+		err      error
+	)
+	if req.Phone != "" {
+		customer, err = uc.customerRepo.GetByPhone(ctx, entities.NormalizeCustomerPhone(req.Phone))
+	} else {
+		customer, err = uc.customerRepo.GetByEmail(ctx, entities.NormalizeCustomerEmail(req.Email))
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (uc *CustomerUseCase) UpdateCustomer(ctx context.Context, id string, req *CustomerRequest) (*entities.Customer, error) {
+	customer, err := uc.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyCustomerRequest(customer, req)
+
+	if err := customer.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureContactsAvailable(ctx, customer); err != nil {
+		return nil, err
+	}
+
+	if err := uc.customerRepo.Update(ctx, customer); err != nil {
+		uc.logger.Error("Failed to update customer", "error", err, "customer_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Customer updated successfully", "customer_id", id)
+	return customer, nil
+}
+
+// DeleteCustomer removes a member. Their past transactions keep the link for reporting.
+func (uc *CustomerUseCase) DeleteCustomer(ctx context.Context, id string) error {
+	if _, err := uc.GetCustomer(ctx, id); err != nil {
+		return err
+	}
+
+	if err := uc.customerRepo.Delete(ctx, id); err != nil {
+		uc.logger.Error("Failed to delete customer", "error", err, "customer_id", id)
+		return err
+	}
+
+	uc.logger.Info("Customer deleted successfully", "customer_id", id)
+	return nil
+}
+
+func (uc *CustomerUseCase) ListCustomers(ctx context.Context, filters *CustomerFilters) ([]entities.Customer, error) {
+	customers, err := uc.customerRepo.List(ctx, repositories.CustomerFilters{
+		Search: filters.Search,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to list customers", "error", err)
+		return nil, err
+	}
+	return customers, nil
+}
+
+// GetPoints returns the balance of a customer with its history, newest first
+func (uc *CustomerUseCase) GetPoints(ctx context.Context, id string, filters *PointsFilters) (*PointsResponse, error) {
+	customer, err := uc.GetCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := uc.customerRepo.HeldPoints(ctx, customer.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := uc.customerRepo.ListPointsEntries(ctx, customer.ID, filters.Limit, filters.Offset)
+	if err != nil {
+		uc.logger.Error("Failed to list points entries", "error", err, "customer_id", id)
+		return nil, err
+	}
+
+	return &PointsResponse{
+		CustomerID: customer.ID,
+		Balance:    customer.Points,
+		Held:       held,
+		Available:  max(customer.Points-held, 0),
+		PointValue: uc.loyaltyConfig.PointValue,
+		Entries:    entries,
+	}, nil
+}
+
+// AdjustPoints changes a balance by hand, such as for points a customer forgot to collect.
+// A balance can't be taken below zero.
+func (uc *CustomerUseCase) AdjustPoints(ctx context.Context, id, userID string, req *AdjustPointsRequest) (*PointsResponse, error) {
+	if _, err := uc.GetCustomer(ctx, id); err != nil {
+		return nil, err
+	}
+
+	entry := &entities.PointsEntry{
+		CustomerID: id,
+		Type:       entities.PointsAdjusted,
+		Points:     req.Points,
+		Note:       req.Note,
+		CreatedBy:  &userID,
+	}
+	if err := uc.customerRepo.AddPointsEntry(ctx, entry); err != nil {
+		uc.logger.Error("Failed to adjust points", "error", err, "customer_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Points adjusted", "customer_id", id, "points", req.Points, "balance", entry.Balance, "user_id", userID)
+	return uc.GetPoints(ctx, id, &PointsFilters{Limit: 20})
+}
+
+// ensureContactsAvailable rejects a phone number or email already used by another customer
+func (uc *CustomerUseCase) ensureContactsAvailable(ctx context.Context, customer *entities.Customer) error {
+	if customer.Phone != nil {
+		existing, err := uc.customerRepo.GetByPhone(ctx, *customer.Phone)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if existing != nil && existing.ID != customer.ID {
+			return appErrors.ErrCustomerExists
+		}
+	}
+	if customer.Email != nil {
+		existing, err := uc.customerRepo.GetByEmail(ctx, *customer.Email)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if existing != nil && existing.ID != customer.ID {
+			return appErrors.ErrCustomerExists
+		}
+	}
+	return nil
+}
+
+func applyCustomerRequest(customer *entities.Customer, req *CustomerRequest) {
+	customer.Name = req.Name
+	customer.Phone = req.Phone
+	customer.Email = req.Email
+	customer.Notes = req.Notes
+}
@@ -0,0 +1,132 @@
+package expiry
+
+import (
+	"context"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/payment"
+	"qris-pos-backend/pkg/logger"
+)
+
+// batchSize caps how many records a single run touches
+const batchSize = 100
+
+type ExpiryUseCase struct {
+	paymentRepo     repositories.PaymentRepository
+	transactionRepo repositories.TransactionRepository
+	gateways        *payment.Gateways
+	config          config.JobsConfig
+	logger          logger.Logger
+}
+
+func NewExpiryUseCase(
+	paymentRepo repositories.PaymentRepository,
+	transactionRepo repositories.TransactionRepository,
+	gateways *payment.Gateways,
+	cfg config.JobsConfig,
+	logger logger.Logger,
+) *ExpiryUseCase {
+	return &ExpiryUseCase{
+		paymentRepo:     paymentRepo,
+		transactionRepo: transactionRepo,
+		gateways:        gateways,
+		config:          cfg,
+		logger:          logger,
+	}
+}
+
+// Run expires stale payments and transactions. It is meant to be called periodically by the scheduler.
+func (uc *ExpiryUseCase) Run(ctx context.Context) error {
+	expiredPayments, err := uc.ExpireStalePayments(ctx)
+	if err != nil {
+		return err
+	}
+
+	expiredTransactions, err := uc.ExpireStaleTransactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if expiredPayments > 0 || expiredTransactions > 0 {
+		uc.logger.Info("Expired stale records",
+			"payments", expiredPayments,
+			"transactions", expiredTransactions)
+	}
+	return nil
+}
+
+// ExpireStalePayments marks pending payments past their expiry as expired.
+// The transaction stays pending so the cashier can still refresh the QRIS.
+func (uc *ExpiryUseCase) ExpireStalePayments(ctx context.Context) (int, error) {
+	payments, err := uc.paymentRepo.ListExpiredPending(ctx, time.Now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range payments {
+		paymentEntity := &payments[i]
+		err := paymentEntity.MarkAsExpired(entities.SystemActor("expiry"))
+		if err == nil {
+			err = uc.paymentRepo.UpdatePayment(ctx, paymentEntity)
+		}
+		if err != nil {
+			uc.logger.Error("Failed to expire payment", "error", err, "payment_id", paymentEntity.ID)
+			continue
+		}
+		expired++
+
+		if uc.config.CancelExpiredOnGateway && paymentEntity.HasGatewayOrder() {
+			gateway, err := uc.gateways.For(ctx, paymentEntity)
+			if err == nil {
+				err = gateway.CancelTransaction(ctx, paymentEntity.OrderID)
+			}
+			if err != nil {
+				uc.logger.Warn("Failed to cancel expired order on the gateway", "error", err, "provider", paymentEntity.Provider, "order_id", paymentEntity.OrderID)
+			}
+		}
+	}
+
+	return expired, nil
+}
+
+// ExpireStaleTransactions expires pending transactions older than the configured TTL
+// that have no active payment, and releases their reserved stock.
+func (uc *ExpiryUseCase) ExpireStaleTransactions(ctx context.Context) (int, error) {
+	if uc.config.TransactionTTLMinutes <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-time.Duration(uc.config.TransactionTTLMinutes) * time.Minute)
+	transactions, err := uc.transactionRepo.ListStalePending(ctx, cutoff, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range transactions {
+		transaction := &transactions[i]
+		if err := uc.expireTransaction(ctx, transaction); err != nil {
+			uc.logger.Error("Failed to expire transaction", "error", err, "transaction_id", transaction.ID)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (uc *ExpiryUseCase) expireTransaction(ctx context.Context, transaction *entities.Transaction) error {
+	if err := transaction.MarkAsExpired(); err != nil {
+		return err
+	}
+
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		return err
+	}
+
+	return uc.transactionRepo.ReleaseReservedStock(ctx, transaction.ID)
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+)
+
+// pageSize is how many rows are read from the database at a time while writing a file
+const pageSize = 500
+
+var (
+	productHeader     = []string{"id", "store_id", "sku", "barcode", "name", "category", "price", "cost_price", "stock", "unit", "tax_class", "is_active", "created_at"}
+	transactionHeader = []string{"id", "store_id", "created_at", "cashier", "status", "is_training", "order_type", "total_amount", "tax_amount", "discount", "points_discount", "refunded_amount", "customer_id", "payment_method", "payment_status", "paid_at"}
+	itemHeader        = []string{"transaction_id", "sequence", "product_id", "sku", "product_name", "quantity", "unit", "unit_price", "total_price"}
+	customerHeader    = []string{"id", "name", "phone", "email", "points", "created_at"}
+)
+
+// archive is a zip of CSV files built in memory
+type archive struct {
+	buf     bytes.Buffer
+	zip     *zip.Writer
+	current *csv.Writer
+}
+
+func newArchive() *archive {
+	a := &archive{}
+	a.zip = zip.NewWriter(&a.buf)
+	return a
+}
+
+// Create starts a new CSV file in the archive. The file written before it is complete once Create
+// or Close is called.
+func (a *archive) Create(name string, header []string) (*csv.Writer, error) {
+	if err := a.flush(); err != nil {
+		return nil, err
+	}
+	f, err := a.zip.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	a.current = csv.NewWriter(f)
+	return a.current, a.current.Write(header)
+}
+
+// Close finishes the archive and returns its content
+func (a *archive) Close() ([]byte, error) {
+	if err := a.flush(); err != nil {
+		return nil, err
+	}
+	if err := a.zip.Close(); err != nil {
+		return nil, err
+	}
+	return a.buf.Bytes(), nil
+}
+
+func (a *archive) flush() error {
+	if a.current == nil {
+		return nil
+	}
+	a.current.Flush()
+	return a.current.Error()
+}
+
+func productRow(p *entities.Product) []string {
+	return []string{
+		p.ID,
+		p.StoreID,
+		p.SKU,
+		p.Barcode,
+		p.Name,
+		p.Category.Name,
+		strconv.FormatInt(p.Price, 10),
+		strconv.FormatInt(p.CostPrice, 10),
+		formatQuantity(p.Stock),
+		string(p.Unit),
+		string(p.TaxClass),
+		strconv.FormatBool(p.IsActive),
+		formatTime(&p.CreatedAt),
+	}
+}
+
+func transactionRow(t *entities.Transaction) []string {
+	var method, status string
+	var paidAt *time.Time
+	if t.Payment != nil {
+		method = string(t.Payment.Method)
+		status = string(t.Payment.Status)
+		paidAt = t.Payment.PaidAt
+	}
+	return []string{
+		t.ID,
+		t.StoreID,
+		formatTime(&t.CreatedAt),
+		t.User.Name,
+		string(t.Status),
+		strconv.FormatBool(t.IsTraining),
+		string(t.OrderType),
+		strconv.FormatInt(t.TotalAmount, 10),
+		strconv.FormatInt(t.TaxAmount, 10),
+		strconv.FormatInt(t.Discount, 10),
+		strconv.FormatInt(t.PointsDiscount, 10),
+		strconv.FormatInt(t.RefundedAmount, 10),
+		stringValue(t.CustomerID),
+		method,
+		status,
+		formatTime(paidAt),
+	}
+}
+
+func itemRow(item *entities.TransactionItem) []string {
+	return []string{
+		item.TransactionID,
+		strconv.Itoa(item.Sequence),
+		item.ProductID,
+		item.Product.SKU,
+		item.Product.Name,
+		formatQuantity(item.Quantity),
+		string(item.Unit),
+		strconv.FormatInt(item.UnitPrice, 10),
+		strconv.FormatInt(item.TotalPrice, 10),
+	}
+}
+
+func customerRow(c *entities.Customer) []string {
+	return []string{
+		c.ID,
+		c.Name,
+		stringValue(c.Phone),
+		stringValue(c.Email),
+		strconv.FormatInt(c.Points, 10),
+		formatTime(&c.CreatedAt),
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,338 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/notification"
+	"qris-pos-backend/internal/infrastructure/storage"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// batchSize caps how many exports a single run builds, each one reads the whole catalog
+const batchSize = 2
+
+type CreateExportRequest struct {
+	DateFrom string `json:"date_from" validate:"required,datetime=2006-01-02"`
+	DateTo   string `json:"date_to" validate:"required,datetime=2006-01-02"` // Inclusive
+	Email    string `json:"email" validate:"omitempty,email,max=255"`        // Defaults to the email of the admin asking
+}
+
+type ExportFilters struct {
+	Limit  int `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset int `form:"offset,default=0" validate:"gte=0"`
+}
+
+type ExportResponse struct {
+	entities.Export
+	DownloadURL       string     `json:"download_url,omitempty"` // Signed link, only once the export is ready
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+}
+
+type ExportUseCase struct {
+	exportRepo      repositories.ExportRepository
+	userRepo        repositories.UserRepository
+	productRepo     repositories.ProductRepository
+	transactionRepo repositories.TransactionRepository
+	customerRepo    repositories.CustomerRepository
+	storageClient   storage.Storage
+	emailSender     notification.Sender
+	config          config.ExportConfig
+	logger          logger.Logger
+}
+
+func NewExportUseCase(
+	exportRepo repositories.ExportRepository,
+	userRepo repositories.UserRepository,
+	productRepo repositories.ProductRepository,
+	transactionRepo repositories.TransactionRepository,
+	customerRepo repositories.CustomerRepository,
+	storageClient storage.Storage,
+	senders []notification.Sender,
+	cfg config.ExportConfig,
+	logger logger.Logger,
+) *ExportUseCase {
+	var emailSender notification.Sender
+	for _, sender := range senders {
+		if sender.Channel() == entities.ReceiptChannelEmail {
+			emailSender = sender
+		}
+	}
+
+	return &ExportUseCase{
+		exportRepo:      exportRepo,
+		userRepo:        userRepo,
+		productRepo:     productRepo,
+		transactionRepo: transactionRepo,
+		customerRepo:    customerRepo,
+		storageClient:   storageClient,
+		emailSender:     emailSender,
+		config:          cfg,
+		logger:          logger,
+	}
+}
+
+// CreateExport queues an export of the store the admin works in, or of every store when none is picked.
+// The archive is built in the background and its link emailed once ready.
+func (uc *ExportUseCase) CreateExport(ctx context.Context, userID string, req *CreateExportRequest) (*ExportResponse, error) {
+	dateFrom, err := time.ParseInLocation(dateLayout, req.DateFrom, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date_from", appErrors.ErrInvalidInput)
+	}
+	dateTo, err := time.ParseInLocation(dateLayout, req.DateTo, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date_to", appErrors.ErrInvalidInput)
+	}
+	if dateTo.Before(dateFrom) {
+		return nil, fmt.Errorf("%w: date_to must not be before date_from", appErrors.ErrInvalidInput)
+	}
+	if uc.config.MaxRangeDays > 0 && dateTo.Sub(dateFrom) >= time.Duration(uc.config.MaxRangeDays)*24*time.Hour {
+		return nil, fmt.Errorf("%w: date range must not exceed %d days", appErrors.ErrInvalidInput, uc.config.MaxRangeDays)
+	}
+
+	email := req.Email
+	if email == "" {
+		user, err := uc.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, appErrors.ErrUserNotFound
+			}
+			return nil, err
+		}
+		email = user.Email
+	}
+
+	var storeID *string
+	if id, ok := repositories.StoreFromContext(ctx); ok {
+		storeID = &id
+	}
+
+	export := entities.NewExport(storeID, userID, email, dateFrom, dateTo.AddDate(0, 0, 1))
+	if err := uc.exportRepo.Create(ctx, export); err != nil {
+		uc.logger.Error("Failed to queue export", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	uc.logger.Info("Export queued", "export_id", export.ID, "user_id", userID, "date_from", req.DateFrom, "date_to", req.DateTo)
+	return &ExportResponse{Export: *export}, nil
+}
+
+// GetExport returns an export with a fresh download link when it is ready
+func (uc *ExportUseCase) GetExport(ctx context.Context, id string) (*ExportResponse, error) {
+	export, err := uc.exportRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	response := &ExportResponse{Export: *export}
+	if export.Status == entities.ExportReady {
+		url, expiresAt, err := uc.downloadURL(ctx, export)
+		if err != nil {
+			uc.logger.Error("Failed to sign export download link", "error", err, "export_id", id)
+			return nil, err
+		}
+		response.DownloadURL = url
+		response.DownloadExpiresAt = &expiresAt
+	}
+	return response, nil
+}
+
+// ListExports lists the exports, newest first. Links are only signed when a single export is fetched.
+func (uc *ExportUseCase) ListExports(ctx context.Context, filters *ExportFilters) ([]entities.Export, error) {
+	exports, err := uc.exportRepo.List(ctx, filters.Limit, filters.Offset)
+	if err != nil {
+		uc.logger.Error("Failed to list exports", "error", err)
+		return nil, err
+	}
+	return exports, nil
+}
+
+// Run builds the queued exports. It is meant to be called periodically by the scheduler.
+func (uc *ExportUseCase) Run(ctx context.Context) error {
+	exports, err := uc.exportRepo.ListPending(ctx, batchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range exports {
+		export := &exports[i]
+		if err := uc.exportRepo.Claim(ctx, export); err != nil {
+			if !errors.Is(err, appErrors.ErrExportAlreadyClaimed) {
+				uc.logger.Error("Failed to claim export", "error", err, "export_id", export.ID)
+			}
+			continue
+		}
+
+		if err := uc.build(ctx, export); err != nil {
+			export.MarkAsFailed(err)
+			uc.logger.Error("Failed to build export", "error", err, "export_id", export.ID)
+		}
+		if err := uc.exportRepo.Update(ctx, export); err != nil {
+			uc.logger.Error("Failed to update export", "error", err, "export_id", export.ID)
+			continue
+		}
+
+		uc.notify(ctx, export)
+	}
+	return nil
+}
+
+// build writes the archive of an export and stores it in the export bucket
+func (uc *ExportUseCase) build(ctx context.Context, export *entities.Export) error {
+	// Reads see the same tenant and store the export was asked for
+	ctx = repositories.WithTenantOf(ctx, export.TenantID)
+	if export.StoreID != nil {
+		ctx = repositories.WithStore(ctx, *export.StoreID)
+	}
+
+	archive := newArchive()
+	if err := uc.writeProducts(ctx, archive); err != nil {
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+	if err := uc.writeTransactions(ctx, archive, export); err != nil {
+		return fmt.Errorf("failed to export transactions: %w", err)
+	}
+	if err := uc.writeCustomers(ctx, archive); err != nil {
+		return fmt.Errorf("failed to export customers: %w", err)
+	}
+
+	content, err := archive.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	objectPath := fmt.Sprintf("exports/%s/export-%s-%s.zip",
+		export.ID,
+		export.DateFrom.Format(dateLayout),
+		export.DateTo.AddDate(0, 0, -1).Format(dateLayout))
+	if err := uc.storageClient.UploadFile(ctx, uc.config.Bucket, objectPath, "application/zip", content); err != nil {
+		return err
+	}
+
+	export.MarkAsReady(objectPath, int64(len(content)))
+	uc.logger.Info("Export ready", "export_id", export.ID, "bytes", len(content))
+	return nil
+}
+
+func (uc *ExportUseCase) writeProducts(ctx context.Context, archive *archive) error {
+	w, err := archive.Create("products.csv", productHeader)
+	if err != nil {
+		return err
+	}
+	for offset := 0; ; offset += pageSize {
+		products, err := uc.productRepo.List(ctx, repositories.ProductFilters{Limit: pageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for i := range products {
+			if err := w.Write(productRow(&products[i])); err != nil {
+				return err
+			}
+		}
+		if len(products) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (uc *ExportUseCase) writeTransactions(ctx context.Context, archive *archive, export *entities.Export) error {
+	transactions, err := archive.Create("transactions.csv", transactionHeader)
+	if err != nil {
+		return err
+	}
+	items, err := archive.Create("transaction_items.csv", itemHeader)
+	if err != nil {
+		return err
+	}
+	for offset := 0; ; offset += pageSize {
+		page, err := uc.transactionRepo.ListCreatedBetween(ctx, export.DateFrom, export.DateTo, pageSize, offset)
+		if err != nil {
+			return err
+		}
+		for i := range page {
+			transaction := &page[i]
+			if err := transactions.Write(transactionRow(transaction)); err != nil {
+				return err
+			}
+			for j := range transaction.Items {
+				if err := items.Write(itemRow(&transaction.Items[j])); err != nil {
+					return err
+				}
+			}
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (uc *ExportUseCase) writeCustomers(ctx context.Context, archive *archive) error {
+	w, err := archive.Create("customers.csv", customerHeader)
+	if err != nil {
+		return err
+	}
+	for offset := 0; ; offset += pageSize {
+		customers, err := uc.customerRepo.List(ctx, repositories.CustomerFilters{Limit: pageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for i := range customers {
+			if err := w.Write(customerRow(&customers[i])); err != nil {
+				return err
+			}
+		}
+		if len(customers) < pageSize {
+			return nil
+		}
+	}
+}
+
+func (uc *ExportUseCase) downloadURL(ctx context.Context, export *entities.Export) (string, time.Time, error) {
+	expiry := time.Duration(uc.config.LinkExpiryHours) * time.Hour
+	url, err := uc.storageClient.SignedURL(ctx, uc.config.Bucket, export.ObjectPath, expiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return url, time.Now().Add(expiry), nil
+}
+
+// notify emails the requester the download link, or why the export failed. A missed email is only
+// logged, the export can still be downloaded from the API.
+func (uc *ExportUseCase) notify(ctx context.Context, export *entities.Export) {
+	if uc.emailSender == nil {
+		return
+	}
+
+	msg := &notification.Message{To: export.NotifyEmail}
+	period := fmt.Sprintf("%s to %s", export.DateFrom.Format(dateLayout), export.DateTo.AddDate(0, 0, -1).Format(dateLayout))
+	if export.Status == entities.ExportReady {
+		url, expiresAt, err := uc.downloadURL(ctx, export)
+		if err != nil {
+			uc.logger.Error("Failed to sign export download link", "error", err, "export_id", export.ID)
+			return
+		}
+		msg.Subject = "Your data export is ready"
+		msg.Text = fmt.Sprintf("Your export of products, customers and the transactions from %s is ready.\n\nDownload it before %s:\n%s\n",
+			period, expiresAt.Format("2006-01-02 15:04"), url)
+	} else {
+		msg.Subject = "Your data export failed"
+		msg.Text = fmt.Sprintf("Your export of the transactions from %s could not be built: %s\n\nPlease request it again.\n", period, export.Error)
+	}
+
+	if err := uc.emailSender.Send(ctx, msg); err != nil {
+		uc.logger.Warn("Failed to email export notification", "error", err, "export_id", export.ID)
+	}
+}
@@ -0,0 +1,33 @@
+package inventory
+
+import (
+	"context"
+)
+
+// costingBatchSize caps how many transactions a single costing run records
+const costingBatchSize = 100
+
+// CostSales records the cost of goods of settled transactions not costed yet and deducts the
+// ingredients of the recipes they sold from stock. It is meant to be called periodically by the
+// scheduler; a transaction that fails is retried on the next run.
+func (uc *InventoryUseCase) CostSales(ctx context.Context) error {
+	ids, err := uc.transactionRepo.ListUncosted(ctx, costingBatchSize)
+	if err != nil {
+		uc.logger.Error("Failed to list uncosted transactions", "error", err)
+		return err
+	}
+
+	costed := 0
+	for _, id := range ids {
+		if err := uc.transactionRepo.RecordCostOfGoods(ctx, id); err != nil {
+			uc.logger.Error("Failed to record cost of goods", "error", err, "transaction_id", id)
+			continue
+		}
+		costed++
+	}
+
+	if costed > 0 {
+		uc.logger.Info("Sales costed", "transactions", costed)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package inventory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/domain/services"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/pkg/logger"
+)
+
+const dateLayout = "2006-01-02"
+
+type ForecastRequest struct {
+	Days        int    `form:"days,default=14" validate:"gte=1,lte=90"`
+	Method      string `form:"method,default=ses" validate:"oneof=sma ses"`
+	CategoryID  string `form:"category_id" validate:"omitempty,uuid"`
+	ReorderOnly bool   `form:"reorder_only"` // Only products that need reordering
+}
+
+// ProductForecast is the expected demand of a product and the reorder it calls for
+type ProductForecast struct {
+	ProductID        string                 `json:"product_id"`
+	ProductName      string                 `json:"product_name"`
+	CategoryName     string                 `json:"category_name"`
+	Stock            float64                `json:"stock"`
+	Unit             entities.UnitOfMeasure `json:"unit"`
+	DailyRate        float64                `json:"daily_rate"`        // Expected units sold per day
+	ForecastQuantity int                    `json:"forecast_quantity"` // Expected units sold over the forecast horizon
+	DaysOfCover      *float64               `json:"days_of_cover"`     // Days until stock runs out, null without demand
+	ReorderQuantity  int                    `json:"reorder_quantity"`  // Units to order to cover the horizon plus lead time
+}
+
+type ForecastResponse struct {
+	Days         int               `json:"days"`
+	Method       string            `json:"method"`
+	HistoryFrom  string            `json:"history_from"`
+	HistoryTo    string            `json:"history_to"` // Inclusive
+	LeadTimeDays int               `json:"lead_time_days"`
+	Products     []ProductForecast `json:"products"`
+}
+
+type InventoryUseCase struct {
+	productRepo     repositories.ProductRepository
+	reportRepo      repositories.ReportRepository
+	transactionRepo repositories.TransactionRepository
+	forecaster      *services.DemandForecaster
+	config          config.InventoryConfig
+	logger          logger.Logger
+}
+
+func NewInventoryUseCase(
+	productRepo repositories.ProductRepository,
+	reportRepo repositories.ReportRepository,
+	transactionRepo repositories.TransactionRepository,
+	cfg config.InventoryConfig,
+	logger logger.Logger,
+) *InventoryUseCase {
+	return &InventoryUseCase{
+		productRepo:     productRepo,
+		reportRepo:      reportRepo,
+		transactionRepo: transactionRepo,
+		forecaster:      services.NewDemandForecaster(cfg.MovingAverageDays, cfg.SmoothingAlpha),
+		config:          cfg,
+		logger:          logger,
+	}
+}
+
+// GetForecast forecasts the demand of every active product from its daily net sales over the
+// configured history, up to yesterday, and suggests how much to reorder. Products are sorted
+// by how soon they run out.
+func (uc *InventoryUseCase) GetForecast(ctx context.Context, req *ForecastRequest) (*ForecastResponse, error) {
+	historyDays := max(uc.config.ForecastHistoryDays, 1)
+	now := time.Now()
+	historyTo := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	historyFrom := historyTo.AddDate(0, 0, -historyDays)
+
+	isActive := true
+	products, err := uc.productRepo.List(ctx, repositories.ProductFilters{
+		CategoryID: req.CategoryID,
+		IsActive:   &isActive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sales, err := uc.reportRepo.DailySalesByProduct(ctx, repositories.SalesReportFilters{
+		DateFrom:   historyFrom,
+		DateTo:     historyTo,
+		CategoryID: req.CategoryID,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to roll up daily product sales", "error", err)
+		return nil, err
+	}
+
+	sold := make(map[string]map[string]float64, len(products))
+	for _, row := range sales {
+		if sold[row.ProductID] == nil {
+			sold[row.ProductID] = make(map[string]float64)
+		}
+		sold[row.ProductID][row.Date.Format(dateLayout)] += row.QuantitySold
+	}
+
+	method := services.ForecastMethod(req.Method)
+	coverDays := req.Days + uc.config.LeadTimeDays
+	result := &ForecastResponse{
+		Days:         req.Days,
+		Method:       req.Method,
+		HistoryFrom:  historyFrom.Format(dateLayout),
+		HistoryTo:    historyTo.AddDate(0, 0, -1).Format(dateLayout),
+		LeadTimeDays: uc.config.LeadTimeDays,
+		Products:     make([]ProductForecast, 0, len(products)),
+	}
+
+	for _, product := range products {
+		// Days before a product was listed would read as zero demand
+		start := historyFrom
+		if created := product.CreatedAt.In(time.Local); created.After(start) {
+			start = time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.Local)
+		}
+
+		var history []float64
+		for day := start; day.Before(historyTo); day = day.AddDate(0, 0, 1) {
+			history = append(history, sold[product.ID][day.Format(dateLayout)])
+		}
+
+		rate := uc.forecaster.DailyRate(history, method)
+		forecast := ProductForecast{
+			ProductID:        product.ID,
+			ProductName:      product.Name,
+			CategoryName:     product.Category.Name,
+			Stock:            product.Stock,
+			Unit:             product.Unit,
+			DailyRate:        math.Round(rate*100) / 100,
+			ForecastQuantity: int(math.Ceil(rate * float64(req.Days))),
+			ReorderQuantity:  uc.forecaster.ReorderQuantity(product.Stock, rate, coverDays),
+		}
+		if rate > 0 {
+			cover := math.Round(product.Stock/rate*10) / 10
+			forecast.DaysOfCover = &cover
+		}
+
+		if req.ReorderOnly && forecast.ReorderQuantity == 0 {
+			continue
+		}
+		result.Products = append(result.Products, forecast)
+	}
+
+	sort.SliceStable(result.Products, func(i, j int) bool {
+		a, b := result.Products[i].DaysOfCover, result.Products[j].DaysOfCover
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a < *b
+	})
+
+	return result, nil
+}
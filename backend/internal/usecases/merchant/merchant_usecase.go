@@ -0,0 +1,223 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/secrets"
+	"qris-pos-backend/internal/usecases/settings"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// MerchantRequest is the business profile of the merchant
+type MerchantRequest struct {
+	Name       string `json:"name" validate:"required,min=1,max=255"`
+	LegalName  string `json:"legal_name" validate:"max=255"`
+	Address    string `json:"address" validate:"max=500"`
+	City       string `json:"city" validate:"max=100"`
+	PostalCode string `json:"postal_code" validate:"omitempty,numeric,len=5"`
+	Phone      string `json:"phone" validate:"omitempty,phone_id"`
+	Email      string `json:"email" validate:"omitempty,email,max=255"`
+	TaxID      string `json:"tax_id" validate:"omitempty,npwp"`
+	LogoURL    string `json:"logo_url" validate:"omitempty,url,max=500"`
+	// Midtrans account payments are taken with. The server key is encrypted at rest and never returned,
+	// it is unchanged when omitted and an empty one goes back to the configured account.
+	MidtransServerKey   *string `json:"midtrans_server_key" validate:"omitempty,max=255"`
+	MidtransClientKey   string  `json:"midtrans_client_key" validate:"max=255"`
+	MidtransEnvironment string  `json:"midtrans_environment" validate:"omitempty,oneof=sandbox production"` // Sandbox when onboarding, unchanged when omitted
+}
+
+// MerchantResponse is the merchant profile as the API shows it
+type MerchantResponse struct {
+	entities.Merchant
+	MidtransConfigured bool `json:"midtrans_configured"` // Whether payments go through the merchant's own Midtrans account
+}
+
+// MerchantUseCase keeps the profile in memory, receipts and payments read it on every sale. Changes
+// apply right away on this instance and within a reload on the others.
+type MerchantUseCase struct {
+	merchantRepo repositories.MerchantRepository
+	settings     *settings.SettingsUseCase
+	cipher       *secrets.Cipher
+	logger       logger.Logger
+
+	mu        sync.RWMutex
+	current   *entities.Merchant // nil until the merchant is onboarded
+	listeners []func(*entities.Merchant)
+}
+
+func NewMerchantUseCase(merchantRepo repositories.MerchantRepository, settings *settings.SettingsUseCase, cipher *secrets.Cipher, logger logger.Logger) *MerchantUseCase {
+	return &MerchantUseCase{
+		merchantRepo: merchantRepo,
+		settings:     settings,
+		cipher:       cipher,
+		logger:       logger,
+	}
+}
+
+// Profile returns the profile in effect, nil before the merchant is onboarded. It must not be modified.
+func (uc *MerchantUseCase) Profile() *entities.Merchant {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	return uc.current
+}
+
+// Store returns the merchant information printed on receipts. Once the merchant is onboarded the name,
+// address, phone and NPWP come from its profile instead of the configuration, the footer stays a setting.
+func (uc *MerchantUseCase) Store() config.StoreConfig {
+	store := uc.settings.Store()
+	merchant := uc.Profile()
+	if merchant == nil {
+		return store
+	}
+	store.Name = merchant.Name
+	store.Address = merchant.FullAddress()
+	store.Phone = merchant.Phone
+	store.TaxID = merchant.TaxID
+	return store
+}
+
+// MidtransServerKey decrypts the server key of the merchant's own Midtrans account, empty when it has none
+func (uc *MerchantUseCase) MidtransServerKey(merchant *entities.Merchant) (string, error) {
+	return uc.cipher.Decrypt(merchant.MidtransServerKey)
+}
+
+// OnChange registers fn to be called with the new profile every time it changes
+func (uc *MerchantUseCase) OnChange(fn func(*entities.Merchant)) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.listeners = append(uc.listeners, fn)
+}
+
+// GetProfile returns the profile of the merchant
+func (uc *MerchantUseCase) GetProfile(ctx context.Context) (*MerchantResponse, error) {
+	merchant, err := uc.merchantRepo.Get(ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return toResponse(merchant), nil
+}
+
+// Onboard sets the merchant up. It is done once, the profile is updated afterwards.
+func (uc *MerchantUseCase) Onboard(ctx context.Context, req *MerchantRequest) (*MerchantResponse, error) {
+	_, err := uc.merchantRepo.Get(ctx)
+	if err == nil {
+		return nil, appErrors.ErrMerchantOnboarded
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	merchant := &entities.Merchant{}
+	if err := uc.applyMerchantRequest(merchant, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.merchantRepo.Create(ctx, merchant); err != nil {
+		uc.logger.Error("Failed to onboard merchant", "error", err)
+		return nil, err
+	}
+	uc.apply(merchant)
+
+	uc.logger.Info("Merchant onboarded", "merchant_id", merchant.ID, "midtrans_account", merchant.HasMidtransAccount())
+	return toResponse(merchant), nil
+}
+
+// UpdateProfile changes the profile, taking effect on the next receipt and payment
+func (uc *MerchantUseCase) UpdateProfile(ctx context.Context, req *MerchantRequest) (*MerchantResponse, error) {
+	merchant, err := uc.merchantRepo.Get(ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrMerchantNotFound
+		}
+		return nil, err
+	}
+
+	if err := uc.applyMerchantRequest(merchant, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.merchantRepo.Update(ctx, merchant); err != nil {
+		uc.logger.Error("Failed to update merchant", "error", err, "merchant_id", merchant.ID)
+		return nil, err
+	}
+	uc.apply(merchant)
+
+	uc.logger.Info("Merchant updated", "merchant_id", merchant.ID, "midtrans_account", merchant.HasMidtransAccount())
+	return toResponse(merchant), nil
+}
+
+// Reload reads the profile, notifying listeners when it changed. Runs as a background job so changes
+// made through another instance reach this one.
+func (uc *MerchantUseCase) Reload(ctx context.Context) error {
+	merchant, err := uc.merchantRepo.Get(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load merchant: %w", err)
+	}
+	uc.apply(merchant)
+	return nil
+}
+
+// apply puts a saved profile in effect, unless it is the one already in effect
+func (uc *MerchantUseCase) apply(merchant *entities.Merchant) {
+	uc.mu.Lock()
+	if uc.current != nil && uc.current.ID == merchant.ID && uc.current.UpdatedAt.Equal(merchant.UpdatedAt) {
+		uc.mu.Unlock()
+		return
+	}
+	uc.current = merchant
+	listeners := uc.listeners
+	uc.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(merchant)
+	}
+}
+
+// applyMerchantRequest validates the profile and encrypts a new server key
+func (uc *MerchantUseCase) applyMerchantRequest(merchant *entities.Merchant, req *MerchantRequest) error {
+	merchant.Name = req.Name
+	merchant.LegalName = req.LegalName
+	merchant.Address = req.Address
+	merchant.City = req.City
+	merchant.PostalCode = req.PostalCode
+	merchant.Phone = req.Phone
+	merchant.Email = req.Email
+	merchant.TaxID = req.TaxID
+	merchant.LogoURL = req.LogoURL
+	merchant.MidtransClientKey = req.MidtransClientKey
+	if req.MidtransEnvironment != "" {
+		merchant.MidtransEnvironment = req.MidtransEnvironment
+	}
+	if err := merchant.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+
+	if req.MidtransServerKey != nil {
+		encrypted, err := uc.cipher.Encrypt(strings.TrimSpace(*req.MidtransServerKey))
+		if err != nil {
+			return fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+		}
+		merchant.MidtransServerKey = encrypted
+	}
+	return nil
+}
+
+func toResponse(merchant *entities.Merchant) *MerchantResponse {
+	return &MerchantResponse{Merchant: *merchant, MidtransConfigured: merchant.HasMidtransAccount()}
+}
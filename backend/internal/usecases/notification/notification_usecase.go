@@ -0,0 +1,188 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/notification"
+	"qris-pos-backend/internal/infrastructure/receipt"
+	"qris-pos-backend/internal/usecases/merchant"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// batchSize caps how many deliveries a single run sends
+const batchSize = 50
+
+// errChannelNotConfigured fails a delivery for good, retrying can't help until the provider is set up
+var errChannelNotConfigured = errors.New("receipt channel is not configured")
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+type SendReceiptRequest struct {
+	Channel   entities.ReceiptChannel `json:"channel" validate:"required,oneof=email whatsapp"`
+	Recipient string                  `json:"recipient" validate:"required,max=255"` // Email address or WhatsApp number in E.164 format
+}
+
+type NotificationUseCase struct {
+	deliveryRepo    repositories.ReceiptDeliveryRepository
+	transactionRepo repositories.TransactionRepository
+	paymentRepo     repositories.PaymentRepository
+	senders         map[entities.ReceiptChannel]notification.Sender
+	merchant        *merchant.MerchantUseCase
+	config          config.NotificationConfig
+	logger          logger.Logger
+}
+
+func NewNotificationUseCase(
+	deliveryRepo repositories.ReceiptDeliveryRepository,
+	transactionRepo repositories.TransactionRepository,
+	paymentRepo repositories.PaymentRepository,
+	senders []notification.Sender,
+	merchant *merchant.MerchantUseCase,
+	cfg config.NotificationConfig,
+	logger logger.Logger,
+) *NotificationUseCase {
+	senderByChannel := make(map[entities.ReceiptChannel]notification.Sender, len(senders))
+	for _, sender := range senders {
+		senderByChannel[sender.Channel()] = sender
+	}
+
+	return &NotificationUseCase{
+		deliveryRepo:    deliveryRepo,
+		transactionRepo: transactionRepo,
+		paymentRepo:     paymentRepo,
+		senders:         senderByChannel,
+		merchant:        merchant,
+		config:          cfg,
+		logger:          logger,
+	}
+}
+
+// SendReceipt queues the digital receipt of a paid transaction to a recipient given by the cashier
+func (uc *NotificationUseCase) SendReceipt(ctx context.Context, transactionID string, req *SendReceiptRequest) (*entities.ReceiptDelivery, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.Status == entities.StatusPending || transaction.Status == entities.StatusCancelled || transaction.Status == entities.StatusExpired {
+		return nil, fmt.Errorf("receipt is only available for paid transactions")
+	}
+
+	if _, ok := uc.senders[req.Channel]; !ok {
+		return nil, errChannelNotConfigured
+	}
+	if !validRecipient(req.Channel, req.Recipient) {
+		return nil, fmt.Errorf("invalid %s recipient", req.Channel)
+	}
+
+	delivery := entities.NewReceiptDelivery(transactionID, req.Channel, req.Recipient)
+	if err := uc.deliveryRepo.Create(ctx, delivery); err != nil {
+		uc.logger.Error("Failed to queue receipt delivery", "error", err, "transaction_id", transactionID)
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// ListReceiptDeliveries lists the receipt deliveries of a transaction
+func (uc *NotificationUseCase) ListReceiptDeliveries(ctx context.Context, transactionID string) ([]entities.ReceiptDelivery, error) {
+	return uc.deliveryRepo.ListByTransactionID(ctx, transactionID)
+}
+
+// Run sends the due receipt deliveries. It is meant to be called periodically by the scheduler.
+func (uc *NotificationUseCase) Run(ctx context.Context) error {
+	deliveries, err := uc.deliveryRepo.ListDue(ctx, time.Now(), batchSize)
+	if err != nil {
+		return err
+	}
+
+	var sent, failed int
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		if err := uc.deliver(ctx, delivery); err != nil {
+			if errors.Is(err, errChannelNotConfigured) {
+				delivery.MarkAsFailed(err)
+			} else {
+				delivery.MarkAttemptFailed(err, uc.config.MaxAttempts, time.Duration(uc.config.RetryBackoffSeconds)*time.Second)
+			}
+			failed++
+			uc.logger.Warn("Failed to deliver receipt",
+				"error", err,
+				"delivery_id", delivery.ID,
+				"transaction_id", delivery.TransactionID,
+				"channel", delivery.Channel,
+				"attempts", delivery.Attempts)
+		} else {
+			delivery.MarkAsSent()
+			sent++
+		}
+
+		if err := uc.deliveryRepo.Update(ctx, delivery); err != nil {
+			uc.logger.Error("Failed to update receipt delivery", "error", err, "delivery_id", delivery.ID)
+		}
+	}
+
+	if sent > 0 || failed > 0 {
+		uc.logger.Info("Receipt deliveries processed", "sent", sent, "failed", failed)
+	}
+	return nil
+}
+
+func (uc *NotificationUseCase) deliver(ctx context.Context, delivery *entities.ReceiptDelivery) error {
+	sender, ok := uc.senders[delivery.Channel]
+	if !ok {
+		return errChannelNotConfigured
+	}
+
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, delivery.TransactionID)
+	if err != nil {
+		return fmt.Errorf("failed to load transaction: %w", err)
+	}
+
+	payment, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, delivery.TransactionID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load payment: %w", err)
+	}
+
+	r := receipt.New(receipt.NewStore(uc.merchant.Store()).ForOutlet(transaction.Store), transaction, payment)
+	msg := &notification.Message{
+		To:      delivery.Recipient,
+		Subject: fmt.Sprintf("Receipt from %s", r.Store.Name),
+		Text:    r.Text(receipt.WidthThermal58),
+	}
+	if delivery.Channel == entities.ReceiptChannelEmail {
+		msg.Attachments = []notification.Attachment{{
+			FileName:    fmt.Sprintf("receipt-%s.pdf", transaction.ID),
+			ContentType: "application/pdf",
+			Content:     receipt.RenderPDF(r),
+		}}
+	}
+
+	return sender.Send(ctx, msg)
+}
+
+func validRecipient(channel entities.ReceiptChannel, recipient string) bool {
+	switch channel {
+	case entities.ReceiptChannelEmail:
+		_, err := mail.ParseAddress(recipient)
+		return err == nil
+	case entities.ReceiptChannelWhatsApp:
+		return e164Pattern.MatchString(recipient)
+	}
+	return false
+}
@@ -0,0 +1,583 @@
+package offline
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/internal/usecases/payment"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// SyncStatus is what became of a transaction pushed by an offline terminal
+type SyncStatus string
+
+const (
+	SyncAccepted  SyncStatus = "accepted"  // Recorded, possibly with conflicts resolved by the server
+	SyncDuplicate SyncStatus = "duplicate" // Already recorded by an earlier push, safe to drop on the terminal
+	SyncRejected  SyncStatus = "rejected"  // Can't be recorded, retrying won't help
+	SyncFailed    SyncStatus = "failed"    // Not recorded because of a server error, push it again later
+)
+
+// ConflictType is how an offline sale disagreed with the server
+type ConflictType string
+
+const (
+	ConflictPriceChanged      ConflictType = "price_changed"
+	ConflictTotalMismatch     ConflictType = "total_mismatch"
+	ConflictInsufficientStock ConflictType = "insufficient_stock"
+	ConflictProductNotFound   ConflictType = "product_not_found"
+	ConflictTooOld            ConflictType = "too_old"
+)
+
+// Resolution is what the server did about a conflict
+type Resolution string
+
+const (
+	ResolutionKeptClientPrice Resolution = "kept_client_price" // The sale keeps the price the customer paid
+	ResolutionServerTotal     Resolution = "server_total"      // The total is recomputed with the current tax rules
+	ResolutionStockDepleted   Resolution = "stock_depleted"    // The stock is taken down to zero, not below
+	ResolutionRejected        Resolution = "rejected"          // The sale is not recorded
+)
+
+type PushTransactionsRequest struct {
+	Transactions []OfflineTransaction `json:"transactions" validate:"required,min=1,max=100,dive"`
+}
+
+// OfflineTransaction is a paid sale made while the terminal had no connection, identified by the
+// UUID the terminal gave it so pushing it again is harmless
+type OfflineTransaction struct {
+	ID            string             `json:"id" validate:"required,uuid"`
+	CreatedAt     time.Time          `json:"created_at" validate:"required"` // Terminal clock when the sale was made
+	Items         []OfflineItem      `json:"items" validate:"required,min=1,dive"`
+	Discount      int64              `json:"discount" validate:"gte=0"`
+	TotalAmount   int64              `json:"total_amount" validate:"gte=0"` // Total the terminal charged
+	Notes         string             `json:"notes"`
+	CustomerEmail string             `json:"customer_email" validate:"omitempty,email"`
+	CustomerPhone string             `json:"customer_phone" validate:"omitempty,phone_id"`
+	OrderType     entities.OrderType `json:"order_type" validate:"omitempty,oneof=dine_in pickup delivery"`
+	Payment       OfflinePayment     `json:"payment" validate:"required"`
+}
+
+type OfflineItem struct {
+	ProductID string  `json:"product_id" validate:"required,uuid"`
+	Quantity  float64 `json:"quantity" validate:"required,gt=0"` // In the product's unit
+	UnitPrice int64   `json:"unit_price" validate:"gte=0"`       // Price the terminal charged
+}
+
+type OfflinePayment struct {
+	Method         entities.PaymentMethod `json:"method" validate:"required,oneof=cash card other"`
+	AmountTendered int64                  `json:"amount_tendered" validate:"gte=0"`
+	Reference      string                 `json:"reference" validate:"required_if=Method card,max=100"` // Card approval code
+}
+
+type PushTransactionsResponse struct {
+	Results []PushResult `json:"results"`
+}
+
+type PushResult struct {
+	ID          string     `json:"id"`
+	Status      SyncStatus `json:"status"`
+	TotalAmount int64      `json:"total_amount,omitempty"` // Total recorded by the server
+	Conflicts   []Conflict `json:"conflicts,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type Conflict struct {
+	Type        ConflictType `json:"type"`
+	ProductID   string       `json:"product_id,omitempty"`
+	ClientValue float64      `json:"client_value"` // Amount, time or quantity depending on the type
+	ServerValue float64      `json:"server_value"`
+	Resolution  Resolution   `json:"resolution"`
+}
+
+type ChangesRequest struct {
+	Cursor string `form:"cursor"` // next_cursor of the previous pull, empty for a full download
+	Limit  int    `form:"limit" validate:"omitempty,gte=1,lte=1000"`
+}
+
+type ChangesResponse struct {
+	Products   []ProductChange `json:"products"`
+	NextCursor string          `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"` // Pull again with next_cursor right away
+	ServerTime time.Time       `json:"server_time"`
+}
+
+// ProductChange is the current state of a product the terminal caches, deleted ones included so the
+// terminal can drop them
+type ProductChange struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	SKU        string                 `json:"sku"`
+	Barcode    string                 `json:"barcode"`
+	Price      int64                  `json:"price"`
+	Stock      float64                `json:"stock"`
+	Unit       entities.UnitOfMeasure `json:"unit"`
+	CategoryID string                 `json:"category_id"`
+	TaxClass   entities.TaxClass      `json:"tax_class"`
+	ImageURL   string                 `json:"image_url"`
+	IsActive   bool                   `json:"is_active"`
+	Deleted    bool                   `json:"deleted"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+type SyncUseCase struct {
+	transactionRepo repositories.TransactionRepository
+	productRepo     repositories.ProductRepository
+	userRepo        repositories.UserRepository
+	storeRepo       repositories.StoreRepository
+	shiftRepo       repositories.ShiftRepository
+	taxRuleRepo     repositories.TaxRuleRepository
+	paymentUseCase  *payment.PaymentUseCase
+	eventBroker     *events.Broker
+	syncConfig      config.SyncConfig
+	logger          logger.Logger
+}
+
+func NewSyncUseCase(
+	transactionRepo repositories.TransactionRepository,
+	productRepo repositories.ProductRepository,
+	userRepo repositories.UserRepository,
+	storeRepo repositories.StoreRepository,
+	shiftRepo repositories.ShiftRepository,
+	taxRuleRepo repositories.TaxRuleRepository,
+	paymentUseCase *payment.PaymentUseCase,
+	eventBroker *events.Broker,
+	syncConfig config.SyncConfig,
+	logger logger.Logger,
+) *SyncUseCase {
+	return &SyncUseCase{
+		transactionRepo: transactionRepo,
+		productRepo:     productRepo,
+		userRepo:        userRepo,
+		storeRepo:       storeRepo,
+		shiftRepo:       shiftRepo,
+		taxRuleRepo:     taxRuleRepo,
+		paymentUseCase:  paymentUseCase,
+		eventBroker:     eventBroker,
+		syncConfig:      syncConfig,
+		logger:          logger,
+	}
+}
+
+// errRejected marks an offline sale the server refuses for good
+var errRejected = errors.New("offline transaction rejected")
+
+// PushTransactions records the sales an offline terminal made, each on its own so one bad sale doesn't
+// hold back the others. The sale happened already, so the server records what the customer paid and
+// reports where it disagrees rather than refusing it.
+func (uc *SyncUseCase) PushTransactions(ctx context.Context, userID string, req *PushTransactionsRequest) (*PushTransactionsResponse, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	storeID, ok := repositories.StoreFromContext(ctx)
+	if !ok {
+		if user.StoreID == nil {
+			return nil, appErrors.ErrStoreRequired
+		}
+		storeID = *user.StoreID
+	}
+
+	store, err := uc.storeRepo.GetByID(ctx, storeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrStoreNotFound
+		}
+		return nil, err
+	}
+
+	results := make([]PushResult, 0, len(req.Transactions))
+	for i := range req.Transactions {
+		offline := &req.Transactions[i]
+		result := uc.pushTransaction(ctx, store, userID, offline)
+		if result.Status == SyncFailed {
+			uc.logger.Error("Failed to record offline transaction", "error", result.Error, "transaction_id", offline.ID)
+		}
+		results = append(results, result)
+	}
+
+	return &PushTransactionsResponse{Results: results}, nil
+}
+
+func (uc *SyncUseCase) pushTransaction(ctx context.Context, store *entities.Store, userID string, offline *OfflineTransaction) PushResult {
+	result := PushResult{ID: offline.ID, Conflicts: []Conflict{}}
+
+	existing, err := uc.transactionRepo.GetByID(ctx, offline.ID)
+	if err == nil {
+		result.Status = SyncDuplicate
+		result.TotalAmount = existing.TotalAmount
+		// An earlier push stopped between recording the sale and its payment
+		if existing.Status == entities.StatusPending {
+			if err := uc.recordPayment(ctx, existing.ID, offline); err != nil {
+				return failed(result, err)
+			}
+		}
+		return result
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return failed(result, err)
+	}
+
+	transaction, reserved, err := uc.buildTransaction(ctx, store, userID, offline, &result)
+	if err != nil {
+		return failed(result, err)
+	}
+
+	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+		uc.releaseStock(ctx, reserved)
+		return failed(result, err)
+	}
+	if err := uc.recordPayment(ctx, transaction.ID, offline); err != nil {
+		// The sale stays pending, the next push of it retries the payment
+		return failed(result, err)
+	}
+
+	uc.publishStockChanged(ctx, reserved)
+	uc.logger.Info("Offline transaction recorded", "transaction_id", transaction.ID, "user_id", userID, "conflicts", len(result.Conflicts))
+
+	result.Status = SyncAccepted
+	result.TotalAmount = transaction.TotalAmount
+	return result
+}
+
+// buildTransaction turns an offline sale into a pending transaction with its stock reserved, recording
+// the conflicts it resolves on the result
+func (uc *SyncUseCase) buildTransaction(ctx context.Context, store *entities.Store, userID string, offline *OfflineTransaction, result *PushResult) (*entities.Transaction, []entities.TransactionItem, error) {
+	now := time.Now()
+	createdAt := offline.CreatedAt
+	if createdAt.Before(now.Add(-time.Duration(uc.syncConfig.MaxAgeHours) * time.Hour)) {
+		result.Conflicts = append(result.Conflicts, Conflict{
+			Type:        ConflictTooOld,
+			ClientValue: float64(createdAt.Unix()),
+			ServerValue: float64(now.Unix()),
+			Resolution:  ResolutionRejected,
+		})
+		return nil, nil, fmt.Errorf("%w: made more than %d hours ago", errRejected, uc.syncConfig.MaxAgeHours)
+	}
+	// A terminal clock running ahead can't date a sale in the future
+	if createdAt.After(now.Add(time.Duration(uc.syncConfig.MaxClockSkewMins) * time.Minute)) {
+		createdAt = now
+	}
+
+	shiftID, err := uc.openShiftID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transaction := entities.NewTransaction(store.ID, userID)
+	transaction.ID = offline.ID
+	transaction.ShiftID = shiftID
+	transaction.IsTraining = store.TrainingMode
+	transaction.Currency = store.Currency
+	transaction.ChargeRules = store.ChargeRules
+	transaction.Notes = offline.Notes
+	transaction.CustomerEmail = offline.CustomerEmail
+	transaction.CustomerPhone = entities.NormalizeCustomerPhone(offline.CustomerPhone)
+	if offline.OrderType != "" {
+		transaction.OrderType = offline.OrderType
+	}
+	transaction.CreatedAt = createdAt
+	transaction.EnableTracking()
+
+	// Quantity breaks are reached by the quantity of a product over all lines
+	quantities := make(map[string]float64, len(offline.Items))
+	for _, itemReq := range offline.Items {
+		quantities[itemReq.ProductID] = entities.RoundQuantity(quantities[itemReq.ProductID] + itemReq.Quantity)
+	}
+
+	for _, itemReq := range offline.Items {
+		product, err := uc.productRepo.GetByID(ctx, itemReq.ProductID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				result.Conflicts = append(result.Conflicts, Conflict{
+					Type:       ConflictProductNotFound,
+					ProductID:  itemReq.ProductID,
+					Resolution: ResolutionRejected,
+				})
+				return nil, nil, fmt.Errorf("%w: product with ID %s not found", errRejected, itemReq.ProductID)
+			}
+			return nil, nil, err
+		}
+		if err := product.ValidateQuantity(itemReq.Quantity); err != nil {
+			return nil, nil, fmt.Errorf("%w: product with ID %s: %v", errRejected, product.ID, err)
+		}
+
+		price, tierQuantity := product.Price, 0
+		if tier := entities.PriceTierFor(product.PriceTiers, quantities[product.ID], product.Price); tier != nil {
+			price, tierQuantity = tier.Price, tier.MinQuantity
+		}
+		priceSource, priceScheduleID := product.PriceSource(), product.PriceScheduleID
+		if itemReq.UnitPrice != price {
+			priceSource, priceScheduleID, tierQuantity = entities.PriceSourceClient, nil, 0
+			result.Conflicts = append(result.Conflicts, Conflict{
+				Type:        ConflictPriceChanged,
+				ProductID:   product.ID,
+				ClientValue: float64(itemReq.UnitPrice),
+				ServerValue: float64(price),
+				Resolution:  ResolutionKeptClientPrice,
+			})
+		}
+
+		transaction.Items = append(transaction.Items, entities.TransactionItem{
+			TransactionID:   transaction.ID,
+			ProductID:       product.ID,
+			Quantity:        itemReq.Quantity,
+			Unit:            product.Unit,
+			UnitPrice:       itemReq.UnitPrice,
+			BaseUnitPrice:   product.Price,
+			TierMinQuantity: tierQuantity,
+			TotalPrice:      entities.LinePrice(itemReq.UnitPrice, itemReq.Quantity),
+			UnitCost:        product.CostPrice,
+			TaxClass:        product.TaxClass,
+			PriceSource:     priceSource,
+			PriceScheduleID: priceScheduleID,
+			Sequence:        len(transaction.Items) + 1,
+			PrepStatus:      entities.ItemQueued,
+			Product:         *product,
+		})
+	}
+
+	// A discount the terminal gave is kept, as far as the items cover it
+	var subtotal int64
+	for _, item := range transaction.Items {
+		subtotal += item.TotalPrice
+	}
+	if err := transaction.ApplyDiscount(min(offline.Discount, subtotal)); err != nil {
+		return nil, nil, err
+	}
+
+	rules, err := uc.taxRuleRepo.ListActive(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	taxes, err := transaction.ApplyTax(rules)
+	if err != nil {
+		return nil, nil, err
+	}
+	transaction.Taxes = taxes
+
+	if transaction.TotalAmount != offline.TotalAmount {
+		result.Conflicts = append(result.Conflicts, Conflict{
+			Type:        ConflictTotalMismatch,
+			ClientValue: float64(offline.TotalAmount),
+			ServerValue: float64(transaction.TotalAmount),
+			Resolution:  ResolutionServerTotal,
+		})
+	}
+
+	// Practice sales leave the stock alone
+	var reserved []entities.TransactionItem
+	if !transaction.IsTraining {
+		reserved, err = uc.reserveStock(ctx, transaction.Items, result)
+		if err != nil {
+			return nil, nil, err
+		}
+		transaction.StockReserved = true
+	}
+
+	return transaction, reserved, nil
+}
+
+// reserveStock deducts the sold quantities from stock. The goods are gone already, so a product sold
+// out meanwhile is taken down to zero and reported, and the item records the shortfall so voids and
+// refunds don't return more than was taken. The reserved quantities are returned.
+func (uc *SyncUseCase) reserveStock(ctx context.Context, items []entities.TransactionItem, result *PushResult) ([]entities.TransactionItem, error) {
+	reserved := make([]entities.TransactionItem, 0, len(items))
+	for i := range items {
+		item := items[i]
+		err := uc.productRepo.ReserveStock(ctx, item.ProductID, item.Quantity)
+		if errors.Is(err, appErrors.ErrInsufficientStock) {
+			var product *entities.Product
+			product, err = uc.productRepo.GetByID(ctx, item.ProductID)
+			if err == nil {
+				result.Conflicts = append(result.Conflicts, Conflict{
+					Type:        ConflictInsufficientStock,
+					ProductID:   item.ProductID,
+					ClientValue: item.Quantity,
+					ServerValue: product.Stock,
+					Resolution:  ResolutionStockDepleted,
+				})
+				items[i].StockShortfall = entities.RoundQuantity(item.Quantity - product.Stock)
+				item.Quantity = product.Stock
+				if item.Quantity > 0 {
+					err = uc.productRepo.ReserveStock(ctx, item.ProductID, item.Quantity)
+				}
+			}
+		}
+		if err != nil {
+			uc.releaseStock(ctx, reserved)
+			return nil, err
+		}
+		reserved = append(reserved, item)
+	}
+	return reserved, nil
+}
+
+func (uc *SyncUseCase) releaseStock(ctx context.Context, items []entities.TransactionItem) {
+	for _, item := range items {
+		if item.Quantity == 0 {
+			continue
+		}
+		if err := uc.productRepo.UpdateStock(ctx, item.ProductID, item.Quantity); err != nil {
+			uc.logger.Error("Failed to release product stock", "error", err, "product_id", item.ProductID, "quantity", item.Quantity)
+		}
+	}
+}
+
+func (uc *SyncUseCase) publishStockChanged(ctx context.Context, items []entities.TransactionItem) {
+	for _, item := range items {
+		product, err := uc.productRepo.GetByID(ctx, item.ProductID)
+		if err != nil {
+			continue
+		}
+		uc.eventBroker.Publish(events.Event{
+			Type:     events.EventStockChanged,
+			TenantID: events.TenantOf(product.TenantID),
+			Data:     &events.StockChange{ProductID: product.ID, Stock: product.Stock},
+		})
+	}
+}
+
+// openShiftID returns the open shift of the cashier, an offline sale is recorded without one when the
+// shift was closed before the terminal came back online
+func (uc *SyncUseCase) openShiftID(ctx context.Context, userID string) (*string, error) {
+	shift, err := uc.shiftRepo.GetOpenByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shift.ID, nil
+}
+
+func (uc *SyncUseCase) recordPayment(ctx context.Context, transactionID string, offline *OfflineTransaction) error {
+	_, err := uc.paymentUseCase.RecordOfflinePayment(ctx, &payment.OfflinePaymentRequest{
+		TransactionID:  transactionID,
+		Method:         offline.Payment.Method,
+		AmountTendered: offline.Payment.AmountTendered,
+		Reference:      offline.Payment.Reference,
+		PaidAt:         offline.CreatedAt,
+	})
+	return err
+}
+
+func failed(result PushResult, err error) PushResult {
+	result.Status = SyncFailed
+	if errors.Is(err, errRejected) {
+		result.Status = SyncRejected
+	}
+	result.Error = err.Error()
+	return result
+}
+
+// GetChanges returns the products of the store changed since the cursor, oldest change first. Changes
+// of the last moments are held back for the next pull, a write still committing may carry an earlier
+// time than one already returned.
+func (uc *SyncUseCase) GetChanges(ctx context.Context, req *ChangesRequest) (*ChangesResponse, error) {
+	since, afterID, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = uc.syncConfig.PageSize
+	}
+
+	now := time.Now()
+	until := now.Add(-time.Duration(uc.syncConfig.SettleDelayMillis) * time.Millisecond)
+	products, err := uc.productRepo.ListChanged(ctx, since, afterID, until, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(products) > limit
+	if hasMore {
+		products = products[:limit]
+	}
+
+	changes := make([]ProductChange, 0, len(products))
+	for i := range products {
+		changes = append(changes, mapProductChange(&products[i]))
+	}
+
+	nextCursor := req.Cursor
+	if len(changes) > 0 {
+		last := changes[len(changes)-1]
+		nextCursor = encodeCursor(last.UpdatedAt, last.ID)
+	}
+
+	return &ChangesResponse{
+		Products:   changes,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+		ServerTime: now,
+	}, nil
+}
+
+func mapProductChange(product *entities.Product) ProductChange {
+	change := ProductChange{
+		ID:         product.ID,
+		Name:       product.Name,
+		SKU:        product.SKU,
+		Barcode:    product.Barcode,
+		Price:      product.Price,
+		Stock:      product.Stock,
+		Unit:       product.Unit,
+		CategoryID: product.CategoryID,
+		TaxClass:   product.TaxClass,
+		ImageURL:   product.ImageURL,
+		IsActive:   product.IsActive,
+		UpdatedAt:  product.UpdatedAt,
+	}
+	// Matches the change time the repository orders by
+	if product.DeletedAt.Valid {
+		change.Deleted = true
+		if product.DeletedAt.Time.After(change.UpdatedAt) {
+			change.UpdatedAt = product.DeletedAt.Time
+		}
+	}
+	return change
+}
+
+// encodeCursor keeps the position of the last change returned, microseconds match the precision of
+// the timestamps in the database
+func encodeCursor(changedAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", changedAt.UnixMicro(), id)))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Time{}, "", nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: invalid cursor", appErrors.ErrInvalidInput)
+	}
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, "", fmt.Errorf("%w: invalid cursor", appErrors.ErrInvalidInput)
+	}
+	unixMicro, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("%w: invalid cursor", appErrors.ErrInvalidInput)
+	}
+	return time.UnixMicro(unixMicro), id, nil
+}
@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/messaging"
+	"qris-pos-backend/pkg/logger"
+)
+
+// Message headers, so consumers can route an event without decoding it
+const (
+	HeaderEventID       = "event-id"
+	HeaderEventType     = "event-type"
+	HeaderAggregateType = "aggregate-type"
+	HeaderAggregateID   = "aggregate-id"
+	HeaderStoreID       = "store-id"
+	HeaderTenantID      = "tenant-id"
+)
+
+// Envelope is the body of every message published on the bus
+type Envelope struct {
+	ID            string                   `json:"id"`
+	Type          entities.OutboxEventType `json:"type"`
+	AggregateType string                   `json:"aggregate_type"`
+	AggregateID   string                   `json:"aggregate_id"`
+	StoreID       *string                  `json:"store_id,omitempty"`
+	TenantID      *string                  `json:"tenant_id,omitempty"` // Set in SaaS mode
+	OccurredAt    time.Time                `json:"occurred_at"`
+	Data          json.RawMessage          `json:"data"`
+}
+
+// RelayUseCase publishes the outbox on the message bus. Events go out at least once and in the order
+// they were written, consumers drop duplicates by event ID.
+type RelayUseCase struct {
+	outboxRepo repositories.OutboxRepository
+	publisher  messaging.Publisher
+	config     config.OutboxConfig
+	logger     logger.Logger
+}
+
+// NewRelayUseCase creates the relay, publisher is nil when no bus is configured and then only Purge is of use
+func NewRelayUseCase(outboxRepo repositories.OutboxRepository, publisher messaging.Publisher, cfg config.OutboxConfig, logger logger.Logger) *RelayUseCase {
+	return &RelayUseCase{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		config:     cfg,
+		logger:     logger,
+	}
+}
+
+// Run publishes the unpublished events, oldest first. It stops at the first event the bus refuses so
+// none overtakes it, the next run starts again from that event once its backoff has passed. It is
+// meant to be called periodically by the scheduler.
+func (uc *RelayUseCase) Run(ctx context.Context) error {
+	if uc.publisher == nil {
+		return nil
+	}
+
+	outboxEvents, err := uc.outboxRepo.ListUnpublished(ctx, uc.config.BatchSize)
+	if err != nil {
+		return err
+	}
+	if len(outboxEvents) == 0 || outboxEvents[0].NextAttemptAt.After(time.Now()) {
+		return nil
+	}
+
+	published := 0
+	for i := range outboxEvents {
+		event := &outboxEvents[i]
+
+		if err := uc.publish(ctx, event); err != nil {
+			event.MarkAttemptFailed(err, time.Duration(uc.config.RetryBackoffSeconds)*time.Second)
+			if updateErr := uc.outboxRepo.Update(ctx, event); updateErr != nil {
+				uc.logger.Error("Failed to update outbox event", "error", updateErr, "event_id", event.ID)
+			}
+			uc.logger.Warn("Failed to publish outbox event, relay paused until the retry",
+				"error", err,
+				"event_id", event.ID,
+				"event_type", event.EventType,
+				"attempts", event.Attempts,
+				"next_attempt_at", event.NextAttemptAt,
+				"published", published)
+			return nil
+		}
+
+		event.MarkAsPublished()
+		if err := uc.outboxRepo.Update(ctx, event); err != nil {
+			// Published but not marked, it goes out again on the next run
+			return fmt.Errorf("failed to mark outbox event %s as published: %w", event.ID, err)
+		}
+		published++
+	}
+
+	uc.logger.Info("Outbox events published", "published", published)
+	return nil
+}
+
+// Purge deletes the events older than the retention, published or not. It is meant to be called
+// periodically by the scheduler.
+func (uc *RelayUseCase) Purge(ctx context.Context) error {
+	cutoff := time.Now().Add(-time.Duration(uc.config.RetentionHours) * time.Hour)
+	purged, err := uc.outboxRepo.PurgeBefore(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		uc.logger.Info("Outbox events purged", "purged", purged, "cutoff", cutoff)
+	}
+	return nil
+}
+
+func (uc *RelayUseCase) publish(ctx context.Context, event *entities.OutboxEvent) error {
+	payload, err := json.Marshal(&Envelope{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		StoreID:       event.StoreID,
+		TenantID:      event.TenantID,
+		OccurredAt:    event.CreatedAt,
+		Data:          json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{
+		HeaderEventID:       event.ID,
+		HeaderEventType:     string(event.EventType),
+		HeaderAggregateType: event.AggregateType,
+		HeaderAggregateID:   event.AggregateID,
+	}
+	if event.StoreID != nil {
+		headers[HeaderStoreID] = *event.StoreID
+	}
+	if event.TenantID != nil {
+		headers[HeaderTenantID] = *event.TenantID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(uc.config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	return uc.publisher.Publish(ctx, &messaging.Message{
+		ID:      event.ID,
+		Topic:   uc.topic(event.EventType),
+		Key:     event.AggregateID,
+		Payload: payload,
+		Headers: headers,
+	})
+}
+
+// topic is the NATS subject or Kafka topic of an event type
+func (uc *RelayUseCase) topic(eventType entities.OutboxEventType) string {
+	if uc.config.TopicPrefix == "" {
+		return string(eventType)
+	}
+	return fmt.Sprintf("%s.%s", uc.config.TopicPrefix, eventType)
+}
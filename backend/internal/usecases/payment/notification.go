@@ -0,0 +1,190 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/payment"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// notificationRetryBatchSize is the number of stored notifications retried per run of the job
+const notificationRetryBatchSize = 100
+
+type PaymentNotificationFilters struct {
+	Status   string `form:"status" validate:"omitempty,oneof=received processed retrying failed rejected"`
+	Provider string `form:"provider" validate:"omitempty,oneof=midtrans xendit"`
+	OrderID  string `form:"order_id" validate:"omitempty,max=100"`
+	Limit    int    `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset   int    `form:"offset,default=0" validate:"gte=0"`
+}
+
+// HandleGatewayCallback authenticates and decodes a webhook of the given gateway and applies it. The
+// callback is stored first, so when applying it fails it is retried in the background and the gateway
+// gets its answer instead of a server error.
+func (uc *PaymentUseCase) HandleGatewayCallback(ctx context.Context, provider entities.PaymentProvider, header http.Header, body []byte) error {
+	record := entities.NewPaymentNotification(provider, body, uc.notificationBackoff())
+
+	gateway, err := uc.notificationGateway(ctx, provider, body)
+	if err != nil {
+		return err
+	}
+
+	notification, err := gateway.ParseNotification(header, body)
+	if err != nil {
+		// Kept for investigation, a forged or malformed callback is never applied
+		record.MarkAsRejected(err)
+		if createErr := uc.notificationRepo.Create(ctx, record); createErr != nil {
+			uc.logger.Error("Failed to store rejected payment notification", "error", createErr, "provider", provider)
+		}
+		return err
+	}
+
+	record.OrderID = notification.OrderID
+	record.ExternalID = notification.ExternalID
+	record.GatewayStatus = notification.Status
+	record.GrossAmount = notification.GrossAmount
+	if err := uc.notificationRepo.Create(ctx, record); err != nil {
+		// Without a stored copy there is nothing to retry, the gateway has to send it again
+		uc.logger.Error("Failed to store payment notification", "error", err, "provider", provider, "order_id", notification.OrderID)
+		return err
+	}
+
+	if err := uc.processNotification(ctx, record); err != nil {
+		uc.logger.Warn("Payment notification failed, queued for retry", "error", err, "notification_id", record.ID, "order_id", record.OrderID)
+	}
+	return nil
+}
+
+// notificationGateway returns the gateway that authenticates a webhook. Midtrans signs a notification
+// with the server key of the account that issued the order, so it is checked with the account of the
+// store the order belongs to, an order a refresh superseded included. An order the POS doesn't know is
+// checked with the shared account.
+func (uc *PaymentUseCase) notificationGateway(ctx context.Context, provider entities.PaymentProvider, body []byte) (payment.Gateway, error) {
+	if provider != entities.ProviderMidtrans {
+		return uc.gateways.Get(provider)
+	}
+
+	var order struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := json.Unmarshal(body, &order); err != nil || order.OrderID == "" {
+		return uc.gateways.Get(provider)
+	}
+	existing, err := uc.paymentRepo.GetPaymentByOrderID(ctx, order.OrderID)
+	if err != nil {
+		// The QRIS of a superseded order can still be paid, its payment is found through the order mapping
+		mapping, mappingErr := uc.paymentRepo.GetPaymentOrder(ctx, order.OrderID)
+		if mappingErr != nil {
+			return uc.gateways.Get(provider)
+		}
+		if existing, err = uc.paymentRepo.GetPaymentByID(ctx, mapping.PaymentID); err != nil {
+			return uc.gateways.Get(provider)
+		}
+	}
+	return uc.gateways.ForStore(ctx, existing.StoreID, provider)
+}
+
+// RetryNotifications applies again the stored notifications that failed on a passing error, and those
+// whose processing was cut off. It is meant to be called periodically by the scheduler.
+func (uc *PaymentUseCase) RetryNotifications(ctx context.Context) error {
+	notifications, err := uc.notificationRepo.ListDue(ctx, time.Now(), notificationRetryBatchSize)
+	if err != nil {
+		uc.logger.Error("Failed to list due payment notifications", "error", err)
+		return err
+	}
+
+	var processed, failed int
+	for i := range notifications {
+		if err := uc.processNotification(ctx, &notifications[i]); err != nil {
+			failed++
+			continue
+		}
+		processed++
+	}
+
+	if processed > 0 || failed > 0 {
+		uc.logger.Info("Payment notifications retried", "processed", processed, "failed", failed)
+	}
+	return nil
+}
+
+// ListPaymentNotifications returns the stored gateway notifications, newest first
+func (uc *PaymentUseCase) ListPaymentNotifications(ctx context.Context, filters *PaymentNotificationFilters) ([]entities.PaymentNotification, error) {
+	notifications, err := uc.notificationRepo.List(ctx, repositories.PaymentNotificationFilters{
+		Status:   entities.PaymentNotificationStatus(filters.Status),
+		Provider: entities.PaymentProvider(filters.Provider),
+		OrderID:  filters.OrderID,
+		Limit:    filters.Limit,
+		Offset:   filters.Offset,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to list payment notifications", "error", err)
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// ReplayPaymentNotification applies a stored notification again right away, typically one out of
+// attempts once what made it fail is fixed. Notifications settle payments idempotently, so replaying
+// one already processed changes nothing. When it fails again it gets a fresh set of retries.
+func (uc *PaymentUseCase) ReplayPaymentNotification(ctx context.Context, id, userID string) (*entities.PaymentNotification, error) {
+	record, err := uc.notificationRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotificationNotFound
+		}
+		return nil, err
+	}
+	if !record.CanBeReplayed() {
+		return nil, appErrors.ErrNotificationRejected
+	}
+
+	record.Replay(userID, uc.notificationBackoff())
+	if err := uc.notificationRepo.Update(ctx, record); err != nil {
+		return nil, err
+	}
+
+	if err := uc.processNotification(ctx, record); err != nil {
+		uc.logger.Warn("Replayed payment notification failed", "error", err, "notification_id", id, "replayed_by", userID)
+	} else {
+		uc.logger.Info("Payment notification replayed", "notification_id", id, "order_id", record.OrderID, "replayed_by", userID)
+	}
+	return record, nil
+}
+
+// processNotification applies a stored notification and records the outcome. A failure is retried with
+// backoff until the attempts run out, then the notification waits for an admin to replay it.
+func (uc *PaymentUseCase) processNotification(ctx context.Context, record *entities.PaymentNotification) error {
+	err := uc.HandlePaymentNotification(ctx, &PaymentNotification{
+		OrderID:           record.OrderID,
+		TransactionStatus: record.GatewayStatus,
+		ExternalID:        record.ExternalID,
+		GrossAmount:       record.GrossAmount,
+		RawResponse:       record.Payload,
+	})
+	if err != nil {
+		record.MarkAttemptFailed(err, uc.config.NotificationMaxAttempts, uc.notificationBackoff())
+		if record.Status == entities.NotificationFailed {
+			uc.logger.Error("Payment notification out of attempts, replay it once the cause is fixed", "error", err, "notification_id", record.ID, "order_id", record.OrderID)
+		}
+	} else {
+		record.MarkAsProcessed()
+	}
+
+	if saveErr := uc.notificationRepo.Update(ctx, record); saveErr != nil {
+		uc.logger.Error("Failed to record payment notification outcome", "error", saveErr, "notification_id", record.ID)
+	}
+	return err
+}
+
+func (uc *PaymentUseCase) notificationBackoff() time.Duration {
+	return time.Duration(uc.config.NotificationRetryBackoffSeconds) * time.Second
+}
@@ -0,0 +1,162 @@
+package payment
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+)
+
+// Formats of the payment list
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+const dateLayout = "2006-01-02"
+
+// exportBatchSize is the number of payments read at a time while a CSV export is written
+const exportBatchSize = 500
+
+type ListPaymentsRequest struct {
+	Status    entities.PaymentStatus `form:"status" validate:"omitempty,oneof=pending success failed expired cancelled"`
+	Method    entities.PaymentMethod `form:"method" validate:"omitempty,oneof=qris cash card other ewallet"`
+	StoreID   string                 `form:"store_id" validate:"omitempty,uuid"` // Cashiers only ever see the payments of their store
+	DateFrom  string                 `form:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo    string                 `form:"date_to" validate:"omitempty,datetime=2006-01-02"` // Inclusive
+	MinAmount *int64                 `form:"min_amount" validate:"omitempty,gte=0"`
+	MaxAmount *int64                 `form:"max_amount" validate:"omitempty,gte=0"`
+	Training  bool                   `form:"training"` // List the payments of practice sales instead
+	Format    string                 `form:"format,default=json" validate:"oneof=json csv"`
+	Limit     int                    `form:"limit,default=50" validate:"gte=1,lte=200"`
+	Offset    int                    `form:"offset,default=0" validate:"gte=0"`
+}
+
+// ListPayments returns a page of the payments matching the filters, newest first
+func (uc *PaymentUseCase) ListPayments(ctx context.Context, req *ListPaymentsRequest) ([]PaymentResponse, error) {
+	filters, err := paymentFilters(req)
+	if err != nil {
+		return nil, err
+	}
+	filters.Limit = req.Limit
+	filters.Offset = req.Offset
+
+	payments, err := uc.paymentRepo.ListPayments(ctx, filters)
+	if err != nil {
+		uc.logger.Error("Failed to list payments", "error", err)
+		return nil, err
+	}
+
+	result := make([]PaymentResponse, 0, len(payments))
+	for i := range payments {
+		result = append(result, *uc.mapPaymentToResponse(&payments[i], nil))
+	}
+	return result, nil
+}
+
+// ValidatePaymentFilters checks the filters of a list before a CSV export starts writing
+func (uc *PaymentUseCase) ValidatePaymentFilters(req *ListPaymentsRequest) error {
+	_, err := paymentFilters(req)
+	return err
+}
+
+// ExportPayments writes every payment matching the filters as CSV, newest first. Pagination is ignored,
+// the payments are read in batches so large exports don't sit in memory.
+func (uc *PaymentUseCase) ExportPayments(ctx context.Context, w io.Writer, req *ListPaymentsRequest) error {
+	filters, err := paymentFilters(req)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{
+		"id", "transaction_id", "store_id", "created_at", "paid_at", "method", "provider", "wallet", "status",
+		"currency", "amount", "amount_tendered", "change_amount", "order_id", "external_id", "reference",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	filters.Limit = exportBatchSize
+	for {
+		payments, err := uc.paymentRepo.ListPayments(ctx, filters)
+		if err != nil {
+			return err
+		}
+		for _, p := range payments {
+			paidAt := ""
+			if p.PaidAt != nil {
+				paidAt = p.PaidAt.Format(time.RFC3339)
+			}
+			err := writer.Write([]string{
+				p.ID,
+				p.TransactionID,
+				p.StoreID,
+				p.CreatedAt.Format(time.RFC3339),
+				paidAt,
+				string(p.Method),
+				string(p.Provider),
+				string(p.Wallet),
+				string(p.Status),
+				string(p.Currency),
+				strconv.FormatInt(p.Amount, 10),
+				strconv.FormatInt(p.AmountTendered, 10),
+				strconv.FormatInt(p.ChangeAmount, 10),
+				p.OrderID,
+				p.ExternalID,
+				p.Reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		if len(payments) < exportBatchSize {
+			return nil
+		}
+		filters.Offset += exportBatchSize
+	}
+}
+
+func paymentFilters(req *ListPaymentsRequest) (repositories.PaymentFilters, error) {
+	filters := repositories.PaymentFilters{
+		StoreID:   req.StoreID,
+		Status:    req.Status,
+		Method:    req.Method,
+		Training:  req.Training,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
+	}
+
+	if req.DateFrom != "" {
+		from, err := time.ParseInLocation(dateLayout, req.DateFrom, time.Local)
+		if err != nil {
+			return filters, fmt.Errorf("%w: invalid date_from", appErrors.ErrInvalidInput)
+		}
+		filters.From = &from
+	}
+	if req.DateTo != "" {
+		to, err := time.ParseInLocation(dateLayout, req.DateTo, time.Local)
+		if err != nil {
+			return filters, fmt.Errorf("%w: invalid date_to", appErrors.ErrInvalidInput)
+		}
+		to = to.AddDate(0, 0, 1)
+		filters.To = &to
+	}
+	if filters.From != nil && filters.To != nil && !filters.From.Before(*filters.To) {
+		return filters, fmt.Errorf("%w: date_from must not be after date_to", appErrors.ErrInvalidInput)
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		return filters, fmt.Errorf("%w: min_amount must not be above max_amount", appErrors.ErrInvalidInput)
+	}
+	return filters, nil
+}
@@ -2,98 +2,255 @@ package payment
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
 	"qris-pos-backend/internal/infrastructure/payment"
 	"qris-pos-backend/internal/infrastructure/qrcode"
+	"qris-pos-backend/internal/usecases/merchant"
+	"qris-pos-backend/internal/usecases/settings"
+	"qris-pos-backend/pkg/emvco"
 	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type GenerateQRISRequest struct {
-	TransactionID string  `json:"transaction_id" validate:"required,uuid"`
-	Amount        float64 `json:"amount" validate:"required,gte=0"`
-	CallbackURL   string  `json:"callback_url"`
-	ExpiryMinutes int     `json:"expiry_minutes"`
+	TransactionID string `json:"transaction_id" validate:"required,uuid"`
+	Amount        int64  `json:"amount" validate:"idr_amount"` // The balance due when empty, less than the balance takes a deposit and leaves the rest open
+	CallbackURL   string `json:"callback_url"`
+	ExpiryMinutes int    `json:"expiry_minutes"`
+	Async         bool   `json:"async"` // Queue the gateway charge and return a job to poll or stream instead of waiting for it
 }
 
 type PaymentResponse struct {
-	ID            string                 `json:"id"`
-	TransactionID string                 `json:"transaction_id"`
-	Amount        float64                `json:"amount"`
-	Method        entities.PaymentMethod `json:"method"`
-	Status        entities.PaymentStatus `json:"status"`
-	ExternalID    string                 `json:"external_id"`
-	PaidAt        *string                `json:"paid_at"`
-	ExpiresAt     string                 `json:"expires_at"`
-	CreatedAt     string                 `json:"created_at"`
-	UpdatedAt     string                 `json:"updated_at"`
-	QRISCode      *QRISCodeResponse      `json:"qr_code,omitempty"`
+	ID              string                   `json:"id"`
+	TransactionID   string                   `json:"transaction_id"`
+	StoreID         string                   `json:"store_id"`
+	Amount          int64                    `json:"amount"`
+	AmountDue       int64                    `json:"amount_due"`       // Balance of the transaction when the payment was taken
+	RemainingAmount int64                    `json:"remaining_amount"` // Left to pay once the payment settles, above 0 for a deposit
+	Currency        entities.Currency        `json:"currency"`
+	Method          entities.PaymentMethod   `json:"method"`
+	Status          entities.PaymentStatus   `json:"status"`
+	Provider        entities.PaymentProvider `json:"provider,omitempty"`
+	ExternalID      string                   `json:"external_id"`
+	AmountTendered  int64                    `json:"amount_tendered,omitempty"`
+	ChangeAmount    int64                    `json:"change_amount,omitempty"`
+	Reference       string                   `json:"reference,omitempty"`
+	Wallet          entities.EWallet         `json:"wallet,omitempty"`
+	DeeplinkURL     string                   `json:"deeplink_url,omitempty"` // Opens the e-wallet app on the payment
+	PaidAt          *string                  `json:"paid_at"`
+	ExpiresAt       string                   `json:"expires_at"`
+	CreatedAt       string                   `json:"created_at"`
+	UpdatedAt       string                   `json:"updated_at"`
+	QRISCode        *QRISCodeResponse        `json:"qr_code,omitempty"`
 }
 
 type QRISCodeResponse struct {
-	ID            string `json:"id"`
-	TransactionID string `json:"transaction_id"`
-	PaymentID     string `json:"payment_id"`
-	QRCode        string `json:"qr_code"` // QRIS EMVCo string for frontend QR generation
-	URL           string `json:"url"`     // Midtrans simulator URL for testing
-	ExpiresAt     string `json:"expires_at"`
-	CreatedAt     string `json:"created_at"`
+	ID               string `json:"id"`
+	TransactionID    string `json:"transaction_id"`
+	PaymentID        string `json:"payment_id"`
+	QRCode           string `json:"qr_code"` // QRIS EMVCo string for frontend QR generation
+	URL              string `json:"url"`     // Midtrans simulator URL for testing, empty for other providers
+	ExpiresAt        string `json:"expires_at"`
+	ExpiresAtEpoch   int64  `json:"expires_at_epoch"`  // Expiry in Unix seconds, independent of time zones
+	SecondsRemaining int64  `json:"seconds_remaining"` // Computed by the server, count down from this instead of the device clock
+	CreatedAt        string `json:"created_at"`
+}
+
+// QRISImageRequest picks how the QR of a QRIS is rendered on the server
+type QRISImageRequest struct {
+	Size   int    `form:"size,default=256" validate:"gte=128,lte=1024"` // Width and height in pixels
+	Format string `form:"format,default=png" validate:"oneof=png svg data-uri"`
+}
+
+// QRISImage is the QR of a QRIS rendered on the server, for clients that can't draw one themselves.
+// Data holds the PNG or SVG, a data-uri rendering carries the PNG in DataURI instead.
+type QRISImage struct {
+	Format      string    `json:"format"`
+	ContentType string    `json:"-"`
+	Data        []byte    `json:"-"`
+	DataURI     string    `json:"data_uri,omitempty"`
+	ETag        string    `json:"-"`          // Changes when the QRIS is refreshed
+	ExpiresAt   time.Time `json:"expires_at"` // The image is worthless afterwards
 }
 
 type PaymentStatusResponse struct {
+	TransactionID   string                 `json:"transaction_id"`
+	Status          entities.PaymentStatus `json:"status"`
+	ExternalID      string                 `json:"external_id"`
+	Message         string                 `json:"message"`
+	RemainingAmount int64                  `json:"remaining_amount,omitempty"` // Balance still open after a settled deposit
+}
+
+type BatchPaymentStatusRequest struct {
+	TransactionIDs []string `json:"transaction_ids" validate:"required,min=1,max=50,dive,uuid"`
+}
+
+// PaymentStatusResult is the status of one transaction in a batch, Error is set when it couldn't be checked
+type PaymentStatusResult struct {
 	TransactionID string                 `json:"transaction_id"`
-	Status        entities.PaymentStatus `json:"status"`
-	ExternalID    string                 `json:"external_id"`
-	Message       string                 `json:"message"`
+	Status        entities.PaymentStatus `json:"status,omitempty"`
+	ExternalID    string                 `json:"external_id,omitempty"`
+	Message       string                 `json:"message,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+type BatchPaymentStatusResponse struct {
+	Results []PaymentStatusResult `json:"results"`
+}
+
+type CashPaymentRequest struct {
+	TransactionID  string `json:"transaction_id" validate:"required,uuid"`
+	AmountTendered int64  `json:"amount_tendered" validate:"required,idr_amount"`
+}
+
+type ManualPaymentRequest struct {
+	TransactionID string                 `json:"transaction_id" validate:"required,uuid"`
+	Method        entities.PaymentMethod `json:"method" validate:"required,oneof=card other"`
+	Reference     string                 `json:"reference" validate:"max=100"` // Card approval code or other reference
+}
+
+// OfflinePaymentRequest records a payment taken by a terminal while it was offline
+type OfflinePaymentRequest struct {
+	TransactionID  string                 `json:"transaction_id" validate:"required,uuid"`
+	Method         entities.PaymentMethod `json:"method" validate:"required,oneof=cash card other"`
+	AmountTendered int64                  `json:"amount_tendered" validate:"idr_amount"` // Cash handed over, the amount due when empty
+	Reference      string                 `json:"reference" validate:"max=100"`
+	PaidAt         time.Time              `json:"paid_at"`
+}
+
+// EWalletPaymentRequest charges a transaction to an e-wallet. The customer pays by opening the
+// returned deeplink on their phone, GoPay also returns a QRIS to scan from another phone.
+type EWalletPaymentRequest struct {
+	TransactionID string           `json:"transaction_id" validate:"required,uuid"`
+	Wallet        entities.EWallet `json:"wallet" validate:"required,oneof=gopay shopeepay"`
+	CallbackURL   string           `json:"callback_url" validate:"omitempty,url"` // Where the wallet app sends the customer back after paying
+	ExpiryMinutes int              `json:"expiry_minutes" validate:"gte=0,lte=60"`
+}
+
+// ConfirmQRISRequest confirms a local QRIS payment the cashier has seen arrive in the merchant's account
+type ConfirmQRISRequest struct {
+	Reference string `json:"reference" validate:"required,max=100"` // Reference number shown in the merchant's banking app
+}
+
+// PaymentNotification is a payment status notification pushed by a gateway
+type PaymentNotification struct {
+	OrderID           string
+	TransactionStatus string
+	ExternalID        string
+	GrossAmount       int64
+	RawResponse       string
+}
+
+type PaymentExceptionFilters struct {
+	Status string `form:"status" validate:"omitempty,oneof=open acknowledged refunded"`
+	Reason string `form:"reason" validate:"omitempty,oneof=late_payment duplicate_payment unknown_order void_refund stock_unavailable"`
+	Limit  int    `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset int    `form:"offset,default=0" validate:"gte=0"`
+}
+
+type ResolvePaymentExceptionRequest struct {
+	Status entities.PaymentExceptionStatus `json:"status" validate:"required,oneof=acknowledged refunded"`
+	Note   string                          `json:"note" validate:"max=500"`
 }
 
+const (
+	// reconcileGracePeriod leaves time for the gateway webhook before a payment is requeried
+	reconcileGracePeriod = 2 * time.Minute
+	reconcileBatchSize   = 100
+)
+
 type PaymentUseCase struct {
 	paymentRepo      repositories.PaymentRepository
 	transactionRepo  repositories.TransactionRepository
-	midtransClient   *payment.MidtransClient
+	exceptionRepo    repositories.PaymentExceptionRepository
+	notificationRepo repositories.PaymentNotificationRepository
+	deliveryRepo     repositories.ReceiptDeliveryRepository
+	customerRepo     repositories.CustomerRepository
+	txManager        repositories.TransactionManager
+	gateways         *payment.Gateways
 	qrCodeGenerator  *qrcode.QRCodeGenerator
+	eventBroker      *events.Broker
 	logger           logger.Logger
-	defaultExpiryMin int
+	config           config.PaymentConfig
+	loyaltyConfig    config.LoyaltyConfig
+	settings         *settings.SettingsUseCase
+	merchant         *merchant.MerchantUseCase
+	localQRIS        emvco.Payload // Merchant's static QRIS in local mode, nil when QRIS goes through a gateway
 }
 
 func NewPaymentUseCase(
 	paymentRepo repositories.PaymentRepository,
 	transactionRepo repositories.TransactionRepository,
-	midtransClient *payment.MidtransClient,
+	exceptionRepo repositories.PaymentExceptionRepository,
+	notificationRepo repositories.PaymentNotificationRepository,
+	deliveryRepo repositories.ReceiptDeliveryRepository,
+	customerRepo repositories.CustomerRepository,
+	txManager repositories.TransactionManager,
+	gateways *payment.Gateways,
 	qrCodeGenerator *qrcode.QRCodeGenerator,
+	eventBroker *events.Broker,
+	cfg config.PaymentConfig,
+	loyaltyConfig config.LoyaltyConfig,
+	settings *settings.SettingsUseCase,
+	merchant *merchant.MerchantUseCase,
 	logger logger.Logger,
 ) *PaymentUseCase {
-	return &PaymentUseCase{
+	uc := &PaymentUseCase{
 		paymentRepo:      paymentRepo,
 		transactionRepo:  transactionRepo,
-		midtransClient:   midtransClient,
+		exceptionRepo:    exceptionRepo,
+		notificationRepo: notificationRepo,
+		deliveryRepo:     deliveryRepo,
+		customerRepo:     customerRepo,
+		txManager:        txManager,
+		gateways:         gateways,
 		qrCodeGenerator:  qrCodeGenerator,
+		eventBroker:      eventBroker,
 		logger:           logger,
-		defaultExpiryMin: 10, // Default 10 minutes expiry
+		config:           cfg,
+		loyaltyConfig:    loyaltyConfig,
+		settings:         settings,
+		merchant:         merchant,
+	}
+
+	if cfg.QRISMode == "local" {
+		template, err := localQRISTemplate(cfg.LocalQRIS)
+		if err != nil {
+			logger.Error("Local QRIS is misconfigured, QRIS generation will fail", "error", err)
+		}
+		uc.localQRIS = template
 	}
+
+	return uc
 }
 
 // GenerateQRIS generates a QRIS code for a transaction
 func (uc *PaymentUseCase) GenerateQRIS(ctx context.Context, req *GenerateQRISRequest) (*PaymentResponse, error) {
-	// Validate transaction exists and is pending
-	// Use GetByIDWithDetails to preload User and Items
-	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, req.TransactionID)
+	transaction, err := uc.qrisTransaction(ctx, req.TransactionID)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, appErrors.ErrTransactionNotFound
-		}
 		return nil, err
 	}
-
-	// Check if transaction is in pending status
-	if transaction.Status != entities.StatusPending {
-		return nil, fmt.Errorf("transaction is not in pending status")
+	amount, amountDue, err := uc.qrisAmount(ctx, transaction, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.reserveStock(ctx, transaction); err != nil {
+		return nil, err
 	}
 
 	// Check if transaction already has a payment
@@ -102,130 +259,116 @@ func (uc *PaymentUseCase) GenerateQRIS(ctx context.Context, req *GenerateQRISReq
 		return nil, err
 	}
 
-	if existingPayment != nil {
-		// If payment exists and is still valid, return it
-		if existingPayment.CanBeProcessed() {
-			// Get existing QRIS code
-			existingQRIS, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, existingPayment.ID)
-			if err != nil && err != gorm.ErrRecordNotFound {
-				return nil, err
-			}
-
-			return uc.mapPaymentToResponse(existingPayment, existingQRIS), nil
+	// A settled deposit stays as it is, this payment is for the balance
+	if existingPayment != nil && existingPayment.Status != entities.PaymentSuccess {
+		// A QRIS that can still be paid is handed back instead of issuing a second one
+		if existingPayment.CanBeProcessed() && existingPayment.Method == entities.PaymentMethodQRIS {
+			return nil, uc.duplicatePaymentError(ctx, existingPayment)
 		}
 
 		// If payment is expired, mark it as expired
-		if existingPayment.IsExpired() {
-			existingPayment.MarkAsExpired()
-			if err := uc.paymentRepo.UpdatePayment(ctx, existingPayment); err != nil {
+		if existingPayment.Status == entities.PaymentPending && existingPayment.IsExpired() {
+			if err := uc.expirePayment(ctx, existingPayment); err != nil {
 				uc.logger.Error("Failed to update expired payment", "error", err)
 			}
+		} else if err := uc.cancelPendingPayment(ctx, req.TransactionID); err != nil {
+			// An e-wallet charge is still open, the customer switched to scanning a QRIS
+			return nil, err
 		}
 	}
 
 	// Determine expiry minutes
 	expiryMinutes := req.ExpiryMinutes
 	if expiryMinutes <= 0 {
-		expiryMinutes = uc.defaultExpiryMin
+		expiryMinutes = uc.settings.QRISExpiryMinutes()
 	}
 
 	// Create payment record
-	paymentEntity := entities.NewPayment(req.TransactionID, req.Amount, expiryMinutes)
+	paymentEntity := entities.NewPayment(req.TransactionID, amount, expiryMinutes)
+	paymentEntity.AmountDue = amountDue
+	paymentEntity.StoreID = transaction.StoreID
+	paymentEntity.Currency = transaction.Currency
+	paymentEntity.IsTraining = transaction.IsTraining
 
-	// Generate QRIS via Midtrans
-	// OrderID must be <= 50 chars. Using first 8 chars of UUID + current timestamp
-	shortTxID := req.TransactionID
-	if len(shortTxID) > 8 {
-		shortTxID = shortTxID[:8]
-	}
-	// Use current time for consistent order_id
-	now := time.Now()
-	orderID := fmt.Sprintf("qris-%s-%d", shortTxID, now.Unix())
+	orderID := newOrderID(req.TransactionID, time.Now())
 
 	// Store order_id in payment entity for later status checking
 	paymentEntity.OrderID = orderID
 
+	customer := uc.gatewayCustomer(transaction)
 	qrisReq := payment.QRISRequest{
 		TransactionID:  req.TransactionID,
 		OrderID:        orderID,
-		GrossAmount:    transaction.TotalAmount, // Use transaction total (includes tax & discount)
-		CustomerName:   transaction.User.Name,
-		CustomerEmail:  transaction.User.Email,
-		Items:          uc.mapTransactionItemsToQRISItems(transaction),
+		GrossAmount:    amount,
+		CustomerName:   customer.Name,
+		CustomerEmail:  customer.Email,
+		CustomerPhone:  customer.Phone,
+		Items:          uc.qrisItems(transaction, amount, amountDue),
 		ExpiryDuration: expiryMinutes,
 	}
 
-	// Debug: Log QRIS request details
-	uc.logger.Info("Generating QRIS with details",
+	// Amount breakdown is only useful when debugging Midtrans gross_amount mismatches
+	var itemsSum int64
+	for _, item := range qrisReq.Items {
+		itemsSum += item.Price * int64(item.Quantity)
+	}
+	uc.logger.Debug("Generating QRIS",
 		"order_id", orderID,
-		"gross_amount", qrisReq.GrossAmount,
 		"items_count", len(qrisReq.Items),
-		"transaction_total", transaction.TotalAmount)
-
-	// Debug: Log each item
-	var itemsSum float64
-	for _, item := range qrisReq.Items {
-		itemTotal := item.Price * float64(item.Quantity)
-		itemsSum += itemTotal
-		uc.logger.Info("Item details",
-			"name", item.Name,
-			"unit_price", item.Price,
-			"quantity", item.Quantity,
-			"item_total", itemTotal)
-	}
-	uc.logger.Info("Items sum check",
 		"items_sum", itemsSum,
 		"gross_amount", qrisReq.GrossAmount,
 		"match", itemsSum == qrisReq.GrossAmount)
 
-	qrisResponse, err := uc.midtransClient.GenerateQRIS(ctx, qrisReq)
+	qrisResponse, provider, err := uc.issueQRIS(ctx, qrisReq, transaction)
 	if err != nil {
-		uc.logger.Error("Failed to generate QRIS via Midtrans", "error", err)
+		uc.logger.Error("Failed to generate QRIS", "error", err, "provider", provider)
+		if errors.Is(err, appErrors.ErrGatewayUnavailable) {
+			return nil, err
+		}
+		if provider != entities.ProviderLocalQRIS {
+			return nil, &appErrors.GatewayUnavailableError{Provider: string(provider), Err: err}
+		}
 		return nil, fmt.Errorf("failed to generate QRIS: %w", err)
 	}
+	paymentEntity.Provider = provider
+	paymentEntity.OrderID = qrisResponse.OrderID // Some gateways identify the order by their own ID
 
-	// Save payment first to get the ID
-	if err := uc.paymentRepo.CreatePayment(ctx, paymentEntity); err != nil {
+	// The payment and its QRIS code are saved together, a payment without a code can't be paid
+	var qrCodeEntity *entities.QRISCode
+	if err := paymentEntity.RecordCreation(uc.requestActor(ctx)); err != nil {
+		return nil, err
+	}
+	err = uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.paymentRepo.CreatePayment(ctx, paymentEntity); err != nil {
+			return err
+		}
+
+		// Store both QRIS string (for frontend QR generation) and URL (for Midtrans simulator testing)
+		qrCodeEntity = entities.NewQRISCode(
+			req.TransactionID,
+			paymentEntity.ID,
+			qrisResponse.QRString,
+			qrisResponse.URL, // Midtrans simulator URL for testing, empty for other providers
+			expiryMinutes,
+		)
+		return uc.paymentRepo.CreateQRISCode(ctx, qrCodeEntity)
+	})
+	if err != nil {
 		// Check if error is due to duplicate constraint violation
 		if strings.Contains(err.Error(), "idx_unique_pending_payment_per_transaction") {
-			// Payment already exists, fetch and return existing payment
+			// A concurrent request created the payment first, hand that one back
 			uc.logger.Warn("Payment already exists for transaction, returning existing", "transaction_id", req.TransactionID)
 			existingPayment, getErr := uc.paymentRepo.GetPaymentByTransactionID(ctx, req.TransactionID)
 			if getErr != nil {
 				uc.logger.Error("Failed to get existing payment", "error", getErr)
 				return nil, err
 			}
-			existingQRIS, getErr := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, existingPayment.ID)
-			if getErr != nil {
-				uc.logger.Error("Failed to get existing QRIS", "error", getErr)
-				return nil, err
-			}
-			return uc.mapPaymentToResponse(existingPayment, existingQRIS), nil
+			return nil, uc.duplicatePaymentError(ctx, existingPayment)
 		}
 		uc.logger.Error("Failed to create payment record", "error", err)
 		return nil, err
 	}
 
-	// Now create QRIS code record with the payment ID
-	// Store both QRIS string (for frontend QR generation) and URL (for Midtrans simulator testing)
-	qrCodeEntity := entities.NewQRISCode(
-		req.TransactionID,
-		paymentEntity.ID,
-		qrisResponse.QRString,
-		qrisResponse.URL, // Midtrans simulator URL for testing
-		expiryMinutes,
-	)
-
-	// Save QRIS code
-	if err := uc.paymentRepo.CreateQRISCode(ctx, qrCodeEntity); err != nil {
-		uc.logger.Error("Failed to create QRIS code record", "error", err)
-		// Try to rollback payment creation
-		if delErr := uc.paymentRepo.DeletePayment(ctx, paymentEntity.ID); delErr != nil {
-			uc.logger.Error("Failed to rollback payment creation", "error", delErr)
-		}
-		return nil, err
-	}
-
 	uc.logger.Info("QRIS generated successfully", "transaction_id", req.TransactionID, "payment_id", paymentEntity.ID)
 
 	return uc.mapPaymentToResponse(paymentEntity, qrCodeEntity), nil
@@ -244,20 +387,23 @@ func (uc *PaymentUseCase) GetPaymentStatus(ctx context.Context, transactionID st
 
 	// If payment is already success, failed, or cancelled, return current status
 	if paymentEntity.Status != entities.PaymentPending && paymentEntity.Status != entities.PaymentExpired {
-		return &PaymentStatusResponse{
+		response := &PaymentStatusResponse{
 			TransactionID: transactionID,
 			Status:        paymentEntity.Status,
 			ExternalID:    paymentEntity.ExternalID,
 			Message:       fmt.Sprintf("Payment status: %s", paymentEntity.Status),
-		}, nil
+		}
+		if paymentEntity.Status == entities.PaymentSuccess {
+			response.RemainingAmount = paymentEntity.RemainingAmount()
+		}
+		return response, nil
 	}
 
 	// If payment is expired, return expired status
 	if paymentEntity.IsExpired() {
 		// Update payment status to expired if not already marked
 		if paymentEntity.Status != entities.PaymentExpired {
-			paymentEntity.MarkAsExpired()
-			if err := uc.paymentRepo.UpdatePayment(ctx, paymentEntity); err != nil {
+			if err := uc.expirePayment(ctx, paymentEntity); err != nil {
 				uc.logger.Error("Failed to update expired payment", "error", err)
 			}
 		}
@@ -269,7 +415,16 @@ func (uc *PaymentUseCase) GetPaymentStatus(ctx context.Context, transactionID st
 		}, nil
 	}
 
-	// For pending payments, check status with Midtrans
+	// Local QRIS isn't known to any gateway, only the cashier can confirm it
+	if paymentEntity.Provider == entities.ProviderLocalQRIS {
+		return &PaymentStatusResponse{
+			TransactionID: transactionID,
+			Status:        entities.PaymentPending,
+			Message:       "Payment is pending. Waiting for the cashier to confirm the transfer.",
+		}, nil
+	}
+
+	// For pending payments, check status with the gateway that issued the QRIS
 	// Use the stored OrderID from payment entity
 	orderID := paymentEntity.OrderID
 	if orderID == "" {
@@ -282,85 +437,73 @@ func (uc *PaymentUseCase) GetPaymentStatus(ctx context.Context, transactionID st
 		}, nil
 	}
 
-	// Check status with Midtrans
-	midtransStatus, err := uc.midtransClient.GetTransactionStatus(ctx, orderID)
+	gateway, err := uc.gateways.For(ctx, paymentEntity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check status with the gateway
+	gatewayStatus, err := gateway.GetTransactionStatus(ctx, orderID)
 	if err != nil {
-		uc.logger.Error("Failed to check Midtrans status", "error", err, "order_id", orderID)
+		uc.logger.Error("Failed to check gateway status", "error", err, "provider", gateway.Provider(), "order_id", orderID)
+		message := "Payment is pending. Waiting for customer to complete payment."
+		if errors.Is(err, appErrors.ErrGatewayUnavailable) {
+			message = "Payment gateway is unavailable, the status updates once it is reachable again."
+		}
 		return &PaymentStatusResponse{
 			TransactionID: transactionID,
 			Status:        entities.PaymentPending,
-			Message:       "Payment is pending. Waiting for customer to complete payment.",
+			Message:       message,
 		}, nil
 	}
 
-	// Update payment based on Midtrans status
-	var newStatus entities.PaymentStatus
-	switch midtransStatus.TransactionStatus {
-	case "settlement", "capture":
-		newStatus = entities.PaymentSuccess
-		paymentEntity.MarkAsSuccess(midtransStatus.TransactionID, midtransStatus.StatusMessage)
-
-		// Update transaction status
-		transaction, _ := uc.transactionRepo.GetByID(ctx, transactionID)
-		if transaction != nil {
-			transaction.MarkAsPaid()
-			uc.transactionRepo.Update(ctx, transaction)
-		}
-	case "pending":
-		newStatus = entities.PaymentPending
-	case "deny", "cancel", "expire":
-		newStatus = entities.PaymentFailed
-		paymentEntity.MarkAsFailed(midtransStatus.StatusMessage)
-	default:
-		newStatus = entities.PaymentPending
-	}
-
-	// Update payment in database
-	if err := uc.paymentRepo.UpdatePayment(ctx, paymentEntity); err != nil {
+	// Update payment based on gateway status
+	newStatus, err := uc.applyGatewayStatus(ctx, paymentEntity, entities.GatewayActor(paymentEntity.Provider), gatewayStatus.Status, gatewayStatus.TransactionID, gatewayStatus.StatusMessage)
+	if err != nil {
 		uc.logger.Error("Failed to update payment status", "error", err)
 	}
 
-	return &PaymentStatusResponse{
+	response := &PaymentStatusResponse{
 		TransactionID: transactionID,
 		Status:        newStatus,
-		ExternalID:    midtransStatus.TransactionID,
-		Message:       midtransStatus.StatusMessage,
-	}, nil
+		ExternalID:    gatewayStatus.TransactionID,
+		Message:       gatewayStatus.StatusMessage,
+	}
+	if newStatus == entities.PaymentSuccess {
+		response.RemainingAmount = paymentEntity.RemainingAmount()
+	}
+	return response, nil
 }
 
-// HandlePaymentNotification handles payment notifications from Midtrans
-func (uc *PaymentUseCase) HandlePaymentNotification(ctx context.Context, orderID string, status string, externalID string, response string) error {
-	// Since we shortened the order_id, we need to find payment by external_id (Midtrans transaction_id)
-	// which should be stored in the payment record
-	// For simplicity, we'll use the externalID parameter to find the payment
-	uc.logger.Info("Received payment notification", "order_id", orderID, "external_id", externalID, "status", status)
-
-	// Find payment by external_id - this would require adding a new method to repository
-	// For now, we'll need to extract what we can and handle accordingly
-	// The externalID from Midtrans should help us identify the payment
-
-	// For now, we'll use order_id to look up the QRIS code
-	// Order ID format: qris-{short_tx_id}-{timestamp}
-	// We can search QRIS codes by matching the order_id pattern
+// GetQRIS returns the QRIS payment of a transaction as it is now, so a client that reloads or reconnects
+// can resume its countdown from the server's seconds_remaining
+func (uc *PaymentUseCase) GetQRIS(ctx context.Context, transactionID string) (*PaymentResponse, error) {
+	paymentEntity, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
 
-	// Temporary solution: Get all recent QRIS codes and match by external_id
-	// This is not optimal but works for MVP
-	// TODO: Add proper index and lookup by external_id or order_id
+	if paymentEntity.Method != entities.PaymentMethodQRIS {
+		return nil, appErrors.ErrPaymentNotFound
+	}
 
-	// For now, just log and return - webhook implementation can be done later
-	uc.logger.Warn("Payment notification received but lookup not fully implemented",
-		"order_id", orderID,
-		"external_id", externalID,
-		"status", status)
+	qrCodeEntity, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, paymentEntity.ID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
 
-	// Return nil to acknowledge receipt
-	uc.logger.Info("Payment notification acknowledged", "order_id", orderID, "status", status)
-	return nil
+	return uc.mapPaymentToResponse(paymentEntity, qrCodeEntity), nil
 }
 
-// RefreshQRIS refreshes an expired QRIS code
-func (uc *PaymentUseCase) RefreshQRIS(ctx context.Context, transactionID string) (*PaymentResponse, error) {
-	// Get existing payment
+// RenderQRIS renders the stored EMVCo string of the QRIS of a transaction as an image. Only a QRIS that
+// can still be paid is rendered.
+func (uc *PaymentUseCase) RenderQRIS(ctx context.Context, transactionID string, req *QRISImageRequest) (*QRISImage, error) {
 	paymentEntity, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -368,175 +511,1302 @@ func (uc *PaymentUseCase) RefreshQRIS(ctx context.Context, transactionID string)
 		}
 		return nil, err
 	}
-
-	// Check if payment can be refreshed (expired or about to expire)
-	if paymentEntity.Status != entities.PaymentPending && paymentEntity.Status != entities.PaymentExpired {
-		return nil, fmt.Errorf("payment cannot be refreshed: current status is %s", paymentEntity.Status)
+	if paymentEntity.Method != entities.PaymentMethodQRIS {
+		return nil, appErrors.ErrPaymentNotFound
+	}
+	if paymentEntity.Status == entities.PaymentSuccess {
+		return nil, appErrors.ErrAlreadyPaid
 	}
 
-	// Get transaction with details for User and Items
-	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	qrCodeEntity, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, paymentEntity.ID)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, appErrors.ErrTransactionNotFound
+			return nil, appErrors.ErrPaymentNotFound
 		}
 		return nil, err
 	}
-
-	// If payment is not expired yet, check if it's close to expiry (within 2 minutes)
-	if paymentEntity.Status == entities.PaymentPending && !paymentEntity.IsExpired() {
-		// For simplicity, we'll allow refresh anytime - in production you might want to restrict this
-		// based on time to expiry
+	if !paymentEntity.CanBeProcessed() || qrCodeEntity.IsExpired() {
+		return nil, appErrors.ErrQRISExpired
 	}
 
-	// Generate new QRIS via Midtrans
-	// Use short transaction ID (first 8 chars) to keep order_id under 50 chars limit
-	shortTxID := transactionID
-	if len(shortTxID) > 8 {
-		shortTxID = shortTxID[:8]
+	image := &QRISImage{
+		Format:    req.Format,
+		ExpiresAt: qrCodeEntity.ExpiresAt,
 	}
-	now := time.Now()
-	orderID := fmt.Sprintf("qris-%s-%d", shortTxID, now.Unix())
-
-	// Store order_id in payment entity for status checking
-	paymentEntity.OrderID = orderID
-
-	qrisReq := payment.QRISRequest{
-		TransactionID:  transactionID,
-		OrderID:        orderID,
-		GrossAmount:    transaction.TotalAmount, // Use transaction total (includes tax & discount)
-		CustomerName:   transaction.User.Name,
-		CustomerEmail:  transaction.User.Email,
-		Items:          uc.mapTransactionItemsToQRISItems(transaction),
-		ExpiryDuration: uc.defaultExpiryMin,
+	switch req.Format {
+	case "svg":
+		image.ContentType = "image/svg+xml"
+		image.Data, err = uc.qrCodeGenerator.GenerateQRCodeSVG(qrCodeEntity.QRCode, req.Size)
+	case "data-uri":
+		image.ContentType = "application/json"
+		image.DataURI, err = uc.qrCodeGenerator.GenerateQRCodeDataURI(qrCodeEntity.QRCode, req.Size)
+	default:
+		image.ContentType = "image/png"
+		image.Data, err = uc.qrCodeGenerator.GenerateQRCode(qrCodeEntity.QRCode, req.Size)
 	}
-
-	qrisResponse, err := uc.midtransClient.GenerateQRIS(ctx, qrisReq)
 	if err != nil {
-		uc.logger.Error("Failed to generate new QRIS via Midtrans", "error", err)
-		return nil, fmt.Errorf("failed to generate QRIS: %w", err)
+		return nil, err
 	}
 
-	// Update payment expiry using the same 'now' used for order_id
-	newExpiry := now.Add(time.Duration(uc.defaultExpiryMin) * time.Minute)
-	paymentEntity.ExpiresAt = newExpiry
-	paymentEntity.Status = entities.PaymentPending
-	paymentEntity.ExternalID = "" // Clear previous external ID
-	paymentEntity.ExternalResponse = ""
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s", qrCodeEntity.QRCode, req.Size, req.Format)))
+	image.ETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	return image, nil
+}
 
-	// Get existing QRIS code or create new one
-	qrCodeEntity, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, paymentEntity.ID)
-	if err != nil {
-		if err != gorm.ErrRecordNotFound {
-			return nil, err
-		}
-		// Create new QRIS code
-		qrCodeEntity = entities.NewQRISCode(
-			transactionID,
-			paymentEntity.ID,
-			qrisResponse.QRString,
-			qrisResponse.URL,
-			uc.defaultExpiryMin,
-		)
-	} else {
-		// Update existing QRIS code
-		qrCodeEntity.QRCode = qrisResponse.QRString
-		qrCodeEntity.URL = qrisResponse.URL
-		qrCodeEntity.ExpiresAt = newExpiry
+// GetPaymentStatuses checks the payments of several transactions at once. Pending payments are requeried
+// on their gateway in parallel, capped so a large batch can't flood the gateway. Results keep the request order.
+func (uc *PaymentUseCase) GetPaymentStatuses(ctx context.Context, req *BatchPaymentStatusRequest) *BatchPaymentStatusResponse {
+	concurrency := uc.config.StatusCheckConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
 	}
 
-	// Update payment
-	if err := uc.paymentRepo.UpdatePayment(ctx, paymentEntity); err != nil {
-		uc.logger.Error("Failed to update payment", "error", err)
-		return nil, err
-	}
+	results := make([]PaymentStatusResult, len(req.TransactionIDs))
+	checked := make(map[string]int, len(req.TransactionIDs))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
 
-	// Update or create QRIS code
-	if qrCodeEntity.ID == "" {
-		// New QRIS code
-		if err := uc.paymentRepo.CreateQRISCode(ctx, qrCodeEntity); err != nil {
-			uc.logger.Error("Failed to create QRIS code", "error", err)
-			return nil, err
-		}
-	} else {
-		// Existing QRIS code
-		if err := uc.paymentRepo.UpdateQRISCode(ctx, qrCodeEntity); err != nil {
-			uc.logger.Error("Failed to update QRIS code", "error", err)
-			return nil, err
+	for i, transactionID := range req.TransactionIDs {
+		// Duplicate IDs are checked once and copied afterwards
+		if _, seen := checked[transactionID]; seen {
+			continue
 		}
+		checked[transactionID] = i
+
+		wg.Add(1)
+		go func(i int, transactionID string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			results[i] = uc.checkPaymentStatus(ctx, transactionID)
+		}(i, transactionID)
 	}
+	wg.Wait()
 
-	uc.logger.Info("QRIS refreshed successfully", "transaction_id", transactionID, "payment_id", paymentEntity.ID)
+	for i, transactionID := range req.TransactionIDs {
+		if first := checked[transactionID]; first != i {
+			results[i] = results[first]
+		}
+	}
 
-	return uc.mapPaymentToResponse(paymentEntity, qrCodeEntity), nil
+	return &BatchPaymentStatusResponse{Results: results}
 }
 
-// Helper methods
-func (uc *PaymentUseCase) mapTransactionItemsToQRISItems(transaction *entities.Transaction) []payment.QRISItem {
-	var qrisItems []payment.QRISItem
-
-	// Add product items
-	for _, item := range transaction.Items {
-		qrisItems = append(qrisItems, payment.QRISItem{
-			ID:       item.ProductID,
-			Name:     item.Product.Name,
-			Price:    item.UnitPrice,
-			Quantity: item.Quantity,
-		})
+func (uc *PaymentUseCase) checkPaymentStatus(ctx context.Context, transactionID string) PaymentStatusResult {
+	if ctx.Err() != nil {
+		return PaymentStatusResult{TransactionID: transactionID, Error: ctx.Err().Error()}
 	}
 
-	// Add tax as a line item if present
-	if transaction.TaxAmount > 0 {
-		qrisItems = append(qrisItems, payment.QRISItem{
-			ID:       "TAX",
-			Name:     "Tax",
-			Price:    transaction.TaxAmount,
-			Quantity: 1,
-		})
+	status, err := uc.GetPaymentStatus(ctx, transactionID)
+	if err != nil {
+		return PaymentStatusResult{TransactionID: transactionID, Error: err.Error()}
 	}
 
-	// Add discount as negative line item if present
-	if transaction.Discount > 0 {
-		qrisItems = append(qrisItems, payment.QRISItem{
-			ID:       "DISCOUNT",
-			Name:     "Discount",
-			Price:    -transaction.Discount, // Negative to reduce total
-			Quantity: 1,
-		})
+	return PaymentStatusResult{
+		TransactionID: transactionID,
+		Status:        status.Status,
+		ExternalID:    status.ExternalID,
+		Message:       status.Message,
 	}
-
-	return qrisItems
 }
 
-func (uc *PaymentUseCase) mapPaymentToResponse(payment *entities.Payment, qrisCode *entities.QRISCode) *PaymentResponse {
-	response := &PaymentResponse{
-		ID:            payment.ID,
-		TransactionID: payment.TransactionID,
-		Amount:        payment.Amount,
-		Method:        payment.Method,
-		Status:        payment.Status,
-		ExternalID:    payment.ExternalID,
-		ExpiresAt:     payment.ExpiresAt.Format(time.RFC3339),
-		CreatedAt:     payment.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:     payment.UpdatedAt.Format(time.RFC3339),
+// PayWithEWallet charges a transaction to GoPay or ShopeePay through Midtrans and returns the deeplink
+// that opens the wallet app. The payment then settles like a gateway QRIS: by webhook, status polling
+// or reconciliation.
+func (uc *PaymentUseCase) PayWithEWallet(ctx context.Context, req *EWalletPaymentRequest) (*PaymentResponse, error) {
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, req.TransactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
 	}
 
-	if payment.PaidAt != nil {
-		paidAt := payment.PaidAt.Format(time.RFC3339)
-		response.PaidAt = &paidAt
+	if transaction.Status != entities.StatusPending {
+		return nil, fmt.Errorf("transaction is not in pending status")
 	}
-
-	if qrisCode != nil {
-		response.QRISCode = &QRISCodeResponse{
-			ID:            qrisCode.ID,
-			TransactionID: qrisCode.TransactionID,
-			PaymentID:     qrisCode.PaymentID,
-			QRCode:        qrisCode.QRCode,
-			URL:           qrisCode.URL,
-			ExpiresAt:     qrisCode.ExpiresAt.Format(time.RFC3339),
-			CreatedAt:     qrisCode.CreatedAt.Format(time.RFC3339),
-		}
+	if transaction.IsHeld() {
+		return nil, appErrors.ErrTransactionHeld
+	}
+	// GoPay and ShopeePay charge in rupiah only
+	if transaction.Currency != entities.CurrencyIDR {
+		return nil, appErrors.ErrUnsupportedCurrency
+	}
+	// E-wallets charge the whole balance, a deposit is taken with a QRIS
+	amountDue, err := uc.amountDue(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.reserveStock(ctx, transaction); err != nil {
+		return nil, err
 	}
 
-	return response
+	// Training sales never reach Midtrans
+	provider := entities.ProviderMidtrans
+	if transaction.IsTraining {
+		provider = entities.ProviderTraining
+	}
+	gateway, err := uc.gateways.ForStore(ctx, transaction.StoreID, provider)
+	if err != nil {
+		return nil, err
+	}
+	walletGateway, ok := gateway.(payment.EWalletGateway)
+	if !ok {
+		return nil, appErrors.ErrEWalletUnavailable
+	}
+
+	existingPayment, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, req.TransactionID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if existingPayment != nil && existingPayment.CanBeProcessed() && existingPayment.Wallet == req.Wallet {
+		existingQRIS, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, existingPayment.ID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		return uc.mapPaymentToResponse(existingPayment, existingQRIS), nil
+	}
+
+	// Only one payment can be pending, so an open QRIS or charge to the other wallet is cancelled first
+	if err := uc.cancelPendingPayment(ctx, req.TransactionID); err != nil {
+		return nil, err
+	}
+
+	expiryMinutes := req.ExpiryMinutes
+	if expiryMinutes <= 0 {
+		expiryMinutes = uc.settings.QRISExpiryMinutes()
+	}
+
+	shortTxID := req.TransactionID
+	if len(shortTxID) > 8 {
+		shortTxID = shortTxID[:8]
+	}
+	orderID := fmt.Sprintf("%s-%s-%d", req.Wallet, shortTxID, time.Now().Unix())
+
+	customer := uc.gatewayCustomer(transaction)
+	charge, err := walletGateway.ChargeEWallet(ctx, payment.EWalletRequest{
+		QRISRequest: payment.QRISRequest{
+			TransactionID:  req.TransactionID,
+			OrderID:        orderID,
+			GrossAmount:    amountDue,
+			CustomerName:   customer.Name,
+			CustomerEmail:  customer.Email,
+			CustomerPhone:  customer.Phone,
+			Items:          uc.qrisItems(transaction, amountDue, amountDue),
+			ExpiryDuration: expiryMinutes,
+		},
+		Wallet:      req.Wallet,
+		CallbackURL: req.CallbackURL,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to charge e-wallet", "error", err, "wallet", req.Wallet, "transaction_id", req.TransactionID)
+		return nil, fmt.Errorf("failed to charge %s: %w", req.Wallet, err)
+	}
+
+	paymentEntity := entities.NewEWalletPayment(req.TransactionID, amountDue, req.Wallet, expiryMinutes)
+	paymentEntity.AmountDue = amountDue
+	paymentEntity.StoreID = transaction.StoreID
+	paymentEntity.Currency = transaction.Currency
+	paymentEntity.IsTraining = transaction.IsTraining
+	paymentEntity.Provider = gateway.Provider()
+	paymentEntity.OrderID = charge.OrderID
+	paymentEntity.DeeplinkURL = charge.DeeplinkURL
+
+	err = paymentEntity.RecordCreation(uc.requestActor(ctx))
+	if err == nil {
+		err = uc.paymentRepo.CreatePayment(ctx, paymentEntity)
+	}
+	if err != nil {
+		uc.logger.Error("Failed to create payment record", "error", err, "transaction_id", req.TransactionID)
+		if cancelErr := gateway.CancelTransaction(ctx, charge.OrderID); cancelErr != nil {
+			uc.logger.Warn("Failed to cancel e-wallet charge on the gateway", "error", cancelErr, "order_id", charge.OrderID)
+		}
+		return nil, err
+	}
+
+	// The wallet's QRIS, when it offers one, is kept like any other QRIS so it can be shown again
+	var qrCodeEntity *entities.QRISCode
+	if charge.QRString != "" || charge.QRImageURL != "" {
+		qrCodeEntity = entities.NewQRISCode(req.TransactionID, paymentEntity.ID, charge.QRString, charge.QRImageURL, expiryMinutes)
+		if err := uc.paymentRepo.CreateQRISCode(ctx, qrCodeEntity); err != nil {
+			// The deeplink still works without it
+			uc.logger.Warn("Failed to store e-wallet QRIS", "error", err, "payment_id", paymentEntity.ID)
+			qrCodeEntity = nil
+		}
+	}
+
+	uc.logger.Info("E-wallet charge created", "transaction_id", req.TransactionID, "payment_id", paymentEntity.ID, "wallet", req.Wallet)
+	return uc.mapPaymentToResponse(paymentEntity, qrCodeEntity), nil
+}
+
+// ReconcilePendingPayments requeries pending gateway payments older than the reconcile grace period, settling
+// those whose webhook never arrived. It is meant to be called periodically by the scheduler and only fails
+// when the gateways couldn't be reached at all, so a run that checked nothing still counts as successful.
+func (uc *PaymentUseCase) ReconcilePendingPayments(ctx context.Context) error {
+	now := time.Now()
+	payments, err := uc.paymentRepo.ListUnsettledGatewayPayments(ctx, now.Add(-reconcileGracePeriod), now, reconcileBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var settled, failed int
+	var lastErr error
+	for i := range payments {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		paymentEntity := &payments[i]
+		gateway, err := uc.gateways.For(ctx, paymentEntity)
+		if err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+
+		gatewayStatus, err := gateway.GetTransactionStatus(ctx, paymentEntity.OrderID)
+		if err != nil {
+			failed++
+			lastErr = err
+			uc.logger.Warn("Failed to reconcile payment", "error", err, "payment_id", paymentEntity.ID, "provider", gateway.Provider())
+			continue
+		}
+
+		newStatus, err := uc.applyGatewayStatus(ctx, paymentEntity, entities.GatewayActor(paymentEntity.Provider), gatewayStatus.Status, gatewayStatus.TransactionID, gatewayStatus.StatusMessage)
+		if err != nil {
+			failed++
+			lastErr = err
+			uc.logger.Error("Failed to update reconciled payment", "error", err, "payment_id", paymentEntity.ID)
+			continue
+		}
+		if newStatus != entities.PaymentPending {
+			settled++
+		}
+	}
+
+	if settled > 0 {
+		uc.logger.Info("Reconciled pending payments", "checked", len(payments), "settled", settled)
+	}
+	if failed > 0 && failed == len(payments) {
+		return fmt.Errorf("failed to reconcile %d pending payments: %w", failed, lastErr)
+	}
+	return nil
+}
+
+// PayWithCash settles a transaction with cash and returns the change due
+func (uc *PaymentUseCase) PayWithCash(ctx context.Context, req *CashPaymentRequest) (*PaymentResponse, error) {
+	return uc.settleOffline(ctx, req.TransactionID, func(amount int64) (*entities.Payment, error) {
+		return entities.NewCashPayment(req.TransactionID, amount, req.AmountTendered)
+	})
+}
+
+// PayWithManualMethod settles a transaction with a card or other method confirmed at the terminal
+func (uc *PaymentUseCase) PayWithManualMethod(ctx context.Context, req *ManualPaymentRequest) (*PaymentResponse, error) {
+	return uc.settleOffline(ctx, req.TransactionID, func(amount int64) (*entities.Payment, error) {
+		return entities.NewManualPayment(req.TransactionID, amount, req.Method, req.Reference)
+	})
+}
+
+// RecordOfflinePayment settles a transaction with a payment the terminal took while offline, dated
+// when it was taken
+func (uc *PaymentUseCase) RecordOfflinePayment(ctx context.Context, req *OfflinePaymentRequest) (*PaymentResponse, error) {
+	return uc.settleOffline(ctx, req.TransactionID, func(amount int64) (*entities.Payment, error) {
+		var paymentEntity *entities.Payment
+		var err error
+		if req.Method == entities.PaymentMethodCash {
+			paymentEntity, err = entities.NewCashPayment(req.TransactionID, amount, max(req.AmountTendered, amount))
+		} else {
+			paymentEntity, err = entities.NewManualPayment(req.TransactionID, amount, req.Method, req.Reference)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !req.PaidAt.IsZero() {
+			paymentEntity.PaidAt = &req.PaidAt
+		}
+		return paymentEntity, nil
+	})
+}
+
+// SubscribePaymentStatus returns a channel of payment status changes for a transaction.
+// The returned function must be called to release the subscription.
+func (uc *PaymentUseCase) SubscribePaymentStatus(transactionID string) (<-chan events.Event, func()) {
+	return uc.eventBroker.Subscribe(events.ForTransaction(transactionID))
+}
+
+// HandlePaymentNotification handles payment notifications from the gateways
+func (uc *PaymentUseCase) HandlePaymentNotification(ctx context.Context, notification *PaymentNotification) error {
+	orderID := notification.OrderID
+	status := notification.TransactionStatus
+	uc.logger.Info("Received payment notification", "order_id", orderID, "external_id", notification.ExternalID, "status", status)
+
+	paid := isGatewaySuccess(status)
+
+	// The order_id is stored on the payment when the QRIS is generated or refreshed
+	paymentEntity, err := uc.paymentRepo.GetPaymentByOrderID(ctx, orderID)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		// A superseded order ID (e.g. an old QR after a refresh) that was paid anyway still took the customer's money
+		if paid {
+			superseded, err := uc.supersededOrderPayment(ctx, notification)
+			if err != nil {
+				return err
+			}
+			return uc.recordPaymentException(ctx, entities.ExceptionUnknownOrder, superseded, notification)
+		}
+		uc.logger.Warn("Payment notification for unknown order", "order_id", orderID, "status", status)
+		return nil
+	}
+
+	switch paymentEntity.Status {
+	case entities.PaymentSuccess:
+		if paid && notification.ExternalID != "" && paymentEntity.ExternalID != "" && notification.ExternalID != paymentEntity.ExternalID {
+			return uc.recordPaymentException(ctx, entities.ExceptionDuplicatePayment, paymentEntity, notification)
+		}
+		uc.logger.Info("Payment already settled, ignoring notification", "order_id", orderID, "payment_id", paymentEntity.ID)
+		return nil
+	case entities.PaymentFailed, entities.PaymentExpired, entities.PaymentCancelled:
+		if paid {
+			return uc.recordPaymentException(ctx, entities.ExceptionLatePayment, paymentEntity, notification)
+		}
+		uc.logger.Info("Payment already finalized, ignoring notification",
+			"order_id", orderID,
+			"payment_id", paymentEntity.ID,
+			"payment_status", paymentEntity.Status)
+		return nil
+	}
+
+	// The payment is pending but the sale itself may have been cancelled or expired meanwhile
+	if paid {
+		transaction, err := uc.transactionRepo.GetByID(ctx, paymentEntity.TransactionID)
+		if err != nil {
+			return err
+		}
+		if transaction.Status != entities.StatusPending {
+			return uc.recordPaymentException(ctx, entities.ExceptionLatePayment, paymentEntity, notification)
+		}
+	}
+
+	if _, err := uc.applyGatewayStatus(ctx, paymentEntity, entities.GatewayActor(paymentEntity.Provider), status, notification.ExternalID, notification.RawResponse); err != nil {
+		return err
+	}
+
+	uc.logger.Info("Payment notification processed", "order_id", orderID, "payment_id", paymentEntity.ID, "status", paymentEntity.Status)
+	return nil
+}
+
+// ListPaymentHistory returns the status changes of a payment, from its creation on
+func (uc *PaymentUseCase) ListPaymentHistory(ctx context.Context, paymentID string) ([]entities.PaymentEvent, error) {
+	if _, err := uc.paymentRepo.GetPaymentByID(ctx, paymentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return uc.paymentRepo.ListPaymentEvents(ctx, paymentID)
+}
+
+// ListPaymentExceptions returns the exceptions queue
+func (uc *PaymentUseCase) ListPaymentExceptions(ctx context.Context, filters *PaymentExceptionFilters) ([]entities.PaymentException, error) {
+	exceptions, err := uc.exceptionRepo.List(ctx, repositories.PaymentExceptionFilters{
+		Status: entities.PaymentExceptionStatus(filters.Status),
+		Reason: entities.PaymentExceptionReason(filters.Reason),
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to list payment exceptions", "error", err)
+		return nil, err
+	}
+	return exceptions, nil
+}
+
+// ResolvePaymentException marks an exception as refunded or acknowledged
+func (uc *PaymentUseCase) ResolvePaymentException(ctx context.Context, id, userID string, req *ResolvePaymentExceptionRequest) (*entities.PaymentException, error) {
+	exception, err := uc.exceptionRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrPaymentExceptionNotFound
+		}
+		return nil, err
+	}
+
+	if err := exception.Resolve(req.Status, userID, req.Note); err != nil {
+		return nil, err
+	}
+
+	if err := uc.exceptionRepo.Update(ctx, exception); err != nil {
+		uc.logger.Error("Failed to resolve payment exception", "error", err, "exception_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Payment exception resolved", "exception_id", id, "status", exception.Status, "resolved_by", userID)
+	return exception, nil
+}
+
+// RefreshQRIS refreshes an expired QRIS code
+func (uc *PaymentUseCase) RefreshQRIS(ctx context.Context, transactionID string) (*PaymentResponse, error) {
+	// Get existing payment
+	paymentEntity, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	if paymentEntity.Method != entities.PaymentMethodQRIS {
+		return nil, fmt.Errorf("only QRIS payments can be refreshed, charge the %s again instead", paymentEntity.Method)
+	}
+
+	// Check if payment can be refreshed (expired or about to expire)
+	if paymentEntity.Status != entities.PaymentPending && paymentEntity.Status != entities.PaymentExpired {
+		return nil, fmt.Errorf("payment cannot be refreshed: current status is %s", paymentEntity.Status)
+	}
+
+	// Get transaction with details for User and Items
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if transaction.IsHeld() {
+		return nil, appErrors.ErrTransactionHeld
+	}
+
+	// The balance may have changed since the QRIS was issued, a deposit keeps its amount when the balance still covers it
+	amountDue, err := uc.amountDue(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+	amount := paymentEntity.Amount
+	if !paymentEntity.IsDeposit() || amount > amountDue {
+		amount = amountDue
+	}
+	if err := uc.validateQRISAmount(amount); err != nil {
+		return nil, err
+	}
+	if err := uc.reserveStock(ctx, transaction); err != nil {
+		return nil, err
+	}
+	paymentEntity.Amount = amount
+	paymentEntity.AmountDue = amountDue
+
+	// If payment is not expired yet, check if it's close to expiry (within 2 minutes)
+	if paymentEntity.Status == entities.PaymentPending && !paymentEntity.IsExpired() {
+		// For simplicity, we'll allow refresh anytime - in production you might want to restrict this
+		// based on time to expiry
+	}
+
+	now := time.Now()
+	orderID := newOrderID(transactionID, now)
+
+	// Store order_id in payment entity for status checking
+	paymentEntity.OrderID = orderID
+
+	expiryMinutes := uc.settings.QRISExpiryMinutes()
+	customer := uc.gatewayCustomer(transaction)
+	qrisReq := payment.QRISRequest{
+		TransactionID:  transactionID,
+		OrderID:        orderID,
+		GrossAmount:    amount,
+		CustomerName:   customer.Name,
+		CustomerEmail:  customer.Email,
+		CustomerPhone:  customer.Phone,
+		Items:          uc.qrisItems(transaction, amount, amountDue),
+		ExpiryDuration: expiryMinutes,
+	}
+
+	qrisResponse, provider, err := uc.issueQRIS(ctx, qrisReq, transaction)
+	if err != nil {
+		uc.logger.Error("Failed to generate new QRIS", "error", err, "provider", provider)
+		return nil, fmt.Errorf("failed to generate QRIS: %w", err)
+	}
+	paymentEntity.Provider = provider
+	paymentEntity.OrderID = qrisResponse.OrderID // Some gateways identify the order by their own ID
+
+	// Update payment expiry using the same 'now' used for order_id
+	newExpiry := now.Add(time.Duration(expiryMinutes) * time.Minute)
+	paymentEntity.ExpiresAt = newExpiry
+	if err := paymentEntity.Reissue(uc.requestActor(ctx), "QRIS refreshed"); err != nil {
+		return nil, err
+	}
+
+	// Get existing QRIS code or create new one
+	qrCodeEntity, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, paymentEntity.ID)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		// Create new QRIS code
+		qrCodeEntity = entities.NewQRISCode(
+			transactionID,
+			paymentEntity.ID,
+			qrisResponse.QRString,
+			qrisResponse.URL,
+			expiryMinutes,
+		)
+	} else {
+		// Update existing QRIS code
+		qrCodeEntity.QRCode = qrisResponse.QRString
+		qrCodeEntity.URL = qrisResponse.URL
+		qrCodeEntity.ExpiresAt = newExpiry
+	}
+
+	// Update payment
+	if err := uc.paymentRepo.UpdatePayment(ctx, paymentEntity); err != nil {
+		uc.logger.Error("Failed to update payment", "error", err)
+		return nil, err
+	}
+
+	// Update or create QRIS code
+	if qrCodeEntity.ID == "" {
+		// New QRIS code
+		if err := uc.paymentRepo.CreateQRISCode(ctx, qrCodeEntity); err != nil {
+			uc.logger.Error("Failed to create QRIS code", "error", err)
+			return nil, err
+		}
+	} else {
+		// Existing QRIS code
+		if err := uc.paymentRepo.UpdateQRISCode(ctx, qrCodeEntity); err != nil {
+			uc.logger.Error("Failed to update QRIS code", "error", err)
+			return nil, err
+		}
+	}
+
+	uc.logger.Info("QRIS refreshed successfully", "transaction_id", transactionID, "payment_id", paymentEntity.ID)
+
+	return uc.mapPaymentToResponse(paymentEntity, qrCodeEntity), nil
+}
+
+// ConfirmLocalQRIS settles a local QRIS payment once the cashier has seen the transfer arrive.
+// Without a gateway there is no notification, so the reference from the merchant's banking app
+// is kept as the external ID to reconcile against the settlement statement. Training payments
+// are settled the same way.
+func (uc *PaymentUseCase) ConfirmLocalQRIS(ctx context.Context, transactionID, userID string, req *ConfirmQRISRequest) (*PaymentResponse, error) {
+	paymentEntity, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	if paymentEntity.Provider != entities.ProviderLocalQRIS && paymentEntity.Provider != entities.ProviderTraining {
+		return nil, appErrors.ErrNotLocalQRIS
+	}
+	if paymentEntity.Status == entities.PaymentSuccess {
+		return nil, appErrors.ErrAlreadyPaid
+	}
+	// The payer may finish the transfer just after the code expires, so expired payments can still be confirmed
+	if paymentEntity.Status != entities.PaymentPending && paymentEntity.Status != entities.PaymentExpired {
+		return nil, fmt.Errorf("payment cannot be confirmed: current status is %s", paymentEntity.Status)
+	}
+
+	rawResponse, _ := json.Marshal(map[string]string{
+		"reference":    req.Reference,
+		"confirmed_by": userID,
+		"confirmed_at": time.Now().Format(time.RFC3339),
+	})
+	if _, err := uc.applyGatewayStatus(ctx, paymentEntity, entities.UserActor(userID), payment.StatusSettlement, req.Reference, string(rawResponse)); err != nil {
+		uc.logger.Error("Failed to confirm local QRIS payment", "error", err, "transaction_id", transactionID)
+		return nil, err
+	}
+
+	uc.logger.Info("Local QRIS payment confirmed", "transaction_id", transactionID, "payment_id", paymentEntity.ID, "confirmed_by", userID)
+	return uc.mapPaymentToResponse(paymentEntity, nil), nil
+}
+
+// Helper methods
+
+// duplicatePaymentError carries the pending payment of a transaction, with its QRIS when it has one
+func (uc *PaymentUseCase) duplicatePaymentError(ctx context.Context, existing *entities.Payment) error {
+	existingQRIS, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, existing.ID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	return &appErrors.DuplicatePaymentError{
+		TransactionID: existing.TransactionID,
+		Payment:       uc.mapPaymentToResponse(existing, existingQRIS),
+	}
+}
+
+// qrisTransaction loads a transaction a QRIS is about to be generated for and checks it can be paid with one
+func (uc *PaymentUseCase) qrisTransaction(ctx context.Context, transactionID string) (*entities.Transaction, error) {
+	// Use GetByIDWithDetails to preload User and Items
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	// Check if transaction is in pending status
+	if transaction.Status == entities.StatusExpired {
+		return nil, appErrors.ErrTransactionExpired
+	}
+	if transaction.Status != entities.StatusPending {
+		return nil, fmt.Errorf("transaction is not in pending status")
+	}
+	if transaction.IsHeld() {
+		return nil, appErrors.ErrTransactionHeld
+	}
+	// QRIS settles in rupiah only, other currencies pay by cash or card
+	if transaction.Currency != entities.CurrencyIDR {
+		return nil, appErrors.ErrUnsupportedCurrency
+	}
+
+	return transaction, nil
+}
+
+// qrisAmount returns what a QRIS charges for the transaction and the balance due. The requested amount
+// defaults to the balance, less takes a deposit. QRIS has regulatory min/max amounts; beyond them another
+// payment method, or a smaller deposit, is required.
+func (uc *PaymentUseCase) qrisAmount(ctx context.Context, transaction *entities.Transaction, requested int64) (int64, int64, error) {
+	amountDue, err := uc.amountDue(ctx, transaction)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	amount := requested
+	if amount == 0 {
+		amount = amountDue
+	}
+	if amount > amountDue {
+		return 0, 0, fmt.Errorf("%w: %d requested, %d due", appErrors.ErrAmountExceedsDue, amount, amountDue)
+	}
+	if err := uc.validateQRISAmount(amount); err != nil {
+		return 0, 0, err
+	}
+	return amount, amountDue, nil
+}
+
+// amountDue is the balance of a transaction, its total less the deposits already paid
+func (uc *PaymentUseCase) amountDue(ctx context.Context, transaction *entities.Transaction) (int64, error) {
+	paid, err := uc.paymentRepo.GetPaidAmount(ctx, transaction.ID)
+	if err != nil {
+		return 0, err
+	}
+	if paid >= transaction.TotalAmount && paid > 0 {
+		return 0, appErrors.ErrAlreadyPaid
+	}
+	return transaction.TotalAmount - paid, nil
+}
+
+// pendingQRISError returns a duplicate payment error when the transaction already has a QRIS that can be paid
+func (uc *PaymentUseCase) pendingQRISError(ctx context.Context, transactionID string) error {
+	existingPayment, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	if existingPayment.CanBeProcessed() && existingPayment.Method == entities.PaymentMethodQRIS {
+		return uc.duplicatePaymentError(ctx, existingPayment)
+	}
+	return nil
+}
+
+// issueQRIS creates the QRIS for an order, through the active gateway with the account of the store or,
+// in local mode, by stamping the amount and order ID into the merchant's own static QRIS. Training
+// orders get a simulated one.
+func (uc *PaymentUseCase) issueQRIS(ctx context.Context, req payment.QRISRequest, transaction *entities.Transaction) (*payment.QRISResponse, entities.PaymentProvider, error) {
+	if transaction.IsTraining {
+		gateway, err := uc.gateways.Get(entities.ProviderTraining)
+		if err != nil {
+			return nil, entities.ProviderTraining, err
+		}
+		response, err := gateway.GenerateQRIS(ctx, req)
+		return response, gateway.Provider(), err
+	}
+
+	if uc.config.QRISMode != "local" {
+		gateway, err := uc.gateways.ActiveFor(ctx, transaction.StoreID)
+		if err != nil {
+			return nil, uc.gateways.Active().Provider(), err
+		}
+		response, err := gateway.GenerateQRIS(ctx, req)
+		return response, gateway.Provider(), err
+	}
+
+	if uc.localQRIS == nil {
+		return nil, entities.ProviderLocalQRIS, fmt.Errorf("local QRIS merchant is not configured")
+	}
+	dynamic, err := emvco.DynamicQRIS(uc.localQRIS, req.GrossAmount, req.OrderID)
+	if err != nil {
+		return nil, entities.ProviderLocalQRIS, err
+	}
+	qrString, err := dynamic.Encode()
+	if err != nil {
+		return nil, entities.ProviderLocalQRIS, err
+	}
+	return &payment.QRISResponse{OrderID: req.OrderID, QRString: qrString}, entities.ProviderLocalQRIS, nil
+}
+
+// localQRISTemplate is the merchant's printed static QRIS when configured, otherwise one built from its NMID
+func localQRISTemplate(cfg config.LocalQRISConfig) (emvco.Payload, error) {
+	if cfg.StaticPayload != "" {
+		return emvco.Parse(strings.TrimSpace(cfg.StaticPayload))
+	}
+	return emvco.StaticQRIS(emvco.Merchant{
+		NMID:         cfg.NMID,
+		Criteria:     cfg.Criteria,
+		CategoryCode: cfg.CategoryCode,
+		Name:         cfg.MerchantName,
+		City:         cfg.MerchantCity,
+		PostalCode:   cfg.PostalCode,
+	})
+}
+
+// applyGatewayStatus maps a gateway status, in the Midtrans vocabulary, onto the payment and its transaction,
+// persists the change and publishes it to live subscribers.
+func (uc *PaymentUseCase) applyGatewayStatus(ctx context.Context, paymentEntity *entities.Payment, actor entities.PaymentActor, gatewayStatus, externalID, rawResponse string) (entities.PaymentStatus, error) {
+	previousStatus := paymentEntity.Status
+
+	var transaction *entities.Transaction
+	stockUnavailable := false
+	switch {
+	case isGatewaySuccess(gatewayStatus):
+		if err := paymentEntity.MarkAsSuccess(actor, gatewayStatus, externalID, rawResponse); err != nil {
+			return paymentEntity.Status, err
+		}
+
+		// Update transaction status
+		transaction, _ = uc.transactionRepo.GetByID(ctx, paymentEntity.TransactionID)
+		if transaction != nil {
+			// The money is taken either way, a cart that can't be fulfilled anymore is still paid and queued
+			// as an exception for an admin to hand the goods over some other way or refund the customer
+			if err := uc.reserveStock(ctx, transaction); err != nil {
+				if !errors.Is(err, appErrors.ErrInsufficientStock) {
+					return paymentEntity.Status, err
+				}
+				stockUnavailable = true
+			}
+			// A deposit leaves the sale open until the balance is paid
+			if paymentEntity.IsDeposit() {
+				if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+					uc.logger.Error("Failed to update transaction", "error", err, "transaction_id", transaction.ID)
+				}
+				transaction = nil
+			} else {
+				transaction.MarkAsPaid()
+				if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+					uc.logger.Error("Failed to mark transaction as paid", "error", err, "transaction_id", transaction.ID)
+				}
+			}
+		}
+	case gatewayStatus == payment.StatusDeny || gatewayStatus == payment.StatusCancel || gatewayStatus == payment.StatusExpire:
+		if err := paymentEntity.MarkAsFailed(actor, gatewayStatus, rawResponse); err != nil {
+			return paymentEntity.Status, err
+		}
+	default:
+		return entities.PaymentPending, nil
+	}
+
+	if err := uc.paymentRepo.UpdatePayment(ctx, paymentEntity); err != nil {
+		return paymentEntity.Status, err
+	}
+
+	if paymentEntity.Status != previousStatus {
+		uc.publishPaymentStatus(paymentEntity, gatewayStatus)
+		if paymentEntity.Status == entities.PaymentSuccess && transaction != nil {
+			uc.queueReceiptDeliveries(ctx, transaction)
+			uc.settleLoyaltyPoints(ctx, transaction)
+		}
+	}
+
+	if stockUnavailable {
+		notification := &PaymentNotification{
+			OrderID:     paymentEntity.OrderID,
+			ExternalID:  externalID,
+			GrossAmount: paymentEntity.Amount,
+			RawResponse: rawResponse,
+		}
+		if err := uc.recordPaymentException(ctx, entities.ExceptionStockUnavailable, paymentEntity, notification); err != nil {
+			return paymentEntity.Status, err
+		}
+	}
+
+	return paymentEntity.Status, nil
+}
+
+// expirePayment marks a pending payment past its expiry as expired
+func (uc *PaymentUseCase) expirePayment(ctx context.Context, paymentEntity *entities.Payment) error {
+	if err := paymentEntity.MarkAsExpired(entities.SystemActor("expiry")); err != nil {
+		return err
+	}
+	return uc.paymentRepo.UpdatePayment(ctx, paymentEntity)
+}
+
+// requestActor is who changes a payment within the request: its signed in user, or the API for requests
+// made without one
+func (uc *PaymentUseCase) requestActor(ctx context.Context) entities.PaymentActor {
+	if userID, ok := repositories.UserFromContext(ctx); ok {
+		return entities.UserActor(userID)
+	}
+	return entities.SystemActor("api")
+}
+
+// reserveStock takes the items of a pending sale out of stock when it holds no reservation yet, so no
+// QRIS is issued and no payment settles a cart that can't be sold. Carts normally reserve their stock
+// when they are created, practice sales leave it alone.
+func (uc *PaymentUseCase) reserveStock(ctx context.Context, transaction *entities.Transaction) error {
+	if transaction.IsTraining || transaction.StockReserved || transaction.Status != entities.StatusPending {
+		return nil
+	}
+
+	if err := uc.transactionRepo.ReserveStock(ctx, transaction.ID); err != nil {
+		if errors.Is(err, appErrors.ErrInsufficientStock) {
+			uc.logger.Warn("Stock unavailable for sale", "error", err, "transaction_id", transaction.ID)
+		}
+		return err
+	}
+	transaction.StockReserved = true
+	return nil
+}
+
+// settleOffline records a payment settled outside the gateway and marks the transaction as paid.
+// A pending QRIS for the transaction is cancelled first so it can't be paid twice.
+func (uc *PaymentUseCase) settleOffline(ctx context.Context, transactionID string, newPayment func(amount int64) (*entities.Payment, error)) (*PaymentResponse, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.Status != entities.StatusPending {
+		return nil, fmt.Errorf("transaction is not in pending status")
+	}
+	if transaction.IsHeld() {
+		return nil, appErrors.ErrTransactionHeld
+	}
+
+	amountDue, err := uc.amountDue(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+	paymentEntity, err := newPayment(amountDue)
+	if err != nil {
+		return nil, err
+	}
+	paymentEntity.AmountDue = amountDue
+	paymentEntity.StoreID = transaction.StoreID
+	paymentEntity.Currency = transaction.Currency
+	paymentEntity.IsTraining = transaction.IsTraining
+
+	if err := uc.cancelPendingPayment(ctx, transactionID); err != nil {
+		return nil, err
+	}
+
+	if err := paymentEntity.RecordCreation(uc.requestActor(ctx)); err != nil {
+		return nil, err
+	}
+	if err := uc.paymentRepo.CreatePayment(ctx, paymentEntity); err != nil {
+		uc.logger.Error("Failed to create payment record", "error", err, "transaction_id", transactionID)
+		return nil, err
+	}
+
+	if err := transaction.MarkAsPaid(); err != nil {
+		return nil, err
+	}
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		uc.logger.Error("Failed to mark transaction as paid", "error", err, "transaction_id", transactionID)
+		return nil, err
+	}
+
+	uc.publishPaymentStatus(paymentEntity, fmt.Sprintf("Paid with %s", paymentEntity.Method))
+	uc.queueReceiptDeliveries(ctx, transaction)
+	uc.settleLoyaltyPoints(ctx, transaction)
+	uc.logger.Info("Payment settled", "transaction_id", transactionID, "payment_id", paymentEntity.ID, "method", paymentEntity.Method)
+
+	return uc.mapPaymentToResponse(paymentEntity, nil), nil
+}
+
+// cancelPendingPayment cancels an open QRIS payment of the transaction, locally and on its gateway
+func (uc *PaymentUseCase) cancelPendingPayment(ctx context.Context, transactionID string) error {
+	existingPayment, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	switch existingPayment.Status {
+	case entities.PaymentSuccess:
+		// A deposit leaves the balance to pay
+		if existingPayment.IsDeposit() {
+			return nil
+		}
+		return appErrors.ErrAlreadyPaid
+	case entities.PaymentPending:
+	default:
+		return nil
+	}
+
+	if err := existingPayment.MarkAsCancelled(uc.requestActor(ctx), "Cancelled in favour of another payment method"); err != nil {
+		return err
+	}
+	if err := uc.paymentRepo.UpdatePayment(ctx, existingPayment); err != nil {
+		return err
+	}
+
+	if existingPayment.HasGatewayOrder() {
+		gateway, err := uc.gateways.For(ctx, existingPayment)
+		if err == nil {
+			err = gateway.CancelTransaction(ctx, existingPayment.OrderID)
+		}
+		if err != nil {
+			// A QRIS paid after this point is caught by the late payment exceptions queue
+			uc.logger.Warn("Failed to cancel QRIS order on the gateway", "error", err, "provider", existingPayment.Provider, "order_id", existingPayment.OrderID)
+		}
+	}
+
+	uc.publishPaymentStatus(existingPayment, "Cancelled in favour of another payment method")
+	return nil
+}
+
+// recordPaymentException queues money received for a closed or already paid payment.
+// Gateways retry notifications, so an exception is only recorded once per gateway transaction.
+func (uc *PaymentUseCase) recordPaymentException(ctx context.Context, reason entities.PaymentExceptionReason, paymentEntity *entities.Payment, notification *PaymentNotification) error {
+	if notification.ExternalID != "" {
+		if _, err := uc.exceptionRepo.GetByExternalID(ctx, notification.ExternalID); err == nil {
+			return nil
+		} else if err != gorm.ErrRecordNotFound {
+			return err
+		}
+	}
+
+	amount := notification.GrossAmount
+	if amount == 0 && paymentEntity != nil {
+		amount = paymentEntity.Amount
+	}
+
+	exception := entities.NewPaymentException(reason, notification.OrderID, notification.ExternalID, amount, notification.RawResponse)
+	if paymentEntity != nil {
+		exception.PaymentID = &paymentEntity.ID
+		exception.TransactionID = &paymentEntity.TransactionID
+	}
+
+	if err := uc.exceptionRepo.Create(ctx, exception); err != nil {
+		uc.logger.Error("Failed to record payment exception", "error", err, "order_id", notification.OrderID)
+		return err
+	}
+
+	uc.logger.Error("Unexpected payment received, refund or acknowledgement required",
+		"exception_id", exception.ID,
+		"reason", reason,
+		"order_id", notification.OrderID,
+		"external_id", notification.ExternalID,
+		"amount", amount)
+
+	if uc.eventBroker != nil {
+		transactionID := ""
+		if exception.TransactionID != nil {
+			transactionID = *exception.TransactionID
+		}
+		uc.eventBroker.Publish(events.Event{
+			Type:          events.EventPaymentException,
+			TransactionID: transactionID,
+			Data:          exception,
+		})
+	}
+
+	return nil
+}
+
+// supersededOrderPayment finds the payment a notified order was issued for before a refresh replaced it,
+// through the order mapping or the gateway transaction ID, so the exception names the transaction the
+// customer paid for. Nil when the order is unknown altogether.
+func (uc *PaymentUseCase) supersededOrderPayment(ctx context.Context, notification *PaymentNotification) (*entities.Payment, error) {
+	order, err := uc.paymentRepo.GetPaymentOrder(ctx, notification.OrderID)
+	if err == nil {
+		paymentEntity, err := uc.paymentRepo.GetPaymentByID(ctx, order.PaymentID)
+		if err == nil {
+			uc.logger.Warn("Superseded order was paid", "order_id", notification.OrderID, "payment_id", paymentEntity.ID, "current_order_id", paymentEntity.OrderID)
+			return paymentEntity, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	if notification.ExternalID == "" {
+		return nil, nil
+	}
+	paymentEntity, err := uc.paymentRepo.GetPaymentByExternalID(ctx, notification.ExternalID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return paymentEntity, err
+}
+
+// queueReceiptDeliveries queues the digital receipt to the contacts captured at checkout.
+// Delivery is best effort and never fails the payment.
+func (uc *PaymentUseCase) queueReceiptDeliveries(ctx context.Context, transaction *entities.Transaction) {
+	// Practice sales must not reach real customers
+	if transaction.IsTraining {
+		return
+	}
+	for channel, recipient := range transaction.ReceiptRecipients() {
+		delivery := entities.NewReceiptDelivery(transaction.ID, channel, recipient)
+		if err := uc.deliveryRepo.Create(ctx, delivery); err != nil {
+			uc.logger.Error("Failed to queue receipt delivery", "error", err, "transaction_id", transaction.ID, "channel", channel)
+		}
+	}
+}
+
+// settleLoyaltyPoints spends the points redeemed on a paid transaction and credits the points it earned.
+// Like receipts it never fails the payment, a failure is logged for staff to adjust the balance by hand.
+func (uc *PaymentUseCase) settleLoyaltyPoints(ctx context.Context, transaction *entities.Transaction) {
+	if transaction.CustomerID == nil || transaction.IsTraining {
+		return
+	}
+
+	earned := entities.PointsEarnedFor(transaction.TotalAmount, uc.loyaltyConfig.EarnAmount)
+	if earned == 0 && transaction.PointsRedeemed == 0 {
+		return
+	}
+	if err := uc.customerRepo.SettleTransactionPoints(ctx, *transaction.CustomerID, transaction.ID, transaction.PointsRedeemed, earned); err != nil {
+		uc.logger.Error("Failed to settle loyalty points", "error", err, "transaction_id", transaction.ID, "customer_id", *transaction.CustomerID)
+	}
+}
+
+func (uc *PaymentUseCase) publishPaymentStatus(paymentEntity *entities.Payment, message string) {
+	if uc.eventBroker == nil {
+		return
+	}
+
+	uc.eventBroker.Publish(events.Event{
+		Type:          events.EventPaymentStatus,
+		TransactionID: paymentEntity.TransactionID,
+		StoreID:       paymentEntity.StoreID,
+		TenantID:      events.TenantOf(paymentEntity.TenantID),
+		Data: &PaymentStatusResponse{
+			TransactionID: paymentEntity.TransactionID,
+			Status:        paymentEntity.Status,
+			ExternalID:    paymentEntity.ExternalID,
+			Message:       message,
+		},
+	})
+}
+
+func (uc *PaymentUseCase) validateQRISAmount(amount int64) error {
+	if amount < uc.config.QRISMinAmount || (uc.config.QRISMaxAmount > 0 && amount > uc.config.QRISMaxAmount) {
+		return &appErrors.AmountLimitError{
+			Method: string(entities.PaymentMethodQRIS),
+			Amount: amount,
+			Min:    uc.config.QRISMinAmount,
+			Max:    uc.config.QRISMaxAmount,
+		}
+	}
+	return nil
+}
+
+// gatewayCustomer is who a charge is made out to, the customer_details of the gateway
+type gatewayCustomer struct {
+	Name  string
+	Email string
+	Phone string
+}
+
+// gatewayCustomer returns the merchant's name and contacts once it is onboarded, the cashier's before
+func (uc *PaymentUseCase) gatewayCustomer(transaction *entities.Transaction) gatewayCustomer {
+	if profile := uc.merchant.Profile(); profile != nil {
+		return gatewayCustomer{Name: profile.Name, Email: profile.Email, Phone: profile.Phone}
+	}
+	return gatewayCustomer{Name: transaction.User.Name, Email: transaction.User.Email}
+}
+
+// qrisItems lists what a payment charges for, gateways require the lines to add up to the amount. A payment
+// of part of the sale, a deposit or the balance left after one, is charged as a single line.
+func (uc *PaymentUseCase) qrisItems(transaction *entities.Transaction, amount, amountDue int64) []payment.QRISItem {
+	if amount == transaction.TotalAmount {
+		return uc.mapTransactionItemsToQRISItems(transaction)
+	}
+
+	item := payment.QRISItem{ID: "BALANCE", Name: "Balance", Price: amount, Quantity: 1}
+	if amount < amountDue {
+		item.ID, item.Name = "DEPOSIT", "Deposit"
+	}
+	return []payment.QRISItem{item}
+}
+
+func (uc *PaymentUseCase) mapTransactionItemsToQRISItems(transaction *entities.Transaction) []payment.QRISItem {
+	var qrisItems []payment.QRISItem
+
+	// Add product items. Gateways only take whole quantities, so a line sold by weight or volume goes
+	// as one unit at its total with the quantity in its name, keeping the items sum equal to the total.
+	for _, item := range transaction.Items {
+		qrisItem := payment.QRISItem{
+			ID:       item.ProductID,
+			Name:     item.Product.Name,
+			Price:    item.UnitPrice,
+			Quantity: int(item.Quantity),
+		}
+		if float64(qrisItem.Quantity) != item.Quantity {
+			qrisItem.Name = fmt.Sprintf("%s %s", item.Product.Name, entities.FormatQuantity(item.Quantity, item.Unit))
+			qrisItem.Price = item.TotalPrice
+			qrisItem.Quantity = 1
+		}
+		qrisItems = append(qrisItems, qrisItem)
+	}
+
+	// Add the service charge, before the taxes charged on it
+	if transaction.ServiceCharge > 0 {
+		qrisItems = append(qrisItems, payment.QRISItem{
+			ID:       "SERVICE",
+			Name:     fmt.Sprintf("Service %s%%", strconv.FormatFloat(transaction.ServiceChargeRate, 'f', -1, 64)),
+			Price:    transaction.ServiceCharge,
+			Quantity: 1,
+		})
+	}
+
+	// Add each exclusive tax as a line item, inclusive taxes are already in the item prices
+	var itemizedTax int64
+	for _, tax := range transaction.Taxes {
+		if tax.Inclusive || tax.Amount == 0 {
+			continue
+		}
+		qrisItems = append(qrisItems, payment.QRISItem{
+			ID:       "TAX-" + tax.TaxRuleID,
+			Name:     fmt.Sprintf("%s %s%%", tax.Name, strconv.FormatFloat(tax.Rate, 'f', -1, 64)),
+			Price:    tax.Amount,
+			Quantity: 1,
+		})
+		itemizedTax += tax.Amount
+	}
+	// Tax without a breakdown, or rounding the breakdown doesn't cover
+	if remaining := transaction.TaxAmount - itemizedTax; remaining > 0 {
+		qrisItems = append(qrisItems, payment.QRISItem{
+			ID:       "TAX",
+			Name:     "Tax",
+			Price:    remaining,
+			Quantity: 1,
+		})
+	}
+
+	// Add discount as negative line item if present
+	if transaction.Discount > 0 {
+		qrisItems = append(qrisItems, payment.QRISItem{
+			ID:       "DISCOUNT",
+			Name:     "Discount",
+			Price:    -transaction.Discount, // Negative to reduce total
+			Quantity: 1,
+		})
+	}
+
+	// Items returned in an exchange are credited the same way
+	if transaction.ExchangeCredit > 0 {
+		qrisItems = append(qrisItems, payment.QRISItem{
+			ID:       "EXCHANGE",
+			Name:     "Exchange credit",
+			Price:    -transaction.ExchangeCredit,
+			Quantity: 1,
+		})
+	}
+
+	// And so are redeemed loyalty points
+	if transaction.PointsDiscount > 0 {
+		qrisItems = append(qrisItems, payment.QRISItem{
+			ID:       "POINTS",
+			Name:     fmt.Sprintf("Points (%d)", transaction.PointsRedeemed),
+			Price:    -transaction.PointsDiscount,
+			Quantity: 1,
+		})
+	}
+
+	// Cash rounding last, so the items add up to the gross amount
+	if transaction.Rounding != 0 {
+		qrisItems = append(qrisItems, payment.QRISItem{
+			ID:       "ROUNDING",
+			Name:     "Rounding",
+			Price:    transaction.Rounding,
+			Quantity: 1,
+		})
+	}
+
+	return qrisItems
+}
+
+func (uc *PaymentUseCase) mapPaymentToResponse(payment *entities.Payment, qrisCode *entities.QRISCode) *PaymentResponse {
+	response := &PaymentResponse{
+		ID:              payment.ID,
+		TransactionID:   payment.TransactionID,
+		StoreID:         payment.StoreID,
+		Amount:          payment.Amount,
+		AmountDue:       payment.AmountDue,
+		RemainingAmount: payment.RemainingAmount(),
+		Currency:        payment.Currency,
+		Method:          payment.Method,
+		Status:          payment.Status,
+		Provider:        payment.Provider,
+		ExternalID:      payment.ExternalID,
+		AmountTendered:  payment.AmountTendered,
+		ChangeAmount:    payment.ChangeAmount,
+		Reference:       payment.Reference,
+		Wallet:          payment.Wallet,
+		DeeplinkURL:     payment.DeeplinkURL,
+		ExpiresAt:       payment.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:       payment.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       payment.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if payment.PaidAt != nil {
+		paidAt := payment.PaidAt.Format(time.RFC3339)
+		response.PaidAt = &paidAt
+	}
+
+	if qrisCode != nil {
+		response.QRISCode = &QRISCodeResponse{
+			ID:               qrisCode.ID,
+			TransactionID:    qrisCode.TransactionID,
+			PaymentID:        qrisCode.PaymentID,
+			QRCode:           qrisCode.QRCode,
+			URL:              qrisCode.URL,
+			ExpiresAt:        qrisCode.ExpiresAt.Format(time.RFC3339),
+			ExpiresAtEpoch:   qrisCode.ExpiresAt.Unix(),
+			SecondsRemaining: secondsUntil(qrisCode.ExpiresAt),
+			CreatedAt:        qrisCode.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return response
+}
+
+// newOrderID names a new gateway order of the transaction, at most 50 characters as Midtrans requires:
+// the first 8 characters of the transaction ID, the time and a random part, so orders issued within the
+// same second don't collide on the unique index
+func newOrderID(transactionID string, now time.Time) string {
+	shortTxID := transactionID
+	if len(shortTxID) > 8 {
+		shortTxID = shortTxID[:8]
+	}
+	return fmt.Sprintf("qris-%s-%d-%s", shortTxID, now.Unix(), uuid.New().String()[:8])
+}
+
+// secondsUntil returns the whole seconds left before t, rounded up so a countdown only shows 0 once
+// the code has expired
+func secondsUntil(t time.Time) int64 {
+	remaining := time.Until(t)
+	if remaining <= 0 {
+		return 0
+	}
+	return int64((remaining + time.Second - 1) / time.Second)
+}
+
+func isGatewaySuccess(status string) bool {
+	return status == payment.StatusSettlement || status == payment.StatusCapture
 }
@@ -0,0 +1,265 @@
+package payment_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/usecases/payment"
+	"qris-pos-backend/internal/usecases/payment/paymenttest"
+	appErrors "qris-pos-backend/pkg/errors"
+)
+
+func TestGenerateQRISIssuesThroughGateway(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+
+	result, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID})
+	if err != nil {
+		t.Fatalf("GenerateQRIS: %v", err)
+	}
+
+	if result.Status != entities.PaymentPending || result.Amount != 50000 || result.Provider != entities.ProviderMidtrans {
+		t.Errorf("payment = %s %d through %s, want pending 50000 through midtrans", result.Status, result.Amount, result.Provider)
+	}
+	requests := f.Gateway.Requests()
+	if len(requests) != 1 || requests[0].GrossAmount != 50000 {
+		t.Fatalf("gateway requests = %+v, want one for 50000", requests)
+	}
+	if result.QRISCode == nil || result.QRISCode.QRCode == "" {
+		t.Errorf("QRIS code missing from %+v", result)
+	}
+
+	events, _ := f.Payments.ListPaymentEvents(context.Background(), result.ID)
+	if len(events) != 1 || events[0].FromStatus != "" || events[0].ToStatus != entities.PaymentPending {
+		t.Errorf("events = %+v, want the creation of a pending payment", events)
+	}
+}
+
+func TestGenerateQRISReturnsPendingPaymentAsDuplicate(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	existing := f.AddPayment(entities.NewPayment(transaction.ID, 50000, 15))
+
+	_, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID})
+
+	var duplicate *appErrors.DuplicatePaymentError
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("err = %v, want a duplicate payment error", err)
+	}
+	if response, ok := duplicate.Payment.(*payment.PaymentResponse); !ok || response.ID != existing.ID {
+		t.Errorf("duplicate carries %+v, want payment %s", duplicate.Payment, existing.ID)
+	}
+	if requests := f.Gateway.Requests(); len(requests) != 0 {
+		t.Errorf("gateway got %d requests, want none", len(requests))
+	}
+}
+
+func TestGenerateQRISReplacesExpiredPayment(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	stale := entities.NewPayment(transaction.ID, 50000, 15)
+	stale.ExpiresAt = time.Now().Add(-time.Minute)
+	f.AddPayment(stale)
+
+	result, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID})
+	if err != nil {
+		t.Fatalf("GenerateQRIS: %v", err)
+	}
+
+	if result.ID == stale.ID {
+		t.Fatal("expired payment was handed back")
+	}
+	expired, _ := f.Payments.GetPaymentByID(context.Background(), stale.ID)
+	if expired.Status != entities.PaymentExpired {
+		t.Errorf("old payment status = %s, want expired", expired.Status)
+	}
+}
+
+func TestGenerateQRISGatewayOutage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"reported unavailable", &appErrors.GatewayUnavailableError{Provider: "midtrans", Err: errors.New("circuit open")}},
+		{"failed request", errors.New("connection reset by peer")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := paymenttest.New()
+			transaction := f.AddTransaction(50000)
+			f.Gateway.Err = tt.err
+
+			_, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID})
+
+			if !errors.Is(err, appErrors.ErrGatewayUnavailable) {
+				t.Fatalf("err = %v, want the gateway unavailable", err)
+			}
+			if _, err := f.Payments.GetPaymentByTransactionID(context.Background(), transaction.ID); err == nil {
+				t.Error("a payment was stored without a QRIS")
+			}
+		})
+	}
+}
+
+func TestGenerateQRISRefusesExpiredTransaction(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	transaction.Status = entities.StatusExpired
+	f.Transactions.Add(transaction)
+
+	_, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID})
+
+	if !errors.Is(err, appErrors.ErrTransactionExpired) {
+		t.Fatalf("err = %v, want the transaction expired", err)
+	}
+	if requests := f.Gateway.Requests(); len(requests) != 0 {
+		t.Errorf("gateway got %d requests, want none", len(requests))
+	}
+}
+
+func TestGenerateQRISDeposit(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+
+	result, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID, Amount: 20000})
+	if err != nil {
+		t.Fatalf("GenerateQRIS: %v", err)
+	}
+
+	if result.Amount != 20000 || result.AmountDue != 50000 || result.RemainingAmount != 30000 {
+		t.Errorf("deposit = %d of %d leaving %d, want 20000 of 50000 leaving 30000", result.Amount, result.AmountDue, result.RemainingAmount)
+	}
+	items := f.Gateway.Requests()[0].Items
+	if len(items) != 1 || items[0].ID != "DEPOSIT" || items[0].Price != 20000 {
+		t.Errorf("gateway items = %+v, want a single deposit line", items)
+	}
+}
+
+func TestGenerateQRISChargesBalanceAfterDeposit(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	deposit := entities.NewPayment(transaction.ID, 20000, 15)
+	deposit.Status = entities.PaymentSuccess
+	f.AddPayment(deposit)
+
+	result, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID})
+	if err != nil {
+		t.Fatalf("GenerateQRIS: %v", err)
+	}
+
+	if result.Amount != 30000 || result.AmountDue != 30000 {
+		t.Errorf("payment = %d of %d, want the 30000 balance", result.Amount, result.AmountDue)
+	}
+}
+
+func TestGenerateQRISRefusesMoreThanDue(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+
+	_, err := f.UseCase.GenerateQRIS(context.Background(), &payment.GenerateQRISRequest{TransactionID: transaction.ID, Amount: 60000})
+
+	if !errors.Is(err, appErrors.ErrAmountExceedsDue) {
+		t.Fatalf("err = %v, want the amount exceeding the balance", err)
+	}
+}
+
+func TestRefreshQRISSupersedesOrder(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	expired := entities.NewPayment(transaction.ID, 50000, 15)
+	expired.Provider = entities.ProviderMidtrans
+	expired.OrderID = "qris-old-order"
+	expired.ExternalID = "gateway-payment"
+	f.AddPayment(expired)
+	if err := expired.MarkAsExpired(entities.SystemActor("expiry")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Payments.UpdatePayment(context.Background(), expired); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := f.UseCase.RefreshQRIS(context.Background(), transaction.ID)
+	if err != nil {
+		t.Fatalf("RefreshQRIS: %v", err)
+	}
+
+	if result.ID != expired.ID || result.Status != entities.PaymentPending || result.ExternalID != "" {
+		t.Errorf("refreshed payment = %s %s external %q, want %s pending without external ID", result.ID, result.Status, result.ExternalID, expired.ID)
+	}
+	refreshed, _ := f.Payments.GetPaymentByID(context.Background(), expired.ID)
+	if refreshed.OrderID == "qris-old-order" || refreshed.OrderID == "" {
+		t.Fatalf("order ID = %q, want a new one", refreshed.OrderID)
+	}
+	if requests := f.Gateway.Requests(); len(requests) != 1 || requests[0].OrderID != refreshed.OrderID {
+		t.Errorf("gateway requests = %+v, want one for order %s", requests, refreshed.OrderID)
+	}
+	// A notification for the superseded order still finds its payment
+	if order, err := f.Payments.GetPaymentOrder(context.Background(), "qris-old-order"); err != nil || order.PaymentID != expired.ID {
+		t.Errorf("superseded order maps to %+v (%v), want payment %s", order, err, expired.ID)
+	}
+
+	events, _ := f.Payments.ListPaymentEvents(context.Background(), expired.ID)
+	if last := events[len(events)-1]; last.FromStatus != entities.PaymentExpired || last.ToStatus != entities.PaymentPending {
+		t.Errorf("last event = %s to %s, want expired to pending", last.FromStatus, last.ToStatus)
+	}
+}
+
+func TestRefreshQRISRefusesSettledPayment(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	settled := entities.NewPayment(transaction.ID, 50000, 15)
+	settled.Status = entities.PaymentSuccess
+	f.AddPayment(settled)
+
+	if _, err := f.UseCase.RefreshQRIS(context.Background(), transaction.ID); err == nil {
+		t.Fatal("settled payment was refreshed")
+	}
+	if requests := f.Gateway.Requests(); len(requests) != 0 {
+		t.Errorf("gateway got %d requests, want none", len(requests))
+	}
+}
+
+func TestRefreshQRISGatewayOutageKeepsPayment(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	pending := entities.NewPayment(transaction.ID, 50000, 15)
+	pending.OrderID = "qris-current-order"
+	f.AddPayment(pending)
+	f.Gateway.Err = &appErrors.GatewayUnavailableError{Provider: "midtrans", Err: errors.New("circuit open")}
+
+	if _, err := f.UseCase.RefreshQRIS(context.Background(), transaction.ID); err == nil {
+		t.Fatal("refresh succeeded without the gateway")
+	}
+
+	current, _ := f.Payments.GetPaymentByID(context.Background(), pending.ID)
+	if current.OrderID != "qris-current-order" || current.Status != entities.PaymentPending {
+		t.Errorf("payment = %s on order %s, want it untouched", current.Status, current.OrderID)
+	}
+}
+
+func TestRefreshQRISTwiceWithinASecondIssuesDistinctOrders(t *testing.T) {
+	f := paymenttest.New()
+	transaction := f.AddTransaction(50000)
+	pending := entities.NewPayment(transaction.ID, 50000, 15)
+	f.AddPayment(pending)
+
+	orders := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		if _, err := f.UseCase.RefreshQRIS(context.Background(), transaction.ID); err != nil {
+			t.Fatalf("RefreshQRIS: %v", err)
+		}
+		refreshed, _ := f.Payments.GetPaymentByID(context.Background(), pending.ID)
+		if len(refreshed.OrderID) > 50 {
+			t.Errorf("order ID %q is longer than Midtrans takes", refreshed.OrderID)
+		}
+		orders[refreshed.OrderID] = true
+	}
+
+	if len(orders) != 2 {
+		t.Errorf("order IDs = %v, want two distinct ones", orders)
+	}
+}
@@ -0,0 +1,332 @@
+// Package paymenttest runs the payment use cases against in-memory repositories and a FakeGateway, so their
+// behaviour can be tested without a database or an acquirer.
+package paymenttest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	gateways "qris-pos-backend/internal/infrastructure/payment"
+	"qris-pos-backend/internal/usecases/merchant"
+	"qris-pos-backend/internal/usecases/payment"
+	"qris-pos-backend/internal/usecases/settings"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Fixture is a payment use case wired to in-memory repositories and a fake Midtrans
+type Fixture struct {
+	UseCase      *payment.PaymentUseCase
+	Gateway      *gateways.FakeGateway
+	Payments     *PaymentRepository
+	Transactions *TransactionRepository
+}
+
+// New creates a fixture with QRIS issued through the fake gateway, expiring after 15 minutes by default
+func New() *Fixture {
+	cfg := config.PaymentConfig{QRISExpiryMinutes: 15}
+	log := logger.NewLogger("error")
+	broker := events.NewBroker()
+	settingsUseCase := settings.NewSettingsUseCase(nil, broker, config.StoreConfig{}, cfg, log)
+
+	f := &Fixture{
+		Gateway:      gateways.NewFakeGateway(entities.ProviderMidtrans),
+		Payments:     NewPaymentRepository(),
+		Transactions: NewTransactionRepository(),
+	}
+	f.UseCase = payment.NewPaymentUseCase(
+		f.Payments,
+		f.Transactions,
+		nil, nil, nil, nil,
+		TransactionManager{},
+		gateways.NewGatewaysFrom(f.Gateway),
+		nil,
+		broker,
+		cfg,
+		config.LoyaltyConfig{},
+		settingsUseCase,
+		merchant.NewMerchantUseCase(nil, settingsUseCase, nil, log),
+		log,
+	)
+	return f
+}
+
+// AddTransaction stores a pending sale of the total, in rupiah with its stock already reserved
+func (f *Fixture) AddTransaction(total int64) *entities.Transaction {
+	transaction := &entities.Transaction{
+		ID:            uuid.New().String(),
+		Status:        entities.StatusPending,
+		Currency:      entities.CurrencyIDR,
+		TotalAmount:   total,
+		StockReserved: true,
+		User:          entities.User{Name: "Cashier", Email: "cashier@example.com"},
+	}
+	f.Transactions.Add(transaction)
+	return transaction
+}
+
+// AddPayment stores a payment as the use cases would have created it
+func (f *Fixture) AddPayment(payment *entities.Payment) *entities.Payment {
+	if err := payment.RecordCreation(entities.SystemActor("test")); err != nil {
+		panic(err)
+	}
+	if err := f.Payments.CreatePayment(context.Background(), payment); err != nil {
+		panic(err)
+	}
+	return payment
+}
+
+// TransactionManager runs the function as is, the in-memory repositories have nothing to roll back
+type TransactionManager struct{}
+
+func (TransactionManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// PaymentRepository keeps payments, their QRIS codes, orders and events in memory. Only what the QRIS use
+// cases need is implemented, the other methods panic.
+type PaymentRepository struct {
+	repositories.PaymentRepository
+
+	mu        sync.Mutex
+	payments  map[string]entities.Payment
+	qrisCodes map[string]entities.QRISCode // By payment ID
+	orders    map[string]entities.PaymentOrder
+	events    []entities.PaymentEvent
+}
+
+func NewPaymentRepository() *PaymentRepository {
+	return &PaymentRepository{
+		payments:  make(map[string]entities.Payment),
+		qrisCodes: make(map[string]entities.QRISCode),
+		orders:    make(map[string]entities.PaymentOrder),
+	}
+}
+
+func (r *PaymentRepository) CreatePayment(ctx context.Context, payment *entities.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if payment.ID == "" {
+		payment.ID = uuid.New().String()
+	}
+	now := time.Now()
+	payment.CreatedAt, payment.UpdatedAt = now, now
+	r.save(payment)
+	return nil
+}
+
+// UpdatePayment refuses changes made to a stale copy, as the database does
+func (r *PaymentRepository) UpdatePayment(ctx context.Context, payment *entities.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.payments[payment.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if from, changed := payment.StatusBeforeChanges(); changed && current.Status != from {
+		return fmt.Errorf("%w: payment is %s now", appErrors.ErrIllegalPaymentTransition, current.Status)
+	}
+	payment.UpdatedAt = time.Now()
+	r.save(payment)
+	return nil
+}
+
+func (r *PaymentRepository) save(payment *entities.Payment) {
+	for _, event := range payment.TakePaymentEvents() {
+		r.events = append(r.events, *event)
+	}
+	if _, ok := r.orders[payment.OrderID]; payment.OrderID != "" && !ok {
+		r.orders[payment.OrderID] = entities.PaymentOrder{
+			OrderID:       payment.OrderID,
+			PaymentID:     payment.ID,
+			TransactionID: payment.TransactionID,
+			Provider:      payment.Provider,
+		}
+	}
+	r.payments[payment.ID] = stored(payment)
+}
+
+// stored is the copy of a payment the repository keeps, without its relations
+func stored(payment *entities.Payment) entities.Payment {
+	copied := *payment
+	copied.Transaction = entities.Transaction{}
+	copied.QRCode = nil
+	return copied
+}
+
+func (r *PaymentRepository) GetPaymentByID(ctx context.Context, id string) (*entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &payment, nil
+}
+
+// GetPaymentByTransactionID returns the latest payment of the transaction
+func (r *PaymentRepository) GetPaymentByTransactionID(ctx context.Context, transactionID string) (*entities.Payment, error) {
+	payments := r.byTransaction(transactionID)
+	if len(payments) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &payments[len(payments)-1], nil
+}
+
+func (r *PaymentRepository) GetPaidAmount(ctx context.Context, transactionID string) (int64, error) {
+	var paid int64
+	for _, payment := range r.byTransaction(transactionID) {
+		if payment.Status == entities.PaymentSuccess {
+			paid += payment.Amount
+		}
+	}
+	return paid, nil
+}
+
+func (r *PaymentRepository) GetPaymentByOrderID(ctx context.Context, orderID string) (*entities.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, payment := range r.payments {
+		if payment.OrderID == orderID {
+			return &payment, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *PaymentRepository) GetPaymentOrder(ctx context.Context, orderID string) (*entities.PaymentOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &order, nil
+}
+
+func (r *PaymentRepository) ListPaymentEvents(ctx context.Context, paymentID string) ([]entities.PaymentEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []entities.PaymentEvent
+	for _, event := range r.events {
+		if event.PaymentID == paymentID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *PaymentRepository) CreateQRISCode(ctx context.Context, qrisCode *entities.QRISCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if qrisCode.ID == "" {
+		qrisCode.ID = uuid.New().String()
+	}
+	qrisCode.CreatedAt = time.Now()
+	r.qrisCodes[qrisCode.PaymentID] = *qrisCode
+	return nil
+}
+
+// UpdateQRISCode saves the QRIS code, stored or not, as the database does
+func (r *PaymentRepository) UpdateQRISCode(ctx context.Context, qrisCode *entities.QRISCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.qrisCodes[qrisCode.PaymentID] = *qrisCode
+	return nil
+}
+
+func (r *PaymentRepository) GetQRISCodeByPaymentID(ctx context.Context, paymentID string) (*entities.QRISCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	qrisCode, ok := r.qrisCodes[paymentID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &qrisCode, nil
+}
+
+// byTransaction returns the payments of a transaction, oldest first
+func (r *PaymentRepository) byTransaction(transactionID string) []entities.Payment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var payments []entities.Payment
+	for _, payment := range r.payments {
+		if payment.TransactionID == transactionID {
+			payments = append(payments, payment)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].CreatedAt.Before(payments[j].CreatedAt) })
+	return payments
+}
+
+// TransactionRepository keeps transactions in memory. Only what the QRIS use cases need is implemented, the
+// other methods panic.
+type TransactionRepository struct {
+	repositories.TransactionRepository
+
+	mu           sync.Mutex
+	transactions map[string]entities.Transaction
+}
+
+func NewTransactionRepository() *TransactionRepository {
+	return &TransactionRepository{transactions: make(map[string]entities.Transaction)}
+}
+
+func (r *TransactionRepository) Add(transaction *entities.Transaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.transactions[transaction.ID] = *transaction
+}
+
+func (r *TransactionRepository) GetByID(ctx context.Context, id string) (*entities.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transaction, ok := r.transactions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &transaction, nil
+}
+
+func (r *TransactionRepository) GetByIDWithDetails(ctx context.Context, id string) (*entities.Transaction, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *TransactionRepository) Update(ctx context.Context, transaction *entities.Transaction) error {
+	r.Add(transaction)
+	return nil
+}
+
+func (r *TransactionRepository) ReserveStock(ctx context.Context, transactionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transaction, ok := r.transactions[transactionID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	transaction.StockReserved = true
+	r.transactions[transactionID] = transaction
+	return nil
+}
@@ -0,0 +1,306 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+type QRISJobStatus string
+
+const (
+	QRISJobQueued     QRISJobStatus = "queued"
+	QRISJobProcessing QRISJobStatus = "processing"
+	QRISJobCompleted  QRISJobStatus = "completed"
+	QRISJobFailed     QRISJobStatus = "failed"
+)
+
+// QRISJob is a QRIS generation waiting for or running in the worker pool. Payment is the QRIS once the
+// job completed, or the pending one a failed job found when the transaction already had a QRIS.
+type QRISJob struct {
+	ID            string           `json:"id"`
+	TransactionID string           `json:"transaction_id"`
+	Status        QRISJobStatus    `json:"status"`
+	Payment       *PaymentResponse `json:"payment,omitempty"`
+	Error         string           `json:"error,omitempty"`
+	ErrorCode     string           `json:"error_code,omitempty"` // DUPLICATE_PAYMENT, TRANSACTION_EXPIRED, GATEWAY_UNAVAILABLE...
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+
+	storeID  string
+	tenantID string
+	userID   string
+	request  GenerateQRISRequest
+}
+
+// IsFinished reports whether the job completed or failed, no further updates follow
+func (j *QRISJob) IsFinished() bool {
+	return j.Status == QRISJobCompleted || j.Status == QRISJobFailed
+}
+
+// QRISQueue generates QRIS in a bounded pool of workers, so a peak of checkouts waits in the queue
+// instead of holding a request each while the gateway is slow. Jobs live in memory, clients poll them
+// or stream their updates by ID, finished jobs are forgotten after the retention period.
+type QRISQueue struct {
+	paymentUseCase *PaymentUseCase
+	eventBroker    *events.Broker
+	config         config.PaymentConfig
+	logger         logger.Logger
+
+	queue   chan *QRISJob
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	jobs    map[string]*QRISJob
+	active  map[string]*QRISJob // Unfinished job of each transaction, a second request joins it
+	stopped bool
+}
+
+func NewQRISQueue(paymentUseCase *PaymentUseCase, eventBroker *events.Broker, cfg config.PaymentConfig, logger logger.Logger) *QRISQueue {
+	return &QRISQueue{
+		paymentUseCase: paymentUseCase,
+		eventBroker:    eventBroker,
+		config:         cfg,
+		logger:         logger,
+		queue:          make(chan *QRISJob, max(cfg.QRISQueueSize, 1)),
+		jobs:           make(map[string]*QRISJob),
+		active:         make(map[string]*QRISJob),
+	}
+}
+
+// Start runs the workers until Stop
+func (q *QRISQueue) Start() {
+	for range max(q.config.QRISWorkers, 1) {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Stop refuses new jobs, fails the queued ones and waits for the charges in flight to finish
+func (q *QRISQueue) Stop() {
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return
+	}
+	q.stopped = true
+	close(q.queue)
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+// Enqueue queues a QRIS generation and returns its job. A transaction that can't get a QRIS is refused
+// right away with the error GenerateQRIS would return, and one that already has a job in progress gets
+// that job back.
+func (q *QRISQueue) Enqueue(ctx context.Context, req *GenerateQRISRequest) (*QRISJob, error) {
+	// Refuse what would fail anyway before it takes a place in the queue
+	transaction, err := q.paymentUseCase.qrisTransaction(ctx, req.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := q.paymentUseCase.qrisAmount(ctx, transaction, req.Amount); err != nil {
+		return nil, err
+	}
+	if err := q.paymentUseCase.pendingQRISError(ctx, req.TransactionID); err != nil {
+		return nil, err
+	}
+
+	storeID, _ := repositories.StoreFromContext(ctx)
+	tenantID, _ := repositories.TenantFromContext(ctx)
+	userID, _ := repositories.UserFromContext(ctx)
+	now := time.Now()
+
+	q.mu.Lock()
+	q.prune(now)
+	if job, ok := q.active[req.TransactionID]; ok {
+		snapshot := *job
+		q.mu.Unlock()
+		return &snapshot, nil
+	}
+	if q.stopped {
+		q.mu.Unlock()
+		return nil, appErrors.ErrQRISQueueFull
+	}
+
+	job := &QRISJob{
+		ID:            uuid.New().String(),
+		TransactionID: req.TransactionID,
+		Status:        QRISJobQueued,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		storeID:       storeID,
+		tenantID:      tenantID,
+		userID:        userID,
+		request:       *req,
+	}
+	select {
+	case q.queue <- job:
+	default:
+		q.mu.Unlock()
+		q.logger.Warn("QRIS generation queue is full", "transaction_id", req.TransactionID, "queue_size", cap(q.queue))
+		return nil, appErrors.ErrQRISQueueFull
+	}
+	q.jobs[job.ID] = job
+	q.active[job.TransactionID] = job
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.publish(snapshot)
+	return &snapshot, nil
+}
+
+// GetJob returns a job of the store the context is scoped to
+func (q *QRISQueue) GetJob(ctx context.Context, id string) (*QRISJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, appErrors.ErrQRISJobNotFound
+	}
+	if storeID, scoped := repositories.StoreFromContext(ctx); scoped && job.storeID != "" && job.storeID != storeID {
+		return nil, appErrors.ErrQRISJobNotFound
+	}
+	if tenantID, scoped := repositories.TenantFromContext(ctx); scoped && job.tenantID != tenantID {
+		return nil, appErrors.ErrQRISJobNotFound
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// SubscribeJob streams the updates of a job. Subscribe before reading the job so no update is missed.
+func (q *QRISQueue) SubscribeJob(id string) (<-chan events.Event, func()) {
+	return q.eventBroker.Subscribe(func(event events.Event) bool {
+		job, ok := event.Data.(*QRISJob)
+		return ok && event.Type == events.EventQRISJob && job.ID == id
+	})
+}
+
+func (q *QRISQueue) work() {
+	defer q.wg.Done()
+
+	for job := range q.queue {
+		q.mu.Lock()
+		stopped := q.stopped
+		q.mu.Unlock()
+
+		if stopped {
+			q.finish(job, nil, errors.New("server is shutting down, generate the QRIS again"))
+			continue
+		}
+		q.process(job)
+	}
+}
+
+func (q *QRISQueue) process(job *QRISJob) {
+	q.update(job, func(j *QRISJob) {
+		j.Status = QRISJobProcessing
+	})
+
+	// The request that queued the job is long gone, keep only the tenant and store it was scoped to and
+	// the user it was made by
+	ctx := repositories.WithTenantOf(context.Background(), &job.tenantID)
+	if job.storeID != "" {
+		ctx = repositories.WithStore(ctx, job.storeID)
+	}
+	if job.userID != "" {
+		ctx = repositories.WithUser(ctx, job.userID)
+	}
+
+	result, err := q.paymentUseCase.GenerateQRIS(ctx, &job.request)
+	if err != nil {
+		q.logger.Warn("Queued QRIS generation failed", "error", err, "job_id", job.ID, "transaction_id", job.TransactionID)
+	}
+	q.finish(job, result, err)
+}
+
+func (q *QRISQueue) finish(job *QRISJob, result *PaymentResponse, err error) {
+	q.update(job, func(j *QRISJob) {
+		if err == nil {
+			j.Status = QRISJobCompleted
+			j.Payment = result
+			return
+		}
+
+		j.Status = QRISJobFailed
+		j.Error = err.Error()
+		j.ErrorCode = qrisErrorCode(err)
+		var duplicateErr *appErrors.DuplicatePaymentError
+		if errors.As(err, &duplicateErr) {
+			j.Payment, _ = duplicateErr.Payment.(*PaymentResponse)
+		}
+	})
+}
+
+// update changes a job and publishes the result to its subscribers
+func (q *QRISQueue) update(job *QRISJob, change func(j *QRISJob)) {
+	q.mu.Lock()
+	change(job)
+	job.UpdatedAt = time.Now()
+	if job.IsFinished() && q.active[job.TransactionID] == job {
+		delete(q.active, job.TransactionID)
+	}
+	snapshot := *job
+	q.mu.Unlock()
+
+	q.publish(snapshot)
+}
+
+func (q *QRISQueue) publish(job QRISJob) {
+	q.eventBroker.Publish(events.Event{
+		Type:          events.EventQRISJob,
+		TransactionID: job.TransactionID,
+		StoreID:       job.storeID,
+		TenantID:      job.tenantID,
+		Data:          &job,
+	})
+}
+
+// prune forgets the jobs finished longer than the retention period ago, the caller holds the lock
+func (q *QRISQueue) prune(now time.Time) {
+	retention := time.Duration(q.config.QRISJobRetentionSeconds) * time.Second
+	for id, job := range q.jobs {
+		if job.IsFinished() && now.Sub(job.UpdatedAt) > retention {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// qrisErrorCode tells clients of a failed job what to do next, the same way the status codes of a
+// synchronous generation do
+func qrisErrorCode(err error) string {
+	var limitErr *appErrors.AmountLimitError
+	switch {
+	case errors.Is(err, appErrors.ErrDuplicatePayment):
+		return "DUPLICATE_PAYMENT"
+	case errors.Is(err, appErrors.ErrTransactionNotFound):
+		return "TRANSACTION_NOT_FOUND"
+	case errors.Is(err, appErrors.ErrTransactionExpired):
+		return "TRANSACTION_EXPIRED"
+	case errors.Is(err, appErrors.ErrTransactionHeld):
+		return "TRANSACTION_HELD"
+	case errors.Is(err, appErrors.ErrInsufficientStock):
+		return "INSUFFICIENT_STOCK"
+	case errors.Is(err, appErrors.ErrAmountExceedsDue):
+		return "AMOUNT_EXCEEDS_DUE"
+	case errors.Is(err, appErrors.ErrAlreadyPaid):
+		return "ALREADY_PAID"
+	case errors.As(err, &limitErr):
+		return "AMOUNT_OUT_OF_RANGE"
+	case errors.Is(err, appErrors.ErrUnsupportedCurrency):
+		return "UNSUPPORTED_CURRENCY"
+	case errors.Is(err, appErrors.ErrGatewayUnavailable):
+		return "GATEWAY_UNAVAILABLE"
+	default:
+		return "QRIS_GENERATION_FAILED"
+	}
+}
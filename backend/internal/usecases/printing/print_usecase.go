@@ -0,0 +1,466 @@
+package printing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/internal/infrastructure/receipt"
+	"qris-pos-backend/internal/usecases/merchant"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type PrinterRequest struct {
+	Name  string `json:"name" validate:"required,min=1,max=100"`
+	Width int    `json:"width" validate:"omitempty,oneof=32 48"` // Characters per line, defaults to 32 (58mm paper)
+}
+
+// PrinterTokenResponse is a printer with its agent token, returned only when the token is issued
+type PrinterTokenResponse struct {
+	Printer *entities.Printer `json:"printer"`
+	Token   string            `json:"token"` // Goes into the X-Printer-Token header of the agent, shown once
+}
+
+type PrintJobRequest struct {
+	PrinterID     string                `json:"printer_id" validate:"required,uuid"`
+	Kind          entities.PrintJobKind `json:"kind" validate:"required,oneof=receipt qris"`
+	TransactionID string                `json:"transaction_id" validate:"required,uuid"`
+	Copies        int                   `json:"copies" validate:"omitempty,gte=1,lte=5"`
+}
+
+type PrintJobFilters struct {
+	PrinterID     string                  `form:"printer_id" validate:"omitempty,uuid"`
+	TransactionID string                  `form:"transaction_id" validate:"omitempty,uuid"`
+	Status        entities.PrintJobStatus `form:"status" validate:"omitempty,oneof=queued printing printed failed"`
+	Limit         int                     `form:"limit" validate:"omitempty,gte=1,lte=100"`
+	Offset        int                     `form:"offset" validate:"omitempty,gte=0"`
+}
+
+type NextJobRequest struct {
+	Wait int `form:"wait" validate:"omitempty,gte=0,lte=300"` // Seconds to wait for a job, capped by the configured maximum
+}
+
+// PrintAckRequest reports how the agent did with a job it claimed
+type PrintAckRequest struct {
+	Status entities.PrintJobStatus `json:"status" validate:"required,oneof=printed failed"`
+	Error  string                  `json:"error" validate:"omitempty,max=500"` // Printer error, such as out of paper
+}
+
+// PrintJobContent is a claimed job with the bytes to send to the printer
+type PrintJobContent struct {
+	Job         *entities.PrintJob `json:"job"`
+	ContentType string             `json:"content_type"`
+	Content     []byte             `json:"content"` // ESC/POS byte stream, base64 in JSON
+}
+
+// PrintUseCase spools receipts and QRIS slips for networked thermal printers. The agent next to a
+// printer pulls its jobs with a long poll, so printers behind the store's NAT need no inbound access.
+type PrintUseCase struct {
+	printJobRepo    repositories.PrintJobRepository
+	transactionRepo repositories.TransactionRepository
+	paymentRepo     repositories.PaymentRepository
+	eventBroker     *events.Broker
+	config          config.PrintConfig
+	merchant        *merchant.MerchantUseCase
+	logger          logger.Logger
+}
+
+func NewPrintUseCase(
+	printJobRepo repositories.PrintJobRepository,
+	transactionRepo repositories.TransactionRepository,
+	paymentRepo repositories.PaymentRepository,
+	eventBroker *events.Broker,
+	cfg config.PrintConfig,
+	merchant *merchant.MerchantUseCase,
+	logger logger.Logger,
+) *PrintUseCase {
+	return &PrintUseCase{
+		printJobRepo:    printJobRepo,
+		transactionRepo: transactionRepo,
+		paymentRepo:     paymentRepo,
+		eventBroker:     eventBroker,
+		config:          cfg,
+		merchant:        merchant,
+		logger:          logger,
+	}
+}
+
+// CreatePrinter registers a printer of the store the admin works in and issues its agent token
+func (uc *PrintUseCase) CreatePrinter(ctx context.Context, req *PrinterRequest) (*PrinterTokenResponse, error) {
+	storeID, ok := repositories.StoreFromContext(ctx)
+	if !ok {
+		return nil, appErrors.ErrStoreRequired
+	}
+
+	printer := &entities.Printer{
+		StoreID:  storeID,
+		Name:     req.Name,
+		Width:    req.Width,
+		IsActive: true,
+	}
+	if printer.Width == 0 {
+		printer.Width = receipt.WidthThermal58
+	}
+	token, err := printer.IssueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.printJobRepo.CreatePrinter(ctx, printer); err != nil {
+		uc.logger.Error("Failed to create printer", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Printer registered", "printer_id", printer.ID, "store_id", storeID)
+	return &PrinterTokenResponse{Printer: printer, Token: token}, nil
+}
+
+func (uc *PrintUseCase) ListPrinters(ctx context.Context) ([]entities.Printer, error) {
+	return uc.printJobRepo.ListPrinters(ctx)
+}
+
+// RotateToken issues a new agent token, the agent holding the old one is locked out
+func (uc *PrintUseCase) RotateToken(ctx context.Context, id string) (*PrinterTokenResponse, error) {
+	printer, err := uc.getPrinter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := printer.IssueToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.printJobRepo.UpdatePrinter(ctx, printer); err != nil {
+		uc.logger.Error("Failed to rotate printer token", "error", err, "printer_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Printer token rotated", "printer_id", id)
+	return &PrinterTokenResponse{Printer: printer, Token: token}, nil
+}
+
+func (uc *PrintUseCase) DeletePrinter(ctx context.Context, id string) error {
+	if _, err := uc.getPrinter(ctx, id); err != nil {
+		return err
+	}
+	return uc.printJobRepo.DeletePrinter(ctx, id)
+}
+
+// CreateJob queues a receipt or the QRIS of a pending payment on a printer of the store
+func (uc *PrintUseCase) CreateJob(ctx context.Context, userID string, req *PrintJobRequest) (*entities.PrintJob, error) {
+	printer, err := uc.getPrinter(ctx, req.PrinterID)
+	if err != nil {
+		return nil, err
+	}
+	if !printer.IsActive {
+		return nil, appErrors.ErrPrinterInactive
+	}
+
+	transaction, err := uc.transactionRepo.GetByID(ctx, req.TransactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if transaction.StoreID != printer.StoreID {
+		return nil, appErrors.ErrTransactionNotFound
+	}
+
+	// Refuse a QRIS that can't be paid anymore now, rather than when the agent claims it
+	if req.Kind == entities.PrintJobQRIS {
+		if _, _, err := uc.payableQRIS(ctx, req.TransactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	job := entities.NewPrintJob(printer, req.Kind, req.TransactionID, req.Copies, userID)
+	if err := uc.printJobRepo.CreateJob(ctx, job); err != nil {
+		uc.logger.Error("Failed to create print job", "error", err, "printer_id", printer.ID)
+		return nil, err
+	}
+
+	uc.publishQueued(job)
+	return job, nil
+}
+
+func (uc *PrintUseCase) ListJobs(ctx context.Context, filters *PrintJobFilters) ([]entities.PrintJob, error) {
+	limit := filters.Limit
+	if limit == 0 {
+		limit = 50
+	}
+	return uc.printJobRepo.ListJobs(ctx, repositories.PrintJobFilters{
+		PrinterID:     filters.PrinterID,
+		TransactionID: filters.TransactionID,
+		Status:        filters.Status,
+		Limit:         limit,
+		Offset:        filters.Offset,
+	})
+}
+
+func (uc *PrintUseCase) GetJob(ctx context.Context, id string) (*entities.PrintJob, error) {
+	job, err := uc.printJobRepo.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPrintJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// Reprint queues a finished job again on its printer, as a new job pointing back to the original
+func (uc *PrintUseCase) Reprint(ctx context.Context, id, userID string) (*entities.PrintJob, error) {
+	original, err := uc.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !original.IsFinished() {
+		return nil, appErrors.ErrPrintJobNotFinished
+	}
+
+	printer, err := uc.getPrinter(ctx, original.PrinterID)
+	if err != nil {
+		return nil, err
+	}
+	if !printer.IsActive {
+		return nil, appErrors.ErrPrinterInactive
+	}
+
+	job := original.Reprint(userID)
+	if err := uc.printJobRepo.CreateJob(ctx, job); err != nil {
+		uc.logger.Error("Failed to create reprint job", "error", err, "reprint_of", original.ID)
+		return nil, err
+	}
+
+	uc.publishQueued(job)
+	return job, nil
+}
+
+// AuthenticatePrinter returns the printer an agent token belongs to and records that its agent is online
+func (uc *PrintUseCase) AuthenticatePrinter(ctx context.Context, token string) (*entities.Printer, error) {
+	if token == "" {
+		return nil, appErrors.ErrInvalidPrinterToken
+	}
+
+	printer, err := uc.printJobRepo.GetPrinterByTokenHash(ctx, entities.HashPrinterToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrInvalidPrinterToken
+		}
+		return nil, err
+	}
+
+	if err := uc.printJobRepo.TouchPrinter(ctx, printer.ID, time.Now()); err != nil {
+		uc.logger.Warn("Failed to record printer activity", "error", err, "printer_id", printer.ID)
+	}
+	return printer, nil
+}
+
+// NextJob claims the next job of the printer, waiting up to wait for one to be queued. Returns nil when
+// none arrived in time. The agent acknowledges the job once printed, otherwise it is handed out again
+// after the lease.
+func (uc *PrintUseCase) NextJob(ctx context.Context, printer *entities.Printer, wait time.Duration) (*PrintJobContent, error) {
+	wait = min(wait, time.Duration(uc.config.MaxWaitSeconds)*time.Second)
+	// Agents may call the platform, they only ever see the tenant of their printer
+	ctx = repositories.WithTenantOf(ctx, printer.TenantID)
+
+	// Subscribe before looking, so a job queued in between still wakes the poll up
+	queued, unsubscribe := uc.eventBroker.Subscribe(func(event events.Event) bool {
+		job, ok := event.Data.(*entities.PrintJob)
+		return ok && event.Type == events.EventPrintJobQueued && job.PrinterID == printer.ID
+	})
+	defer unsubscribe()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		content, err := uc.claim(ctx, printer)
+		if err != nil || content != nil {
+			return content, err
+		}
+
+		select {
+		case <-queued:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
+
+// Ack records the outcome of a job the printer claimed. A failed attempt is retried until the job
+// reaches the maximum number of attempts.
+func (uc *PrintUseCase) Ack(ctx context.Context, printer *entities.Printer, id string, req *PrintAckRequest) (*entities.PrintJob, error) {
+	ctx = repositories.WithTenantOf(ctx, printer.TenantID)
+	job, err := uc.GetJob(repositories.WithStore(ctx, printer.StoreID), id)
+	if err != nil {
+		return nil, err
+	}
+	if job.PrinterID != printer.ID || job.Status != entities.PrintJobPrinting {
+		return nil, appErrors.ErrPrintJobNotClaimed
+	}
+
+	if req.Status == entities.PrintJobPrinted {
+		job.MarkAsPrinted()
+	} else {
+		job.MarkAttemptFailed(req.Error, uc.config.MaxAttempts)
+	}
+	if err := uc.printJobRepo.UpdateJob(ctx, job); err != nil {
+		uc.logger.Error("Failed to update print job", "error", err, "job_id", id)
+		return nil, err
+	}
+
+	if job.Status == entities.PrintJobQueued {
+		uc.publishQueued(job)
+	} else if job.Status == entities.PrintJobFailed {
+		uc.logger.Warn("Print job failed", "job_id", id, "printer_id", printer.ID, "attempts", job.Attempts, "error", req.Error)
+	}
+	return job, nil
+}
+
+// claim takes the next job of the printer and renders it. Jobs out of attempts, or whose document can't
+// be printed anymore, are failed and skipped.
+func (uc *PrintUseCase) claim(ctx context.Context, printer *entities.Printer) (*PrintJobContent, error) {
+	leaseExpiredBefore := time.Now().Add(-time.Duration(uc.config.LeaseSeconds) * time.Second)
+	storeCtx := repositories.WithStore(ctx, printer.StoreID)
+
+	for {
+		job, err := uc.printJobRepo.ClaimNext(ctx, printer.ID, leaseExpiredBefore)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		// The agent took the job before and never acknowledged it, give up after the last lease
+		if job.Attempts > uc.config.MaxAttempts {
+			uc.fail(ctx, job, "printer agent never acknowledged the job")
+			continue
+		}
+
+		content, err := uc.render(storeCtx, printer, job)
+		if err != nil {
+			if errors.Is(err, appErrors.ErrTransactionNotFound) || errors.Is(err, appErrors.ErrPaymentNotFound) ||
+				errors.Is(err, appErrors.ErrQRISExpired) || errors.Is(err, appErrors.ErrAlreadyPaid) {
+				uc.fail(ctx, job, err.Error())
+				continue
+			}
+			return nil, err
+		}
+
+		return &PrintJobContent{
+			Job:         job,
+			ContentType: "application/vnd.escpos",
+			Content:     content,
+		}, nil
+	}
+}
+
+func (uc *PrintUseCase) fail(ctx context.Context, job *entities.PrintJob, message string) {
+	job.Status = entities.PrintJobFailed
+	job.LastError = message
+	if err := uc.printJobRepo.UpdateJob(ctx, job); err != nil {
+		uc.logger.Error("Failed to update print job", "error", err, "job_id", job.ID)
+		return
+	}
+	uc.logger.Warn("Print job failed", "job_id", job.ID, "printer_id", job.PrinterID, "error", message)
+}
+
+// render draws the document of a job for the printer's paper width, once per copy
+func (uc *PrintUseCase) render(ctx context.Context, printer *entities.Printer, job *entities.PrintJob) ([]byte, error) {
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, job.TransactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	store := receipt.NewStore(uc.merchant.Store()).ForOutlet(transaction.Store)
+
+	var document []byte
+	switch job.Kind {
+	case entities.PrintJobQRIS:
+		payment, qrCode, err := uc.payableQRIS(ctx, job.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		document = receipt.RenderQRISESCPOS(receipt.QRISSlip{
+			Store:         store,
+			TransactionID: transaction.ID,
+			Amount:        payment.Amount,
+			ExpiresAt:     qrCode.ExpiresAt,
+			QRString:      qrCode.QRCode,
+		}, printer.Width)
+	default:
+		// Pending or cancelled transactions still get a receipt, but only without payment details
+		payment, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, job.TransactionID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		document = receipt.RenderESCPOS(receipt.New(store, transaction, payment), printer.Width)
+	}
+
+	return bytes.Repeat(document, max(job.Copies, 1)), nil
+}
+
+// payableQRIS returns the QRIS of a transaction that can still be paid
+func (uc *PrintUseCase) payableQRIS(ctx context.Context, transactionID string) (*entities.Payment, *entities.QRISCode, error) {
+	payment, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, nil, err
+	}
+	if payment.Method != entities.PaymentMethodQRIS {
+		return nil, nil, appErrors.ErrPaymentNotFound
+	}
+	if payment.Status == entities.PaymentSuccess {
+		return nil, nil, appErrors.ErrAlreadyPaid
+	}
+
+	qrCode, err := uc.paymentRepo.GetQRISCodeByPaymentID(ctx, payment.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, nil, err
+	}
+	if !payment.CanBeProcessed() || qrCode.IsExpired() {
+		return nil, nil, appErrors.ErrQRISExpired
+	}
+	return payment, qrCode, nil
+}
+
+func (uc *PrintUseCase) getPrinter(ctx context.Context, id string) (*entities.Printer, error) {
+	printer, err := uc.printJobRepo.GetPrinter(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPrinterNotFound
+		}
+		return nil, err
+	}
+	return printer, nil
+}
+
+func (uc *PrintUseCase) publishQueued(job *entities.PrintJob) {
+	uc.eventBroker.Publish(events.Event{
+		Type:          events.EventPrintJobQueued,
+		TransactionID: job.TransactionID,
+		StoreID:       job.StoreID,
+		TenantID:      events.TenantOf(job.TenantID),
+		Data:          job,
+	})
+}
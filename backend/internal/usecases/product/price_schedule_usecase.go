@@ -0,0 +1,311 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PriceScheduleRequest struct {
+	ProductID  string     `json:"product_id" validate:"required,uuid"`
+	Name       string     `json:"name" validate:"required,min=1,max=100"`
+	Price      int64      `json:"price" validate:"gte=0"`
+	DaysOfWeek string     `json:"days_of_week" validate:"max=20"` // Comma separated, 0 = Sunday, e.g. "0,6" for weekends
+	StartsAt   time.Time  `json:"starts_at" validate:"required"`
+	EndsAt     *time.Time `json:"ends_at"` // Without an end or days of the week the price becomes the list price for good
+	IsActive   *bool      `json:"is_active"`
+}
+
+type PriceScheduleFilters struct {
+	ProductID string                       `form:"product_id" validate:"omitempty,uuid"`
+	Status    entities.PriceScheduleStatus `form:"status" validate:"omitempty,oneof=pending in_effect completed"`
+	Limit     int                          `form:"limit" validate:"omitempty,gte=1,lte=100"`
+	Offset    int                          `form:"offset" validate:"omitempty,gte=0"`
+}
+
+// PriceScheduleUseCase manages scheduled product prices and moves products to and from them as the
+// schedules start and end
+type PriceScheduleUseCase struct {
+	scheduleRepo repositories.PriceScheduleRepository
+	productRepo  repositories.ProductRepository
+	location     *time.Location
+	logger       logger.Logger
+}
+
+func NewPriceScheduleUseCase(
+	scheduleRepo repositories.PriceScheduleRepository,
+	productRepo repositories.ProductRepository,
+	timezone string,
+	logger logger.Logger,
+) *PriceScheduleUseCase {
+	// Days of the week are those of the business, not of the server
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		logger.Warn("Unknown business timezone, using server local time", "timezone", timezone, "error", err)
+		location = time.Local
+	}
+
+	return &PriceScheduleUseCase{
+		scheduleRepo: scheduleRepo,
+		productRepo:  productRepo,
+		location:     location,
+		logger:       logger,
+	}
+}
+
+// CreatePriceSchedule schedules a price for a product of the current store. It takes effect on the next
+// run of the scheduler job once it starts.
+func (uc *PriceScheduleUseCase) CreatePriceSchedule(ctx context.Context, userID string, req *PriceScheduleRequest) (*entities.PriceSchedule, error) {
+	schedule := &entities.PriceSchedule{
+		Status:    entities.PriceSchedulePending,
+		IsActive:  true,
+		CreatedBy: userID,
+	}
+	if err := uc.applyRequest(ctx, schedule, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.scheduleRepo.Create(ctx, schedule); err != nil {
+		uc.logger.Error("Failed to create price schedule", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Price schedule created", "price_schedule_id", schedule.ID, "product_id", schedule.ProductID, "price", schedule.Price)
+	return schedule, nil
+}
+
+func (uc *PriceScheduleUseCase) GetPriceSchedule(ctx context.Context, id string) (*entities.PriceSchedule, error) {
+	schedule, err := uc.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPriceScheduleNotFound
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdatePriceSchedule changes a schedule. A schedule in effect is ended first, so the product goes back
+// to its list price until the job applies the changed schedule.
+func (uc *PriceScheduleUseCase) UpdatePriceSchedule(ctx context.Context, id string, req *PriceScheduleRequest) (*entities.PriceSchedule, error) {
+	schedule, err := uc.GetPriceSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status == entities.PriceScheduleCompleted {
+		return nil, errors.New("a completed price schedule cannot be changed")
+	}
+
+	if schedule.Status == entities.PriceScheduleInEffect {
+		if err := uc.end(ctx, schedule, entities.PriceSchedulePending, "Price schedule changed"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.applyRequest(ctx, schedule, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.scheduleRepo.Update(ctx, schedule); err != nil {
+		uc.logger.Error("Failed to update price schedule", "error", err, "price_schedule_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Price schedule updated", "price_schedule_id", id)
+	return schedule, nil
+}
+
+// DeletePriceSchedule removes a schedule, putting the product back to its list price if the schedule
+// is in effect
+func (uc *PriceScheduleUseCase) DeletePriceSchedule(ctx context.Context, id string) error {
+	schedule, err := uc.GetPriceSchedule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if schedule.Status == entities.PriceScheduleInEffect {
+		if err := uc.end(ctx, schedule, entities.PriceScheduleCompleted, "Price schedule deleted"); err != nil {
+			return err
+		}
+	}
+
+	if err := uc.scheduleRepo.Delete(ctx, id); err != nil {
+		uc.logger.Error("Failed to delete price schedule", "error", err, "price_schedule_id", id)
+		return err
+	}
+
+	uc.logger.Info("Price schedule deleted", "price_schedule_id", id)
+	return nil
+}
+
+func (uc *PriceScheduleUseCase) ListPriceSchedules(ctx context.Context, filters *PriceScheduleFilters) ([]entities.PriceSchedule, error) {
+	limit := filters.Limit
+	if limit == 0 {
+		limit = 50
+	}
+	return uc.scheduleRepo.List(ctx, repositories.PriceScheduleFilters{
+		ProductID: filters.ProductID,
+		Status:    filters.Status,
+		Limit:     limit,
+		Offset:    filters.Offset,
+	})
+}
+
+// Run starts the schedules of every store whose window has begun and ends those whose window is over.
+// It is meant to be called periodically by the scheduler, so prices change within one interval.
+func (uc *PriceScheduleUseCase) Run(ctx context.Context) error {
+	now := time.Now().In(uc.location)
+	schedules, err := uc.scheduleRepo.ListDue(ctx, now)
+	if err != nil {
+		uc.logger.Error("Failed to list due price schedules", "error", err)
+		return err
+	}
+
+	// Schedules ending are handled before those starting, so the next one can take over the product
+	var started, ended int
+	for i := range schedules {
+		schedule := &schedules[i]
+		if schedule.Status != entities.PriceScheduleInEffect || schedule.IsActiveAt(now) {
+			continue
+		}
+		status := entities.PriceSchedulePending
+		if schedule.IsOver(now) {
+			status = entities.PriceScheduleCompleted
+		}
+		if err := uc.end(ctx, schedule, status, "Price schedule ended"); err != nil {
+			uc.logger.Error("Failed to end price schedule", "error", err, "price_schedule_id", schedule.ID)
+			continue
+		}
+		ended++
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+		if schedule.Status != entities.PriceSchedulePending {
+			continue
+		}
+		if schedule.IsOver(now) {
+			schedule.Status = entities.PriceScheduleCompleted
+			if err := uc.scheduleRepo.Update(ctx, schedule); err != nil {
+				uc.logger.Error("Failed to complete price schedule", "error", err, "price_schedule_id", schedule.ID)
+			}
+			continue
+		}
+		if !schedule.IsActiveAt(now) {
+			continue
+		}
+		ok, err := uc.start(ctx, schedule)
+		if err != nil {
+			uc.logger.Error("Failed to start price schedule", "error", err, "price_schedule_id", schedule.ID)
+			continue
+		}
+		if ok {
+			started++
+		}
+	}
+
+	if started > 0 || ended > 0 {
+		uc.logger.Info("Price schedules applied", "started", started, "ended", ended)
+	}
+	return nil
+}
+
+// start sells the product at the scheduled price. It waits while another schedule is in effect for the
+// product, and reports whether the schedule started.
+func (uc *PriceScheduleUseCase) start(ctx context.Context, schedule *entities.PriceSchedule) (bool, error) {
+	product, err := uc.productRepo.GetByID(ctx, schedule.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			schedule.Status = entities.PriceScheduleCompleted
+			return false, uc.scheduleRepo.Update(ctx, schedule)
+		}
+		return false, err
+	}
+	if product.PriceScheduleID != nil {
+		return false, nil
+	}
+
+	change := &entities.PriceChange{
+		ProductID:  product.ID,
+		BatchID:    uuid.New().String(),
+		OldPrice:   product.Price,
+		NewPrice:   schedule.Price,
+		Source:     entities.PriceChangeSchedule,
+		ScheduleID: &schedule.ID,
+		ChangedBy:  schedule.CreatedBy,
+		Reason:     "Price schedule started: " + schedule.Name,
+	}
+	if schedule.IsPermanent() {
+		schedule.Status = entities.PriceScheduleCompleted
+	} else {
+		schedule.Status = entities.PriceScheduleInEffect
+		schedule.ListPrice = product.Price
+	}
+
+	if err := uc.scheduleRepo.Transition(ctx, schedule, change); err != nil {
+		return false, err
+	}
+	uc.logger.Info("Price schedule started", "price_schedule_id", schedule.ID, "product_id", product.ID, "old_price", change.OldPrice, "new_price", change.NewPrice)
+	return true, nil
+}
+
+// end puts the product back to the list price it had when the schedule started. A price changed by
+// hand in the meantime is the new list price and is kept.
+func (uc *PriceScheduleUseCase) end(ctx context.Context, schedule *entities.PriceSchedule, status entities.PriceScheduleStatus, reason string) error {
+	var change *entities.PriceChange
+	product, err := uc.productRepo.GetByID(ctx, schedule.ProductID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if product != nil && product.PriceScheduleID != nil && *product.PriceScheduleID == schedule.ID {
+		change = &entities.PriceChange{
+			ProductID:  product.ID,
+			BatchID:    uuid.New().String(),
+			OldPrice:   product.Price,
+			NewPrice:   schedule.ListPrice,
+			Source:     entities.PriceChangeSchedule,
+			ScheduleID: &schedule.ID,
+			ChangedBy:  schedule.CreatedBy,
+			Reason:     reason + ": " + schedule.Name,
+		}
+	}
+
+	schedule.Status = status
+	if err := uc.scheduleRepo.Transition(ctx, schedule, change); err != nil {
+		return err
+	}
+	uc.logger.Info("Price schedule ended", "price_schedule_id", schedule.ID, "product_id", schedule.ProductID, "restored", change != nil)
+	return nil
+}
+
+func (uc *PriceScheduleUseCase) applyRequest(ctx context.Context, schedule *entities.PriceSchedule, req *PriceScheduleRequest) error {
+	product, err := uc.productRepo.GetByID(ctx, req.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrProductNotFound
+		}
+		return err
+	}
+
+	schedule.StoreID = product.StoreID
+	schedule.ProductID = product.ID
+	schedule.Name = strings.TrimSpace(req.Name)
+	schedule.Price = req.Price
+	schedule.DaysOfWeek = strings.ReplaceAll(req.DaysOfWeek, " ", "")
+	schedule.StartsAt = req.StartsAt
+	schedule.EndsAt = req.EndsAt
+	if req.IsActive != nil {
+		schedule.IsActive = *req.IsActive
+	}
+
+	return schedule.Validate()
+}
@@ -0,0 +1,59 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qris-pos-backend/internal/domain/entities"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type PriceTierRequest struct {
+	MinQuantity int   `json:"min_quantity" validate:"gte=2"`
+	Price       int64 `json:"price" validate:"gte=0"` // Unit price from min_quantity units on
+}
+
+// SetPriceTiersRequest lists every quantity break of a product, an empty list removes them
+type SetPriceTiersRequest struct {
+	Tiers []PriceTierRequest `json:"tiers" validate:"max=20,dive"`
+}
+
+type PriceTierResponse struct {
+	MinQuantity int   `json:"min_quantity"`
+	Price       int64 `json:"price"`
+}
+
+// SetPriceTiers replaces the quantity breaks of a product. Pending transactions are repriced on their
+// next change.
+func (uc *ProductUseCase) SetPriceTiers(ctx context.Context, productID string, req *SetPriceTiersRequest) (*ProductResponse, error) {
+	if _, err := uc.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	tiers := make([]entities.PriceTier, 0, len(req.Tiers))
+	for _, tier := range req.Tiers {
+		tiers = append(tiers, entities.PriceTier{MinQuantity: tier.MinQuantity, Price: tier.Price})
+	}
+	if err := entities.ValidatePriceTiers(tiers); err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+
+	if err := uc.productRepo.ReplacePriceTiers(ctx, productID, tiers); err != nil {
+		uc.logger.Error("Failed to set price tiers", "error", err, "product_id", productID)
+		return nil, err
+	}
+
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Price tiers set", "product_id", productID, "tiers", len(tiers))
+	return uc.mapProductToResponse(product), nil
+}
@@ -0,0 +1,302 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/infrastructure/imaging"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// orphanCheckBatch bounds the URLs looked up in one query of the orphan image cleanup
+const orphanCheckBatch = 500
+
+type ProductImageResponse struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	SortOrder    int    `json:"sort_order"`
+}
+
+// ReorderProductImagesRequest lists every image of a product in its new order, main picture first
+type ReorderProductImagesRequest struct {
+	ImageIDs []string `json:"image_ids" validate:"required,min=1,dive,uuid"`
+}
+
+// AddProductImage resizes an uploaded picture, stores it with its thumbnail and appends it to the
+// images of the product. The first image of a product becomes its main picture.
+func (uc *ProductUseCase) AddProductImage(ctx context.Context, productID string, file io.Reader) (*ProductImageResponse, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	images, err := uc.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) >= uc.storageConfig.MaxImagesPerProduct {
+		return nil, fmt.Errorf("%w of %d", appErrors.ErrTooManyImages, uc.storageConfig.MaxImagesPerProduct)
+	}
+
+	maxBytes := int64(uc.storageConfig.MaxFileSizeMB) * 1024 * 1024
+	content, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxBytes {
+		return nil, fmt.Errorf("%w: file exceeds %d MB", appErrors.ErrInvalidImage, uc.storageConfig.MaxFileSizeMB)
+	}
+
+	resized, err := imaging.Resize(content, uc.storageConfig.ImageMaxDimension)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedImage) {
+			return nil, fmt.Errorf("%w: use a JPEG, PNG, WebP or GIF picture", appErrors.ErrInvalidImage)
+		}
+		return nil, err
+	}
+	thumbnail, err := imaging.Resize(resized.Data, uc.storageConfig.ThumbnailDimension)
+	if err != nil {
+		return nil, err
+	}
+
+	name := uuid.New().String()
+	image := &entities.ProductImage{
+		ProductID:     productID,
+		ObjectPath:    fmt.Sprintf("products/%s%s", name, resized.Extension),
+		ThumbnailPath: fmt.Sprintf("products/%s_thumb%s", name, thumbnail.Extension),
+		Width:         resized.Width,
+		Height:        resized.Height,
+	}
+	if len(images) > 0 {
+		image.SortOrder = images[len(images)-1].SortOrder + 1
+	}
+
+	image.URL, err = uc.storageClient.UploadProductImage(ctx, image.ObjectPath, resized.ContentType, resized.Data)
+	if err != nil {
+		return nil, err
+	}
+	image.ThumbnailURL, err = uc.storageClient.UploadProductImage(ctx, image.ThumbnailPath, thumbnail.ContentType, thumbnail.Data)
+	if err != nil {
+		uc.deleteObjects(image.ObjectPath)
+		return nil, err
+	}
+
+	if err := uc.imageRepo.Create(ctx, image); err != nil {
+		uc.deleteObjects(image.ObjectPath, image.ThumbnailPath)
+		return nil, err
+	}
+
+	if err := uc.syncMainImage(ctx, product); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Product image added", "product_id", productID, "image_id", image.ID, "bytes", len(resized.Data))
+	return mapProductImageToResponse(image), nil
+}
+
+// DeleteProductImage removes an image from a product and from storage, the next image becomes the main
+// picture when it was the first
+func (uc *ProductUseCase) DeleteProductImage(ctx context.Context, productID, imageID string) error {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrProductNotFound
+		}
+		return err
+	}
+
+	image, err := uc.imageRepo.GetByID(ctx, productID, imageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrProductImageNotFound
+		}
+		return err
+	}
+
+	if err := uc.imageRepo.Delete(ctx, image.ID); err != nil {
+		return err
+	}
+	// The cleanup job removes whatever is left behind when storage is unavailable
+	uc.deleteObjects(image.ObjectPath, image.ThumbnailPath)
+
+	if err := uc.syncMainImage(ctx, product); err != nil {
+		return err
+	}
+
+	uc.logger.Info("Product image deleted", "product_id", productID, "image_id", imageID)
+	return nil
+}
+
+// ReorderProductImages saves the order of the images of a product, the first becomes its main picture
+func (uc *ProductUseCase) ReorderProductImages(ctx context.Context, productID string, req *ReorderProductImagesRequest) ([]ProductImageResponse, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	images, err := uc.imageRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]entities.ProductImage, len(images))
+	for _, image := range images {
+		byID[image.ID] = image
+	}
+	if len(req.ImageIDs) != len(images) {
+		return nil, fmt.Errorf("%w: list each of the %d images of the product once", appErrors.ErrInvalidInput, len(images))
+	}
+
+	ordered := make([]entities.ProductImage, 0, len(images))
+	for i, id := range req.ImageIDs {
+		image, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: list each of the %d images of the product once", appErrors.ErrInvalidInput, len(images))
+		}
+		delete(byID, id)
+		image.SortOrder = i
+		ordered = append(ordered, image)
+	}
+
+	if err := uc.imageRepo.UpdateSortOrders(ctx, ordered); err != nil {
+		return nil, err
+	}
+	if err := uc.syncMainImage(ctx, product); err != nil {
+		return nil, err
+	}
+
+	responses := make([]ProductImageResponse, len(ordered))
+	for i := range ordered {
+		responses[i] = *mapProductImageToResponse(&ordered[i])
+	}
+	return responses, nil
+}
+
+// CleanupOrphanImages deletes product images from storage that no product or product image refers to
+// anymore. Uploads younger than the grace period are kept, they may belong to a product being created.
+func (uc *ProductUseCase) CleanupOrphanImages(ctx context.Context) error {
+	objects, err := uc.storageClient.ListProductImages(ctx)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-time.Duration(uc.storageConfig.OrphanImageGraceHours) * time.Hour)
+	paths := make(map[string]string)
+	for _, object := range objects {
+		if object.CreatedAt.Before(cutoff) {
+			paths[uc.storageClient.GetPublicURL(object.Path)] = object.Path
+		}
+	}
+
+	urls := make([]string, 0, len(paths))
+	for url := range paths {
+		urls = append(urls, url)
+	}
+
+	deleted := 0
+	for start := 0; start < len(urls); start += orphanCheckBatch {
+		batch := urls[start:min(start+orphanCheckBatch, len(urls))]
+		referenced, err := uc.imageRepo.FilterReferenced(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, url := range batch {
+			if referenced[url] {
+				continue
+			}
+			if err := uc.storageClient.DeleteImage(paths[url]); err != nil {
+				uc.logger.Warn("Failed to delete orphaned product image", "error", err, "path", paths[url])
+				continue
+			}
+			deleted++
+		}
+	}
+
+	if deleted > 0 {
+		uc.logger.Info("Orphaned product images deleted", "count", deleted)
+	}
+	return nil
+}
+
+// syncMainImage mirrors the first image of a product to its image URL, the picture it replaces is
+// deleted from storage once nothing refers to it
+func (uc *ProductUseCase) syncMainImage(ctx context.Context, product *entities.Product) error {
+	images, err := uc.imageRepo.ListByProduct(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+
+	mainURL := ""
+	if len(images) > 0 {
+		mainURL = images[0].URL
+	}
+	if product.ImageURL == mainURL {
+		return nil
+	}
+
+	previous := product.ImageURL
+	product.ImageURL = mainURL
+	if err := uc.productRepo.Update(ctx, product); err != nil {
+		return err
+	}
+
+	uc.releaseImage(ctx, previous)
+	return nil
+}
+
+// releaseImage deletes a picture of the bucket that no product or product image refers to anymore.
+// Failures are left to the orphan image cleanup.
+func (uc *ProductUseCase) releaseImage(ctx context.Context, url string) {
+	if url == "" {
+		return
+	}
+	path := uc.storageClient.ObjectPath(url)
+	if path == url {
+		// Hosted elsewhere
+		return
+	}
+
+	referenced, err := uc.imageRepo.FilterReferenced(ctx, []string{url})
+	if err != nil {
+		uc.logger.Warn("Failed to check product image references", "error", err, "url", url)
+		return
+	}
+	if !referenced[url] {
+		uc.deleteObjects(path)
+	}
+}
+
+func (uc *ProductUseCase) deleteObjects(paths ...string) {
+	for _, path := range paths {
+		if err := uc.storageClient.DeleteImage(path); err != nil {
+			uc.logger.Warn("Failed to delete product image from storage", "error", err, "path", path)
+		}
+	}
+}
+
+func mapProductImageToResponse(image *entities.ProductImage) *ProductImageResponse {
+	return &ProductImageResponse{
+		ID:           image.ID,
+		URL:          image.URL,
+		ThumbnailURL: image.ThumbnailURL,
+		Width:        image.Width,
+		Height:       image.Height,
+		SortOrder:    image.SortOrder,
+	}
+}
@@ -0,0 +1,563 @@
+package product
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Catalog file formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const (
+	// maxProductImportRows keeps an import within one request's worth of work
+	maxProductImportRows = 5000
+	// exportBatchSize is how many products are read at a time while streaming an export
+	exportBatchSize = 500
+	// catalogSheet names the worksheet of an exported XLSX catalog
+	catalogSheet = "Products"
+)
+
+// catalogColumns is the layout of an exported catalog. An import accepts the same columns in any order,
+// so an export can be edited and imported back.
+var catalogColumns = []string{"sku", "barcode", "name", "description", "category", "price", "cost_price", "stock", "unit", "tax_class", "is_active", "image_url"}
+
+// ProductImportResult summarizes a catalog import. Rows with errors are skipped and the others applied,
+// so a file can be fixed and imported again. A dry run validates and counts without saving anything.
+type ProductImportResult struct {
+	DryRun    bool                 `json:"dry_run"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Errors    []ProductImportError `json:"errors,omitempty"`
+}
+
+type ProductImportError struct {
+	Row   int    `json:"row"` // Line in the file, the header is row 1
+	SKU   string `json:"sku,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// CatalogFormat returns the catalog format of a file name, empty when it is neither CSV nor XLSX
+func CatalogFormat(fileName string) string {
+	lower := strings.ToLower(fileName)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(lower, ".xlsx"):
+		return FormatXLSX
+	}
+	return ""
+}
+
+// ImportProducts creates or updates the products of the current store from a CSV or XLSX catalog with a
+// header row. Name is required, category takes a category name or ID. Products are matched by SKU, rows
+// without one always create a product. Empty optional cells keep the value of an existing product, and a
+// missing price is suggested from the category markup as when creating a product by hand.
+func (uc *ProductUseCase) ImportProducts(ctx context.Context, file io.Reader, format string, dryRun bool) (*ProductImportResult, error) {
+	storeID, ok := repositories.StoreFromContext(ctx)
+	if !ok {
+		return nil, appErrors.ErrStoreRequired
+	}
+
+	rows, err := readCatalogRows(file, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", appErrors.ErrInvalidImportFile)
+	}
+	if len(rows)-1 > maxProductImportRows {
+		return nil, fmt.Errorf("%w: more than %d products", appErrors.ErrInvalidImportFile, maxProductImportRows)
+	}
+
+	columns := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		// Spreadsheet apps may start the file with a byte order mark
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%w: missing name column", appErrors.ErrInvalidImportFile)
+	}
+
+	result := &ProductImportResult{DryRun: dryRun}
+	categories := make(map[string]*entities.Category)
+	seenSKUs := make(map[string]int)
+	seenBarcodes := make(map[string]int)
+
+	for i, record := range rows[1:] {
+		row := i + 2
+		field := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		name, sku := field("name"), field("sku")
+		rowError := func(message string) {
+			result.Errors = append(result.Errors, ProductImportError{Row: row, SKU: sku, Name: name, Error: message})
+		}
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if name == "" {
+			rowError("name is required")
+			continue
+		}
+		if len(name) > 255 {
+			rowError("name is longer than 255 characters")
+			continue
+		}
+		if sku != "" {
+			if first, ok := seenSKUs[sku]; ok {
+				rowError(fmt.Sprintf("SKU is already used on row %d", first))
+				continue
+			}
+			seenSKUs[sku] = row
+		}
+
+		barcode := field("barcode")
+		if barcode != "" {
+			if len(barcode) > 64 || !isPrintableASCII(barcode) {
+				rowError("barcode must be at most 64 printable ASCII characters")
+				continue
+			}
+			if first, ok := seenBarcodes[barcode]; ok {
+				rowError(fmt.Sprintf("barcode is already used on row %d", first))
+				continue
+			}
+			seenBarcodes[barcode] = row
+		}
+
+		price, err := optionalInt(field("price"), "price")
+		if err != nil {
+			rowError(err.Error())
+			continue
+		}
+		costPrice, err := optionalInt(field("cost_price"), "cost_price")
+		if err != nil {
+			rowError(err.Error())
+			continue
+		}
+		stock, err := optionalQuantity(field("stock"), "stock")
+		if err != nil {
+			rowError(err.Error())
+			continue
+		}
+		unit := entities.UnitOfMeasure(strings.ToLower(field("unit")))
+		if unit != "" && !unit.IsValid() {
+			rowError("unit must be pcs, kg or liter")
+			continue
+		}
+		taxClass := strings.ToLower(field("tax_class"))
+		if taxClass != "" && taxClass != string(entities.TaxClassStandard) && taxClass != string(entities.TaxClassExempt) {
+			rowError("tax_class must be standard or exempt")
+			continue
+		}
+		var isActive *bool
+		if value := field("is_active"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				rowError("is_active must be true or false")
+				continue
+			}
+			isActive = &parsed
+		}
+
+		var existing *entities.Product
+		if sku != "" {
+			existing, err = uc.productRepo.GetBySKU(ctx, sku)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+		}
+
+		var category *entities.Category
+		if ref := field("category"); ref != "" {
+			category, err = uc.importCategory(ctx, categories, ref)
+			if err != nil {
+				if errors.Is(err, appErrors.ErrCategoryNotFound) {
+					rowError(fmt.Sprintf("category %q not found", ref))
+					continue
+				}
+				return nil, err
+			}
+		} else if existing == nil {
+			rowError("category is required for a new product")
+			continue
+		}
+
+		existingID := ""
+		if existing != nil {
+			existingID = existing.ID
+		}
+		if barcode != "" && (existing == nil || existing.Barcode != barcode) {
+			if err := uc.ensureBarcodeAvailable(ctx, barcode, existingID); err != nil {
+				if errors.Is(err, appErrors.ErrBarcodeExists) {
+					rowError(err.Error())
+					continue
+				}
+				return nil, err
+			}
+		}
+
+		stockUnit := unit
+		if stockUnit == "" {
+			stockUnit = entities.UnitPiece
+			if existing != nil {
+				stockUnit = existing.Unit
+			}
+		}
+		if stock != nil {
+			if err := stockUnit.ValidateStock(*stock); err != nil {
+				rowError(err.Error())
+				continue
+			}
+		}
+
+		if existing == nil {
+			product := &entities.Product{
+				Name:        name,
+				Description: field("description"),
+				SKU:         sku,
+				Barcode:     barcode,
+				CategoryID:  category.ID,
+				StoreID:     storeID,
+				ImageURL:    field("image_url"),
+				TaxClass:    entities.TaxClassStandard,
+				Unit:        stockUnit,
+				IsActive:    true,
+			}
+			if costPrice != nil {
+				product.CostPrice = *costPrice
+			}
+			if price != nil && *price > 0 {
+				product.Price = *price
+			} else if product.Price = category.SuggestedPrice(product.CostPrice); product.Price == 0 {
+				rowError("price is required when the category has no default markup or cost price is not set")
+				continue
+			}
+			if stock != nil {
+				product.Stock = *stock
+			}
+			if taxClass != "" {
+				product.TaxClass = entities.TaxClass(taxClass)
+			}
+			if isActive != nil {
+				product.IsActive = *isActive
+			}
+
+			if !dryRun {
+				if product.SKU == "" {
+					if product.SKU, err = uc.generateSKU(ctx, category); err != nil {
+						return nil, err
+					}
+				}
+				if err := uc.productRepo.Create(ctx, product); err != nil {
+					uc.logger.Error("Failed to import product", "error", err, "sku", sku)
+					rowError(err.Error())
+					continue
+				}
+			}
+			result.Created++
+			continue
+		}
+
+		updated := *existing
+		if category != nil {
+			updated.CategoryID = category.ID
+		}
+		if value := field("description"); value != "" {
+			updated.Description = value
+		}
+		if barcode != "" {
+			updated.Barcode = barcode
+		}
+		if value := field("image_url"); value != "" {
+			updated.ImageURL = value
+		}
+		updated.Name = name
+		if price != nil {
+			updated.Price = *price
+		}
+		if costPrice != nil {
+			updated.CostPrice = *costPrice
+		}
+		if stock != nil {
+			updated.SetStock(*stock)
+		}
+		updated.Unit = stockUnit
+		if taxClass != "" {
+			updated.TaxClass = entities.TaxClass(taxClass)
+		}
+		if isActive != nil {
+			updated.IsActive = *isActive
+		}
+
+		if !productChanged(existing, &updated) {
+			result.Unchanged++
+			continue
+		}
+		if !dryRun {
+			// The preloaded category would be saved along, drop it so a moved product keeps its new one
+			updated.Category = entities.Category{}
+			if err := uc.productRepo.Update(ctx, &updated); err != nil {
+				uc.logger.Error("Failed to import product", "error", err, "product_id", existing.ID)
+				rowError(err.Error())
+				continue
+			}
+			if updated.Stock != existing.Stock {
+				uc.publishStockChanged(&updated)
+			}
+		}
+		result.Updated++
+	}
+
+	uc.logger.Info("Products imported",
+		"store_id", storeID,
+		"dry_run", dryRun,
+		"created", result.Created,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"errors", len(result.Errors))
+	return result, nil
+}
+
+// ExportProducts writes every product of the current store to w, active or not, in the import layout.
+// Products are read in batches so a large catalog is streamed rather than held in memory.
+func (uc *ProductUseCase) ExportProducts(ctx context.Context, w io.Writer, format string) error {
+	if _, ok := repositories.StoreFromContext(ctx); !ok {
+		return appErrors.ErrStoreRequired
+	}
+
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		err := uc.eachExportRow(ctx, func(row []string) error {
+			return writer.Write(row)
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case FormatXLSX:
+		workbook := excelize.NewFile()
+		defer workbook.Close()
+		if err := workbook.SetSheetName("Sheet1", catalogSheet); err != nil {
+			return err
+		}
+		stream, err := workbook.NewStreamWriter(catalogSheet)
+		if err != nil {
+			return err
+		}
+
+		line := 1
+		err = uc.eachExportRow(ctx, func(row []string) error {
+			cells := make([]interface{}, len(row))
+			for i, value := range row {
+				cells[i] = value
+			}
+			cell, err := excelize.CoordinatesToCellName(1, line)
+			if err != nil {
+				return err
+			}
+			line++
+			return stream.SetRow(cell, cells)
+		})
+		if err != nil {
+			return err
+		}
+		if err := stream.Flush(); err != nil {
+			return err
+		}
+		_, err = workbook.WriteTo(w)
+		return err
+	}
+
+	return fmt.Errorf("unsupported export format %q", format)
+}
+
+// eachExportRow calls write with the header and then a row per product
+func (uc *ProductUseCase) eachExportRow(ctx context.Context, write func(row []string) error) error {
+	if err := write(catalogColumns); err != nil {
+		return err
+	}
+
+	for offset := 0; ; offset += exportBatchSize {
+		products, err := uc.productRepo.List(ctx, repositories.ProductFilters{Limit: exportBatchSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			if err := write([]string{
+				product.SKU,
+				product.Barcode,
+				product.Name,
+				product.Description,
+				product.Category.Name,
+				strconv.FormatInt(product.Price, 10),
+				strconv.FormatInt(product.CostPrice, 10),
+				strconv.FormatFloat(product.Stock, 'f', -1, 64),
+				string(product.Unit),
+				string(product.TaxClass),
+				strconv.FormatBool(product.IsActive),
+				product.ImageURL,
+			}); err != nil {
+				return err
+			}
+		}
+		if len(products) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// importCategory resolves a category reference of an import row, by ID or by name ignoring case.
+// Lookups are remembered for the rest of the file.
+func (uc *ProductUseCase) importCategory(ctx context.Context, known map[string]*entities.Category, ref string) (*entities.Category, error) {
+	key := strings.ToLower(ref)
+	if category, ok := known[key]; ok {
+		if category == nil {
+			return nil, appErrors.ErrCategoryNotFound
+		}
+		return category, nil
+	}
+
+	var category *entities.Category
+	var err error
+	if _, parseErr := uuid.Parse(ref); parseErr == nil {
+		category, err = uc.categoryRepo.GetByID(ctx, ref)
+	} else {
+		category, err = uc.categoryRepo.GetByName(ctx, ref)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			known[key] = nil
+			return nil, appErrors.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	known[key] = category
+	return category, nil
+}
+
+// readCatalogRows reads every row of a catalog file, for XLSX from its first worksheet
+func readCatalogRows(file io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(file)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+
+		var rows [][]string
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return rows, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidImportFile, err)
+			}
+			if len(rows) > maxProductImportRows {
+				return nil, fmt.Errorf("%w: more than %d products", appErrors.ErrInvalidImportFile, maxProductImportRows)
+			}
+			rows = append(rows, record)
+		}
+
+	case FormatXLSX:
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidImportFile, err)
+		}
+		defer workbook.Close()
+
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("%w: the workbook has no sheet", appErrors.ErrInvalidImportFile)
+		}
+		rows, err := workbook.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidImportFile, err)
+		}
+		return rows, nil
+	}
+
+	return nil, fmt.Errorf("%w: upload a .csv or .xlsx file", appErrors.ErrInvalidImportFile)
+}
+
+// thousandsGrouped matches numbers written with thousands separators, such as 15.000 or 1,250,000
+var thousandsGrouped = regexp.MustCompile(`^\d{1,3}([.,]\d{3})+$`)
+
+// optionalInt parses a whole, non-negative number. Spreadsheets may format it with thousands separators.
+func optionalInt(value, column string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if thousandsGrouped.MatchString(value) {
+		value = strings.NewReplacer(".", "", ",", "").Replace(value)
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		return nil, fmt.Errorf("%s must be a whole number of at least 0", column)
+	}
+	return &parsed, nil
+}
+
+// optionalQuantity parses a non-negative number of units, with a point before the decimals like 0.5
+func optionalQuantity(value, column string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return nil, fmt.Errorf("%s must be a number of at least 0", column)
+	}
+	return &parsed, nil
+}
+
+func isPrintableASCII(value string) bool {
+	for _, r := range value {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func productChanged(before, after *entities.Product) bool {
+	return before.Name != after.Name ||
+		before.Description != after.Description ||
+		before.Barcode != after.Barcode ||
+		before.CategoryID != after.CategoryID ||
+		before.Price != after.Price ||
+		before.CostPrice != after.CostPrice ||
+		before.Stock != after.Stock ||
+		before.Unit != after.Unit ||
+		before.TaxClass != after.TaxClass ||
+		before.IsActive != after.IsActive ||
+		before.ImageURL != after.ImageURL
+}
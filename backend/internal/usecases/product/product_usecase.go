@@ -2,33 +2,51 @@ package product
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/internal/infrastructure/storage"
 	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type CreateProductRequest struct {
 	Name        string  `json:"name" validate:"required,min=1,max=255"`
 	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gte=0"`
-	Stock       int     `json:"stock" validate:"required,gte=0"`
+	Price       int64   `json:"price" validate:"gte=0"` // Suggested from cost price and category markup when omitted
+	CostPrice   int64   `json:"cost_price" validate:"gte=0"`
+	Stock       float64 `json:"stock" validate:"gte=0"` // In the product's unit
+	Unit        string  `json:"unit" validate:"omitempty,oneof=pcs kg liter"` // Defaults to pcs, kg and liter can be sold in fractions
 	CategoryID  string  `json:"category_id" validate:"required,uuid"`
-	SKU         string  `json:"sku"`
+	SKU         string  `json:"sku"` // Generated from the category prefix when omitted
+	Barcode     string  `json:"barcode" validate:"omitempty,max=64,printascii"`
+	TaxClass    string  `json:"tax_class" validate:"omitempty,oneof=standard exempt"` // Defaults to standard
 	ImageURL    string  `json:"image_url"`
 }
 
 type UpdateProductRequest struct {
 	Name        string  `json:"name" validate:"required,min=1,max=255"`
 	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gte=0"`
-	Stock       int     `json:"stock" validate:"required,gte=0"`
+	Price       int64   `json:"price" validate:"gte=0"`
+	CostPrice   *int64   `json:"cost_price" validate:"omitempty,gte=0"` // Unchanged when omitted
+	Stock       float64 `json:"stock" validate:"gte=0"`
+	Unit        string  `json:"unit" validate:"omitempty,oneof=pcs kg liter"` // Unchanged when omitted
 	CategoryID  string  `json:"category_id" validate:"required,uuid"`
-	SKU         string  `json:"sku"`
+	SKU         string  `json:"sku"` // Unchanged when omitted
+	Barcode     string  `json:"barcode" validate:"omitempty,max=64,printascii"`
+	TaxClass    string  `json:"tax_class" validate:"omitempty,oneof=standard exempt"` // Unchanged when omitted
 	ImageURL    string  `json:"image_url"`
 	IsActive    *bool   `json:"is_active"`
 }
@@ -37,61 +55,184 @@ type ProductResponse struct {
 	ID          string                 `json:"id"`
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
-	Price       float64                `json:"price"`
-	Stock       int                    `json:"stock"`
+	Price       int64                  `json:"price"`
+	CostPrice   int64                  `json:"cost_price" visible:"admin"`
+	Margin      int64                  `json:"margin" visible:"admin"` // Price minus cost price
+	Stock       float64                `json:"stock"`
+	Unit        entities.UnitOfMeasure `json:"unit"`
+	StoreID     string                 `json:"store_id"`
 	CategoryID  string                 `json:"category_id"`
 	SKU         string                 `json:"sku"`
+	Barcode     string                 `json:"barcode,omitempty"`
+	TaxClass    entities.TaxClass      `json:"tax_class"`
 	ImageURL    string                 `json:"image_url"`
 	IsActive    bool                   `json:"is_active"`
 	CreatedAt   string                 `json:"created_at"`
 	UpdatedAt   string                 `json:"updated_at"`
+	DeletedAt   string                 `json:"deleted_at,omitempty"` // Only set on deleted products, listed with include_deleted
+	SuggestedPrice int64               `json:"suggested_price,omitempty" visible:"admin"` // Only returned on create
+	PriceScheduleID *string            `json:"price_schedule_id,omitempty"` // Price schedule the current price comes from
 	Category    *CategoryResponse      `json:"category,omitempty"`
+	Images      []ProductImageResponse `json:"images,omitempty"`
+	PriceTiers  []PriceTierResponse    `json:"price_tiers,omitempty"` // Quantity breaks, lowest quantity first
 }
 
 type CategoryResponse struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	IsActive bool   `json:"is_active"`
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	ParentID      *string `json:"parent_id,omitempty"` // Top-level category when empty
+	SKUPrefix     string  `json:"sku_prefix,omitempty"`
+	DefaultMarkup float64 `json:"default_markup"`
+	IsActive      bool    `json:"is_active"`
+	DeletedAt     string  `json:"deleted_at,omitempty"` // Only set on deleted categories, listed with include_deleted
+}
+
+// CategoryNode is a category with its subcategories, sorted by name
+type CategoryNode struct {
+	CategoryResponse
+	Children []CategoryNode `json:"children"`
 }
 
 type CreateCategoryRequest struct {
-	Name string `json:"name" validate:"required,min=1,max=255"`
+	Name          string  `json:"name" validate:"required,min=1,max=255"`
+	ParentID      string  `json:"parent_id" validate:"omitempty,uuid"`             // Top-level category when empty
+	SKUPrefix     string  `json:"sku_prefix" validate:"omitempty,max=10,alphanum"` // Starts generated SKUs, e.g. BEV
+	DefaultMarkup float64 `json:"default_markup" validate:"gte=0,lte=1000"`        // Percentage
+}
+
+type MoveCategoryRequest struct {
+	ParentID string `json:"parent_id" validate:"omitempty,uuid"` // Empty to make it a top-level category
+}
+
+type DeleteCategoryResponse struct {
+	ProductsMoved int64 `json:"products_moved"`
 }
 
 type UpdateCategoryRequest struct {
-	Name     string `json:"name" validate:"required,min=1,max=255"`
-	IsActive *bool  `json:"is_active"`
+	Name          string   `json:"name" validate:"required,min=1,max=255"`
+	DefaultMarkup *float64 `json:"default_markup" validate:"omitempty,gte=0,lte=1000"` // Unchanged when omitted
+	SKUPrefix     *string  `json:"sku_prefix" validate:"omitempty,max=10,alphanum"`    // Unchanged when omitted, SKUs already generated keep theirs
+	IsActive      *bool    `json:"is_active"`
+}
+
+type MergeCategoryRequest struct {
+	TargetCategoryID string `json:"target_category_id" validate:"required,uuid"`
+}
+
+type MergeCategoryResponse struct {
+	Source        CategoryResponse `json:"source"`
+	Target        CategoryResponse `json:"target"`
+	ProductsMoved int64            `json:"products_moved"`
+}
+
+// CategoryImportResult summarizes a CSV import. Rows with errors are skipped and the others applied,
+// so a file can be fixed and imported again.
+type CategoryImportResult struct {
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Errors    []CategoryImportError `json:"errors,omitempty"`
+}
+
+type CategoryImportError struct {
+	Row   int    `json:"row"` // Line in the file, the header is row 1
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
 }
 
 type ProductFilters struct {
-	CategoryID string `form:"category_id"`
-	IsActive   *bool  `form:"is_active"`
-	Search     string `form:"search"`
-	Limit      int    `form:"limit,default=20" validate:"gte=1,lte=100"`
-	Offset     int    `form:"offset,default=0" validate:"gte=0"`
+	CategoryID     string `form:"category_id"`
+	IsActive       *bool  `form:"is_active"`
+	Search         string `form:"search" validate:"max=100"` // Ranked by relevance, typos tolerated
+	MinPrice       *int64 `form:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice       *int64 `form:"max_price" validate:"omitempty,gte=0"`
+	Sort           string `form:"sort" validate:"omitempty,oneof=newest popular"` // popular lists fast movers first; ignored when searching
+	IncludeDeleted bool   `form:"include_deleted"`                                // Admins only; ignored when searching
+	Limit          int    `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset         int    `form:"offset,default=0" validate:"gte=0"`
+}
+
+type PriceHistoryFilters struct {
+	Limit  int `form:"limit" validate:"omitempty,gte=1,lte=100"`
+	Offset int `form:"offset" validate:"omitempty,gte=0"`
+}
+
+type BulkPriceUpdateRequest struct {
+	CategoryID string                       `json:"category_id" validate:"omitempty,uuid"`
+	ProductIDs []string                     `json:"product_ids" validate:"omitempty,dive,uuid"`
+	Mode       entities.PriceAdjustmentMode `json:"mode" validate:"required,oneof=percentage fixed"`
+	Value      float64                      `json:"value" validate:"required"` // Negative values lower prices
+	Reason     string                       `json:"reason" validate:"max=255"`
+	Preview    bool                         `json:"preview"` // Only calculate, don't apply
+}
+
+type BulkPriceUpdateResponse struct {
+	BatchID string             `json:"batch_id,omitempty"`
+	Preview bool               `json:"preview"`
+	Changes []PriceChangeEntry `json:"changes"`
+}
+
+type PriceChangeEntry struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	CostPrice int64   `json:"cost_price,omitempty" visible:"admin"`
+	OldPrice  int64   `json:"old_price"`
+	NewPrice  int64   `json:"new_price"`
+}
+
+// SuggestedPriceRequest recalculates sale prices from cost prices and category markups
+type SuggestedPriceRequest struct {
+	CategoryID string   `json:"category_id" validate:"omitempty,uuid"`
+	ProductIDs []string `json:"product_ids" validate:"omitempty,dive,uuid"`
+	Reason     string   `json:"reason" validate:"max=255"`
+	Preview    bool     `json:"preview"` // Only calculate, don't apply
 }
 
 type ProductUseCase struct {
-	productRepo  repositories.ProductRepository
-	categoryRepo repositories.CategoryRepository
-	logger       logger.Logger
+	productRepo   repositories.ProductRepository
+	categoryRepo  repositories.CategoryRepository
+	imageRepo     repositories.ProductImageRepository
+	storageClient storage.Storage
+	eventBroker   *events.Broker
+	jobsConfig    config.JobsConfig
+	storageConfig config.StorageConfig
+	skuConfig     config.SKUConfig
+	logger        logger.Logger
 }
 
 func NewProductUseCase(
 	productRepo repositories.ProductRepository,
 	categoryRepo repositories.CategoryRepository,
+	imageRepo repositories.ProductImageRepository,
+	storageClient storage.Storage,
+	eventBroker *events.Broker,
+	jobsConfig config.JobsConfig,
+	storageConfig config.StorageConfig,
+	skuConfig config.SKUConfig,
 	logger logger.Logger,
 ) *ProductUseCase {
 	return &ProductUseCase{
-		productRepo:  productRepo,
-		categoryRepo: categoryRepo,
-		logger:       logger,
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+		imageRepo:     imageRepo,
+		storageClient: storageClient,
+		eventBroker:   eventBroker,
+		jobsConfig:    jobsConfig,
+		storageConfig: storageConfig,
+		skuConfig:     skuConfig,
+		logger:        logger,
 	}
 }
 
 func (uc *ProductUseCase) CreateProduct(ctx context.Context, req *CreateProductRequest) (*ProductResponse, error) {
+	// Products are added to the store the admin works in, SKUs and barcodes only need to be unique there
+	storeID, ok := repositories.StoreFromContext(ctx)
+	if !ok {
+		return nil, appErrors.ErrStoreRequired
+	}
+
 	// Validate category exists
-	_, err := uc.categoryRepo.GetByID(ctx, req.CategoryID)
+	category, err := uc.categoryRepo.GetByID(ctx, req.CategoryID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("category not found")
@@ -99,8 +240,22 @@ func (uc *ProductUseCase) CreateProduct(ctx context.Context, req *CreateProductR
 		return nil, err
 	}
 
-	// Check if SKU already exists (if provided)
-	if req.SKU != "" {
+	suggestedPrice := category.SuggestedPrice(req.CostPrice)
+	price := req.Price
+	if price == 0 {
+		if suggestedPrice == 0 {
+			return nil, errors.New("price is required when the category has no default markup or cost price is not set")
+		}
+		price = suggestedPrice
+	}
+
+	// Check if SKU already exists (if provided), a generated one is unique by construction
+	sku := req.SKU
+	if sku == "" {
+		if sku, err = uc.generateSKU(ctx, category); err != nil {
+			return nil, err
+		}
+	} else {
 		existingProduct, err := uc.productRepo.GetBySKU(ctx, req.SKU)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
@@ -110,13 +265,30 @@ func (uc *ProductUseCase) CreateProduct(ctx context.Context, req *CreateProductR
 		}
 	}
 
-	product, err := entities.NewProduct(req.Name, req.Description, req.SKU, req.CategoryID, req.Price, req.Stock)
+	if err := uc.ensureBarcodeAvailable(ctx, req.Barcode, ""); err != nil {
+		return nil, err
+	}
+
+	product, err := entities.NewProduct(req.Name, req.Description, sku, req.CategoryID, price, req.Stock)
 	if err != nil {
 		return nil, err
 	}
 	
+	product.StoreID = storeID
+
 	// Set image URL if provided
 	product.ImageURL = req.ImageURL
+	product.Barcode = req.Barcode
+	product.CostPrice = req.CostPrice
+	if req.TaxClass != "" {
+		product.TaxClass = entities.TaxClass(req.TaxClass)
+	}
+	if req.Unit != "" {
+		product.Unit = entities.UnitOfMeasure(req.Unit)
+	}
+	if err := product.Unit.ValidateStock(req.Stock); err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
 
 	if err := uc.productRepo.Create(ctx, product); err != nil {
 		uc.logger.Error("Failed to create product", "error", err)
@@ -130,7 +302,9 @@ func (uc *ProductUseCase) CreateProduct(ctx context.Context, req *CreateProductR
 	}
 
 	uc.logger.Info("Product created successfully", "product_id", product.ID, "name", product.Name)
-	return uc.mapProductToResponse(createdProduct), nil
+	response := uc.mapProductToResponse(createdProduct)
+	response.SuggestedPrice = suggestedPrice
+	return response, nil
 }
 
 func (uc *ProductUseCase) GetProduct(ctx context.Context, id string) (*ProductResponse, error) {
@@ -145,6 +319,19 @@ func (uc *ProductUseCase) GetProduct(ctx context.Context, id string) (*ProductRe
 	return uc.mapProductToResponse(product), nil
 }
 
+// GetProductByBarcode looks up the product of a scanned barcode or SKU
+func (uc *ProductUseCase) GetProductByBarcode(ctx context.Context, code string) (*ProductResponse, error) {
+	product, err := uc.productRepo.GetByBarcode(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	return uc.mapProductToResponse(product), nil
+}
+
 func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest) (*ProductResponse, error) {
 	product, err := uc.productRepo.GetByID(ctx, id)
 	if err != nil {
@@ -174,19 +361,47 @@ func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *Upd
 		}
 	}
 
+	if req.Barcode != product.Barcode {
+		if err := uc.ensureBarcodeAvailable(ctx, req.Barcode, id); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update product fields
 	product.Name = req.Name
 	product.Description = req.Description
+	if req.Price != product.Price {
+		// A price set by hand is the new list price, even while a schedule is in effect
+		product.PriceScheduleID = nil
+	}
 	product.Price = req.Price
-	product.Stock = req.Stock
+	if req.Unit != "" {
+		product.Unit = entities.UnitOfMeasure(req.Unit)
+	}
+	if err := product.Unit.ValidateStock(req.Stock); err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+	product.SetStock(req.Stock)
 	product.CategoryID = req.CategoryID
-	product.SKU = req.SKU
+	if req.SKU != "" {
+		product.SKU = req.SKU
+	}
+	product.Barcode = req.Barcode
+	previousImageURL := product.ImageURL
 	product.ImageURL = req.ImageURL
 
 	if req.IsActive != nil {
 		product.IsActive = *req.IsActive
 	}
 
+	if req.CostPrice != nil {
+		product.CostPrice = *req.CostPrice
+	}
+
+	if req.TaxClass != "" {
+		product.TaxClass = entities.TaxClass(req.TaxClass)
+	}
+
 	if err := uc.productRepo.Update(ctx, product); err != nil {
 		uc.logger.Error("Failed to update product", "error", err, "product_id", id)
 		return nil, err
@@ -198,7 +413,12 @@ func (uc *ProductUseCase) UpdateProduct(ctx context.Context, id string, req *Upd
 		return nil, err
 	}
 
+	if previousImageURL != req.ImageURL {
+		uc.releaseImage(ctx, previousImageURL)
+	}
+
 	uc.logger.Info("Product updated successfully", "product_id", id)
+	uc.publishStockChanged(updatedProduct)
 	return uc.mapProductToResponse(updatedProduct), nil
 }
 
@@ -220,19 +440,72 @@ func (uc *ProductUseCase) DeleteProduct(ctx context.Context, id string) error {
 	return nil
 }
 
+// RestoreProduct brings a deleted product back. It is refused while another product uses its SKU or
+// barcode, or while its category is deleted.
+func (uc *ProductUseCase) RestoreProduct(ctx context.Context, id string) (*ProductResponse, error) {
+	product, err := uc.productRepo.GetDeletedByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := uc.categoryRepo.GetByID(ctx, product.CategoryID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: the category of the product is deleted, restore it first", appErrors.ErrInvalidInput)
+		}
+		return nil, err
+	}
+	if product.SKU != "" {
+		if _, err := uc.productRepo.GetBySKU(ctx, product.SKU); err == nil {
+			return nil, appErrors.ErrSKUExists
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	if product.Barcode != "" {
+		if existing, err := uc.productRepo.GetByBarcode(ctx, product.Barcode); err == nil && existing.Barcode == product.Barcode {
+			return nil, appErrors.ErrBarcodeExists
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if err := uc.productRepo.Restore(ctx, id); err != nil {
+		uc.logger.Error("Failed to restore product", "error", err, "product_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Product restored successfully", "product_id", id, "name", product.Name)
+	return uc.GetProduct(ctx, id)
+}
+
 func (uc *ProductUseCase) ListProducts(ctx context.Context, filters *ProductFilters) ([]ProductResponse, error) {
 	repoFilters := repositories.ProductFilters{
 		CategoryID: filters.CategoryID,
 		IsActive:   filters.IsActive,
+		MinPrice:   filters.MinPrice,
+		MaxPrice:   filters.MaxPrice,
+		SortBy:     filters.Sort,
 		Limit:      filters.Limit,
 		Offset:     filters.Offset,
+
+		IncludeDeleted: filters.IncludeDeleted,
 	}
 
 	var products []entities.Product
 	var err error
 
 	if filters.Search != "" {
-		products, err = uc.productRepo.Search(ctx, filters.Search, filters.Limit)
+		products, err = uc.productRepo.Search(ctx, repositories.ProductSearchFilters{
+			Query:      filters.Search,
+			CategoryID: filters.CategoryID,
+			MinPrice:   filters.MinPrice,
+			MaxPrice:   filters.MaxPrice,
+			Limit:      filters.Limit,
+			Offset:     filters.Offset,
+		})
 	} else {
 		products, err = uc.productRepo.List(ctx, repoFilters)
 	}
@@ -250,7 +523,21 @@ func (uc *ProductUseCase) ListProducts(ctx context.Context, filters *ProductFilt
 	return responses, nil
 }
 
-func (uc *ProductUseCase) UpdateStock(ctx context.Context, id string, quantity int) (*ProductResponse, error) {
+// RollupPopularity recounts the units each product sold in the popularity window. It is meant to be
+// called periodically by the scheduler; between runs the popular sort uses the last counts.
+func (uc *ProductUseCase) RollupPopularity(ctx context.Context) error {
+	since := time.Now().AddDate(0, 0, -uc.jobsConfig.PopularityWindowDays)
+	products, err := uc.productRepo.RefreshPopularity(ctx, since)
+	if err != nil {
+		uc.logger.Error("Failed to roll up product popularity", "error", err)
+		return err
+	}
+
+	uc.logger.Info("Product popularity rolled up", "products", products, "since", since.Format(time.RFC3339))
+	return nil
+}
+
+func (uc *ProductUseCase) UpdateStock(ctx context.Context, id string, quantity float64) (*ProductResponse, error) {
 	product, err := uc.productRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -269,14 +556,188 @@ func (uc *ProductUseCase) UpdateStock(ctx context.Context, id string, quantity i
 	}
 
 	uc.logger.Info("Product stock updated", "product_id", id, "quantity_change", quantity, "new_stock", product.Stock)
+	uc.publishStockChanged(product)
 	return uc.mapProductToResponse(product), nil
 }
 
+// BulkUpdatePrices adjusts the prices of a category or product selection.
+// In preview mode the new prices are only calculated.
+func (uc *ProductUseCase) BulkUpdatePrices(ctx context.Context, userID string, req *BulkPriceUpdateRequest) (*BulkPriceUpdateResponse, error) {
+	var products []entities.Product
+	var err error
+
+	switch {
+	case len(req.ProductIDs) > 0:
+		products, err = uc.productRepo.GetByIDs(ctx, req.ProductIDs)
+	case req.CategoryID != "":
+		products, err = uc.productRepo.List(ctx, repositories.ProductFilters{CategoryID: req.CategoryID})
+	default:
+		return nil, errors.New("category_id or product_ids is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, appErrors.ErrProductNotFound
+	}
+
+	result := &BulkPriceUpdateResponse{
+		Preview: req.Preview,
+		Changes: make([]PriceChangeEntry, 0, len(products)),
+	}
+	batchID := uuid.New().String()
+	changes := make([]entities.PriceChange, 0, len(products))
+
+	for _, product := range products {
+		newPrice, err := product.AdjustedPrice(req.Mode, req.Value)
+		if err != nil {
+			return nil, fmt.Errorf("product %s: %w", product.Name, err)
+		}
+
+		result.Changes = append(result.Changes, PriceChangeEntry{
+			ProductID: product.ID,
+			Name:      product.Name,
+			OldPrice:  product.Price,
+			NewPrice:  newPrice,
+		})
+		changes = append(changes, entities.PriceChange{
+			ProductID: product.ID,
+			BatchID:   batchID,
+			OldPrice:  product.Price,
+			NewPrice:  newPrice,
+			Source:    entities.PriceChangeBulk,
+			ChangedBy: userID,
+			Reason:    req.Reason,
+		})
+	}
+
+	if req.Preview {
+		return result, nil
+	}
+
+	if err := uc.productRepo.ApplyPriceChanges(ctx, changes); err != nil {
+		uc.logger.Error("Failed to apply bulk price update", "error", err, "batch_id", batchID)
+		return nil, err
+	}
+
+	result.BatchID = batchID
+	uc.logger.Info("Bulk price update applied",
+		"batch_id", batchID,
+		"products", len(changes),
+		"mode", req.Mode,
+		"value", req.Value,
+		"changed_by", userID)
+
+	return result, nil
+}
+
+// RecalculateSuggestedPrices sets the prices of a category or product selection to their cost price
+// plus the category's default markup. Products without a cost price or markup are left out.
+func (uc *ProductUseCase) RecalculateSuggestedPrices(ctx context.Context, userID string, req *SuggestedPriceRequest) (*BulkPriceUpdateResponse, error) {
+	var products []entities.Product
+	var err error
+
+	switch {
+	case len(req.ProductIDs) > 0:
+		products, err = uc.productRepo.GetByIDs(ctx, req.ProductIDs)
+	case req.CategoryID != "":
+		products, err = uc.productRepo.List(ctx, repositories.ProductFilters{CategoryID: req.CategoryID})
+	default:
+		return nil, errors.New("category_id or product_ids is required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, appErrors.ErrProductNotFound
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "Recalculated from cost price and category markup"
+	}
+
+	result := &BulkPriceUpdateResponse{
+		Preview: req.Preview,
+		Changes: make([]PriceChangeEntry, 0, len(products)),
+	}
+	batchID := uuid.New().String()
+	changes := make([]entities.PriceChange, 0, len(products))
+
+	for _, product := range products {
+		newPrice := product.Category.SuggestedPrice(product.CostPrice)
+		if newPrice == 0 || newPrice == product.Price {
+			continue
+		}
+
+		result.Changes = append(result.Changes, PriceChangeEntry{
+			ProductID: product.ID,
+			Name:      product.Name,
+			CostPrice: product.CostPrice,
+			OldPrice:  product.Price,
+			NewPrice:  newPrice,
+		})
+		changes = append(changes, entities.PriceChange{
+			ProductID: product.ID,
+			BatchID:   batchID,
+			OldPrice:  product.Price,
+			NewPrice:  newPrice,
+			Source:    entities.PriceChangeSuggested,
+			ChangedBy: userID,
+			Reason:    reason,
+		})
+	}
+
+	if req.Preview || len(changes) == 0 {
+		return result, nil
+	}
+
+	if err := uc.productRepo.ApplyPriceChanges(ctx, changes); err != nil {
+		uc.logger.Error("Failed to apply suggested prices", "error", err, "batch_id", batchID)
+		return nil, err
+	}
+
+	result.BatchID = batchID
+	uc.logger.Info("Suggested prices applied",
+		"batch_id", batchID,
+		"products", len(changes),
+		"changed_by", userID)
+
+	return result, nil
+}
+
+// GetPriceHistory returns the price changes of a product, latest first, whether from a bulk update, the
+// suggested prices or a price schedule
+func (uc *ProductUseCase) GetPriceHistory(ctx context.Context, id string, filters *PriceHistoryFilters) ([]entities.PriceChange, error) {
+	if _, err := uc.productRepo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	limit := filters.Limit
+	if limit == 0 {
+		limit = 50
+	}
+	return uc.productRepo.ListPriceChanges(ctx, id, limit, filters.Offset)
+}
+
 // Category operations
 func (uc *ProductUseCase) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*CategoryResponse, error) {
 	category := &entities.Category{
-		Name:     req.Name,
-		IsActive: true,
+		Name:          req.Name,
+		SKUPrefix:     strings.ToUpper(req.SKUPrefix),
+		DefaultMarkup: req.DefaultMarkup,
+		IsActive:      true,
+	}
+	if req.ParentID != "" {
+		if _, err := uc.getCategory(ctx, req.ParentID); err != nil {
+			return nil, err
+		}
+		category.ParentID = &req.ParentID
 	}
 
 	if err := uc.categoryRepo.Create(ctx, category); err != nil {
@@ -288,8 +749,38 @@ func (uc *ProductUseCase) CreateCategory(ctx context.Context, req *CreateCategor
 	return uc.mapCategoryToResponse(category), nil
 }
 
-func (uc *ProductUseCase) ListCategories(ctx context.Context, limit, offset int) ([]CategoryResponse, error) {
-	categories, err := uc.categoryRepo.List(ctx, limit, offset)
+func (uc *ProductUseCase) UpdateCategory(ctx context.Context, id string, req *UpdateCategoryRequest) (*CategoryResponse, error) {
+	category, err := uc.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+
+	category.Name = req.Name
+	if req.DefaultMarkup != nil {
+		category.DefaultMarkup = *req.DefaultMarkup
+	}
+	if req.SKUPrefix != nil {
+		category.SKUPrefix = strings.ToUpper(*req.SKUPrefix)
+	}
+	if req.IsActive != nil {
+		category.IsActive = *req.IsActive
+	}
+
+	if err := uc.categoryRepo.Update(ctx, category); err != nil {
+		uc.logger.Error("Failed to update category", "error", err, "category_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Category updated successfully", "category_id", id, "default_markup", category.DefaultMarkup)
+	return uc.mapCategoryToResponse(category), nil
+}
+
+// ListCategories lists categories by name, deleted ones too when includeDeleted is set
+func (uc *ProductUseCase) ListCategories(ctx context.Context, limit, offset int, includeDeleted bool) ([]CategoryResponse, error) {
+	categories, err := uc.categoryRepo.List(ctx, limit, offset, includeDeleted)
 	if err != nil {
 		uc.logger.Error("Failed to list categories", "error", err)
 		return nil, err
@@ -303,32 +794,430 @@ func (uc *ProductUseCase) ListCategories(ctx context.Context, limit, offset int)
 	return responses, nil
 }
 
+// ListCategoryTree returns every category nested under its parent, top-level categories first
+func (uc *ProductUseCase) ListCategoryTree(ctx context.Context) ([]CategoryNode, error) {
+	categories, err := uc.categoryRepo.ListAll(ctx)
+	if err != nil {
+		uc.logger.Error("Failed to list categories", "error", err)
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(categories))
+	children := make(map[string][]*entities.Category)
+	for i := range categories {
+		ids[categories[i].ID] = true
+	}
+	for i := range categories {
+		category := &categories[i]
+		// A category whose parent was deleted is shown at the top level rather than lost
+		parent := ""
+		if category.ParentID != nil && ids[*category.ParentID] {
+			parent = *category.ParentID
+		}
+		children[parent] = append(children[parent], category)
+	}
+
+	var build func(parent string) []CategoryNode
+	build = func(parent string) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(children[parent]))
+		for _, category := range children[parent] {
+			nodes = append(nodes, CategoryNode{
+				CategoryResponse: *uc.mapCategoryToResponse(category),
+				Children:         build(category.ID),
+			})
+		}
+		return nodes
+	}
+	return build(""), nil
+}
+
+// MoveCategory files a category, with its subcategories, under another one or at the top level
+func (uc *ProductUseCase) MoveCategory(ctx context.Context, id string, req *MoveCategoryRequest) (*CategoryResponse, error) {
+	category, err := uc.getCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID == "" {
+		category.ParentID = nil
+	} else {
+		if _, err := uc.getCategory(ctx, req.ParentID); err != nil {
+			return nil, err
+		}
+
+		// Walk up from the new parent, meeting the category on the way means it would end up inside itself
+		categories, err := uc.categoryRepo.ListAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parents := make(map[string]*string, len(categories))
+		for _, c := range categories {
+			parents[c.ID] = c.ParentID
+		}
+		for current := &req.ParentID; current != nil; current = parents[*current] {
+			if *current == id {
+				return nil, appErrors.ErrCategoryCycle
+			}
+		}
+		category.ParentID = &req.ParentID
+	}
+
+	if err := uc.categoryRepo.Update(ctx, category); err != nil {
+		uc.logger.Error("Failed to move category", "error", err, "category_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Category moved", "category_id", id, "parent_id", req.ParentID)
+	return uc.mapCategoryToResponse(category), nil
+}
+
+// DeleteCategory deletes a category once no product is filed under it, or after moving its products and
+// promotions to reassignTo. Its subcategories move up to its parent.
+func (uc *ProductUseCase) DeleteCategory(ctx context.Context, id, reassignTo string) (*DeleteCategoryResponse, error) {
+	category, err := uc.getCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if reassignTo == "" {
+		count, err := uc.categoryRepo.CountProducts(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, appErrors.ErrCategoryHasProducts
+		}
+	} else {
+		if reassignTo == id {
+			return nil, appErrors.ErrCategoryMergeSelf
+		}
+		if _, err := uc.getCategory(ctx, reassignTo); err != nil {
+			return nil, err
+		}
+	}
+
+	moved, err := uc.categoryRepo.DeleteAndReassign(ctx, category, reassignTo)
+	if err != nil {
+		uc.logger.Error("Failed to delete category", "error", err, "category_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Category deleted", "category_id", id, "reassigned_to", reassignTo, "products_moved", moved)
+	return &DeleteCategoryResponse{ProductsMoved: moved}, nil
+}
+
+// RestoreCategory brings a deleted category back without the subcategories and products moved away when
+// it was deleted. It is refused while another category has its name or while its parent is deleted.
+func (uc *ProductUseCase) RestoreCategory(ctx context.Context, id string) (*CategoryResponse, error) {
+	category, err := uc.categoryRepo.GetDeletedByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := uc.categoryRepo.GetByName(ctx, category.Name); err == nil {
+		return nil, appErrors.ErrCategoryExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if category.ParentID != nil {
+		if _, err := uc.categoryRepo.GetByID(ctx, *category.ParentID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: the parent category is deleted, restore it first", appErrors.ErrInvalidInput)
+			}
+			return nil, err
+		}
+	}
+
+	if err := uc.categoryRepo.Restore(ctx, id); err != nil {
+		uc.logger.Error("Failed to restore category", "error", err, "category_id", id)
+		return nil, err
+	}
+
+	category.DeletedAt = gorm.DeletedAt{}
+	uc.logger.Info("Category restored successfully", "category_id", id, "name", category.Name)
+	return uc.mapCategoryToResponse(category), nil
+}
+
+func (uc *ProductUseCase) getCategory(ctx context.Context, id string) (*entities.Category, error) {
+	category, err := uc.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// maxCategoryImportRows keeps an import within one request's worth of work
+const maxCategoryImportRows = 1000
+
+// ImportCategories creates or updates categories from a CSV with a header row. The name column is
+// required; default_markup and is_active are optional and leave existing categories unchanged when empty.
+// Names are matched ignoring case so a reimport updates instead of duplicating.
+func (uc *ProductUseCase) ImportCategories(ctx context.Context, file io.Reader) (*CategoryImportResult, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidImportFile, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet apps may start the file with a byte order mark
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%w: missing name column", appErrors.ErrInvalidImportFile)
+	}
+
+	result := &CategoryImportResult{}
+	seen := make(map[string]int)
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidImportFile, err)
+		}
+		if row-1 > maxCategoryImportRows {
+			return nil, fmt.Errorf("%w: more than %d categories", appErrors.ErrInvalidImportFile, maxCategoryImportRows)
+		}
+
+		field := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		name := field("name")
+		rowError := func(message string) {
+			result.Errors = append(result.Errors, CategoryImportError{Row: row, Name: name, Error: message})
+		}
+
+		if name == "" {
+			if strings.TrimSpace(strings.Join(record, "")) != "" {
+				rowError("name is required")
+			}
+			continue
+		}
+		if len(name) > 255 {
+			rowError("name is longer than 255 characters")
+			continue
+		}
+		key := strings.ToLower(name)
+		if first, ok := seen[key]; ok {
+			rowError(fmt.Sprintf("duplicate of row %d", first))
+			continue
+		}
+		seen[key] = row
+
+		var markup *float64
+		if value := field("default_markup"); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || parsed > 1000 {
+				rowError("default_markup must be a percentage between 0 and 1000")
+				continue
+			}
+			markup = &parsed
+		}
+		var isActive *bool
+		if value := field("is_active"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				rowError("is_active must be true or false")
+				continue
+			}
+			isActive = &parsed
+		}
+
+		existing, err := uc.categoryRepo.GetByName(ctx, name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		if existing == nil {
+			category := &entities.Category{Name: name, IsActive: true}
+			if markup != nil {
+				category.DefaultMarkup = *markup
+			}
+			if isActive != nil {
+				category.IsActive = *isActive
+			}
+			if err := uc.categoryRepo.Create(ctx, category); err != nil {
+				uc.logger.Error("Failed to import category", "error", err, "name", name)
+				rowError(err.Error())
+				continue
+			}
+			result.Created++
+			continue
+		}
+
+		changed := false
+		if markup != nil && *markup != existing.DefaultMarkup {
+			existing.DefaultMarkup = *markup
+			changed = true
+		}
+		if isActive != nil && *isActive != existing.IsActive {
+			existing.IsActive = *isActive
+			changed = true
+		}
+		if !changed {
+			result.Unchanged++
+			continue
+		}
+		if err := uc.categoryRepo.Update(ctx, existing); err != nil {
+			uc.logger.Error("Failed to import category", "error", err, "category_id", existing.ID)
+			rowError(err.Error())
+			continue
+		}
+		result.Updated++
+	}
+
+	uc.logger.Info("Categories imported",
+		"created", result.Created,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"errors", len(result.Errors))
+	return result, nil
+}
+
+// MergeCategory moves every product of a category to another one and deactivates it. Promotions scoped
+// to the category follow its products. The source is kept so past reports still show its name.
+func (uc *ProductUseCase) MergeCategory(ctx context.Context, sourceID string, req *MergeCategoryRequest) (*MergeCategoryResponse, error) {
+	if sourceID == req.TargetCategoryID {
+		return nil, appErrors.ErrCategoryMergeSelf
+	}
+
+	source, err := uc.categoryRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	target, err := uc.categoryRepo.GetByID(ctx, req.TargetCategoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	moved, err := uc.categoryRepo.Merge(ctx, source.ID, target.ID)
+	if err != nil {
+		uc.logger.Error("Failed to merge categories", "error", err, "source_id", source.ID, "target_id", target.ID)
+		return nil, err
+	}
+	source.IsActive = false
+
+	uc.logger.Info("Categories merged", "source_id", source.ID, "target_id", target.ID, "products_moved", moved)
+	return &MergeCategoryResponse{
+		Source:        *uc.mapCategoryToResponse(source),
+		Target:        *uc.mapCategoryToResponse(target),
+		ProductsMoved: moved,
+	}, nil
+}
+
+// generateSKU makes the SKU of a product created without one from its category prefix, or the configured
+// default, and the next number of a database sequence. The number is never handed out twice, so the SKU
+// needs no uniqueness check.
+func (uc *ProductUseCase) generateSKU(ctx context.Context, category *entities.Category) (string, error) {
+	number, err := uc.productRepo.NextSKUNumber(ctx)
+	if err != nil {
+		uc.logger.Error("Failed to draw SKU number", "error", err)
+		return "", err
+	}
+
+	prefix := category.SKUPrefix
+	if prefix == "" {
+		prefix = uc.skuConfig.DefaultPrefix
+	}
+	return fmt.Sprintf("%s-%0*d", prefix, uc.skuConfig.Digits, number), nil
+}
+
+// ensureBarcodeAvailable rejects a barcode already printed on another product
+func (uc *ProductUseCase) ensureBarcodeAvailable(ctx context.Context, barcode, productID string) error {
+	if barcode == "" {
+		return nil
+	}
+
+	existingProduct, err := uc.productRepo.GetByBarcode(ctx, barcode)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existingProduct != nil && existingProduct.Barcode == barcode && existingProduct.ID != productID {
+		return appErrors.ErrBarcodeExists
+	}
+	return nil
+}
+
+func (uc *ProductUseCase) publishStockChanged(product *entities.Product) {
+	uc.eventBroker.Publish(events.Event{
+		Type:     events.EventStockChanged,
+		TenantID: events.TenantOf(product.TenantID),
+		Data:     &events.StockChange{ProductID: product.ID, Stock: product.Stock},
+	})
+}
+
 func (uc *ProductUseCase) mapProductToResponse(product *entities.Product) *ProductResponse {
 	response := &ProductResponse{
 		ID:          product.ID,
 		Name:        product.Name,
 		Description: product.Description,
 		Price:       product.Price,
+		CostPrice:   product.CostPrice,
+		Margin:      product.Price - product.CostPrice,
 		Stock:       product.Stock,
+		Unit:        product.Unit,
+		StoreID:     product.StoreID,
 		CategoryID:  product.CategoryID,
 		SKU:         product.SKU,
+		Barcode:     product.Barcode,
+		TaxClass:    product.TaxClass,
 		ImageURL:    product.ImageURL,
 		IsActive:    product.IsActive,
+		PriceScheduleID: product.PriceScheduleID,
 		CreatedAt:   product.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:   product.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
+	if product.DeletedAt.Valid {
+		response.DeletedAt = product.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
 
 	if product.Category.ID != "" {
 		response.Category = uc.mapCategoryToResponse(&product.Category)
 	}
 
+	for i := range product.Images {
+		response.Images = append(response.Images, *mapProductImageToResponse(&product.Images[i]))
+	}
+
+	for _, tier := range product.PriceTiers {
+		response.PriceTiers = append(response.PriceTiers, PriceTierResponse{MinQuantity: tier.MinQuantity, Price: tier.Price})
+	}
+
 	return response
 }
 
 func (uc *ProductUseCase) mapCategoryToResponse(category *entities.Category) *CategoryResponse {
-	return &CategoryResponse{
-		ID:       category.ID,
-		Name:     category.Name,
-		IsActive: category.IsActive,
+	response := &CategoryResponse{
+		ID:            category.ID,
+		Name:          category.Name,
+		ParentID:      category.ParentID,
+		SKUPrefix:     category.SKUPrefix,
+		DefaultMarkup: category.DefaultMarkup,
+		IsActive:      category.IsActive,
 	}
+	if category.DeletedAt.Valid {
+		response.DeletedAt = category.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response
 }
\ No newline at end of file
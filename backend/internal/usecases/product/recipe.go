@@ -0,0 +1,122 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qris-pos-backend/internal/domain/entities"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type RecipeItemRequest struct {
+	IngredientID string  `json:"ingredient_id" validate:"required,uuid"`
+	Quantity     float64 `json:"quantity" validate:"required,gt=0"` // Used per unit of the product sold, in the ingredient's unit
+}
+
+// SetRecipeRequest lists every ingredient of a product, an empty list removes the recipe
+type SetRecipeRequest struct {
+	Items []RecipeItemRequest `json:"items" validate:"max=50,dive"`
+}
+
+type RecipeItemResponse struct {
+	IngredientID   string                 `json:"ingredient_id"`
+	IngredientName string                 `json:"ingredient_name"`
+	Quantity       float64                `json:"quantity"`
+	Unit           entities.UnitOfMeasure `json:"unit"`
+	CostPrice      int64                  `json:"cost_price" visible:"admin"` // Ingredient cost per unit
+	Cost           int64                  `json:"cost" visible:"admin"`       // Cost of the quantity used
+}
+
+type RecipeResponse struct {
+	ProductID string               `json:"product_id"`
+	Items     []RecipeItemResponse `json:"items"`
+	Cost      int64                `json:"cost" visible:"admin"` // Ingredients of one unit at their current cost price
+}
+
+// GetRecipe returns the ingredients of a product with what they cost
+func (uc *ProductUseCase) GetRecipe(ctx context.Context, productID string) (*RecipeResponse, error) {
+	if _, err := uc.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	items, err := uc.productRepo.GetRecipe(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return mapRecipeToResponse(productID, items), nil
+}
+
+// SetRecipe replaces the ingredients of a product. Sales are costed and ingredients deducted with the
+// recipe in place once they are paid.
+func (uc *ProductUseCase) SetRecipe(ctx context.Context, productID string, req *SetRecipeRequest) (*RecipeResponse, error) {
+	if _, err := uc.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(req.Items))
+	for _, item := range req.Items {
+		ids = append(ids, item.IngredientID)
+	}
+	ingredients := make(map[string]entities.Product, len(ids))
+	if len(ids) > 0 {
+		products, err := uc.productRepo.GetByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			ingredients[product.ID] = product
+		}
+	}
+
+	items := make([]entities.RecipeItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		ingredient, ok := ingredients[item.IngredientID]
+		if !ok {
+			return nil, fmt.Errorf("%w: ingredient %s not found", appErrors.ErrInvalidInput, item.IngredientID)
+		}
+		items = append(items, entities.RecipeItem{IngredientID: item.IngredientID, Quantity: item.Quantity, Ingredient: ingredient})
+	}
+	if err := entities.ValidateRecipe(productID, items); err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+
+	if err := uc.productRepo.ReplaceRecipe(ctx, productID, items); err != nil {
+		uc.logger.Error("Failed to set recipe", "error", err, "product_id", productID)
+		return nil, err
+	}
+
+	uc.logger.Info("Recipe set", "product_id", productID, "ingredients", len(items))
+	return mapRecipeToResponse(productID, items), nil
+}
+
+func mapRecipeToResponse(productID string, items []entities.RecipeItem) *RecipeResponse {
+	response := &RecipeResponse{
+		ProductID: productID,
+		Items:     make([]RecipeItemResponse, 0, len(items)),
+		Cost:      entities.RecipeCost(items),
+	}
+	for _, item := range items {
+		unit := item.Ingredient.Unit
+		if unit == "" {
+			unit = entities.UnitPiece
+		}
+		response.Items = append(response.Items, RecipeItemResponse{
+			IngredientID:   item.IngredientID,
+			IngredientName: item.Ingredient.Name,
+			Quantity:       item.Quantity,
+			Unit:           unit,
+			CostPrice:      item.Ingredient.CostPrice,
+			Cost:           entities.LinePrice(item.Ingredient.CostPrice, item.Quantity),
+		})
+	}
+	return response
+}
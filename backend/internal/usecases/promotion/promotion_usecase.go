@@ -0,0 +1,272 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/domain/services"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type PromotionRequest struct {
+	Name        string                 `json:"name" validate:"required,min=1,max=255"`
+	Description string                 `json:"description"`
+	Type        entities.PromotionType `json:"type" validate:"required,oneof=buy_x_get_y happy_hour category_percent percentage fixed_amount"`
+	Code        *string                `json:"code" validate:"omitempty,max=50,alphanum"` // Leave empty for a promotion that applies automatically
+	ProductID   *string                `json:"product_id" validate:"omitempty,uuid"`
+	CategoryID  *string                `json:"category_id" validate:"omitempty,uuid"`
+	Percentage  float64                `json:"percentage" validate:"gte=0,lte=100"`
+	BuyQuantity int                    `json:"buy_quantity" validate:"gte=0"`
+	GetQuantity int                    `json:"get_quantity" validate:"gte=0"`
+	Amount      int64                  `json:"amount" validate:"gte=0"`
+	MinBasket   int64                  `json:"min_basket" validate:"gte=0"`
+	UsageLimit  int                    `json:"usage_limit" validate:"gte=0"`
+	StartTime   string                 `json:"start_time"`
+	EndTime     string                 `json:"end_time"`
+	DaysOfWeek  string                 `json:"days_of_week"`
+	StartsAt    *time.Time             `json:"starts_at"`
+	EndsAt      *time.Time             `json:"ends_at"`
+	Priority    int                    `json:"priority"`
+	Stackable   bool                   `json:"stackable"`
+	IsActive    *bool                  `json:"is_active"`
+}
+
+type SimulateRequest struct {
+	Items     []SimulateItemReq `json:"items" validate:"required,min=1,dive"`
+	At        *time.Time        `json:"at"`                                     // Defaults to now
+	PromoCode string            `json:"promo_code" validate:"omitempty,max=50"` // Evaluated with the automatic promotions, usage limits aside
+}
+
+type SimulateItemReq struct {
+	ProductID string  `json:"product_id" validate:"required,uuid"`
+	Quantity  float64 `json:"quantity" validate:"required,gt=0"`
+}
+
+type SimulateResponse struct {
+	Subtotal      int64                       `json:"subtotal"`
+	TotalDiscount int64                       `json:"total_discount"`
+	Total         int64                       `json:"total"`
+	Applied       []services.AppliedPromotion `json:"applied"`
+	EvaluatedAt   string                      `json:"evaluated_at"`
+}
+
+type PromotionFilters struct {
+	Type     string `form:"type"`
+	IsActive *bool  `form:"is_active"`
+	Limit    int    `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset   int    `form:"offset,default=0" validate:"gte=0"`
+}
+
+type PromotionUseCase struct {
+	promotionRepo   repositories.PromotionRepository
+	productRepo     repositories.ProductRepository
+	promotionEngine *services.PromotionEngine
+	logger          logger.Logger
+}
+
+func NewPromotionUseCase(
+	promotionRepo repositories.PromotionRepository,
+	productRepo repositories.ProductRepository,
+	promotionEngine *services.PromotionEngine,
+	logger logger.Logger,
+) *PromotionUseCase {
+	return &PromotionUseCase{
+		promotionRepo:   promotionRepo,
+		productRepo:     productRepo,
+		promotionEngine: promotionEngine,
+		logger:          logger,
+	}
+}
+
+func (uc *PromotionUseCase) CreatePromotion(ctx context.Context, req *PromotionRequest) (*entities.Promotion, error) {
+	promotion := &entities.Promotion{IsActive: true}
+	applyPromotionRequest(promotion, req)
+
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureCodeAvailable(ctx, promotion); err != nil {
+		return nil, err
+	}
+
+	if err := uc.promotionRepo.Create(ctx, promotion); err != nil {
+		uc.logger.Error("Failed to create promotion", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Promotion created successfully", "promotion_id", promotion.ID, "type", promotion.Type)
+	return promotion, nil
+}
+
+func (uc *PromotionUseCase) GetPromotion(ctx context.Context, id string) (*entities.Promotion, error) {
+	promotion, err := uc.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (uc *PromotionUseCase) UpdatePromotion(ctx context.Context, id string, req *PromotionRequest) (*entities.Promotion, error) {
+	promotion, err := uc.GetPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPromotionRequest(promotion, req)
+
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureCodeAvailable(ctx, promotion); err != nil {
+		return nil, err
+	}
+
+	if err := uc.promotionRepo.Update(ctx, promotion); err != nil {
+		uc.logger.Error("Failed to update promotion", "error", err, "promotion_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Promotion updated successfully", "promotion_id", id)
+	return promotion, nil
+}
+
+func (uc *PromotionUseCase) DeletePromotion(ctx context.Context, id string) error {
+	if _, err := uc.GetPromotion(ctx, id); err != nil {
+		return err
+	}
+
+	if err := uc.promotionRepo.Delete(ctx, id); err != nil {
+		uc.logger.Error("Failed to delete promotion", "error", err, "promotion_id", id)
+		return err
+	}
+
+	uc.logger.Info("Promotion deleted successfully", "promotion_id", id)
+	return nil
+}
+
+func (uc *PromotionUseCase) ListPromotions(ctx context.Context, filters *PromotionFilters) ([]entities.Promotion, error) {
+	promotions, err := uc.promotionRepo.List(ctx, repositories.PromotionFilters{
+		Type:     entities.PromotionType(filters.Type),
+		IsActive: filters.IsActive,
+		Limit:    filters.Limit,
+		Offset:   filters.Offset,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to list promotions", "error", err)
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// Simulate evaluates the active promotions against a hypothetical cart without persisting anything
+func (uc *PromotionUseCase) Simulate(ctx context.Context, req *SimulateRequest) (*SimulateResponse, error) {
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	items := make([]entities.TransactionItem, 0, len(req.Items))
+	for _, itemReq := range req.Items {
+		product, err := uc.productRepo.GetByID(ctx, itemReq.ProductID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("product with ID %s not found", itemReq.ProductID)
+			}
+			return nil, err
+		}
+		if err := product.ValidateQuantity(itemReq.Quantity); err != nil {
+			return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+		}
+
+		items = append(items, entities.TransactionItem{
+			ProductID:     product.ID,
+			Quantity:      itemReq.Quantity,
+			Unit:          product.Unit,
+			UnitPrice:     product.Price,
+			BaseUnitPrice: product.Price,
+			TotalPrice:    entities.LinePrice(product.Price, itemReq.Quantity),
+			Product:       *product,
+		})
+		entities.ApplyPriceTiers(items, product.ID, product.PriceTiers)
+	}
+
+	promotions, err := uc.promotionRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PromoCode != "" {
+		promotion, err := uc.promotionRepo.GetByCode(ctx, entities.NormalizePromoCode(req.PromoCode))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, appErrors.ErrPromoCodeInvalid
+			}
+			return nil, err
+		}
+		promotions = append(promotions, *promotion)
+	}
+
+	result := uc.promotionEngine.Evaluate(promotions, items, at)
+
+	return &SimulateResponse{
+		Subtotal:      result.Subtotal,
+		TotalDiscount: result.TotalDiscount,
+		Total:         result.Subtotal - result.TotalDiscount,
+		Applied:       result.Applied,
+		EvaluatedAt:   at.Format(time.RFC3339),
+	}, nil
+}
+
+// ensureCodeAvailable rejects a promo code already taken by another promotion
+func (uc *PromotionUseCase) ensureCodeAvailable(ctx context.Context, promotion *entities.Promotion) error {
+	if promotion.Code == nil {
+		return nil
+	}
+	existing, err := uc.promotionRepo.GetByCode(ctx, *promotion.Code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != promotion.ID {
+		return appErrors.ErrPromoCodeAlreadyExists
+	}
+	return nil
+}
+
+func applyPromotionRequest(promotion *entities.Promotion, req *PromotionRequest) {
+	promotion.Name = req.Name
+	promotion.Description = req.Description
+	promotion.Type = req.Type
+	promotion.ProductID = req.ProductID
+	promotion.CategoryID = req.CategoryID
+	promotion.Percentage = req.Percentage
+	promotion.BuyQuantity = req.BuyQuantity
+	promotion.GetQuantity = req.GetQuantity
+	promotion.Code = req.Code
+	promotion.Amount = req.Amount
+	promotion.MinBasket = req.MinBasket
+	promotion.UsageLimit = req.UsageLimit
+	promotion.StartTime = req.StartTime
+	promotion.EndTime = req.EndTime
+	promotion.DaysOfWeek = req.DaysOfWeek
+	promotion.StartsAt = req.StartsAt
+	promotion.EndsAt = req.EndsAt
+	promotion.Priority = req.Priority
+	promotion.Stackable = req.Stackable
+
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+}
@@ -0,0 +1,154 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/receipt"
+	"qris-pos-backend/internal/usecases/merchant"
+	"qris-pos-backend/pkg/auth"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type Format string
+
+const (
+	FormatPDF    Format = "pdf"
+	FormatESCPOS Format = "escpos"
+)
+
+type ReceiptRequest struct {
+	Format Format `form:"format" validate:"omitempty,oneof=pdf escpos"`
+	Width  int    `form:"width" validate:"omitempty,oneof=32 48"` // Printer characters per line, ESC/POS only
+}
+
+type ReceiptResponse struct {
+	Content     []byte
+	ContentType string
+	FileName    string
+}
+
+// ShareRequest asks for a link to a receipt that opens without logging in
+type ShareRequest struct {
+	ExpiresInHours int `json:"expires_in_hours" validate:"gte=0"` // The configured lifetime when empty
+}
+
+// ShareLink grants read-only access to the receipt of one transaction until it expires
+type ShareLink struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url,omitempty"` // Public invoice page to send over chat apps, empty when none is configured
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ReceiptUseCase struct {
+	transactionRepo repositories.TransactionRepository
+	paymentRepo     repositories.PaymentRepository
+	merchant        *merchant.MerchantUseCase
+	shareTokens     *auth.ShareTokenService
+	shareConfig     config.ShareConfig
+	logger          logger.Logger
+}
+
+func NewReceiptUseCase(
+	transactionRepo repositories.TransactionRepository,
+	paymentRepo repositories.PaymentRepository,
+	merchant *merchant.MerchantUseCase,
+	shareTokens *auth.ShareTokenService,
+	shareConfig config.ShareConfig,
+	logger logger.Logger,
+) *ReceiptUseCase {
+	return &ReceiptUseCase{
+		transactionRepo: transactionRepo,
+		paymentRepo:     paymentRepo,
+		merchant:        merchant,
+		shareTokens:     shareTokens,
+		shareConfig:     shareConfig,
+		logger:          logger,
+	}
+}
+
+// GetReceipt renders the receipt of a transaction as PDF or as an ESC/POS byte stream
+func (uc *ReceiptUseCase) GetReceipt(ctx context.Context, transactionID string, req *ReceiptRequest) (*ReceiptResponse, error) {
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	// Pending or cancelled transactions still get a receipt, but only without payment details
+	payment, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	r := receipt.New(receipt.NewStore(uc.merchant.Store()).ForOutlet(transaction.Store), transaction, payment)
+
+	switch req.Format {
+	case FormatESCPOS:
+		width := req.Width
+		if width == 0 {
+			width = receipt.WidthThermal58
+		}
+		return &ReceiptResponse{
+			Content:     receipt.RenderESCPOS(r, width),
+			ContentType: "application/octet-stream",
+			FileName:    fmt.Sprintf("receipt-%s.bin", transaction.ID),
+		}, nil
+	default:
+		return &ReceiptResponse{
+			Content:     receipt.RenderPDF(r),
+			ContentType: "application/pdf",
+			FileName:    fmt.Sprintf("receipt-%s.pdf", transaction.ID),
+		}, nil
+	}
+}
+
+// ShareReceipt signs a link to the receipt of a transaction, for sending to the customer over chat apps
+func (uc *ReceiptUseCase) ShareReceipt(ctx context.Context, transactionID string, req *ShareRequest) (*ShareLink, error) {
+	if _, err := uc.transactionRepo.GetByID(ctx, transactionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	hours := req.ExpiresInHours
+	if hours == 0 {
+		hours = uc.shareConfig.ExpiryHours
+	}
+	if hours > uc.shareConfig.MaxExpiryHours {
+		return nil, fmt.Errorf("%w: links last at most %d hours", appErrors.ErrInvalidInput, uc.shareConfig.MaxExpiryHours)
+	}
+
+	expiresAt := time.Now().Add(time.Duration(hours) * time.Hour)
+	link := &ShareLink{
+		Token:     uc.shareTokens.GenerateToken(transactionID, expiresAt),
+		ExpiresAt: expiresAt,
+	}
+	if uc.shareConfig.URL != "" {
+		link.URL = strings.TrimRight(uc.shareConfig.URL, "/") + "/" + link.Token
+	}
+
+	uc.logger.Info("Receipt shared", "transaction_id", transactionID, "expires_at", expiresAt)
+	return link, nil
+}
+
+// GetSharedReceipt renders the receipt a share token was issued for. The token is the only credential,
+// so the transaction is looked up in every store.
+func (uc *ReceiptUseCase) GetSharedReceipt(ctx context.Context, token string, req *ReceiptRequest) (*ReceiptResponse, error) {
+	transactionID, err := uc.shareTokens.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return uc.GetReceipt(ctx, transactionID, req)
+}
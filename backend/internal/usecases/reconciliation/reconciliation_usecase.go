@@ -0,0 +1,377 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/payment"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// orderIDBatchSize caps the order IDs looked up in one query
+const orderIDBatchSize = 1000
+
+type CreateRunRequest struct {
+	DateFrom string `json:"date_from" validate:"required,datetime=2006-01-02"`
+	DateTo   string `json:"date_to" validate:"required,datetime=2006-01-02"` // Inclusive
+}
+
+type UploadRunRequest struct {
+	DateFrom string `form:"date_from" validate:"required,datetime=2006-01-02"` // Period the report covers, by payment creation time
+	DateTo   string `form:"date_to" validate:"required,datetime=2006-01-02"`   // Inclusive
+}
+
+type RunFilters struct {
+	Limit  int `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset int `form:"offset,default=0" validate:"gte=0"`
+}
+
+type ItemFilters struct {
+	Result string `form:"result" validate:"omitempty,oneof=matched amount_mismatch status_mismatch missing_local missing_settlement"`
+	Limit  int    `form:"limit,default=100" validate:"gte=1,lte=500"`
+	Offset int    `form:"offset,default=0" validate:"gte=0"`
+}
+
+// ReconciliationUseCase matches Midtrans settlements against the gateway payments of the POS, so money
+// the gateway settled without a successful payment, or a sale that was never settled, gets noticed
+type ReconciliationUseCase struct {
+	reconciliationRepo repositories.ReconciliationRepository
+	paymentRepo        repositories.PaymentRepository
+	gateways           *payment.Gateways
+	config             config.ReconciliationConfig
+	logger             logger.Logger
+}
+
+func NewReconciliationUseCase(
+	reconciliationRepo repositories.ReconciliationRepository,
+	paymentRepo repositories.PaymentRepository,
+	gateways *payment.Gateways,
+	cfg config.ReconciliationConfig,
+	logger logger.Logger,
+) *ReconciliationUseCase {
+	return &ReconciliationUseCase{
+		reconciliationRepo: reconciliationRepo,
+		paymentRepo:        paymentRepo,
+		gateways:           gateways,
+		config:             cfg,
+		logger:             logger,
+	}
+}
+
+// CreateRun queues a run that pulls the settlement of every Midtrans payment created in the range from
+// the status API. It is processed in the background.
+func (uc *ReconciliationUseCase) CreateRun(ctx context.Context, userID string, req *CreateRunRequest) (*entities.ReconciliationRun, error) {
+	dateFrom, dateTo, err := uc.parseDateRange(req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uc.settlementReporter(); err != nil {
+		return nil, err
+	}
+
+	run := entities.NewReconciliationRun(entities.ReconciliationSourceMidtrans, "", userID, dateFrom, dateTo)
+	if err := uc.reconciliationRepo.CreateRun(ctx, run); err != nil {
+		uc.logger.Error("Failed to queue reconciliation run", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	uc.logger.Info("Reconciliation run queued", "run_id", run.ID, "user_id", userID, "date_from", req.DateFrom, "date_to", req.DateTo)
+	return run, nil
+}
+
+// UploadRun reconciles a settlement or disbursement CSV exported from the Midtrans dashboard against the
+// payments created in the range it covers. The report is matched right away.
+func (uc *ReconciliationUseCase) UploadRun(ctx context.Context, userID, fileName string, file io.Reader, req *UploadRunRequest) (*entities.ReconciliationRun, error) {
+	dateFrom, dateTo, err := uc.parseDateRange(req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	settlements, fees, err := readSettlementReport(file)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := uc.listPayments(ctx, dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Settlements of orders created outside the range still belong to a payment
+	byOrderID := make(map[string]*entities.Payment, len(payments))
+	for i := range payments {
+		byOrderID[payments[i].OrderID] = &payments[i]
+	}
+	var unknown []string
+	for orderID := range settlements {
+		if _, ok := byOrderID[orderID]; !ok {
+			unknown = append(unknown, orderID)
+		}
+	}
+	for start := 0; start < len(unknown); start += orderIDBatchSize {
+		others, err := uc.paymentRepo.ListPaymentsByOrderIDs(ctx, unknown[start:min(start+orderIDBatchSize, len(unknown))])
+		if err != nil {
+			return nil, err
+		}
+		for i := range others {
+			byOrderID[others[i].OrderID] = &others[i]
+		}
+	}
+
+	var items []entities.ReconciliationItem
+	for orderID, settlement := range settlements {
+		if item, ok := matchSettlement(byOrderID[orderID], settlement); ok {
+			item.OrderID = orderID
+			item.FeeAmount = fees[orderID]
+			items = append(items, item)
+		}
+	}
+	for i := range payments {
+		if _, ok := settlements[payments[i].OrderID]; ok {
+			continue
+		}
+		if item, ok := matchSettlement(&payments[i], nil); ok {
+			item.Note = "Not in the settlement report"
+			items = append(items, item)
+		}
+	}
+
+	now := time.Now()
+	run := entities.NewReconciliationRun(entities.ReconciliationSourceUpload, fileName, userID, dateFrom, dateTo)
+	run.StartedAt = &now
+	run.MarkAsCompleted(items)
+	for i := range items {
+		items[i].RunID = run.ID
+	}
+
+	if err := uc.reconciliationRepo.CompleteRun(ctx, run, items); err != nil {
+		uc.logger.Error("Failed to save reconciliation run", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	uc.logger.Info("Settlement report reconciled",
+		"run_id", run.ID,
+		"file_name", fileName,
+		"settlements", len(settlements),
+		"discrepancies", run.Discrepancies())
+	return run, nil
+}
+
+func (uc *ReconciliationUseCase) GetRun(ctx context.Context, id string) (*entities.ReconciliationRun, error) {
+	run, err := uc.reconciliationRepo.GetRun(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrReconciliationNotFound
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+// ListRuns lists the runs, newest first
+func (uc *ReconciliationUseCase) ListRuns(ctx context.Context, filters *RunFilters) ([]entities.ReconciliationRun, error) {
+	runs, err := uc.reconciliationRepo.ListRuns(ctx, filters.Limit, filters.Offset)
+	if err != nil {
+		uc.logger.Error("Failed to list reconciliation runs", "error", err)
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ListItems lists the items of a run, discrepancies first
+func (uc *ReconciliationUseCase) ListItems(ctx context.Context, runID string, filters *ItemFilters) ([]entities.ReconciliationItem, error) {
+	if _, err := uc.GetRun(ctx, runID); err != nil {
+		return nil, err
+	}
+
+	items, err := uc.reconciliationRepo.ListItems(ctx, runID, entities.ReconciliationResult(filters.Result), filters.Limit, filters.Offset)
+	if err != nil {
+		uc.logger.Error("Failed to list reconciliation items", "error", err, "run_id", runID)
+		return nil, err
+	}
+	return items, nil
+}
+
+// Run processes the queued runs. It is meant to be called periodically by the scheduler.
+func (uc *ReconciliationUseCase) Run(ctx context.Context) error {
+	runs, err := uc.reconciliationRepo.ListPendingRuns(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	for i := range runs {
+		run := &runs[i]
+		if err := uc.reconciliationRepo.ClaimRun(ctx, run); err != nil {
+			if !errors.Is(err, appErrors.ErrReconciliationAlreadyClaimed) {
+				uc.logger.Error("Failed to claim reconciliation run", "error", err, "run_id", run.ID)
+			}
+			continue
+		}
+
+		items, err := uc.pull(ctx, run)
+		if err != nil {
+			run.MarkAsFailed(err)
+			uc.logger.Error("Failed to reconcile settlements", "error", err, "run_id", run.ID)
+			if err := uc.reconciliationRepo.UpdateRun(ctx, run); err != nil {
+				uc.logger.Error("Failed to update reconciliation run", "error", err, "run_id", run.ID)
+			}
+			continue
+		}
+
+		run.MarkAsCompleted(items)
+		if err := uc.reconciliationRepo.CompleteRun(ctx, run, items); err != nil {
+			uc.logger.Error("Failed to save reconciliation items", "error", err, "run_id", run.ID)
+			continue
+		}
+		uc.logger.Info("Settlements reconciled", "run_id", run.ID, "items", len(items), "discrepancies", run.Discrepancies())
+	}
+	return nil
+}
+
+// pull asks Midtrans how it settled every payment of the run, with the account of the payment's store.
+// An order Midtrans doesn't know counts as unsettled, any other failure fails the run so it can be
+// queued again.
+func (uc *ReconciliationUseCase) pull(ctx context.Context, run *entities.ReconciliationRun) ([]entities.ReconciliationItem, error) {
+	if _, err := uc.settlementReporter(); err != nil {
+		return nil, err
+	}
+
+	payments, err := uc.listPayments(ctx, run.DateFrom, run.DateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []entities.ReconciliationItem
+	for i := range payments {
+		gateway, err := uc.gateways.For(ctx, &payments[i])
+		if err != nil {
+			return nil, err
+		}
+		reporter, ok := gateway.(payment.SettlementReporter)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s doesn't report settlements", appErrors.ErrInvalidInput, gateway.Provider())
+		}
+
+		settlement, err := reporter.GetSettlement(ctx, payments[i].OrderID)
+		if err != nil && !errors.Is(err, payment.ErrOrderNotFound) {
+			return nil, fmt.Errorf("order %s: %w", payments[i].OrderID, err)
+		}
+
+		item, ok := matchSettlement(&payments[i], settlement)
+		if !ok {
+			continue
+		}
+		if settlement == nil {
+			item.Note = "Unknown to Midtrans"
+		}
+		item.RunID = run.ID
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// listPayments returns the Midtrans payments created in the range, refusing ranges with more than a run
+// may check
+func (uc *ReconciliationUseCase) listPayments(ctx context.Context, dateFrom, dateTo time.Time) ([]entities.Payment, error) {
+	payments, err := uc.paymentRepo.ListGatewayPayments(ctx, entities.ProviderMidtrans, dateFrom, dateTo, uc.config.MaxPayments+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(payments) > uc.config.MaxPayments {
+		return nil, fmt.Errorf("%w: more than %d payments in the range, reconcile a shorter one", appErrors.ErrInvalidInput, uc.config.MaxPayments)
+	}
+	return payments, nil
+}
+
+func (uc *ReconciliationUseCase) settlementReporter() (payment.SettlementReporter, error) {
+	gateway, err := uc.gateways.Get(entities.ProviderMidtrans)
+	if err != nil {
+		return nil, err
+	}
+	reporter, ok := gateway.(payment.SettlementReporter)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s doesn't report settlements", appErrors.ErrInvalidInput, gateway.Provider())
+	}
+	return reporter, nil
+}
+
+// parseDateRange returns the start of the first day and the end of the last day of an inclusive range
+func (uc *ReconciliationUseCase) parseDateRange(from, to string) (time.Time, time.Time, error) {
+	dateFrom, err := time.ParseInLocation(dateLayout, from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date_from", appErrors.ErrInvalidInput)
+	}
+	dateTo, err := time.ParseInLocation(dateLayout, to, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date_to", appErrors.ErrInvalidInput)
+	}
+	if dateTo.Before(dateFrom) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: date_to must not be before date_from", appErrors.ErrInvalidInput)
+	}
+	if uc.config.MaxRangeDays > 0 && dateTo.Sub(dateFrom) >= time.Duration(uc.config.MaxRangeDays)*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: date range must not exceed %d days", appErrors.ErrInvalidInput, uc.config.MaxRangeDays)
+	}
+	return dateFrom, dateTo.AddDate(0, 0, 1), nil
+}
+
+// matchSettlement compares a payment with how Midtrans settled its order, either may be missing. Orders
+// where neither side moved money, such as expired QRIS, are left out of the run.
+func matchSettlement(p *entities.Payment, settlement *payment.Settlement) (entities.ReconciliationItem, bool) {
+	settled := settlement != nil && settlement.Status == payment.StatusSettlement
+	paid := p != nil && p.Status == entities.PaymentSuccess
+	if !settled && !paid {
+		return entities.ReconciliationItem{}, false
+	}
+
+	var item entities.ReconciliationItem
+	if p != nil {
+		item.OrderID = p.OrderID
+		item.PaymentID = &p.ID
+		item.TransactionID = &p.TransactionID
+		item.StoreID = &p.StoreID
+		item.LocalStatus = p.Status
+		item.LocalAmount = p.Amount
+		item.ExternalID = p.ExternalID
+	}
+	if settlement != nil {
+		item.SettlementStatus = settlement.Status
+		item.SettledAt = settlement.SettledAt
+		if settlement.TransactionID != "" {
+			item.ExternalID = settlement.TransactionID
+		}
+		if settled {
+			item.SettledAmount = settlement.GrossAmount
+		}
+	}
+
+	switch {
+	case p == nil:
+		item.Result = entities.ReconciliationMissingLocal
+		item.Note = "No payment has this order ID"
+	case !settled:
+		item.Result = entities.ReconciliationMissingSettlement
+		if settlement != nil {
+			item.Note = "Midtrans status is " + settlement.Status
+		}
+	case !paid:
+		item.Result = entities.ReconciliationStatusMismatch
+		item.Note = fmt.Sprintf("Settled by Midtrans while the payment is %s", p.Status)
+	case settlement.GrossAmount != p.Amount:
+		item.Result = entities.ReconciliationAmountMismatch
+		item.Note = fmt.Sprintf("Settled %d, the payment is %d", settlement.GrossAmount, p.Amount)
+	default:
+		item.Result = entities.ReconciliationMatched
+	}
+	return item, true
+}
@@ -0,0 +1,157 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/infrastructure/payment"
+	appErrors "qris-pos-backend/pkg/errors"
+)
+
+// maxReportRows caps the rows of an uploaded settlement report
+const maxReportRows = 50000
+
+// reportTimeZone is the zone Midtrans writes the timestamps of its reports in, Western Indonesia Time
+var reportTimeZone = time.FixedZone("WIB", 7*60*60)
+
+// Columns of a settlement report by the names the Midtrans dashboard and disbursement exports use
+var reportColumns = map[string][]string{
+	"order_id":       {"order_id"},
+	"transaction_id": {"transaction_id"},
+	"amount":         {"gross_amount", "amount", "transaction_amount"},
+	"fee":            {"fee", "fee_amount", "mdr", "mdr_amount"},
+	"status":         {"transaction_status", "status"},
+	"settled_at":     {"settlement_time", "settlement_date", "settled_at"},
+}
+
+var reportTimeLayouts = []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02", "02/01/2006 15:04:05", "02/01/2006"}
+
+// thousandsGrouped matches amounts written with thousands separators, such as 15.000 or 1,250,000
+var thousandsGrouped = regexp.MustCompile(`^-?\d{1,3}([.,]\d{3})+$`)
+
+// readSettlementReport reads the settlements of a CSV report by order ID. Rows of the same order are
+// added up, so refund rows net out against the payment they refund. A report without a status column
+// lists settled orders only.
+func readSettlementReport(file io.Reader) (map[string]*payment.Settlement, map[string]int64, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: the file is empty", appErrors.ErrInvalidSettlementReport)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidSettlementReport, err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Spreadsheet apps may start the file with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+		for column, aliases := range reportColumns {
+			for _, alias := range aliases {
+				if _, ok := columns[column]; !ok && name == alias {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	for _, required := range []string{"order_id", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: missing %s column", appErrors.ErrInvalidSettlementReport, required)
+		}
+	}
+
+	settlements := make(map[string]*payment.Settlement)
+	fees := make(map[string]int64)
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return settlements, fees, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidSettlementReport, err)
+		}
+		if row-1 > maxReportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d rows", appErrors.ErrInvalidSettlementReport, maxReportRows)
+		}
+
+		field := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		orderID := field("order_id")
+		if orderID == "" {
+			if strings.TrimSpace(strings.Join(record, "")) == "" {
+				continue
+			}
+			return nil, nil, fmt.Errorf("%w: row %d has no order ID", appErrors.ErrInvalidSettlementReport, row)
+		}
+		amount, err := parseAmount(field("amount"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: row %d: invalid amount %q", appErrors.ErrInvalidSettlementReport, row, field("amount"))
+		}
+		fee, err := parseAmount(field("fee"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: row %d: invalid fee %q", appErrors.ErrInvalidSettlementReport, row, field("fee"))
+		}
+
+		status := strings.ToLower(field("status"))
+		if status == "" || status == "settled" || status == "success" {
+			status = payment.StatusSettlement
+		}
+
+		settlement, ok := settlements[orderID]
+		if !ok {
+			settlement = &payment.Settlement{OrderID: orderID, Status: status}
+			settlements[orderID] = settlement
+		}
+		if status == payment.StatusSettlement {
+			settlement.Status = status
+		}
+		settlement.GrossAmount += amount
+		fees[orderID] += fee
+		if settlement.TransactionID == "" {
+			settlement.TransactionID = field("transaction_id")
+		}
+		if settlement.SettledAt == nil {
+			settlement.SettledAt = parseReportTime(field("settled_at"))
+		}
+	}
+}
+
+// parseAmount reads a rupiah amount as exported by Midtrans or reformatted by a spreadsheet app
+func parseAmount(value string) (int64, error) {
+	value = strings.TrimSpace(strings.TrimPrefix(value, "Rp"))
+	value = strings.ReplaceAll(value, " ", "")
+	if value == "" {
+		return 0, nil
+	}
+	if thousandsGrouped.MatchString(value) {
+		value = strings.NewReplacer(".", "", ",", "").Replace(value)
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	return entities.CurrencyIDR.ParseMajor(value)
+}
+
+func parseReportTime(value string) *time.Time {
+	for _, layout := range reportTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, reportTimeZone); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
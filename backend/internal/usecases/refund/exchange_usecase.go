@@ -0,0 +1,300 @@
+package refund
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/payment"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExchangeRequest struct {
+	Reason           string          `json:"reason" validate:"required,max=255"`
+	ReturnItems      []RefundItemReq `json:"return_items" validate:"required,min=1,dive"`
+	ReplacementItems []RefundItemReq `json:"replacement_items" validate:"required,min=1,dive"`
+}
+
+type ExchangeResponse struct {
+	Refund                   *entities.Refund `json:"refund"`
+	ReplacementTransactionID string           `json:"replacement_transaction_id"`
+	ReturnAmount             int64            `json:"return_amount"`
+	ReplacementAmount        int64            `json:"replacement_amount"`
+	NetAmount                int64            `json:"net_amount"` // Positive when the customer pays more, negative when money is returned
+	AmountDue                int64            `json:"amount_due"` // Charged through the replacement transaction's payment
+	RefundDue                int64            `json:"refund_due"` // Paid back on the original payment
+	ReplacementStatus        string           `json:"replacement_status"`
+}
+
+type ExchangeUseCase struct {
+	refundRepo      repositories.RefundRepository
+	paymentRepo     repositories.PaymentRepository
+	transactionRepo repositories.TransactionRepository
+	productRepo     repositories.ProductRepository
+	taxRuleRepo     repositories.TaxRuleRepository
+	shiftRepo       repositories.ShiftRepository
+	gateways        *payment.Gateways
+	shiftConfig     config.ShiftConfig
+	logger          logger.Logger
+}
+
+func NewExchangeUseCase(
+	refundRepo repositories.RefundRepository,
+	paymentRepo repositories.PaymentRepository,
+	transactionRepo repositories.TransactionRepository,
+	productRepo repositories.ProductRepository,
+	taxRuleRepo repositories.TaxRuleRepository,
+	shiftRepo repositories.ShiftRepository,
+	gateways *payment.Gateways,
+	shiftConfig config.ShiftConfig,
+	logger logger.Logger,
+) *ExchangeUseCase {
+	return &ExchangeUseCase{
+		refundRepo:      refundRepo,
+		paymentRepo:     paymentRepo,
+		transactionRepo: transactionRepo,
+		productRepo:     productRepo,
+		taxRuleRepo:     taxRuleRepo,
+		shiftRepo:       shiftRepo,
+		gateways:        gateways,
+		shiftConfig:     shiftConfig,
+		logger:          logger,
+	}
+}
+
+// ExchangeItems returns items of a paid transaction and sells replacements in one operation.
+// The returned value is credited to a new replacement transaction, so only the difference moves:
+// the customer pays the amount due through the replacement's payment, or gets the rest back on the original payment.
+// Replacements are sold at list price plus tax, automatic promotions don't apply to exchanges.
+func (uc *ExchangeUseCase) ExchangeItems(ctx context.Context, transactionID, userID string, req *ExchangeRequest) (*ExchangeResponse, error) {
+	original, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	paymentEntity, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if paymentEntity.Status != entities.PaymentSuccess {
+		return nil, errors.New("only transactions with a successful payment can be exchanged")
+	}
+
+	refunded, err := uc.refundRepo.GetRefundedQuantities(ctx, original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	returnItems, returnAmount, err := buildRefundItems(original, refunded, req.ReturnItems)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := original.ApplyRefund(returnAmount); err != nil {
+		return nil, err
+	}
+
+	replacement, err := uc.buildReplacement(ctx, original, userID, req.ReplacementItems)
+	if err != nil {
+		return nil, err
+	}
+	replacementAmount := replacement.TotalAmount
+
+	credit := min(returnAmount, replacementAmount)
+	if err := replacement.ApplyExchangeCredit(credit); err != nil {
+		return nil, err
+	}
+	refundDue := returnAmount - credit
+
+	// Nothing left to charge: the replacement is settled by the returned items alone
+	if replacement.TotalAmount == 0 {
+		if err := replacement.MarkAsPaid(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Like the original, a practice exchange leaves the stock alone
+	var reserved []entities.TransactionItem
+	if !replacement.IsTraining {
+		reserved, err = uc.reserveStock(ctx, replacement.Items)
+		if err != nil {
+			return nil, err
+		}
+		replacement.StockReserved = true
+	}
+
+	if err := uc.transactionRepo.Create(ctx, replacement); err != nil {
+		uc.logger.Error("Failed to create replacement transaction", "error", err, "transaction_id", original.ID)
+		uc.releaseStock(ctx, reserved)
+		return nil, err
+	}
+
+	refund := &entities.Refund{
+		PaymentID:             paymentEntity.ID,
+		TransactionID:         original.ID,
+		Amount:                returnAmount,
+		Reason:                req.Reason,
+		RefundKey:             uuid.New().String(),
+		RefundedBy:            userID,
+		ExchangeTransactionID: &replacement.ID,
+		CreditedAmount:        credit,
+		Items:                 returnItems,
+	}
+
+	// Only the part not credited to the replacement is paid back; gateway QRIS and e-wallets go through their gateway
+	if refundDue > 0 && paymentEntity.SettledThroughGateway() {
+		if paymentEntity.OrderID == "" {
+			uc.discardReplacement(ctx, replacement, reserved)
+			return nil, errors.New("payment has no gateway order to refund")
+		}
+		gateway, err := uc.gateways.For(ctx, paymentEntity)
+		if err != nil {
+			uc.discardReplacement(ctx, replacement, reserved)
+			return nil, err
+		}
+		res, err := gateway.RefundTransaction(ctx, payment.RefundRequest{
+			OrderID:    paymentEntity.OrderID,
+			ExternalID: paymentEntity.ExternalID,
+			RefundKey:  refund.RefundKey,
+			Amount:     refundDue,
+			Reason:     req.Reason,
+		})
+		if err != nil {
+			uc.logger.Error("Gateway refund failed", "error", err, "provider", gateway.Provider(), "payment_id", paymentEntity.ID, "order_id", paymentEntity.OrderID)
+			uc.discardReplacement(ctx, replacement, reserved)
+			return nil, err
+		}
+		if raw, err := json.Marshal(res); err == nil {
+			refund.ExternalResponse = string(raw)
+		}
+	}
+
+	if err := uc.refundRepo.RecordRefund(ctx, refund, original, original.StockReserved); err != nil {
+		uc.logger.Error("Failed to record exchange refund", "error", err, "transaction_id", original.ID, "refund_key", refund.RefundKey)
+		return nil, err
+	}
+
+	uc.logger.Info("Items exchanged",
+		"transaction_id", original.ID,
+		"replacement_transaction_id", replacement.ID,
+		"refund_id", refund.ID,
+		"return_amount", returnAmount,
+		"replacement_amount", replacementAmount,
+		"exchanged_by", userID)
+
+	return &ExchangeResponse{
+		Refund:                   refund,
+		ReplacementTransactionID: replacement.ID,
+		ReturnAmount:             returnAmount,
+		ReplacementAmount:        replacementAmount,
+		NetAmount:                replacementAmount - returnAmount,
+		AmountDue:                replacement.TotalAmount,
+		RefundDue:                refundDue,
+		ReplacementStatus:        string(replacement.Status),
+	}, nil
+}
+
+func (uc *ExchangeUseCase) buildReplacement(ctx context.Context, original *entities.Transaction, userID string, reqs []RefundItemReq) (*entities.Transaction, error) {
+	shiftID, err := uc.openShiftID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The exchange is made in the store the original sale was made in
+	replacement := entities.NewTransaction(original.StoreID, userID)
+	replacement.ShiftID = shiftID
+	replacement.IsTraining = original.IsTraining
+	replacement.Currency = original.Currency
+	replacement.ChargeRules = original.ChargeRules
+	replacement.Notes = fmt.Sprintf("Exchange for transaction %s", original.ID)
+	replacement.CustomerEmail = original.CustomerEmail
+	replacement.CustomerPhone = original.CustomerPhone
+	replacement.CustomerID = original.CustomerID
+
+	for _, itemReq := range reqs {
+		product, err := uc.productRepo.GetByID(ctx, itemReq.ProductID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("product with ID %s not found", itemReq.ProductID)
+			}
+			return nil, err
+		}
+
+		if err := replacement.AddItem(itemReq.ProductID, product, itemReq.Quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := uc.taxRuleRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	taxes, err := replacement.ApplyTax(rules)
+	if err != nil {
+		return nil, err
+	}
+	replacement.Taxes = taxes
+
+	return replacement, nil
+}
+
+// openShiftID returns the shift the replacement sale attaches to, nil when shifts are optional and none is open
+func (uc *ExchangeUseCase) openShiftID(ctx context.Context, userID string) (*string, error) {
+	shift, err := uc.shiftRepo.GetOpenByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if uc.shiftConfig.Required {
+				return nil, appErrors.ErrNoOpenShift
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shift.ID, nil
+}
+
+func (uc *ExchangeUseCase) reserveStock(ctx context.Context, items []entities.TransactionItem) ([]entities.TransactionItem, error) {
+	reserved := make([]entities.TransactionItem, 0, len(items))
+	for _, item := range items {
+		if err := uc.productRepo.ReserveStock(ctx, item.ProductID, item.Quantity); err != nil {
+			uc.releaseStock(ctx, reserved)
+			if errors.Is(err, appErrors.ErrInsufficientStock) {
+				return nil, fmt.Errorf("insufficient stock for product %s", item.Product.Name)
+			}
+			return nil, err
+		}
+		reserved = append(reserved, item)
+	}
+	return reserved, nil
+}
+
+func (uc *ExchangeUseCase) releaseStock(ctx context.Context, items []entities.TransactionItem) {
+	for _, item := range items {
+		if err := uc.productRepo.UpdateStock(ctx, item.ProductID, item.Quantity); err != nil {
+			uc.logger.Error("Failed to release product stock", "error", err, "product_id", item.ProductID, "quantity", item.Quantity)
+		}
+	}
+}
+
+// discardReplacement undoes the replacement sale when the money could not be returned
+func (uc *ExchangeUseCase) discardReplacement(ctx context.Context, replacement *entities.Transaction, reserved []entities.TransactionItem) {
+	if err := uc.transactionRepo.Delete(ctx, replacement.ID); err != nil {
+		uc.logger.Error("Failed to discard replacement transaction", "error", err, "transaction_id", replacement.ID)
+		return
+	}
+	uc.releaseStock(ctx, reserved)
+}
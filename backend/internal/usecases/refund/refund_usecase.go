@@ -0,0 +1,218 @@
+package refund
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/payment"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefundRequest struct {
+	Amount int64           `json:"amount" validate:"gte=0"` // Defaults to the value of the returned items, or the remaining amount
+	Reason string          `json:"reason" validate:"required,max=255"`
+	Items  []RefundItemReq `json:"items" validate:"omitempty,dive"`
+}
+
+type RefundItemReq struct {
+	ProductID string  `json:"product_id" validate:"required,uuid"`
+	Quantity  float64 `json:"quantity" validate:"required,gt=0"` // In the product's unit
+}
+
+type RefundResponse struct {
+	Refund            *entities.Refund           `json:"refund"`
+	TransactionStatus entities.TransactionStatus `json:"transaction_status"`
+	RefundedAmount    int64                      `json:"refunded_amount"`
+}
+
+type RefundUseCase struct {
+	refundRepo      repositories.RefundRepository
+	paymentRepo     repositories.PaymentRepository
+	transactionRepo repositories.TransactionRepository
+	gateways        *payment.Gateways
+	logger          logger.Logger
+}
+
+func NewRefundUseCase(
+	refundRepo repositories.RefundRepository,
+	paymentRepo repositories.PaymentRepository,
+	transactionRepo repositories.TransactionRepository,
+	gateways *payment.Gateways,
+	logger logger.Logger,
+) *RefundUseCase {
+	return &RefundUseCase{
+		refundRepo:      refundRepo,
+		paymentRepo:     paymentRepo,
+		transactionRepo: transactionRepo,
+		gateways:        gateways,
+		logger:          logger,
+	}
+}
+
+// RefundPayment refunds a settled payment fully or partially.
+// Returned items go back to stock; without items and amount the whole remaining sale is refunded.
+func (uc *RefundUseCase) RefundPayment(ctx context.Context, paymentID, userID string, req *RefundRequest) (*RefundResponse, error) {
+	paymentEntity, err := uc.paymentRepo.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	if paymentEntity.Status != entities.PaymentSuccess {
+		return nil, errors.New("only successful payments can be refunded")
+	}
+
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, paymentEntity.TransactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	refunded, err := uc.refundRepo.GetRefundedQuantities(ctx, transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	itemReqs := req.Items
+	if len(itemReqs) == 0 && req.Amount == 0 {
+		// Full refund of whatever hasn't been returned yet
+		for _, item := range transaction.Items {
+			if remaining := entities.RoundQuantity(item.Quantity - refunded[item.ProductID]); remaining > 0 {
+				itemReqs = append(itemReqs, RefundItemReq{ProductID: item.ProductID, Quantity: remaining})
+			}
+		}
+	}
+
+	items, itemsAmount, err := buildRefundItems(transaction, refunded, itemReqs)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := req.Amount
+	if amount == 0 {
+		amount = itemsAmount
+		if len(req.Items) == 0 {
+			amount = transaction.TotalAmount - transaction.RefundedAmount
+		}
+	}
+
+	if err := transaction.ApplyRefund(amount); err != nil {
+		return nil, err
+	}
+
+	refund := &entities.Refund{
+		PaymentID:     paymentEntity.ID,
+		TransactionID: transaction.ID,
+		Amount:        amount,
+		Reason:        req.Reason,
+		RefundKey:     uuid.New().String(),
+		RefundedBy:    userID,
+		Items:         items,
+	}
+
+	// Cash, card and local QRIS refunds are handed back at the counter; gateway QRIS and e-wallets go through their gateway
+	if paymentEntity.SettledThroughGateway() {
+		if paymentEntity.OrderID == "" {
+			return nil, errors.New("payment has no gateway order to refund")
+		}
+		gateway, err := uc.gateways.For(ctx, paymentEntity)
+		if err != nil {
+			return nil, err
+		}
+		res, err := gateway.RefundTransaction(ctx, payment.RefundRequest{
+			OrderID:    paymentEntity.OrderID,
+			ExternalID: paymentEntity.ExternalID,
+			RefundKey:  refund.RefundKey,
+			Amount:     amount,
+			Reason:     req.Reason,
+		})
+		if err != nil {
+			uc.logger.Error("Gateway refund failed", "error", err, "provider", gateway.Provider(), "payment_id", paymentID, "order_id", paymentEntity.OrderID)
+			return nil, err
+		}
+		if raw, err := json.Marshal(res); err == nil {
+			refund.ExternalResponse = string(raw)
+		}
+	}
+
+	if err := uc.refundRepo.RecordRefund(ctx, refund, transaction, transaction.StockReserved); err != nil {
+		uc.logger.Error("Failed to record refund", "error", err, "payment_id", paymentID, "refund_key", refund.RefundKey)
+		return nil, err
+	}
+
+	uc.logger.Info("Payment refunded",
+		"payment_id", paymentID,
+		"transaction_id", transaction.ID,
+		"refund_id", refund.ID,
+		"amount", amount,
+		"refunded_by", userID,
+		"transaction_status", transaction.Status)
+
+	return &RefundResponse{
+		Refund:            refund,
+		TransactionStatus: transaction.Status,
+		RefundedAmount:    transaction.RefundedAmount,
+	}, nil
+}
+
+// ListRefunds returns the refunds of a payment
+func (uc *RefundUseCase) ListRefunds(ctx context.Context, paymentID string) ([]entities.Refund, error) {
+	if _, err := uc.paymentRepo.GetPaymentByID(ctx, paymentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	return uc.refundRepo.ListByPaymentID(ctx, paymentID)
+}
+
+// buildRefundItems checks the returned quantities against what was bought and not yet refunded
+func buildRefundItems(transaction *entities.Transaction, refunded map[string]float64, reqs []RefundItemReq) ([]entities.RefundItem, int64, error) {
+	items := make([]entities.RefundItem, 0, len(reqs))
+	var total int64
+
+	for _, itemReq := range reqs {
+		var purchased *entities.TransactionItem
+		for i := range transaction.Items {
+			if transaction.Items[i].ProductID == itemReq.ProductID {
+				purchased = &transaction.Items[i]
+				break
+			}
+		}
+		if purchased == nil {
+			return nil, 0, fmt.Errorf("product %s is not part of this transaction", itemReq.ProductID)
+		}
+
+		if err := purchased.Unit.ValidateQuantity(itemReq.Quantity); err != nil {
+			return nil, 0, fmt.Errorf("product %s: %v", itemReq.ProductID, err)
+		}
+		remaining := entities.RoundQuantity(purchased.Quantity - refunded[itemReq.ProductID])
+		if itemReq.Quantity > remaining {
+			return nil, 0, fmt.Errorf("only %s of product %s can still be refunded", entities.FormatQuantity(remaining, purchased.Unit), itemReq.ProductID)
+		}
+
+		amount := entities.LinePrice(purchased.UnitPrice, itemReq.Quantity)
+		items = append(items, entities.RefundItem{
+			ProductID: itemReq.ProductID,
+			Quantity:  itemReq.Quantity,
+			Amount:    amount,
+			Restock:   purchased.Restock(refunded[itemReq.ProductID], itemReq.Quantity),
+		})
+		total += amount
+	}
+
+	return items, total, nil
+}
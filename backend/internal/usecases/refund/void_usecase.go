@@ -0,0 +1,322 @@
+package refund
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/payment"
+	"qris-pos-backend/internal/usecases/auth"
+	pkgAuth "qris-pos-backend/pkg/auth"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CreateVoidRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+// ApproveVoidRequest signs off a void. A manager at the register enters their own credentials on the
+// cashier's session, an admin signed in on their own device can approve without them.
+type ApproveVoidRequest struct {
+	ApproverEmail    string `json:"approver_email" validate:"omitempty,email"`
+	ApproverPassword string `json:"approver_password" validate:"required_with=ApproverEmail"`
+	Note             string `json:"note" validate:"max=255"`
+}
+
+type RejectVoidRequest struct {
+	Note string `json:"note" validate:"required,max=255"`
+}
+
+type ListVoidRequestsRequest struct {
+	Status entities.VoidRequestStatus `form:"status" validate:"omitempty,oneof=pending approved rejected"`
+	Limit  int                        `form:"limit,default=50" validate:"gte=1,lte=200"`
+	Offset int                        `form:"offset,default=0" validate:"gte=0"`
+}
+
+type VoidUseCase struct {
+	voidRepo        repositories.VoidRequestRepository
+	refundRepo      repositories.RefundRepository
+	paymentRepo     repositories.PaymentRepository
+	transactionRepo repositories.TransactionRepository
+	exceptionRepo   repositories.PaymentExceptionRepository
+	userRepo        repositories.UserRepository
+	passwordService *pkgAuth.PasswordService
+	gateways        *payment.Gateways
+	logger          logger.Logger
+}
+
+func NewVoidUseCase(
+	voidRepo repositories.VoidRequestRepository,
+	refundRepo repositories.RefundRepository,
+	paymentRepo repositories.PaymentRepository,
+	transactionRepo repositories.TransactionRepository,
+	exceptionRepo repositories.PaymentExceptionRepository,
+	userRepo repositories.UserRepository,
+	passwordService *pkgAuth.PasswordService,
+	gateways *payment.Gateways,
+	logger logger.Logger,
+) *VoidUseCase {
+	return &VoidUseCase{
+		voidRepo:        voidRepo,
+		refundRepo:      refundRepo,
+		paymentRepo:     paymentRepo,
+		transactionRepo: transactionRepo,
+		exceptionRepo:   exceptionRepo,
+		userRepo:        userRepo,
+		passwordService: passwordService,
+		gateways:        gateways,
+		logger:          logger,
+	}
+}
+
+// RequestVoid asks a manager to void a paid sale. Only untouched sales can be voided, once part of it
+// was refunded or exchanged the rest goes through a regular refund.
+func (uc *VoidUseCase) RequestVoid(ctx context.Context, transactionID, userID string, req *CreateVoidRequest) (*entities.VoidRequest, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	if transaction.Status != entities.StatusPaid {
+		return nil, fmt.Errorf("%w: only paid transactions can be voided, this one is %s", appErrors.ErrInvalidInput, transaction.Status)
+	}
+
+	if _, err := uc.voidRepo.GetPendingByTransactionID(ctx, transactionID); err == nil {
+		return nil, appErrors.ErrVoidPending
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	request := entities.NewVoidRequest(transaction, userID, req.Reason)
+	if err := uc.voidRepo.Create(ctx, request); err != nil {
+		uc.logger.Error("Failed to create void request", "error", err, "transaction_id", transactionID)
+		return nil, err
+	}
+
+	uc.logger.Info("Void requested", "void_request_id", request.ID, "transaction_id", transactionID, "requested_by", userID)
+	return request, nil
+}
+
+func (uc *VoidUseCase) GetVoidRequest(ctx context.Context, id string) (*entities.VoidRequest, error) {
+	request, err := uc.voidRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrVoidRequestNotFound
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+func (uc *VoidUseCase) ListVoidRequests(ctx context.Context, req *ListVoidRequestsRequest) ([]entities.VoidRequest, error) {
+	return uc.voidRepo.List(ctx, repositories.VoidRequestFilters{
+		Status: req.Status,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+}
+
+// ApproveVoid voids the sale of a pending request: it is refunded in full and its items go back to stock.
+// When the gateway refund fails the void still goes through and the money owed is queued as a payment
+// exception, to be paid back by hand.
+func (uc *VoidUseCase) ApproveVoid(ctx context.Context, id, sessionUserID string, sessionRole entities.UserRole, req *ApproveVoidRequest) (*entities.VoidRequest, error) {
+	request, err := uc.GetVoidRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	approverID, err := uc.resolveApprover(ctx, sessionUserID, sessionRole, req)
+	if err != nil {
+		return nil, err
+	}
+	if approverID == request.RequestedBy {
+		return nil, fmt.Errorf("%w: a void must be approved by someone other than who requested it", appErrors.ErrForbidden)
+	}
+
+	if err := request.Decide(entities.VoidApproved, approverID, req.Note); err != nil {
+		return nil, appErrors.ErrVoidRequestDecided
+	}
+	// Claim the request first so two managers approving at once can't refund the sale twice
+	if err := uc.voidRepo.SaveDecision(ctx, request); err != nil {
+		return nil, err
+	}
+
+	if err := uc.settleVoid(ctx, request); err != nil {
+		uc.logger.Error("Failed to void transaction, request back to pending", "error", err, "void_request_id", id, "transaction_id", request.TransactionID)
+		request.Status = entities.VoidPending
+		request.DecidedBy = nil
+		request.DecidedAt = nil
+		request.DecisionNote = ""
+		if updateErr := uc.voidRepo.Update(ctx, request); updateErr != nil {
+			uc.logger.Error("Failed to reopen void request", "error", updateErr, "void_request_id", id)
+		}
+		return nil, err
+	}
+
+	if err := uc.voidRepo.Update(ctx, request); err != nil {
+		// The sale is voided either way, only the link to its refund is missing
+		uc.logger.Error("Failed to save void settlement", "error", err, "void_request_id", id, "refund_id", request.RefundID)
+	}
+
+	uc.logger.Info("Transaction voided",
+		"void_request_id", id,
+		"transaction_id", request.TransactionID,
+		"requested_by", request.RequestedBy,
+		"approved_by", approverID,
+		"settlement", request.Settlement)
+	return request, nil
+}
+
+// RejectVoid closes a pending request without touching the sale
+func (uc *VoidUseCase) RejectVoid(ctx context.Context, id, userID string, req *RejectVoidRequest) (*entities.VoidRequest, error) {
+	request, err := uc.GetVoidRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := request.Decide(entities.VoidRejected, userID, req.Note); err != nil {
+		return nil, appErrors.ErrVoidRequestDecided
+	}
+	if err := uc.voidRepo.SaveDecision(ctx, request); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Void rejected", "void_request_id", id, "transaction_id", request.TransactionID, "rejected_by", userID)
+	return request, nil
+}
+
+// resolveApprover returns the manager signing off: the admin whose credentials were entered, or the
+// signed in admin when none were
+func (uc *VoidUseCase) resolveApprover(ctx context.Context, sessionUserID string, sessionRole entities.UserRole, req *ApproveVoidRequest) (string, error) {
+	if req.ApproverEmail == "" {
+		if sessionRole != entities.RoleAdmin {
+			return "", appErrors.ErrApprovalRequired
+		}
+		return sessionUserID, nil
+	}
+
+	approver, err := auth.VerifyApprover(ctx, uc.userRepo, uc.passwordService, req.ApproverEmail, req.ApproverPassword)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidCredentials) {
+			uc.logger.Warn("Void approval with invalid credentials", "email", req.ApproverEmail, "session_user_id", sessionUserID)
+		}
+		return "", err
+	}
+	return approver.ID, nil
+}
+
+// settleVoid refunds what is left of the sale and records how the money went back on the request
+func (uc *VoidUseCase) settleVoid(ctx context.Context, request *entities.VoidRequest) error {
+	paymentEntity, err := uc.paymentRepo.GetPaymentByTransactionID(ctx, request.TransactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrPaymentNotFound
+		}
+		return err
+	}
+	if paymentEntity.Status != entities.PaymentSuccess {
+		return errors.New("only successful payments can be refunded")
+	}
+
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, request.TransactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrTransactionNotFound
+		}
+		return err
+	}
+
+	refunded, err := uc.refundRepo.GetRefundedQuantities(ctx, transaction.ID)
+	if err != nil {
+		return err
+	}
+	var itemReqs []RefundItemReq
+	for _, item := range transaction.Items {
+		if remaining := entities.RoundQuantity(item.Quantity - refunded[item.ProductID]); remaining > 0 {
+			itemReqs = append(itemReqs, RefundItemReq{ProductID: item.ProductID, Quantity: remaining})
+		}
+	}
+	items, _, err := buildRefundItems(transaction, refunded, itemReqs)
+	if err != nil {
+		return err
+	}
+
+	amount := transaction.TotalAmount - transaction.RefundedAmount
+	if err := transaction.ApplyRefund(amount); err != nil {
+		return err
+	}
+
+	// The cashier who asked for the void hands cash back from their drawer
+	refund := &entities.Refund{
+		PaymentID:     paymentEntity.ID,
+		TransactionID: transaction.ID,
+		Amount:        amount,
+		Reason:        "Void: " + request.Reason,
+		RefundKey:     uuid.New().String(),
+		RefundedBy:    request.RequestedBy,
+		Items:         items,
+	}
+
+	request.Settlement = entities.VoidSettledAtCounter
+	var gatewayErr error
+	if paymentEntity.SettledThroughGateway() {
+		request.Settlement = entities.VoidSettledByGateway
+		gatewayErr = uc.refundThroughGateway(ctx, paymentEntity, refund)
+		if gatewayErr != nil {
+			request.Settlement = entities.VoidSettledManually
+		}
+	}
+
+	if err := uc.refundRepo.RecordRefund(ctx, refund, transaction, transaction.StockReserved); err != nil {
+		uc.logger.Error("Failed to record void refund", "error", err, "transaction_id", transaction.ID, "refund_key", refund.RefundKey)
+		return err
+	}
+	request.RefundID = &refund.ID
+
+	if gatewayErr != nil {
+		exception := entities.NewPaymentException(entities.ExceptionVoidRefund, paymentEntity.OrderID, "", amount, gatewayErr.Error())
+		exception.PaymentID = &paymentEntity.ID
+		exception.TransactionID = &transaction.ID
+		if err := uc.exceptionRepo.Create(ctx, exception); err != nil {
+			// The refund is recorded, the failure is in the logs for staff to pick up
+			uc.logger.Error("Failed to queue void refund for manual settlement", "error", err, "transaction_id", transaction.ID, "amount", amount)
+		} else {
+			request.ExceptionID = &exception.ID
+		}
+	}
+	return nil
+}
+
+func (uc *VoidUseCase) refundThroughGateway(ctx context.Context, paymentEntity *entities.Payment, refund *entities.Refund) error {
+	if paymentEntity.OrderID == "" {
+		return errors.New("payment has no gateway order to refund")
+	}
+	gateway, err := uc.gateways.For(ctx, paymentEntity)
+	if err != nil {
+		return err
+	}
+	res, err := gateway.RefundTransaction(ctx, payment.RefundRequest{
+		OrderID:    paymentEntity.OrderID,
+		ExternalID: paymentEntity.ExternalID,
+		RefundKey:  refund.RefundKey,
+		Amount:     refund.Amount,
+		Reason:     refund.Reason,
+	})
+	if err != nil {
+		uc.logger.Error("Gateway refund of void failed, queued for manual settlement", "error", err, "provider", gateway.Provider(), "payment_id", paymentEntity.ID, "order_id", paymentEntity.OrderID)
+		return err
+	}
+	if raw, err := json.Marshal(res); err == nil {
+		refund.ExternalResponse = string(raw)
+	}
+	return nil
+}
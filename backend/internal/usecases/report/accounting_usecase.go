@@ -0,0 +1,338 @@
+package report
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/accounting"
+	"qris-pos-backend/internal/infrastructure/config"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+)
+
+// Formats of the journal export
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Outcomes of posting the journal of a day
+const (
+	PushPosted  = "posted"
+	PushSkipped = "skipped" // Posted before, the journal is not sent again
+	PushFailed  = "failed"
+)
+
+type AccountingExportRequest struct {
+	DateFrom string `form:"date_from" validate:"required,datetime=2006-01-02"`
+	DateTo   string `form:"date_to" validate:"required,datetime=2006-01-02"` // Inclusive
+	StoreID  string `form:"store_id" validate:"omitempty,uuid"`              // Defaults to the store picked with X-Store-ID, every store without one
+	Format   string `form:"format,default=json" validate:"oneof=json csv"`
+}
+
+type AccountingPushRequest struct {
+	DateFrom  string `json:"date_from" validate:"required,datetime=2006-01-02"`
+	DateTo    string `json:"date_to" validate:"required,datetime=2006-01-02"` // Inclusive
+	StoreID   string `json:"store_id" validate:"omitempty,uuid"`
+	Connector string `json:"connector" validate:"required,oneof=accurate jurnal xero"`
+}
+
+type AccountingExportResponse struct {
+	DateFrom    string                    `json:"date_from"`
+	DateTo      string                    `json:"date_to"`
+	StoreID     string                    `json:"store_id,omitempty"` // Empty when the export covers every store
+	Entries     []accounting.JournalEntry `json:"entries"`
+	TotalDebit  int64                     `json:"total_debit"`
+	TotalCredit int64                     `json:"total_credit"`
+}
+
+type PushResult struct {
+	Date       string `json:"date"`
+	StoreID    string `json:"store_id"`
+	StoreCode  string `json:"store_code"`
+	Reference  string `json:"reference"`
+	Status     string `json:"status"` // posted, skipped or failed
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type AccountingPushResponse struct {
+	Connector string       `json:"connector"`
+	Posted    int          `json:"posted"`
+	Skipped   int          `json:"skipped"`
+	Failed    int          `json:"failed"`
+	Results   []PushResult `json:"results"`
+}
+
+// AccountingUseCase turns settled sales into daily journal entries for the books: the money collected
+// is debited to a clearing account per payment method and credited to sales and tax payable
+type AccountingUseCase struct {
+	reportRepo  repositories.ReportRepository
+	postingRepo repositories.AccountingPostingRepository
+	connectors  []accounting.Connector
+	config      config.AccountingConfig
+	logger      logger.Logger
+}
+
+func NewAccountingUseCase(
+	reportRepo repositories.ReportRepository,
+	postingRepo repositories.AccountingPostingRepository,
+	connectors []accounting.Connector,
+	cfg config.AccountingConfig,
+	logger logger.Logger,
+) *AccountingUseCase {
+	return &AccountingUseCase{
+		reportRepo:  reportRepo,
+		postingRepo: postingRepo,
+		connectors:  connectors,
+		config:      cfg,
+		logger:      logger,
+	}
+}
+
+// ExportJournal builds a journal entry per store and day with settled payments in the range
+func (uc *AccountingUseCase) ExportJournal(ctx context.Context, req *AccountingExportRequest) (*AccountingExportResponse, error) {
+	dateFrom, dateTo, err := parseDateRange(req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	storeID := reportStore(ctx, req.StoreID)
+
+	entries, err := uc.buildEntries(ctx, dateFrom, dateTo, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AccountingExportResponse{
+		DateFrom: req.DateFrom,
+		DateTo:   req.DateTo,
+		StoreID:  storeID,
+		Entries:  entries,
+	}
+	for _, entry := range entries {
+		for _, line := range entry.Lines {
+			result.TotalDebit += line.Debit
+			result.TotalCredit += line.Credit
+		}
+	}
+	return result, nil
+}
+
+// WriteJournalCSV writes the entries with a row per journal line, the layout the import tools of
+// accounting systems take
+func (uc *AccountingUseCase) WriteJournalCSV(w io.Writer, entries []accounting.JournalEntry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"date", "reference", "store_code", "account", "description", "debit", "credit"}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		for _, line := range entry.Lines {
+			err := writer.Write([]string{
+				entry.Date.Format(dateLayout),
+				entry.Reference,
+				entry.StoreCode,
+				line.Account,
+				line.Description,
+				strconv.FormatInt(line.Debit, 10),
+				strconv.FormatInt(line.Credit, 10),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// PushJournal posts the journal of every store and day in the range to an accounting system. Days
+// posted to it before are skipped, a failed day doesn't stop the others and can be pushed again.
+func (uc *AccountingUseCase) PushJournal(ctx context.Context, userID string, req *AccountingPushRequest) (*AccountingPushResponse, error) {
+	connector := uc.connector(req.Connector)
+	if connector == nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrAccountingConnectorNotConfigured, req.Connector)
+	}
+
+	dateFrom, dateTo, err := parseDateRange(req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	storeID := reportStore(ctx, req.StoreID)
+
+	entries, err := uc.buildEntries(ctx, dateFrom, dateTo, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	postings, err := uc.postingRepo.ListByConnector(ctx, connector.Name(), storeID, dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+	posted := make(map[string]*entities.AccountingPosting, len(postings))
+	for i := range postings {
+		posted[postingKey(postings[i].StoreID, postings[i].Date)] = &postings[i]
+	}
+
+	result := &AccountingPushResponse{Connector: connector.Name(), Results: make([]PushResult, 0, len(entries))}
+	for i := range entries {
+		entry := &entries[i]
+		item := PushResult{
+			Date:      entry.Date.Format(dateLayout),
+			StoreID:   entry.StoreID,
+			StoreCode: entry.StoreCode,
+			Reference: entry.Reference,
+		}
+
+		if posting, ok := posted[postingKey(entry.StoreID, entry.Date)]; ok {
+			item.Status = PushSkipped
+			item.ExternalID = posting.ExternalID
+			result.Skipped++
+			result.Results = append(result.Results, item)
+			continue
+		}
+
+		externalID, err := connector.PostJournal(ctx, entry)
+		if err != nil {
+			uc.logger.Error("Failed to post journal", "error", err, "connector", connector.Name(), "reference", entry.Reference)
+			item.Status = PushFailed
+			item.Error = err.Error()
+			result.Failed++
+			result.Results = append(result.Results, item)
+			continue
+		}
+
+		posting := &entities.AccountingPosting{
+			StoreID:     entry.StoreID,
+			Connector:   connector.Name(),
+			Date:        entry.Date,
+			Reference:   entry.Reference,
+			ExternalID:  externalID,
+			TotalAmount: totalDebit(entry),
+			PostedBy:    userID,
+		}
+		if err := uc.postingRepo.Create(ctx, posting); err != nil {
+			// The journal is in the books, only the guard against posting it again is missing
+			uc.logger.Error("Failed to record journal posting", "error", err, "connector", connector.Name(), "reference", entry.Reference, "external_id", externalID)
+		}
+
+		item.Status = PushPosted
+		item.ExternalID = externalID
+		result.Posted++
+		result.Results = append(result.Results, item)
+	}
+
+	uc.logger.Info("Journals pushed",
+		"connector", connector.Name(),
+		"store_id", storeID,
+		"posted", result.Posted,
+		"skipped", result.Skipped,
+		"failed", result.Failed)
+	return result, nil
+}
+
+// buildEntries groups the daily settlements per store and day into balanced journal entries
+func (uc *AccountingUseCase) buildEntries(ctx context.Context, dateFrom, dateTo time.Time, storeID string) ([]accounting.JournalEntry, error) {
+	settlements, err := uc.reportRepo.DailySettlements(ctx, repositories.SalesReportFilters{
+		DateFrom: dateFrom,
+		DateTo:   dateTo.AddDate(0, 0, 1),
+		StoreID:  storeID,
+	})
+	if err != nil {
+		uc.logger.Error("Failed to aggregate daily settlements", "error", err)
+		return nil, err
+	}
+
+	entries := make([]accounting.JournalEntry, 0)
+	for start := 0; start < len(settlements); {
+		// Settlements come ordered by day and store, the rows of one entry are adjacent
+		end := start + 1
+		for end < len(settlements) && postingKey(settlements[end].StoreID, settlements[end].Date) == postingKey(settlements[start].StoreID, settlements[start].Date) {
+			end++
+		}
+		entries = append(entries, uc.buildEntry(settlements[start:end]))
+		start = end
+	}
+	return entries, nil
+}
+
+// buildEntry debits the clearing account of each payment method with what it collected and credits
+// the tax to tax payable and the rest to sales
+func (uc *AccountingUseCase) buildEntry(settlements []repositories.DailySettlement) accounting.JournalEntry {
+	first := settlements[0]
+	day := first.Date.Format(dateLayout)
+	entry := accounting.JournalEntry{
+		Date:        first.Date,
+		StoreID:     first.StoreID,
+		StoreCode:   first.StoreCode,
+		Currency:    string(first.Currency),
+		Reference:   fmt.Sprintf("POS-%s-%s", first.StoreCode, first.Date.Format("20060102")),
+		Description: fmt.Sprintf("POS sales %s %s", first.StoreCode, day),
+	}
+
+	var collected, tax int64
+	for _, settlement := range settlements {
+		entry.Lines = append(entry.Lines, accounting.JournalLine{
+			Account:     uc.clearingAccount(settlement.Method),
+			Description: fmt.Sprintf("%s payments, %d transactions", settlement.Method, settlement.Transactions),
+			Debit:       settlement.Amount,
+		})
+		collected += settlement.Amount
+		tax += settlement.Tax
+	}
+
+	if tax > 0 {
+		entry.Lines = append(entry.Lines, accounting.JournalLine{
+			Account:     uc.config.TaxPayableAccount,
+			Description: "Tax collected " + day,
+			Credit:      tax,
+		})
+	}
+	entry.Lines = append(entry.Lines, accounting.JournalLine{
+		Account:     uc.config.SalesAccount,
+		Description: "Sales " + day,
+		Credit:      collected - tax,
+	})
+	return entry
+}
+
+func (uc *AccountingUseCase) clearingAccount(method entities.PaymentMethod) string {
+	switch method {
+	case entities.PaymentMethodCash:
+		return uc.config.CashAccount
+	case entities.PaymentMethodQRIS:
+		return uc.config.QRISAccount
+	case entities.PaymentMethodCard:
+		return uc.config.CardAccount
+	case entities.PaymentMethodEWallet:
+		return uc.config.EWalletAccount
+	default:
+		return uc.config.OtherAccount
+	}
+}
+
+func (uc *AccountingUseCase) connector(name string) accounting.Connector {
+	for _, connector := range uc.connectors {
+		if connector.Name() == name {
+			return connector
+		}
+	}
+	return nil
+}
+
+func postingKey(storeID string, date time.Time) string {
+	return storeID + ":" + date.Format(dateLayout)
+}
+
+func totalDebit(entry *accounting.JournalEntry) int64 {
+	var total int64
+	for _, line := range entry.Lines {
+		total += line.Debit
+	}
+	return total
+}
@@ -0,0 +1,180 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+)
+
+const dateLayout = "2006-01-02"
+
+// maxReportRange caps the date range a single report scans
+const maxReportRange = 366 * 24 * time.Hour
+
+type TopProductsRequest struct {
+	DateFrom   string `form:"date_from" validate:"required,datetime=2006-01-02"`
+	DateTo     string `form:"date_to" validate:"required,datetime=2006-01-02"` // Inclusive
+	CategoryID string `form:"category_id" validate:"omitempty,uuid"`
+	StoreID    string `form:"store_id" validate:"omitempty,uuid"`  // Defaults to the store picked with X-Store-ID, every store without one
+	Currency   string `form:"currency" validate:"omitempty,len=3"` // Only sales in this currency, for reports over stores selling in different currencies
+	GroupBy    string `form:"group_by,default=product" validate:"oneof=product category"`
+	SortBy     string `form:"sort_by,default=quantity" validate:"oneof=quantity revenue margin"`
+	Limit      int    `form:"limit,default=10" validate:"gte=1,lte=100"`
+}
+
+type TopProductsResponse struct {
+	DateFrom   string                       `json:"date_from"`
+	DateTo     string                       `json:"date_to"`
+	StoreID    string                       `json:"store_id,omitempty"` // Empty when the report covers every store
+	Currency   string                       `json:"currency,omitempty"`
+	GroupBy    string                       `json:"group_by"`
+	SortBy     string                       `json:"sort_by"`
+	Products   []repositories.ProductSales  `json:"products,omitempty"`
+	Categories []repositories.CategorySales `json:"categories,omitempty"`
+}
+
+type ProfitReportRequest struct {
+	DateFrom string `form:"date_from" validate:"required,datetime=2006-01-02"`
+	DateTo   string `form:"date_to" validate:"required,datetime=2006-01-02"` // Inclusive
+	StoreID  string `form:"store_id" validate:"omitempty,uuid"`              // Defaults to the store picked with X-Store-ID, every store without one
+	Currency string `form:"currency" validate:"omitempty,len=3"`
+}
+
+type ProfitReportResponse struct {
+	DateFrom     string                     `json:"date_from"`
+	DateTo       string                     `json:"date_to"`
+	StoreID      string                     `json:"store_id,omitempty"` // Empty when the report covers every store
+	Currency     string                     `json:"currency,omitempty"`
+	Transactions int                        `json:"transactions"`
+	Uncosted     int                        `json:"uncosted"` // Sales still waiting to be costed, their cost of goods counts as 0
+	Revenue      int64                      `json:"revenue"`
+	CostOfGoods  int64                      `json:"cost_of_goods"`
+	GrossProfit  int64                      `json:"gross_profit"`
+	Days         []repositories.DailyProfit `json:"days"`
+}
+
+type ReportUseCase struct {
+	reportRepo repositories.ReportRepository
+	logger     logger.Logger
+}
+
+func NewReportUseCase(reportRepo repositories.ReportRepository, logger logger.Logger) *ReportUseCase {
+	return &ReportUseCase{
+		reportRepo: reportRepo,
+		logger:     logger,
+	}
+}
+
+// GetTopProducts ranks products or categories by quantity sold, revenue or margin over a date range.
+// Revenue is the item total before transaction level discounts, refunded items are excluded.
+func (uc *ReportUseCase) GetTopProducts(ctx context.Context, req *TopProductsRequest) (*TopProductsResponse, error) {
+	dateFrom, dateTo, err := parseDateRange(req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	storeID := reportStore(ctx, req.StoreID)
+
+	filters := repositories.SalesReportFilters{
+		DateFrom:   dateFrom,
+		DateTo:     dateTo.AddDate(0, 0, 1),
+		StoreID:    storeID,
+		Currency:   strings.ToUpper(req.Currency),
+		CategoryID: req.CategoryID,
+		SortBy:     req.SortBy,
+		Limit:      req.Limit,
+	}
+
+	result := &TopProductsResponse{
+		DateFrom: req.DateFrom,
+		DateTo:   req.DateTo,
+		StoreID:  storeID,
+		Currency: strings.ToUpper(req.Currency),
+		GroupBy:  req.GroupBy,
+		SortBy:   req.SortBy,
+	}
+
+	if req.GroupBy == "category" {
+		result.Categories, err = uc.reportRepo.SalesByCategory(ctx, filters)
+	} else {
+		result.Products, err = uc.reportRepo.SalesByProduct(ctx, filters)
+	}
+	if err != nil {
+		uc.logger.Error("Failed to aggregate product sales", "error", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetProfit sets the net sales of each day in a date range against the cost of goods of the sales,
+// recipe ingredients included. Sales are costed shortly after they are paid.
+func (uc *ReportUseCase) GetProfit(ctx context.Context, req *ProfitReportRequest) (*ProfitReportResponse, error) {
+	dateFrom, dateTo, err := parseDateRange(req.DateFrom, req.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	storeID := reportStore(ctx, req.StoreID)
+
+	days, err := uc.reportRepo.DailyProfit(ctx, repositories.SalesReportFilters{
+		DateFrom: dateFrom,
+		DateTo:   dateTo.AddDate(0, 0, 1),
+		StoreID:  storeID,
+		Currency: strings.ToUpper(req.Currency),
+	})
+	if err != nil {
+		uc.logger.Error("Failed to aggregate profit", "error", err)
+		return nil, err
+	}
+
+	result := &ProfitReportResponse{
+		DateFrom: req.DateFrom,
+		DateTo:   req.DateTo,
+		StoreID:  storeID,
+		Currency: strings.ToUpper(req.Currency),
+		Days:     days,
+	}
+	for _, day := range days {
+		result.Transactions += day.Transactions
+		result.Uncosted += day.Uncosted
+		result.Revenue += day.Revenue
+		result.CostOfGoods += day.CostOfGoods
+		result.GrossProfit += day.GrossProfit
+	}
+	if result.Days == nil {
+		result.Days = []repositories.DailyProfit{}
+	}
+	return result, nil
+}
+
+// parseDateRange parses an inclusive range of days, capped at maxReportRange
+func parseDateRange(from, to string) (time.Time, time.Time, error) {
+	dateFrom, err := time.ParseInLocation(dateLayout, from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date_from", appErrors.ErrInvalidInput)
+	}
+	dateTo, err := time.ParseInLocation(dateLayout, to, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date_to", appErrors.ErrInvalidInput)
+	}
+	if dateTo.Before(dateFrom) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: date_to must not be before date_from", appErrors.ErrInvalidInput)
+	}
+	if dateTo.Sub(dateFrom) > maxReportRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: date range must not exceed one year", appErrors.ErrInvalidInput)
+	}
+	return dateFrom, dateTo, nil
+}
+
+// reportStore is the store a report covers, the one picked with X-Store-ID when the request names
+// none and every store when there is neither
+func reportStore(ctx context.Context, storeID string) string {
+	if storeID == "" {
+		storeID, _ = repositories.StoreFromContext(ctx)
+	}
+	return storeID
+}
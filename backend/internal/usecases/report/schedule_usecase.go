@@ -0,0 +1,456 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/document"
+	"qris-pos-backend/internal/infrastructure/notification"
+	"qris-pos-backend/internal/infrastructure/receipt"
+	"qris-pos-backend/internal/usecases/merchant"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// scheduleBatchSize caps how many reports a single run sends
+	scheduleBatchSize = 20
+	// summaryTopProducts is the number of best sellers listed in a report
+	summaryTopProducts = 10
+)
+
+type ReportScheduleRequest struct {
+	Name       string                   `json:"name" validate:"required,max=100"`
+	Frequency  entities.ReportFrequency `json:"frequency" validate:"required,oneof=daily weekly"`
+	Weekday    int                      `json:"weekday" validate:"gte=0,lte=6"` // Day a weekly report is sent, 0 = Sunday
+	Hour       int                      `json:"hour" validate:"gte=0,lte=23"`   // Hour of the business timezone the report is sent
+	Format     entities.ReportFormat    `json:"format" validate:"required,oneof=pdf csv"`
+	Recipients []string                 `json:"recipients" validate:"required,min=1,max=20,dive,email"`
+	IsActive   *bool                    `json:"is_active"` // Defaults to true
+}
+
+// SalesSummary is the content of a scheduled report
+type SalesSummary struct {
+	Title       string
+	Merchant    string // Name of the merchant from its profile, the configured store name before onboarding
+	TaxID       string // NPWP of the merchant
+	StoreName   string
+	From        time.Time // Inclusive
+	To          time.Time // Exclusive
+	Totals      []SummaryTotal
+	Methods     []SummaryTotal
+	TopProducts []repositories.ProductSales
+}
+
+// SummaryTotal is what was collected in a currency, with one payment method when Method is set
+type SummaryTotal struct {
+	Currency entities.Currency
+	Method   entities.PaymentMethod
+	Payments int
+	Amount   int64
+	Tax      int64
+}
+
+// ReportScheduleUseCase emails sales summaries to the owners on the days and hours they configured
+type ReportScheduleUseCase struct {
+	scheduleRepo repositories.ReportScheduleRepository
+	reportRepo   repositories.ReportRepository
+	storeRepo    repositories.StoreRepository
+	merchant     *merchant.MerchantUseCase
+	email        notification.Sender // nil when no email provider is configured
+	location     *time.Location
+	logger       logger.Logger
+}
+
+func NewReportScheduleUseCase(
+	scheduleRepo repositories.ReportScheduleRepository,
+	reportRepo repositories.ReportRepository,
+	storeRepo repositories.StoreRepository,
+	merchant *merchant.MerchantUseCase,
+	senders []notification.Sender,
+	timezone string,
+	logger logger.Logger,
+) *ReportScheduleUseCase {
+	// Reports go out and cover days of the business, not of the server
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		logger.Warn("Unknown business timezone, using server local time", "timezone", timezone, "error", err)
+		location = time.Local
+	}
+
+	uc := &ReportScheduleUseCase{
+		scheduleRepo: scheduleRepo,
+		reportRepo:   reportRepo,
+		storeRepo:    storeRepo,
+		merchant:     merchant,
+		location:     location,
+		logger:       logger,
+	}
+	for _, sender := range senders {
+		if sender.Channel() == entities.ReceiptChannelEmail {
+			uc.email = sender
+		}
+	}
+	return uc
+}
+
+// CreateReportSchedule schedules a report of the store the admin works in, or of every store when none
+// is picked
+func (uc *ReportScheduleUseCase) CreateReportSchedule(ctx context.Context, userID string, req *ReportScheduleRequest) (*entities.ReportSchedule, error) {
+	if uc.email == nil {
+		return nil, appErrors.ErrEmailNotConfigured
+	}
+
+	schedule := &entities.ReportSchedule{
+		IsActive:  true,
+		CreatedBy: userID,
+	}
+	if storeID, ok := repositories.StoreFromContext(ctx); ok {
+		schedule.StoreID = &storeID
+	}
+	if err := uc.applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.scheduleRepo.Create(ctx, schedule); err != nil {
+		uc.logger.Error("Failed to create report schedule", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Report schedule created", "report_schedule_id", schedule.ID, "frequency", schedule.Frequency, "next_run_at", schedule.NextRunAt)
+	return schedule, nil
+}
+
+func (uc *ReportScheduleUseCase) ListReportSchedules(ctx context.Context) ([]entities.ReportSchedule, error) {
+	return uc.scheduleRepo.List(ctx)
+}
+
+func (uc *ReportScheduleUseCase) GetReportSchedule(ctx context.Context, id string) (*entities.ReportSchedule, error) {
+	schedule, err := uc.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrReportScheduleNotFound
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdateReportSchedule changes a schedule, the next report goes out at the first time the new settings
+// give
+func (uc *ReportScheduleUseCase) UpdateReportSchedule(ctx context.Context, id string, req *ReportScheduleRequest) (*entities.ReportSchedule, error) {
+	schedule, err := uc.GetReportSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.scheduleRepo.Update(ctx, schedule); err != nil {
+		uc.logger.Error("Failed to update report schedule", "error", err, "report_schedule_id", id)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (uc *ReportScheduleUseCase) DeleteReportSchedule(ctx context.Context, id string) error {
+	if _, err := uc.GetReportSchedule(ctx, id); err != nil {
+		return err
+	}
+	if err := uc.scheduleRepo.Delete(ctx, id); err != nil {
+		uc.logger.Error("Failed to delete report schedule", "error", err, "report_schedule_id", id)
+		return err
+	}
+
+	uc.logger.Info("Report schedule deleted", "report_schedule_id", id)
+	return nil
+}
+
+// SendReportNow sends the latest report of a schedule right away, such as to check the recipients or
+// to resend one that failed. The next scheduled report is not moved.
+func (uc *ReportScheduleUseCase) SendReportNow(ctx context.Context, id string) (*entities.ReportSchedule, error) {
+	schedule, err := uc.GetReportSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if uc.email == nil {
+		return nil, appErrors.ErrEmailNotConfigured
+	}
+
+	sendErr := uc.send(ctx, schedule, time.Now())
+	uc.recordRun(schedule, sendErr)
+	if err := uc.scheduleRepo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+	if sendErr != nil {
+		return nil, sendErr
+	}
+	return schedule, nil
+}
+
+// Run sends the reports that are due. It is meant to be called periodically by the scheduler. A report
+// that fails is not retried, its error is kept on the schedule and it can be sent again by hand.
+func (uc *ReportScheduleUseCase) Run(ctx context.Context) error {
+	schedules, err := uc.scheduleRepo.ListDue(ctx, time.Now(), scheduleBatchSize)
+	if err != nil {
+		uc.logger.Error("Failed to list due report schedules", "error", err)
+		return err
+	}
+
+	var sent, failed int
+	for i := range schedules {
+		schedule := &schedules[i]
+
+		sendErr := appErrors.ErrEmailNotConfigured
+		if uc.email != nil {
+			// The report covers the days before it was due, however late the job gets to it
+			sendErr = uc.send(repositories.WithTenantOf(ctx, schedule.TenantID), schedule, schedule.NextRunAt)
+		}
+		uc.recordRun(schedule, sendErr)
+		schedule.NextRunAt = schedule.NextRun(time.Now(), uc.location)
+
+		if err := uc.scheduleRepo.Update(ctx, schedule); err != nil {
+			uc.logger.Error("Failed to update report schedule", "error", err, "report_schedule_id", schedule.ID)
+			continue
+		}
+		if sendErr != nil {
+			uc.logger.Error("Failed to send scheduled report", "error", sendErr, "report_schedule_id", schedule.ID)
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 || failed > 0 {
+		uc.logger.Info("Scheduled reports sent", "sent", sent, "failed", failed)
+	}
+	return nil
+}
+
+// send builds the report covering the period before sentAt and emails it to every recipient
+func (uc *ReportScheduleUseCase) send(ctx context.Context, schedule *entities.ReportSchedule, sentAt time.Time) error {
+	summary, err := uc.buildSummary(ctx, schedule, sentAt)
+	if err != nil {
+		return err
+	}
+
+	text := strings.Join(summary.Lines(), "\n")
+	attachment := notification.Attachment{
+		FileName:    summary.FileName(schedule.Format),
+		ContentType: "application/pdf",
+	}
+	if schedule.Format == entities.ReportFormatCSV {
+		attachment.ContentType = "text/csv"
+		attachment.Content, err = summary.CSV()
+		if err != nil {
+			return err
+		}
+	} else {
+		attachment.Content = document.RenderTextPDF(summary.Lines())
+	}
+
+	var failures []string
+	for _, recipient := range schedule.RecipientList() {
+		err := uc.email.Send(ctx, &notification.Message{
+			To:          recipient,
+			Subject:     summary.Title,
+			Text:        text,
+			Attachments: []notification.Attachment{attachment},
+		})
+		if err != nil {
+			failures = append(failures, recipient+": "+err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("report not sent to %s", strings.Join(failures, "; "))
+	}
+
+	uc.logger.Info("Report sent", "report_schedule_id", schedule.ID, "recipients", len(schedule.RecipientList()))
+	return nil
+}
+
+func (uc *ReportScheduleUseCase) buildSummary(ctx context.Context, schedule *entities.ReportSchedule, sentAt time.Time) (*SalesSummary, error) {
+	from, to := schedule.Period(sentAt, uc.location)
+	merchant := uc.merchant.Store()
+	summary := &SalesSummary{
+		Title:     fmt.Sprintf("%s sales report: %s", strings.ToUpper(string(schedule.Frequency[:1]))+string(schedule.Frequency[1:]), schedule.Name),
+		Merchant:  merchant.Name,
+		TaxID:     merchant.TaxID,
+		StoreName: "All stores",
+		From:      from,
+		To:        to,
+	}
+
+	filters := repositories.SalesReportFilters{DateFrom: from, DateTo: to}
+	if schedule.StoreID != nil {
+		filters.StoreID = *schedule.StoreID
+		store, err := uc.storeRepo.GetByID(ctx, *schedule.StoreID)
+		if err != nil {
+			return nil, err
+		}
+		summary.StoreName = store.Name
+	}
+
+	settlements, err := uc.reportRepo.DailySettlements(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[entities.Currency]*SummaryTotal)
+	methods := make(map[string]*SummaryTotal)
+	for _, settlement := range settlements {
+		total, ok := totals[settlement.Currency]
+		if !ok {
+			total = &SummaryTotal{Currency: settlement.Currency}
+			totals[settlement.Currency] = total
+		}
+		total.Payments += settlement.Transactions
+		total.Amount += settlement.Amount
+		total.Tax += settlement.Tax
+
+		key := string(settlement.Currency) + "/" + string(settlement.Method)
+		method, ok := methods[key]
+		if !ok {
+			method = &SummaryTotal{Currency: settlement.Currency, Method: settlement.Method}
+			methods[key] = method
+		}
+		method.Payments += settlement.Transactions
+		method.Amount += settlement.Amount
+		method.Tax += settlement.Tax
+	}
+	for _, total := range totals {
+		summary.Totals = append(summary.Totals, *total)
+	}
+	for _, method := range methods {
+		summary.Methods = append(summary.Methods, *method)
+	}
+	sort.Slice(summary.Totals, func(i, j int) bool { return summary.Totals[i].Currency < summary.Totals[j].Currency })
+	sort.Slice(summary.Methods, func(i, j int) bool {
+		if summary.Methods[i].Currency != summary.Methods[j].Currency {
+			return summary.Methods[i].Currency < summary.Methods[j].Currency
+		}
+		return summary.Methods[i].Amount > summary.Methods[j].Amount
+	})
+
+	filters.SortBy = "revenue"
+	filters.Limit = summaryTopProducts
+	summary.TopProducts, err = uc.reportRepo.SalesByProduct(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// recordRun keeps the outcome of a report on its schedule
+func (uc *ReportScheduleUseCase) recordRun(schedule *entities.ReportSchedule, err error) {
+	now := time.Now()
+	schedule.LastRunAt = &now
+	schedule.LastError = ""
+	if err != nil {
+		schedule.LastError = err.Error()
+	}
+}
+
+func (uc *ReportScheduleUseCase) applyRequest(schedule *entities.ReportSchedule, req *ReportScheduleRequest) error {
+	schedule.Name = strings.TrimSpace(req.Name)
+	schedule.Frequency = req.Frequency
+	schedule.Weekday = req.Weekday
+	schedule.Hour = req.Hour
+	schedule.Format = req.Format
+	schedule.SetRecipients(req.Recipients)
+	if req.IsActive != nil {
+		schedule.IsActive = *req.IsActive
+	}
+	if err := schedule.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+
+	schedule.NextRunAt = schedule.NextRun(time.Now(), uc.location)
+	return nil
+}
+
+// Lines lays the summary out as plain text, for the email body and the PDF
+func (s *SalesSummary) Lines() []string {
+	lastDay := s.To.AddDate(0, 0, -1)
+	period := s.From.Format("02/01/2006")
+	if !lastDay.Equal(s.From) {
+		period += " - " + lastDay.Format("02/01/2006")
+	}
+
+	lines := []string{s.Title, "Merchant: " + s.Merchant}
+	if s.TaxID != "" {
+		lines = append(lines, "NPWP:     "+s.TaxID)
+	}
+	lines = append(lines,
+		"Store:    "+s.StoreName,
+		"Period:   "+period,
+		"",
+		"SALES",
+	)
+	if len(s.Totals) == 0 {
+		lines = append(lines, "  No settled sales in this period")
+	}
+	for _, total := range s.Totals {
+		lines = append(lines,
+			fmt.Sprintf("  %-20s %d", "Payments", total.Payments),
+			fmt.Sprintf("  %-20s %s", "Collected", receipt.FormatAmount(total.Amount, total.Currency)),
+			fmt.Sprintf("  %-20s %s", "Tax", receipt.FormatAmount(total.Tax, total.Currency)),
+			fmt.Sprintf("  %-20s %s", "Net of tax", receipt.FormatAmount(total.Amount-total.Tax, total.Currency)),
+		)
+	}
+
+	if len(s.Methods) > 0 {
+		lines = append(lines, "", "BY PAYMENT METHOD")
+		for _, method := range s.Methods {
+			lines = append(lines, fmt.Sprintf("  %-20s %6d  %s", method.Method, method.Payments, receipt.FormatAmount(method.Amount, method.Currency)))
+		}
+	}
+
+	if len(s.TopProducts) > 0 {
+		lines = append(lines, "", "TOP PRODUCTS BY REVENUE")
+		for i, product := range s.TopProducts {
+			name := product.ProductName
+			if len(name) > 40 {
+				name = name[:40]
+			}
+			lines = append(lines, fmt.Sprintf("  %2d. %-40s %6s  %s", i+1, name, strconv.FormatFloat(product.QuantitySold, 'f', -1, 64), receipt.FormatRupiah(product.Revenue)))
+		}
+	}
+	return lines
+}
+
+// CSV writes the summary as one row per figure, for spreadsheets
+func (s *SalesSummary) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	rows := [][]string{{"section", "name", "currency", "count", "amount", "tax"}}
+	for _, total := range s.Totals {
+		rows = append(rows, []string{"total", "", string(total.Currency), strconv.Itoa(total.Payments), strconv.FormatInt(total.Amount, 10), strconv.FormatInt(total.Tax, 10)})
+	}
+	for _, method := range s.Methods {
+		rows = append(rows, []string{"payment_method", string(method.Method), string(method.Currency), strconv.Itoa(method.Payments), strconv.FormatInt(method.Amount, 10), strconv.FormatInt(method.Tax, 10)})
+	}
+	for _, product := range s.TopProducts {
+		rows = append(rows, []string{"product", product.ProductName, "", strconv.FormatFloat(product.QuantitySold, 'f', -1, 64), strconv.FormatInt(product.Revenue, 10), ""})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FileName names the attachment after the period, e.g. sales-report-2024-05-21.pdf
+func (s *SalesSummary) FileName(format entities.ReportFormat) string {
+	return fmt.Sprintf("sales-report-%s.%s", s.From.Format(dateLayout), format)
+}
@@ -0,0 +1,191 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+)
+
+// Settings is the business configuration merchants change at runtime. Keys never saved keep the
+// value of the environment configuration. Tax rates are runtime configuration too, managed as tax rules.
+type Settings struct {
+	StoreName         string     `json:"store_name"`
+	StoreLogoURL      string     `json:"store_logo_url"`
+	ReceiptFooter     string     `json:"receipt_footer"`
+	QRISExpiryMinutes int        `json:"qris_expiry_minutes"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"` // Last change of any saved setting
+}
+
+// UpdateSettingsRequest changes the given settings, omitted ones are left as they are
+type UpdateSettingsRequest struct {
+	StoreName         *string `json:"store_name" validate:"omitempty,min=1,max=100"`
+	StoreLogoURL      *string `json:"store_logo_url" validate:"omitempty,url,max=500"`
+	ReceiptFooter     *string `json:"receipt_footer" validate:"omitempty,max=255"`
+	QRISExpiryMinutes *int    `json:"qris_expiry_minutes" validate:"omitempty,gte=1,lte=60"`
+}
+
+// SettingsUseCase serves the settings from memory so reading them costs nothing on hot paths like
+// receipts and QRIS generation. Changes are saved, applied right away and pushed to live terminals,
+// other instances pick them up on their next reload.
+type SettingsUseCase struct {
+	settingRepo repositories.SettingRepository
+	eventBroker *events.Broker
+	store       config.StoreConfig
+	defaults    Settings
+	logger      logger.Logger
+
+	mu        sync.RWMutex
+	current   Settings
+	listeners []func(Settings)
+}
+
+func NewSettingsUseCase(
+	settingRepo repositories.SettingRepository,
+	eventBroker *events.Broker,
+	store config.StoreConfig,
+	payment config.PaymentConfig,
+	logger logger.Logger,
+) *SettingsUseCase {
+	defaults := Settings{
+		StoreName:         store.Name,
+		ReceiptFooter:     store.Footer,
+		QRISExpiryMinutes: payment.QRISExpiryMinutes,
+	}
+	return &SettingsUseCase{
+		settingRepo: settingRepo,
+		eventBroker: eventBroker,
+		store:       store,
+		defaults:    defaults,
+		logger:      logger,
+		current:     defaults,
+	}
+}
+
+// Current returns the settings in effect
+func (uc *SettingsUseCase) Current() Settings {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	return uc.current
+}
+
+// Store returns the merchant information printed on receipts with the current name and footer
+func (uc *SettingsUseCase) Store() config.StoreConfig {
+	current := uc.Current()
+	store := uc.store
+	store.Name = current.StoreName
+	store.Footer = current.ReceiptFooter
+	return store
+}
+
+// QRISExpiryMinutes is the lifetime of a QRIS when the request doesn't give one
+func (uc *SettingsUseCase) QRISExpiryMinutes() int {
+	return uc.Current().QRISExpiryMinutes
+}
+
+// OnChange registers fn to be called with the new settings every time they change
+func (uc *SettingsUseCase) OnChange(fn func(Settings)) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.listeners = append(uc.listeners, fn)
+}
+
+// UpdateSettings saves the given settings and applies them right away
+func (uc *SettingsUseCase) UpdateSettings(ctx context.Context, userID string, req *UpdateSettingsRequest) (*Settings, error) {
+	var changes []entities.Setting
+	add := func(key, value string) {
+		changes = append(changes, entities.Setting{Key: key, Value: value, UpdatedBy: &userID})
+	}
+	if req.StoreName != nil {
+		add(entities.SettingStoreName, *req.StoreName)
+	}
+	if req.StoreLogoURL != nil {
+		add(entities.SettingStoreLogoURL, *req.StoreLogoURL)
+	}
+	if req.ReceiptFooter != nil {
+		add(entities.SettingReceiptFooter, *req.ReceiptFooter)
+	}
+	if req.QRISExpiryMinutes != nil {
+		add(entities.SettingQRISExpiryMinutes, strconv.Itoa(*req.QRISExpiryMinutes))
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: no setting to change", appErrors.ErrInvalidInput)
+	}
+
+	if err := uc.settingRepo.Save(ctx, changes); err != nil {
+		uc.logger.Error("Failed to save settings", "error", err, "user_id", userID)
+		return nil, err
+	}
+	if err := uc.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Settings updated", "user_id", userID, "count", len(changes))
+	current := uc.Current()
+	return &current, nil
+}
+
+// Reload reads the saved settings, notifying listeners and live terminals when they changed.
+// Runs as a background job so changes made through another instance reach this one.
+func (uc *SettingsUseCase) Reload(ctx context.Context) error {
+	saved, err := uc.settingRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	next := uc.defaults
+	for _, setting := range saved {
+		switch setting.Key {
+		case entities.SettingStoreName:
+			next.StoreName = setting.Value
+		case entities.SettingStoreLogoURL:
+			next.StoreLogoURL = setting.Value
+		case entities.SettingReceiptFooter:
+			next.ReceiptFooter = setting.Value
+		case entities.SettingQRISExpiryMinutes:
+			minutes, err := strconv.Atoi(setting.Value)
+			if err != nil || minutes <= 0 {
+				uc.logger.Warn("Ignoring invalid setting", "key", setting.Key, "value", setting.Value)
+				continue
+			}
+			next.QRISExpiryMinutes = minutes
+		default:
+			continue
+		}
+		if next.UpdatedAt == nil || setting.UpdatedAt.After(*next.UpdatedAt) {
+			updatedAt := setting.UpdatedAt
+			next.UpdatedAt = &updatedAt
+		}
+	}
+
+	uc.mu.Lock()
+	changed := !sameSettings(uc.current, next)
+	uc.current = next
+	listeners := uc.listeners
+	uc.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	for _, fn := range listeners {
+		fn(next)
+	}
+	uc.eventBroker.Publish(events.Event{Type: events.EventSettingsUpdated, Data: next})
+	return nil
+}
+
+func sameSettings(a, b Settings) bool {
+	if (a.UpdatedAt == nil) != (b.UpdatedAt == nil) || (a.UpdatedAt != nil && !a.UpdatedAt.Equal(*b.UpdatedAt)) {
+		return false
+	}
+	a.UpdatedAt, b.UpdatedAt = nil, nil
+	return a == b
+}
@@ -0,0 +1,262 @@
+package shift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type OpenShiftRequest struct {
+	OpeningFloat int64  `json:"opening_float" validate:"gte=0"`
+	Note         string `json:"note" validate:"max=255"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash  int64  `json:"counted_cash" validate:"gte=0"`
+	Note         string `json:"note" validate:"max=255"`
+	HandoverNote string `json:"handover_note" validate:"max=1000"` // For whoever takes over the till next
+}
+
+type CashMovementRequest struct {
+	Type   entities.CashMovementType `json:"type" validate:"required,oneof=drawer_open pay_in pay_out drop"`
+	Amount int64                     `json:"amount" validate:"gte=0"` // Zero when only opening the drawer
+	Reason string                    `json:"reason" validate:"required,max=255"`
+}
+
+type ApproveVarianceRequest struct {
+	Note string `json:"note" validate:"required,max=255"` // Why the variance is accepted
+}
+
+// ShiftReport is the Z-report of a shift; totals are live while the shift is still open
+type ShiftReport struct {
+	Shift            *entities.Shift `json:"shift"`
+	TotalSales       int64           `json:"total_sales"`
+	NetCashSales     int64           `json:"net_cash_sales"`
+	IsFinal          bool            `json:"is_final"`
+	ApprovalRequired bool            `json:"approval_required"`  // Closed with a cash variance awaiting a manager
+	Handover         *Handover       `json:"handover,omitempty"` // Note left by the previous shift when this one opened
+
+	CashMovements []entities.CashMovement `json:"cash_movements"` // Pay-ins, pay-outs, drops and drawer opens, oldest first
+}
+
+// Handover is the note a closing shift left for the next one
+type Handover struct {
+	ShiftID  string    `json:"shift_id"`
+	UserName string    `json:"user_name"`
+	Note     string    `json:"note"`
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+type ShiftUseCase struct {
+	shiftRepo repositories.ShiftRepository
+	config    config.ShiftConfig
+	logger    logger.Logger
+}
+
+func NewShiftUseCase(shiftRepo repositories.ShiftRepository, cfg config.ShiftConfig, logger logger.Logger) *ShiftUseCase {
+	return &ShiftUseCase{
+		shiftRepo: shiftRepo,
+		config:    cfg,
+		logger:    logger,
+	}
+}
+
+func (uc *ShiftUseCase) OpenShift(ctx context.Context, userID string, req *OpenShiftRequest) (*entities.Shift, error) {
+	if _, err := uc.shiftRepo.GetOpenByUserID(ctx, userID); err == nil {
+		return nil, appErrors.ErrShiftAlreadyOpen
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	shift, err := entities.NewShift(userID, req.OpeningFloat, req.Note)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+
+	if err := uc.shiftRepo.Create(ctx, shift); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Shift opened", "shift_id", shift.ID, "user_id", userID, "opening_float", shift.OpeningFloat)
+	return shift, nil
+}
+
+// CloseShift closes the user's open shift and returns its Z-report. A cash variance beyond the
+// configured threshold leaves the shift pending until a manager approves it.
+func (uc *ShiftUseCase) CloseShift(ctx context.Context, userID string, req *CloseShiftRequest) (*ShiftReport, error) {
+	shift, err := uc.GetCurrentShift(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Totals are recomputed at closing so late settlements are counted
+	if err := uc.applyTotals(ctx, shift); err != nil {
+		return nil, err
+	}
+
+	if err := shift.Close(req.CountedCash, req.Note, req.HandoverNote, uc.config.VarianceApprovalThreshold); err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+
+	if err := uc.shiftRepo.Update(ctx, shift); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Shift closed",
+		"shift_id", shift.ID,
+		"user_id", userID,
+		"expected_cash", shift.ExpectedCash,
+		"counted_cash", req.CountedCash,
+		"cash_difference", shift.CashDifference,
+		"status", shift.Status,
+	)
+	return uc.newShiftReport(ctx, shift)
+}
+
+// RecordCashMovement records cash put into or taken out of the drawer of the user's open shift, or the
+// drawer opened without a sale. The movement counts toward the cash the shift expects at closing.
+func (uc *ShiftUseCase) RecordCashMovement(ctx context.Context, userID string, req *CashMovementRequest) (*entities.CashMovement, error) {
+	shift, err := uc.GetCurrentShift(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	movement, err := entities.NewCashMovement(shift, userID, req.Type, req.Amount, strings.TrimSpace(req.Reason))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+
+	if err := uc.shiftRepo.CreateCashMovement(ctx, movement); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Cash movement recorded",
+		"shift_id", shift.ID,
+		"user_id", userID,
+		"type", movement.Type,
+		"amount", movement.Amount,
+		"reason", movement.Reason,
+	)
+	return movement, nil
+}
+
+// ApproveVariance lets a manager accept the cash variance of a shift closed pending approval.
+// Managers cannot approve their own shifts.
+func (uc *ShiftUseCase) ApproveVariance(ctx context.Context, id, approverID string, req *ApproveVarianceRequest) (*ShiftReport, error) {
+	shift, err := uc.shiftRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrShiftNotFound
+		}
+		return nil, err
+	}
+
+	if shift.UserID == approverID {
+		return nil, fmt.Errorf("%w: cannot approve the variance of your own shift", appErrors.ErrForbidden)
+	}
+
+	if err := shift.ApproveVariance(approverID, req.Note); err != nil {
+		return nil, appErrors.ErrShiftNotPending
+	}
+
+	if err := uc.shiftRepo.Update(ctx, shift); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Shift variance approved",
+		"shift_id", shift.ID,
+		"approved_by", approverID,
+		"cash_difference", shift.CashDifference,
+	)
+	return uc.newShiftReport(ctx, shift)
+}
+
+// ListPendingApprovals lists the shifts whose cash variance awaits a manager's approval
+func (uc *ShiftUseCase) ListPendingApprovals(ctx context.Context) ([]entities.Shift, error) {
+	return uc.shiftRepo.ListByStatus(ctx, entities.ShiftPendingApproval)
+}
+
+// GetCurrentShift returns the user's open shift with its running totals
+func (uc *ShiftUseCase) GetCurrentShift(ctx context.Context, userID string) (*entities.Shift, error) {
+	shift, err := uc.shiftRepo.GetOpenByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNoOpenShift
+		}
+		return nil, err
+	}
+
+	if err := uc.applyTotals(ctx, shift); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+func (uc *ShiftUseCase) GetShiftReport(ctx context.Context, id string) (*ShiftReport, error) {
+	shift, err := uc.shiftRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrShiftNotFound
+		}
+		return nil, err
+	}
+
+	// Closed shifts keep the totals frozen at closing time
+	if shift.Status == entities.ShiftOpen {
+		if err := uc.applyTotals(ctx, shift); err != nil {
+			return nil, err
+		}
+	}
+
+	return uc.newShiftReport(ctx, shift)
+}
+
+func (uc *ShiftUseCase) applyTotals(ctx context.Context, shift *entities.Shift) error {
+	totals, err := uc.shiftRepo.GetTotals(ctx, shift)
+	if err != nil {
+		return err
+	}
+	shift.ApplyTotals(*totals)
+	return nil
+}
+
+func (uc *ShiftUseCase) newShiftReport(ctx context.Context, shift *entities.Shift) (*ShiftReport, error) {
+	report := &ShiftReport{
+		Shift:            shift,
+		TotalSales:       shift.CashSales + shift.QRISSales + shift.CardSales + shift.OtherSales,
+		NetCashSales:     shift.CashSales - shift.CashRefunds,
+		IsFinal:          shift.Status == entities.ShiftClosed,
+		ApprovalRequired: shift.Status == entities.ShiftPendingApproval,
+	}
+
+	movements, err := uc.shiftRepo.ListCashMovements(ctx, shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	report.CashMovements = movements
+
+	previous, err := uc.shiftRepo.GetLastHandover(ctx, shift.OpenedAt)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if previous != nil {
+		report.Handover = &Handover{
+			ShiftID:  previous.ID,
+			UserName: previous.User.Name,
+			Note:     previous.HandoverNote,
+			ClosedAt: *previous.ClosedAt,
+		}
+	}
+
+	return report, nil
+}
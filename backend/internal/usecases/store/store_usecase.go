@@ -0,0 +1,313 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/payment"
+	"qris-pos-backend/internal/infrastructure/secrets"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type StoreRequest struct {
+	Code         string `json:"code" validate:"required,min=1,max=20,alphanum"` // Stored uppercase
+	Name         string `json:"name" validate:"required,min=1,max=255"`
+	Address      string `json:"address"`
+	Phone        string `json:"phone" validate:"omitempty,phone_id"`
+	TaxID        string `json:"tax_id" validate:"omitempty,npwp"` // NPWP printed on receipts instead of the configured one
+	IsActive     *bool  `json:"is_active"`                        // Defaults to true
+	TrainingMode *bool  `json:"training_mode"`                    // Defaults to false, new sales are practice sales while on
+	// ISO 4217 code of IDR, SGD, MYR or USD, defaults to IDR. Unchanged when omitted, open sales keep theirs.
+	Currency string `json:"currency" validate:"omitempty,len=3"`
+	// Service charge and cash rounding of new sales, unchanged when omitted. Open sales keep theirs.
+	ServiceChargeRate *float64              `json:"service_charge_rate" validate:"omitempty,gte=0,lte=100"` // Percentage of the discounted subtotal
+	RoundingIncrement *int64                `json:"rounding_increment" validate:"omitempty,gte=0"`          // e.g. 100 to round totals to Rp 100, 0 turns rounding off
+	RoundingMode      entities.RoundingMode `json:"rounding_mode" validate:"omitempty,oneof=nearest up down"`
+}
+
+type StoreFilters struct {
+	Limit  int `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset int `form:"offset,default=0" validate:"gte=0"`
+}
+
+// GatewayCredentialRequest sets the Midtrans account a store takes payments with
+type GatewayCredentialRequest struct {
+	ServerKey   string `json:"server_key" validate:"required,max=255"` // Encrypted at rest and never returned
+	ClientKey   string `json:"client_key" validate:"max=255"`
+	Environment string `json:"environment" validate:"omitempty,oneof=sandbox production"` // Defaults to sandbox
+}
+
+// GatewayCredentialResponse is the Midtrans account of a store without its server key
+type GatewayCredentialResponse struct {
+	StoreID       string                   `json:"store_id"`
+	Provider      entities.PaymentProvider `json:"provider"`
+	Configured    bool                     `json:"configured"`                // False while the store takes payments with the shared account
+	ServerKeyHint string                   `json:"server_key_hint,omitempty"` // Last characters of the server key, to tell accounts apart
+	ClientKey     string                   `json:"client_key,omitempty"`
+	Environment   string                   `json:"environment,omitempty"`
+	UpdatedAt     *time.Time               `json:"updated_at,omitempty"`
+}
+
+// PurgeTrainingResponse reports what a training data purge removed
+type PurgeTrainingResponse struct {
+	StoreID             string `json:"store_id"`
+	TransactionsDeleted int64  `json:"transactions_deleted"`
+}
+
+type StoreUseCase struct {
+	storeRepo       repositories.StoreRepository
+	transactionRepo repositories.TransactionRepository
+	credentialRepo  repositories.GatewayCredentialRepository
+	cipher          *secrets.Cipher
+	logger          logger.Logger
+}
+
+func NewStoreUseCase(
+	storeRepo repositories.StoreRepository,
+	transactionRepo repositories.TransactionRepository,
+	credentialRepo repositories.GatewayCredentialRepository,
+	cipher *secrets.Cipher,
+	logger logger.Logger,
+) *StoreUseCase {
+	return &StoreUseCase{
+		storeRepo:       storeRepo,
+		transactionRepo: transactionRepo,
+		credentialRepo:  credentialRepo,
+		cipher:          cipher,
+		logger:          logger,
+	}
+}
+
+func (uc *StoreUseCase) CreateStore(ctx context.Context, req *StoreRequest) (*entities.Store, error) {
+	store := &entities.Store{IsActive: true}
+	applyStoreRequest(store, req)
+
+	if err := store.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureCodeAvailable(ctx, store); err != nil {
+		return nil, err
+	}
+
+	if err := uc.storeRepo.Create(ctx, store); err != nil {
+		uc.logger.Error("Failed to create store", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Store created successfully", "store_id", store.ID, "code", store.Code)
+	return store, nil
+}
+
+func (uc *StoreUseCase) GetStore(ctx context.Context, id string) (*entities.Store, error) {
+	store, err := uc.storeRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrStoreNotFound
+		}
+		return nil, err
+	}
+	return store, nil
+}
+
+// UpdateStore changes the details of a store. A deactivated store keeps its products and history.
+func (uc *StoreUseCase) UpdateStore(ctx context.Context, id string, req *StoreRequest) (*entities.Store, error) {
+	store, err := uc.GetStore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyStoreRequest(store, req)
+
+	if err := store.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureCodeAvailable(ctx, store); err != nil {
+		return nil, err
+	}
+
+	if err := uc.storeRepo.Update(ctx, store); err != nil {
+		uc.logger.Error("Failed to update store", "error", err, "store_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Store updated successfully", "store_id", id)
+	return store, nil
+}
+
+func (uc *StoreUseCase) ListStores(ctx context.Context, filters *StoreFilters) ([]entities.Store, error) {
+	stores, err := uc.storeRepo.List(ctx, filters.Limit, filters.Offset)
+	if err != nil {
+		uc.logger.Error("Failed to list stores", "error", err)
+		return nil, err
+	}
+	return stores, nil
+}
+
+// PurgeTrainingData deletes the practice sales of a store, with their payments, refunds and receipts.
+// Real sales are never touched, so it can be run while the store is trading.
+func (uc *StoreUseCase) PurgeTrainingData(ctx context.Context, id string) (*PurgeTrainingResponse, error) {
+	if _, err := uc.GetStore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	purged, err := uc.transactionRepo.PurgeTraining(ctx, id)
+	if err != nil {
+		uc.logger.Error("Failed to purge training data", "error", err, "store_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Training data purged", "store_id", id, "transactions", purged)
+	return &PurgeTrainingResponse{StoreID: id, TransactionsDeleted: purged}, nil
+}
+
+// GetGatewayCredential returns the Midtrans account of a store, unconfigured when it uses the shared one
+func (uc *StoreUseCase) GetGatewayCredential(ctx context.Context, storeID string) (*GatewayCredentialResponse, error) {
+	if _, err := uc.GetStore(ctx, storeID); err != nil {
+		return nil, err
+	}
+
+	credential, err := uc.credentialRepo.Get(ctx, storeID, entities.ProviderMidtrans)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &GatewayCredentialResponse{StoreID: storeID, Provider: entities.ProviderMidtrans}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return uc.credentialResponse(credential)
+}
+
+// SetGatewayCredential makes a store take its Midtrans payments with an account of its own. Payments
+// already pending keep settling, their notifications are checked with the account of the store.
+func (uc *StoreUseCase) SetGatewayCredential(ctx context.Context, storeID, userID string, req *GatewayCredentialRequest) (*GatewayCredentialResponse, error) {
+	if _, err := uc.GetStore(ctx, storeID); err != nil {
+		return nil, err
+	}
+
+	credential := &entities.GatewayCredential{
+		StoreID:     storeID,
+		Provider:    entities.ProviderMidtrans,
+		ServerKey:   req.ServerKey,
+		ClientKey:   req.ClientKey,
+		Environment: req.Environment,
+		UpdatedBy:   &userID,
+	}
+	if err := credential.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+	encrypted, err := uc.cipher.Encrypt(credential.ServerKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+	credential.ServerKey = encrypted
+
+	if err := uc.credentialRepo.Save(ctx, credential); err != nil {
+		uc.logger.Error("Failed to save gateway credential", "error", err, "store_id", storeID)
+		return nil, err
+	}
+
+	uc.logger.Info("Gateway credential set", "store_id", storeID, "provider", credential.Provider, "environment", credential.Environment, "user_id", userID)
+	return uc.GetGatewayCredential(ctx, storeID)
+}
+
+// DeleteGatewayCredential makes a store take its payments with the shared Midtrans account again
+func (uc *StoreUseCase) DeleteGatewayCredential(ctx context.Context, storeID, userID string) error {
+	if _, err := uc.GetStore(ctx, storeID); err != nil {
+		return err
+	}
+	if err := uc.credentialRepo.Delete(ctx, storeID, entities.ProviderMidtrans); err != nil {
+		uc.logger.Error("Failed to delete gateway credential", "error", err, "store_id", storeID)
+		return err
+	}
+
+	uc.logger.Info("Gateway credential deleted", "store_id", storeID, "user_id", userID)
+	return nil
+}
+
+// MidtransAccount returns the decrypted Midtrans account of a store for the payment gateways, nil when
+// the store uses the shared one
+func (uc *StoreUseCase) MidtransAccount(ctx context.Context, storeID string) (*payment.MidtransAccount, error) {
+	credential, err := uc.credentialRepo.Get(ctx, storeID, entities.ProviderMidtrans)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serverKey, err := uc.cipher.Decrypt(credential.ServerKey)
+	if err != nil {
+		return nil, err
+	}
+	return &payment.MidtransAccount{
+		ServerKey:   serverKey,
+		Environment: credential.Environment,
+		UpdatedAt:   credential.UpdatedAt,
+	}, nil
+}
+
+func (uc *StoreUseCase) credentialResponse(credential *entities.GatewayCredential) (*GatewayCredentialResponse, error) {
+	serverKey, err := uc.cipher.Decrypt(credential.ServerKey)
+	if err != nil {
+		return nil, err
+	}
+	var hint string
+	if len(serverKey) > 8 {
+		hint = "..." + serverKey[len(serverKey)-4:]
+	}
+
+	updatedAt := credential.UpdatedAt
+	return &GatewayCredentialResponse{
+		StoreID:       credential.StoreID,
+		Provider:      credential.Provider,
+		Configured:    true,
+		ServerKeyHint: hint,
+		ClientKey:     credential.ClientKey,
+		Environment:   credential.Environment,
+		UpdatedAt:     &updatedAt,
+	}, nil
+}
+
+// ensureCodeAvailable rejects a code already used by another store
+func (uc *StoreUseCase) ensureCodeAvailable(ctx context.Context, store *entities.Store) error {
+	existing, err := uc.storeRepo.GetByCode(ctx, store.Code)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil && existing.ID != store.ID {
+		return appErrors.ErrStoreExists
+	}
+	return nil
+}
+
+func applyStoreRequest(store *entities.Store, req *StoreRequest) {
+	store.Code = req.Code
+	store.Name = req.Name
+	store.Address = req.Address
+	store.Phone = req.Phone
+	store.TaxID = req.TaxID
+	if req.IsActive != nil {
+		store.IsActive = *req.IsActive
+	}
+	if req.TrainingMode != nil {
+		store.TrainingMode = *req.TrainingMode
+	}
+	if req.Currency != "" {
+		store.Currency = entities.Currency(req.Currency)
+	}
+	if req.ServiceChargeRate != nil {
+		store.ServiceChargeRate = *req.ServiceChargeRate
+	}
+	if req.RoundingIncrement != nil {
+		store.RoundingIncrement = *req.RoundingIncrement
+	}
+	if req.RoundingMode != "" {
+		store.RoundingMode = req.RoundingMode
+	}
+}
@@ -0,0 +1,300 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/scheduler"
+	"qris-pos-backend/internal/infrastructure/storage"
+	"qris-pos-backend/pkg/logger"
+)
+
+// ReconciliationJob is the scheduler job that requeries pending gateway payments
+const ReconciliationJob = "reconcile-payments"
+
+const (
+	// receiptBacklogAge is how long a receipt may wait before the delivery queue counts as backed up
+	receiptBacklogAge = time.Hour
+	// jobFailureLimit is how many runs in a row a job may fail before it is critical
+	jobFailureLimit = 3
+	// missedRuns is how many intervals may pass without a successful run before a job counts as behind
+	missedRuns = 3
+)
+
+// StatusLevel grades a check, from fine to needing attention right away
+type StatusLevel string
+
+const (
+	StatusOK       StatusLevel = "ok"
+	StatusWarning  StatusLevel = "warning"
+	StatusCritical StatusLevel = "critical"
+)
+
+var statusSeverity = map[StatusLevel]int{
+	StatusOK:       0,
+	StatusWarning:  1,
+	StatusCritical: 2,
+}
+
+// SystemStatusResponse is the health of the background machinery at a glance. Status is the worst
+// of the checks and each check explains itself in a sentence, so owners can read it without
+// knowing how the system works.
+type SystemStatusResponse struct {
+	Status    StatusLevel   `json:"status"`
+	CheckedAt string        `json:"checked_at"`
+	Checks    []StatusCheck `json:"checks"`
+	Jobs      []JobStatus   `json:"jobs"`
+}
+
+type StatusCheck struct {
+	Name    string      `json:"name"`
+	Status  StatusLevel `json:"status"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type JobStatus struct {
+	scheduler.JobStatus
+	IntervalSeconds int         `json:"interval_seconds"`
+	Status          StatusLevel `json:"status"`
+}
+
+// StorageUsage is the space used by the database and the product image bucket
+type StorageUsage struct {
+	DatabaseBytes int64                `json:"database_bytes"`
+	Images        *storage.BucketUsage `json:"images,omitempty"`
+}
+
+type SystemStatusUseCase struct {
+	statusRepo    repositories.SystemStatusRepository
+	scheduler     *scheduler.Scheduler
+	storageClient storage.Storage
+	jobsConfig    config.JobsConfig
+	storageConfig config.StorageConfig
+	logger        logger.Logger
+}
+
+func NewSystemStatusUseCase(
+	statusRepo repositories.SystemStatusRepository,
+	jobScheduler *scheduler.Scheduler,
+	storageClient storage.Storage,
+	jobsConfig config.JobsConfig,
+	storageConfig config.StorageConfig,
+	logger logger.Logger,
+) *SystemStatusUseCase {
+	return &SystemStatusUseCase{
+		statusRepo:    statusRepo,
+		scheduler:     jobScheduler,
+		storageClient: storageClient,
+		jobsConfig:    jobsConfig,
+		storageConfig: storageConfig,
+		logger:        logger,
+	}
+}
+
+// GetSystemStatus runs every check. A check that can't be completed is reported as critical
+// instead of failing the whole page.
+func (uc *SystemStatusUseCase) GetSystemStatus(ctx context.Context) *SystemStatusResponse {
+	now := time.Now()
+	jobs := uc.jobStatuses(now)
+
+	result := &SystemStatusResponse{
+		Status:    StatusOK,
+		CheckedAt: now.Format(time.RFC3339),
+		Checks: []StatusCheck{
+			uc.checkReceiptDeliveries(ctx, now),
+			uc.checkPaymentExceptions(ctx),
+			uc.checkStuckPayments(ctx, now),
+			checkJobs(jobs),
+			uc.checkReconciliation(now),
+			uc.checkStorage(ctx),
+		},
+		Jobs: jobs,
+	}
+	for _, check := range result.Checks {
+		if statusSeverity[check.Status] > statusSeverity[result.Status] {
+			result.Status = check.Status
+		}
+	}
+	return result
+}
+
+func (uc *SystemStatusUseCase) checkReceiptDeliveries(ctx context.Context, now time.Time) StatusCheck {
+	check := StatusCheck{Name: "receipt_deliveries", Status: StatusOK}
+
+	backlog, err := uc.statusRepo.ReceiptDeliveryBacklog(ctx)
+	if err != nil {
+		return uc.failedCheck(check, err)
+	}
+	check.Details = backlog
+
+	switch {
+	case backlog.OldestAt != nil && now.Sub(*backlog.OldestAt) > receiptBacklogAge:
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("%d receipts are waiting to be sent, the oldest since %s", backlog.Pending, backlog.OldestAt.Format(time.RFC3339))
+	case backlog.Failed > 0:
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("%d receipts could not be sent after all retries", backlog.Failed)
+	default:
+		check.Message = fmt.Sprintf("Receipts are being sent, %d waiting", backlog.Pending)
+	}
+	return check
+}
+
+func (uc *SystemStatusUseCase) checkPaymentExceptions(ctx context.Context) StatusCheck {
+	check := StatusCheck{Name: "payment_exceptions", Status: StatusOK}
+
+	backlog, err := uc.statusRepo.PaymentExceptionBacklog(ctx)
+	if err != nil {
+		return uc.failedCheck(check, err)
+	}
+	check.Details = backlog
+
+	if backlog.Pending > 0 {
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("%d payments need to be reviewed in the payment exceptions queue", backlog.Pending)
+		return check
+	}
+	check.Message = "No payments are waiting for review"
+	return check
+}
+
+// checkStuckPayments looks for pending payments the expiry job should have closed a few runs ago
+func (uc *SystemStatusUseCase) checkStuckPayments(ctx context.Context, now time.Time) StatusCheck {
+	check := StatusCheck{Name: "stuck_payments", Status: StatusOK}
+
+	grace := missedRuns * time.Duration(uc.jobsConfig.ExpiryIntervalSeconds) * time.Second
+	backlog, err := uc.statusRepo.StuckPayments(ctx, now.Add(-grace))
+	if err != nil {
+		return uc.failedCheck(check, err)
+	}
+	check.Details = backlog
+
+	if backlog.Pending > 0 {
+		check.Status = StatusCritical
+		check.Message = fmt.Sprintf("%d payments are still pending after they expired, the expiry job may not be running", backlog.Pending)
+		return check
+	}
+	check.Message = "Expired payments are being closed on time"
+	return check
+}
+
+func checkJobs(jobs []JobStatus) StatusCheck {
+	check := StatusCheck{Name: "background_jobs", Status: StatusOK, Message: "All background jobs are running"}
+
+	failing := 0
+	for _, job := range jobs {
+		if job.Status == StatusOK {
+			continue
+		}
+		failing++
+		if statusSeverity[job.Status] > statusSeverity[check.Status] {
+			check.Status = job.Status
+		}
+	}
+	if failing > 0 {
+		check.Message = fmt.Sprintf("%d of %d background jobs are failing", failing, len(jobs))
+	}
+	return check
+}
+
+// checkReconciliation reports when pending payments were last requeried with the gateways
+func (uc *SystemStatusUseCase) checkReconciliation(now time.Time) StatusCheck {
+	check := StatusCheck{Name: "reconciliation", Status: StatusOK}
+
+	job, ok := uc.scheduler.JobStatus(ReconciliationJob)
+	if !ok {
+		check.Status = StatusWarning
+		check.Message = "Payment reconciliation is turned off, payments whose webhook is lost stay pending until they expire"
+		return check
+	}
+	check.Details = job
+
+	switch {
+	case job.LastSuccessAt == nil && job.Runs == 0:
+		check.Message = "Payment reconciliation has not run yet since the server started"
+	case job.LastSuccessAt == nil:
+		check.Status = StatusCritical
+		check.Message = "Payment reconciliation has not succeeded since the server started: " + job.LastError
+	case now.Sub(*job.LastSuccessAt) > missedRuns*job.Interval:
+		check.Status = StatusWarning
+		check.Message = fmt.Sprintf("Payments were last reconciled at %s, the latest runs did not finish", job.LastSuccessAt.Format(time.RFC3339))
+	default:
+		check.Message = fmt.Sprintf("Payments were last reconciled at %s", job.LastSuccessAt.Format(time.RFC3339))
+	}
+	return check
+}
+
+// checkStorage reports the space in use. Supabase quotas depend on the plan, so usage is informational
+// and only an unreachable bucket is flagged.
+func (uc *SystemStatusUseCase) checkStorage(ctx context.Context) StatusCheck {
+	check := StatusCheck{Name: "storage", Status: StatusOK}
+
+	size, err := uc.statusRepo.DatabaseSize(ctx)
+	if err != nil {
+		return uc.failedCheck(check, err)
+	}
+	usage := StorageUsage{DatabaseBytes: size}
+	check.Details = &usage
+	check.Message = fmt.Sprintf("Database uses %s", formatBytes(size))
+
+	if !storage.Configured(uc.storageConfig) {
+		return check
+	}
+	images, err := uc.storageClient.Usage(ctx)
+	if err != nil {
+		uc.logger.Warn("Failed to read image storage usage", "error", err)
+		check.Status = StatusWarning
+		check.Message += ", image storage could not be reached"
+		return check
+	}
+	usage.Images = images
+	check.Message += fmt.Sprintf(", %d product images use %s", images.Objects, formatBytes(images.Bytes))
+	return check
+}
+
+func (uc *SystemStatusUseCase) jobStatuses(now time.Time) []JobStatus {
+	statuses := uc.scheduler.Status()
+	jobs := make([]JobStatus, 0, len(statuses))
+	for _, status := range statuses {
+		job := JobStatus{
+			JobStatus:       status,
+			IntervalSeconds: int(status.Interval / time.Second),
+			Status:          StatusOK,
+		}
+		switch {
+		case status.ConsecutiveFailures >= jobFailureLimit:
+			job.Status = StatusCritical
+		case status.ConsecutiveFailures > 0:
+			job.Status = StatusWarning
+		case status.LastRunAt != nil && now.Sub(*status.LastRunAt) > missedRuns*status.Interval:
+			// A run is hanging
+			job.Status = StatusWarning
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func (uc *SystemStatusUseCase) failedCheck(check StatusCheck, err error) StatusCheck {
+	uc.logger.Error("System status check failed", "check", check.Name, "error", err)
+	check.Status = StatusCritical
+	check.Message = "This could not be checked: " + err.Error()
+	return check
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,227 @@
+package table
+
+import (
+	"context"
+	"errors"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type TableRequest struct {
+	Name      string `json:"name" validate:"required,min=1,max=50"`
+	Area      string `json:"area" validate:"omitempty,max=50"`
+	Seats     int    `json:"seats" validate:"gte=0,lte=100"`
+	SortOrder int    `json:"sort_order" validate:"gte=0"`
+	IsActive  *bool  `json:"is_active"` // Defaults to true, inactive tables can't take new orders
+}
+
+type TableFilters struct {
+	ActiveOnly bool `form:"active_only"`
+}
+
+// FloorPlanResponse lists the tables of the store with the order open on each of them
+type FloorPlanResponse struct {
+	Tables     []FloorPlanTable `json:"tables"`
+	OpenTables int              `json:"open_tables"`
+	OpenTotal  int64            `json:"open_total"` // Sum of the open orders, not yet settled
+}
+
+type FloorPlanTable struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Area      string         `json:"area"`
+	Seats     int            `json:"seats"`
+	SortOrder int            `json:"sort_order"`
+	Occupied  bool           `json:"occupied"`
+	Order     *OpenOrderInfo `json:"order,omitempty"`
+}
+
+type OpenOrderInfo struct {
+	TransactionID string `json:"transaction_id"`
+	UserID        string `json:"user_id"`
+	TotalAmount   int64  `json:"total_amount"`
+	ItemCount     int    `json:"item_count"`
+	OpenedAt      string `json:"opened_at"`
+}
+
+type TableUseCase struct {
+	tableRepo repositories.TableRepository
+	logger    logger.Logger
+}
+
+func NewTableUseCase(tableRepo repositories.TableRepository, logger logger.Logger) *TableUseCase {
+	return &TableUseCase{
+		tableRepo: tableRepo,
+		logger:    logger,
+	}
+}
+
+// CreateTable adds a table to the floor plan of the store the admin works in
+func (uc *TableUseCase) CreateTable(ctx context.Context, req *TableRequest) (*entities.Table, error) {
+	storeID, ok := repositories.StoreFromContext(ctx)
+	if !ok {
+		return nil, appErrors.ErrStoreRequired
+	}
+
+	table := &entities.Table{StoreID: storeID, IsActive: true}
+	applyTableRequest(table, req)
+
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureNameAvailable(ctx, table); err != nil {
+		return nil, err
+	}
+
+	if err := uc.tableRepo.Create(ctx, table); err != nil {
+		uc.logger.Error("Failed to create table", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Table created successfully", "table_id", table.ID, "name", table.Name)
+	return table, nil
+}
+
+func (uc *TableUseCase) GetTable(ctx context.Context, id string) (*entities.Table, error) {
+	table, err := uc.tableRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTableNotFound
+		}
+		return nil, err
+	}
+	return table, nil
+}
+
+// UpdateTable changes a table. An order already open on a deactivated table can still be settled.
+func (uc *TableUseCase) UpdateTable(ctx context.Context, id string, req *TableRequest) (*entities.Table, error) {
+	table, err := uc.GetTable(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyTableRequest(table, req)
+
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.ensureNameAvailable(ctx, table); err != nil {
+		return nil, err
+	}
+
+	if err := uc.tableRepo.Update(ctx, table); err != nil {
+		uc.logger.Error("Failed to update table", "error", err, "table_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Table updated successfully", "table_id", id)
+	return table, nil
+}
+
+// DeleteTable removes a table from the floor plan. Settled orders keep pointing at it.
+func (uc *TableUseCase) DeleteTable(ctx context.Context, id string) error {
+	if _, err := uc.GetTable(ctx, id); err != nil {
+		return err
+	}
+
+	if _, err := uc.tableRepo.GetOpenOrder(ctx, id); err == nil {
+		return appErrors.ErrTableOccupied
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err := uc.tableRepo.Delete(ctx, id); err != nil {
+		uc.logger.Error("Failed to delete table", "error", err, "table_id", id)
+		return err
+	}
+
+	uc.logger.Info("Table deleted successfully", "table_id", id)
+	return nil
+}
+
+func (uc *TableUseCase) ListTables(ctx context.Context, filters *TableFilters) ([]entities.Table, error) {
+	tables, err := uc.tableRepo.List(ctx, filters.ActiveOnly)
+	if err != nil {
+		uc.logger.Error("Failed to list tables", "error", err)
+		return nil, err
+	}
+	return tables, nil
+}
+
+// GetFloorPlan lists the active tables in floor plan order with the running total of the order open on each
+func (uc *TableUseCase) GetFloorPlan(ctx context.Context) (*FloorPlanResponse, error) {
+	tables, err := uc.tableRepo.List(ctx, true)
+	if err != nil {
+		uc.logger.Error("Failed to list tables", "error", err)
+		return nil, err
+	}
+
+	orders, err := uc.tableRepo.ListOpenOrders(ctx)
+	if err != nil {
+		uc.logger.Error("Failed to list open table orders", "error", err)
+		return nil, err
+	}
+
+	// The oldest order wins should a table ever hold two
+	openByTable := make(map[string]repositories.TableOrder, len(orders))
+	for _, order := range orders {
+		if _, ok := openByTable[order.TableID]; !ok {
+			openByTable[order.TableID] = order
+		}
+	}
+
+	result := &FloorPlanResponse{Tables: make([]FloorPlanTable, 0, len(tables))}
+	for _, table := range tables {
+		entry := FloorPlanTable{
+			ID:        table.ID,
+			Name:      table.Name,
+			Area:      table.Area,
+			Seats:     table.Seats,
+			SortOrder: table.SortOrder,
+		}
+
+		if order, ok := openByTable[table.ID]; ok {
+			entry.Occupied = true
+			entry.Order = &OpenOrderInfo{
+				TransactionID: order.TransactionID,
+				UserID:        order.UserID,
+				TotalAmount:   order.TotalAmount,
+				ItemCount:     order.ItemCount,
+				OpenedAt:      order.OpenedAt.Format("2006-01-02T15:04:05Z07:00"),
+			}
+			result.OpenTables++
+			result.OpenTotal += order.TotalAmount
+		}
+
+		result.Tables = append(result.Tables, entry)
+	}
+
+	return result, nil
+}
+
+// ensureNameAvailable rejects a name already used by another table of the store
+func (uc *TableUseCase) ensureNameAvailable(ctx context.Context, table *entities.Table) error {
+	existing, err := uc.tableRepo.GetByName(ctx, table.Name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil && existing.ID != table.ID {
+		return appErrors.ErrTableExists
+	}
+	return nil
+}
+
+func applyTableRequest(table *entities.Table, req *TableRequest) {
+	table.Name = req.Name
+	table.Area = req.Area
+	table.Seats = req.Seats
+	table.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		table.IsActive = *req.IsActive
+	}
+}
@@ -0,0 +1,138 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type TaxRuleRequest struct {
+	Name              string   `json:"name" validate:"required,min=1,max=100"`
+	Rate              float64  `json:"rate" validate:"gt=0,lte=100"` // Percentage
+	Inclusive         bool     `json:"inclusive"`                    // Prices already include the tax
+	ExemptCategoryIDs []string `json:"exempt_category_ids" validate:"omitempty,dive,uuid"`
+	IsActive          *bool    `json:"is_active"`
+}
+
+type TaxUseCase struct {
+	taxRuleRepo  repositories.TaxRuleRepository
+	categoryRepo repositories.CategoryRepository
+	logger       logger.Logger
+}
+
+func NewTaxUseCase(
+	taxRuleRepo repositories.TaxRuleRepository,
+	categoryRepo repositories.CategoryRepository,
+	logger logger.Logger,
+) *TaxUseCase {
+	return &TaxUseCase{
+		taxRuleRepo:  taxRuleRepo,
+		categoryRepo: categoryRepo,
+		logger:       logger,
+	}
+}
+
+// CreateTaxRule adds a tax rule. Active rules are applied to pending transactions from their next change.
+func (uc *TaxUseCase) CreateTaxRule(ctx context.Context, req *TaxRuleRequest) (*entities.TaxRule, error) {
+	rule := &entities.TaxRule{IsActive: true}
+	if err := uc.applyTaxRuleRequest(ctx, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taxRuleRepo.Create(ctx, rule); err != nil {
+		uc.logger.Error("Failed to create tax rule", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Tax rule created successfully", "tax_rule_id", rule.ID, "rate", rule.Rate, "inclusive", rule.Inclusive)
+	return rule, nil
+}
+
+func (uc *TaxUseCase) GetTaxRule(ctx context.Context, id string) (*entities.TaxRule, error) {
+	rule, err := uc.taxRuleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTaxRuleNotFound
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (uc *TaxUseCase) UpdateTaxRule(ctx context.Context, id string, req *TaxRuleRequest) (*entities.TaxRule, error) {
+	rule, err := uc.GetTaxRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.applyTaxRuleRequest(ctx, rule, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.taxRuleRepo.Update(ctx, rule); err != nil {
+		uc.logger.Error("Failed to update tax rule", "error", err, "tax_rule_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Tax rule updated successfully", "tax_rule_id", id)
+	return rule, nil
+}
+
+// DeleteTaxRule removes a tax rule. Transactions keep the taxes already charged under it.
+func (uc *TaxUseCase) DeleteTaxRule(ctx context.Context, id string) error {
+	if _, err := uc.GetTaxRule(ctx, id); err != nil {
+		return err
+	}
+
+	if err := uc.taxRuleRepo.Delete(ctx, id); err != nil {
+		uc.logger.Error("Failed to delete tax rule", "error", err, "tax_rule_id", id)
+		return err
+	}
+
+	uc.logger.Info("Tax rule deleted successfully", "tax_rule_id", id)
+	return nil
+}
+
+func (uc *TaxUseCase) ListTaxRules(ctx context.Context) ([]entities.TaxRule, error) {
+	rules, err := uc.taxRuleRepo.List(ctx)
+	if err != nil {
+		uc.logger.Error("Failed to list tax rules", "error", err)
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (uc *TaxUseCase) applyTaxRuleRequest(ctx context.Context, rule *entities.TaxRule, req *TaxRuleRequest) error {
+	rule.Name = req.Name
+	rule.Rate = req.Rate
+	rule.Inclusive = req.Inclusive
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	rule.Exemptions = make([]entities.TaxRuleExemption, 0, len(req.ExemptCategoryIDs))
+	seen := make(map[string]bool, len(req.ExemptCategoryIDs))
+	for _, categoryID := range req.ExemptCategoryIDs {
+		if seen[categoryID] {
+			continue
+		}
+		seen[categoryID] = true
+
+		if _, err := uc.categoryRepo.GetByID(ctx, categoryID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", appErrors.ErrCategoryNotFound, categoryID)
+			}
+			return err
+		}
+		rule.Exemptions = append(rule.Exemptions, entities.TaxRuleExemption{CategoryID: categoryID})
+	}
+
+	return rule.Validate()
+}
@@ -0,0 +1,170 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/pkg/auth"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// CreateTenantRequest onboards a merchant with its first store and admin, who sets up the rest from
+// inside the tenant
+type CreateTenantRequest struct {
+	Slug          string `json:"slug" validate:"required,min=1,max=63"` // Subdomain, lowercase letters, digits and hyphens
+	Name          string `json:"name" validate:"required,min=1,max=255"`
+	StoreCode     string `json:"store_code" validate:"required,min=1,max=20,alphanum"`
+	StoreName     string `json:"store_name" validate:"required,min=1,max=255"`
+	AdminName     string `json:"admin_name" validate:"required,min=2,max=100"`
+	AdminEmail    string `json:"admin_email" validate:"required,email"`
+	AdminPassword string `json:"admin_password" validate:"required,min=6"`
+}
+
+// UpdateTenantRequest renames or suspends a tenant. The slug stays, it is in the addresses of the tenant.
+type UpdateTenantRequest struct {
+	Name     string `json:"name" validate:"required,min=1,max=255"`
+	IsActive *bool  `json:"is_active"` // Unchanged when omitted, requests to a suspended tenant are refused
+}
+
+type TenantFilters struct {
+	Limit  int `form:"limit,default=20" validate:"gte=1,lte=100"`
+	Offset int `form:"offset,default=0" validate:"gte=0"`
+}
+
+// OnboardTenantResponse is a new tenant with what it was set up with
+type OnboardTenantResponse struct {
+	Tenant *entities.Tenant `json:"tenant"`
+	Store  *entities.Store  `json:"store"`
+	Admin  *entities.User   `json:"admin"`
+}
+
+type TenantUseCase struct {
+	tenantRepo      repositories.TenantRepository
+	storeRepo       repositories.StoreRepository
+	userRepo        repositories.UserRepository
+	txManager       repositories.TransactionManager
+	passwordService *auth.PasswordService
+	logger          logger.Logger
+}
+
+func NewTenantUseCase(
+	tenantRepo repositories.TenantRepository,
+	storeRepo repositories.StoreRepository,
+	userRepo repositories.UserRepository,
+	txManager repositories.TransactionManager,
+	passwordService *auth.PasswordService,
+	logger logger.Logger,
+) *TenantUseCase {
+	return &TenantUseCase{
+		tenantRepo:      tenantRepo,
+		storeRepo:       storeRepo,
+		userRepo:        userRepo,
+		txManager:       txManager,
+		passwordService: passwordService,
+		logger:          logger,
+	}
+}
+
+// CreateTenant onboards a merchant. The tenant, its store and its admin are created together, the admin
+// oversees every store of the tenant and can log in right away.
+func (uc *TenantUseCase) CreateTenant(ctx context.Context, req *CreateTenantRequest) (*OnboardTenantResponse, error) {
+	tenant := &entities.Tenant{Slug: req.Slug, Name: req.Name, IsActive: true}
+	if err := tenant.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+	store := &entities.Store{Code: req.StoreCode, Name: req.StoreName, Currency: entities.DefaultCurrency, IsActive: true}
+	if err := store.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+
+	if _, err := uc.tenantRepo.GetBySlug(ctx, tenant.Slug); err == nil {
+		return nil, appErrors.ErrTenantExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	// Emails log in on every tenant, so they are unique across the platform
+	if _, err := uc.userRepo.GetByEmail(ctx, req.AdminEmail); err == nil {
+		return nil, appErrors.ErrEmailExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := uc.passwordService.ValidatePasswordStrength(req.AdminPassword); err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+	hashedPassword, err := uc.passwordService.HashPassword(req.AdminPassword)
+	if err != nil {
+		uc.logger.Error("Failed to hash password", "error", err)
+		return nil, errors.New("failed to process password")
+	}
+	admin := entities.NewUser(req.AdminEmail, req.AdminName, hashedPassword, entities.RoleAdmin)
+
+	err = uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.tenantRepo.Create(ctx, tenant); err != nil {
+			return err
+		}
+		ctx = repositories.WithTenant(ctx, tenant.ID)
+		if err := uc.storeRepo.Create(ctx, store); err != nil {
+			return err
+		}
+		return uc.userRepo.Create(ctx, admin)
+	})
+	if err != nil {
+		uc.logger.Error("Failed to create tenant", "error", err, "slug", tenant.Slug)
+		return nil, err
+	}
+
+	uc.logger.Info("Tenant created", "tenant_id", tenant.ID, "slug", tenant.Slug, "store_id", store.ID, "admin_id", admin.ID)
+	return &OnboardTenantResponse{Tenant: tenant, Store: store, Admin: admin}, nil
+}
+
+func (uc *TenantUseCase) GetTenant(ctx context.Context, id string) (*entities.Tenant, error) {
+	tenant, err := uc.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// UpdateTenant renames a tenant or suspends it. A suspended tenant keeps its data, its users can't
+// reach it until it is active again.
+func (uc *TenantUseCase) UpdateTenant(ctx context.Context, id string, req *UpdateTenantRequest) (*entities.Tenant, error) {
+	tenant, err := uc.GetTenant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Name = req.Name
+	if req.IsActive != nil {
+		tenant.IsActive = *req.IsActive
+	}
+	if err := tenant.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", appErrors.ErrInvalidInput, err.Error())
+	}
+
+	if err := uc.tenantRepo.Update(ctx, tenant); err != nil {
+		uc.logger.Error("Failed to update tenant", "error", err, "tenant_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Tenant updated", "tenant_id", id, "is_active", tenant.IsActive)
+	return tenant, nil
+}
+
+func (uc *TenantUseCase) ListTenants(ctx context.Context, filters *TenantFilters) ([]entities.Tenant, error) {
+	tenants, err := uc.tenantRepo.List(ctx, filters.Limit, filters.Offset)
+	if err != nil {
+		uc.logger.Error("Failed to list tenants", "error", err)
+		return nil, err
+	}
+	return tenants, nil
+}
@@ -0,0 +1,180 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/usecases/auth"
+	appErrors "qris-pos-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type ApplyDiscountRequest struct {
+	Type             entities.DiscountType `json:"type" validate:"required,oneof=percentage nominal"`
+	Value            float64               `json:"value" validate:"gt=0"` // Percentage off, or amount off (per unit on an item)
+	Reason           string                `json:"reason" validate:"required,max=255"`
+	ApproverEmail    string                `json:"approver_email" validate:"omitempty,email"` // Admin signing off a discount above the cashier's limit
+	ApproverPassword string                `json:"approver_password" validate:"required_with=ApproverEmail"`
+}
+
+// ApplyTransactionDiscount gives a manual discount on the whole cart of a pending transaction. It applies
+// to what is left after promotions and item discounts, and replaces any previous cart discount.
+func (uc *TransactionUseCase) ApplyTransactionDiscount(ctx context.Context, transactionID, sessionUserID string, sessionRole entities.UserRole, req *ApplyDiscountRequest) (*TransactionResponse, error) {
+	transaction, err := uc.getPendingTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := uc.transactionRepo.GetItems(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	var subtotal int64
+	for _, item := range items {
+		subtotal += item.TotalPrice
+	}
+
+	discount, err := uc.authorizeDiscount(ctx, sessionUserID, sessionRole, req, subtotal-(transaction.Discount-transaction.ManualDiscount.Amount), 1)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.ManualDiscount = *discount
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		uc.logger.Error("Failed to apply transaction discount", "error", err, "transaction_id", transactionID)
+		return nil, err
+	}
+
+	if err := uc.recalculateTransaction(ctx, transactionID); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Transaction discount applied", "transaction_id", transactionID, "type", discount.Type, "value", discount.Value, "given_by", sessionUserID, "approved_by", discount.ApprovedBy)
+	return uc.GetTransaction(ctx, transactionID)
+}
+
+// RemoveTransactionDiscount takes the manual cart discount off a pending transaction
+func (uc *TransactionUseCase) RemoveTransactionDiscount(ctx context.Context, transactionID string) (*TransactionResponse, error) {
+	transaction, err := uc.getPendingTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.ManualDiscount = entities.ManualDiscount{}
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	if err := uc.recalculateTransaction(ctx, transactionID); err != nil {
+		return nil, err
+	}
+
+	return uc.GetTransaction(ctx, transactionID)
+}
+
+// ApplyItemDiscount gives a manual discount on a product of a pending transaction, on every line of it.
+// A nominal discount is taken off each unit.
+func (uc *TransactionUseCase) ApplyItemDiscount(ctx context.Context, transactionID, productID, sessionUserID string, sessionRole entities.UserRole, req *ApplyDiscountRequest) (*TransactionResponse, error) {
+	if _, err := uc.getPendingTransaction(ctx, transactionID); err != nil {
+		return nil, err
+	}
+
+	items, err := uc.transactionRepo.GetItems(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	var base int64
+	var quantity float64
+	for _, item := range items {
+		if item.ProductID == productID {
+			base += item.TotalPrice
+			quantity += item.Quantity
+		}
+	}
+	if quantity == 0 {
+		return nil, appErrors.ErrOrderItemNotFound
+	}
+
+	discount, err := uc.authorizeDiscount(ctx, sessionUserID, sessionRole, req, base, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.SetItemDiscount(ctx, transactionID, productID, *discount); err != nil {
+		uc.logger.Error("Failed to apply item discount", "error", err, "transaction_id", transactionID, "product_id", productID)
+		return nil, err
+	}
+
+	if err := uc.recalculateTransaction(ctx, transactionID); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Item discount applied", "transaction_id", transactionID, "product_id", productID, "type", discount.Type, "value", discount.Value, "given_by", sessionUserID, "approved_by", discount.ApprovedBy)
+	return uc.GetTransaction(ctx, transactionID)
+}
+
+// RemoveItemDiscount takes the manual discount off a product of a pending transaction
+func (uc *TransactionUseCase) RemoveItemDiscount(ctx context.Context, transactionID, productID string) (*TransactionResponse, error) {
+	if _, err := uc.getPendingTransaction(ctx, transactionID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.SetItemDiscount(ctx, transactionID, productID, entities.ManualDiscount{}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrOrderItemNotFound
+		}
+		return nil, err
+	}
+
+	if err := uc.recalculateTransaction(ctx, transactionID); err != nil {
+		return nil, err
+	}
+
+	return uc.GetTransaction(ctx, transactionID)
+}
+
+// authorizeDiscount builds the requested discount on base, the price of quantity units, and checks it
+// against the limits of the roles. Nobody goes past the admin limit. A cashier going past theirs needs an
+// admin's credentials, and the admin is recorded as the approver.
+func (uc *TransactionUseCase) authorizeDiscount(ctx context.Context, sessionUserID string, sessionRole entities.UserRole, req *ApplyDiscountRequest, base int64, quantity float64) (*entities.ManualDiscount, error) {
+	discount := &entities.ManualDiscount{
+		Type:    req.Type,
+		Value:   req.Value,
+		Reason:  strings.TrimSpace(req.Reason),
+		GivenBy: &sessionUserID,
+	}
+	if err := discount.Validate(); err != nil {
+		return nil, err
+	}
+	if base <= 0 {
+		return nil, errors.New("nothing left to discount")
+	}
+
+	percent := discount.Value
+	if discount.Type == entities.DiscountNominal {
+		percent = entities.DiscountPercent(discount.AmountOff(base, quantity), base)
+	}
+	if percent > uc.discountConfig.AdminMaxPercent {
+		return nil, appErrors.ErrDiscountTooLarge
+	}
+	if sessionRole == entities.RoleAdmin || percent <= uc.discountConfig.CashierMaxPercent {
+		return discount, nil
+	}
+
+	if req.ApproverEmail == "" {
+		return nil, appErrors.ErrApprovalRequired
+	}
+	approver, err := auth.VerifyApprover(ctx, uc.userRepo, uc.passwordService, req.ApproverEmail, req.ApproverPassword)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrInvalidCredentials) {
+			uc.logger.Warn("Discount approval with invalid credentials", "email", req.ApproverEmail, "session_user_id", sessionUserID)
+		}
+		return nil, err
+	}
+
+	discount.ApprovedBy = &approver.ID
+	return discount, nil
+}
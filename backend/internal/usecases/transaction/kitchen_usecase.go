@@ -0,0 +1,153 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/events"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+type UpdateItemStatusRequest struct {
+	Status entities.ItemPrepStatus `json:"status" validate:"required,oneof=preparing ready"`
+}
+
+// KitchenOrder is an order as shown on the kitchen display, without prices
+type KitchenOrder struct {
+	TransactionID string                     `json:"transaction_id"`
+	OrderNumber   string                     `json:"order_number"`
+	OrderType     entities.OrderType         `json:"order_type"`
+	Status        entities.TransactionStatus `json:"status"` // Cancelled and expired orders can be dropped from the display
+	TableName     string                     `json:"table_name,omitempty"`
+	Notes         string                     `json:"notes,omitempty"`
+	Ready         bool                       `json:"ready"` // Every item is prepared
+	Items         []KitchenItem              `json:"items"`
+	CreatedAt     string                     `json:"created_at"`
+}
+
+type KitchenItem struct {
+	ID         string                  `json:"id"`
+	Name       string                  `json:"name"`
+	Quantity   float64                 `json:"quantity"`
+	Unit       entities.UnitOfMeasure  `json:"unit"`
+	Sequence   int                     `json:"sequence"`
+	PrepStatus entities.ItemPrepStatus `json:"prep_status"`
+}
+
+type KitchenUseCase struct {
+	transactionRepo repositories.TransactionRepository
+	eventBroker     *events.Broker
+	logger          logger.Logger
+}
+
+func NewKitchenUseCase(
+	transactionRepo repositories.TransactionRepository,
+	eventBroker *events.Broker,
+	logger logger.Logger,
+) *KitchenUseCase {
+	return &KitchenUseCase{
+		transactionRepo: transactionRepo,
+		eventBroker:     eventBroker,
+		logger:          logger,
+	}
+}
+
+// UpdateItemStatus moves an item of an order forward through the kitchen statuses. The order is
+// pushed to the kitchen channel, as ready once its last item is.
+func (uc *KitchenUseCase) UpdateItemStatus(ctx context.Context, transactionID, itemID string, req *UpdateItemStatusRequest) (*KitchenOrder, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	switch transaction.Status {
+	case entities.StatusCancelled, entities.StatusExpired, entities.StatusRefunded:
+		return nil, errors.New("order is no longer active")
+	}
+
+	item, err := uc.transactionRepo.GetItem(ctx, transactionID, itemID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrOrderItemNotFound
+		}
+		return nil, err
+	}
+
+	if err := item.AdvancePrep(req.Status); err != nil {
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.UpdateItemPrep(ctx, item); err != nil {
+		uc.logger.Error("Failed to update item preparation status", "error", err, "transaction_id", transactionID, "item_id", itemID)
+		return nil, err
+	}
+
+	detailed, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventType := events.EventKitchenOrderUpdated
+	if detailed.ItemsReady() {
+		eventType = events.EventKitchenOrderReady
+	}
+	result := publishKitchenOrder(uc.eventBroker, eventType, detailed)
+
+	uc.logger.Info("Item preparation status updated", "transaction_id", transactionID, "item_id", itemID, "status", req.Status)
+	return result, nil
+}
+
+// publishKitchenOrder pushes the order to the kitchen channel of its store. Training sales are never
+// sent to the kitchen.
+func publishKitchenOrder(broker *events.Broker, eventType events.EventType, transaction *entities.Transaction) *KitchenOrder {
+	order := mapKitchenOrder(transaction)
+	if transaction.IsTraining {
+		return order
+	}
+
+	broker.Publish(events.Event{
+		Type:          eventType,
+		TransactionID: transaction.ID,
+		StoreID:       transaction.StoreID,
+		TenantID:      events.TenantOf(transaction.TenantID),
+		Data:          order,
+	})
+	return order
+}
+
+func mapKitchenOrder(transaction *entities.Transaction) *KitchenOrder {
+	order := &KitchenOrder{
+		TransactionID: transaction.ID,
+		OrderNumber:   orderNumber(transaction),
+		OrderType:     transaction.OrderType,
+		Status:        transaction.Status,
+		Notes:         transaction.Notes,
+		Ready:         transaction.ItemsReady(),
+		Items:         make([]KitchenItem, 0, len(transaction.Items)),
+		CreatedAt:     transaction.CreatedAt.Format(time.RFC3339),
+	}
+	if transaction.Table != nil {
+		order.TableName = transaction.Table.Name
+	}
+
+	for _, item := range transaction.Items {
+		order.Items = append(order.Items, KitchenItem{
+			ID:         item.ID,
+			Name:       item.Product.Name,
+			Quantity:   item.Quantity,
+			Unit:       item.Unit,
+			Sequence:   item.Sequence,
+			PrepStatus: item.PrepStatus,
+		})
+	}
+	return order
+}
@@ -0,0 +1,226 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/events"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// OrderStage is the progress of an order as shown to the customer
+type OrderStage string
+
+const (
+	StageAwaitingPayment OrderStage = "awaiting_payment"
+	StagePaid            OrderStage = "paid"
+	StagePreparing       OrderStage = "preparing"
+	StageReady           OrderStage = "ready"
+	StageCompleted       OrderStage = "completed"
+	StageCancelled       OrderStage = "cancelled"
+)
+
+type UpdateFulfillmentRequest struct {
+	Status entities.FulfillmentStatus `json:"status" validate:"required,oneof=preparing ready completed"`
+}
+
+// OrderTrackingResponse is the public view of an order on its status page. Anyone holding the link
+// can open it, so prices and customer details are left out.
+type OrderTrackingResponse struct {
+	OrderNumber string              `json:"order_number"`
+	OrderType   entities.OrderType  `json:"order_type"`
+	Stage       OrderStage          `json:"stage"`
+	Steps       []OrderTrackingStep `json:"steps"`
+	Items       []OrderTrackingItem `json:"items"`
+	UpdatedAt   string              `json:"updated_at"`
+}
+
+type OrderTrackingStep struct {
+	Stage OrderStage `json:"stage"`
+	Done  bool       `json:"done"`
+	At    *string    `json:"at,omitempty"`
+}
+
+type OrderTrackingItem struct {
+	Name     string                 `json:"name"`
+	Quantity float64                `json:"quantity"`
+	Unit     entities.UnitOfMeasure `json:"unit"`
+}
+
+// IsFinal reports whether the order won't change any more
+func (r *OrderTrackingResponse) IsFinal() bool {
+	return r.Stage == StageCompleted || r.Stage == StageCancelled
+}
+
+type OrderTrackingUseCase struct {
+	transactionRepo repositories.TransactionRepository
+	eventBroker     *events.Broker
+	logger          logger.Logger
+}
+
+func NewOrderTrackingUseCase(
+	transactionRepo repositories.TransactionRepository,
+	eventBroker *events.Broker,
+	logger logger.Logger,
+) *OrderTrackingUseCase {
+	return &OrderTrackingUseCase{
+		transactionRepo: transactionRepo,
+		eventBroker:     eventBroker,
+		logger:          logger,
+	}
+}
+
+// UpdateFulfillment moves a paid order forward through the kitchen statuses and pushes the change
+// to its status page
+func (uc *OrderTrackingUseCase) UpdateFulfillment(ctx context.Context, transactionID string, req *UpdateFulfillmentRequest) (*OrderTrackingResponse, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if err := transaction.AdvanceFulfillment(req.Status); err != nil {
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		uc.logger.Error("Failed to update fulfillment status", "error", err, "transaction_id", transactionID)
+		return nil, err
+	}
+
+	detailed, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	result := mapOrderTracking(detailed)
+
+	uc.eventBroker.Publish(events.Event{
+		Type:          events.EventOrderStatus,
+		TransactionID: transactionID,
+		TenantID:      events.TenantOf(detailed.TenantID),
+		Data:          result,
+	})
+	uc.logger.Info("Order fulfillment updated", "transaction_id", transactionID, "status", req.Status)
+
+	return result, nil
+}
+
+// GetOrderStatus returns the public view of the order behind a tracking token
+func (uc *OrderTrackingUseCase) GetOrderStatus(ctx context.Context, token string) (*OrderTrackingResponse, error) {
+	transaction, err := uc.getByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return mapOrderTracking(transaction), nil
+}
+
+// SubscribeOrderStatus notifies of payment and kitchen changes of the order behind a tracking token.
+// The events only signal a change, the current status is read again with GetOrderStatus.
+func (uc *OrderTrackingUseCase) SubscribeOrderStatus(ctx context.Context, token string) (<-chan events.Event, func(), error) {
+	transaction, err := uc.getByToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates, unsubscribe := uc.eventBroker.Subscribe(func(event events.Event) bool {
+		return event.TransactionID == transaction.ID &&
+			(event.Type == events.EventOrderStatus || event.Type == events.EventPaymentStatus)
+	})
+	return updates, unsubscribe, nil
+}
+
+func (uc *OrderTrackingUseCase) getByToken(ctx context.Context, token string) (*entities.Transaction, error) {
+	transaction, err := uc.transactionRepo.GetByTrackingToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// trackingURL is the public status page of an order, empty for orders without tracking
+func trackingURL(baseURL string, transaction *entities.Transaction) string {
+	if transaction.TrackingToken == nil || baseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + *transaction.TrackingToken
+}
+
+func mapOrderTracking(transaction *entities.Transaction) *OrderTrackingResponse {
+	var paidAt *time.Time
+	if transaction.Payment != nil {
+		paidAt = transaction.Payment.PaidAt
+	}
+
+	paid := paidAt != nil ||
+		transaction.Status == entities.StatusPaid ||
+		transaction.Status == entities.StatusPartiallyRefunded
+
+	steps := []OrderTrackingStep{
+		newTrackingStep(StagePaid, paidAt, paid),
+		newTrackingStep(StagePreparing, transaction.PreparingAt, transaction.PreparingAt != nil),
+		newTrackingStep(StageReady, transaction.ReadyAt, transaction.ReadyAt != nil),
+		newTrackingStep(StageCompleted, transaction.CompletedAt, transaction.CompletedAt != nil),
+	}
+
+	result := &OrderTrackingResponse{
+		OrderNumber: orderNumber(transaction),
+		OrderType:   transaction.OrderType,
+		Stage:       orderStage(transaction),
+		Steps:       steps,
+		Items:       make([]OrderTrackingItem, 0, len(transaction.Items)),
+		UpdatedAt:   transaction.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, item := range transaction.Items {
+		result.Items = append(result.Items, OrderTrackingItem{
+			Name:     item.Product.Name,
+			Quantity: item.Quantity,
+			Unit:     item.Unit,
+		})
+	}
+	return result
+}
+
+// orderNumber is the short number called out to the customer and shown in the kitchen
+func orderNumber(transaction *entities.Transaction) string {
+	return strings.ToUpper(transaction.ID[:8])
+}
+
+func orderStage(transaction *entities.Transaction) OrderStage {
+	switch transaction.Status {
+	case entities.StatusPending:
+		return StageAwaitingPayment
+	case entities.StatusCancelled, entities.StatusExpired, entities.StatusRefunded:
+		return StageCancelled
+	}
+
+	switch transaction.FulfillmentStatus {
+	case entities.FulfillmentPreparing:
+		return StagePreparing
+	case entities.FulfillmentReady:
+		return StageReady
+	case entities.FulfillmentCompleted:
+		return StageCompleted
+	}
+	return StagePaid
+}
+
+func newTrackingStep(stage OrderStage, at *time.Time, done bool) OrderTrackingStep {
+	step := OrderTrackingStep{Stage: stage, Done: done}
+	if at != nil {
+		formatted := at.Format(time.RFC3339)
+		step.At = &formatted
+	}
+	return step
+}
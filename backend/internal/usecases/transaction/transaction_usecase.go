@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"qris-pos-backend/internal/domain/entities"
 	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/domain/services"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	pkgAuth "qris-pos-backend/pkg/auth"
 	appErrors "qris-pos-backend/pkg/errors"
 	"qris-pos-backend/pkg/logger"
 
@@ -14,46 +19,131 @@ import (
 )
 
 type CreateTransactionRequest struct {
+	ID     string              `json:"-"` // Set by checkout to the ID the register generated, new transactions get one otherwise
 	UserID string              `json:"user_id" validate:"required,uuid"`
 	Items  []TransactionItemReq `json:"items" validate:"required,min=1"`
 	Notes  string              `json:"notes"`
+	CustomerEmail string       `json:"customer_email" validate:"omitempty,email"` // Sends the digital receipt by email once paid
+	CustomerPhone string       `json:"customer_phone" validate:"omitempty,phone_id"` // Sends the digital receipt by WhatsApp once paid, e.g. +6281234567890 or 0812-3456-7890
+	OrderType     entities.OrderType `json:"order_type" validate:"omitempty,oneof=dine_in pickup delivery"` // Pickup and delivery orders get a public status page
+	CustomerID    *string      `json:"customer_id" validate:"omitempty,uuid"` // Loyalty member who earns points on the sale
+	TableID       *string      `json:"table_id" validate:"omitempty,uuid"`    // Opens a dine-in order on a free table, items are added as the guests order
 }
 
 type TransactionItemReq struct {
-	ProductID string `json:"product_id" validate:"required,uuid"`
-	Quantity  int    `json:"quantity" validate:"required,gte=1"`
+	ProductID string  `json:"product_id" validate:"required,uuid"`
+	Quantity  float64 `json:"quantity" validate:"required,gt=0"` // In the product's unit, whole for products sold in pieces
 }
 
 type AddItemRequest struct {
-	ProductID string `json:"product_id" validate:"required,uuid"`
-	Quantity  int    `json:"quantity" validate:"required,gte=1"`
+	ProductID string  `json:"product_id" validate:"required_without=Barcode,omitempty,uuid"`
+	Barcode   string  `json:"barcode" validate:"required_without=ProductID,omitempty,max=64"` // Scanned barcode or SKU, used when product_id is empty
+	Quantity  float64 `json:"quantity" validate:"required,gt=0"`                              // In the product's unit, whole for products sold in pieces
 }
 
 type UpdateItemRequest struct {
-	Quantity int `json:"quantity" validate:"required,gte=0"`
+	Quantity float64 `json:"quantity" validate:"gte=0"` // 0 removes the item
+}
+
+type ApplyPromoCodeRequest struct {
+	Code string `json:"code" validate:"required,max=50"`
+}
+
+type AttachCustomerRequest struct {
+	CustomerID string `json:"customer_id" validate:"required,uuid"`
+}
+
+type RedeemPointsRequest struct {
+	Points int64 `json:"points" validate:"gte=0"` // 0 removes the redemption
+}
+
+type HoldTransactionRequest struct {
+	Label string `json:"label" validate:"required,max=100"` // Shown in the held carts list, e.g. "Table 5"
+}
+
+type ListHeldTransactionsRequest struct {
+	Limit  int `form:"limit,default=50" validate:"gte=1,lte=200"`
+	Offset int `form:"offset,default=0" validate:"gte=0"`
+}
+
+type ReorderItemsRequest struct {
+	ItemIDs []string `json:"item_ids" validate:"required,min=1,dive,uuid"` // Every item of the transaction, in display order
 }
 
 type TransactionResponse struct {
 	ID          string                    `json:"id"`
+	StoreID     string                    `json:"store_id"`
 	UserID      string                    `json:"user_id"`
-	TotalAmount float64                   `json:"total_amount"`
-	TaxAmount   float64                   `json:"tax_amount"`
-	Discount    float64                   `json:"discount"`
+	ReceiptNo   string                    `json:"receipt_no,omitempty"` // Sequential number printed on the receipt, e.g. INV-20240521-0001
+	Currency    entities.Currency         `json:"currency"`
+	TotalAmount int64                     `json:"total_amount"`
+	TaxAmount   int64                     `json:"tax_amount"`
+	IncludedTax int64                     `json:"included_tax,omitempty"` // Tax already in the prices, not added to the total
+	Discount    int64                     `json:"discount"`
+	ServiceChargeRate float64             `json:"service_charge_rate,omitempty"`
+	ServiceCharge int64                   `json:"service_charge,omitempty"`
+	Rounding    int64                     `json:"rounding,omitempty"` // Added to round the total to the cash increment, negative when rounded down
 	Status      entities.TransactionStatus `json:"status"`
 	Notes       string                    `json:"notes"`
+	CustomerEmail string                  `json:"customer_email,omitempty"`
+	CustomerPhone string                  `json:"customer_phone,omitempty"`
+	ExchangeCredit int64                  `json:"exchange_credit,omitempty"`
+	PromoCode   *string                   `json:"promo_code,omitempty"`
+	CustomerID  *string                   `json:"customer_id,omitempty"`
+	PointsRedeemed int64                  `json:"points_redeemed,omitempty"`
+	PointsDiscount int64                  `json:"points_discount,omitempty"` // Value of the redeemed points, deducted from the total
+	OrderType   entities.OrderType        `json:"order_type"`
+	FulfillmentStatus entities.FulfillmentStatus `json:"fulfillment_status,omitempty"`
+	TrackingURL string                    `json:"tracking_url,omitempty"`
+	ShiftID     *string                   `json:"shift_id,omitempty"`
+	HoldLabel   string                    `json:"hold_label,omitempty"`
+	HeldAt      string                    `json:"held_at,omitempty"` // Set while the cart is parked on hold
+	TableID     *string                   `json:"table_id,omitempty"`
+	TableName   string                    `json:"table_name,omitempty"`
+	ManualDiscount *entities.ManualDiscount `json:"manual_discount,omitempty"` // Cart discount given by hand, part of discount
 	CreatedAt   string                    `json:"created_at"`
 	UpdatedAt   string                    `json:"updated_at"`
 	Items       []TransactionItemResponse `json:"items"`
+	Promotions  []AppliedPromotionInfo    `json:"promotions"`
+	Taxes       []AppliedTaxInfo          `json:"taxes"`
 	User        *UserInfo                 `json:"user,omitempty"`
+	Customer    *CustomerInfo             `json:"customer,omitempty"`
+}
+
+type AppliedPromotionInfo struct {
+	PromotionID    string  `json:"promotion_id"`
+	Name           string  `json:"name"`
+	DiscountAmount int64   `json:"discount_amount"`
+}
+
+type AppliedTaxInfo struct {
+	TaxRuleID string  `json:"tax_rule_id"`
+	Name      string  `json:"name"`
+	Rate      float64 `json:"rate"`
+	Inclusive bool    `json:"inclusive"`
+	Amount    int64   `json:"amount"`
 }
 
 type TransactionItemResponse struct {
-	ID         string      `json:"id"`
-	ProductID  string      `json:"product_id"`
-	Quantity   int         `json:"quantity"`
-	UnitPrice  float64     `json:"unit_price"`
-	TotalPrice float64     `json:"total_price"`
-	Product    *ProductInfo `json:"product,omitempty"`
+	ID              string                   `json:"id"`
+	ProductID       string                   `json:"product_id"`
+	Quantity        float64                  `json:"quantity"`
+	Unit            entities.UnitOfMeasure   `json:"unit"`
+	UnitPrice       int64                    `json:"unit_price"`
+	TotalPrice      int64                    `json:"total_price"`
+	BaseUnitPrice   int64                    `json:"base_unit_price"`             // Unit price before quantity breaks
+	TierMinQuantity int                      `json:"tier_min_quantity,omitempty"` // Quantity break applied, e.g. 10 for "10+"
+	PriceSource     entities.PriceSource     `json:"price_source,omitempty"`
+	ManualDiscount  *entities.ManualDiscount `json:"manual_discount,omitempty"` // Item discount given by hand, part of the transaction discount
+	TaxClass        entities.TaxClass        `json:"tax_class,omitempty"`
+	Sequence        int                      `json:"sequence"`
+	Product         *ProductInfo             `json:"product,omitempty"`
+}
+
+type CustomerInfo struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Points int64  `json:"points"`
 }
 
 type UserInfo struct {
@@ -65,8 +155,8 @@ type UserInfo struct {
 type ProductInfo struct {
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
+	Price       int64   `json:"price"`
+	Stock       float64 `json:"stock"`
 	CategoryName string `json:"category_name,omitempty"`
 }
 
@@ -74,6 +164,20 @@ type TransactionUseCase struct {
 	transactionRepo repositories.TransactionRepository
 	productRepo     repositories.ProductRepository
 	userRepo        repositories.UserRepository
+	promotionRepo   repositories.PromotionRepository
+	taxRuleRepo     repositories.TaxRuleRepository
+	customerRepo    repositories.CustomerRepository
+	shiftRepo       repositories.ShiftRepository
+	storeRepo       repositories.StoreRepository
+	tableRepo       repositories.TableRepository
+	txManager       repositories.TransactionManager
+	promotionEngine *services.PromotionEngine
+	eventBroker     *events.Broker
+	shiftConfig     config.ShiftConfig
+	storeConfig     config.StoreConfig
+	loyaltyConfig   config.LoyaltyConfig
+	discountConfig  config.DiscountConfig
+	passwordService *pkgAuth.PasswordService
 	logger          logger.Logger
 }
 
@@ -81,19 +185,47 @@ func NewTransactionUseCase(
 	transactionRepo repositories.TransactionRepository,
 	productRepo repositories.ProductRepository,
 	userRepo repositories.UserRepository,
+	promotionRepo repositories.PromotionRepository,
+	taxRuleRepo repositories.TaxRuleRepository,
+	customerRepo repositories.CustomerRepository,
+	shiftRepo repositories.ShiftRepository,
+	storeRepo repositories.StoreRepository,
+	tableRepo repositories.TableRepository,
+	txManager repositories.TransactionManager,
+	promotionEngine *services.PromotionEngine,
+	eventBroker *events.Broker,
+	shiftConfig config.ShiftConfig,
+	storeConfig config.StoreConfig,
+	loyaltyConfig config.LoyaltyConfig,
+	discountConfig config.DiscountConfig,
+	passwordService *pkgAuth.PasswordService,
 	logger logger.Logger,
 ) *TransactionUseCase {
 	return &TransactionUseCase{
 		transactionRepo: transactionRepo,
 		productRepo:     productRepo,
 		userRepo:        userRepo,
+		promotionRepo:   promotionRepo,
+		taxRuleRepo:     taxRuleRepo,
+		customerRepo:    customerRepo,
+		shiftRepo:       shiftRepo,
+		storeRepo:       storeRepo,
+		tableRepo:       tableRepo,
+		txManager:       txManager,
+		promotionEngine: promotionEngine,
+		eventBroker:     eventBroker,
+		shiftConfig:     shiftConfig,
+		storeConfig:     storeConfig,
+		loyaltyConfig:   loyaltyConfig,
+		discountConfig:  discountConfig,
+		passwordService: passwordService,
 		logger:          logger,
 	}
 }
 
 func (uc *TransactionUseCase) CreateTransaction(ctx context.Context, req *CreateTransactionRequest) (*TransactionResponse, error) {
 	// Validate user exists
-	_, err := uc.userRepo.GetByID(ctx, req.UserID)
+	user, err := uc.userRepo.GetByID(ctx, req.UserID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, appErrors.ErrUserNotFound
@@ -101,9 +233,61 @@ func (uc *TransactionUseCase) CreateTransaction(ctx context.Context, req *Create
 		return nil, err
 	}
 
+	// The sale is made in the store the request works in, admins without one sell in their own store
+	storeID, ok := repositories.StoreFromContext(ctx)
+	if !ok {
+		if user.StoreID == nil {
+			return nil, appErrors.ErrStoreRequired
+		}
+		storeID = *user.StoreID
+	}
+
+	store, err := uc.storeRepo.GetByID(ctx, storeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrStoreNotFound
+		}
+		return nil, err
+	}
+
+	shiftID, err := uc.openShiftID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CustomerID != nil {
+		if _, err := uc.getCustomer(ctx, *req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.TableID != nil {
+		if req.OrderType != "" && req.OrderType != entities.OrderDineIn {
+			return nil, errors.New("only dine-in orders can be opened on a table")
+		}
+		if err := uc.ensureTableFree(ctx, *req.TableID, storeID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create new transaction
-	transaction := entities.NewTransaction(req.UserID)
+	transaction := entities.NewTransaction(storeID, req.UserID)
+	if req.ID != "" {
+		transaction.ID = req.ID
+	}
+	transaction.ShiftID = shiftID
+	transaction.IsTraining = store.TrainingMode
+	transaction.Currency = store.Currency
+	transaction.ChargeRules = store.ChargeRules
 	transaction.Notes = req.Notes
+	transaction.CustomerEmail = req.CustomerEmail
+	transaction.CustomerPhone = entities.NormalizeCustomerPhone(req.CustomerPhone)
+	transaction.CustomerID = req.CustomerID
+	transaction.TableID = req.TableID
+	if req.OrderType != "" {
+		transaction.OrderType = req.OrderType
+	}
+	transaction.EnableTracking()
 
 	// Add items and calculate total
 	for _, itemReq := range req.Items {
@@ -120,9 +304,39 @@ func (uc *TransactionUseCase) CreateTransaction(ctx context.Context, req *Create
 		}
 	}
 
-	// Save transaction
-	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
-		uc.logger.Error("Failed to create transaction", "error", err, "user_id", req.UserID)
+	// Apply automatic promotions
+	promoResult, err := uc.evaluatePromotions(ctx, transaction.Items, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := transaction.ApplyDiscount(promoResult.TotalDiscount); err != nil {
+		return nil, err
+	}
+
+	// Charge tax on the discounted items, the taxes are saved with the transaction
+	taxes, err := uc.applyTax(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+	transaction.Taxes = taxes
+
+	// The transaction, its items and promotions and the stock it reserves are saved all together or not at all
+	err = uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Reserve stock so items in an open cart can't be sold twice, practice sales leave the stock alone
+		if !transaction.IsTraining {
+			if err := uc.reserveItemsStock(ctx, transaction.Items); err != nil {
+				return err
+			}
+			transaction.StockReserved = true
+		}
+
+		if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+			uc.logger.Error("Failed to create transaction", "error", err, "user_id", req.UserID)
+			return err
+		}
+		return uc.promotionRepo.ReplaceTransactionPromotions(ctx, transaction.ID, mapAppliedPromotions(promoResult))
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -134,7 +348,20 @@ func (uc *TransactionUseCase) CreateTransaction(ctx context.Context, req *Create
 		return nil, err
 	}
 
-	return uc.mapTransactionToResponse(fullTransaction), nil
+	result := uc.mapTransactionToResponse(fullTransaction)
+	uc.eventBroker.Publish(events.Event{
+		Type:          events.EventTransactionCreated,
+		TransactionID: transaction.ID,
+		StoreID:       transaction.StoreID,
+		TenantID:      events.TenantOf(transaction.TenantID),
+		Data:          result,
+	})
+	publishKitchenOrder(uc.eventBroker, events.EventKitchenOrderNew, fullTransaction)
+	if transaction.StockReserved {
+		uc.publishStockChanged(ctx, transaction.Items)
+	}
+
+	return result, nil
 }
 
 func (uc *TransactionUseCase) GetTransaction(ctx context.Context, id string) (*TransactionResponse, error) {
@@ -165,41 +392,137 @@ func (uc *TransactionUseCase) AddItemToTransaction(ctx context.Context, transact
 		return nil, errors.New("cannot modify non-pending transaction")
 	}
 
-	// Get product
-	product, err := uc.productRepo.GetByID(ctx, req.ProductID)
+	// Get product, by scanned barcode when no ID is given
+	var product *entities.Product
+	if req.ProductID != "" {
+		product, err = uc.productRepo.GetByID(ctx, req.ProductID)
+	} else {
+		product, err = uc.productRepo.GetByBarcode(ctx, req.Barcode)
+	}
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, appErrors.ErrProductNotFound
 		}
 		return nil, err
 	}
+	if product.StoreID != transaction.StoreID {
+		return nil, appErrors.ErrProductNotFound
+	}
+	if err := product.ValidateQuantity(req.Quantity); err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
 
 	// Create transaction item
 	item := &entities.TransactionItem{
-		TransactionID: transactionID,
-		ProductID:     req.ProductID,
-		Quantity:      req.Quantity,
-		UnitPrice:     product.Price,
-		TotalPrice:    product.Price * float64(req.Quantity),
-		Product:       *product,
+		TransactionID:   transactionID,
+		ProductID:       product.ID,
+		Quantity:        req.Quantity,
+		Unit:            product.Unit,
+		UnitPrice:       product.Price,
+		BaseUnitPrice:   product.Price,
+		TotalPrice:      entities.LinePrice(product.Price, req.Quantity),
+		UnitCost:        product.CostPrice,
+		TaxClass:        product.TaxClass,
+		PriceSource:     product.PriceSource(),
+		PriceScheduleID: product.PriceScheduleID,
+		PrepStatus:      entities.ItemQueued,
+		Product:         *product,
 	}
 
-	// Add item to transaction
-	if err := uc.transactionRepo.AddItem(ctx, item); err != nil {
+	err = uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Reserve stock for the added quantity
+		if transaction.StockReserved {
+			if err := uc.productRepo.ReserveStock(ctx, product.ID, req.Quantity); err != nil {
+				return err
+			}
+		}
+
+		if err := uc.transactionRepo.AddItem(ctx, item); err != nil {
+			return err
+		}
+
+		// Recalculate transaction total
+		return uc.recalculateTransaction(ctx, transactionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.StockReserved {
+		uc.publishStockChanged(ctx, []entities.TransactionItem{*item})
+	}
+
+	// Return updated transaction, the kitchen sees the new round of items
+	return uc.publishKitchenUpdate(ctx, transactionID)
+}
+
+// ApplyPromoCode attaches a promo code to a pending transaction and recalculates its total. The code's
+// promotion then competes with the automatic ones under the usual stacking rules.
+func (uc *TransactionUseCase) ApplyPromoCode(ctx context.Context, transactionID string, req *ApplyPromoCodeRequest) (*TransactionResponse, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.Status != entities.StatusPending {
+		return nil, errors.New("cannot modify non-pending transaction")
+	}
+
+	code := entities.NormalizePromoCode(req.Code)
+	promotion, err := uc.promotionRepo.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrPromoCodeInvalid
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !promotion.IsActiveAt(now) {
+		return nil, appErrors.ErrPromoCodeInvalid
+	}
+
+	if promotion.UsageLimit > 0 {
+		used, err := uc.promotionRepo.CountCodeRedemptions(ctx, code, transactionID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promotion.UsageLimit) {
+			return nil, appErrors.ErrPromoCodeUsedUp
+		}
+	}
+
+	items, err := uc.transactionRepo.GetItems(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	result := uc.promotionEngine.Evaluate([]entities.Promotion{*promotion}, items, now)
+	if result.TotalDiscount <= 0 {
+		if result.Subtotal < promotion.MinBasket {
+			return nil, fmt.Errorf("%w: requires a minimum purchase of %d", appErrors.ErrPromoCodeNotApplicable, promotion.MinBasket)
+		}
+		return nil, appErrors.ErrPromoCodeNotApplicable
+	}
+
+	transaction.PromoCode = &code
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		uc.logger.Error("Failed to apply promo code", "error", err, "transaction_id", transactionID)
 		return nil, err
 	}
 
-	// Recalculate transaction total
 	if err := uc.recalculateTransaction(ctx, transactionID); err != nil {
 		return nil, err
 	}
 
-	// Return updated transaction
+	uc.logger.Info("Promo code applied", "transaction_id", transactionID, "code", code, "promotion_id", promotion.ID)
 	return uc.GetTransaction(ctx, transactionID)
 }
 
-func (uc *TransactionUseCase) RemoveItemFromTransaction(ctx context.Context, transactionID, productID string) (*TransactionResponse, error) {
-	// Check transaction exists and is pending
+// RemovePromoCode takes the promo code off a pending transaction and recalculates its total
+func (uc *TransactionUseCase) RemovePromoCode(ctx context.Context, transactionID string) (*TransactionResponse, error) {
 	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -212,12 +535,11 @@ func (uc *TransactionUseCase) RemoveItemFromTransaction(ctx context.Context, tra
 		return nil, errors.New("cannot modify non-pending transaction")
 	}
 
-	// Remove item
-	if err := uc.transactionRepo.RemoveItem(ctx, transactionID, productID); err != nil {
+	transaction.PromoCode = nil
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
 		return nil, err
 	}
 
-	// Recalculate transaction total
 	if err := uc.recalculateTransaction(ctx, transactionID); err != nil {
 		return nil, err
 	}
@@ -225,6 +547,196 @@ func (uc *TransactionUseCase) RemoveItemFromTransaction(ctx context.Context, tra
 	return uc.GetTransaction(ctx, transactionID)
 }
 
+// AttachCustomer links a loyalty member to a pending transaction so it earns them points once paid.
+// Points redeemed for a previous customer are given back.
+func (uc *TransactionUseCase) AttachCustomer(ctx context.Context, transactionID string, req *AttachCustomerRequest) (*TransactionResponse, error) {
+	transaction, err := uc.getPendingTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.getCustomer(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+
+	if transaction.CustomerID == nil || *transaction.CustomerID != req.CustomerID {
+		transaction.CustomerID = &req.CustomerID
+		if err := uc.setRedeemedPoints(ctx, transaction, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	uc.logger.Info("Customer attached to transaction", "transaction_id", transactionID, "customer_id", req.CustomerID)
+	return uc.GetTransaction(ctx, transactionID)
+}
+
+// DetachCustomer unlinks the loyalty member of a pending transaction and gives back redeemed points
+func (uc *TransactionUseCase) DetachCustomer(ctx context.Context, transactionID string) (*TransactionResponse, error) {
+	transaction, err := uc.getPendingTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.CustomerID = nil
+	if err := uc.setRedeemedPoints(ctx, transaction, 0); err != nil {
+		return nil, err
+	}
+
+	return uc.GetTransaction(ctx, transactionID)
+}
+
+// RedeemPoints spends points of the attached customer as a discount. The points are held until the
+// transaction is paid and come back if it is cancelled or expires.
+func (uc *TransactionUseCase) RedeemPoints(ctx context.Context, transactionID string, req *RedeemPointsRequest) (*TransactionResponse, error) {
+	transaction, err := uc.getPendingTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A practice sale must not hold a real member's points
+	if req.Points > 0 && transaction.IsTraining {
+		return nil, appErrors.ErrTrainingPoints
+	}
+
+	if req.Points > 0 && transaction.CustomerID != nil {
+		customer, err := uc.getCustomer(ctx, *transaction.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		held, err := uc.customerRepo.HeldPoints(ctx, customer.ID, transactionID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Points > customer.Points-held {
+			return nil, fmt.Errorf("%w: %d points available", appErrors.ErrInsufficientPoints, max(customer.Points-held, 0))
+		}
+	}
+
+	if err := uc.setRedeemedPoints(ctx, transaction, req.Points); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Points redeemed", "transaction_id", transactionID, "points", req.Points, "discount", transaction.PointsDiscount)
+	return uc.GetTransaction(ctx, transactionID)
+}
+
+// setRedeemedPoints applies a redemption against the transaction's items and saves the new total
+func (uc *TransactionUseCase) setRedeemedPoints(ctx context.Context, transaction *entities.Transaction, points int64) error {
+	items, err := uc.transactionRepo.GetItems(ctx, transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	transaction.Items = items
+	if err := transaction.RedeemPoints(points, uc.loyaltyConfig.PointValue); err != nil {
+		return err
+	}
+	// Items are stored on their own, keep the save below to the transaction row
+	transaction.Items = nil
+
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		uc.logger.Error("Failed to update redeemed points", "error", err, "transaction_id", transaction.ID)
+		return err
+	}
+	return nil
+}
+
+func (uc *TransactionUseCase) getPendingTransaction(ctx context.Context, transactionID string) (*entities.Transaction, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.Status != entities.StatusPending {
+		return nil, errors.New("cannot modify non-pending transaction")
+	}
+	return transaction, nil
+}
+
+// ensureTableFree checks a dine-in order can be opened on the table, a table holds one open order at a time
+func (uc *TransactionUseCase) ensureTableFree(ctx context.Context, tableID, storeID string) error {
+	table, err := uc.tableRepo.GetByID(ctx, tableID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.ErrTableNotFound
+		}
+		return err
+	}
+	if table.StoreID != storeID {
+		return appErrors.ErrTableNotFound
+	}
+	if !table.IsActive {
+		return appErrors.ErrTableInactive
+	}
+
+	if _, err := uc.tableRepo.GetOpenOrder(ctx, tableID); err == nil {
+		return appErrors.ErrTableOccupied
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (uc *TransactionUseCase) getCustomer(ctx context.Context, customerID string) (*entities.Customer, error) {
+	customer, err := uc.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrCustomerNotFound
+		}
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (uc *TransactionUseCase) RemoveItemFromTransaction(ctx context.Context, transactionID, productID string) (*TransactionResponse, error) {
+	// Check transaction exists and is pending
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.Status != entities.StatusPending {
+		return nil, errors.New("cannot modify non-pending transaction")
+	}
+
+	existingItem, err := uc.findItem(ctx, transactionID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseStock := transaction.StockReserved && existingItem != nil
+	err = uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.transactionRepo.RemoveItem(ctx, transactionID, productID); err != nil {
+			return err
+		}
+
+		// Return the removed quantity to stock
+		if releaseStock {
+			if err := uc.productRepo.UpdateStock(ctx, existingItem.ProductID, existingItem.Quantity); err != nil {
+				return err
+			}
+		}
+
+		// Recalculate transaction total
+		return uc.recalculateTransaction(ctx, transactionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if releaseStock {
+		uc.publishStockChanged(ctx, []entities.TransactionItem{*existingItem})
+	}
+
+	return uc.publishKitchenUpdate(ctx, transactionID)
+}
+
 func (uc *TransactionUseCase) UpdateItemQuantity(ctx context.Context, transactionID, productID string, req *UpdateItemRequest) (*TransactionResponse, error) {
 	// Check transaction exists and is pending
 	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
@@ -239,13 +751,89 @@ func (uc *TransactionUseCase) UpdateItemQuantity(ctx context.Context, transactio
 		return nil, errors.New("cannot modify non-pending transaction")
 	}
 
-	// Update item quantity
-	if err := uc.transactionRepo.UpdateItemQuantity(ctx, transactionID, productID, req.Quantity); err != nil {
+	existingItem, err := uc.findItem(ctx, transactionID, productID)
+	if err != nil {
 		return nil, err
 	}
+	if existingItem != nil && req.Quantity > 0 {
+		if err := existingItem.Unit.ValidateQuantity(req.Quantity); err != nil {
+			return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+		}
+	}
 
-	// Recalculate transaction total
-	if err := uc.recalculateTransaction(ctx, transactionID); err != nil {
+	// Adjust reserved stock by the quantity difference
+	var delta float64
+	if transaction.StockReserved && existingItem != nil {
+		delta = entities.RoundQuantity(req.Quantity - existingItem.Quantity)
+	}
+
+	err = uc.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if delta > 0 {
+			if err := uc.productRepo.ReserveStock(ctx, productID, delta); err != nil {
+				return err
+			}
+		}
+
+		if err := uc.transactionRepo.UpdateItemQuantity(ctx, transactionID, productID, req.Quantity); err != nil {
+			return err
+		}
+
+		if delta < 0 {
+			if err := uc.productRepo.UpdateStock(ctx, productID, -delta); err != nil {
+				return err
+			}
+		}
+
+		// Recalculate transaction total
+		return uc.recalculateTransaction(ctx, transactionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if delta != 0 {
+		uc.publishStockChanged(ctx, []entities.TransactionItem{{ProductID: productID}})
+	}
+
+	return uc.publishKitchenUpdate(ctx, transactionID)
+}
+
+// ReorderItems changes the display order of the transaction items
+func (uc *TransactionUseCase) ReorderItems(ctx context.Context, transactionID string, req *ReorderItemsRequest) (*TransactionResponse, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if transaction.Status != entities.StatusPending {
+		return nil, errors.New("cannot modify non-pending transaction")
+	}
+
+	items, err := uc.transactionRepo.GetItems(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new order must list every item exactly once
+	if len(req.ItemIDs) != len(items) {
+		return nil, errors.New("item_ids must contain every item of the transaction")
+	}
+	existing := make(map[string]bool, len(items))
+	for _, item := range items {
+		existing[item.ID] = true
+	}
+	for _, itemID := range req.ItemIDs {
+		if !existing[itemID] {
+			return nil, fmt.Errorf("item %s is not part of this transaction or is listed twice", itemID)
+		}
+		existing[itemID] = false
+	}
+
+	if err := uc.transactionRepo.ReorderItems(ctx, transactionID, req.ItemIDs); err != nil {
+		uc.logger.Error("Failed to reorder transaction items", "error", err, "transaction_id", transactionID)
 		return nil, err
 	}
 
@@ -269,10 +857,72 @@ func (uc *TransactionUseCase) CancelTransaction(ctx context.Context, id string)
 		return err
 	}
 
+	if err := uc.transactionRepo.ReleaseReservedStock(ctx, id); err != nil {
+		uc.logger.Error("Failed to release reserved stock", "error", err, "transaction_id", id)
+	} else if items, err := uc.transactionRepo.GetItems(ctx, id); err == nil {
+		uc.publishStockChanged(ctx, items)
+	}
+
+	// Let the kitchen drop the order
+	if detailed, err := uc.transactionRepo.GetByIDWithDetails(ctx, id); err == nil {
+		publishKitchenOrder(uc.eventBroker, events.EventKitchenOrderUpdated, detailed)
+	}
+
 	uc.logger.Info("Transaction cancelled", "transaction_id", id)
 	return nil
 }
 
+// HoldTransaction parks a pending cart under a label. Its stock stays reserved and it is kept out
+// of the expiry job until it is resumed or cancelled.
+func (uc *TransactionUseCase) HoldTransaction(ctx context.Context, id string, req *HoldTransactionRequest) (*TransactionResponse, error) {
+	transaction, err := uc.getPendingTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transaction.Hold(req.Label); err != nil {
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		uc.logger.Error("Failed to hold transaction", "error", err, "transaction_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Transaction held", "transaction_id", id, "label", req.Label)
+	return uc.GetTransaction(ctx, id)
+}
+
+// ResumeTransaction takes a parked cart back to the register for checkout
+func (uc *TransactionUseCase) ResumeTransaction(ctx context.Context, id string) (*TransactionResponse, error) {
+	transaction, err := uc.getPendingTransaction(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transaction.Resume(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.Update(ctx, transaction); err != nil {
+		uc.logger.Error("Failed to resume transaction", "error", err, "transaction_id", id)
+		return nil, err
+	}
+
+	uc.logger.Info("Transaction resumed", "transaction_id", id)
+	return uc.GetTransaction(ctx, id)
+}
+
+// ListHeldTransactions lists the carts parked in the store, whoever held them, so any register can resume them
+func (uc *TransactionUseCase) ListHeldTransactions(ctx context.Context, req *ListHeldTransactionsRequest) ([]TransactionResponse, error) {
+	return uc.ListTransactions(ctx, repositories.TransactionFilters{
+		Status: entities.StatusPending,
+		Held:   true,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	})
+}
+
 func (uc *TransactionUseCase) ListTransactions(ctx context.Context, filters repositories.TransactionFilters) ([]TransactionResponse, error) {
 	transactions, err := uc.transactionRepo.List(ctx, filters)
 	if err != nil {
@@ -287,6 +937,77 @@ func (uc *TransactionUseCase) ListTransactions(ctx context.Context, filters repo
 	return responses, nil
 }
 
+// reserveItemsStock reserves stock for every item. Run it inside a database transaction so the
+// reservations made before a failure are rolled back with it.
+func (uc *TransactionUseCase) reserveItemsStock(ctx context.Context, items []entities.TransactionItem) error {
+	for _, item := range items {
+		if err := uc.productRepo.ReserveStock(ctx, item.ProductID, item.Quantity); err != nil {
+			if errors.Is(err, appErrors.ErrInsufficientStock) {
+				return fmt.Errorf("insufficient stock for product %s", item.Product.Name)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// openShiftID returns the shift the user's sales attach to, nil when shifts are optional and none is open
+func (uc *TransactionUseCase) openShiftID(ctx context.Context, userID string) (*string, error) {
+	shift, err := uc.shiftRepo.GetOpenByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if uc.shiftConfig.Required {
+				return nil, appErrors.ErrNoOpenShift
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shift.ID, nil
+}
+
+// publishStockChanged notifies live terminals of the current stock of the given items' products
+// publishKitchenUpdate returns the transaction after its items changed and pushes it to the kitchen
+func (uc *TransactionUseCase) publishKitchenUpdate(ctx context.Context, transactionID string) (*TransactionResponse, error) {
+	transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	publishKitchenOrder(uc.eventBroker, events.EventKitchenOrderUpdated, transaction)
+	return uc.mapTransactionToResponse(transaction), nil
+}
+
+func (uc *TransactionUseCase) publishStockChanged(ctx context.Context, items []entities.TransactionItem) {
+	for _, item := range items {
+		product, err := uc.productRepo.GetByID(ctx, item.ProductID)
+		if err != nil {
+			continue
+		}
+		uc.eventBroker.Publish(events.Event{
+			Type:     events.EventStockChanged,
+			TenantID: events.TenantOf(product.TenantID),
+			Data:     &events.StockChange{ProductID: product.ID, Stock: product.Stock},
+		})
+	}
+}
+
+func (uc *TransactionUseCase) findItem(ctx context.Context, transactionID, productID string) (*entities.TransactionItem, error) {
+	items, err := uc.transactionRepo.GetItems(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i].ProductID == productID {
+			return &items[i], nil
+		}
+	}
+	return nil, nil
+}
+
 func (uc *TransactionUseCase) recalculateTransaction(ctx context.Context, transactionID string) error {
 	// Get all items
 	items, err := uc.transactionRepo.GetItems(ctx, transactionID)
@@ -294,35 +1015,199 @@ func (uc *TransactionUseCase) recalculateTransaction(ctx context.Context, transa
 		return err
 	}
 
-	// Calculate total
-	var total float64
-	for _, item := range items {
-		total += item.TotalPrice
-	}
-
 	// Get transaction and update total
 	transaction, err := uc.transactionRepo.GetByID(ctx, transactionID)
 	if err != nil {
 		return err
 	}
 
-	transaction.TotalAmount = total - transaction.Discount + transaction.TaxAmount
+	if err := uc.applyPriceTiers(ctx, items); err != nil {
+		return err
+	}
+
+	// Re-evaluate promotions, with the entered promo code, against the current items
+	promoResult, err := uc.evaluatePromotions(ctx, items, transaction.PromoCode)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.promotionRepo.ReplaceTransactionPromotions(ctx, transactionID, mapAppliedPromotions(promoResult)); err != nil {
+		return err
+	}
+
+	transaction.Items = items
+	if changed := transaction.ApplyManualDiscounts(promoResult.TotalDiscount); len(changed) > 0 {
+		discounted := make([]entities.TransactionItem, 0, len(changed))
+		for _, i := range changed {
+			discounted = append(discounted, items[i])
+		}
+		if err := uc.transactionRepo.UpdateItemPrices(ctx, discounted); err != nil {
+			return err
+		}
+	}
+	taxes, err := uc.applyTax(ctx, transaction)
+	if err != nil {
+		return err
+	}
+	// Redeemed points that are now worth more than the total are given back
+	if err := transaction.RedeemPoints(transaction.PointsRedeemed, uc.loyaltyConfig.PointValue); err != nil {
+		uc.logger.Info("Redeemed points no longer fit the transaction, removing them", "transaction_id", transactionID, "points", transaction.PointsRedeemed)
+		if err := transaction.RedeemPoints(0, uc.loyaltyConfig.PointValue); err != nil {
+			return err
+		}
+	}
+	// Items are stored on their own, keep the save below to the transaction row
+	transaction.Items = nil
+
+	if err := uc.taxRuleRepo.ReplaceTransactionTaxes(ctx, transactionID, taxes); err != nil {
+		return err
+	}
 
 	return uc.transactionRepo.Update(ctx, transaction)
 }
 
+// applyPriceTiers reprices the items at the quantity breaks their products reach with the current
+// quantities, and saves the items whose price changed
+func (uc *TransactionUseCase) applyPriceTiers(ctx context.Context, items []entities.TransactionItem) error {
+	productIDs := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return err
+	}
+
+	var changed []entities.TransactionItem
+	for _, product := range products {
+		for _, i := range entities.ApplyPriceTiers(items, product.ID, product.PriceTiers) {
+			changed = append(changed, items[i])
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return uc.transactionRepo.UpdateItemPrices(ctx, changed)
+}
+
+// applyTax charges the active tax rules on a transaction with its items loaded
+func (uc *TransactionUseCase) applyTax(ctx context.Context, transaction *entities.Transaction) ([]entities.TransactionTax, error) {
+	rules, err := uc.taxRuleRepo.ListActive(ctx)
+	if err != nil {
+		uc.logger.Error("Failed to load tax rules", "error", err)
+		return nil, err
+	}
+	return transaction.ApplyTax(rules)
+}
+
+// evaluatePromotions runs the automatic promotions and, when one was entered, the promotion of the promo code.
+// A code whose promotion was disabled or deleted since it was entered simply stops applying.
+func (uc *TransactionUseCase) evaluatePromotions(ctx context.Context, items []entities.TransactionItem, promoCode *string) (*services.PromotionResult, error) {
+	promotions, err := uc.promotionRepo.ListActive(ctx)
+	if err != nil {
+		uc.logger.Error("Failed to load active promotions", "error", err)
+		return nil, err
+	}
+
+	if promoCode != nil {
+		promotion, err := uc.promotionRepo.GetByCode(ctx, *promoCode)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if promotion != nil {
+			promotions = append(promotions, *promotion)
+		}
+	}
+
+	return uc.promotionEngine.Evaluate(promotions, items, time.Now()), nil
+}
+
+func mapAppliedPromotions(result *services.PromotionResult) []entities.TransactionPromotion {
+	applied := make([]entities.TransactionPromotion, 0, len(result.Applied))
+	for _, promo := range result.Applied {
+		applied = append(applied, entities.TransactionPromotion{
+			PromotionID:    promo.PromotionID,
+			Name:           promo.Name,
+			DiscountAmount: promo.Discount,
+		})
+	}
+	return applied
+}
+
 func (uc *TransactionUseCase) mapTransactionToResponse(transaction *entities.Transaction) *TransactionResponse {
 	response := &TransactionResponse{
 		ID:          transaction.ID,
+		StoreID:     transaction.StoreID,
 		UserID:      transaction.UserID,
+		ReceiptNo:   transaction.ReceiptNo,
+		Currency:    transaction.Currency,
 		TotalAmount: transaction.TotalAmount,
 		TaxAmount:   transaction.TaxAmount,
+		IncludedTax: transaction.IncludedTax,
 		Discount:    transaction.Discount,
+		ServiceChargeRate: transaction.ServiceChargeRate,
+		ServiceCharge: transaction.ServiceCharge,
+		Rounding:    transaction.Rounding,
 		Status:      transaction.Status,
 		Notes:       transaction.Notes,
+		CustomerEmail: transaction.CustomerEmail,
+		CustomerPhone: transaction.CustomerPhone,
+		ExchangeCredit: transaction.ExchangeCredit,
+		PromoCode:   transaction.PromoCode,
+		CustomerID:  transaction.CustomerID,
+		PointsRedeemed: transaction.PointsRedeemed,
+		PointsDiscount: transaction.PointsDiscount,
+		OrderType:   transaction.OrderType,
+		FulfillmentStatus: transaction.FulfillmentStatus,
+		TrackingURL: trackingURL(uc.storeConfig.OrderTrackingURL, transaction),
+		ShiftID:     transaction.ShiftID,
+		HoldLabel:   transaction.HoldLabel,
 		CreatedAt:   transaction.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:   transaction.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		Items:       []TransactionItemResponse{},
+		Promotions:  []AppliedPromotionInfo{},
+		Taxes:       []AppliedTaxInfo{},
+	}
+
+	if transaction.HeldAt != nil {
+		response.HeldAt = transaction.HeldAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	if transaction.ManualDiscount.IsSet() {
+		response.ManualDiscount = &transaction.ManualDiscount
+	}
+
+	response.TableID = transaction.TableID
+	if transaction.Table != nil {
+		response.TableName = transaction.Table.Name
+	}
+
+	// Map applied promotions
+	for _, promo := range transaction.Promotions {
+		response.Promotions = append(response.Promotions, AppliedPromotionInfo{
+			PromotionID:    promo.PromotionID,
+			Name:           promo.Name,
+			DiscountAmount: promo.DiscountAmount,
+		})
+	}
+
+	// Map charged taxes
+	for _, tax := range transaction.Taxes {
+		response.Taxes = append(response.Taxes, AppliedTaxInfo{
+			TaxRuleID: tax.TaxRuleID,
+			Name:      tax.Name,
+			Rate:      tax.Rate,
+			Inclusive: tax.Inclusive,
+			Amount:    tax.Amount,
+		})
 	}
 
 	// Map user info
@@ -334,14 +1219,32 @@ func (uc *TransactionUseCase) mapTransactionToResponse(transaction *entities.Tra
 		}
 	}
 
+	// Map customer info
+	if transaction.Customer != nil {
+		response.Customer = &CustomerInfo{
+			ID:     transaction.Customer.ID,
+			Name:   transaction.Customer.Name,
+			Points: transaction.Customer.Points,
+		}
+	}
+
 	// Map items
 	for _, item := range transaction.Items {
 		itemResponse := TransactionItemResponse{
-			ID:         item.ID,
-			ProductID:  item.ProductID,
-			Quantity:   item.Quantity,
-			UnitPrice:  item.UnitPrice,
-			TotalPrice: item.TotalPrice,
+			ID:              item.ID,
+			ProductID:       item.ProductID,
+			Quantity:        item.Quantity,
+			Unit:            item.Unit,
+			UnitPrice:       item.UnitPrice,
+			TotalPrice:      item.TotalPrice,
+			BaseUnitPrice:   item.BaseUnitPrice,
+			TierMinQuantity: item.TierMinQuantity,
+			PriceSource:     item.PriceSource,
+			TaxClass:        item.TaxClass,
+			Sequence:        item.Sequence,
+		}
+		if item.ManualDiscount.IsSet() {
+			itemResponse.ManualDiscount = &item.ManualDiscount
 		}
 
 		// Map product info
@@ -0,0 +1,536 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"qris-pos-backend/internal/domain/entities"
+	"qris-pos-backend/internal/domain/repositories"
+	"qris-pos-backend/internal/infrastructure/config"
+	"qris-pos-backend/internal/infrastructure/events"
+	"qris-pos-backend/internal/infrastructure/webhook"
+	appErrors "qris-pos-backend/pkg/errors"
+	"qris-pos-backend/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// batchSize caps how many deliveries a single run posts
+const batchSize = 50
+
+// eventBuffer lets the dispatcher fall behind a burst of sales without the broker dropping events
+const eventBuffer = 1024
+
+type EndpointRequest struct {
+	URL         string                  `json:"url" validate:"required,url,max=500"`
+	Description string                  `json:"description" validate:"max=255"`
+	Events      []entities.WebhookEvent `json:"events" validate:"required,min=1,dive,oneof=payment.settled transaction.created stock.low"`
+	IsActive    *bool                   `json:"is_active"` // Defaults to true, inactive endpoints get no new deliveries
+}
+
+type DeliveryFilters struct {
+	Status entities.WebhookDeliveryStatus `form:"status" validate:"omitempty,oneof=pending sent failed"`
+	Limit  int                            `form:"limit,default=50" validate:"gte=1,lte=200"`
+	Offset int                            `form:"offset,default=0" validate:"gte=0"`
+}
+
+type EndpointResponse struct {
+	ID          string                  `json:"id"`
+	StoreID     *string                 `json:"store_id,omitempty"`
+	URL         string                  `json:"url"`
+	Description string                  `json:"description"`
+	Events      []entities.WebhookEvent `json:"events"`
+	IsActive    bool                    `json:"is_active"`
+	Secret      string                  `json:"secret,omitempty"` // Only returned when created or rotated, keep it to verify signatures
+	CreatedBy   string                  `json:"created_by"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// Envelope is the JSON body posted for every event
+type Envelope struct {
+	ID        string                `json:"id"` // Same for every endpoint receiving the event
+	Type      entities.WebhookEvent `json:"type"`
+	StoreID   string                `json:"store_id,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	Data      any                   `json:"data"`
+}
+
+type TransactionData struct {
+	ID          string                     `json:"id"`
+	UserID      string                     `json:"user_id"`
+	Status      entities.TransactionStatus `json:"status"`
+	OrderType   entities.OrderType         `json:"order_type"`
+	Discount    int64                      `json:"discount"`
+	TaxAmount   int64                      `json:"tax_amount"`
+	TotalAmount int64                      `json:"total_amount"`
+	Currency    entities.Currency          `json:"currency"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	Items       []TransactionItemData      `json:"items"`
+}
+
+type TransactionItemData struct {
+	ProductID  string                 `json:"product_id"`
+	SKU        string                 `json:"sku"`
+	Name       string                 `json:"name"`
+	Quantity   float64                `json:"quantity"`
+	Unit       entities.UnitOfMeasure `json:"unit"`
+	UnitPrice  int64                  `json:"unit_price"`
+	TotalPrice int64                  `json:"total_price"`
+}
+
+type PaymentData struct {
+	PaymentID     string                   `json:"payment_id"`
+	TransactionID string                   `json:"transaction_id"`
+	Method        entities.PaymentMethod   `json:"method"`
+	Provider      entities.PaymentProvider `json:"provider,omitempty"`
+	Amount        int64                    `json:"amount"`
+	Reference     string                   `json:"reference,omitempty"`
+	PaidAt        *time.Time               `json:"paid_at"`
+	Transaction   TransactionData          `json:"transaction"`
+}
+
+type StockData struct {
+	ProductID string                 `json:"product_id"`
+	SKU       string                 `json:"sku"`
+	Name      string                 `json:"name"`
+	Stock     float64                `json:"stock"`
+	Unit      entities.UnitOfMeasure `json:"unit"`
+	Threshold int                    `json:"threshold"`
+}
+
+type WebhookUseCase struct {
+	webhookRepo     repositories.WebhookRepository
+	transactionRepo repositories.TransactionRepository
+	productRepo     repositories.ProductRepository
+	client          *webhook.Client
+	eventBroker     *events.Broker
+	config          config.WebhookConfig
+	logger          logger.Logger
+}
+
+func NewWebhookUseCase(
+	webhookRepo repositories.WebhookRepository,
+	transactionRepo repositories.TransactionRepository,
+	productRepo repositories.ProductRepository,
+	client *webhook.Client,
+	eventBroker *events.Broker,
+	cfg config.WebhookConfig,
+	logger logger.Logger,
+) *WebhookUseCase {
+	return &WebhookUseCase{
+		webhookRepo:     webhookRepo,
+		transactionRepo: transactionRepo,
+		productRepo:     productRepo,
+		client:          client,
+		eventBroker:     eventBroker,
+		config:          cfg,
+		logger:          logger,
+	}
+}
+
+// CreateEndpoint registers a URL for the events of the store the admin works in, or of every store
+// when none is picked. The signing secret is only shown in this response.
+func (uc *WebhookUseCase) CreateEndpoint(ctx context.Context, userID string, req *EndpointRequest) (*EndpointResponse, error) {
+	if err := uc.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &entities.WebhookEndpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		Secret:      secret,
+		IsActive:    true,
+		CreatedBy:   userID,
+	}
+	if storeID, ok := repositories.StoreFromContext(ctx); ok {
+		endpoint.StoreID = &storeID
+	}
+	endpoint.SetEvents(req.Events)
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := uc.webhookRepo.CreateEndpoint(ctx, endpoint); err != nil {
+		uc.logger.Error("Failed to create webhook endpoint", "error", err)
+		return nil, err
+	}
+
+	uc.logger.Info("Webhook endpoint created", "endpoint_id", endpoint.ID, "events", endpoint.Events)
+	response := mapEndpointToResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return response, nil
+}
+
+func (uc *WebhookUseCase) ListEndpoints(ctx context.Context) ([]EndpointResponse, error) {
+	endpoints, err := uc.webhookRepo.ListEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]EndpointResponse, len(endpoints))
+	for i := range endpoints {
+		responses[i] = *mapEndpointToResponse(&endpoints[i])
+	}
+	return responses, nil
+}
+
+func (uc *WebhookUseCase) GetEndpoint(ctx context.Context, id string) (*EndpointResponse, error) {
+	endpoint, err := uc.getEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mapEndpointToResponse(endpoint), nil
+}
+
+// UpdateEndpoint changes the URL, events or state of an endpoint, deliveries already queued keep going out
+func (uc *WebhookUseCase) UpdateEndpoint(ctx context.Context, id string, req *EndpointRequest) (*EndpointResponse, error) {
+	endpoint, err := uc.getEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.validateURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	endpoint.URL = req.URL
+	endpoint.Description = req.Description
+	endpoint.SetEvents(req.Events)
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := uc.webhookRepo.UpdateEndpoint(ctx, endpoint); err != nil {
+		uc.logger.Error("Failed to update webhook endpoint", "error", err, "endpoint_id", id)
+		return nil, err
+	}
+	return mapEndpointToResponse(endpoint), nil
+}
+
+// RotateSecret gives an endpoint a new signing secret, requests are signed with it from now on
+func (uc *WebhookUseCase) RotateSecret(ctx context.Context, id string) (*EndpointResponse, error) {
+	endpoint, err := uc.getEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.Secret, err = newSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.webhookRepo.UpdateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	uc.logger.Info("Webhook secret rotated", "endpoint_id", id)
+	response := mapEndpointToResponse(endpoint)
+	response.Secret = endpoint.Secret
+	return response, nil
+}
+
+func (uc *WebhookUseCase) DeleteEndpoint(ctx context.Context, id string) error {
+	if _, err := uc.getEndpoint(ctx, id); err != nil {
+		return err
+	}
+	if err := uc.webhookRepo.DeleteEndpoint(ctx, id); err != nil {
+		return err
+	}
+
+	uc.logger.Info("Webhook endpoint deleted", "endpoint_id", id)
+	return nil
+}
+
+// ListDeliveries returns the delivery log of an endpoint, newest first
+func (uc *WebhookUseCase) ListDeliveries(ctx context.Context, endpointID string, filters *DeliveryFilters) ([]entities.WebhookDelivery, error) {
+	if _, err := uc.getEndpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+	return uc.webhookRepo.ListDeliveries(ctx, endpointID, repositories.WebhookDeliveryFilters{
+		Status: filters.Status,
+		Limit:  filters.Limit,
+		Offset: filters.Offset,
+	})
+}
+
+// Redeliver queues a delivery again with a fresh set of attempts, such as after the receiver was fixed
+func (uc *WebhookUseCase) Redeliver(ctx context.Context, endpointID, id string) (*entities.WebhookDelivery, error) {
+	if _, err := uc.getEndpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+	delivery, err := uc.webhookRepo.GetDelivery(ctx, endpointID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	if delivery.Status == entities.WebhookDeliveryPending {
+		return nil, fmt.Errorf("%w: delivery is still queued", appErrors.ErrInvalidInput)
+	}
+
+	delivery.Redeliver()
+	if err := uc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Listen queues a delivery for every endpoint subscribed to an event published on the broker, until
+// the broker closes. It is meant to run in its own goroutine for the life of the server.
+func (uc *WebhookUseCase) Listen() {
+	updates, unsubscribe := uc.eventBroker.SubscribeBuffered(func(event events.Event) bool {
+		switch event.Type {
+		case events.EventPaymentStatus, events.EventTransactionCreated, events.EventStockChanged:
+			return true
+		}
+		return false
+	}, eventBuffer)
+	defer unsubscribe()
+
+	for event := range updates {
+		if err := uc.enqueue(context.Background(), event); err != nil {
+			uc.logger.Error("Failed to queue webhook deliveries", "error", err, "event", event.Type, "transaction_id", event.TransactionID)
+		}
+	}
+}
+
+// Run posts the due deliveries. It is meant to be called periodically by the scheduler.
+func (uc *WebhookUseCase) Run(ctx context.Context) error {
+	deliveries, err := uc.webhookRepo.ListDueDeliveries(ctx, time.Now(), batchSize)
+	if err != nil {
+		return err
+	}
+
+	endpoints := make(map[string]*entities.WebhookEndpoint)
+	var sent, failed int
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			endpoint, err = uc.webhookRepo.GetEndpoint(ctx, delivery.EndpointID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+
+		if endpoint == nil {
+			// Deleted since the event was queued
+			delivery.MarkAsFailed(appErrors.ErrWebhookEndpointNotFound)
+			failed++
+		} else {
+			status, err := uc.client.Send(ctx, &webhook.Request{
+				URL:        endpoint.URL,
+				Secret:     endpoint.Secret,
+				DeliveryID: delivery.ID,
+				Event:      string(delivery.Event),
+				Payload:    []byte(delivery.Payload),
+			})
+			if err != nil {
+				delivery.MarkAttemptFailed(err, status, uc.config.MaxAttempts, time.Duration(uc.config.RetryBackoffSeconds)*time.Second)
+				failed++
+				uc.logger.Warn("Failed to deliver webhook",
+					"error", err,
+					"delivery_id", delivery.ID,
+					"endpoint_id", delivery.EndpointID,
+					"event", delivery.Event,
+					"attempts", delivery.Attempts)
+			} else {
+				delivery.MarkAsSent(status)
+				sent++
+			}
+		}
+
+		if err := uc.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			uc.logger.Error("Failed to update webhook delivery", "error", err, "delivery_id", delivery.ID)
+		}
+	}
+
+	if sent > 0 || failed > 0 {
+		uc.logger.Info("Webhook deliveries processed", "sent", sent, "failed", failed)
+	}
+	return nil
+}
+
+// enqueue turns a broker event into the webhook event it stands for, if any, and queues it for the
+// endpoints that receive it. Practice sales of stores in training mode are never sent.
+func (uc *WebhookUseCase) enqueue(ctx context.Context, event events.Event) error {
+	var webhookEvent entities.WebhookEvent
+	var storeID, fingerprint string
+	var tenantID *string
+	var data any
+	eventID := uuid.New().String()
+
+	switch event.Type {
+	case events.EventPaymentStatus:
+		transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, event.TransactionID)
+		if err != nil {
+			return err
+		}
+		payment := transaction.Payment
+		if transaction.IsTraining || payment == nil || payment.Status != entities.PaymentSuccess {
+			return nil
+		}
+		// Every status change of the payment is published, the settlement is sent once
+		webhookEvent, storeID, tenantID = entities.WebhookPaymentSettled, transaction.StoreID, transaction.TenantID
+		fingerprint = fmt.Sprintf("%s:%s", webhookEvent, payment.ID)
+		data = &PaymentData{
+			PaymentID:     payment.ID,
+			TransactionID: transaction.ID,
+			Method:        payment.Method,
+			Provider:      payment.Provider,
+			Amount:        payment.Amount,
+			Reference:     payment.Reference,
+			PaidAt:        payment.PaidAt,
+			Transaction:   mapTransactionData(transaction),
+		}
+
+	case events.EventTransactionCreated:
+		transaction, err := uc.transactionRepo.GetByIDWithDetails(ctx, event.TransactionID)
+		if err != nil {
+			return err
+		}
+		if transaction.IsTraining {
+			return nil
+		}
+		webhookEvent, storeID, tenantID = entities.WebhookTransactionCreated, transaction.StoreID, transaction.TenantID
+		fingerprint = fmt.Sprintf("%s:%s", webhookEvent, transaction.ID)
+		data = mapTransactionData(transaction)
+
+	case events.EventStockChanged:
+		change, ok := event.Data.(*events.StockChange)
+		if !ok || change.Stock > float64(uc.config.LowStockThreshold) {
+			return nil
+		}
+		product, err := uc.productRepo.GetByID(ctx, change.ProductID)
+		if err != nil {
+			return err
+		}
+		// Sent on every change while the stock stays low, each one is a new event
+		webhookEvent, storeID, tenantID = entities.WebhookStockLow, product.StoreID, product.TenantID
+		fingerprint = fmt.Sprintf("%s:%s", webhookEvent, eventID)
+		data = &StockData{
+			ProductID: product.ID,
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Stock:     change.Stock,
+			Unit:      product.Unit,
+			Threshold: uc.config.LowStockThreshold,
+		}
+
+	default:
+		return nil
+	}
+
+	// Only the endpoints of the tenant the event happened in receive it
+	endpoints, err := uc.webhookRepo.ListActiveEndpoints(repositories.WithTenantOf(ctx, tenantID))
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(&Envelope{
+		ID:        eventID,
+		Type:      webhookEvent,
+		StoreID:   storeID,
+		CreatedAt: event.OccurredAt,
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range endpoints {
+		if !endpoints[i].Receives(webhookEvent, storeID) {
+			continue
+		}
+		delivery := entities.NewWebhookDelivery(endpoints[i].ID, webhookEvent, fingerprint, payload)
+		if _, err := uc.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uc *WebhookUseCase) getEndpoint(ctx context.Context, id string) (*entities.WebhookEndpoint, error) {
+	endpoint, err := uc.webhookRepo.GetEndpoint(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrWebhookEndpointNotFound
+		}
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// validateURL only accepts absolute http and https URLs, of public hosts in SaaS mode
+func (uc *WebhookUseCase) validateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", appErrors.ErrInvalidInput)
+	}
+	if err := uc.client.CheckHost(parsed.Hostname()); err != nil {
+		return fmt.Errorf("%w: url must point to a public address", appErrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+func mapTransactionData(transaction *entities.Transaction) TransactionData {
+	data := TransactionData{
+		ID:          transaction.ID,
+		UserID:      transaction.UserID,
+		Status:      transaction.Status,
+		OrderType:   transaction.OrderType,
+		Discount:    transaction.Discount,
+		TaxAmount:   transaction.TaxAmount,
+		TotalAmount: transaction.TotalAmount,
+		Currency:    transaction.Currency,
+		CreatedAt:   transaction.CreatedAt,
+		Items:       make([]TransactionItemData, len(transaction.Items)),
+	}
+	for i, item := range transaction.Items {
+		data.Items[i] = TransactionItemData{
+			ProductID:  item.ProductID,
+			SKU:        item.Product.SKU,
+			Name:       item.Product.Name,
+			Quantity:   item.Quantity,
+			Unit:       item.Unit,
+			UnitPrice:  item.UnitPrice,
+			TotalPrice: item.TotalPrice,
+		}
+	}
+	return data
+}
+
+func mapEndpointToResponse(endpoint *entities.WebhookEndpoint) *EndpointResponse {
+	return &EndpointResponse{
+		ID:          endpoint.ID,
+		StoreID:     endpoint.StoreID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		Events:      endpoint.EventList(),
+		IsActive:    endpoint.IsActive,
+		CreatedBy:   endpoint.CreatedBy,
+		CreatedAt:   endpoint.CreatedAt,
+		UpdatedAt:   endpoint.UpdatedAt,
+	}
+}
@@ -0,0 +1,4 @@
+-- Rollback: Remove stock reservation tracking
+DROP INDEX IF EXISTS idx_transactions_status_created_at;
+DROP INDEX IF EXISTS idx_payments_status_expires_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS stock_reserved;
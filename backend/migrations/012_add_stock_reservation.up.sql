@@ -0,0 +1,7 @@
+-- Track whether product stock has been deducted for a transaction's items
+-- so expiry/cancellation can release it exactly once
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS stock_reserved BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Support the expiry job scans
+CREATE INDEX IF NOT EXISTS idx_payments_status_expires_at ON payments(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_status_created_at ON transactions(status, created_at);
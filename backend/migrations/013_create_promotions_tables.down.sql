@@ -0,0 +1,3 @@
+-- Rollback: Drop promotion tables
+DROP TABLE IF EXISTS transaction_promotions;
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,38 @@
+-- Create promotions table for rule-based automatic discounts
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    type VARCHAR(50) NOT NULL CHECK (type IN ('buy_x_get_y', 'happy_hour', 'category_percent')),
+    product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    percentage DECIMAL(5,2) DEFAULT 0 CHECK (percentage >= 0 AND percentage <= 100),
+    buy_quantity INTEGER DEFAULT 0 CHECK (buy_quantity >= 0),
+    get_quantity INTEGER DEFAULT 0 CHECK (get_quantity >= 0),
+    start_time VARCHAR(5),
+    end_time VARCHAR(5),
+    days_of_week VARCHAR(20),
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    priority INTEGER DEFAULT 0,
+    stackable BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_is_active ON promotions(is_active);
+CREATE INDEX IF NOT EXISTS idx_promotions_deleted_at ON promotions(deleted_at);
+
+-- Promotions applied to a transaction with their discount amount
+CREATE TABLE IF NOT EXISTS transaction_promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    promotion_id UUID NOT NULL REFERENCES promotions(id),
+    name VARCHAR(255) NOT NULL,
+    discount_amount DECIMAL(10,2) NOT NULL CHECK (discount_amount >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_promotions_transaction_id ON transaction_promotions(transaction_id);
@@ -0,0 +1,2 @@
+-- Rollback: Drop payment exceptions table
+DROP TABLE IF EXISTS payment_exceptions;
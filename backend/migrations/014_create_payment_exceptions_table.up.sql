@@ -0,0 +1,21 @@
+-- Payments received for closed or already paid orders, pending refund or acknowledgement
+CREATE TABLE IF NOT EXISTS payment_exceptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID REFERENCES payments(id),
+    transaction_id UUID REFERENCES transactions(id),
+    order_id VARCHAR(255) NOT NULL,
+    external_id VARCHAR(255),
+    amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    reason VARCHAR(50) NOT NULL CHECK (reason IN ('late_payment', 'duplicate_payment', 'unknown_order')),
+    status VARCHAR(50) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'refunded')),
+    note TEXT,
+    raw_response TEXT,
+    resolved_by UUID REFERENCES users(id),
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_exceptions_external_id ON payment_exceptions(external_id) WHERE external_id <> '';
+CREATE INDEX IF NOT EXISTS idx_payment_exceptions_payment_id ON payment_exceptions(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_exceptions_status ON payment_exceptions(status);
@@ -0,0 +1,8 @@
+-- Rollback: Restrict payments to QRIS only
+ALTER TABLE payments DROP COLUMN IF EXISTS reference;
+ALTER TABLE payments DROP COLUMN IF EXISTS change_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS amount_tendered;
+
+DELETE FROM payments WHERE method <> 'qris';
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method CHECK (method IN ('qris'));
@@ -0,0 +1,8 @@
+-- Allow cash, card and other payment methods alongside QRIS
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_method_check;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method CHECK (method IN ('qris', 'cash', 'card', 'other'));
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS amount_tendered DECIMAL(10,2) DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS change_amount DECIMAL(10,2) DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS reference VARCHAR(255);
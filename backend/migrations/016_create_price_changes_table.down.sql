@@ -0,0 +1,2 @@
+-- Rollback: Drop price changes audit table
+DROP TABLE IF EXISTS price_changes;
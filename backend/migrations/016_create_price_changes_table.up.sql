@@ -0,0 +1,14 @@
+-- Audit trail of product price changes (bulk price updates)
+CREATE TABLE IF NOT EXISTS price_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    batch_id UUID NOT NULL,
+    old_price DECIMAL(10,2) NOT NULL,
+    new_price DECIMAL(10,2) NOT NULL CHECK (new_price >= 0),
+    changed_by UUID NOT NULL REFERENCES users(id),
+    reason VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_changes_product_id ON price_changes(product_id);
+CREATE INDEX IF NOT EXISTS idx_price_changes_batch_id ON price_changes(batch_id);
@@ -0,0 +1,9 @@
+-- Rollback: Drop refunds tables and refunded statuses
+UPDATE transactions SET status = 'paid' WHERE status IN ('refunded', 'partially_refunded');
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_status;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_status
+    CHECK (status IN ('pending', 'paid', 'cancelled', 'expired'));
+ALTER TABLE transactions DROP COLUMN IF EXISTS refunded_amount;
+
+DROP TABLE IF EXISTS refund_items;
+DROP TABLE IF EXISTS refunds;
@@ -0,0 +1,32 @@
+-- Refunds of settled payments and the items returned to stock
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    reason VARCHAR(255),
+    refund_key VARCHAR(255) UNIQUE,
+    external_response TEXT,
+    refunded_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_transaction_id ON refunds(transaction_id);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    refund_id UUID NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount DECIMAL(10,2) NOT NULL CHECK (amount >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_items_refund_id ON refund_items(refund_id);
+
+-- Refunded transaction statuses
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(10,2) DEFAULT 0 CHECK (refunded_amount >= 0);
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_status;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_status
+    CHECK (status IN ('pending', 'paid', 'cancelled', 'expired', 'refunded', 'partially_refunded'));
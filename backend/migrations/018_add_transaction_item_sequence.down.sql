@@ -0,0 +1,3 @@
+-- Rollback: Remove transaction item display sequence
+DROP INDEX IF EXISTS idx_transaction_items_transaction_sequence;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS sequence;
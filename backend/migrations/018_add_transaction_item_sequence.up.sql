@@ -0,0 +1,13 @@
+-- Display order of transaction items on receipts and kitchen tickets
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS sequence INTEGER NOT NULL DEFAULT 0;
+
+-- Backfill existing items in the order they were added
+UPDATE transaction_items ti
+SET sequence = ordered.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY transaction_id ORDER BY created_at, id) AS rn
+    FROM transaction_items
+) ordered
+WHERE ti.id = ordered.id;
+
+CREATE INDEX IF NOT EXISTS idx_transaction_items_transaction_sequence ON transaction_items(transaction_id, sequence);
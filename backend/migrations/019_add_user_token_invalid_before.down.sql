@@ -0,0 +1,2 @@
+-- Rollback: Remove token revocation cut-off from users
+ALTER TABLE users DROP COLUMN IF EXISTS token_invalid_before;
@@ -0,0 +1,2 @@
+-- Tokens issued before this time are rejected (set when the user changes password)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_invalid_before TIMESTAMP;
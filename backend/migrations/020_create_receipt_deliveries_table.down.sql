@@ -0,0 +1,4 @@
+-- Rollback: Drop receipt delivery queue and customer contacts
+DROP TABLE IF EXISTS receipt_deliveries;
+ALTER TABLE transactions DROP COLUMN IF EXISTS customer_phone;
+ALTER TABLE transactions DROP COLUMN IF EXISTS customer_email;
@@ -0,0 +1,21 @@
+-- Customer contacts captured at checkout for digital receipts
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_email VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_phone VARCHAR(20);
+
+-- Queue of digital receipts sent by email or WhatsApp, retried with backoff
+CREATE TABLE IF NOT EXISTS receipt_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('email', 'whatsapp')),
+    recipient VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipt_deliveries_transaction_id ON receipt_deliveries(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_receipt_deliveries_due ON receipt_deliveries(status, next_attempt_at);
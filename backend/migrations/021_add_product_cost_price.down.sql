@@ -0,0 +1,4 @@
+-- Rollback: Remove product cost price and item unit cost
+DROP INDEX IF EXISTS idx_transaction_items_product_id;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS unit_cost;
+ALTER TABLE products DROP COLUMN IF EXISTS cost_price;
@@ -0,0 +1,6 @@
+-- Purchase cost of products, snapshotted on every sold item for margin reports
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (cost_price >= 0);
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS unit_cost DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Support the best-selling products report
+CREATE INDEX IF NOT EXISTS idx_transaction_items_product_id ON transaction_items(product_id);
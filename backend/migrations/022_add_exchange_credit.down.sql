@@ -0,0 +1,4 @@
+-- Rollback: Remove exchange credit tracking
+ALTER TABLE refunds DROP COLUMN IF EXISTS credited_amount;
+ALTER TABLE refunds DROP COLUMN IF EXISTS exchange_transaction_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS exchange_credit;
@@ -0,0 +1,5 @@
+-- Exchanges: value of returned items credited to the replacement sale
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS exchange_credit DECIMAL(10,2) DEFAULT 0 CHECK (exchange_credit >= 0);
+
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS exchange_transaction_id UUID REFERENCES transactions(id);
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS credited_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Rollback: Remove cashier shifts
+DROP INDEX IF EXISTS idx_transactions_shift_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS shift_id;
+DROP TABLE IF EXISTS shifts;
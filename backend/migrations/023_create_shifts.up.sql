@@ -0,0 +1,28 @@
+-- Cashier shifts: opening float, per-method totals and the Z-report cash count
+CREATE TABLE IF NOT EXISTS shifts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    opening_float DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (opening_float >= 0),
+    cash_sales DECIMAL(10,2) NOT NULL DEFAULT 0,
+    cash_refunds DECIMAL(10,2) NOT NULL DEFAULT 0,
+    qris_sales DECIMAL(10,2) NOT NULL DEFAULT 0,
+    card_sales DECIMAL(10,2) NOT NULL DEFAULT 0,
+    other_sales DECIMAL(10,2) NOT NULL DEFAULT 0,
+    transaction_count INTEGER NOT NULL DEFAULT 0,
+    expected_cash DECIMAL(10,2) NOT NULL DEFAULT 0,
+    counted_cash DECIMAL(10,2),
+    cash_difference DECIMAL(10,2) NOT NULL DEFAULT 0,
+    opening_note TEXT,
+    closing_note TEXT,
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A cashier can only have one open shift at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_user_open ON shifts(user_id) WHERE status = 'open';
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS shift_id UUID REFERENCES shifts(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_shift_id ON transactions(shift_id);
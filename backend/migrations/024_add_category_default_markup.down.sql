@@ -0,0 +1,2 @@
+-- Rollback: Remove category default markup
+ALTER TABLE categories DROP COLUMN IF EXISTS default_markup;
@@ -0,0 +1,2 @@
+-- Default markup percentage per category, used to suggest sale prices from cost price
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS default_markup DECIMAL(7,2) NOT NULL DEFAULT 0 CHECK (default_markup >= 0);
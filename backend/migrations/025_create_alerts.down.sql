@@ -0,0 +1,2 @@
+-- Rollback: Remove anomaly alerts
+DROP TABLE IF EXISTS alerts;
@@ -0,0 +1,20 @@
+-- Anomaly alerts raised by the detection job
+CREATE TABLE IF NOT EXISTS alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    user_id UUID REFERENCES users(id),
+    value DECIMAL(12,4) NOT NULL DEFAULT 0,
+    threshold DECIMAL(12,4) NOT NULL DEFAULT 0,
+    fingerprint VARCHAR(255) NOT NULL,
+    acknowledged_at TIMESTAMP,
+    acknowledged_by UUID REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The fingerprint keeps overlapping detection runs from raising the same alert twice
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_fingerprint ON alerts(fingerprint);
+CREATE INDEX IF NOT EXISTS idx_alerts_type ON alerts(type);
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at ON alerts(created_at);
@@ -0,0 +1,3 @@
+-- Rollback: Remove product barcode
+DROP INDEX IF EXISTS idx_products_barcode;
+ALTER TABLE products DROP COLUMN IF EXISTS barcode;
@@ -0,0 +1,5 @@
+-- Barcode printed on the product package, looked up by checkout scanners
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode VARCHAR(64);
+
+-- Exact-match lookups; unique among active products, empty barcodes allowed
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products(barcode) WHERE barcode <> '' AND deleted_at IS NULL;
@@ -0,0 +1,49 @@
+-- Rollback: Store monetary amounts as DECIMAL(10,2) again (rounded values stay rounded)
+ALTER TABLE products
+    ALTER COLUMN price TYPE DECIMAL(10,2),
+    ALTER COLUMN cost_price TYPE DECIMAL(10,2);
+
+ALTER TABLE transactions
+    ALTER COLUMN total_amount TYPE DECIMAL(10,2),
+    ALTER COLUMN tax_amount TYPE DECIMAL(10,2),
+    ALTER COLUMN discount TYPE DECIMAL(10,2),
+    ALTER COLUMN refunded_amount TYPE DECIMAL(10,2),
+    ALTER COLUMN exchange_credit TYPE DECIMAL(10,2);
+
+ALTER TABLE transaction_items
+    ALTER COLUMN unit_price TYPE DECIMAL(10,2),
+    ALTER COLUMN total_price TYPE DECIMAL(10,2),
+    ALTER COLUMN unit_cost TYPE DECIMAL(10,2);
+
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE DECIMAL(10,2),
+    ALTER COLUMN amount_tendered TYPE DECIMAL(10,2),
+    ALTER COLUMN change_amount TYPE DECIMAL(10,2);
+
+ALTER TABLE payment_exceptions
+    ALTER COLUMN amount TYPE DECIMAL(10,2);
+
+ALTER TABLE refunds
+    ALTER COLUMN amount TYPE DECIMAL(10,2),
+    ALTER COLUMN credited_amount TYPE DECIMAL(10,2);
+
+ALTER TABLE refund_items
+    ALTER COLUMN amount TYPE DECIMAL(10,2);
+
+ALTER TABLE price_changes
+    ALTER COLUMN old_price TYPE DECIMAL(10,2),
+    ALTER COLUMN new_price TYPE DECIMAL(10,2);
+
+ALTER TABLE transaction_promotions
+    ALTER COLUMN discount_amount TYPE DECIMAL(10,2);
+
+ALTER TABLE shifts
+    ALTER COLUMN opening_float TYPE DECIMAL(10,2),
+    ALTER COLUMN cash_sales TYPE DECIMAL(10,2),
+    ALTER COLUMN cash_refunds TYPE DECIMAL(10,2),
+    ALTER COLUMN qris_sales TYPE DECIMAL(10,2),
+    ALTER COLUMN card_sales TYPE DECIMAL(10,2),
+    ALTER COLUMN other_sales TYPE DECIMAL(10,2),
+    ALTER COLUMN expected_cash TYPE DECIMAL(10,2),
+    ALTER COLUMN counted_cash TYPE DECIMAL(10,2),
+    ALTER COLUMN cash_difference TYPE DECIMAL(10,2);
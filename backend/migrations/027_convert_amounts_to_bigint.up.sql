@@ -0,0 +1,50 @@
+-- Store monetary amounts as whole rupiah (BIGINT) instead of DECIMAL(10,2).
+-- Existing values are rounded to the nearest rupiah; IDR has no minor unit in circulation.
+ALTER TABLE products
+    ALTER COLUMN price TYPE BIGINT USING ROUND(price),
+    ALTER COLUMN cost_price TYPE BIGINT USING ROUND(cost_price);
+
+ALTER TABLE transactions
+    ALTER COLUMN total_amount TYPE BIGINT USING ROUND(total_amount),
+    ALTER COLUMN tax_amount TYPE BIGINT USING ROUND(tax_amount),
+    ALTER COLUMN discount TYPE BIGINT USING ROUND(discount),
+    ALTER COLUMN refunded_amount TYPE BIGINT USING ROUND(refunded_amount),
+    ALTER COLUMN exchange_credit TYPE BIGINT USING ROUND(exchange_credit);
+
+ALTER TABLE transaction_items
+    ALTER COLUMN unit_price TYPE BIGINT USING ROUND(unit_price),
+    ALTER COLUMN total_price TYPE BIGINT USING ROUND(total_price),
+    ALTER COLUMN unit_cost TYPE BIGINT USING ROUND(unit_cost);
+
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount),
+    ALTER COLUMN amount_tendered TYPE BIGINT USING ROUND(amount_tendered),
+    ALTER COLUMN change_amount TYPE BIGINT USING ROUND(change_amount);
+
+ALTER TABLE payment_exceptions
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount);
+
+ALTER TABLE refunds
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount),
+    ALTER COLUMN credited_amount TYPE BIGINT USING ROUND(credited_amount);
+
+ALTER TABLE refund_items
+    ALTER COLUMN amount TYPE BIGINT USING ROUND(amount);
+
+ALTER TABLE price_changes
+    ALTER COLUMN old_price TYPE BIGINT USING ROUND(old_price),
+    ALTER COLUMN new_price TYPE BIGINT USING ROUND(new_price);
+
+ALTER TABLE transaction_promotions
+    ALTER COLUMN discount_amount TYPE BIGINT USING ROUND(discount_amount);
+
+ALTER TABLE shifts
+    ALTER COLUMN opening_float TYPE BIGINT USING ROUND(opening_float),
+    ALTER COLUMN cash_sales TYPE BIGINT USING ROUND(cash_sales),
+    ALTER COLUMN cash_refunds TYPE BIGINT USING ROUND(cash_refunds),
+    ALTER COLUMN qris_sales TYPE BIGINT USING ROUND(qris_sales),
+    ALTER COLUMN card_sales TYPE BIGINT USING ROUND(card_sales),
+    ALTER COLUMN other_sales TYPE BIGINT USING ROUND(other_sales),
+    ALTER COLUMN expected_cash TYPE BIGINT USING ROUND(expected_cash),
+    ALTER COLUMN counted_cash TYPE BIGINT USING ROUND(counted_cash),
+    ALTER COLUMN cash_difference TYPE BIGINT USING ROUND(cash_difference);
@@ -0,0 +1,3 @@
+-- Rollback: Remove product tax class and item tax class snapshots
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS tax_class;
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
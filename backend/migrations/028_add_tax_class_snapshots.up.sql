@@ -0,0 +1,5 @@
+-- Tax class of products, snapshotted on every sold item next to the unit cost
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class VARCHAR(20) NOT NULL DEFAULT 'standard' CHECK (tax_class IN ('standard', 'exempt'));
+
+-- Left NULL on existing items until `go run ./cmd/backfill-snapshots` copies the product's class
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS tax_class VARCHAR(20);
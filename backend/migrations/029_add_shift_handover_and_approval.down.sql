@@ -0,0 +1,11 @@
+-- Rollback: Remove shift handover notes and variance approval
+DROP INDEX IF EXISTS idx_shifts_status_closed_at;
+
+UPDATE shifts SET status = 'closed' WHERE status = 'pending_approval';
+ALTER TABLE shifts DROP CONSTRAINT IF EXISTS chk_shifts_status;
+ALTER TABLE shifts ADD CONSTRAINT chk_shifts_status CHECK (status IN ('open', 'closed'));
+
+ALTER TABLE shifts DROP COLUMN IF EXISTS approval_note;
+ALTER TABLE shifts DROP COLUMN IF EXISTS approved_at;
+ALTER TABLE shifts DROP COLUMN IF EXISTS approved_by;
+ALTER TABLE shifts DROP COLUMN IF EXISTS handover_note;
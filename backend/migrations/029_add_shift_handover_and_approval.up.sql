@@ -0,0 +1,12 @@
+-- Handover notes for the next shift, and manager approval of large cash variances
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS handover_note TEXT;
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS approved_by UUID REFERENCES users(id);
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS approved_at TIMESTAMP;
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS approval_note TEXT;
+
+ALTER TABLE shifts DROP CONSTRAINT IF EXISTS shifts_status_check;
+ALTER TABLE shifts DROP CONSTRAINT IF EXISTS chk_shifts_status;
+ALTER TABLE shifts ADD CONSTRAINT chk_shifts_status CHECK (status IN ('open', 'pending_approval', 'closed'));
+
+-- Supports the list of shifts awaiting approval and the handover lookup
+CREATE INDEX IF NOT EXISTS idx_shifts_status_closed_at ON shifts(status, closed_at);
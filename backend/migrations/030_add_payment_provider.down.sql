@@ -0,0 +1,3 @@
+-- Rollback: Remove payment provider
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_provider;
+ALTER TABLE payments DROP COLUMN IF EXISTS provider;
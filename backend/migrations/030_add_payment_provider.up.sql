@@ -0,0 +1,9 @@
+-- Which provider issued a QRIS payment: Midtrans, or a QRIS built locally from the merchant's NMID
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider VARCHAR(20);
+
+-- Every QRIS issued so far went through Midtrans
+UPDATE payments SET provider = 'midtrans'
+WHERE method = 'qris' AND order_id IS NOT NULL AND order_id <> '' AND provider IS NULL;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_provider;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_provider CHECK (provider IS NULL OR provider IN ('midtrans', 'local_qris'));
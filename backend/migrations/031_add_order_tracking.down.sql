@@ -0,0 +1,12 @@
+-- Rollback: Remove order types and order status tracking
+DROP INDEX IF EXISTS idx_transactions_tracking_token;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_fulfillment_status;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_order_type;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS completed_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS ready_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS preparing_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fulfillment_status;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tracking_token;
+ALTER TABLE transactions DROP COLUMN IF EXISTS order_type;
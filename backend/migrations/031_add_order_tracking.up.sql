@@ -0,0 +1,15 @@
+-- Pickup and delivery orders with a public status page driven by kitchen statuses
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS order_type VARCHAR(20) NOT NULL DEFAULT 'dine_in';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tracking_token VARCHAR(64);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fulfillment_status VARCHAR(20);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS preparing_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS ready_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_order_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_order_type CHECK (order_type IN ('dine_in', 'pickup', 'delivery'));
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_fulfillment_status;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_fulfillment_status CHECK (fulfillment_status IS NULL OR fulfillment_status IN ('', 'preparing', 'ready', 'completed'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_tracking_token ON transactions(tracking_token);
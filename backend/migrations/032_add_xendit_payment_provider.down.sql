@@ -0,0 +1,3 @@
+-- Rollback: Remove the Xendit payment provider
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_provider;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_provider CHECK (provider IS NULL OR provider IN ('midtrans', 'local_qris'));
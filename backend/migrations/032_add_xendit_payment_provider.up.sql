@@ -0,0 +1,3 @@
+-- QRIS payments can be issued through Xendit as well as Midtrans
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_provider;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_provider CHECK (provider IS NULL OR provider IN ('midtrans', 'xendit', 'local_qris'));
@@ -0,0 +1,8 @@
+-- Rollback: Remove e-wallet payments
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_wallet;
+ALTER TABLE payments DROP COLUMN IF EXISTS deeplink_url;
+ALTER TABLE payments DROP COLUMN IF EXISTS wallet;
+
+DELETE FROM payments WHERE method = 'ewallet';
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method CHECK (method IN ('qris', 'cash', 'card', 'other'));
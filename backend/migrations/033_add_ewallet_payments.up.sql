@@ -0,0 +1,9 @@
+-- E-wallet payments: the customer pays in GoPay or ShopeePay opened from a deeplink
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_method;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_method CHECK (method IN ('qris', 'cash', 'card', 'other', 'ewallet'));
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS wallet VARCHAR(20);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS deeplink_url TEXT;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_wallet;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_wallet CHECK (wallet IS NULL OR wallet IN ('gopay', 'shopeepay'));
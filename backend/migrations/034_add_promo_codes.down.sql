@@ -0,0 +1,14 @@
+-- Rollback: Remove promo codes, percentage and fixed amount promotions
+DROP INDEX IF EXISTS idx_transactions_promo_code;
+ALTER TABLE transactions DROP COLUMN IF EXISTS promo_code;
+
+DROP INDEX IF EXISTS idx_promotions_code;
+ALTER TABLE promotions DROP COLUMN IF EXISTS usage_limit;
+ALTER TABLE promotions DROP COLUMN IF EXISTS min_basket;
+ALTER TABLE promotions DROP COLUMN IF EXISTS amount;
+ALTER TABLE promotions DROP COLUMN IF EXISTS code;
+
+DELETE FROM transaction_promotions WHERE promotion_id IN (SELECT id FROM promotions WHERE type IN ('percentage', 'fixed_amount'));
+DELETE FROM promotions WHERE type IN ('percentage', 'fixed_amount');
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS chk_promotions_type;
+ALTER TABLE promotions ADD CONSTRAINT chk_promotions_type CHECK (type IN ('buy_x_get_y', 'happy_hour', 'category_percent'));
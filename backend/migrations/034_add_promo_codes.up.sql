@@ -0,0 +1,17 @@
+-- Percentage and fixed amount promotions, minimum basket and promo codes with usage limits
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_type_check;
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS chk_promotions_type;
+ALTER TABLE promotions ADD CONSTRAINT chk_promotions_type
+    CHECK (type IN ('buy_x_get_y', 'happy_hour', 'category_percent', 'percentage', 'fixed_amount'));
+
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS code VARCHAR(50);
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS amount BIGINT DEFAULT 0 CHECK (amount >= 0);
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS min_basket BIGINT DEFAULT 0 CHECK (min_basket >= 0);
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS usage_limit INTEGER DEFAULT 0 CHECK (usage_limit >= 0);
+
+-- A code identifies one promotion at a time; deleted promotions give their code back
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promotions_code ON promotions(code) WHERE deleted_at IS NULL AND code IS NOT NULL;
+
+-- Promo code entered on a transaction, also used to count redemptions against the usage limit
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS promo_code VARCHAR(50);
+CREATE INDEX IF NOT EXISTS idx_transactions_promo_code ON transactions(promo_code) WHERE promo_code IS NOT NULL;
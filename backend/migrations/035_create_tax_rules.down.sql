@@ -0,0 +1,6 @@
+-- Rollback: Remove tax rules and the taxes charged on transactions
+ALTER TABLE transactions DROP COLUMN IF EXISTS included_tax;
+
+DROP TABLE IF EXISTS transaction_taxes;
+DROP TABLE IF EXISTS tax_rule_exemptions;
+DROP TABLE IF EXISTS tax_rules;
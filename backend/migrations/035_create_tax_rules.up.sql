@@ -0,0 +1,40 @@
+-- Tax rules charged automatically on transactions, with per-category exemptions
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    rate DECIMAL(5,2) NOT NULL CONSTRAINT chk_tax_rules_rate CHECK (rate > 0 AND rate <= 100),
+    inclusive BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_rules_deleted_at ON tax_rules(deleted_at);
+
+CREATE TABLE IF NOT EXISTS tax_rule_exemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tax_rule_id UUID NOT NULL REFERENCES tax_rules(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_rule_exemptions_tax_rule_id ON tax_rule_exemptions(tax_rule_id);
+
+-- Taxes charged on a transaction, kept with the rate at the time of sale
+CREATE TABLE IF NOT EXISTS transaction_taxes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    tax_rule_id UUID NOT NULL REFERENCES tax_rules(id),
+    name VARCHAR(100) NOT NULL,
+    rate DECIMAL(5,2) NOT NULL,
+    inclusive BOOLEAN DEFAULT FALSE,
+    taxable_amount BIGINT NOT NULL CHECK (taxable_amount >= 0),
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_taxes_transaction_id ON transaction_taxes(transaction_id);
+
+-- Tax of inclusive rules, already in the item prices and not added to the total
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS included_tax BIGINT DEFAULT 0 CHECK (included_tax >= 0);
@@ -0,0 +1,9 @@
+-- Rollback: Remove customers, their points and the customer of transactions
+DROP INDEX IF EXISTS idx_transactions_customer_id;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS points_discount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS points_redeemed;
+ALTER TABLE transactions DROP COLUMN IF EXISTS customer_id;
+
+DROP TABLE IF EXISTS points_entries;
+DROP TABLE IF EXISTS customers;
@@ -0,0 +1,39 @@
+-- Loyalty members, looked up at checkout by phone or email
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(20),
+    email VARCHAR(255),
+    points BIGINT NOT NULL DEFAULT 0 CONSTRAINT chk_customers_points CHECK (points >= 0),
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone ON customers(phone) WHERE phone IS NOT NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers(email) WHERE email IS NOT NULL AND deleted_at IS NULL;
+
+-- Changes to points balances; a transaction earns and redeems at most once
+CREATE TABLE IF NOT EXISTS points_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id UUID NOT NULL REFERENCES customers(id),
+    transaction_id UUID REFERENCES transactions(id),
+    type VARCHAR(20) NOT NULL CONSTRAINT chk_points_entries_type CHECK (type IN ('earn', 'redeem', 'adjust')),
+    points BIGINT NOT NULL,
+    balance BIGINT NOT NULL,
+    note TEXT,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_points_entries_customer_id ON points_entries(customer_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_points_entries_transaction_type ON points_entries(transaction_id, type);
+
+-- Customer of a sale and the points redeemed on it, held until the sale is paid
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id UUID REFERENCES customers(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed BIGINT DEFAULT 0 CHECK (points_redeemed >= 0);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_discount BIGINT DEFAULT 0 CHECK (points_discount >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_customer_id ON transactions(customer_id);
@@ -0,0 +1,2 @@
+-- Rollback: Remove the rolled up product sales counts
+DROP TABLE IF EXISTS product_popularity;
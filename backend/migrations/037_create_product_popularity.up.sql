@@ -0,0 +1,8 @@
+-- Units sold per product over the recent window, rolled up by a background job for the popular catalog sort
+CREATE TABLE IF NOT EXISTS product_popularity (
+    product_id UUID PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    units_sold BIGINT NOT NULL DEFAULT 0,
+    refreshed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_popularity_units_sold ON product_popularity(units_sold DESC);
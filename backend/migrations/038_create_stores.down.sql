@@ -0,0 +1,20 @@
+-- Rollback: Remove stores and the store of users, products, transactions and payments
+DROP INDEX IF EXISTS idx_payments_store_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS store_id;
+
+DROP INDEX IF EXISTS idx_transactions_store_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS store_id;
+
+-- SKUs and barcodes become unique across the catalog again
+DROP INDEX IF EXISTS idx_products_barcode;
+DROP INDEX IF EXISTS idx_products_store_sku;
+DROP INDEX IF EXISTS idx_products_store_id;
+ALTER TABLE products DROP COLUMN IF EXISTS store_id;
+ALTER TABLE products ADD CONSTRAINT products_sku_key UNIQUE (sku);
+CREATE INDEX IF NOT EXISTS idx_products_sku ON products(sku);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products(barcode) WHERE barcode <> '' AND deleted_at IS NULL;
+
+DROP INDEX IF EXISTS idx_users_store_id;
+ALTER TABLE users DROP COLUMN IF EXISTS store_id;
+
+DROP TABLE IF EXISTS stores;
@@ -0,0 +1,48 @@
+-- Outlets of the merchant; products, cashiers, transactions and payments belong to one store
+CREATE TABLE IF NOT EXISTS stores (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(20) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address TEXT,
+    phone VARCHAR(20),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stores_code ON stores(code);
+CREATE INDEX IF NOT EXISTS idx_stores_deleted_at ON stores(deleted_at);
+
+-- Everything recorded so far was sold in one store
+INSERT INTO stores (code, name)
+SELECT 'MAIN', 'Main Store'
+WHERE NOT EXISTS (SELECT 1 FROM stores);
+
+-- Cashiers work in one store, admins without a store oversee every store
+ALTER TABLE users ADD COLUMN IF NOT EXISTS store_id UUID REFERENCES stores(id);
+UPDATE users SET store_id = (SELECT id FROM stores ORDER BY created_at LIMIT 1)
+WHERE store_id IS NULL AND role = 'cashier';
+CREATE INDEX IF NOT EXISTS idx_users_store_id ON users(store_id);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS store_id UUID REFERENCES stores(id);
+UPDATE products SET store_id = (SELECT id FROM stores ORDER BY created_at LIMIT 1) WHERE store_id IS NULL;
+ALTER TABLE products ALTER COLUMN store_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_products_store_id ON products(store_id);
+
+-- SKUs and barcodes only need to be unique within a store
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
+DROP INDEX IF EXISTS idx_products_sku;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_store_sku ON products(store_id, sku);
+DROP INDEX IF EXISTS idx_products_barcode;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products(store_id, barcode) WHERE barcode <> '' AND deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS store_id UUID REFERENCES stores(id);
+UPDATE transactions SET store_id = (SELECT id FROM stores ORDER BY created_at LIMIT 1) WHERE store_id IS NULL;
+ALTER TABLE transactions ALTER COLUMN store_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_store_id ON transactions(store_id);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS store_id UUID REFERENCES stores(id);
+UPDATE payments p SET store_id = t.store_id FROM transactions t WHERE t.id = p.transaction_id AND p.store_id IS NULL;
+ALTER TABLE payments ALTER COLUMN store_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_store_id ON payments(store_id);
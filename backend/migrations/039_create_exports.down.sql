@@ -0,0 +1,2 @@
+-- Rollback: Remove merchant data exports
+DROP TABLE IF EXISTS exports;
@@ -0,0 +1,21 @@
+-- Merchant data exports, built in the background into a zip in the export bucket
+CREATE TABLE IF NOT EXISTS exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID REFERENCES stores(id),
+    requested_by UUID NOT NULL REFERENCES users(id),
+    notify_email VARCHAR(255) NOT NULL,
+    date_from TIMESTAMP NOT NULL,
+    date_to TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'ready', 'failed')),
+    object_path TEXT,
+    size_bytes BIGINT DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_exports_store_id ON exports(store_id);
+-- The export job picks up pending exports oldest first
+CREATE INDEX IF NOT EXISTS idx_exports_status ON exports(status);
@@ -0,0 +1,3 @@
+-- Rollback: Remove refresh tokens and the revoked access token list
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
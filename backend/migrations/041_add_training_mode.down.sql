@@ -0,0 +1,11 @@
+-- Rollback: Remove training mode, training transactions should be purged first
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_provider;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_provider CHECK (provider IS NULL OR provider IN ('midtrans', 'xendit', 'local_qris'));
+
+DROP INDEX IF EXISTS idx_payments_is_training;
+ALTER TABLE payments DROP COLUMN IF EXISTS is_training;
+
+DROP INDEX IF EXISTS idx_transactions_is_training;
+ALTER TABLE transactions DROP COLUMN IF EXISTS is_training;
+
+ALTER TABLE stores DROP COLUMN IF EXISTS training_mode;
@@ -0,0 +1,12 @@
+-- Training mode: stores can switch to practice sales that are never charged, reported or stocked
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS training_mode BOOLEAN DEFAULT false;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS is_training BOOLEAN DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_transactions_is_training ON transactions(is_training);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS is_training BOOLEAN DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_payments_is_training ON payments(is_training);
+
+-- Training payments are issued by the in-process simulator
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS chk_payments_provider;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_provider CHECK (provider IS NULL OR provider IN ('midtrans', 'xendit', 'local_qris', 'training'));
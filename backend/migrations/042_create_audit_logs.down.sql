@@ -0,0 +1,2 @@
+-- Rollback: Remove the audit log
+DROP TABLE IF EXISTS audit_logs;
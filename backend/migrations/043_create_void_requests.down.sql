@@ -0,0 +1,5 @@
+-- Rollback: Remove void requests, void refund exceptions should be resolved and deleted first
+ALTER TABLE payment_exceptions DROP CONSTRAINT IF EXISTS chk_payment_exceptions_reason;
+ALTER TABLE payment_exceptions ADD CONSTRAINT chk_payment_exceptions_reason CHECK (reason IN ('late_payment', 'duplicate_payment', 'unknown_order'));
+
+DROP TABLE IF EXISTS void_requests;
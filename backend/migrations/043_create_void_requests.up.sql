@@ -0,0 +1,28 @@
+-- Voids of paid sales: a cashier requests one and a manager approves it before the sale is refunded and restocked
+CREATE TABLE IF NOT EXISTS void_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    requested_by UUID NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    decided_by UUID REFERENCES users(id),
+    decided_at TIMESTAMP,
+    decision_note TEXT,
+    refund_id UUID REFERENCES refunds(id),
+    settlement VARCHAR(20) CHECK (settlement IS NULL OR settlement IN ('gateway', 'counter', 'manual')),
+    exception_id UUID REFERENCES payment_exceptions(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_void_requests_transaction_id ON void_requests(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_void_requests_store_id ON void_requests(store_id);
+CREATE INDEX IF NOT EXISTS idx_void_requests_status ON void_requests(status);
+-- One open request per sale
+CREATE UNIQUE INDEX IF NOT EXISTS idx_void_requests_pending ON void_requests(transaction_id) WHERE status = 'pending';
+
+-- Voids the gateway couldn't refund are queued as payment exceptions
+ALTER TABLE payment_exceptions DROP CONSTRAINT IF EXISTS payment_exceptions_reason_check;
+ALTER TABLE payment_exceptions DROP CONSTRAINT IF EXISTS chk_payment_exceptions_reason;
+ALTER TABLE payment_exceptions ADD CONSTRAINT chk_payment_exceptions_reason CHECK (reason IN ('late_payment', 'duplicate_payment', 'unknown_order', 'void_refund'));
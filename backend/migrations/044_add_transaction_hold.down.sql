@@ -0,0 +1,4 @@
+-- Rollback: Remove held carts, they become regular pending transactions
+DROP INDEX IF EXISTS idx_transactions_held_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS held_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS hold_label;
@@ -0,0 +1,4 @@
+-- Held carts: pending transactions parked under a label and resumed later, skipped by the expiry job
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS hold_label VARCHAR(100);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS held_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_transactions_held_at ON transactions(held_at);
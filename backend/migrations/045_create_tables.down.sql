@@ -0,0 +1,6 @@
+-- Rollback: Remove dining tables, open table orders become regular pending transactions
+DROP INDEX IF EXISTS idx_transactions_open_table;
+DROP INDEX IF EXISTS idx_transactions_table_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS table_id;
+
+DROP TABLE IF EXISTS tables;
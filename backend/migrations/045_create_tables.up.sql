@@ -0,0 +1,22 @@
+-- Dining tables: dine-in orders stay open on a table while guests keep ordering and are settled when they leave
+CREATE TABLE IF NOT EXISTS tables (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    name VARCHAR(50) NOT NULL,
+    area VARCHAR(50),
+    seats INTEGER NOT NULL DEFAULT 0 CHECK (seats >= 0),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tables_store_id ON tables(store_id);
+CREATE INDEX IF NOT EXISTS idx_tables_deleted_at ON tables(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tables_store_name ON tables(store_id, name) WHERE deleted_at IS NULL;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS table_id UUID REFERENCES tables(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_table_id ON transactions(table_id);
+-- One open order per table
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_open_table ON transactions(table_id) WHERE status = 'pending' AND deleted_at IS NULL;
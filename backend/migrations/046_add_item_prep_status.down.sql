@@ -0,0 +1,4 @@
+-- Rollback: Remove item preparation statuses
+ALTER TABLE transaction_items DROP CONSTRAINT IF EXISTS chk_transaction_items_prep_status;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS prep_updated_at;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS prep_status;
@@ -0,0 +1,5 @@
+-- Kitchen display: preparation status of each item of an order
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS prep_status VARCHAR(20) NOT NULL DEFAULT 'queued';
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS prep_updated_at TIMESTAMP;
+ALTER TABLE transaction_items DROP CONSTRAINT IF EXISTS chk_transaction_items_prep_status;
+ALTER TABLE transaction_items ADD CONSTRAINT chk_transaction_items_prep_status CHECK (prep_status IN ('queued', 'preparing', 'ready'));
@@ -0,0 +1,3 @@
+-- Rollback: Remove product images, products keep their main image_url
+DROP INDEX IF EXISTS idx_products_image_url;
+DROP TABLE IF EXISTS product_images;
@@ -0,0 +1,20 @@
+-- Pictures of a product, resized on upload and stored next to a thumbnail. The first by sort order is
+-- mirrored to products.image_url
+CREATE TABLE IF NOT EXISTS product_images (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    thumbnail_url TEXT NOT NULL,
+    object_path TEXT NOT NULL,
+    thumbnail_path TEXT NOT NULL,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product_id ON product_images(product_id, sort_order);
+-- The orphan image cleanup looks pictures up by URL
+CREATE INDEX IF NOT EXISTS idx_product_images_url ON product_images(url);
+CREATE INDEX IF NOT EXISTS idx_product_images_thumbnail_url ON product_images(thumbnail_url);
+CREATE INDEX IF NOT EXISTS idx_products_image_url ON products(image_url);
@@ -0,0 +1,3 @@
+-- Rollback: Remove webhook endpoints and their delivery log
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,2 @@
+-- Rollback: Remove the outbox, events not yet published are lost
+DROP TABLE IF EXISTS outbox_events;
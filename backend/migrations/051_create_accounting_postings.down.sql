@@ -0,0 +1,2 @@
+-- Rollback: Remove the log of posted journals, days already posted could then be posted again
+DROP TABLE IF EXISTS accounting_postings;
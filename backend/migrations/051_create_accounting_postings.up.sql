@@ -0,0 +1,15 @@
+-- Daily sales journals posted to Accurate, Jurnal or Xero, one per store, system and day
+CREATE TABLE IF NOT EXISTS accounting_postings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    connector VARCHAR(20) NOT NULL,
+    date DATE NOT NULL,
+    reference VARCHAR(50) NOT NULL,
+    external_id VARCHAR(100) NOT NULL,
+    total_amount BIGINT NOT NULL,
+    posted_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Posting a day twice would book its sales twice
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounting_postings_day ON accounting_postings(store_id, connector, date);
@@ -0,0 +1,3 @@
+-- Rollback: Remove the settlement reconciliation runs and their items
+DROP TABLE IF EXISTS reconciliation_items;
+DROP TABLE IF EXISTS reconciliation_runs;
@@ -0,0 +1,46 @@
+-- Settlement reconciliation runs, Midtrans settlements matched against the payments of the POS
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source VARCHAR(20) NOT NULL CHECK (source IN ('midtrans', 'upload')),
+    file_name VARCHAR(255),
+    date_from TIMESTAMP NOT NULL,
+    date_to TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    matched INTEGER NOT NULL DEFAULT 0,
+    amount_mismatch INTEGER NOT NULL DEFAULT 0,
+    status_mismatch INTEGER NOT NULL DEFAULT 0,
+    missing_local INTEGER NOT NULL DEFAULT 0,
+    missing_settlement INTEGER NOT NULL DEFAULT 0,
+    settled_amount BIGINT NOT NULL DEFAULT 0,
+    fee_amount BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_by UUID NOT NULL REFERENCES users(id),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_status ON reconciliation_runs(status);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_created_at ON reconciliation_runs(created_at);
+
+CREATE TABLE IF NOT EXISTS reconciliation_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    order_id VARCHAR(255) NOT NULL,
+    payment_id UUID,
+    transaction_id UUID,
+    store_id UUID,
+    result VARCHAR(30) NOT NULL CHECK (result IN ('matched', 'amount_mismatch', 'status_mismatch', 'missing_local', 'missing_settlement')),
+    local_status VARCHAR(50),
+    local_amount BIGINT NOT NULL DEFAULT 0,
+    settlement_status VARCHAR(50),
+    settled_amount BIGINT NOT NULL DEFAULT 0,
+    fee_amount BIGINT NOT NULL DEFAULT 0,
+    external_id VARCHAR(255),
+    settled_at TIMESTAMP,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_items_run ON reconciliation_items(run_id, result);
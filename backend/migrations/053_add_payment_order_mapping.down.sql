@@ -0,0 +1,3 @@
+-- Rollback: Remove the payment order mapping and the unique order ID index
+DROP TABLE IF EXISTS payment_orders;
+DROP INDEX IF EXISTS idx_payments_order_id_unique;
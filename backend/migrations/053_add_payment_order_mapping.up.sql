@@ -0,0 +1,25 @@
+-- A gateway order ID identifies exactly one live payment. Cash, card and other payments have none.
+-- Fails on existing duplicates, find them with:
+--   SELECT order_id, COUNT(*) FROM payments WHERE order_id <> '' AND deleted_at IS NULL GROUP BY order_id HAVING COUNT(*) > 1;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_order_id_unique
+ON payments (order_id)
+WHERE order_id <> '' AND deleted_at IS NULL;
+
+-- Every order ID issued for a payment. Refreshing a QRIS issues a new order and supersedes the old
+-- one on the payment, the mapping still traces a late payment of the old QR back to its transaction.
+CREATE TABLE IF NOT EXISTS payment_orders (
+    order_id VARCHAR(255) PRIMARY KEY,
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    transaction_id UUID NOT NULL,
+    provider VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_orders_payment_id ON payment_orders (payment_id);
+
+-- Current orders of the existing payments, the superseded ones were never kept
+INSERT INTO payment_orders (order_id, payment_id, transaction_id, provider, created_at)
+SELECT order_id, id, transaction_id, provider, created_at
+FROM payments
+WHERE order_id IS NOT NULL AND order_id <> '' AND deleted_at IS NULL
+ON CONFLICT (order_id) DO NOTHING;
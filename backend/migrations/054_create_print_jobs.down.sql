@@ -0,0 +1,3 @@
+-- Rollback: Remove printers and their print jobs
+DROP TABLE IF EXISTS print_jobs;
+DROP TABLE IF EXISTS printers;
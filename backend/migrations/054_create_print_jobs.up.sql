@@ -0,0 +1,42 @@
+-- Networked thermal printers of a store. The agent next to a printer pulls its jobs with the printer's
+-- token, only the SHA-256 of the token is stored.
+CREATE TABLE IF NOT EXISTS printers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    name VARCHAR(100) NOT NULL,
+    width INTEGER NOT NULL DEFAULT 32 CHECK (width IN (32, 48)),
+    token_hash VARCHAR(64) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    last_seen_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_printers_store_id ON printers(store_id);
+CREATE INDEX IF NOT EXISTS idx_printers_deleted_at ON printers(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_printers_token_hash ON printers(token_hash);
+
+-- Receipts and QRIS slips queued for a printer. The content is rendered when the agent claims the job.
+CREATE TABLE IF NOT EXISTS print_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    printer_id UUID NOT NULL REFERENCES printers(id),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('receipt', 'qris')),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    copies INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'printing', 'printed', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    reprint_of UUID REFERENCES print_jobs(id),
+    created_by UUID NOT NULL REFERENCES users(id),
+    claimed_at TIMESTAMP,
+    printed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Agents claim the oldest waiting job of their printer
+CREATE INDEX IF NOT EXISTS idx_print_jobs_printer ON print_jobs(printer_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_store_id ON print_jobs(store_id);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_transaction_id ON print_jobs(transaction_id);
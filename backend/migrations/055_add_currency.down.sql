@@ -0,0 +1,4 @@
+-- Rollback: Remove the currency of stores, transactions and payments, every amount is rupiah again
+ALTER TABLE payments DROP COLUMN IF EXISTS currency;
+ALTER TABLE transactions DROP COLUMN IF EXISTS currency;
+ALTER TABLE stores DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,5 @@
+-- Amounts are integer counts of the minor unit of their currency. Every existing store, sale and
+-- payment is in rupiah, counted in whole rupiah.
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'IDR';
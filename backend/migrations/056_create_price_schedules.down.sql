@@ -0,0 +1,7 @@
+-- Rollback: Remove price schedules and the price sources of products, price changes and sold items
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS price_schedule_id;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS price_source;
+ALTER TABLE price_changes DROP COLUMN IF EXISTS schedule_id;
+ALTER TABLE price_changes DROP COLUMN IF EXISTS source;
+ALTER TABLE products DROP COLUMN IF EXISTS price_schedule_id;
+DROP TABLE IF EXISTS price_schedules;
@@ -0,0 +1,37 @@
+-- Scheduled product prices. A schedule with days of the week or an end is in effect on those days until
+-- the end and the product goes back to its list price in between; one without either becomes the list
+-- price once it starts.
+CREATE TABLE IF NOT EXISTS price_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID NOT NULL REFERENCES stores(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    name VARCHAR(100) NOT NULL,
+    price BIGINT NOT NULL CHECK (price >= 0),
+    days_of_week VARCHAR(20),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'in_effect', 'completed')),
+    list_price BIGINT NOT NULL DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_schedules_store_id ON price_schedules(store_id);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_product_id ON price_schedules(product_id);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_status ON price_schedules(status);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_deleted_at ON price_schedules(deleted_at);
+
+-- Schedule the current price of a product comes from, NULL for its list price
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price_schedule_id UUID REFERENCES price_schedules(id);
+
+-- What changed a price, so the price history tells bulk updates, suggested prices and schedules apart
+ALTER TABLE price_changes ADD COLUMN IF NOT EXISTS source VARCHAR(20) NOT NULL DEFAULT 'bulk';
+ALTER TABLE price_changes ADD COLUMN IF NOT EXISTS schedule_id UUID REFERENCES price_schedules(id);
+UPDATE price_changes SET source = 'suggested' WHERE reason = 'Recalculated from cost price and category markup';
+
+-- Where the unit price of each sold item came from
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS price_source VARCHAR(20) NOT NULL DEFAULT 'list';
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS price_schedule_id UUID REFERENCES price_schedules(id);
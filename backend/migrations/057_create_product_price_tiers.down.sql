@@ -0,0 +1,4 @@
+-- Rollback: Remove product quantity breaks and the breaks applied to sold items
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS tier_min_quantity;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS base_unit_price;
+DROP TABLE IF EXISTS product_price_tiers;
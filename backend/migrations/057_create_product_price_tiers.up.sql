@@ -0,0 +1,16 @@
+-- Quantity breaks of a product: from min_quantity units in a transaction on, every unit costs price
+CREATE TABLE IF NOT EXISTS product_price_tiers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    min_quantity INTEGER NOT NULL CHECK (min_quantity >= 2),
+    price BIGINT NOT NULL CHECK (price >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_price_tiers_quantity ON product_price_tiers(product_id, min_quantity);
+
+-- Unit price before quantity breaks and the break applied to each sold item. Items sold so far had no
+-- break, their unit price is the base.
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS base_unit_price BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS tier_min_quantity INTEGER NOT NULL DEFAULT 0;
+UPDATE transaction_items SET base_unit_price = unit_price;
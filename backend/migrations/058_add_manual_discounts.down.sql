@@ -0,0 +1,14 @@
+-- Rollback: Remove manual discounts from transactions and their items
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS manual_discount_approved_by;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS manual_discount_given_by;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS manual_discount_reason;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS manual_discount_amount;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS manual_discount_value;
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS manual_discount_type;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS manual_discount_approved_by;
+ALTER TABLE transactions DROP COLUMN IF EXISTS manual_discount_given_by;
+ALTER TABLE transactions DROP COLUMN IF EXISTS manual_discount_reason;
+ALTER TABLE transactions DROP COLUMN IF EXISTS manual_discount_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS manual_discount_value;
+ALTER TABLE transactions DROP COLUMN IF EXISTS manual_discount_type;
@@ -0,0 +1,15 @@
+-- Manual discounts given at the register, on the whole cart and on items. Their amounts are part of
+-- transactions.discount, the value is a percentage or an amount off (per unit on items).
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS manual_discount_type VARCHAR(20);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS manual_discount_value DECIMAL(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS manual_discount_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS manual_discount_reason VARCHAR(255);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS manual_discount_given_by UUID REFERENCES users(id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS manual_discount_approved_by UUID REFERENCES users(id);
+
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS manual_discount_type VARCHAR(20);
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS manual_discount_value DECIMAL(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS manual_discount_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS manual_discount_reason VARCHAR(255);
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS manual_discount_given_by UUID REFERENCES users(id);
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS manual_discount_approved_by UUID REFERENCES users(id);
@@ -0,0 +1,10 @@
+-- Rollback: Remove the service charge and cash rounding of stores and transactions
+ALTER TABLE transactions DROP COLUMN IF EXISTS rounding;
+ALTER TABLE transactions DROP COLUMN IF EXISTS service_charge;
+ALTER TABLE transactions DROP COLUMN IF EXISTS rounding_mode;
+ALTER TABLE transactions DROP COLUMN IF EXISTS rounding_increment;
+ALTER TABLE transactions DROP COLUMN IF EXISTS service_charge_rate;
+
+ALTER TABLE stores DROP COLUMN IF EXISTS rounding_mode;
+ALTER TABLE stores DROP COLUMN IF EXISTS rounding_increment;
+ALTER TABLE stores DROP COLUMN IF EXISTS service_charge_rate;
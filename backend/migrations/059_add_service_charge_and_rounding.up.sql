@@ -0,0 +1,11 @@
+-- Service charge and cash rounding of a store, copied onto each sale when it is created
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS service_charge_rate DECIMAL(5,2) NOT NULL DEFAULT 0;
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS rounding_increment BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS rounding_mode VARCHAR(10);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS service_charge_rate DECIMAL(5,2) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS rounding_increment BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS rounding_mode VARCHAR(10);
+-- Amounts charged: the service charge, and what rounding added to the total (negative when rounded down)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS service_charge BIGINT NOT NULL DEFAULT 0 CHECK (service_charge >= 0);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS rounding BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Rollback: Remove receipt numbers of transactions
+DROP INDEX IF EXISTS idx_transactions_receipt_no;
+ALTER TABLE transactions DROP COLUMN IF EXISTS receipt_no;
+
+DROP TABLE IF EXISTS receipt_sequences;
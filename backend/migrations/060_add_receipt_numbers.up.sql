@@ -0,0 +1,11 @@
+-- Last receipt number given by each store on each business day
+CREATE TABLE IF NOT EXISTS receipt_sequences (
+    store_id UUID NOT NULL,
+    day DATE NOT NULL,
+    last_number INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (store_id, day)
+);
+
+-- Receipt number printed on each sale. Earlier sales keep none, their receipts were printed with the ID.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS receipt_no VARCHAR(30);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_receipt_no ON transactions(store_id, receipt_no) WHERE receipt_no <> '';
@@ -0,0 +1,2 @@
+-- Rollback: Drop the payment notifications log
+DROP TABLE IF EXISTS payment_notifications;
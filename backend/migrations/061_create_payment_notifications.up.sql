@@ -0,0 +1,22 @@
+-- Gateway callbacks as received, applied from here and retried when applying them fails
+CREATE TABLE IF NOT EXISTS payment_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(20) NOT NULL,
+    order_id VARCHAR(100),
+    external_id VARCHAR(100),
+    gateway_status VARCHAR(50),
+    gross_amount BIGINT NOT NULL DEFAULT 0,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'received' CHECK (status IN ('received', 'processed', 'retrying', 'failed', 'rejected')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    processed_at TIMESTAMP,
+    replayed_by UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_notifications_order_id ON payment_notifications(order_id);
+CREATE INDEX IF NOT EXISTS idx_payment_notifications_due ON payment_notifications(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_payment_notifications_created_at ON payment_notifications(created_at);
@@ -0,0 +1,2 @@
+-- Rollback: Drop the scheduled sales reports
+DROP TABLE IF EXISTS report_schedules;
@@ -0,0 +1,23 @@
+-- Sales summaries emailed to the owners on a daily or weekly schedule
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID,
+    name VARCHAR(100) NOT NULL,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('daily', 'weekly')),
+    weekday INTEGER NOT NULL DEFAULT 1 CHECK (weekday BETWEEN 0 AND 6),
+    hour INTEGER NOT NULL DEFAULT 7 CHECK (hour BETWEEN 0 AND 23),
+    format VARCHAR(10) NOT NULL DEFAULT 'pdf' CHECK (format IN ('pdf', 'csv')),
+    recipients TEXT NOT NULL,
+    is_active BOOLEAN DEFAULT true,
+    next_run_at TIMESTAMP NOT NULL,
+    last_run_at TIMESTAMP,
+    last_error TEXT,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_store_id ON report_schedules(store_id);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_next_run_at ON report_schedules(next_run_at);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_deleted_at ON report_schedules(deleted_at);
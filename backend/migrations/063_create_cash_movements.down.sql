@@ -0,0 +1,7 @@
+-- Rollback: Drop cash drawer movements
+ALTER TABLE shifts DROP COLUMN IF EXISTS drawer_opens;
+ALTER TABLE shifts DROP COLUMN IF EXISTS cash_dropped;
+ALTER TABLE shifts DROP COLUMN IF EXISTS cash_paid_out;
+ALTER TABLE shifts DROP COLUMN IF EXISTS cash_paid_in;
+
+DROP TABLE IF EXISTS cash_movements;
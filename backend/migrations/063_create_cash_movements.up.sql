@@ -0,0 +1,18 @@
+-- Cash put into or taken out of the drawer outside sales, counted in the shift's expected cash
+CREATE TABLE IF NOT EXISTS cash_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    shift_id UUID NOT NULL REFERENCES shifts(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('drawer_open', 'pay_in', 'pay_out', 'drop')),
+    amount BIGINT NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_movements_shift_id ON cash_movements(shift_id);
+
+-- Z-report totals of the movements, frozen at closing like the sales totals
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS cash_paid_in BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS cash_paid_out BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS cash_dropped BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS drawer_opens INTEGER NOT NULL DEFAULT 0;
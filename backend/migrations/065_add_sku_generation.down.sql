@@ -0,0 +1,4 @@
+-- Rollback: Remove SKU generation
+DROP SEQUENCE IF EXISTS product_sku_seq;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS sku_prefix;
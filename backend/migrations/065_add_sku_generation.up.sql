@@ -0,0 +1,4 @@
+-- Generated SKUs: a prefix per category and a sequence number that is never handed out twice
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS sku_prefix VARCHAR(10);
+
+CREATE SEQUENCE IF NOT EXISTS product_sku_seq;
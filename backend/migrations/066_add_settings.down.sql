@@ -0,0 +1,2 @@
+-- Rollback: Drop the runtime settings
+DROP TABLE IF EXISTS settings;
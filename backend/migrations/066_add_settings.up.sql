@@ -0,0 +1,7 @@
+-- Business settings admins change at runtime, overriding the configured defaults
+CREATE TABLE IF NOT EXISTS settings (
+    key VARCHAR(50) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_by UUID,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
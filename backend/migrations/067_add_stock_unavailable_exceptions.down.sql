@@ -0,0 +1,3 @@
+-- Rollback: Remove stock unavailable exceptions, they should be resolved and deleted first
+ALTER TABLE payment_exceptions DROP CONSTRAINT IF EXISTS chk_payment_exceptions_reason;
+ALTER TABLE payment_exceptions ADD CONSTRAINT chk_payment_exceptions_reason CHECK (reason IN ('late_payment', 'duplicate_payment', 'unknown_order', 'void_refund'));
@@ -0,0 +1,4 @@
+-- Gateway payments settled for a cart whose stock ran out are queued as payment exceptions
+ALTER TABLE payment_exceptions DROP CONSTRAINT IF EXISTS payment_exceptions_reason_check;
+ALTER TABLE payment_exceptions DROP CONSTRAINT IF EXISTS chk_payment_exceptions_reason;
+ALTER TABLE payment_exceptions ADD CONSTRAINT chk_payment_exceptions_reason CHECK (reason IN ('late_payment', 'duplicate_payment', 'unknown_order', 'void_refund', 'stock_unavailable'));
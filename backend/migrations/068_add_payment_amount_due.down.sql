@@ -0,0 +1,2 @@
+-- Rollback: Remove payment amount due
+ALTER TABLE payments DROP COLUMN IF EXISTS amount_due;
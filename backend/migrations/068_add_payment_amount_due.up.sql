@@ -0,0 +1,5 @@
+-- Balance of the transaction when a payment was taken, a payment for less is a deposit that leaves the sale open
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS amount_due BIGINT NOT NULL DEFAULT 0;
+
+-- Every payment so far paid the whole transaction
+UPDATE payments SET amount_due = amount WHERE amount_due = 0;
@@ -0,0 +1,14 @@
+-- Rollback: Remove units of measure, fractional quantities are rounded to whole pieces
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS unit;
+
+ALTER TABLE refund_items
+    ALTER COLUMN quantity TYPE INTEGER USING CEIL(quantity);
+
+ALTER TABLE transaction_items
+    ALTER COLUMN quantity TYPE INTEGER USING CEIL(quantity);
+
+ALTER TABLE products
+    ALTER COLUMN stock TYPE INTEGER USING FLOOR(stock);
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_unit;
+ALTER TABLE products DROP COLUMN IF EXISTS unit;
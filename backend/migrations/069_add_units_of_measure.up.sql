@@ -0,0 +1,17 @@
+-- Unit a product is stocked and sold in, prices are per unit. Pieces only sell whole, kg and liter in fractions.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit VARCHAR(10) NOT NULL DEFAULT 'pcs';
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_unit;
+ALTER TABLE products ADD CONSTRAINT chk_products_unit CHECK (unit IN ('pcs', 'kg', 'liter'));
+
+-- Quantities and stock to the gram or millilitre
+ALTER TABLE products
+    ALTER COLUMN stock TYPE DECIMAL(12,3);
+
+ALTER TABLE transaction_items
+    ALTER COLUMN quantity TYPE DECIMAL(12,3);
+
+ALTER TABLE refund_items
+    ALTER COLUMN quantity TYPE DECIMAL(12,3);
+
+-- Unit of the product when it was sold, every sale so far was counted in pieces
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS unit VARCHAR(10) NOT NULL DEFAULT 'pcs';
@@ -0,0 +1,6 @@
+-- Rollback: Drop recipes and the cost of goods of sales, ingredients already deducted stay deducted
+DROP INDEX IF EXISTS idx_transactions_costed_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS costed_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS cost_of_goods;
+
+DROP TABLE IF EXISTS recipe_items;
@@ -0,0 +1,27 @@
+-- Ingredients of menu items: every unit of the product sold uses quantity of the ingredient, in its unit
+CREATE TABLE IF NOT EXISTS recipe_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    ingredient_id UUID NOT NULL REFERENCES products(id),
+    quantity DECIMAL(12,3) NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recipe_items_ingredient ON recipe_items(product_id, ingredient_id);
+CREATE INDEX IF NOT EXISTS idx_recipe_items_ingredient_id ON recipe_items(ingredient_id);
+
+-- Cost of goods of a sale, recorded once it is paid together with the deduction of recipe ingredients
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS cost_of_goods BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS costed_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_transactions_costed_at ON transactions(costed_at);
+
+-- Sales settled so far are costed at the cost price they were sold at, there were no recipes to deduct
+UPDATE transactions t
+SET cost_of_goods = COALESCE((
+        SELECT ROUND(SUM(ti.unit_cost * ti.quantity))::BIGINT
+        FROM transaction_items ti
+        WHERE ti.transaction_id = t.id AND ti.deleted_at IS NULL
+    ), 0),
+    costed_at = NOW()
+WHERE t.status IN ('paid', 'partially_refunded', 'refunded')
+  AND t.costed_at IS NULL;
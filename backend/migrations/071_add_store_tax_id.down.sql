@@ -0,0 +1,2 @@
+-- Rollback: Remove the NPWP of stores
+ALTER TABLE stores DROP COLUMN IF EXISTS tax_id;
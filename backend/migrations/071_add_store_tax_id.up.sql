@@ -0,0 +1,2 @@
+-- NPWP of an outlet, printed on its receipts instead of the configured STORE_TAX_ID
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS tax_id VARCHAR(25);
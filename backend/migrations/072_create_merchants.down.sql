@@ -0,0 +1,2 @@
+-- Rollback: Drop the merchant profile
+DROP TABLE IF EXISTS merchants;
@@ -0,0 +1,19 @@
+-- Business profile of the merchant, replacing the STORE_* configuration on receipts and reports and
+-- the MIDTRANS_* account on payments once it is set
+CREATE TABLE IF NOT EXISTS merchants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    legal_name VARCHAR(255),
+    address TEXT,
+    city VARCHAR(100),
+    postal_code VARCHAR(10),
+    phone VARCHAR(20),
+    email VARCHAR(255),
+    tax_id VARCHAR(25),
+    logo_url TEXT,
+    midtrans_server_key VARCHAR(255),
+    midtrans_client_key VARCHAR(255),
+    midtrans_environment VARCHAR(20) NOT NULL DEFAULT 'sandbox',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
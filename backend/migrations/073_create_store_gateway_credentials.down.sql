@@ -0,0 +1,2 @@
+-- Rollback: Drop the Midtrans accounts of stores
+DROP TABLE IF EXISTS store_gateway_credentials;
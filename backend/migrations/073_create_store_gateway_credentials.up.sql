@@ -0,0 +1,18 @@
+-- Midtrans accounts of stores that settle into an account of their own, the server key is encrypted
+-- with SECRETS_ENCRYPTION_KEY
+CREATE TABLE IF NOT EXISTS store_gateway_credentials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    server_key TEXT NOT NULL,
+    client_key VARCHAR(255),
+    environment VARCHAR(20) NOT NULL DEFAULT 'sandbox',
+    updated_by UUID,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gateway_credentials_store_provider ON store_gateway_credentials(store_id, provider);
+
+-- The merchant's server key is encrypted too, which no longer fits the column
+ALTER TABLE merchants ALTER COLUMN midtrans_server_key TYPE TEXT;
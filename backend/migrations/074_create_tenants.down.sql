@@ -0,0 +1,35 @@
+-- Rollback: Drop the tenants of SaaS mode, store codes, category names and customer contacts are
+-- unique globally again
+DROP INDEX IF EXISTS idx_customers_tenant_email;
+DROP INDEX IF EXISTS idx_customers_tenant_phone;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone ON customers(phone) WHERE phone IS NOT NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_email ON customers(email) WHERE email IS NOT NULL AND deleted_at IS NULL;
+
+DROP INDEX IF EXISTS idx_categories_tenant_name;
+ALTER TABLE categories ADD CONSTRAINT categories_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS idx_stores_tenant_code;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stores_code ON stores(code);
+
+ALTER TABLE void_requests DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE price_schedules DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE exports DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE report_schedules DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE print_jobs DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE printers DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE tables DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE shifts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE tax_rules DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE promotions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE products DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE stores DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,86 @@
+-- Tenants of SaaS mode, each a merchant whose rows carry its ID in tenant_id. Rows created outside
+-- SaaS mode keep an empty tenant_id.
+CREATE TABLE IF NOT EXISTS tenants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug VARCHAR(63) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenants_slug ON tenants(slug);
+
+ALTER TABLE stores ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_stores_tenant_id ON stores(tenant_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users(tenant_id);
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_categories_tenant_id ON categories(tenant_id);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_products_tenant_id ON products(tenant_id);
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_id ON customers(tenant_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_tenant_id ON transactions(tenant_id);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_payments_tenant_id ON payments(tenant_id);
+
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_promotions_tenant_id ON promotions(tenant_id);
+
+ALTER TABLE tax_rules ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_tax_rules_tenant_id ON tax_rules(tenant_id);
+
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_shifts_tenant_id ON shifts(tenant_id);
+
+ALTER TABLE tables ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_tables_tenant_id ON tables(tenant_id);
+
+ALTER TABLE printers ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_printers_tenant_id ON printers(tenant_id);
+
+ALTER TABLE print_jobs ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_tenant_id ON print_jobs(tenant_id);
+
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_tenant_id ON webhook_endpoints(tenant_id);
+
+ALTER TABLE report_schedules ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_report_schedules_tenant_id ON report_schedules(tenant_id);
+
+ALTER TABLE exports ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_exports_tenant_id ON exports(tenant_id);
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_id ON audit_logs(tenant_id);
+
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_tenant_id ON outbox_events(tenant_id);
+
+ALTER TABLE price_schedules ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_price_schedules_tenant_id ON price_schedules(tenant_id);
+
+ALTER TABLE void_requests ADD COLUMN IF NOT EXISTS tenant_id UUID REFERENCES tenants(id);
+CREATE INDEX IF NOT EXISTS idx_void_requests_tenant_id ON void_requests(tenant_id);
+
+-- Store codes, category names and customer contacts are unique within a tenant instead of globally
+DROP INDEX IF EXISTS idx_stores_code;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stores_tenant_code ON stores(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), code);
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
+DROP INDEX IF EXISTS idx_categories_name;
+CREATE INDEX IF NOT EXISTS idx_categories_name ON categories(name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_tenant_name ON categories(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), name);
+
+DROP INDEX IF EXISTS idx_customers_phone;
+DROP INDEX IF EXISTS idx_customers_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_phone ON customers(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), phone) WHERE phone IS NOT NULL AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_email ON customers(COALESCE(tenant_id, '00000000-0000-0000-0000-000000000000'), email) WHERE email IS NOT NULL AND deleted_at IS NULL;
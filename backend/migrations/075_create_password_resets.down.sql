@@ -0,0 +1,2 @@
+-- Rollback: Remove the forgot password requests
+DROP TABLE IF EXISTS password_resets;
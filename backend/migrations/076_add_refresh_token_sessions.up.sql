@@ -0,0 +1,13 @@
+-- Sessions: the refresh tokens rotated from one login share the session ID of the first, and record the
+-- device and the access token issued along with them so a session can be listed and revoked
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS session_id UUID;
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS access_token_id VARCHAR(64);
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS user_agent VARCHAR(255);
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45);
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS logged_in_at TIMESTAMP;
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP;
+
+-- Tokens issued before sessions are a session of their own
+UPDATE refresh_tokens SET session_id = id, logged_in_at = created_at WHERE session_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens(session_id);
@@ -0,0 +1,4 @@
+-- Rollback: Drop the payment events
+DROP TRIGGER IF EXISTS payment_events_append_only ON payment_events;
+DROP FUNCTION IF EXISTS reject_payment_event_changes();
+DROP TABLE IF EXISTS payment_events;
@@ -0,0 +1,37 @@
+-- Payment events: every status change of a payment, from its creation on, with who made it and why.
+-- Events are append only, the trigger refuses to change or delete them.
+CREATE TABLE IF NOT EXISTS payment_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID REFERENCES tenants(id),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    from_status VARCHAR(50),
+    to_status VARCHAR(50) NOT NULL,
+    actor_type VARCHAR(20) NOT NULL,
+    actor_id VARCHAR(100),
+    reason TEXT,
+    gateway_payload TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_events_tenant_id ON payment_events(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_payment_events_payment_id ON payment_events(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_events_created_at ON payment_events(created_at);
+
+-- Payments made before the history start it in their current status. Training payments keep no history,
+-- they are purged with their transactions.
+INSERT INTO payment_events (tenant_id, payment_id, to_status, actor_type, actor_id, reason, created_at)
+SELECT p.tenant_id, p.id, p.status, 'system', 'migration', 'recorded before payment history', p.updated_at
+FROM payments p
+WHERE NOT COALESCE(p.is_training, false)
+  AND NOT EXISTS (SELECT 1 FROM payment_events e WHERE e.payment_id = p.id);
+
+CREATE OR REPLACE FUNCTION reject_payment_event_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'payment events are append only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS payment_events_append_only ON payment_events;
+CREATE TRIGGER payment_events_append_only BEFORE UPDATE OR DELETE ON payment_events
+    FOR EACH ROW EXECUTE FUNCTION reject_payment_event_changes();
@@ -0,0 +1,2 @@
+-- Rollback: Remove the stock shortfall of transaction items
+ALTER TABLE transaction_items DROP COLUMN IF EXISTS stock_shortfall;
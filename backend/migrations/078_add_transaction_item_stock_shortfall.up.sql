@@ -0,0 +1,3 @@
+-- Quantity an offline sale sold while the product was out of stock. It was never deducted, so voids and
+-- refunds leave it out of what they return to stock
+ALTER TABLE transaction_items ADD COLUMN IF NOT EXISTS stock_shortfall DECIMAL(12,3) NOT NULL DEFAULT 0;
@@ -7,46 +7,67 @@ import (
 	"qris-pos-backend/internal/domain/entities"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
-	UserID string           `json:"user_id"`
-	Email  string           `json:"email"`
-	Role   entities.UserRole `json:"role"`
+	UserID    string            `json:"user_id"`
+	Email     string            `json:"email"`
+	Role      entities.UserRole `json:"role"`
+	StoreID   string            `json:"store_id,omitempty"`  // Store a cashier works in, empty for admins overseeing every store
+	TenantID  string            `json:"tenant_id,omitempty"` // Tenant of the user in SaaS mode, empty for platform admins
+	SessionID string            `json:"sid,omitempty"`       // Session of the refresh token issued along with it
 	jwt.RegisteredClaims
 }
 
 type JWTService struct {
 	secretKey []byte
 	expiry    time.Duration
+	issuer    string
+	audience  string
+	leeway    time.Duration
 }
 
-func NewJWTService(secretKey string, expiryHours int) *JWTService {
+func NewJWTService(secretKey string, expiryHours int, issuer, audience string, leewaySeconds int) *JWTService {
 	return &JWTService{
 		secretKey: []byte(secretKey),
 		expiry:    time.Duration(expiryHours) * time.Hour,
+		issuer:    issuer,
+		audience:  audience,
+		leeway:    time.Duration(leewaySeconds) * time.Second,
 	}
 }
 
-func (j *JWTService) GenerateToken(user *entities.User) (string, error) {
+// GenerateToken signs an access token for a session of the user and returns it with its jti
+func (j *JWTService) GenerateToken(user *entities.User, sessionID string) (string, string, error) {
 	now := time.Now()
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(), // Lets the token be revoked on logout
 			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(j.expiry)),
 			Subject:   user.ID,
+			Issuer:    j.issuer,
+			Audience:  jwt.ClaimStrings{j.audience},
 		},
 	}
+	if user.StoreID != nil {
+		claims.StoreID = *user.StoreID
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	return token, claims.ID, err
 }
 
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+	// Time based claims are checked below so clock skew between servers can be tolerated
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
@@ -62,26 +83,36 @@ func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.New("invalid token")
 	}
 
+	if err := j.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
 
-func (j *JWTService) RefreshToken(tokenString string) (string, error) {
-	claims, err := j.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
-	}
+func (j *JWTService) validateClaims(claims *Claims) error {
+	now := time.Now()
 
-	// Check if token is close to expiry (within 1 hour)
-	if time.Until(claims.ExpiresAt.Time) > time.Hour {
-		return tokenString, nil // Token still has time, return the same token
+	if !claims.VerifyExpiresAt(now.Add(-j.leeway), true) {
+		return errors.New("token is expired")
 	}
-
-	// Generate new token
-	user := &entities.User{
-		ID:    claims.UserID,
-		Email: claims.Email,
-		Role:  claims.Role,
+	if !claims.VerifyIssuedAt(now.Add(j.leeway), true) {
+		return errors.New("token used before issued")
+	}
+	if !claims.VerifyNotBefore(now.Add(j.leeway), false) {
+		return errors.New("token is not valid yet")
+	}
+	if !claims.VerifyIssuer(j.issuer, true) {
+		return errors.New("token has invalid issuer")
 	}
+	if !claims.VerifyAudience(j.audience, true) {
+		return errors.New("token has invalid audience")
+	}
+
+	return nil
+}
 
-	return j.GenerateToken(user)
+// Expiry is how long an access token is valid for
+func (j *JWTService) Expiry() time.Duration {
+	return j.expiry
 }
\ No newline at end of file
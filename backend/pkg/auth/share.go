@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	appErrors "qris-pos-backend/pkg/errors"
+)
+
+// ShareTokenService signs tokens granting read-only access to a single transaction until they expire.
+// Nothing is stored, so a token can't be revoked before its expiry short of rotating the secret.
+type ShareTokenService struct {
+	secretKey []byte
+}
+
+func NewShareTokenService(secretKey string) *ShareTokenService {
+	return &ShareTokenService{secretKey: []byte(secretKey)}
+}
+
+// GenerateToken signs the transaction ID and expiry into a URL-safe token
+func (s *ShareTokenService) GenerateToken(transactionID string, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(transactionID + "." + strconv.FormatInt(expiresAt.Unix(), 10)))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+// ValidateToken returns the transaction a token was issued for, ErrInvalidToken when it was tampered
+// with and ErrTokenExpired once it is past its expiry
+func (s *ShareTokenService) ValidateToken(token string) (string, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", appErrors.ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(payload)) {
+		return "", appErrors.ErrInvalidToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", appErrors.ErrInvalidToken
+	}
+	transactionID, expires, ok := strings.Cut(string(decoded), ".")
+	if !ok || transactionID == "" {
+		return "", appErrors.ErrInvalidToken
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", appErrors.ErrInvalidToken
+	}
+	if time.Now().Unix() > expiresAt {
+		return "", appErrors.ErrTokenExpired
+	}
+	return transactionID, nil
+}
+
+func (s *ShareTokenService) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secretKey)
+	mac.Write([]byte("share\n" + payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,167 @@
+// Package emvco encodes and decodes EMVCo merchant-presented QR payloads, the format QRIS is built on.
+//
+// A payload is a flat list of TLV fields: a 2 digit ID, a 2 digit length and the value.
+// Template fields such as merchant account information (26-51) and additional data (62)
+// hold nested TLV fields in their value. The payload always ends with a CRC field (63).
+package emvco
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Root field IDs
+const (
+	IDPayloadFormat        = "00"
+	IDPointOfInitiation    = "01"
+	IDMerchantCategoryCode = "52"
+	IDCurrency             = "53"
+	IDAmount               = "54"
+	IDTipIndicator         = "55"
+	IDTipFixed             = "56"
+	IDTipPercentage        = "57"
+	IDCountryCode          = "58"
+	IDMerchantName         = "59"
+	IDMerchantCity         = "60"
+	IDPostalCode           = "61"
+	IDAdditionalData       = "62"
+	IDCRC                  = "63"
+)
+
+// Point of initiation values
+const (
+	PointOfInitiationStatic  = "11" // Reusable, the payer enters the amount
+	PointOfInitiationDynamic = "12" // Single use, the amount is part of the payload
+)
+
+const maxValueLength = 99
+
+var (
+	ErrMalformed   = errors.New("malformed EMVCo payload")
+	ErrCRCMismatch = errors.New("EMVCo payload CRC mismatch")
+)
+
+// Field is a single TLV field
+type Field struct {
+	ID    string
+	Value string
+}
+
+// Payload is a list of fields in encoding order
+type Payload []Field
+
+// Parse decodes a payload, or the value of a template field. A trailing CRC field is verified.
+func Parse(data string) (Payload, error) {
+	var payload Payload
+	for pos := 0; pos < len(data); {
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("%w: truncated field header at %d", ErrMalformed, pos)
+		}
+		id := data[pos : pos+2]
+		length, err := strconv.Atoi(data[pos+2 : pos+4])
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("%w: invalid length of field %s", ErrMalformed, id)
+		}
+		end := pos + 4 + length
+		if end > len(data) {
+			return nil, fmt.Errorf("%w: field %s overruns the payload", ErrMalformed, id)
+		}
+
+		if id == IDCRC {
+			if end != len(data) {
+				return nil, fmt.Errorf("%w: CRC is not the last field", ErrMalformed)
+			}
+			if expected := CRC16(data[:pos+4]); !strings.EqualFold(data[pos+4:end], expected) {
+				return nil, ErrCRCMismatch
+			}
+		}
+
+		payload = append(payload, Field{ID: id, Value: data[pos+4 : end]})
+		pos = end
+	}
+	return payload, nil
+}
+
+// Get returns the value of a field
+func (p Payload) Get(id string) (string, bool) {
+	for _, field := range p {
+		if field.ID == id {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set returns a copy of the payload with the field replaced, or inserted in ID order
+func (p Payload) Set(id, value string) Payload {
+	result := append(p.Remove(id), Field{ID: id, Value: value})
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Remove returns a copy of the payload without the given fields
+func (p Payload) Remove(ids ...string) Payload {
+	result := make(Payload, 0, len(p))
+	for _, field := range p {
+		removed := false
+		for _, id := range ids {
+			if field.ID == id {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// EncodeFields encodes the fields without a CRC, as used for the value of template fields
+func (p Payload) EncodeFields() (string, error) {
+	var b strings.Builder
+	for _, field := range p {
+		if field.ID == IDCRC {
+			continue
+		}
+		if len(field.ID) != 2 {
+			return "", fmt.Errorf("%w: invalid field ID %q", ErrMalformed, field.ID)
+		}
+		if len(field.Value) > maxValueLength {
+			return "", fmt.Errorf("%w: field %s is longer than %d characters", ErrMalformed, field.ID, maxValueLength)
+		}
+		fmt.Fprintf(&b, "%s%02d%s", field.ID, len(field.Value), field.Value)
+	}
+	return b.String(), nil
+}
+
+// Encode encodes the payload and appends its CRC
+func (p Payload) Encode() (string, error) {
+	data, err := p.EncodeFields()
+	if err != nil {
+		return "", err
+	}
+	data += IDCRC + "04"
+	return data + CRC16(data), nil
+}
+
+// CRC16 computes the CRC-16/CCITT-FALSE checksum EMVCo requires, as 4 uppercase hex digits
+func CRC16(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
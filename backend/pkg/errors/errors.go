@@ -5,6 +5,8 @@ import (
 	"fmt"
 )
 
+// The messages are returned to clients translated by pkg/i18n, whose catalogs are keyed by the English
+// text. A message changed here needs its translations changed too.
 var (
 	// Authentication errors
 	ErrInvalidCredentials = errors.New("invalid credentials")
@@ -12,6 +14,12 @@ var (
 	ErrEmailExists        = errors.New("email already exists")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrTokenExpired       = errors.New("token expired")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+
+	// Password reset errors
+	ErrInvalidResetCode         = errors.New("invalid or expired reset code")
+	ErrPasswordResetUnavailable = errors.New("password reset by email is not available")
+	ErrSessionNotFound          = errors.New("session not found")
 
 	// Authorization errors
 	ErrUnauthorized   = errors.New("unauthorized")
@@ -24,20 +32,132 @@ var (
 	ErrInvalidFormat   = errors.New("invalid format")
 
 	// Product errors
-	ErrProductNotFound    = errors.New("product not found")
-	ErrInsufficientStock  = errors.New("insufficient stock")
-	ErrSKUExists          = errors.New("SKU already exists")
+	ErrProductNotFound       = errors.New("product not found")
+	ErrInsufficientStock     = errors.New("insufficient stock")
+	ErrSKUExists             = errors.New("SKU already exists")
+	ErrBarcodeExists         = errors.New("barcode already exists")
+	ErrProductImageNotFound  = errors.New("product image not found")
+	ErrTooManyImages         = errors.New("product has reached the image limit")
+	ErrInvalidImage          = errors.New("invalid image")
+	ErrPriceScheduleNotFound = errors.New("price schedule not found")
+
+	// Category errors
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryMergeSelf   = errors.New("a category cannot be merged into itself")
+	ErrInvalidImportFile   = errors.New("invalid import file")
+	ErrCategoryCycle       = errors.New("a category cannot be moved under itself or one of its subcategories")
+	ErrCategoryHasProducts = errors.New("category still has products, reassign them to another category first")
+	ErrCategoryExists      = errors.New("a category with this name already exists")
 
 	// Transaction errors
 	ErrTransactionNotFound = errors.New("transaction not found")
 	ErrEmptyCart           = errors.New("cart is empty")
 	ErrTransactionExpired  = errors.New("transaction expired")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderItemNotFound   = errors.New("order item not found")
+	ErrTransactionHeld     = errors.New("transaction is on hold, resume it before checkout")
+	ErrDiscountTooLarge    = errors.New("discount is above the highest allowed for your role")
+
+	// Table errors
+	ErrTableNotFound = errors.New("table not found")
+	ErrTableExists   = errors.New("a table with this name already exists")
+	ErrTableOccupied = errors.New("table already has an open order")
+	ErrTableInactive = errors.New("table is not in service")
+
+	// Shift errors
+	ErrShiftNotFound    = errors.New("shift not found")
+	ErrNoOpenShift      = errors.New("no open shift, open a shift before selling")
+	ErrShiftAlreadyOpen = errors.New("a shift is already open for this user")
+	ErrShiftNotPending  = errors.New("shift is not awaiting variance approval")
 
 	// Payment errors
-	ErrPaymentFailed   = errors.New("payment failed")
-	ErrPaymentExpired  = errors.New("payment expired")
-	ErrQRISExpired     = errors.New("QRIS code expired")
-	ErrPaymentNotFound = errors.New("payment not found")
+	ErrPaymentFailed            = errors.New("payment failed")
+	ErrPaymentExpired           = errors.New("payment expired")
+	ErrQRISExpired              = errors.New("QRIS code expired")
+	ErrPaymentNotFound          = errors.New("payment not found")
+	ErrAmountOutOfRange         = errors.New("amount out of range for payment method")
+	ErrPaymentExceptionNotFound = errors.New("payment exception not found")
+	ErrNotificationNotFound     = errors.New("payment notification not found")
+	ErrNotificationRejected     = errors.New("payment notification was rejected by the gateway checks and cannot be replayed")
+	ErrAlreadyPaid              = errors.New("transaction already paid")
+	ErrNotLocalQRIS             = errors.New("payment is not a locally generated QRIS")
+	ErrUnsupportedCurrency      = errors.New("payment method does not accept the currency of the transaction")
+	ErrEWalletUnavailable       = errors.New("e-wallet payments are not supported by the payment gateway")
+	ErrGatewayUnavailable       = errors.New("payment gateway is unavailable")
+	ErrDuplicatePayment         = errors.New("transaction already has a pending payment")
+	ErrQRISQueueFull            = errors.New("QRIS generation queue is full, try again shortly")
+	ErrQRISJobNotFound          = errors.New("QRIS generation job not found")
+	ErrAmountExceedsDue         = errors.New("amount is more than the balance due on the transaction")
+	ErrIllegalPaymentTransition = errors.New("payment status cannot change that way")
+
+	// Promotion errors
+	ErrPromotionNotFound      = errors.New("promotion not found")
+	ErrPromoCodeInvalid       = errors.New("promo code is invalid or no longer active")
+	ErrPromoCodeUsedUp        = errors.New("promo code has reached its usage limit")
+	ErrPromoCodeNotApplicable = errors.New("promo code does not apply to this transaction")
+	ErrPromoCodeAlreadyExists = errors.New("promo code is already used by another promotion")
+
+	// Tax errors
+	ErrTaxRuleNotFound = errors.New("tax rule not found")
+
+	// Customer errors
+	ErrCustomerNotFound   = errors.New("customer not found")
+	ErrCustomerExists     = errors.New("a customer with this phone number or email already exists")
+	ErrInsufficientPoints = errors.New("customer does not have enough points")
+	ErrTrainingPoints     = errors.New("points cannot be redeemed on a training sale")
+
+	// Store errors
+	ErrStoreNotFound  = errors.New("store not found")
+	ErrStoreExists    = errors.New("a store with this code already exists")
+	ErrStoreRequired  = errors.New("no store selected, pick a store to work in")
+	ErrStoreForbidden = errors.New("user cannot work in this store")
+
+	// Merchant errors
+	ErrMerchantNotFound  = errors.New("merchant is not onboarded yet")
+	ErrMerchantOnboarded = errors.New("merchant is already onboarded")
+
+	// Tenant errors
+	ErrTenantNotFound  = errors.New("tenant not found")
+	ErrTenantExists    = errors.New("a tenant with this slug already exists")
+	ErrTenantSuspended = errors.New("tenant is suspended")
+	ErrTenantForbidden = errors.New("tenants are managed from the platform, not from inside a tenant")
+
+	// Void errors
+	ErrVoidRequestNotFound = errors.New("void request not found")
+	ErrVoidRequestDecided  = errors.New("void request is already decided")
+	ErrVoidPending         = errors.New("a void is already awaiting approval for this transaction")
+	ErrApprovalRequired    = errors.New("manager approval is required, enter the credentials of an admin")
+
+	// Export errors
+	ErrExportNotFound       = errors.New("export not found")
+	ErrExportAlreadyClaimed = errors.New("export is already being built")
+
+	// Alert errors
+	ErrAlertNotFound = errors.New("alert not found")
+
+	// Webhook errors
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// Accounting errors
+	ErrAccountingConnectorNotConfigured = errors.New("accounting connector is not configured")
+
+	// Report schedule errors
+	ErrReportScheduleNotFound = errors.New("report schedule not found")
+	ErrEmailNotConfigured     = errors.New("email is not configured, set the SMTP settings to send reports")
+
+	// Reconciliation errors
+	ErrReconciliationNotFound       = errors.New("reconciliation run not found")
+	ErrReconciliationAlreadyClaimed = errors.New("reconciliation run is already being processed")
+	ErrInvalidSettlementReport      = errors.New("invalid settlement report")
+
+	// Print errors
+	ErrPrinterNotFound     = errors.New("printer not found")
+	ErrPrinterInactive     = errors.New("printer is not in service")
+	ErrInvalidPrinterToken = errors.New("invalid printer token")
+	ErrPrintJobNotFound    = errors.New("print job not found")
+	ErrPrintJobNotClaimed  = errors.New("print job is not being printed by this printer")
+	ErrPrintJobNotFinished = errors.New("print job is still queued or printing")
 )
 
 type AppError struct {
@@ -64,4 +184,61 @@ func NewValidationError(field, message string) *AppError {
 		Message: fmt.Sprintf("Validation failed for field '%s': %s", field, message),
 		Details: map[string]string{"field": field, "error": message},
 	}
-}
\ No newline at end of file
+}
+
+// AmountLimitError reports an amount outside the range a payment method accepts
+type AmountLimitError struct {
+	Method string
+	Amount int64
+	Min    int64
+	Max    int64
+}
+
+func (e *AmountLimitError) Error() string {
+	if e.Amount < e.Min {
+		return fmt.Sprintf("amount %d is below the minimum %d for %s payments", e.Amount, e.Min, e.Method)
+	}
+	return fmt.Sprintf("amount %d exceeds the maximum %d for %s payments", e.Amount, e.Max, e.Method)
+}
+
+func (e *AmountLimitError) Is(target error) bool {
+	return target == ErrAmountOutOfRange
+}
+
+// AboveMaximum reports whether the amount exceeded the cap, meaning another payment method is required
+func (e *AmountLimitError) AboveMaximum() bool {
+	return e.Amount > e.Max
+}
+
+// GatewayUnavailableError reports a payment gateway that could not issue a payment, the request can be retried later
+type GatewayUnavailableError struct {
+	Provider string
+	Err      error
+}
+
+func (e *GatewayUnavailableError) Error() string {
+	return fmt.Sprintf("payment gateway %s is unavailable: %v", e.Provider, e.Err)
+}
+
+func (e *GatewayUnavailableError) Is(target error) bool {
+	return target == ErrGatewayUnavailable
+}
+
+func (e *GatewayUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// DuplicatePaymentError reports a transaction that already has a pending payment. Payment is the
+// existing payment as returned to clients, so they can show it instead of issuing another one.
+type DuplicatePaymentError struct {
+	TransactionID string
+	Payment       any
+}
+
+func (e *DuplicatePaymentError) Error() string {
+	return fmt.Sprintf("transaction %s already has a pending payment", e.TransactionID)
+}
+
+func (e *DuplicatePaymentError) Is(target error) bool {
+	return target == ErrDuplicatePayment
+}
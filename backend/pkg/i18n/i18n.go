@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Messages are written in English in the code and the English text is the key of their translation.
+// A message missing from the catalog of a language is returned in English.
+
+// Language is a language messages can be returned in
+type Language string
+
+const (
+	English    Language = "en"
+	Indonesian Language = "id"
+)
+
+// Default is the language of clients that ask for none of the supported ones
+const Default = English
+
+var (
+	supported = []Language{English, Indonesian}
+	matcher   = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+)
+
+var catalogs = map[Language]map[string]string{
+	Indonesian: indonesian,
+}
+
+// Negotiate picks the supported language an Accept-Language header prefers, Default when it names none
+func Negotiate(acceptLanguage string) Language {
+	if acceptLanguage == "" {
+		return Default
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return supported[index]
+}
+
+// Translate returns a message in the language. A message wrapping others, like "invalid input:
+// quantity must be above 0", is translated part by part when it isn't in the catalog as a whole, parts
+// missing from it are kept as they are.
+func Translate(lang Language, message string) string {
+	catalog, ok := catalogs[lang]
+	if !ok || message == "" {
+		return message
+	}
+	if translated, ok := catalog[message]; ok {
+		return translated
+	}
+
+	parts := strings.Split(message, ": ")
+	if len(parts) == 1 {
+		return message
+	}
+	for i, part := range parts {
+		if translated, ok := catalog[part]; ok {
+			parts[i] = translated
+		}
+	}
+	return strings.Join(parts, ": ")
+}
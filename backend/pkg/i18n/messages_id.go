@@ -0,0 +1,284 @@
+package i18n
+
+// indonesian translates the messages returned to clients: the errors of pkg/errors, the checks of the
+// domain entities, the validator messages and the fixed messages of the handlers and middleware
+var indonesian = map[string]string{
+	// Authentication and authorization
+	"invalid credentials":                              "kredensial tidak valid",
+	"user not found":                                   "pengguna tidak ditemukan",
+	"email already exists":                             "email sudah terdaftar",
+	"invalid token":                                    "token tidak valid",
+	"token expired":                                    "token sudah kedaluwarsa",
+	"token has been revoked":                           "token sudah dicabut",
+	"invalid or expired reset code":                    "kode reset tidak valid atau sudah kedaluwarsa",
+	"password reset by email is not available":         "reset kata sandi melalui email tidak tersedia",
+	"session not found":                                "sesi tidak ditemukan",
+	"unauthorized":                                     "tidak terautentikasi",
+	"forbidden":                                        "akses ditolak",
+	"invalid role":                                     "peran tidak valid",
+	"Authorization header is required":                 "Header Authorization wajib diisi",
+	"Invalid authorization header format":              "Format header Authorization tidak valid",
+	"Invalid or expired token":                         "Token tidak valid atau sudah kedaluwarsa",
+	"Invalid token":                                    "Token tidak valid",
+	"Token has been revoked, please login again":       "Token sudah dicabut, silakan login kembali",
+	"Refresh token has expired, please login again":    "Refresh token sudah kedaluwarsa, silakan login kembali",
+	"User not authenticated":                           "Pengguna belum terautentikasi",
+	"User not found":                                   "Pengguna tidak ditemukan",
+	"User role not found":                              "Peran pengguna tidak ditemukan",
+	"Invalid user role":                                "Peran pengguna tidak valid",
+	"Insufficient permissions":                         "Hak akses tidak mencukupi",
+	"Email already exists":                             "Email sudah terdaftar",
+	"New password must be different from old password": "Kata sandi baru harus berbeda dari kata sandi lama",
+	"Address is not allowed":                           "Alamat tidak diizinkan",
+
+	// Requests and validation
+	"invalid input":                               "input tidak valid",
+	"required field missing":                      "kolom wajib belum diisi",
+	"invalid format":                              "format tidak valid",
+	"Invalid request format":                      "Format permintaan tidak valid",
+	"Invalid query parameters":                    "Parameter kueri tidak valid",
+	"Validation failed":                           "Validasi gagal",
+	"Transaction ID is required":                  "ID transaksi wajib diisi",
+	"No file provided or invalid file":            "Berkas tidak dikirim atau tidak valid",
+	"Invalid file":                                "Berkas tidak valid",
+	"Upload a .csv or .xlsx file":                 "Unggah berkas .csv atau .xlsx",
+	"Format must be csv or xlsx":                  "Format harus csv atau xlsx",
+	"Too many requests, please try again shortly": "Terlalu banyak permintaan, silakan coba lagi sebentar lagi",
+	"Server is busy, please try again shortly":    "Server sedang sibuk, silakan coba lagi sebentar lagi",
+	"Request timed out, please try again":         "Waktu permintaan habis, silakan coba lagi",
+
+	// Validator messages, the first %s is the field and the second the parameter of the check
+	"%s is required":                                                 "%s wajib diisi",
+	"Invalid email format":                                           "Format email tidak valid",
+	"%s must be at least %s characters long":                         "%s minimal %s karakter",
+	"%s must be at most %s characters long":                          "%s maksimal %s karakter",
+	"%s must be greater than or equal to %s":                         "%s harus lebih besar dari atau sama dengan %s",
+	"%s must be less than or equal to %s":                            "%s harus lebih kecil dari atau sama dengan %s",
+	"%s must be greater than %s":                                     "%s harus lebih besar dari %s",
+	"%s must be one of: %s":                                          "%s harus salah satu dari: %s",
+	"%s must be a valid UUID":                                        "%s harus berupa UUID yang valid",
+	"%s must be a date in the format %s":                             "%s harus berupa tanggal dengan format %s",
+	"%s must be an Indonesian mobile number starting with +62 or 08": "%s harus berupa nomor ponsel Indonesia yang diawali +62 atau 08",
+	"%s must be an NPWP of 15 or 16 digits":                          "%s harus berupa NPWP 15 atau 16 digit",
+	"%s must be an amount from 0 to %s rupiah":                       "%s harus berupa jumlah dari 0 sampai %s rupiah",
+	"%s is not valid":                                                "%s tidak valid",
+
+	// Products and categories
+	"product not found":                       "produk tidak ditemukan",
+	"insufficient stock":                      "stok tidak mencukupi",
+	"SKU already exists":                      "SKU sudah digunakan",
+	"barcode already exists":                  "barcode sudah digunakan",
+	"product image not found":                 "gambar produk tidak ditemukan",
+	"product has reached the image limit":     "produk sudah mencapai batas jumlah gambar",
+	"invalid image":                           "gambar tidak valid",
+	"price schedule not found":                "jadwal harga tidak ditemukan",
+	"category not found":                      "kategori tidak ditemukan",
+	"a category cannot be merged into itself": "kategori tidak dapat digabungkan ke dirinya sendiri",
+	"invalid import file":                     "berkas impor tidak valid",
+	"a category cannot be moved under itself or one of its subcategories":  "kategori tidak dapat dipindahkan ke bawah dirinya sendiri atau salah satu subkategorinya",
+	"category still has products, reassign them to another category first": "kategori masih memiliki produk, pindahkan ke kategori lain terlebih dahulu",
+	"a category with this name already exists":                             "kategori dengan nama ini sudah ada",
+	"Only admins can list deleted products":                                "Hanya admin yang dapat melihat produk yang dihapus",
+	"Only admins can list deleted categories":                              "Hanya admin yang dapat melihat kategori yang dihapus",
+	"product cannot be nil":                                                "produk wajib diisi",
+	"product is not available":                                             "produk tidak tersedia",
+	"product is sold by another store":                                     "produk dijual oleh toko lain",
+	"product name cannot be empty":                                         "nama produk wajib diisi",
+	"product price cannot be negative":                                     "harga produk tidak boleh negatif",
+	"product stock cannot be negative":                                     "stok produk tidak boleh negatif",
+	"quantity must be above 0":                                             "jumlah harus lebih dari 0",
+	"quantity cannot have more than 3 decimals":                            "jumlah tidak boleh lebih dari 3 desimal",
+	"quantity must be whole for products sold in pieces":                   "jumlah harus bilangan bulat untuk produk yang dijual per satuan",
+	"a product cannot be an ingredient of itself":                          "produk tidak dapat menjadi bahan bagi dirinya sendiri",
+	"recipe ingredients must be different":                                 "bahan resep tidak boleh sama",
+	"price tier min_quantity must be at least 2":                           "min_quantity tingkat harga minimal 2",
+	"price tier price cannot be negative":                                  "harga tingkat harga tidak boleh negatif",
+	"price tiers for larger quantities cannot cost more":                   "tingkat harga untuk jumlah lebih besar tidak boleh lebih mahal",
+	"price tiers must have different min_quantity":                         "tingkat harga harus memiliki min_quantity yang berbeda",
+	"adjusted price cannot be negative":                                    "harga hasil penyesuaian tidak boleh negatif",
+	"invalid price adjustment mode":                                        "mode penyesuaian harga tidak valid",
+	"price schedule name cannot be empty":                                  "nama jadwal harga wajib diisi",
+	"scheduled price cannot be negative":                                   "harga terjadwal tidak boleh negatif",
+	"starts_at is required":                                                "starts_at wajib diisi",
+	"ends_at must be after starts_at":                                      "ends_at harus setelah starts_at",
+
+	// Transactions, tables and orders
+	"transaction not found": "transaksi tidak ditemukan",
+	"cart is empty":         "keranjang kosong",
+	"transaction expired":   "transaksi sudah kedaluwarsa",
+	"order not found":       "pesanan tidak ditemukan",
+	"order item not found":  "item pesanan tidak ditemukan",
+	"transaction is on hold, resume it before checkout":   "transaksi sedang ditahan, lanjutkan sebelum checkout",
+	"discount is above the highest allowed for your role": "diskon melebihi batas tertinggi untuk peran Anda",
+	"table not found":                                             "meja tidak ditemukan",
+	"a table with this name already exists":                       "meja dengan nama ini sudah ada",
+	"table already has an open order":                             "meja sudah memiliki pesanan yang terbuka",
+	"table is not in service":                                     "meja tidak sedang digunakan",
+	"table name is required":                                      "nama meja wajib diisi",
+	"only pending transactions can be cancelled":                  "hanya transaksi yang tertunda yang dapat dibatalkan",
+	"only pending transactions can be held":                       "hanya transaksi yang tertunda yang dapat ditahan",
+	"only pending transactions can be marked as expired":          "hanya transaksi yang tertunda yang dapat ditandai kedaluwarsa",
+	"only pending transactions can be marked as paid":             "hanya transaksi yang tertunda yang dapat ditandai lunas",
+	"only paid transactions can be refunded":                      "hanya transaksi yang sudah dibayar yang dapat dikembalikan dananya",
+	"only paid orders can be fulfilled":                           "hanya pesanan yang sudah dibayar yang dapat diproses",
+	"transaction is already on hold":                              "transaksi sudah ditahan",
+	"transaction is not on hold":                                  "transaksi tidak sedang ditahan",
+	"invalid fulfillment status":                                  "status pesanan tidak valid",
+	"invalid preparation status":                                  "status persiapan tidak valid",
+	"discount amount must be above 0":                             "jumlah diskon harus lebih dari 0",
+	"discount cannot be negative":                                 "diskon tidak boleh negatif",
+	"discount cannot exceed subtotal":                             "diskon tidak boleh melebihi subtotal",
+	"discount percentage must be above 0 and at most 100":         "persentase diskon harus lebih dari 0 dan paling banyak 100",
+	"discount type must be percentage or nominal":                 "jenis diskon harus percentage atau nominal",
+	"exchange credit can only be applied to pending transactions": "kredit penukaran hanya dapat diterapkan pada transaksi yang tertunda",
+	"exchange credit cannot be negative":                          "kredit penukaran tidak boleh negatif",
+	"exchange credit cannot exceed the transaction total":         "kredit penukaran tidak boleh melebihi total transaksi",
+	"refund amount exceeds the remaining paid amount":             "jumlah pengembalian dana melebihi sisa jumlah yang dibayar",
+	"refund amount must be positive":                              "jumlah pengembalian dana harus positif",
+	"a reason is required":                                        "alasan wajib diisi",
+	"service charge rate must be between 0 and 100":               "tarif biaya layanan harus antara 0 dan 100",
+	"rounding increment cannot be negative":                       "kelipatan pembulatan tidak boleh negatif",
+	"rounding mode must be nearest, up or down":                   "mode pembulatan harus nearest, up atau down",
+
+	// Shifts and cash
+	"shift not found": "shift tidak ditemukan",
+	"no open shift, open a shift before selling":                           "tidak ada shift yang terbuka, buka shift sebelum berjualan",
+	"a shift is already open for this user":                                "pengguna ini sudah memiliki shift yang terbuka",
+	"shift is not awaiting variance approval":                              "shift tidak sedang menunggu persetujuan selisih",
+	"shift is already closed":                                              "shift sudah ditutup",
+	"You can only view your own shifts":                                    "Anda hanya dapat melihat shift Anda sendiri",
+	"cash can only be moved during an open shift":                          "kas hanya dapat dipindahkan selama shift terbuka",
+	"amount is more than the cash the drawer should hold":                  "jumlah melebihi kas yang seharusnya ada di laci",
+	"counted cash cannot be negative":                                      "kas yang dihitung tidak boleh negatif",
+	"opening float cannot be negative":                                     "modal awal tidak boleh negatif",
+	"opening the drawer moves no cash, record a pay-in or pay-out instead": "membuka laci tidak memindahkan kas, catat kas masuk atau kas keluar",
+	"unknown cash movement type":                                           "jenis pergerakan kas tidak dikenal",
+
+	// Payments
+	"payment failed":                         "pembayaran gagal",
+	"payment expired":                        "pembayaran sudah kedaluwarsa",
+	"QRIS code expired":                      "kode QRIS sudah kedaluwarsa",
+	"payment not found":                      "pembayaran tidak ditemukan",
+	"Payment not found":                      "Pembayaran tidak ditemukan",
+	"amount out of range for payment method": "jumlah di luar batas metode pembayaran",
+	"payment exception not found":            "pengecualian pembayaran tidak ditemukan",
+	"payment notification not found":         "notifikasi pembayaran tidak ditemukan",
+	"payment notification was rejected by the gateway checks and cannot be replayed": "notifikasi pembayaran ditolak oleh pemeriksaan gateway dan tidak dapat diputar ulang",
+	"transaction already paid":                                                        "transaksi sudah dibayar",
+	"payment is not a locally generated QRIS":                                         "pembayaran bukan QRIS yang dibuat secara lokal",
+	"payment method does not accept the currency of the transaction":                  "metode pembayaran tidak menerima mata uang transaksi",
+	"e-wallet payments are not supported by the payment gateway":                      "pembayaran e-wallet tidak didukung oleh payment gateway",
+	"payment gateway is unavailable":                                                  "payment gateway tidak tersedia",
+	"Payment gateway is unavailable, try again shortly or use another payment method": "Payment gateway tidak tersedia, coba lagi sebentar lagi atau gunakan metode pembayaran lain",
+	"transaction already has a pending payment":                                       "transaksi sudah memiliki pembayaran yang tertunda",
+	"payment status cannot change that way":                                           "status pembayaran tidak dapat berubah seperti itu",
+	"QRIS generation queue is full, try again shortly":                                "antrean pembuatan QRIS penuh, coba lagi sebentar lagi",
+	"QRIS generation job not found":                                                   "tugas pembuatan QRIS tidak ditemukan",
+	"amount is more than the balance due on the transaction":                          "jumlah melebihi sisa tagihan transaksi",
+	"amount must be greater than zero":                                                "jumlah harus lebih dari nol",
+	"amount tendered is less than the amount due":                                     "uang yang diterima kurang dari jumlah tagihan",
+	"card payment requires an approval reference":                                     "pembayaran kartu memerlukan kode persetujuan",
+	"invalid manual payment method":                                                   "metode pembayaran manual tidak valid",
+	"payment exception is already resolved":                                           "pengecualian pembayaran sudah diselesaikan",
+	"invalid resolution status":                                                       "status penyelesaian tidak valid",
+	"Receipt not found":                                                               "Struk tidak ditemukan",
+	"Receipt link has expired":                                                        "Tautan struk sudah kedaluwarsa",
+
+	// Promotions and taxes
+	"promotion not found":                                            "promosi tidak ditemukan",
+	"promo code is invalid or no longer active":                      "kode promo tidak valid atau sudah tidak aktif",
+	"promo code has reached its usage limit":                         "kode promo sudah mencapai batas penggunaan",
+	"promo code does not apply to this transaction":                  "kode promo tidak berlaku untuk transaksi ini",
+	"promo code is already used by another promotion":                "kode promo sudah digunakan oleh promosi lain",
+	"promotion name cannot be empty":                                 "nama promosi wajib diisi",
+	"invalid promotion type":                                         "jenis promosi tidak valid",
+	"percentage promotion requires a percentage":                     "promosi percentage memerlukan persentase",
+	"fixed_amount promotion requires a positive amount":              "promosi fixed_amount memerlukan jumlah yang positif",
+	"category_percent promotion requires a category":                 "promosi category_percent memerlukan kategori",
+	"category_percent promotion requires a percentage":               "promosi category_percent memerlukan persentase",
+	"buy_x_get_y promotion requires a product or category":           "promosi buy_x_get_y memerlukan produk atau kategori",
+	"buy_x_get_y promotion requires positive buy and get quantities": "promosi buy_x_get_y memerlukan jumlah beli dan gratis yang positif",
+	"happy_hour promotion requires a percentage":                     "promosi happy_hour memerlukan persentase",
+	"happy_hour promotion requires start and end time":               "promosi happy_hour memerlukan waktu mulai dan selesai",
+	"start_time must use HH:MM format":                               "start_time harus menggunakan format HH:MM",
+	"end_time must use HH:MM format":                                 "end_time harus menggunakan format HH:MM",
+	"days_of_week must list days from 0 (Sunday) to 6":               "days_of_week harus berisi hari dari 0 (Minggu) sampai 6",
+	"usage_limit requires a promo code":                              "usage_limit memerlukan kode promo",
+	"tax rule not found":                                             "aturan pajak tidak ditemukan",
+	"tax rule name is required":                                      "nama aturan pajak wajib diisi",
+	"tax rate must be above 0 and at most 100 percent":               "tarif pajak harus lebih dari 0 dan paling banyak 100 persen",
+
+	// Customers and loyalty
+	"customer not found": "pelanggan tidak ditemukan",
+	"a customer with this phone number or email already exists":   "pelanggan dengan nomor telepon atau email ini sudah ada",
+	"customer does not have enough points":                        "poin pelanggan tidak mencukupi",
+	"points cannot be redeemed on a training sale":                "poin tidak dapat ditukarkan pada penjualan latihan",
+	"customer name is required":                                   "nama pelanggan wajib diisi",
+	"a phone number or email is required to look the customer up": "nomor telepon atau email diperlukan untuk mencari pelanggan",
+	"attach a customer before redeeming points":                   "tambahkan pelanggan sebelum menukarkan poin",
+	"points can only be redeemed on pending transactions":         "poin hanya dapat ditukarkan pada transaksi yang tertunda",
+	"points redemption is disabled":                               "penukaran poin dinonaktifkan",
+	"redeemed points cannot be negative":                          "poin yang ditukarkan tidak boleh negatif",
+	"redeemed points cannot exceed the transaction total":         "poin yang ditukarkan tidak boleh melebihi total transaksi",
+
+	// Stores
+	"store not found":                            "toko tidak ditemukan",
+	"a store with this code already exists":      "toko dengan kode ini sudah ada",
+	"no store selected, pick a store to work in": "belum ada toko yang dipilih, pilih toko untuk bekerja",
+	"user cannot work in this store":             "pengguna tidak dapat bekerja di toko ini",
+	"store code is required":                     "kode toko wajib diisi",
+	"store name is required":                     "nama toko wajib diisi",
+
+	// Merchant profile
+	"merchant is not onboarded yet":                                                "merchant belum terdaftar",
+	"tenant not found":                                                             "tenant tidak ditemukan",
+	"a tenant with this slug already exists":                                       "tenant dengan slug ini sudah ada",
+	"tenant is suspended":                                                          "tenant sedang ditangguhkan",
+	"tenants are managed from the platform, not from inside a tenant":              "tenant dikelola dari platform, bukan dari dalam tenant",
+	"merchant is already onboarded":                                                "merchant sudah terdaftar",
+	"merchant name is required":                                                    "nama merchant wajib diisi",
+	"midtrans environment must be sandbox or production":                           "environment Midtrans harus sandbox atau production",
+	"only Midtrans accounts can be set per store":                                  "hanya akun Midtrans yang dapat diatur per toko",
+	"server key is required":                                                       "server key wajib diisi",
+	"no encryption key is configured, set SECRETS_ENCRYPTION_KEY to store secrets": "kunci enkripsi belum dikonfigurasi, atur SECRETS_ENCRYPTION_KEY untuk menyimpan rahasia",
+
+	// Voids and approvals
+	"void request not found":                                          "permintaan pembatalan tidak ditemukan",
+	"void request is already decided":                                 "permintaan pembatalan sudah diputuskan",
+	"a void is already awaiting approval for this transaction":        "pembatalan untuk transaksi ini sudah menunggu persetujuan",
+	"manager approval is required, enter the credentials of an admin": "persetujuan manajer diperlukan, masukkan kredensial admin",
+	"invalid void decision":                                           "keputusan pembatalan tidak valid",
+
+	// Exports, alerts, webhooks and accounting
+	"export not found":                       "ekspor tidak ditemukan",
+	"export is already being built":          "ekspor sedang dibuat",
+	"alert not found":                        "peringatan tidak ditemukan",
+	"alert is already acknowledged":          "peringatan sudah dikonfirmasi",
+	"webhook endpoint not found":             "endpoint webhook tidak ditemukan",
+	"webhook delivery not found":             "pengiriman webhook tidak ditemukan",
+	"accounting connector is not configured": "konektor akuntansi belum dikonfigurasi",
+
+	// Report schedules
+	"report schedule not found":                                      "jadwal laporan tidak ditemukan",
+	"email is not configured, set the SMTP settings to send reports": "email belum dikonfigurasi, atur pengaturan SMTP untuk mengirim laporan",
+	"report schedule name cannot be empty":                           "nama jadwal laporan wajib diisi",
+	"a report can be sent to at most 20 recipients":                  "laporan dapat dikirim ke paling banyak 20 penerima",
+	"at least one recipient is required":                             "minimal satu penerima wajib diisi",
+	"format must be pdf or csv":                                      "format harus pdf atau csv",
+	"frequency must be daily or weekly":                              "frekuensi harus daily atau weekly",
+	"hour must be from 0 to 23":                                      "jam harus dari 0 sampai 23",
+	"weekday must be from 0 (Sunday) to 6":                           "hari harus dari 0 (Minggu) sampai 6",
+
+	// Reconciliation
+	"reconciliation run not found":                  "proses rekonsiliasi tidak ditemukan",
+	"reconciliation run is already being processed": "proses rekonsiliasi sedang diproses",
+	"invalid settlement report":                     "laporan settlement tidak valid",
+
+	// Printing
+	"printer not found":                              "printer tidak ditemukan",
+	"printer is not in service":                      "printer tidak sedang digunakan",
+	"invalid printer token":                          "token printer tidak valid",
+	"print job not found":                            "tugas cetak tidak ditemukan",
+	"print job is not being printed by this printer": "tugas cetak tidak sedang dicetak oleh printer ini",
+	"print job is still queued or printing":          "tugas cetak masih dalam antrean atau sedang dicetak",
+}
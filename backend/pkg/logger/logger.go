@@ -34,7 +34,8 @@ func NewLogger(level string) Logger {
 	}
 
 	opts := &slog.HandlerOptions{
-		Level: logLevel,
+		Level:       logLevel,
+		ReplaceAttr: RedactAttr, // Keep secrets and customer data out of the logs
 	}
 
 	handler := slog.NewJSONHandler(os.Stdout, opts)
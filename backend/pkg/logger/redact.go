@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// secretKeys are never written to the log, whatever their value
+var secretKeys = map[string]bool{
+	"password":          true,
+	"new_password":      true,
+	"old_password":      true,
+	"token":             true,
+	"access_token":      true,
+	"refresh_token":     true,
+	"authorization":     true,
+	"secret":            true,
+	"jwt_secret":        true,
+	"server_key":        true,
+	"client_key":        true,
+	"api_key":           true,
+	"signature_key":     true,
+	"card_number":       true,
+	"cvv":               true,
+	"raw_response":      true,
+	"external_response": true,
+}
+
+// Personal data keys are masked so log lines stay correlatable without exposing the customer
+var (
+	emailKeys = map[string]bool{"email": true, "user_email": true, "customer_email": true}
+	phoneKeys = map[string]bool{"phone": true, "customer_phone": true, "whatsapp": true}
+	nameKeys  = map[string]bool{"customer_name": true, "user_name": true, "cashier_name": true}
+)
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// RedactAttr sanitizes a log attribute, it is installed as the slog ReplaceAttr hook
+func RedactAttr(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+
+	switch {
+	case isSecretKey(key):
+		return slog.String(a.Key, redacted)
+	case emailKeys[key]:
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	case phoneKeys[key]:
+		return slog.String(a.Key, MaskPhone(a.Value.String()))
+	case nameKeys[key]:
+		return slog.String(a.Key, MaskName(a.Value.String()))
+	}
+
+	// Free text such as error messages may still carry an email address
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, maskEmbeddedEmails(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, maskEmbeddedEmails(err.Error()))
+		}
+	}
+
+	return a
+}
+
+func isSecretKey(key string) bool {
+	if secretKeys[key] {
+		return true
+	}
+	return strings.HasSuffix(key, "_token") || strings.HasSuffix(key, "_secret") || strings.HasSuffix(key, "_password")
+}
+
+// MaskEmail keeps the first character of the local part and the domain, e.g. "j***@mail.com"
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// MaskPhone keeps only the last four digits, e.g. "********7890"
+func MaskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return strings.Repeat("*", len(phone))
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
+
+// MaskName keeps the initial of every word, e.g. "J*** D***"
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		words[i] = fmt.Sprintf("%c***", []rune(word)[0])
+	}
+	return strings.Join(words, " ")
+}
+
+func maskEmbeddedEmails(text string) string {
+	if !strings.Contains(text, "@") {
+		return text
+	}
+	return emailPattern.ReplaceAllStringFunc(text, MaskEmail)
+}
@@ -3,6 +3,8 @@ package response
 import (
 	"net/http"
 
+	"qris-pos-backend/pkg/i18n"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -29,47 +31,90 @@ func Created(c *gin.Context, message string, data any) {
 	})
 }
 
-func BadRequest(c *gin.Context, message string, err any) {
-	c.JSON(http.StatusBadRequest, Response{
-		Success: false,
+func Accepted(c *gin.Context, message string, data any) {
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
 		Message: message,
-		Error:   err,
+		Data:    data,
 	})
 }
 
+func BadRequest(c *gin.Context, message string, err any) {
+	fail(c, http.StatusBadRequest, message, err, nil)
+}
+
 func Unauthorized(c *gin.Context, message string) {
-	c.JSON(http.StatusUnauthorized, Response{
-		Success: false,
-		Message: message,
-	})
+	fail(c, http.StatusUnauthorized, message, nil, nil)
 }
 
 func Forbidden(c *gin.Context, message string) {
-	c.JSON(http.StatusForbidden, Response{
-		Success: false,
-		Message: message,
-	})
+	fail(c, http.StatusForbidden, message, nil, nil)
 }
 
 func NotFound(c *gin.Context, message string) {
-	c.JSON(http.StatusNotFound, Response{
-		Success: false,
-		Message: message,
-	})
+	fail(c, http.StatusNotFound, message, nil, nil)
 }
 
 func InternalError(c *gin.Context, message string, err any) {
-	c.JSON(http.StatusInternalServerError, Response{
-		Success: false,
-		Message: message,
-		Error:   err,
-	})
+	fail(c, http.StatusInternalServerError, message, err, nil)
 }
 
 func ValidationError(c *gin.Context, err any) {
-	c.JSON(http.StatusBadRequest, Response{
+	fail(c, http.StatusBadRequest, "Validation failed", err, nil)
+}
+
+func UnprocessableEntity(c *gin.Context, message string, err any) {
+	fail(c, http.StatusUnprocessableEntity, message, err, nil)
+}
+
+func Conflict(c *gin.Context, message string) {
+	fail(c, http.StatusConflict, message, nil, nil)
+}
+
+// ConflictWithData reports a conflict along with the resource it conflicts with, so the client can carry on with it
+func ConflictWithData(c *gin.Context, message string, data any) {
+	fail(c, http.StatusConflict, message, nil, data)
+}
+
+func Gone(c *gin.Context, message string) {
+	fail(c, http.StatusGone, message, nil, nil)
+}
+
+func TooManyRequests(c *gin.Context, message string) {
+	fail(c, http.StatusTooManyRequests, message, nil, nil)
+}
+
+func ServiceUnavailable(c *gin.Context, message string) {
+	fail(c, http.StatusServiceUnavailable, message, nil, nil)
+}
+
+func GatewayTimeout(c *gin.Context, message string) {
+	fail(c, http.StatusGatewayTimeout, message, nil, nil)
+}
+
+// Localizable is an error detail whose messages can be written in the language of the client
+type Localizable interface {
+	Localize(lang i18n.Language) any
+}
+
+// fail writes an error response in the language the client asks for with Accept-Language
+func fail(c *gin.Context, status int, message string, err any, data any) {
+	lang := language(c)
+	if detail, ok := err.(Localizable); ok {
+		err = detail.Localize(lang)
+	}
+	c.JSON(status, Response{
 		Success: false,
-		Message: "Validation failed",
+		Message: i18n.Translate(lang, message),
+		Data:    data,
 		Error:   err,
 	})
-}
\ No newline at end of file
+}
+
+// language negotiates the language of the response, caches keep one response per Accept-Language
+func language(c *gin.Context) i18n.Language {
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", string(lang))
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return lang
+}
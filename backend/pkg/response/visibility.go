@@ -0,0 +1,176 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// VisibleTag restricts a response field to some roles, e.g. `visible:"admin"` or `visible:"admin,manager"`
+const VisibleTag = "visible"
+
+var (
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	restrictedType sync.Map // reflect.Type -> bool, whether values of the type hold restricted fields
+)
+
+// ForRole shapes data for the role of the caller: fields tagged with VisibleTag are left out of the JSON
+// unless the role is listed, anonymous callers have an empty role. Data without restricted fields is
+// returned as is.
+func ForRole(data any, role string) any {
+	if data == nil || !isRestricted(reflect.TypeOf(data)) {
+		return data
+	}
+	return shaped{data: data, role: role}
+}
+
+func isRestricted(t reflect.Type) bool {
+	if cached, ok := restrictedType.Load(t); ok {
+		return cached.(bool)
+	}
+	restricted := hasRestrictedFields(t, map[reflect.Type]bool{})
+	restrictedType.Store(t, restricted)
+	return restricted
+}
+
+type shaped struct {
+	data any
+	role string
+}
+
+func (s shaped) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal(s.data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on the generic JSON so omitempty and custom marshalers behave as usual
+	var tree any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	redact(reflect.TypeOf(s.data), tree, s.role)
+	return json.Marshal(tree)
+}
+
+// redact removes the keys of node the role may not see, t is the Go type node was encoded from
+func redact(t reflect.Type, node any, role string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := node.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, field := range jsonFields(t) {
+			if field.embedded {
+				redact(field.typ, object, role)
+				continue
+			}
+			value, ok := object[field.name]
+			if !ok {
+				continue
+			}
+			if field.roles != nil && !field.roles[role] {
+				delete(object, field.name)
+				continue
+			}
+			redact(field.typ, value, role)
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := node.([]any); ok {
+			for _, item := range items {
+				redact(t.Elem(), item, role)
+			}
+		}
+	case reflect.Map:
+		if object, ok := node.(map[string]any); ok {
+			for _, value := range object {
+				redact(t.Elem(), value, role)
+			}
+		}
+	}
+}
+
+func hasRestrictedFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Recursive types such as a user and their transactions are checked once
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+			return false
+		}
+		for _, field := range jsonFields(t) {
+			if field.roles != nil || hasRestrictedFields(field.typ, seen) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return hasRestrictedFields(t.Elem(), seen)
+	}
+	return false
+}
+
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	embedded bool            // Anonymous struct whose fields are encoded inline
+	roles    map[string]bool // Roles allowed to see the field, nil when everyone may
+}
+
+// jsonFields lists the fields of a struct the way encoding/json names them
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		field := jsonField{name: name, typ: f.Type}
+		if name == "" {
+			inner := f.Type
+			if inner.Kind() == reflect.Ptr {
+				inner = inner.Elem()
+			}
+			if f.Anonymous && inner.Kind() == reflect.Struct {
+				field.embedded = true
+			} else {
+				field.name = f.Name
+			}
+		}
+
+		if visible, ok := f.Tag.Lookup(VisibleTag); ok {
+			field.roles = map[string]bool{}
+			for _, role := range strings.Split(visible, ",") {
+				field.roles[strings.TrimSpace(role)] = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,50 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MaxIDRAmount is the largest amount in rupiah a payment can be made for, the 13 digits the amount of
+// a QRIS holds
+const MaxIDRAmount int64 = 9_999_999_999_999
+
+var (
+	// Mobile numbers with the country code, with or without the plus, or the trunk prefix 0
+	indonesianPhonePattern = regexp.MustCompile(`^(\+62|62|0)8[1-9][0-9]{7,10}$`)
+	digitsPattern          = regexp.MustCompile(`^[0-9]+$`)
+
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "")
+	npwpSeparators  = strings.NewReplacer(".", "", "-", "", " ", "")
+)
+
+// registerIndonesianRules adds the checks of Indonesian formats:
+//   - phone_id: an Indonesian mobile number, +62 or 08 followed by the subscriber number, spaces and
+//     dashes allowed
+//   - npwp: a tax ID of 15 digits, like 01.234.567.8-901.000, or the 16 digits of the NIK based NPWP
+//   - idr_amount: an amount in whole rupiah from 0 to MaxIDRAmount
+func registerIndonesianRules(v *validator.Validate) {
+	v.RegisterValidation("phone_id", func(fl validator.FieldLevel) bool {
+		return IsIndonesianPhone(fl.Field().String())
+	})
+	v.RegisterValidation("npwp", func(fl validator.FieldLevel) bool {
+		return IsNPWP(fl.Field().String())
+	})
+	v.RegisterValidation("idr_amount", func(fl validator.FieldLevel) bool {
+		amount := fl.Field().Int()
+		return amount >= 0 && amount <= MaxIDRAmount
+	})
+}
+
+// IsIndonesianPhone reports whether a phone number is an Indonesian mobile number
+func IsIndonesianPhone(phone string) bool {
+	return indonesianPhonePattern.MatchString(phoneSeparators.Replace(strings.TrimSpace(phone)))
+}
+
+// IsNPWP reports whether a tax ID has the 15 or 16 digits of an NPWP, dots and dashes aside
+func IsNPWP(npwp string) bool {
+	digits := npwpSeparators.Replace(strings.TrimSpace(npwp))
+	return (len(digits) == 15 || len(digits) == 16) && digitsPattern.MatchString(digits)
+}
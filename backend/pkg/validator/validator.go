@@ -3,42 +3,67 @@ package validator
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
-	"github.com/gin-gonic/gin/binding"
+	"qris-pos-backend/pkg/i18n"
+
 	"github.com/go-playground/validator/v10"
 )
 
-var validate *validator.Validate
+// validate checks the validate tags of requests. It is not the engine gin binds with, which reads
+// binding tags, so binding only parses and ValidateStruct reports what is wrong.
+var validate = validator.New()
 
 func init() {
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		validate = v
-		// Register custom tag name function to use JSON tag names
-		validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
-			name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
-			if name == "-" {
-				return ""
-			}
-			return name
-		})
-	}
+	// Name fields by their JSON tag, or the form tag of query parameters
+	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		tag := fld.Tag.Get("json")
+		if tag == "" {
+			tag = fld.Tag.Get("form")
+		}
+		name := strings.SplitN(tag, ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	registerIndonesianRules(validate)
 }
 
 type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
+
+	format string // English message, the key of its translations
+	args   []any
 }
 
-func ValidateStruct(s interface{}) []ValidationError {
-	var errors []ValidationError
+// Errors are the failed checks of a struct, with messages in English until localized
+type Errors []ValidationError
+
+// Localize returns the errors with their messages in the language
+func (errs Errors) Localize(lang i18n.Language) any {
+	localized := make(Errors, len(errs))
+	for i, err := range errs {
+		localized[i] = err
+		localized[i].Message = fmt.Sprintf(i18n.Translate(lang, err.format), err.args...)
+	}
+	return localized
+}
+
+func ValidateStruct(s interface{}) Errors {
+	var errors Errors
 
 	err := validate.Struct(s)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
+			format, args := getErrorMessage(err)
 			errors = append(errors, ValidationError{
 				Field:   err.Field(),
-				Message: getErrorMessage(err),
+				Message: fmt.Sprintf(format, args...),
+				format:  format,
+				args:    args,
 			})
 		}
 	}
@@ -46,23 +71,36 @@ func ValidateStruct(s interface{}) []ValidationError {
 	return errors
 }
 
-func getErrorMessage(fe validator.FieldError) string {
+// getErrorMessage returns the English message of a failed check as a format and its arguments
+func getErrorMessage(fe validator.FieldError) (string, []any) {
 	switch fe.Tag() {
 	case "required":
-		return fmt.Sprintf("%s is required", fe.Field())
+		return "%s is required", []any{fe.Field()}
 	case "email":
-		return "Invalid email format"
+		return "Invalid email format", nil
 	case "min":
-		return fmt.Sprintf("%s must be at least %s characters long", fe.Field(), fe.Param())
+		return "%s must be at least %s characters long", []any{fe.Field(), fe.Param()}
 	case "max":
-		return fmt.Sprintf("%s must be at most %s characters long", fe.Field(), fe.Param())
+		return "%s must be at most %s characters long", []any{fe.Field(), fe.Param()}
 	case "gte":
-		return fmt.Sprintf("%s must be greater than or equal to %s", fe.Field(), fe.Param())
+		return "%s must be greater than or equal to %s", []any{fe.Field(), fe.Param()}
 	case "lte":
-		return fmt.Sprintf("%s must be less than or equal to %s", fe.Field(), fe.Param())
+		return "%s must be less than or equal to %s", []any{fe.Field(), fe.Param()}
+	case "gt":
+		return "%s must be greater than %s", []any{fe.Field(), fe.Param()}
 	case "oneof":
-		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+		return "%s must be one of: %s", []any{fe.Field(), fe.Param()}
+	case "uuid":
+		return "%s must be a valid UUID", []any{fe.Field()}
+	case "datetime":
+		return "%s must be a date in the format %s", []any{fe.Field(), fe.Param()}
+	case "phone_id":
+		return "%s must be an Indonesian mobile number starting with +62 or 08", []any{fe.Field()}
+	case "npwp":
+		return "%s must be an NPWP of 15 or 16 digits", []any{fe.Field()}
+	case "idr_amount":
+		return "%s must be an amount from 0 to %s rupiah", []any{fe.Field(), strconv.FormatInt(MaxIDRAmount, 10)}
 	default:
-		return fmt.Sprintf("%s is not valid", fe.Field())
+		return "%s is not valid", []any{fe.Field()}
 	}
-}
\ No newline at end of file
+}
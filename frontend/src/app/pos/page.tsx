@@ -29,7 +29,7 @@ export default function POSPage() {
   // Load initial data
   useEffect(() => {
     if (user) {
-      listProducts()
+      listProducts(undefined, 'popular')
       listCategories()
     }
   }, [user, listProducts, listCategories])
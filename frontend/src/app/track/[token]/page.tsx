@@ -0,0 +1,104 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { useParams } from 'next/navigation'
+
+const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api/v1'
+
+type OrderStage = 'awaiting_payment' | 'paid' | 'preparing' | 'ready' | 'completed' | 'cancelled'
+
+interface OrderStatus {
+  order_number: string
+  order_type: 'dine_in' | 'pickup' | 'delivery'
+  stage: OrderStage
+  steps: { stage: OrderStage; done: boolean; at?: string }[]
+  items: { name: string; quantity: number }[]
+  updated_at: string
+}
+
+const stageLabels: Record<OrderStage, string> = {
+  awaiting_payment: 'Menunggu pembayaran',
+  paid: 'Dibayar',
+  preparing: 'Sedang disiapkan',
+  ready: 'Siap',
+  completed: 'Selesai',
+  cancelled: 'Dibatalkan',
+}
+
+export default function OrderTrackingPage() {
+  const { token } = useParams<{ token: string }>()
+  const [order, setOrder] = useState<OrderStatus | null>(null)
+  const [error, setError] = useState('')
+
+  useEffect(() => {
+    // The stream sends the current status first, then every change until the order is done
+    const source = new EventSource(`${API_BASE_URL}/orders/track/${token}/events`)
+    source.addEventListener('status', (event) => {
+      const status: OrderStatus = JSON.parse((event as MessageEvent).data)
+      setOrder(status)
+      setError('')
+      if (status.stage === 'completed' || status.stage === 'cancelled') {
+        source.close()
+      }
+    })
+    source.onerror = () => {
+      if (source.readyState === EventSource.CLOSED) {
+        setError('Pesanan tidak ditemukan')
+      }
+    }
+    return () => source.close()
+  }, [token])
+
+  if (error) {
+    return (
+      <div className="min-h-screen flex items-center justify-center bg-gray-50 px-4">
+        <p className="text-gray-600">{error}</p>
+      </div>
+    )
+  }
+
+  if (!order) {
+    return (
+      <div className="min-h-screen flex items-center justify-center bg-gray-50 px-4">
+        <p className="text-gray-600">Memuat status pesanan...</p>
+      </div>
+    )
+  }
+
+  return (
+    <div className="min-h-screen bg-gray-50 py-12 px-4">
+      <div className="max-w-md mx-auto bg-white rounded-lg shadow p-6 space-y-6">
+        <div className="text-center">
+          <p className="text-sm text-gray-500">Pesanan #{order.order_number}</p>
+          <h1 className="mt-1 text-2xl font-bold text-gray-900">{stageLabels[order.stage]}</h1>
+        </div>
+
+        {order.stage !== 'cancelled' && (
+          <ol className="space-y-3">
+            {order.steps.map((step) => (
+              <li key={step.stage} className="flex items-center justify-between">
+                <span className={step.done ? 'text-green-700 font-medium' : 'text-gray-400'}>
+                  {step.done ? '✓' : '○'} {stageLabels[step.stage]}
+                </span>
+                {step.at && (
+                  <span className="text-sm text-gray-500">
+                    {new Date(step.at).toLocaleTimeString('id-ID', { hour: '2-digit', minute: '2-digit' })}
+                  </span>
+                )}
+              </li>
+            ))}
+          </ol>
+        )}
+
+        <ul className="border-t pt-4 space-y-1 text-sm text-gray-700">
+          {order.items.map((item, index) => (
+            <li key={index} className="flex justify-between">
+              <span>{item.name}</span>
+              <span>x{item.quantity}</span>
+            </li>
+          ))}
+        </ul>
+      </div>
+    </div>
+  )
+}
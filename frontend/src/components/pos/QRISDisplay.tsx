@@ -38,19 +38,17 @@ export function QRISDisplay({ qrCode, onRefresh, size = 256 }: QRISDisplayProps)
   }, [qrCode.qr_code, size])
 
   useEffect(() => {
-    // Calculate time left until expiry
-    const expiryTime = new Date(qrCode.expires_at).getTime()
-    const now = new Date().getTime()
-    const diff = expiryTime - now
+    // Count down from the server's remaining seconds, the device clock may be off
+    const secondsLeft = qrCode.seconds_remaining ?? Math.floor((new Date(qrCode.expires_at).getTime() - Date.now()) / 1000)
 
-    if (diff <= 0) {
+    if (secondsLeft <= 0) {
       setIsExpired(true)
       setTimeLeft(0)
     } else {
       setIsExpired(false)
-      setTimeLeft(Math.floor(diff / 1000))
+      setTimeLeft(secondsLeft)
     }
-  }, [qrCode.expires_at])
+  }, [qrCode.expires_at, qrCode.seconds_remaining])
 
   useEffect(() => {
     if (isExpired || timeLeft <= 0) return
@@ -57,7 +57,7 @@ export function AddProductModal({ isOpen, onClose }: AddProductModalProps) {
       const productData = {
         name: formData.name.trim(),
         description: formData.description.trim() || undefined,
-        price: parseFloat(formData.price),
+        price: Math.round(parseFloat(formData.price)),
         stock: parseInt(formData.stock),
         category_id: formData.categoryId, // Backend expects category_id not categoryId
         sku: formData.sku.trim() || undefined,
@@ -171,7 +171,7 @@ export function AddProductModal({ isOpen, onClose }: AddProductModalProps) {
                 <input
                   type="number"
                   id="price"
-                  step="0.01"
+                  step="1"
                   min="0"
                   value={formData.price}
                   onChange={(e) => handleChange('price', e.target.value)}
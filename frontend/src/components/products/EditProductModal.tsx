@@ -77,7 +77,7 @@ export function EditProductModal({ isOpen, onClose, product }: EditProductModalP
       const productData = {
         name: formData.name.trim(),
         description: formData.description.trim() || undefined,
-        price: parseFloat(formData.price),
+        price: Math.round(parseFloat(formData.price)),
         stock: parseInt(formData.stock),
         category_id: formData.categoryId,
         sku: formData.sku.trim() || undefined,
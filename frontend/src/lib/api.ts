@@ -3,12 +3,17 @@ const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/a
 class ApiClient {
   private baseURL: string
   private token: string | null = null
+  private refreshToken: string | null = null
+  private refreshing: Promise<boolean> | null = null
+  private storeId: string | null = null
 
   constructor(baseURL: string) {
     this.baseURL = baseURL
     // Get token from localStorage if available
     if (typeof window !== 'undefined') {
       this.token = localStorage.getItem('auth_token')
+      this.refreshToken = localStorage.getItem('refresh_token')
+      this.storeId = localStorage.getItem('store_id')
     }
   }
 
@@ -19,17 +24,66 @@ class ApiClient {
     }
   }
 
+  setRefreshToken(refreshToken: string) {
+    this.refreshToken = refreshToken
+    if (typeof window !== 'undefined') {
+      localStorage.setItem('refresh_token', refreshToken)
+    }
+  }
+
   removeToken() {
     this.token = null
+    this.refreshToken = null
     if (typeof window !== 'undefined') {
       localStorage.removeItem('auth_token')
+      localStorage.removeItem('refresh_token')
+    }
+  }
+
+  // Trades the refresh token for a new pair. Concurrent 401s share one refresh since each token is single use.
+  private async refreshSession(): Promise<boolean> {
+    if (!this.refreshToken) return false
+    if (!this.refreshing) {
+      this.refreshing = fetch(`${this.baseURL}/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: this.refreshToken }),
+      })
+        .then(async (response) => {
+          if (!response.ok) {
+            this.removeToken()
+            return false
+          }
+          const { data } = await response.json()
+          this.setToken(data.token)
+          this.setRefreshToken(data.refresh_token)
+          return true
+        })
+        .catch(() => false)
+        .finally(() => {
+          this.refreshing = null
+        })
+    }
+    return this.refreshing
+  }
+
+  // Store an admin works in; cashiers always work in their own store. Null works across every store.
+  setStoreId(storeId: string | null) {
+    this.storeId = storeId
+    if (typeof window !== 'undefined') {
+      if (storeId) {
+        localStorage.setItem('store_id', storeId)
+      } else {
+        localStorage.removeItem('store_id')
+      }
     }
   }
 
   private async request<T>(
     endpoint: string,
     options: RequestInit = {},
-    skipJsonContentType: boolean = false
+    skipJsonContentType: boolean = false,
+    retried: boolean = false
   ): Promise<T> {
     const url = `${this.baseURL}${endpoint}`
     
@@ -49,21 +103,33 @@ class ApiClient {
     if (this.token) {
       headers.Authorization = `Bearer ${this.token}`
     }
+    if (this.storeId) {
+      headers['X-Store-ID'] = this.storeId
+    }
 
     const config: RequestInit = {
       ...options,
       // Only set headers if we have any, otherwise omit entirely for FormData
       ...(skipJsonContentType ? 
-        (this.token ? { headers: { Authorization: `Bearer ${this.token}` } } : {}) : 
+        (this.token ? { headers: { Authorization: `Bearer ${this.token}`, ...(this.storeId ? { 'X-Store-ID': this.storeId } : {}) } } : {}) : 
         { headers }
       ),
     }
 
     const response = await fetch(url, config)
+
+    // The access token expired, retry once with a refreshed one
+    if (response.status === 401 && !retried && endpoint !== '/auth/login' && await this.refreshSession()) {
+      return this.request<T>(endpoint, options, skipJsonContentType, true)
+    }
     
     if (!response.ok) {
       const errorData = await response.json().catch(() => ({}))
-      throw new Error(errorData.message || `HTTP ${response.status}`)
+      // Keep the status and data, some errors carry the resource to continue with (e.g. 409 on an existing payment)
+      throw Object.assign(new Error(errorData.message || `HTTP ${response.status}`), {
+        status: response.status,
+        data: errorData.data,
+      })
     }
 
     return response.json()
@@ -79,13 +145,22 @@ class ApiClient {
     if (response.data?.token) {
       this.setToken(response.data.token)
     }
+    if (response.data?.refresh_token) {
+      this.setRefreshToken(response.data.refresh_token)
+    }
     
     return response
   }
 
   async logout() {
-    await this.request('/auth/logout', { method: 'POST' })
-    this.removeToken()
+    try {
+      await this.request('/auth/logout', {
+        method: 'POST',
+        body: JSON.stringify({ refresh_token: this.refreshToken }),
+      })
+    } finally {
+      this.removeToken()
+    }
   }
 
   async getCurrentUser() {
@@ -104,6 +179,37 @@ class ApiClient {
     })
   }
 
+  async getSessions() {
+    return this.request<any>('/auth/sessions')
+  }
+
+  async revokeSession(id: string) {
+    return this.request<any>(`/auth/sessions/${id}`, {
+      method: 'DELETE',
+    })
+  }
+
+  async forgotPassword(email: string) {
+    return this.request<any>('/auth/forgot-password', {
+      method: 'POST',
+      body: JSON.stringify({ email }),
+    })
+  }
+
+  async verifyResetCode(email: string, code: string) {
+    return this.request<any>('/auth/verify-reset-code', {
+      method: 'POST',
+      body: JSON.stringify({ email, code }),
+    })
+  }
+
+  async resetPassword(resetToken: string, newPassword: string) {
+    return this.request<any>('/auth/reset-password', {
+      method: 'POST',
+      body: JSON.stringify({ reset_token: resetToken, new_password: newPassword }),
+    })
+  }
+
   // Product endpoints
   async getProducts(params?: {
     category_id?: string
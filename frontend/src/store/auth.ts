@@ -44,7 +44,8 @@ export const useAuthStore = create<AuthState>()(
       },
 
       logout: () => {
-        api.removeToken()
+        // Revoke the session on the server, the local state is cleared either way
+        api.logout().catch(() => api.removeToken())
         set({ 
           user: null, 
           token: null, 
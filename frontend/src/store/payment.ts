@@ -39,6 +39,11 @@ export const usePaymentStore = create<PaymentState>((set) => ({
 
       return payment
     } catch (error: any) {
+      // The transaction already has a pending QRIS, show that one
+      if (error.status === 409 && error.data) {
+        set({ currentPayment: error.data, loading: false })
+        return error.data
+      }
       const errorMessage = error.message || 'Failed to generate QRIS'
       set({ error: errorMessage, loading: false })
       return null
@@ -2,6 +2,9 @@ import { create } from 'zustand'
 import { Product, Category } from '@/types'
 import { api } from '@/lib/api'
 
+// popular lists the best sellers of recent weeks first
+export type ProductSort = 'newest' | 'popular'
+
 interface ProductState {
   products: Product[]
   categories: Category[]
@@ -9,7 +12,7 @@ interface ProductState {
   error: string | null
   
   // Actions
-  listProducts: (categoryId?: string) => Promise<void>
+  listProducts: (categoryId?: string, sort?: ProductSort) => Promise<void>
   listCategories: () => Promise<void>
   getProduct: (id: string) => Promise<Product | null>
   createProduct: (productData: Partial<Product>) => Promise<Product | null>
@@ -25,11 +28,14 @@ export const useProductStore = create<ProductState>((set, get) => ({
   loading: false,
   error: null,
 
-  listProducts: async (categoryId?: string) => {
+  listProducts: async (categoryId?: string, sort?: ProductSort) => {
     set({ loading: true, error: null })
     
     try {
-      const params = categoryId ? `?category_id=${categoryId}` : ''
+      const query = new URLSearchParams()
+      if (categoryId) query.set('category_id', categoryId)
+      if (sort) query.set('sort', sort)
+      const params = query.toString() ? `?${query}` : ''
       const response = await api.get(`/products${params}`)
       const products = response.data || []
       
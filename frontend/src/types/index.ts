@@ -3,7 +3,9 @@ export interface User {
   name: string
   email: string
   role: 'admin' | 'cashier'
+  store_id?: string
   is_active: boolean
+  deleted_at?: string  // Only on deleted users, listed with include_deleted
 }
 
 export interface LoginResponse {
@@ -14,22 +16,62 @@ export interface LoginResponse {
 export interface Category {
   id: string
   name: string
+  parent_id?: string  // Top-level category when empty
+  sku_prefix?: string  // Starts the SKUs generated for its products
   is_active: boolean
+  deleted_at?: string  // Only on deleted categories, listed with include_deleted
 }
 
+export interface CategoryNode extends Category {
+  default_markup: number
+  children: CategoryNode[]
+}
+
+// Unit a product is stocked and sold in, kg and liter sell in fractions like 0.5
+export type UnitOfMeasure = 'pcs' | 'kg' | 'liter'
+
 export interface Product {
   id: string
   name: string
   description?: string
   price: number
-  stock: number
+  cost_price?: number  // Admins only
+  margin?: number      // Admins only
+  stock: number        // In the product's unit
+  unit: UnitOfMeasure
   category_id: string
   sku?: string
   image_url?: string
   is_active: boolean
+  price_schedule_id?: string  // Set while a price schedule is in effect
+  price_tiers?: PriceTier[]   // Quantity breaks, lowest quantity first
   created_at: string
   updated_at: string
+  deleted_at?: string  // Only on deleted products, listed with include_deleted
   category?: Category
+  images?: ProductImage[]  // Main picture first, also in image_url
+}
+
+export interface ProductImage {
+  id: string
+  url: string
+  thumbnail_url: string
+  width: number
+  height: number
+  sort_order: number
+}
+
+export interface ProductImportResult {
+  dry_run: boolean     // Nothing was saved, the counts show what would change
+  created: number
+  updated: number
+  unchanged: number
+  errors?: {
+    row: number        // Line in the file, the header is row 1
+    sku?: string
+    name?: string
+    error: string
+  }[]
 }
 
 export interface CartItem {
@@ -39,14 +81,27 @@ export interface CartItem {
 
 export type TransactionStatus = 'pending' | 'paid' | 'cancelled' | 'expired'
 
+export type Currency = 'IDR' | 'SGD' | 'MYR' | 'USD'
+
 export interface Transaction {
   id: string
   user_id: string
+  receipt_no?: string
+  currency: Currency
   total_amount: number
   tax_amount: number
   discount: number
+  manual_discount?: ManualDiscount  // Cart discount given by hand, part of discount
+  service_charge_rate?: number  // Percentage of the discounted subtotal
+  service_charge?: number
+  rounding?: number  // Added to round the total to the cash increment, negative when rounded down
   status: TransactionStatus
   notes?: string
+  is_training?: boolean
+  hold_label?: string
+  held_at?: string     // Set while the cart is parked on hold
+  table_id?: string    // Dine-in table the order is open on
+  table_name?: string
   created_at: string
   updated_at: string
   items: TransactionItem[]
@@ -57,21 +112,116 @@ export interface TransactionItem {
   id: string
   transactionId: string
   productId: string
-  quantity: number
+  quantity: number     // In the unit, up to 3 decimals
+  unit: UnitOfMeasure
   unitPrice: number
   totalPrice: number
+  price_source?: PriceSource
+  price_schedule_id?: string
+  base_unit_price?: number    // Unit price before quantity breaks
+  tier_min_quantity?: number  // Quantity break applied, e.g. 10 for "10+"
+  manual_discount?: ManualDiscount  // Item discount given by hand, part of the transaction discount
   product?: Product
 }
 
+export type DiscountType = 'percentage' | 'nominal'
+
+export interface ManualDiscount {
+  type: DiscountType
+  value: number   // Percentage, or amount off (per unit on items)
+  amount: number  // Discount the value currently comes to
+  reason?: string
+  given_by?: string
+  approved_by?: string  // Admin who signed off a discount above the cashier's limit
+}
+
+export interface ApplyDiscountRequest {
+  type: DiscountType
+  value: number
+  reason: string
+  approver_email?: string  // Needed when a cashier goes past their limit
+  approver_password?: string
+}
+
+export interface PriceTier {
+  min_quantity: number
+  price: number  // Unit price from min_quantity units on
+}
+
+export interface RecipeItem {
+  ingredient_id: string
+  ingredient_name: string
+  quantity: number  // Used per unit of the product sold, in the ingredient's unit
+  unit: UnitOfMeasure
+  cost_price?: number  // Admin only
+  cost?: number
+}
+
+export interface Recipe {
+  product_id: string
+  items: RecipeItem[]
+  cost?: number  // Ingredients of one unit at their current cost price, admin only
+}
+
+export type VoidRequestStatus = 'pending' | 'approved' | 'rejected'
+
+export interface VoidRequest {
+  id: string
+  transaction_id: string
+  requested_by: string
+  reason: string
+  status: VoidRequestStatus
+  decided_by?: string
+  decided_at?: string
+  decision_note?: string
+  refund_id?: string
+  settlement?: 'gateway' | 'counter' | 'manual'  // manual: queued in payment exceptions
+  created_at: string
+}
+
+// Opens the receipt without logging in until it expires, from POST /transactions/:id/share
+export interface ReceiptShareLink {
+  token: string
+  url?: string  // Public invoice page, missing when none is configured
+  expires_at: string
+}
+
+export interface FloorPlanTable {
+  id: string
+  name: string
+  area: string
+  seats: number
+  sort_order: number
+  occupied: boolean
+  order?: {
+    transaction_id: string
+    user_id: string
+    total_amount: number
+    item_count: number
+    opened_at: string
+  }
+}
+
+export interface FloorPlan {
+  tables: FloorPlanTable[]
+  open_tables: number
+  open_total: number   // Sum of the open orders, not yet settled
+}
+
 export type PaymentStatus = 'pending' | 'success' | 'failed' | 'expired' | 'cancelled'
 export type PaymentMethod = 'qris'
 
 export interface Payment {
   id: string
   transaction_id: string
+  store_id?: string
   amount: number
+  amount_due: number        // Balance of the transaction when the payment was taken
+  remaining_amount: number  // Left to pay once it settles, above 0 for a deposit
+  currency: Currency
   method: PaymentMethod
   status: PaymentStatus
+  provider?: string
   external_id?: string
   paid_at?: string
   expires_at: string
@@ -80,6 +230,24 @@ export interface Payment {
   qr_code?: QRISCode
 }
 
+export interface CheckoutRequest {
+  id: string   // Generated by the register, resend the same checkout with it after a timeout
+  items: { product_id: string; quantity: number }[]
+  notes?: string
+  customer_email?: string
+  customer_phone?: string
+  order_type?: 'dine_in' | 'pickup' | 'delivery'
+  customer_id?: string
+  promo_code?: string
+  expiry_minutes?: number
+}
+
+export interface CheckoutResponse {
+  transaction: Transaction
+  payment?: Payment   // QRIS to show, missing once the sale was paid another way
+  replayed: boolean   // Returned by an earlier checkout with the same id
+}
+
 export interface QRISCode {
   id: string
   transaction_id: string
@@ -87,12 +255,346 @@ export interface QRISCode {
   qr_code: string      // QRIS EMVCo string for QR generation
   url?: string         // Midtrans simulator URL for testing
   expires_at: string
+  expires_at_epoch: number   // Expiry in Unix seconds
+  seconds_remaining: number  // Server computed, immune to device clock drift
   created_at: string
 }
 
+export interface OfflineTransaction {
+  id: string           // UUID generated on the terminal, pushing it again is harmless
+  created_at: string
+  items: { product_id: string; quantity: number; unit_price: number }[]
+  discount: number
+  total_amount: number
+  notes?: string
+  customer_email?: string
+  customer_phone?: string
+  order_type?: 'dine_in' | 'pickup' | 'delivery'
+  payment: { method: 'cash' | 'card' | 'other'; amount_tendered: number; reference?: string }
+}
+
+export interface SyncConflict {
+  type: 'price_changed' | 'total_mismatch' | 'insufficient_stock' | 'product_not_found' | 'too_old'
+  product_id?: string
+  client_value: number
+  server_value: number
+  resolution: 'kept_client_price' | 'server_total' | 'stock_depleted' | 'rejected'
+}
+
+export interface SyncPushResult {
+  id: string
+  status: 'accepted' | 'duplicate' | 'rejected' | 'failed'  // Failed ones should be pushed again later
+  total_amount?: number
+  conflicts?: SyncConflict[]
+  error?: string
+}
+
+export interface ProductChange {
+  id: string
+  name: string
+  sku: string
+  barcode: string
+  price: number
+  stock: number
+  unit: UnitOfMeasure
+  category_id: string
+  tax_class: string
+  image_url: string
+  is_active: boolean
+  deleted: boolean     // Drop it from the terminal cache
+  updated_at: string
+}
+
+export interface SyncChanges {
+  products: ProductChange[]
+  next_cursor: string  // Pass to the next pull
+  has_more: boolean
+  server_time: string
+}
+
+export type WebhookEvent = 'payment.settled' | 'transaction.created' | 'stock.low'
+
+export interface WebhookEndpoint {
+  id: string
+  store_id?: string    // Events of every store when empty
+  url: string
+  description: string
+  events: WebhookEvent[]
+  is_active: boolean
+  secret?: string      // Only returned when created or rotated
+  created_by: string
+  created_at: string
+  updated_at: string
+}
+
+export interface WebhookDelivery {
+  id: string
+  endpoint_id: string
+  event: WebhookEvent
+  payload: any
+  status: 'pending' | 'sent' | 'failed'
+  attempts: number
+  next_attempt_at: string
+  response_status: number
+  last_error: string
+  delivered_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface JournalLine {
+  account: string
+  description: string
+  debit: number
+  credit: number
+}
+
+export interface JournalEntry {
+  date: string
+  store_id: string
+  store_code: string
+  reference: string
+  description: string
+  lines: JournalLine[]
+}
+
+export type AccountingConnector = 'accurate' | 'jurnal' | 'xero'
+
+export interface AccountingPushResult {
+  date: string
+  store_id: string
+  store_code: string
+  reference: string
+  status: 'posted' | 'skipped' | 'failed'
+  external_id?: string
+  error?: string
+}
+
+export type ReconciliationResult =
+  | 'matched'
+  | 'amount_mismatch'
+  | 'status_mismatch'
+  | 'missing_local'
+  | 'missing_settlement'
+
+export interface ReconciliationRun {
+  id: string
+  source: 'midtrans' | 'upload'
+  file_name?: string
+  date_from: string
+  date_to: string      // Exclusive
+  status: 'pending' | 'processing' | 'completed' | 'failed'
+  matched: number
+  amount_mismatch: number
+  status_mismatch: number
+  missing_local: number
+  missing_settlement: number
+  settled_amount: number
+  fee_amount: number
+  error?: string
+  created_by: string
+  started_at?: string
+  completed_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface ReconciliationItem {
+  id: string
+  run_id: string
+  order_id: string
+  payment_id?: string
+  transaction_id?: string
+  store_id?: string
+  result: ReconciliationResult
+  local_status?: string
+  local_amount: number
+  settlement_status?: string
+  settled_amount: number
+  fee_amount: number
+  external_id?: string
+  settled_at?: string
+  note?: string
+  created_at: string
+}
+
+export type QRISJobStatus = 'queued' | 'processing' | 'completed' | 'failed'
+
+export interface QRISJob {
+  id: string
+  transaction_id: string
+  status: QRISJobStatus
+  payment?: Payment
+  error?: string
+  error_code?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface QRISImage {
+  format: 'png' | 'svg' | 'data-uri'
+  data_uri?: string
+  expires_at: string
+}
+
+export interface Printer {
+  id: string
+  store_id: string
+  name: string
+  width: 32 | 48
+  is_active: boolean
+  last_seen_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export interface PrinterToken {
+  printer: Printer
+  token: string
+}
+
+export type PrintJobKind = 'receipt' | 'qris'
+
+export type PrintJobStatus = 'queued' | 'printing' | 'printed' | 'failed'
+
+export interface PrintJob {
+  id: string
+  printer_id: string
+  store_id: string
+  kind: PrintJobKind
+  transaction_id: string
+  copies: number
+  status: PrintJobStatus
+  attempts: number
+  last_error: string
+  reprint_of?: string
+  created_by: string
+  claimed_at?: string
+  printed_at?: string
+  created_at: string
+  updated_at: string
+}
+
+export type PriceSource = 'list' | 'schedule' | 'client'  // client: price kept from an offline terminal
+
+export type PriceScheduleStatus = 'pending' | 'in_effect' | 'completed'
+
+export interface PriceSchedule {
+  id: string
+  store_id: string
+  product_id: string
+  name: string
+  price: number
+  days_of_week: string  // Comma separated, 0 = Sunday
+  starts_at: string
+  ends_at?: string
+  status: PriceScheduleStatus
+  list_price: number  // Price restored when the schedule ends
+  is_active: boolean
+  created_by: string
+  created_at: string
+  updated_at: string
+}
+
+export interface PriceChange {
+  id: string
+  product_id: string
+  batch_id: string
+  old_price: number
+  new_price: number
+  source: 'bulk' | 'suggested' | 'schedule'
+  schedule_id?: string
+  changed_by: string
+  reason: string
+  created_at: string
+}
+
+export type ReportFrequency = 'daily' | 'weekly'
+
+export type ReportFormat = 'pdf' | 'csv'
+
+export interface ReportSchedule {
+  id: string
+  store_id?: string  // Sales of every store when empty
+  name: string
+  frequency: ReportFrequency
+  weekday: number  // Day a weekly report is sent, 0 = Sunday
+  hour: number
+  format: ReportFormat
+  recipients: string  // Comma separated
+  is_active: boolean
+  next_run_at: string
+  last_run_at?: string
+  last_error?: string
+  created_by: string
+  created_at: string
+  updated_at: string
+}
+
+// Business settings changed at runtime, pushed to terminals as settings.updated events
+export interface Settings {
+  store_name: string
+  store_logo_url: string
+  receipt_footer: string
+  qris_expiry_minutes: number  // Lifetime of a QRIS when the request doesn't give one
+  updated_at?: string
+}
+
+// Business profile of the merchant, heading receipts and reports once onboarded
+export interface Merchant {
+  id: string
+  name: string
+  legal_name: string
+  address: string
+  city: string
+  postal_code: string
+  phone: string
+  email: string
+  tax_id: string  // NPWP
+  logo_url: string
+  midtrans_client_key: string
+  midtrans_environment: 'sandbox' | 'production'
+  midtrans_configured: boolean  // The server key is write only
+  created_at: string
+  updated_at: string
+}
+
+// Midtrans account a store settles into instead of the shared one, the server key is write only
+export interface GatewayCredential {
+  store_id: string
+  provider: 'midtrans'
+  configured: boolean
+  server_key_hint?: string  // e.g. "...x9Qz"
+  client_key?: string
+  environment?: 'sandbox' | 'production'
+  updated_at?: string
+}
+
 export interface ApiResponse<T> {
   success: boolean
   message: string
   data?: T
   error?: any
-}
\ No newline at end of file
+}
+
+export interface DailyProfit {
+  date: string
+  transactions: number
+  uncosted: number  // Sales not costed yet, counted at 0
+  revenue: number
+  cost_of_goods: number
+  gross_profit: number
+}
+
+export interface ProfitReport {
+  date_from: string
+  date_to: string
+  store_id?: string
+  currency?: string
+  transactions: number
+  uncosted: number
+  revenue: number
+  cost_of_goods: number
+  gross_profit: number
+  days: DailyProfit[]
+}